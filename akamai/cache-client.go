@@ -70,6 +70,9 @@ type errFatal string
 
 func (e errFatal) Error() string { return string(e) }
 
+// Fatal marks errFatal as fatal to the cdn.PurgeQueue.
+func (e errFatal) Fatal() bool { return true }
+
 var (
 	// ErrAllRetriesFailed lets the caller of Purge to know if all the purge submission
 	// attempts failed
@@ -162,6 +162,7 @@ type DNSClientImpl struct {
 	totalLookupTime       *prometheus.HistogramVec
 	cancelCounter         *prometheus.CounterVec
 	usedAllRetriesCounter *prometheus.CounterVec
+	pathologicalCounter   *prometheus.CounterVec
 }
 
 var _ DNSClient = &DNSClientImpl{}
@@ -215,7 +216,14 @@ func NewDNSClientImpl(
 		},
 		[]string{"qtype"},
 	)
-	stats.MustRegister(queryTime, totalLookupTime, cancelCounter, usedAllRetriesCounter)
+	pathologicalCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dns_pathological_responses",
+			Help: "Counter of DNS responses rejected for exceeding hard caps on their contents",
+		},
+		[]string{"qtype", "reason"},
+	)
+	stats.MustRegister(queryTime, totalLookupTime, cancelCounter, usedAllRetriesCounter, pathologicalCounter)
 
 	return &DNSClientImpl{
 		dnsClient:                dnsClient,
@@ -227,6 +235,7 @@ func NewDNSClientImpl(
 		totalLookupTime:          totalLookupTime,
 		cancelCounter:            cancelCounter,
 		usedAllRetriesCounter:    usedAllRetriesCounter,
+		pathologicalCounter:      pathologicalCounter,
 	}
 }
 
@@ -318,6 +327,15 @@ func (dnsClient *DNSClientImpl) exchangeOne(ctx context.Context, hostname string
 				}
 			}
 			resp, err = r.m, r.err
+			if err == nil && resp != nil {
+				if perr := checkResponse(hostname, resp); perr != nil {
+					dnsClient.pathologicalCounter.With(prometheus.Labels{
+						"qtype":  qtypeStr,
+						"reason": perr.reason,
+					}).Inc()
+					err = perr
+				}
+			}
 			return
 		}
 	}
@@ -414,6 +432,13 @@ func (dnsClient *DNSClientImpl) LookupHost(ctx context.Context, hostname string)
 	if errA != nil && errAAAA != nil {
 		return nil, errA
 	}
+	// A pathological response to either query fails the lookup, even if the
+	// other query succeeded.
+	for _, err := range []error{errA, errAAAA} {
+		if dnsErr, ok := err.(*DNSError); ok && dnsErr.Pathological() {
+			return nil, err
+		}
+	}
 
 	var addrs []net.IP
 
@@ -0,0 +1,108 @@
+package bdns
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// Hard caps on the shape of DNS responses. A resolver will happily hand back
+// whatever an authoritative server answers with, so these protect the VA from
+// spending time and memory on responses that no legitimate zone produces.
+const (
+	// maxAnswerRecords is the maximum number of records accepted in the answer
+	// section of a response.
+	maxAnswerRecords = 100
+	// maxCNAMEChain is the maximum number of CNAME records that may be
+	// followed from the queried name.
+	maxCNAMEChain = 8
+	// maxLabelRepeats is the maximum number of times a single label may appear
+	// in a name in the answer section, e.g. "a.a.a.a.example.com" repeats "a"
+	// four times.
+	maxLabelRepeats = 4
+)
+
+// Reasons a response is rejected, used as the value of the "reason" label of
+// the dns_pathological_responses metric, and the matching error details.
+const (
+	reasonTooManyRecords = "too_many_records"
+	reasonCNAMELoop      = "cname_loop"
+	reasonCNAMEChain     = "cname_chain_too_long"
+	reasonRepeatedLabels = "repeated_labels"
+
+	detailTooManyRecords = "too many records in response"
+	detailCNAMELoop      = "CNAME loop in response"
+	detailCNAMEChain     = "CNAME chain too long in response"
+	detailRepeatedLabels = "repeated labels in response"
+)
+
+// pathologicalResponseError is returned for a response that exceeds one of
+// the hard caps above. It is wrapped in a DNSError like any other lookup
+// failure, and its detail is used as the class of the DNSError.
+type pathologicalResponseError struct {
+	reason string
+	detail string
+}
+
+func (e *pathologicalResponseError) Error() string {
+	return e.detail
+}
+
+// checkResponse returns a *pathologicalResponseError if the answer section of
+// resp, the response to a query for qname, exceeds any of the hard caps on
+// the size of RRsets, the length of CNAME chains, or repeated labels in names.
+func checkResponse(qname string, resp *dns.Msg) *pathologicalResponseError {
+	if len(resp.Answer) > maxAnswerRecords {
+		return &pathologicalResponseError{reasonTooManyRecords, detailTooManyRecords}
+	}
+
+	cnames := make(map[string]string)
+	for _, rr := range resp.Answer {
+		name := strings.ToLower(rr.Header().Name)
+		if repeatedLabels(name) {
+			return &pathologicalResponseError{reasonRepeatedLabels, detailRepeatedLabels}
+		}
+		if cname, ok := rr.(*dns.CNAME); ok {
+			target := strings.ToLower(cname.Target)
+			if repeatedLabels(target) {
+				return &pathologicalResponseError{reasonRepeatedLabels, detailRepeatedLabels}
+			}
+			cnames[name] = target
+		}
+	}
+
+	// Walk the chain from every owner name rather than just the queried name,
+	// so that a loop elsewhere in the answer is caught as well.
+	for owner := range cnames {
+		seen := map[string]bool{owner: true}
+		name := owner
+		for {
+			target, present := cnames[name]
+			if !present {
+				break
+			}
+			if seen[target] {
+				return &pathologicalResponseError{reasonCNAMELoop, detailCNAMELoop}
+			}
+			seen[target] = true
+			name = target
+		}
+		if owner == strings.ToLower(dns.Fqdn(qname)) && len(seen)-1 > maxCNAMEChain {
+			return &pathologicalResponseError{reasonCNAMEChain, detailCNAMEChain}
+		}
+	}
+	return nil
+}
+
+// repeatedLabels returns true if any label appears in name more than
+// maxLabelRepeats times.
+func repeatedLabels(name string) bool {
+	counts := make(map[string]int)
+	for _, label := range dns.SplitDomainName(name) {
+		counts[label]++
+		if counts[label] > maxLabelRepeats {
+			return true
+		}
+	}
+	return false
+}
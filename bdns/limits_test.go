@@ -0,0 +1,125 @@
+package bdns
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func cnameRR(name, target string) dns.RR {
+	return &dns.CNAME{
+		Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET},
+		Target: target,
+	}
+}
+
+func aRR(name string) dns.RR {
+	return &dns.A{
+		Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET},
+		A:   net.ParseIP("192.0.2.1"),
+	}
+}
+
+// cnameChain returns a chain of n CNAMEs starting at name, ending in an A
+// record.
+func cnameChain(name string, n int) []dns.RR {
+	var rrs []dns.RR
+	for i := 0; i < n; i++ {
+		next := fmt.Sprintf("c%d.example.com.", i)
+		rrs = append(rrs, cnameRR(name, next))
+		name = next
+	}
+	return append(rrs, aRR(name))
+}
+
+func TestCheckResponse(t *testing.T) {
+	var manyRecords []dns.RR
+	for i := 0; i < maxAnswerRecords+1; i++ {
+		manyRecords = append(manyRecords, aRR("example.com."))
+	}
+
+	testCases := []struct {
+		name   string
+		answer []dns.RR
+		reason string
+	}{
+		{"plain answer", []dns.RR{aRR("example.com.")}, ""},
+		{"max records", manyRecords[1:], ""},
+		{"too many records", manyRecords, reasonTooManyRecords},
+		{"cname chain", cnameChain("example.com.", maxCNAMEChain), ""},
+		{"cname chain too long", cnameChain("example.com.", maxCNAMEChain+1), reasonCNAMEChain},
+		{"cname loop", []dns.RR{
+			cnameRR("example.com.", "a.example.com."),
+			cnameRR("a.example.com.", "b.example.com."),
+			cnameRR("b.example.com.", "A.example.com."),
+		}, reasonCNAMELoop},
+		{"cname to self", []dns.RR{cnameRR("example.com.", "example.com.")}, reasonCNAMELoop},
+		{"unrelated cname loop", []dns.RR{
+			aRR("example.com."),
+			cnameRR("x.example.net.", "y.example.net."),
+			cnameRR("y.example.net.", "x.example.net."),
+		}, reasonCNAMELoop},
+		{"repeated labels", []dns.RR{aRR("a.a.a.a.example.com.")}, ""},
+		{"too many repeated labels", []dns.RR{aRR("a.b.a.b.a.b.a.b.a.example.com.")}, reasonRepeatedLabels},
+		{"repeated labels in target", []dns.RR{
+			cnameRR("example.com.", "x.x.x.x.x.example.net."),
+		}, reasonRepeatedLabels},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkResponse("Example.com", &dns.Msg{Answer: tc.answer})
+			if tc.reason == "" {
+				test.Assert(t, err == nil, fmt.Sprintf("unexpected error %v", err))
+				return
+			}
+			test.Assert(t, err != nil, "expected an error")
+			test.AssertEquals(t, err.reason, tc.reason)
+		})
+	}
+}
+
+// answerExchanger answers every query with the given answer section.
+type answerExchanger struct {
+	answer []dns.RR
+}
+
+func (ae *answerExchanger) Exchange(m *dns.Msg, a string) (*dns.Msg, time.Duration, error) {
+	resp := new(dns.Msg)
+	resp.SetReply(m)
+	resp.Answer = ae.answer
+	return resp, time.Millisecond, nil
+}
+
+func TestPathologicalResponse(t *testing.T) {
+	dr := NewDNSClientImpl(time.Second, []string{"127.0.0.1:4053"}, newTestStats(), clock.NewFake(), 1)
+	dr.dnsClient = &answerExchanger{answer: []dns.RR{
+		cnameRR("loop.example.com.", "loop2.example.com."),
+		cnameRR("loop2.example.com.", "loop.example.com."),
+	}}
+
+	_, err := dr.LookupHost(context.Background(), "loop.example.com")
+	test.AssertError(t, err, "LookupHost accepted a CNAME loop")
+	test.AssertEquals(t, err.Error(), "DNS problem: CNAME loop in response looking up A for loop.example.com")
+	dnsErr, ok := err.(*DNSError)
+	test.Assert(t, ok, "expected a DNSError")
+	test.Assert(t, dnsErr.Pathological(), "expected a pathological response error")
+	test.Assert(t, !dnsErr.Timeout(), "pathological response reported as a timeout")
+
+	_, _, err = dr.LookupTXT(context.Background(), "loop.example.com")
+	test.AssertError(t, err, "LookupTXT accepted a CNAME loop")
+	_, err = dr.LookupCAA(context.Background(), "loop.example.com")
+	test.AssertError(t, err, "LookupCAA accepted a CNAME loop")
+
+	for _, qtype := range []string{"A", "AAAA", "TXT", "CAA"} {
+		count := test.CountCounter(dr.pathologicalCounter.With(prometheus.Labels{"qtype": qtype, "reason": reasonCNAMELoop}))
+		test.AssertEquals(t, count, 1)
+	}
+}
@@ -18,28 +18,40 @@ type DNSError struct {
 }
 
 func (d DNSError) Error() string {
-	var detail string
+	return fmt.Sprintf("DNS problem: %s looking up %s for %s", d.Detail(),
+		dns.TypeToString[d.recordType], d.hostname)
+}
+
+// Detail returns a short description of the class of failure, e.g. "query
+// timed out" or the response code name such as "NXDOMAIN".
+func (d DNSError) Detail() string {
 	if d.underlying != nil {
+		if pErr, ok := d.underlying.(*pathologicalResponseError); ok {
+			return pErr.detail
+		}
 		if netErr, ok := d.underlying.(*net.OpError); ok {
 			if netErr.Timeout() {
-				detail = detailDNSTimeout
-			} else {
-				detail = detailDNSNetFailure
+				return detailDNSTimeout
 			}
-			// Note: we check d.underlying here even though `Timeout()` does this because the call to `netErr.Timeout()` above only
-			// happens for `*net.OpError` underlying types!
-		} else if d.underlying == context.Canceled || d.underlying == context.DeadlineExceeded {
-			detail = detailDNSTimeout
-		} else {
-			detail = detailServerFailure
+			return detailDNSNetFailure
 		}
+		// Note: we check d.underlying here even though `Timeout()` does this because the call to `netErr.Timeout()` above only
+		// happens for `*net.OpError` underlying types!
+		if d.underlying == context.Canceled || d.underlying == context.DeadlineExceeded {
+			return detailDNSTimeout
+		}
+		return detailServerFailure
 	} else if d.rCode != dns.RcodeSuccess {
-		detail = dns.RcodeToString[d.rCode]
-	} else {
-		detail = detailServerFailure
+		return dns.RcodeToString[d.rCode]
 	}
-	return fmt.Sprintf("DNS problem: %s looking up %s for %s", detail,
-		dns.TypeToString[d.recordType], d.hostname)
+	return detailServerFailure
+}
+
+// Pathological returns true if the lookup failed because the response
+// exceeded one of the hard caps on the contents of DNS responses.
+func (d DNSError) Pathological() bool {
+	_, ok := d.underlying.(*pathologicalResponseError)
+	return ok
 }
 
 // Timeout returns true if the underlying error was a timeout
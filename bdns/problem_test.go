@@ -29,6 +29,9 @@ func TestDNSError(t *testing.T) {
 		}, {
 			&DNSError{dns.TypeTXT, "hostname", context.Canceled, -1},
 			"DNS problem: query timed out looking up TXT for hostname",
+		}, {
+			&DNSError{dns.TypeCAA, "hostname", &pathologicalResponseError{reasonTooManyRecords, detailTooManyRecords}, -1},
+			"DNS problem: too many records in response looking up CAA for hostname",
 		},
 	}
 	for _, tc := range testCases {
@@ -35,6 +35,8 @@ import (
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/lint"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/prometheus/client_golang/prometheus"
@@ -98,7 +100,7 @@ const (
 )
 
 type certificateStorage interface {
-	AddCertificate(context.Context, []byte, int64, []byte) (string, error)
+	AddCertificate(context.Context, []byte, int64, []byte, *time.Time) (string, error)
 }
 
 type certificateType string
@@ -123,21 +125,58 @@ type CertificateAuthorityImpl struct {
 	clk                      clock.Clock
 	log                      blog.Logger
 	stats                    metrics.Scope
-	prefix                   int // Prepended to the serial number
 	validityPeriod           time.Duration
 	backdate                 time.Duration
-	maxNames                 int
+	csrPolicy                csrlib.Policy
 	forceCNFromSAN           bool
 	enableMustStaple         bool
 	enablePrecertificateFlow bool
 	signatureCount           *prometheus.CounterVec
+	signatureLatency         *prometheus.HistogramVec
 	csrExtensionCount        *prometheus.CounterVec
+	linter                   *lint.Linter
+	lintCount                *prometheus.CounterVec
+	// shortLived is the profile used for short-lived certificates, it is nil
+	// if the CA isn't configured to issue them.
+	shortLived *shortLivedProfile
+	// allowCTContingency is whether the default profiles may be used to issue
+	// certificates without embedded SCTs during a CT log outage.
+	allowCTContingency bool
+	contingencyCount   prometheus.Counter
+}
+
+// maxShortLivedValidity is the longest validity period allowed for
+// certificates issued with the short-lived profile. Certificates this short
+// lived don't need revocation checking, so they are issued without an OCSP URL.
+const maxShortLivedValidity = 7 * 24 * time.Hour
+
+// shortLivedProfile describes the CFSSL profiles and validity period used for
+// short-lived certificates.
+type shortLivedProfile struct {
+	rsaProfile         string
+	ecdsaProfile       string
+	validityPeriod     time.Duration
+	allowCTContingency bool
 }
 
 // Issuer represents a single issuer certificate, along with its key.
 type Issuer struct {
 	Signer crypto.Signer
 	Cert   *x509.Certificate
+	// Chain optionally contains the certificates above Cert in the hierarchy.
+	// Any name constraints they carry are enforced at signing time in addition
+	// to those in Cert itself.
+	Chain []*x509.Certificate
+	// SerialPrefix optionally overrides the CA-wide serial prefix for
+	// certificates signed by this issuer. Zero means use the CA-wide prefix.
+	// Only the first, default, issuer signs certificates, so only it may have
+	// one.
+	SerialPrefix int
+	// OCSPSigner and OCSPCert, if set, are the key and certificate of a
+	// delegated OCSP responder issued by Cert. OCSP responses are signed with
+	// them instead of with Signer.
+	OCSPSigner crypto.Signer
+	OCSPCert   *x509.Certificate
 }
 
 // internalIssuer represents the fully initialized internal state for a single
@@ -146,21 +185,47 @@ type internalIssuer struct {
 	cert       *x509.Certificate
 	eeSigner   *local.Signer
 	ocspSigner ocsp.Signer
+	// constraints holds the certificates from the issuer's hierarchy (including
+	// the issuer itself) that carry name constraints.
+	constraints []*x509.Certificate
+	// serialPrefix is the byte embedded at the start of every serial number
+	// for certificates signed by this issuer.
+	serialPrefix int
+	// lintSigner signs with a throwaway key in place of the issuer's, producing
+	// certificates that are only used for linting. It is nil if linting is
+	// disabled.
+	lintSigner *local.Signer
+}
+
+// validSerialPrefix returns true if the prefix fits in a single non-zero byte.
+func validSerialPrefix(prefix int) bool {
+	return prefix > 0 && prefix < 256
 }
 
 func makeInternalIssuers(
 	issuers []Issuer,
 	policy *cfsslConfig.Signing,
 	lifespanOCSP time.Duration,
+	serialPrefix int,
 ) (map[string]*internalIssuer, error) {
 	if len(issuers) == 0 {
 		return nil, errors.New("No issuers specified.")
 	}
 	internalIssuers := make(map[string]*internalIssuer)
-	for _, iss := range issuers {
+	for i, iss := range issuers {
 		if iss.Cert == nil || iss.Signer == nil {
 			return nil, errors.New("Issuer with nil cert or signer specified.")
 		}
+		if i > 0 && iss.SerialPrefix != 0 {
+			return nil, fmt.Errorf("Issuer %q only signs OCSP responses, so it can't have a serial prefix", iss.Cert.Subject.CommonName)
+		}
+		prefix := serialPrefix
+		if iss.SerialPrefix != 0 {
+			prefix = iss.SerialPrefix
+		}
+		if !validSerialPrefix(prefix) {
+			return nil, fmt.Errorf("Issuer %q must have a positive non-zero serial prefix less than 256", iss.Cert.Subject.CommonName)
+		}
 		eeSigner, err := local.NewSigner(iss.Signer, iss.Cert, x509.SHA256WithRSA, policy)
 		if err != nil {
 			return nil, err
@@ -176,10 +241,18 @@ func makeInternalIssuers(
 		if internalIssuers[cn] != nil {
 			return nil, errors.New("Multiple issuer certs with the same CommonName are not supported")
 		}
+		var constraints []*x509.Certificate
+		for _, cert := range append([]*x509.Certificate{iss.Cert}, iss.Chain...) {
+			if hasNameConstraints(cert) {
+				constraints = append(constraints, cert)
+			}
+		}
 		internalIssuers[cn] = &internalIssuer{
-			cert:       iss.Cert,
-			eeSigner:   eeSigner,
-			ocspSigner: ocspSigner,
+			cert:         iss.Cert,
+			eeSigner:     eeSigner,
+			ocspSigner:   ocspSigner,
+			constraints:  constraints,
+			serialPrefix: prefix,
 		}
 	}
 	return internalIssuers, nil
@@ -201,7 +274,7 @@ func NewCertificateAuthorityImpl(
 	var ca *CertificateAuthorityImpl
 	var err error
 
-	if config.SerialPrefix <= 0 || config.SerialPrefix >= 256 {
+	if !validSerialPrefix(config.SerialPrefix) {
 		err = errors.New("Must have a positive non-zero serial prefix less than 256 for CA.")
 		return nil, err
 	}
@@ -230,7 +303,8 @@ func NewCertificateAuthorityImpl(
 	internalIssuers, err := makeInternalIssuers(
 		issuers,
 		cfsslConfigObj.Signing,
-		config.LifespanOCSP.Duration)
+		config.LifespanOCSP.Duration,
+		config.SerialPrefix)
 	if err != nil {
 		return nil, err
 	}
@@ -259,6 +333,30 @@ func NewCertificateAuthorityImpl(
 		[]string{"purpose"})
 	stats.MustRegister(signatureCount)
 
+	signatureLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "signature_latency",
+			Help:    "Histogram of seconds taken to sign, by purpose",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"purpose"})
+	stats.MustRegister(signatureLatency)
+
+	lintCount := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "lintFindings",
+			Help: "Number of certlint findings for to-be-signed certificates, by priority",
+		},
+		[]string{"priority"})
+	stats.MustRegister(lintCount)
+
+	contingencyCount := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ctContingencyIssuances",
+			Help: "Number of certificates issued without embedded SCTs during a CT log outage",
+		})
+	stats.MustRegister(contingencyCount)
+
 	ca = &CertificateAuthorityImpl{
 		sa:                       sa,
 		pa:                       pa,
@@ -266,7 +364,6 @@ func NewCertificateAuthorityImpl(
 		defaultIssuer:            defaultIssuer,
 		rsaProfile:               rsaProfile,
 		ecdsaProfile:             ecdsaProfile,
-		prefix:                   config.SerialPrefix,
 		clk:                      clk,
 		log:                      logger,
 		stats:                    stats,
@@ -275,7 +372,11 @@ func NewCertificateAuthorityImpl(
 		enableMustStaple:         config.EnableMustStaple,
 		enablePrecertificateFlow: config.EnablePrecertificateFlow,
 		signatureCount:           signatureCount,
+		signatureLatency:         signatureLatency,
 		csrExtensionCount:        csrExtensionCount,
+		lintCount:                lintCount,
+		allowCTContingency:       config.AllowCTContingency,
+		contingencyCount:         contingencyCount,
 	}
 
 	if config.Expiry == "" {
@@ -294,11 +395,144 @@ func NewCertificateAuthorityImpl(
 		ca.backdate = time.Hour
 	}
 
-	ca.maxNames = config.MaxNames
+	ca.csrPolicy = config.CSRPolicy
+	ca.csrPolicy.MaxNames = config.MaxNames
+	if ca.csrPolicy.CommonName == "" && ca.forceCNFromSAN {
+		ca.csrPolicy.CommonName = csrlib.CNPromote
+	}
+	if err := ca.csrPolicy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if config.ShortLived != nil {
+		ca.shortLived, err = makeShortLivedProfile(config.ShortLived, cfsslConfigObj.Signing)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Lint != nil {
+		err = ca.setupLinting(config.Lint, cfsslConfigObj.Signing)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = ca.setupDelegatedOCSP(config.LifespanOCSP.Duration, issuers, stats)
+	if err != nil {
+		return nil, err
+	}
 
 	return ca, nil
 }
 
+// makeShortLivedProfile validates the short-lived profile config. Both CFSSL
+// profiles must exist and must not produce certificates with an OCSP URL,
+// either directly or inherited from the default profile.
+func makeShortLivedProfile(config *ca_config.ShortLivedConfig, policy *cfsslConfig.Signing) (*shortLivedProfile, error) {
+	if config.RSAProfile == "" || config.ECDSAProfile == "" {
+		return nil, errors.New("short-lived config must specify RSAProfile and ECDSAProfile")
+	}
+	if config.Expiry.Duration <= 0 || config.Expiry.Duration > maxShortLivedValidity {
+		return nil, fmt.Errorf("short-lived Expiry must be positive and no more than %s", maxShortLivedValidity)
+	}
+	for _, name := range []string{config.RSAProfile, config.ECDSAProfile} {
+		profile, present := policy.Profiles[name]
+		if !present {
+			return nil, fmt.Errorf("short-lived profile %q not found in CFSSL config", name)
+		}
+		if profile.OCSP != "" || (policy.Default != nil && policy.Default.OCSP != "") {
+			return nil, fmt.Errorf("short-lived profile %q must not include an OCSP URL", name)
+		}
+	}
+	return &shortLivedProfile{
+		rsaProfile:         config.RSAProfile,
+		ecdsaProfile:       config.ECDSAProfile,
+		validityPeriod:     config.Expiry.Duration,
+		allowCTContingency: config.AllowCTContingency,
+	}, nil
+}
+
+// checkShortLived returns an error if the request asks for a short-lived
+// certificate but the CA isn't configured to issue them.
+func (ca *CertificateAuthorityImpl) checkShortLived(issueReq *caPB.IssueCertificateRequest) error {
+	if issueReq.GetShortLived() && ca.shortLived == nil {
+		return berrors.InternalServerError("short-lived certificate profile is not configured")
+	}
+	return nil
+}
+
+// checkCTContingency returns an error if the request is for a certificate
+// issued without embedded SCTs during a CT log outage, but either the
+// CTContingency feature is disabled or the requested profile doesn't allow it.
+func (ca *CertificateAuthorityImpl) checkCTContingency(issueReq *caPB.IssueCertificateRequest) error {
+	if !issueReq.GetCtContingency() {
+		return nil
+	}
+	if !features.Enabled(features.CTContingency) {
+		return berrors.InternalServerError("CT contingency issuance is disabled")
+	}
+	allowed := ca.allowCTContingency
+	if issueReq.GetShortLived() {
+		allowed = ca.shortLived != nil && ca.shortLived.allowCTContingency
+	}
+	if !allowed {
+		return berrors.InternalServerError("certificate profile does not allow CT contingency issuance")
+	}
+	return nil
+}
+
+// setupLinting creates the CA's linter and gives each issuer a lint signer
+// backed by a freshly generated throwaway key.
+func (ca *CertificateAuthorityImpl) setupLinting(config *ca_config.LintConfig, policy *cfsslConfig.Signing) error {
+	threshold := config.Threshold
+	if threshold == "" {
+		threshold = "error"
+	}
+	linter, err := lint.New(config.Ignore, threshold)
+	if err != nil {
+		return err
+	}
+	lintKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	for _, issuer := range ca.issuers {
+		// The lint signer's view of the issuer carries the throwaway public key,
+		// since x509 refuses to sign with a key that doesn't match the parent.
+		lintIssuer := *issuer.cert
+		lintIssuer.PublicKey = lintKey.Public()
+		issuer.lintSigner, err = local.NewSigner(lintKey, &lintIssuer, x509.SHA256WithRSA, policy)
+		if err != nil {
+			return err
+		}
+	}
+	ca.linter = linter
+	return nil
+}
+
+// lintCertificate signs the request with the issuer's lint signer and runs the
+// result through the linter, so that a certificate which fails linting is
+// never signed with the real issuer key.
+func (ca *CertificateAuthorityImpl) lintCertificate(issuer *internalIssuer, req signer.SignRequest) error {
+	if ca.linter == nil {
+		return nil
+	}
+	certPEM, err := issuer.lintSigner.Sign(req)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return errors.New("invalid certificate value returned by lint signer")
+	}
+	findings, err := ca.linter.Check(block.Bytes)
+	for _, finding := range findings {
+		ca.lintCount.With(prometheus.Labels{"priority": strings.ToLower(finding.Priority.String())}).Inc()
+	}
+	return err
+}
+
 // noteSignError is called after operations that may cause a CFSSL
 // or PKCS11 signing error.
 func (ca *CertificateAuthorityImpl) noteSignError(err error) {
@@ -409,10 +643,19 @@ func (ca *CertificateAuthorityImpl) GenerateOCSP(ctx context.Context, xferObj co
 			core.SerialToString(cert.SerialNumber), cn, err)
 	}
 
+	signStart := ca.clk.Now()
 	ocspResponse, err := issuer.ocspSigner.Sign(signRequest)
+	ca.signatureLatency.With(prometheus.Labels{"purpose": "ocsp"}).Observe(ca.clk.Since(signStart).Seconds())
 	ca.noteSignError(err)
 	if err == nil {
 		ca.signatureCount.With(prometheus.Labels{"purpose": "ocsp"}).Inc()
+		ca.logSigningRecord(SigningRecord{
+			Purpose:   "ocsp",
+			Serial:    core.SerialToString(cert.SerialNumber),
+			NamesHash: namesHash(cert.DNSNames),
+			Issuer:    cn,
+			Caller:    bgrpc.ClientName(ctx),
+		})
 	}
 	return ocspResponse, err
 }
@@ -436,7 +679,14 @@ func (ca *CertificateAuthorityImpl) IssueCertificate(ctx context.Context, issueR
 		orderID = *issueReq.OrderID
 	}
 
-	serialBigInt, validity, err := ca.generateSerialNumberAndValidity()
+	if err := ca.checkShortLived(issueReq); err != nil {
+		return emptyCert, err
+	}
+	if err := ca.checkCTContingency(issueReq); err != nil {
+		return emptyCert, err
+	}
+
+	serialBigInt, validity, err := ca.generateSerialNumberAndValidity(issueReq.GetShortLived())
 	if err != nil {
 		return emptyCert, err
 	}
@@ -445,8 +695,13 @@ func (ca *CertificateAuthorityImpl) IssueCertificate(ctx context.Context, issueR
 	if err != nil {
 		return emptyCert, err
 	}
+	if issueReq.GetCtContingency() {
+		ca.log.AuditErr(fmt.Sprintf("CT contingency: issued certificate without embedded SCTs: serial=[%s] regID=[%d]",
+			core.SerialToString(serialBigInt), *issueReq.RegistrationID))
+		ca.contingencyCount.Inc()
+	}
 
-	return ca.generateOCSPAndStoreCertificate(ctx, *issueReq.RegistrationID, orderID, serialBigInt, certDER)
+	return ca.generateOCSPAndStoreCertificate(ctx, *issueReq.RegistrationID, orderID, serialBigInt, certDER, issueReq.GetShortLived())
 }
 
 func (ca *CertificateAuthorityImpl) IssuePrecertificate(ctx context.Context, issueReq *caPB.IssueCertificateRequest) (*caPB.IssuePrecertificateResponse, error) {
@@ -454,7 +709,11 @@ func (ca *CertificateAuthorityImpl) IssuePrecertificate(ctx context.Context, iss
 		return nil, berrors.InternalServerError("Precertificate flow is disabled")
 	}
 
-	serialBigInt, validity, err := ca.generateSerialNumberAndValidity()
+	if err := ca.checkShortLived(issueReq); err != nil {
+		return nil, err
+	}
+
+	serialBigInt, validity, err := ca.generateSerialNumberAndValidity(issueReq.GetShortLived())
 	if err != nil {
 		return nil, err
 	}
@@ -507,7 +766,17 @@ func (ca *CertificateAuthorityImpl) IssueCertificateForPrecertificate(ctx contex
 	ca.log.AuditInfo(fmt.Sprintf("Signing success: serial=[%s] names=[%s] precertificate=[%s] certificate=[%s]",
 		serialHex, strings.Join(precert.DNSNames, ", "), hex.EncodeToString(req.DER),
 		hex.EncodeToString(certDER)))
-	return ca.generateOCSPAndStoreCertificate(ctx, *req.RegistrationID, *req.OrderID, precert.SerialNumber, certDER)
+	ca.logSigningRecord(SigningRecord{
+		Purpose:   string(certType),
+		Serial:    serialHex,
+		NamesHash: namesHash(precert.DNSNames),
+		Issuer:    ca.defaultIssuer.cert.Subject.CommonName,
+		Caller:    bgrpc.ClientName(ctx),
+	})
+	// Precertificates issued with the short-lived profile have no OCSP URL,
+	// and neither does the final certificate.
+	skipOCSP := len(precert.OCSPServer) == 0
+	return ca.generateOCSPAndStoreCertificate(ctx, *req.RegistrationID, *req.OrderID, precert.SerialNumber, certDER, skipOCSP)
 }
 
 type validity struct {
@@ -515,11 +784,16 @@ type validity struct {
 	NotAfter  time.Time
 }
 
-func (ca *CertificateAuthorityImpl) generateSerialNumberAndValidity() (*big.Int, validity, error) {
-	// We want 136 bits of random number, plus an 8-bit instance id prefix.
+// generateSerialNumberAndValidity returns a new serial number and the validity
+// period for a certificate, using the short-lived profile's validity period if
+// shortLived is true.
+func (ca *CertificateAuthorityImpl) generateSerialNumberAndValidity(shortLived bool) (*big.Int, validity, error) {
+	// We want 136 bits of random number, plus an 8-bit instance or issuer id
+	// prefix. Certificates are always signed by the default issuer, so its
+	// prefix is the one used.
 	const randBits = 136
 	serialBytes := make([]byte, randBits/8+1)
-	serialBytes[0] = byte(ca.prefix)
+	serialBytes[0] = byte(ca.defaultIssuer.serialPrefix)
 	_, err := rand.Read(serialBytes[1:])
 	if err != nil {
 		err = berrors.InternalServerError("failed to generate serial: %s", err)
@@ -529,10 +803,14 @@ func (ca *CertificateAuthorityImpl) generateSerialNumberAndValidity() (*big.Int,
 	serialBigInt := big.NewInt(0)
 	serialBigInt = serialBigInt.SetBytes(serialBytes)
 
+	validityPeriod := ca.validityPeriod
+	if shortLived {
+		validityPeriod = ca.shortLived.validityPeriod
+	}
 	notBefore := ca.clk.Now().Add(-1 * ca.backdate)
 	validity := validity{
 		NotBefore: notBefore,
-		NotAfter:  notBefore.Add(ca.validityPeriod),
+		NotAfter:  notBefore.Add(validityPeriod),
 	}
 
 	return serialBigInt, validity, nil
@@ -545,14 +823,17 @@ func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context
 	}
 
 	if err := csrlib.VerifyCSR(
+		ctx,
 		csr,
-		ca.maxNames,
+		ca.csrPolicy,
 		&ca.keyPolicy,
 		ca.pa,
-		ca.forceCNFromSAN,
 		*issueReq.RegistrationID,
 	); err != nil {
 		ca.log.AuditErr(err.Error())
+		if _, ok := err.(*berrors.BoulderError); ok {
+			return nil, err
+		}
 		return nil, berrors.MalformedError(err.Error())
 	}
 
@@ -569,18 +850,29 @@ func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context
 		return nil, err
 	}
 
+	if err := issuer.checkNameConstraints(csr.DNSNames); err != nil {
+		ca.log.AuditErr(fmt.Sprintf("Name constraint violation, refusing to sign: issuer=[%s] names=[%s] err=[%v]",
+			issuer.cert.Subject.CommonName, strings.Join(csr.DNSNames, ", "), err))
+		return nil, berrors.RejectedIdentifierError("%s", err)
+	}
+
 	// Convert the CSR to PEM
 	csrPEM := string(pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE REQUEST",
 		Bytes: csr.Raw,
 	}))
 
+	rsaProfile, ecdsaProfile := ca.rsaProfile, ca.ecdsaProfile
+	if issueReq.GetShortLived() {
+		rsaProfile, ecdsaProfile = ca.shortLived.rsaProfile, ca.shortLived.ecdsaProfile
+	}
+
 	var profile string
 	switch csr.PublicKey.(type) {
 	case *rsa.PublicKey:
-		profile = ca.rsaProfile
+		profile = rsaProfile
 	case *ecdsa.PublicKey:
-		profile = ca.ecdsaProfile
+		profile = ecdsaProfile
 	default:
 		err = berrors.InternalServerError("unsupported key type %T", csr.PublicKey)
 		ca.log.AuditErr(err.Error())
@@ -611,10 +903,18 @@ func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context
 		req.Subject.SerialNumber = serialHex
 	}
 
-	ca.log.AuditInfo(fmt.Sprintf("Signing: serial=[%s] names=[%s] csr=[%s]",
-		serialHex, strings.Join(csr.DNSNames, ", "), hex.EncodeToString(csr.Raw)))
+	if err := ca.lintCertificate(issuer, req); err != nil {
+		ca.log.AuditErr(fmt.Sprintf("Linting failed, refusing to sign: serial=[%s] names=[%s] err=[%v]",
+			serialHex, strings.Join(csr.DNSNames, ", "), err))
+		return nil, berrors.InternalServerError("failed to lint certificate: %s", err)
+	}
+
+	ca.log.AuditInfo(fmt.Sprintf("Signing: serial=[%s] names=[%s] csr=[%s] profile=[%s]",
+		serialHex, strings.Join(csr.DNSNames, ", "), hex.EncodeToString(csr.Raw), profile))
 
+	signStart := ca.clk.Now()
 	certPEM, err := issuer.eeSigner.Sign(req)
+	ca.signatureLatency.With(prometheus.Labels{"purpose": string(certType)}).Observe(ca.clk.Since(signStart).Seconds())
 	ca.noteSignError(err)
 	if err != nil {
 		err = berrors.InternalServerError("failed to sign certificate: %s", err)
@@ -641,6 +941,14 @@ func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context
 	ca.log.AuditInfo(fmt.Sprintf("Signing success: serial=[%s] names=[%s] csr=[%s] %s=[%s]",
 		serialHex, strings.Join(csr.DNSNames, ", "), hex.EncodeToString(csr.Raw), certType,
 		hex.EncodeToString(certDER)))
+	ca.logSigningRecord(SigningRecord{
+		Purpose:   string(certType),
+		Serial:    serialHex,
+		NamesHash: namesHash(csr.DNSNames),
+		Profile:   profile,
+		Issuer:    issuer.cert.Subject.CommonName,
+		Caller:    bgrpc.ClientName(ctx),
+	})
 
 	return certDER, nil
 }
@@ -650,20 +958,27 @@ func (ca *CertificateAuthorityImpl) generateOCSPAndStoreCertificate(
 	regID int64,
 	orderID int64,
 	serialBigInt *big.Int,
-	certDER []byte) (core.Certificate, error) {
-	ocspResp, err := ca.GenerateOCSP(ctx, core.OCSPSigningRequest{
-		CertDER: certDER,
-		Status:  "good",
-	})
-	if err != nil {
-		err = berrors.InternalServerError(err.Error())
-		ca.log.AuditInfo(fmt.Sprintf("OCSP Signing failure: serial=[%s] err=[%s]", core.SerialToString(serialBigInt), err))
-		// Ignore errors here to avoid orphaning the certificate. The
-		// ocsp-updater will look for certs with a zero ocspLastUpdated
-		// and generate the initial response in this case.
+	certDER []byte,
+	skipOCSP bool) (core.Certificate, error) {
+	// Short-lived certificates have no OCSP URL, so there is no point in
+	// signing an OCSP response for them.
+	var ocspResp []byte
+	var err error
+	if !skipOCSP {
+		ocspResp, err = ca.GenerateOCSP(ctx, core.OCSPSigningRequest{
+			CertDER: certDER,
+			Status:  "good",
+		})
+		if err != nil {
+			err = berrors.InternalServerError(err.Error())
+			ca.log.AuditInfo(fmt.Sprintf("OCSP Signing failure: serial=[%s] err=[%s]", core.SerialToString(serialBigInt), err))
+			// Ignore errors here to avoid orphaning the certificate. The
+			// ocsp-updater will look for certs with a zero ocspLastUpdated
+			// and generate the initial response in this case.
+		}
 	}
 
-	_, err = ca.sa.AddCertificate(ctx, certDER, regID, ocspResp)
+	_, err = ca.sa.AddCertificate(ctx, certDER, regID, ocspResp, nil)
 	if err != nil {
 		err = berrors.InternalServerError(err.Error())
 		// Note: This log line is parsed by cmd/orphan-finder. If you make any
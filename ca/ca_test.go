@@ -3,12 +3,19 @@ package ca
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,6 +36,7 @@ import (
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/letsencrypt/boulder/lint"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/policy"
@@ -166,7 +174,7 @@ type mockSA struct {
 	certificate core.Certificate
 }
 
-func (m *mockSA) AddCertificate(ctx context.Context, der []byte, _ int64, _ []byte) (string, error) {
+func (m *mockSA) AddCertificate(ctx context.Context, der []byte, _ int64, _ []byte, _ *time.Time) (string, error) {
 	m.certificate.DER = der
 	return "", nil
 }
@@ -191,7 +199,7 @@ func setup(t *testing.T) *testCtx {
 	fc := clock.NewFake()
 	fc.Add(1 * time.Hour)
 
-	pa, err := policy.New(nil)
+	pa, err := policy.New(nil, metrics.NewNoopScope())
 	test.AssertNotError(t, err, "Couldn't create PA")
 	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
 	test.AssertNotError(t, err, "Couldn't set hostname policy")
@@ -266,7 +274,7 @@ func setup(t *testing.T) *testCtx {
 		},
 	}
 
-	issuers := []Issuer{{caKey, caCert}}
+	issuers := []Issuer{{Signer: caKey, Cert: caCert}}
 
 	keyPolicy := goodkey.KeyPolicy{
 		AllowRSA:           true,
@@ -416,6 +424,7 @@ func TestIssueCertificate(t *testing.T) {
 
 func issueCertificateSubTestDefaultSetup(t *testing.T) (*CertificateAuthorityImpl, *mockSA) {
 	testCtx := setup(t)
+	testCtx.caConfig.DoNotForceCN = true
 	sa := &mockSA{}
 	ca, err := NewCertificateAuthorityImpl(
 		testCtx.caConfig,
@@ -427,7 +436,6 @@ func issueCertificateSubTestDefaultSetup(t *testing.T) (*CertificateAuthorityImp
 		testCtx.keyPolicy,
 		testCtx.logger)
 	test.AssertNotError(t, err, "Failed to create CA")
-	ca.forceCNFromSAN = false
 
 	return ca, sa
 }
@@ -594,12 +602,97 @@ func TestOCSP(t *testing.T) {
 	test.AssertEquals(t, parsedNewCertOcspResp.SerialNumber.Cmp(parsedNewCert.SerialNumber), 0)
 }
 
+// makeResponder returns a delegated OCSP responder key and certificate
+// issued by caCert, valid for validity.
+func makeResponder(t *testing.T, now time.Time, validity time.Duration, ekus []x509.ExtKeyUsage) (crypto.Signer, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate responder key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1234),
+		Subject:      pkix.Name{CommonName: "happy hacker OCSP Responder"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  ekus,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	test.AssertNotError(t, err, "Failed to create responder certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "Failed to parse responder certificate")
+	return key, cert
+}
+
+func TestDelegatedOCSP(t *testing.T) {
+	testCtx := setup(t)
+	lifespan := testCtx.caConfig.LifespanOCSP.Duration
+	ocspSigning := []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}
+	newCA := func(issuer Issuer) (*CertificateAuthorityImpl, error) {
+		return NewCertificateAuthorityImpl(
+			testCtx.caConfig,
+			&mockSA{},
+			testCtx.pa,
+			testCtx.fc,
+			metrics.NewNoopScope(),
+			[]Issuer{issuer},
+			testCtx.keyPolicy,
+			testCtx.logger)
+	}
+
+	key, responder := makeResponder(t, testCtx.fc.Now(), lifespan+time.Hour, ocspSigning)
+	ca, err := newCA(Issuer{Signer: caKey, Cert: caCert, OCSPSigner: key, OCSPCert: responder})
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	cert, err := ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue")
+	generate := func() ([]byte, error) {
+		return ca.GenerateOCSP(ctx, core.OCSPSigningRequest{
+			CertDER: cert.DER,
+			Status:  string(core.OCSPStatusGood),
+		})
+	}
+
+	// The response is signed by the responder certificate, not the issuer
+	ocspResp, err := generate()
+	test.AssertNotError(t, err, "Failed to generate OCSP")
+	parsed, err := ocsp.ParseResponse(ocspResp, caCert)
+	test.AssertNotError(t, err, "Failed to parse validate OCSP")
+	test.Assert(t, parsed.Certificate != nil, "Response didn't include a responder certificate")
+	test.AssertDeepEquals(t, parsed.Certificate.Raw, responder.Raw)
+
+	// Once the responder certificate wouldn't outlast the response, responses
+	// are refused rather than signed with the issuer key
+	testCtx.fc.Add(2 * time.Hour)
+	_, err = generate()
+	test.AssertError(t, err, "Signed OCSP with an expiring responder certificate")
+
+	// Responders are checked at startup
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate key")
+	_, noEKU := makeResponder(t, testCtx.fc.Now(), lifespan+time.Hour, nil)
+	_, short := makeResponder(t, testCtx.fc.Now(), lifespan-time.Hour, ocspSigning)
+	_, valid := makeResponder(t, testCtx.fc.Now(), lifespan+time.Hour, ocspSigning)
+	for _, tc := range []struct {
+		name   string
+		signer crypto.Signer
+		cert   *x509.Certificate
+	}{
+		{"no key", nil, valid},
+		{"no OCSP signing EKU", key, noEKU},
+		{"mismatched key", otherKey, valid},
+		{"expires before responses", key, short},
+	} {
+		_, err = newCA(Issuer{Signer: caKey, Cert: caCert, OCSPSigner: tc.signer, OCSPCert: tc.cert})
+		test.AssertError(t, err, "CA accepted responder with "+tc.name)
+	}
+}
+
 func TestInvalidCSRs(t *testing.T) {
 	testCases := []struct {
 		name         string
 		csrPath      string
 		check        func(t *testing.T, ca *CertificateAuthorityImpl, sa *mockSA)
 		errorMessage string
+		errorType    berrors.ErrorType
 	}{
 		// Test that the CA rejects CSRs that have no names.
 		//
@@ -607,7 +700,7 @@ func TestInvalidCSRs(t *testing.T) {
 		// * Random RSA public key.
 		// * CN = [none]
 		// * DNSNames = [none]
-		{"RejectNoHostnames", "./testdata/no_names.der.csr", nil, "Issued certificate with no names"},
+		{"RejectNoHostnames", "./testdata/no_names.der.csr", nil, "Issued certificate with no names", berrors.BadCSR},
 
 		// Test that the CA rejects CSRs that have too many names.
 		//
@@ -615,7 +708,7 @@ func TestInvalidCSRs(t *testing.T) {
 		// * Random public key
 		// * CN = [none]
 		// * DNSNames = not-example.com, www.not-example.com, mail.example.com
-		{"RejectTooManyHostnames", "./testdata/too_many_names.der.csr", nil, "Issued certificate with too many names"},
+		{"RejectTooManyHostnames", "./testdata/too_many_names.der.csr", nil, "Issued certificate with too many names", berrors.BadCSR},
 
 		// Test that the CA rejects CSRs that have public keys that are too short.
 		//
@@ -623,23 +716,23 @@ func TestInvalidCSRs(t *testing.T) {
 		// * Random public key -- 512 bits long
 		// * CN = (none)
 		// * DNSNames = not-example.com, www.not-example.com, mail.not-example.com
-		{"RejectShortKey", "./testdata/short_key.der.csr", nil, "Issued a certificate with too short a key."},
+		{"RejectShortKey", "./testdata/short_key.der.csr", nil, "Issued a certificate with too short a key.", berrors.BadCSR},
 
 		// CSR generated by Go:
 		// * Random RSA public key.
 		// * CN = aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa.com
 		// * DNSNames = [none]
-		{"RejectLongCommonName", "./testdata/long_cn.der.csr", nil, "Issued a certificate with a CN over 64 bytes."},
+		{"RejectLongCommonName", "./testdata/long_cn.der.csr", nil, "Issued a certificate with a CN over 64 bytes.", berrors.BadCSR},
 
 		// CSR generated by OpenSSL:
 		// Edited signature to become invalid.
-		{"RejectWrongSignature", "./testdata/invalid_signature.der.csr", nil, "Issued a certificate based on a CSR with an invalid signature."},
+		{"RejectWrongSignature", "./testdata/invalid_signature.der.csr", nil, "Issued a certificate based on a CSR with an invalid signature.", berrors.BadCSR},
 
 		// CSR generated by Go:
 		// * Random public key
 		// * CN = not-example.com
 		// * Includes an extensionRequest attribute for an empty TLS Feature extension
-		{"TLSFeatureUnknown", "./testdata/tls_feature_unknown.der.csr", issueCertificateSubTestTLSFeatureUnknown, "Issued a certificate based on a CSR with an empty TLS feature extension."},
+		{"TLSFeatureUnknown", "./testdata/tls_feature_unknown.der.csr", issueCertificateSubTestTLSFeatureUnknown, "Issued a certificate based on a CSR with an empty TLS feature extension.", berrors.Malformed},
 	}
 
 	for _, testCase := range testCases {
@@ -667,7 +760,7 @@ func TestInvalidCSRs(t *testing.T) {
 					_, err = ca.IssuePrecertificate(ctx, issueReq)
 				}
 
-				test.Assert(t, berrors.Is(err, berrors.Malformed), "Incorrect error type returned")
+				test.Assert(t, berrors.Is(err, testCase.errorType), "Incorrect error type returned")
 				test.AssertEquals(t, signatureCountByPurpose("cert", ca.signatureCount), 0)
 
 				if mode.issuePrecertificate == mode.enablePrecertificateFlow {
@@ -709,7 +802,7 @@ func TestRejectValidityTooLong(t *testing.T) {
 }
 
 func TestSingleAIAEnforcement(t *testing.T) {
-	pa, err := policy.New(nil)
+	pa, err := policy.New(nil, metrics.NewNoopScope())
 	test.AssertNotError(t, err, "Couldn't create PA")
 
 	_, err = NewCertificateAuthorityImpl(
@@ -826,6 +919,7 @@ func issueCertificateSubTestMustStapleWhenDisabled(t *testing.T, i *TestCertific
 	test.AssertEquals(t, test.CountCounterVec(csrExtensionCategory, csrExtensionTLSFeature, i.ca.csrExtensionCount), 1)
 	test.AssertEquals(t, test.CountCounterVec(csrExtensionCategory, csrExtensionTLSFeatureInvalid, i.ca.csrExtensionCount), 0)
 	test.AssertEquals(t, signatureCountByPurpose(certType, i.ca.signatureCount), 1)
+	test.AssertEquals(t, test.CountHistogramSamples(i.ca.signatureLatency.With(prometheus.Labels{"purpose": certType})), 1)
 	test.AssertEquals(t, countMustStaple(t, i.cert), 0)
 }
 
@@ -959,3 +1053,446 @@ func TestIssueCertificateForPrecertificate(t *testing.T) {
 	}
 	test.Assert(t, list, "returned cert doesn't contain SCT list")
 }
+
+// makeConstrainedIssuer returns a self-signed issuer certificate for caKey
+// carrying the given DNS name constraints.
+func makeConstrainedIssuer(t *testing.T, cn string, permitted, excluded []string) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1337),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Unix(0, 0),
+		NotAfter:              time.Unix(0, 0).Add(10 * 365 * 24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		PermittedDNSDomains:   permitted,
+		ExcludedDNSDomains:    excluded,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, caKey.Public(), caKey)
+	test.AssertNotError(t, err, "Failed to create constrained issuer")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "Failed to parse constrained issuer")
+	return cert
+}
+
+func TestNameConstraints(t *testing.T) {
+	testCases := []struct {
+		name      string
+		issuer    *x509.Certificate
+		chain     []*x509.Certificate
+		expectErr bool
+	}{
+		{
+			name:   "Permitted",
+			issuer: makeConstrainedIssuer(t, "permitted", []string{"not-example.com"}, nil),
+		},
+		{
+			name:      "NotPermitted",
+			issuer:    makeConstrainedIssuer(t, "not permitted", []string{"example.org"}, nil),
+			expectErr: true,
+		},
+		{
+			name:      "SubdomainsOnly",
+			issuer:    makeConstrainedIssuer(t, "subdomains only", []string{".not-example.com"}, nil),
+			expectErr: true,
+		},
+		{
+			name:      "Excluded",
+			issuer:    makeConstrainedIssuer(t, "excluded", nil, []string{"www.not-example.com"}),
+			expectErr: true,
+		},
+		{
+			name:      "ConstrainedHierarchy",
+			issuer:    caCert,
+			chain:     []*x509.Certificate{makeConstrainedIssuer(t, "parent", []string{"example.org"}, nil)},
+			expectErr: true,
+		},
+		{
+			name:   "UnconstrainedHierarchy",
+			issuer: caCert,
+			chain:  []*x509.Certificate{makeConstrainedIssuer(t, "parent", nil, []string{"example.org"})},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCtx := setup(t)
+			sa := &mockSA{}
+			ca, err := NewCertificateAuthorityImpl(
+				testCtx.caConfig,
+				sa,
+				testCtx.pa,
+				testCtx.fc,
+				testCtx.stats,
+				[]Issuer{{Signer: caKey, Cert: tc.issuer, Chain: tc.chain}},
+				testCtx.keyPolicy,
+				testCtx.logger)
+			test.AssertNotError(t, err, "Failed to create CA")
+
+			_, err = ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID})
+			if !tc.expectErr {
+				test.AssertNotError(t, err, "Failed to issue certificate within name constraints")
+				return
+			}
+			test.AssertError(t, err, "Issued certificate violating name constraints")
+			test.Assert(t, berrors.Is(err, berrors.RejectedIdentifier), "Incorrect error type returned")
+			test.AssertEquals(t, signatureCountByPurpose("certificate", ca.signatureCount), 0)
+			mockLog := testCtx.logger.(*blog.Mock)
+			test.AssertEquals(t, len(mockLog.GetAllMatching("Name constraint violation")), 1)
+		})
+	}
+}
+
+func TestWildcardNameConstraints(t *testing.T) {
+	testCases := []struct {
+		name      string
+		permitted []string
+		excluded  []string
+		expectErr bool
+	}{
+		{name: "PermittedBase", permitted: []string{"example.com"}},
+		{name: "PermittedSubdomains", permitted: []string{".example.com"}},
+		{name: "NotPermitted", permitted: []string{"foo.example.com"}, expectErr: true},
+		{name: "ExcludedBase", excluded: []string{"example.com"}, expectErr: true},
+		{name: "ExcludedSubdomain", excluded: []string{"foo.example.com"}, expectErr: true},
+		{name: "ExcludedElsewhere", excluded: []string{"example.org"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ii := &internalIssuer{
+				constraints: []*x509.Certificate{{
+					Subject:             pkix.Name{CommonName: "constrained"},
+					PermittedDNSDomains: tc.permitted,
+					ExcludedDNSDomains:  tc.excluded,
+				}},
+			}
+			err := ii.checkNameConstraints([]string{"*.example.com"})
+			if tc.expectErr {
+				test.AssertError(t, err, "Wildcard name violating constraints was allowed")
+			} else {
+				test.AssertNotError(t, err, "Wildcard name within constraints was rejected")
+			}
+		})
+	}
+}
+
+func TestIssuerSerialPrefix(t *testing.T) {
+	testCtx := setup(t)
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		[]Issuer{{Signer: caKey, Cert: caCert, SerialPrefix: 0x42}},
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	issuedCert, err := ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue certificate")
+	cert, err := x509.ParseCertificate(issuedCert.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, core.SerialToString(cert.SerialNumber)[:2], "42")
+
+	// Without an issuer override the CA-wide prefix is used
+	ca, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertNotError(t, err, "Failed to create CA")
+	issuedCert, err = ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue certificate")
+	cert, err = x509.ParseCertificate(issuedCert.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, core.SerialToString(cert.SerialNumber)[:2], "11")
+
+	_, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		[]Issuer{{Signer: caKey, Cert: caCert, SerialPrefix: 256}},
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertError(t, err, "CA should have failed with an out of range issuer SerialPrefix")
+
+	// Issuers other than the default one don't sign certificates
+	newIssuerCert, err := core.LoadCert("../test/test-ca2.pem")
+	test.AssertNotError(t, err, "Failed to load new cert")
+	_, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		[]Issuer{{Signer: caKey, Cert: caCert}, {Signer: caKey, Cert: newIssuerCert, SerialPrefix: 0x42}},
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertError(t, err, "CA should have failed with a SerialPrefix on a non-default issuer")
+}
+
+func TestLintBeforeSigning(t *testing.T) {
+	testCtx := setup(t)
+	// Short-lived certificates from profiles without a CRL have no revocation
+	// information at all, which certlint rejects.
+	addShortLivedProfiles(testCtx)
+	for _, name := range []string{rsaProfileName, ecdsaProfileName} {
+		testCtx.caConfig.CFSSL.Signing.Profiles[name+"ShortLived"].CRL = ""
+	}
+	testCtx.caConfig.Lint = &ca_config.LintConfig{Threshold: "error"}
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	issueReq := &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID}
+	_, err = ca.IssueCertificate(ctx, issueReq)
+	test.AssertNotError(t, err, "Failed to issue a certificate that should pass linting")
+	test.AssertEquals(t, signatureCountByPurpose("certificate", ca.signatureCount), 1)
+
+	// The short-lived certificate fails linting, and must be caught before
+	// the issuer key is used.
+	shortLived := true
+	issueReq.ShortLived = &shortLived
+	_, err = ca.IssueCertificate(ctx, issueReq)
+	test.AssertError(t, err, "Issued a certificate that fails linting")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Incorrect error type returned")
+	test.Assert(t, strings.Contains(err.Error(), "Certificate contains no CRL or OCSP server"), "Error didn't name the failing check")
+	test.AssertEquals(t, signatureCountByPurpose("certificate", ca.signatureCount), 1)
+	test.AssertEquals(t, test.CountCounter(ca.lintCount.With(prometheus.Labels{"priority": "error"})), 1)
+	mockLog := testCtx.logger.(*blog.Mock)
+	test.AssertEquals(t, len(mockLog.GetAllMatching("Linting failed, refusing to sign")), 1)
+
+	// Ignoring the finding allows the certificate to be signed
+	ca.linter, err = lint.New([]string{"Certificate contains no CRL or OCSP server"}, "error")
+	test.AssertNotError(t, err, "Failed to create linter")
+	_, err = ca.IssueCertificate(ctx, issueReq)
+	test.AssertNotError(t, err, "Failed to issue a certificate with an ignored lint finding")
+	test.AssertEquals(t, signatureCountByPurpose("certificate", ca.signatureCount), 2)
+}
+
+func TestLintConfig(t *testing.T) {
+	testCtx := setup(t)
+	for _, config := range []*ca_config.LintConfig{
+		{Threshold: "severe"},
+		{Threshold: "unknown"},
+	} {
+		testCtx.caConfig.Lint = config
+		_, err := NewCertificateAuthorityImpl(
+			testCtx.caConfig,
+			&mockSA{},
+			testCtx.pa,
+			testCtx.fc,
+			metrics.NewNoopScope(),
+			testCtx.issuers,
+			testCtx.keyPolicy,
+			testCtx.logger)
+		test.AssertError(t, err, "CA accepted an invalid lint config")
+	}
+}
+
+// addShortLivedProfiles adds CFSSL profiles without an OCSP URL to the test
+// config and configures the CA to use them for short-lived certificates.
+func addShortLivedProfiles(testCtx *testCtx) {
+	profiles := testCtx.caConfig.CFSSL.Signing.Profiles
+	for _, name := range []string{rsaProfileName, ecdsaProfileName} {
+		profile := *profiles[name]
+		profile.OCSP = ""
+		profile.ExpiryString = "168h"
+		profiles[name+"ShortLived"] = &profile
+	}
+	testCtx.caConfig.ShortLived = &ca_config.ShortLivedConfig{
+		RSAProfile:   rsaProfileName + "ShortLived",
+		ECDSAProfile: ecdsaProfileName + "ShortLived",
+		Expiry:       cmd.ConfigDuration{Duration: 7 * 24 * time.Hour},
+	}
+}
+
+func TestShortLivedIssuance(t *testing.T) {
+	testCtx := setup(t)
+	addShortLivedProfiles(testCtx)
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	shortLived := true
+	issuedCert, err := ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID, ShortLived: &shortLived})
+	test.AssertNotError(t, err, "Failed to issue short-lived certificate")
+	cert, err := x509.ParseCertificate(issuedCert.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertEquals(t, len(cert.OCSPServer), 0)
+	test.AssertEquals(t, cert.NotAfter.Sub(cert.NotBefore), 7*24*time.Hour)
+	test.AssertEquals(t, signatureCountByPurpose("ocsp", ca.signatureCount), 0)
+
+	// Requests that don't ask for a short-lived certificate are unaffected
+	issuedCert, err = ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue certificate")
+	cert, err = x509.ParseCertificate(issuedCert.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	test.AssertDeepEquals(t, cert.OCSPServer, []string{"http://not-example.com/ocsp"})
+	test.AssertEquals(t, signatureCountByPurpose("ocsp", ca.signatureCount), 1)
+
+	// A CA without a short-lived profile refuses short-lived requests
+	ca.shortLived = nil
+	_, err = ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID, ShortLived: &shortLived})
+	test.AssertError(t, err, "Issued a short-lived certificate without a short-lived profile")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Incorrect error type returned")
+}
+
+func TestShortLivedConfig(t *testing.T) {
+	testCtx := setup(t)
+	addShortLivedProfiles(testCtx)
+	testCtx.caConfig.ShortLived.Expiry.Duration = 8 * 24 * time.Hour
+	_, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertError(t, err, "CA accepted a short-lived expiry longer than 7 days")
+
+	testCtx = setup(t)
+	addShortLivedProfiles(testCtx)
+	testCtx.caConfig.ShortLived.RSAProfile = rsaProfileName
+	_, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertError(t, err, "CA accepted a short-lived profile with an OCSP URL")
+
+	testCtx = setup(t)
+	addShortLivedProfiles(testCtx)
+	testCtx.caConfig.ShortLived.ECDSAProfile = "missing"
+	_, err = NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertError(t, err, "CA accepted a missing short-lived profile")
+}
+
+func TestCTContingencyIssuance(t *testing.T) {
+	testCtx := setup(t)
+	addShortLivedProfiles(testCtx)
+	testCtx.caConfig.AllowCTContingency = true
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	contingency := true
+	req := &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID, CtContingency: &contingency}
+
+	// Without the feature contingency requests are refused
+	_, err = ca.IssueCertificate(ctx, req)
+	test.AssertError(t, err, "Issued a contingency certificate with CTContingency disabled")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Incorrect error type returned")
+
+	err = features.Set(map[string]bool{"CTContingency": true})
+	test.AssertNotError(t, err, "Failed to enable CTContingency")
+	defer features.Reset()
+
+	issuedCert, err := ca.IssueCertificate(ctx, req)
+	test.AssertNotError(t, err, "Failed to issue contingency certificate")
+	_, err = x509.ParseCertificate(issuedCert.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+	mockLog := testCtx.logger.(*blog.Mock)
+	test.AssertEquals(t, len(mockLog.GetAllMatching("CT contingency: issued certificate without embedded SCTs")), 1)
+
+	// The short-lived profile doesn't allow it unless configured to
+	shortLived := true
+	req.ShortLived = &shortLived
+	_, err = ca.IssueCertificate(ctx, req)
+	test.AssertError(t, err, "Issued a short-lived contingency certificate without the profile allowing it")
+	ca.shortLived.allowCTContingency = true
+	_, err = ca.IssueCertificate(ctx, req)
+	test.AssertNotError(t, err, "Failed to issue short-lived contingency certificate")
+
+	// Nor does the default profile
+	ca.allowCTContingency = false
+	req.ShortLived = nil
+	_, err = ca.IssueCertificate(ctx, req)
+	test.AssertError(t, err, "Issued a contingency certificate without the profile allowing it")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Incorrect error type returned")
+}
+
+func TestSigningRecords(t *testing.T) {
+	testCtx := setup(t)
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	issuedCert, err := ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue")
+	cert, err := x509.ParseCertificate(issuedCert.DER)
+	test.AssertNotError(t, err, "Certificate failed to parse")
+
+	// Issuing a certificate signs both it and its first OCSP response
+	mockLog := testCtx.logger.(*blog.Mock)
+	lines := mockLog.GetAllMatching(SigningRecordMessage)
+	test.AssertEquals(t, len(lines), 2)
+	var records []SigningRecord
+	for _, line := range lines {
+		var rec SigningRecord
+		err = json.Unmarshal([]byte(line[strings.Index(line, "JSON=")+len("JSON="):]), &rec)
+		test.AssertNotError(t, err, "Failed to unmarshal signing record")
+		records = append(records, rec)
+	}
+	test.AssertEquals(t, records[0].Purpose, "certificate")
+	test.AssertEquals(t, records[0].Serial, core.SerialToString(cert.SerialNumber))
+	test.AssertEquals(t, records[0].NamesHash, hex.EncodeToString(core.HashNames(cert.DNSNames)))
+	test.AssertEquals(t, records[0].Profile, testCtx.caConfig.RSAProfile)
+	test.AssertEquals(t, records[0].Issuer, caCert.Subject.CommonName)
+	test.AssertEquals(t, records[1].Purpose, "ocsp")
+	test.AssertEquals(t, records[1].Serial, records[0].Serial)
+	test.AssertEquals(t, records[1].NamesHash, records[0].NamesHash)
+}
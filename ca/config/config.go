@@ -5,6 +5,7 @@ import (
 	"github.com/letsencrypt/pkcs11key"
 
 	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/csr"
 )
 
 // CAConfig structs have configuration information for the certificate
@@ -21,6 +22,9 @@ type CAConfig struct {
 	RSAProfile   string
 	ECDSAProfile string
 	TestMode     bool
+	// SerialPrefix is the byte prepended to the serial number of every
+	// certificate issued by this CA instance, unless overridden by the issuer's
+	// own SerialPrefix. It must be between 1 and 255.
 	SerialPrefix int
 	// TODO(jsha): Remove Key field once we've migrated to Issuers
 	Key *IssuerConfig
@@ -36,10 +40,16 @@ type CAConfig struct {
 	// How far back certificates should be backdated, should match backdate
 	// field in cfssl config.
 	Backdate cmd.ConfigDuration
-	// The maximum number of subjectAltNames in a single certificate
+	// The maximum number of subjectAltNames in a single certificate. The limit
+	// subscribers see is enforced by the RA, where it can be overridden per
+	// account, so this is a hard upper bound that overrides can't exceed.
 	MaxNames int
 	CFSSL    cfsslConfig.Config
 
+	// CSRPolicy configures how the names and common name in CSRs are checked.
+	// If its CommonName is unset it is "promote" unless DoNotForceCN is set.
+	CSRPolicy csr.Policy
+
 	// DoNotForceCN is a temporary config setting. It controls whether
 	// to add a certificate's serial to its Subject, and whether to
 	// not pull a SAN entry to be the CN if no CN was given in a CSR.
@@ -53,15 +63,56 @@ type CAConfig struct {
 	// is enabled.
 	EnablePrecertificateFlow bool
 
+	// AllowCTContingency governs whether the RSAProfile and ECDSAProfile may be
+	// used to issue certificates without embedded SCTs when the RA can't get
+	// SCTs during a CT log outage. It has no effect unless the CTContingency
+	// feature is enabled.
+	AllowCTContingency bool
+
 	// WeakKeyFile is the path to a JSON file containing truncated RSA modulus
 	// hashes of known easily enumerable keys.
 	WeakKeyFile string
 
+	// Lint, if present, enables linting of every certificate and
+	// precertificate before it is signed by an issuer key.
+	Lint *LintConfig
+
+	// ShortLived, if present, allows the CA to issue short-lived certificates
+	// without an OCSP URL when the RA asks for them.
+	ShortLived *ShortLivedConfig
+
 	SAService *cmd.GRPCClientConfig
 
 	Features map[string]bool
 }
 
+// LintConfig controls the linting of certificates with certlint before they
+// are signed.
+type LintConfig struct {
+	// Ignore lists the messages of certlint findings that never prevent
+	// signing, e.g. "Certificate contains no CRL or OCSP server" when
+	// short-lived certificates are issued without either.
+	Ignore []string
+	// Threshold is the lowest certlint priority ("notice", "warning",
+	// "error", "critical", ...) that prevents signing. Defaults to "error".
+	Threshold string
+}
+
+// ShortLivedConfig describes the profile used for short-lived certificates.
+type ShortLivedConfig struct {
+	// RSAProfile and ECDSAProfile name the CFSSL profiles used for RSA and
+	// ECDSA keys. Neither profile, nor the default profile, may set an OCSP
+	// URL.
+	RSAProfile   string
+	ECDSAProfile string
+	// Expiry is how long short-lived certificates are valid for, it should
+	// match the expiry of the CFSSL profiles and be no more than 7 days.
+	Expiry cmd.ConfigDuration
+	// AllowCTContingency governs whether short-lived certificates may be
+	// issued without embedded SCTs, like the CA's AllowCTContingency.
+	AllowCTContingency bool
+}
+
 // IssuerConfig contains info about an issuer: private key and issuer cert.
 // It should contain either a File path to a PEM-format private key,
 // or a PKCS11Config defining how to load a module for an HSM.
@@ -71,7 +122,21 @@ type IssuerConfig struct {
 	File       string
 	PKCS11     *pkcs11key.Config
 	CertFile   string
+	// ConstraintCertFiles is an optional list of PEM certificate files for the
+	// certificates above this issuer in the hierarchy. Name constraints present
+	// in any of them are enforced before signing, along with those in CertFile.
+	ConstraintCertFiles []string
+	// SerialPrefix optionally overrides the CA's SerialPrefix for certificates
+	// signed by this issuer, so that serials identify their issuer. Only the
+	// first issuer signs certificates, so it can't be set for the others.
+	SerialPrefix int
 	// Number of sessions to open with the HSM. For maximum performance,
 	// this should be equal to the number of cores in the HSM. Defaults to 1.
 	NumSessions int
+	// OCSPResponder, if present, is the key and certificate of a delegated
+	// OCSP responder issued by this issuer, loaded like the issuer's own. OCSP
+	// responses are signed with it instead of the issuer key. The responder
+	// certificate is issued out of band, and replaced by configuring a new
+	// one before it expires.
+	OCSPResponder *IssuerConfig
 }
@@ -0,0 +1,90 @@
+package ca
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// hasNameConstraints returns true if the certificate carries any DNS name
+// constraints that must be honoured by certificates issued beneath it.
+func hasNameConstraints(cert *x509.Certificate) bool {
+	return len(cert.PermittedDNSDomains) > 0 || len(cert.ExcludedDNSDomains) > 0
+}
+
+// withinConstraint returns true if the (non-wildcard) name falls within the
+// scope of the given RFC 5280 dNSName constraint. A constraint with a leading
+// period only matches subdomains, otherwise it matches the name itself and
+// any of its subdomains.
+func withinConstraint(name, constraint string) bool {
+	constraint = strings.ToLower(constraint)
+	if constraint == "" {
+		// An empty constraint matches every name
+		return true
+	}
+	if strings.HasPrefix(constraint, ".") {
+		return strings.HasSuffix(name, constraint)
+	}
+	return name == constraint || strings.HasSuffix(name, "."+constraint)
+}
+
+// permittedName returns true if every name the (possibly wildcard) name could
+// match is within one of the permitted constraints.
+func permittedName(name string, permitted []string) bool {
+	if len(permitted) == 0 {
+		return true
+	}
+	if strings.HasPrefix(name, "*.") {
+		// A wildcard only ever matches subdomains of its base domain, so it is
+		// permitted as long as a subdomain of the base would be.
+		name = "x" + name[1:]
+	}
+	for _, constraint := range permitted {
+		if withinConstraint(name, constraint) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedName returns the first excluded constraint that the (possibly
+// wildcard) name could match, or the empty string if there is none.
+func excludedName(name string, excluded []string) (string, bool) {
+	for _, constraint := range excluded {
+		if strings.HasPrefix(name, "*.") {
+			base := name[2:]
+			// The wildcard is excluded if any name it could match is excluded:
+			// either the constraint covers all of the base domain's subdomains,
+			// or the constraint sits somewhere beneath the base domain.
+			if withinConstraint("x."+base, constraint) ||
+				strings.HasSuffix(strings.TrimPrefix(strings.ToLower(constraint), "."), "."+base) {
+				return constraint, true
+			}
+			continue
+		}
+		if withinConstraint(name, constraint) {
+			return constraint, true
+		}
+	}
+	return "", false
+}
+
+// checkNameConstraints verifies that every name in the to-be-issued
+// certificate complies with the DNS name constraints of the issuer and of
+// every certificate configured above it in the hierarchy. Names are expected
+// to have already been normalized to lower case.
+func (ii *internalIssuer) checkNameConstraints(names []string) error {
+	for _, constrained := range ii.constraints {
+		for _, name := range names {
+			if !permittedName(name, constrained.PermittedDNSDomains) {
+				return fmt.Errorf("name %q is not permitted by the name constraints of %q",
+					name, constrained.Subject.CommonName)
+			}
+			if constraint, excluded := excludedName(name, constrained.ExcludedDNSDomains); excluded {
+				return fmt.Errorf("name %q is excluded by the name constraint %q of %q",
+					name, constraint, constrained.Subject.CommonName)
+			}
+		}
+	}
+	return nil
+}
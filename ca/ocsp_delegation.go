@@ -0,0 +1,101 @@
+package ca
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudflare/cfssl/ocsp"
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+// delegatedOCSPSigner is an ocsp.Signer that signs OCSP responses for an
+// issuer with a delegated responder certificate rather than with the issuer
+// key. The responder key and certificate are provisioned out of band, with
+// the key normally held in the HSM, and are replaced by restarting the CA
+// with a new responder configured. Responses are refused once the responder
+// certificate would expire before them.
+type delegatedOCSPSigner struct {
+	responder    *x509.Certificate
+	signer       ocsp.Signer
+	lifespanOCSP time.Duration
+	clk          clock.Clock
+}
+
+// Sign signs req with the responder certificate if it outlasts the response.
+func (d *delegatedOCSPSigner) Sign(req ocsp.SignRequest) ([]byte, error) {
+	if d.clk.Now().Add(d.lifespanOCSP).After(d.responder.NotAfter) {
+		return nil, fmt.Errorf("OCSP responder certificate %s expires before responses signed now",
+			core.SerialToString(d.responder.SerialNumber))
+	}
+	return d.signer.Sign(req)
+}
+
+// checkResponder returns an error if iss's responder certificate wasn't
+// issued by it for OCSP signing, doesn't match the responder key, or
+// doesn't outlast the responses it would sign at now.
+func checkResponder(iss Issuer, lifespanOCSP time.Duration, now time.Time) error {
+	if iss.OCSPCert == nil || iss.OCSPSigner == nil {
+		return errors.New("OCSP responder needs both a certificate and a key")
+	}
+	if err := iss.OCSPCert.CheckSignatureFrom(iss.Cert); err != nil {
+		return fmt.Errorf("OCSP responder certificate wasn't issued by the issuer: %s", err)
+	}
+	var ocspSigning bool
+	for _, eku := range iss.OCSPCert.ExtKeyUsage {
+		ocspSigning = ocspSigning || eku == x509.ExtKeyUsageOCSPSigning
+	}
+	if !ocspSigning {
+		return errors.New("OCSP responder certificate doesn't have the OCSP signing extended key usage")
+	}
+	if !core.KeyDigestEquals(iss.OCSPSigner.Public(), iss.OCSPCert.PublicKey) {
+		return errors.New("OCSP responder key doesn't match its certificate")
+	}
+	if now.Add(lifespanOCSP).After(iss.OCSPCert.NotAfter) {
+		return fmt.Errorf("OCSP responder certificate expires at %s, before responses signed now", iss.OCSPCert.NotAfter)
+	}
+	return nil
+}
+
+// setupDelegatedOCSP replaces the OCSP signer of each issuer that has a
+// delegated responder with a delegatedOCSPSigner, so that the issuer key isn't
+// used for OCSP. The expiry of each responder certificate is exported so that
+// rotating it can be alerted on.
+func (ca *CertificateAuthorityImpl) setupDelegatedOCSP(lifespanOCSP time.Duration, issuers []Issuer, stats metrics.Scope) error {
+	expiry := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ocsp_responder_not_after",
+			Help: "The NotAfter time of each issuer's delegated OCSP responder certificate, in seconds since the epoch",
+		},
+		[]string{"issuer"})
+	stats.MustRegister(expiry)
+
+	for _, iss := range issuers {
+		if iss.OCSPCert == nil && iss.OCSPSigner == nil {
+			continue
+		}
+		cn := iss.Cert.Subject.CommonName
+		if err := checkResponder(iss, lifespanOCSP, ca.clk.Now()); err != nil {
+			return fmt.Errorf("issuer %q: %s", cn, err)
+		}
+		signer, err := ocsp.NewSigner(iss.Cert, iss.OCSPCert, iss.OCSPSigner, lifespanOCSP)
+		if err != nil {
+			return fmt.Errorf("issuer %q: %s", cn, err)
+		}
+		ca.issuers[cn].ocspSigner = &delegatedOCSPSigner{
+			responder:    iss.OCSPCert,
+			signer:       signer,
+			lifespanOCSP: lifespanOCSP,
+			clk:          ca.clk,
+		}
+		expiry.With(prometheus.Labels{"issuer": cn}).Set(float64(iss.OCSPCert.NotAfter.Unix()))
+		ca.log.Info(fmt.Sprintf("Signing OCSP for %q with responder certificate %s, which expires at %s",
+			cn, core.SerialToString(iss.OCSPCert.SerialNumber), iss.OCSPCert.NotAfter))
+	}
+	return nil
+}
@@ -42,6 +42,8 @@ type IssueCertificateRequest struct {
 	Csr              []byte `protobuf:"bytes,1,opt,name=csr" json:"csr,omitempty"`
 	RegistrationID   *int64 `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"`
 	OrderID          *int64 `protobuf:"varint,3,opt,name=orderID" json:"orderID,omitempty"`
+	ShortLived       *bool  `protobuf:"varint,4,opt,name=shortLived" json:"shortLived,omitempty"`
+	CtContingency    *bool  `protobuf:"varint,5,opt,name=ctContingency" json:"ctContingency,omitempty"`
 	XXX_unrecognized []byte `json:"-"`
 }
 
@@ -71,6 +73,20 @@ func (m *IssueCertificateRequest) GetOrderID() int64 {
 	return 0
 }
 
+func (m *IssueCertificateRequest) GetShortLived() bool {
+	if m != nil && m.ShortLived != nil {
+		return *m.ShortLived
+	}
+	return false
+}
+
+func (m *IssueCertificateRequest) GetCtContingency() bool {
+	if m != nil && m.CtContingency != nil {
+		return *m.CtContingency
+	}
+	return false
+}
+
 type IssuePrecertificateResponse struct {
 	DER              []byte `protobuf:"bytes,1,opt,name=DER,json=dER" json:"DER,omitempty"`
 	XXX_unrecognized []byte `json:"-"`
@@ -404,30 +420,33 @@ var _OCSPGenerator_serviceDesc = grpc.ServiceDesc{
 func init() { proto1.RegisterFile("ca/proto/ca.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 400 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x93, 0xcf, 0xaf, 0xd2, 0x40,
-	0x10, 0xc7, 0x69, 0xcb, 0x13, 0xdf, 0xa4, 0x1a, 0xde, 0x3e, 0xf5, 0x35, 0xc5, 0x44, 0xec, 0xc1,
-	0x34, 0xc6, 0x94, 0x84, 0xab, 0x27, 0x6c, 0xd1, 0x90, 0x98, 0x48, 0x16, 0xbc, 0x78, 0xdb, 0x2c,
-	0x83, 0x36, 0xc6, 0x2e, 0xce, 0x6e, 0x4d, 0x3c, 0xf8, 0x4f, 0x78, 0xf5, 0x9f, 0x35, 0x2d, 0x2d,
-	0x94, 0xa6, 0x3c, 0x6e, 0xf3, 0x83, 0xe5, 0xf3, 0x9d, 0xf9, 0x4e, 0xe1, 0x46, 0x8a, 0xc9, 0x8e,
-	0x94, 0x51, 0x13, 0x29, 0xa2, 0x32, 0x60, 0xb6, 0x14, 0xfe, 0x53, 0xa9, 0x08, 0xeb, 0x86, 0x22,
-	0xdc, 0xb7, 0x82, 0x1f, 0x70, 0xb7, 0xd0, 0x3a, 0xc7, 0x18, 0xc9, 0xa4, 0xdb, 0x54, 0x0a, 0x83,
-	0x1c, 0x7f, 0xe6, 0xa8, 0x0d, 0x1b, 0x82, 0x23, 0x35, 0x79, 0xd6, 0xd8, 0x0a, 0x5d, 0x5e, 0x84,
-	0xec, 0x15, 0x3c, 0x26, 0xfc, 0x9a, 0x6a, 0x43, 0xc2, 0xa4, 0x2a, 0x5b, 0x24, 0x9e, 0x3d, 0xb6,
-	0x42, 0x87, 0xb7, 0xaa, 0xcc, 0x83, 0x81, 0xa2, 0x0d, 0xd2, 0x22, 0xf1, 0x9c, 0xf2, 0x07, 0x75,
-	0x1a, 0x4c, 0x60, 0x54, 0xe2, 0x96, 0x84, 0xb2, 0x49, 0xd4, 0x3b, 0x95, 0x69, 0x2c, 0x90, 0xc9,
-	0x9c, 0xd7, 0xc8, 0xcd, 0x9c, 0x07, 0x7f, 0x2d, 0x08, 0xdb, 0x02, 0xdf, 0x2b, 0x6a, 0xbf, 0x3f,
-	0x28, 0x3e, 0x7d, 0xce, 0x18, 0xf4, 0x57, 0xf1, 0x5a, 0x7b, 0xf6, 0xd8, 0x09, 0x5d, 0xde, 0xd7,
-	0xf1, 0x5a, 0x77, 0x4c, 0xe1, 0x5c, 0x9a, 0xa2, 0x7f, 0x3a, 0xc5, 0x1f, 0xb8, 0xfd, 0x80, 0x19,
-	0x92, 0x30, 0xf8, 0x29, 0x5e, 0x2d, 0x6b, 0xbc, 0x07, 0x83, 0x42, 0xd4, 0x51, 0x42, 0x9d, 0xb2,
-	0x67, 0xf0, 0x40, 0x1b, 0x61, 0x72, 0x5d, 0x2e, 0xec, 0x9a, 0x57, 0x59, 0x51, 0x27, 0x14, 0x5a,
-	0x65, 0xa5, 0x84, 0x2b, 0x5e, 0x65, 0xec, 0x39, 0x5c, 0x13, 0xfe, 0x52, 0xdf, 0x71, 0x33, 0x33,
-	0x15, 0xfc, 0x58, 0x08, 0x5e, 0x83, 0xbb, 0xc7, 0x56, 0x5b, 0xf3, 0xe1, 0x21, 0x55, 0x71, 0x05,
-	0x3e, 0xe4, 0xd3, 0x7f, 0x36, 0x3c, 0x69, 0xac, 0x6e, 0x96, 0x9b, 0x6f, 0x8a, 0x52, 0xf3, 0x9b,
-	0x25, 0x30, 0x6c, 0xef, 0x95, 0x8d, 0x22, 0x29, 0xa2, 0x33, 0xe7, 0xe0, 0xdf, 0x44, 0xe5, 0xd9,
-	0x34, 0x3a, 0x41, 0x8f, 0x7d, 0x86, 0xdb, 0x0e, 0x3f, 0xef, 0xff, 0xa3, 0x17, 0x87, 0x66, 0xf7,
-	0x15, 0x04, 0x3d, 0xb6, 0x85, 0x97, 0x17, 0x4d, 0x67, 0x6f, 0xba, 0x20, 0xe7, 0x6e, 0xa3, 0x53,
-	0xfe, 0xf4, 0x23, 0x3c, 0x2a, 0x36, 0x59, 0x99, 0xa9, 0x88, 0xbd, 0x05, 0xb7, 0xe9, 0x2c, 0xbb,
-	0x2b, 0x18, 0x1d, 0x5e, 0xfb, 0xc3, 0xa2, 0xd1, 0x74, 0x21, 0xe8, 0xbd, 0x1b, 0x7c, 0xb9, 0x2a,
-	0x3f, 0xaa, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff, 0x09, 0x3d, 0x95, 0xee, 0x83, 0x03, 0x00, 0x00,
+	// 438 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x53, 0xc1, 0x6e, 0xd3, 0x40,
+	0x10, 0x8d, 0xe3, 0x84, 0xb4, 0xa3, 0x14, 0xa5, 0x53, 0xa0, 0xab, 0x14, 0x41, 0x58, 0x21, 0x64,
+	0x21, 0x94, 0x48, 0xbd, 0x72, 0x2a, 0x4e, 0x41, 0x91, 0x2a, 0x51, 0x6d, 0xcb, 0x85, 0xdb, 0x6a,
+	0x33, 0x6d, 0x2d, 0x24, 0x6f, 0x99, 0xdd, 0x54, 0xea, 0x81, 0x9f, 0xe0, 0xca, 0x67, 0xf0, 0x83,
+	0xc8, 0x5b, 0x3b, 0x75, 0x2c, 0x97, 0xde, 0x66, 0xe6, 0xed, 0xfa, 0xbd, 0x7d, 0x6f, 0x0c, 0xbb,
+	0x46, 0xcf, 0xae, 0xd9, 0x7a, 0x3b, 0x33, 0x7a, 0x1a, 0x0a, 0xec, 0x1a, 0x3d, 0x7e, 0x6e, 0x2c,
+	0x53, 0x05, 0x58, 0xa6, 0x3b, 0x48, 0xfe, 0x8d, 0x60, 0x7f, 0xe1, 0xdc, 0x8a, 0x52, 0x62, 0x9f,
+	0x5d, 0x64, 0x46, 0x7b, 0x52, 0xf4, 0x73, 0x45, 0xce, 0xe3, 0x08, 0x62, 0xe3, 0x58, 0x44, 0x93,
+	0x28, 0x19, 0xaa, 0xa2, 0xc4, 0x77, 0xf0, 0x94, 0xe9, 0x32, 0x73, 0x9e, 0xb5, 0xcf, 0x6c, 0xbe,
+	0x98, 0x8b, 0xee, 0x24, 0x4a, 0x62, 0xd5, 0x98, 0xa2, 0x80, 0x81, 0xe5, 0x25, 0xf1, 0x62, 0x2e,
+	0xe2, 0x70, 0xa0, 0x6a, 0xf1, 0x15, 0x80, 0xbb, 0xb2, 0xec, 0x4f, 0xb2, 0x1b, 0x5a, 0x8a, 0xde,
+	0x24, 0x4a, 0xb6, 0x54, 0x6d, 0x82, 0x6f, 0x61, 0xc7, 0xf8, 0xd4, 0xe6, 0x3e, 0xcb, 0x2f, 0x29,
+	0x37, 0xb7, 0xa2, 0x1f, 0x8e, 0x6c, 0x0e, 0xe5, 0x0c, 0x0e, 0x82, 0xe8, 0x53, 0x26, 0x53, 0xd7,
+	0xed, 0xae, 0x6d, 0xee, 0xa8, 0x10, 0x3e, 0x3f, 0x56, 0x95, 0xf0, 0xe5, 0xb1, 0x92, 0xbf, 0x23,
+	0x48, 0x9a, 0xcf, 0xfc, 0x6c, 0xb9, 0x79, 0x7f, 0xfd, 0xee, 0xcd, 0xeb, 0x88, 0xd0, 0x3b, 0x4b,
+	0xcf, 0x9d, 0xe8, 0x4e, 0xe2, 0x64, 0xa8, 0x7a, 0x2e, 0x3d, 0x77, 0x2d, 0x5e, 0xc4, 0x8f, 0x79,
+	0xd1, 0xdb, 0xf0, 0x42, 0xfe, 0x82, 0xbd, 0x2f, 0x94, 0x13, 0x6b, 0x4f, 0x5f, 0xd3, 0xb3, 0xd3,
+	0x8a, 0x5e, 0xc0, 0xa0, 0x10, 0x75, 0x2f, 0xa1, 0x6a, 0xf1, 0x05, 0x3c, 0x71, 0x5e, 0xfb, 0x95,
+	0x0b, 0xb6, 0x6f, 0xab, 0xb2, 0x2b, 0xe6, 0x4c, 0xda, 0xd9, 0x3c, 0x48, 0xe8, 0xab, 0xb2, 0xc3,
+	0x97, 0xb0, 0xcd, 0x74, 0x63, 0x7f, 0xd0, 0xf2, 0xc8, 0x97, 0xe4, 0xf7, 0x03, 0xf9, 0x1e, 0x86,
+	0x77, 0xb4, 0xa5, 0x6b, 0x63, 0xd8, 0xe2, 0xb2, 0x2e, 0x89, 0xd7, 0xfd, 0xe1, 0x9f, 0x2e, 0x3c,
+	0xab, 0x59, 0x77, 0xb4, 0xf2, 0x57, 0x96, 0x33, 0x7f, 0x8b, 0x73, 0x18, 0x35, 0x7d, 0xc5, 0x83,
+	0xa9, 0xd1, 0xd3, 0x07, 0x96, 0x6a, 0xbc, 0x3b, 0x0d, 0xdb, 0x57, 0x43, 0x64, 0x07, 0xbf, 0xc1,
+	0x5e, 0x4b, 0x9e, 0xff, 0xff, 0xd0, 0xeb, 0x35, 0xd8, 0xbe, 0x05, 0xb2, 0x83, 0x17, 0xf0, 0xe6,
+	0xd1, 0xd0, 0xf1, 0x43, 0x1b, 0xc9, 0x43, 0xbb, 0xd1, 0x2a, 0xff, 0xf0, 0x04, 0x76, 0x0a, 0x27,
+	0xcb, 0x30, 0x2d, 0xe3, 0x47, 0x18, 0xd6, 0x93, 0xc5, 0xfd, 0x82, 0xa3, 0x25, 0xeb, 0xf1, 0xa8,
+	0x00, 0xea, 0x29, 0xc8, 0xce, 0xa7, 0xc1, 0xf7, 0x7e, 0xf8, 0x37, 0xff, 0x0d, 0x00, 0x1d, 0x95,
+	0x3c, 0x1d, 0xca, 0x03, 0x00, 0x00,
 }
@@ -0,0 +1,41 @@
+package ca
+
+import (
+	"encoding/hex"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// SigningRecordMessage is the message of the audit log entry written for each
+// signature made by the CA.
+const SigningRecordMessage = "Signing record"
+
+// SigningRecord describes a single signing operation. One is audit logged
+// with each signature made with an issuer key, so that the log can be
+// reconciled against the certificates stored by the SA. The CA doesn't sign
+// CRLs, so there are no records for them. Like any other log line, a record
+// is only protected against corruption by the line's checksum; it isn't
+// authenticated.
+type SigningRecord struct {
+	// Purpose is one of the signatureCount purposes: "precertificate",
+	// "certificate" or "ocsp".
+	Purpose string `json:"purpose"`
+	Serial  string `json:"serial"`
+	// NamesHash is the hex encoded core.HashNames of the DNS names in the
+	// signed certificate, or in the certificate an OCSP response is for.
+	NamesHash string `json:"namesHash,omitempty"`
+	Profile   string `json:"profile,omitempty"`
+	Issuer    string `json:"issuer"`
+	// Caller is the name of the component that requested the signature, as
+	// given by the SAN of its client certificate.
+	Caller string `json:"caller,omitempty"`
+}
+
+func namesHash(names []string) string {
+	return hex.EncodeToString(core.HashNames(names))
+}
+
+// logSigningRecord audit logs rec.
+func (ca *CertificateAuthorityImpl) logSigningRecord(rec SigningRecord) {
+	ca.log.AuditObject(SigningRecordMessage, rec)
+}
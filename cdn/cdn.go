@@ -0,0 +1,70 @@
+// Package cdn purges OCSP responses cached by a CDN, so that revocations are
+// seen by clients without waiting for the cached responses to expire. Each
+// supported CDN has a Purger, and a PurgeQueue batches the URLs to purge.
+package cdn
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Purger removes URLs from a CDN's cache.
+type Purger interface {
+	// Purge purges urls, returning an error if any of them may still be
+	// cached. Errors with a Fatal method returning true mean that retrying
+	// won't help.
+	Purge(urls []string) error
+}
+
+// ErrFatal is returned by a Purger when a purge failed in a way that retrying
+// won't fix, for instance because its credentials were refused.
+type ErrFatal string
+
+func (e ErrFatal) Error() string { return string(e) }
+
+// Fatal implements the fatal interface.
+func (e ErrFatal) Fatal() bool { return true }
+
+type fatal interface {
+	Fatal() bool
+}
+
+// isFatal returns true if err marks itself as fatal.
+func isFatal(err error) bool {
+	f, ok := err.(fatal)
+	return ok && f.Fatal()
+}
+
+// requestTimeout bounds each HTTP request made by the Purgers in this package.
+const requestTimeout = 30 * time.Second
+
+// maxErrorBody is the most of an error response's body kept in errors.
+const maxErrorBody = 1024
+
+// do sends req with client, returning the body of a successful (2xx) response.
+// Unsuccessful responses give an error, which is fatal for client errors other
+// than rate limiting.
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return body, nil
+	}
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	err = fmt.Errorf("purge request to %s failed with HTTP status %d: %s", req.URL.Host, resp.StatusCode, body)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return nil, ErrFatal(err.Error())
+	}
+	return nil, err
+}
@@ -0,0 +1,100 @@
+package cdn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestFastlyPurger(t *testing.T) {
+	var purged []string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		test.AssertEquals(t, r.Method, "PURGE")
+		test.AssertEquals(t, r.Header.Get("Fastly-Key"), "key")
+		purged = append(purged, r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	fp := NewFastlyPurger("key")
+	err := fp.Purge([]string{srv.URL + "/a", srv.URL + "/b"})
+	test.AssertNotError(t, err, "Purge failed")
+	test.AssertDeepEquals(t, purged, []string{"/a", "/b"})
+
+	status = http.StatusServiceUnavailable
+	err = fp.Purge([]string{srv.URL + "/a"})
+	test.AssertError(t, err, "Purge didn't fail")
+	test.Assert(t, !isFatal(err), "Server error was fatal")
+
+	status = http.StatusUnauthorized
+	err = fp.Purge([]string{srv.URL + "/a"})
+	test.Assert(t, isFatal(err), "Unauthorized error wasn't fatal")
+}
+
+func TestCloudflarePurger(t *testing.T) {
+	var batches [][]string
+	success := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		test.AssertEquals(t, r.Method, "POST")
+		test.AssertEquals(t, r.URL.Path, "/zones/zone/purge_cache")
+		test.AssertEquals(t, r.Header.Get("Authorization"), "Bearer token")
+		var req cloudflarePurgeRequest
+		body, _ := ioutil.ReadAll(r.Body)
+		err := json.Unmarshal(body, &req)
+		test.AssertNotError(t, err, "Bad purge request")
+		batches = append(batches, req.Files)
+		fmt.Fprintf(w, `{"success":%t,"errors":[]}`, success)
+	}))
+	defer srv.Close()
+
+	cp := NewCloudflarePurger("zone", "token")
+	cp.apiBase = srv.URL
+
+	// Requests are split up to Cloudflare's limit
+	var urls []string
+	for i := 0; i < cloudflareMaxFiles+1; i++ {
+		urls = append(urls, fmt.Sprintf("http://ocsp.example.com/%d", i))
+	}
+	err := cp.Purge(urls)
+	test.AssertNotError(t, err, "Purge failed")
+	test.AssertEquals(t, len(batches), 2)
+	test.AssertDeepEquals(t, batches[0], urls[:cloudflareMaxFiles])
+	test.AssertDeepEquals(t, batches[1], urls[cloudflareMaxFiles:])
+
+	success = false
+	err = cp.Purge(urls[:1])
+	test.AssertError(t, err, "Purge didn't fail")
+}
+
+func TestWebhookPurger(t *testing.T) {
+	var purged []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		test.AssertEquals(t, r.Header.Get("Content-Type"), "application/json")
+		var req webhookRequest
+		body, _ := ioutil.ReadAll(r.Body)
+		err := json.Unmarshal(body, &req)
+		test.AssertNotError(t, err, "Bad purge request")
+		purged = req.URLs
+		if len(req.URLs) > 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	wp := NewWebhookPurger(srv.URL)
+	err := wp.Purge([]string{"a"})
+	test.AssertNotError(t, err, "Purge failed")
+	test.AssertDeepEquals(t, purged, []string{"a"})
+
+	err = wp.Purge([]string{"a", "b"})
+	test.AssertError(t, err, "Purge didn't fail")
+	test.Assert(t, !isFatal(err), "Rate limiting was fatal")
+}
@@ -0,0 +1,94 @@
+package cdn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	cloudflareAPIBase = "https://api.cloudflare.com/client/v4"
+	// cloudflareMaxFiles is the most URLs Cloudflare accepts in one purge
+	// request.
+	cloudflareMaxFiles = 30
+)
+
+type cloudflarePurgeRequest struct {
+	Files []string `json:"files"`
+}
+
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// CloudflarePurger purges URLs from the cache of a Cloudflare zone using the
+// Cloudflare API.
+type CloudflarePurger struct {
+	client   *http.Client
+	apiBase  string
+	zoneID   string
+	apiToken string
+}
+
+// NewCloudflarePurger creates a CloudflarePurger for the zone with the given
+// ID. The apiToken must have the Zone Cache Purge permission.
+func NewCloudflarePurger(zoneID, apiToken string) *CloudflarePurger {
+	return &CloudflarePurger{
+		client:   &http.Client{Timeout: requestTimeout},
+		apiBase:  cloudflareAPIBase,
+		zoneID:   zoneID,
+		apiToken: apiToken,
+	}
+}
+
+// Purge implements Purger.
+func (cp *CloudflarePurger) Purge(urls []string) error {
+	for len(urls) > 0 {
+		n := len(urls)
+		if n > cloudflareMaxFiles {
+			n = cloudflareMaxFiles
+		}
+		err := cp.purge(urls[:n])
+		if err != nil {
+			return err
+		}
+		urls = urls[n:]
+	}
+	return nil
+}
+
+func (cp *CloudflarePurger) purge(urls []string) error {
+	reqJSON, err := json.Marshal(cloudflarePurgeRequest{Files: urls})
+	if err != nil {
+		return ErrFatal(err.Error())
+	}
+	req, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("%s/zones/%s/purge_cache", cp.apiBase, cp.zoneID),
+		bytes.NewReader(reqJSON),
+	)
+	if err != nil {
+		return ErrFatal(err.Error())
+	}
+	req.Header.Set("Authorization", "Bearer "+cp.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := do(cp.client, req)
+	if err != nil {
+		return err
+	}
+	var resp cloudflareResponse
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("Cloudflare purge failed: %v", resp.Errors)
+	}
+	return nil
+}
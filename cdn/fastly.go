@@ -0,0 +1,39 @@
+package cdn
+
+import (
+	"net/http"
+)
+
+// FastlyPurger purges URLs from Fastly's cache, sending a PURGE request for
+// each URL to the URL itself.
+type FastlyPurger struct {
+	client *http.Client
+	apiKey string
+}
+
+// NewFastlyPurger creates a FastlyPurger. The apiKey is only needed if the
+// Fastly service requires purges to be authenticated.
+func NewFastlyPurger(apiKey string) *FastlyPurger {
+	return &FastlyPurger{
+		client: &http.Client{Timeout: requestTimeout},
+		apiKey: apiKey,
+	}
+}
+
+// Purge implements Purger.
+func (fp *FastlyPurger) Purge(urls []string) error {
+	for _, url := range urls {
+		req, err := http.NewRequest("PURGE", url, nil)
+		if err != nil {
+			return ErrFatal(err.Error())
+		}
+		if fp.apiKey != "" {
+			req.Header.Set("Fastly-Key", fp.apiKey)
+		}
+		_, err = do(fp.client, req)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
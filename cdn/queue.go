@@ -0,0 +1,115 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+// PurgeQueue collects the URLs to be purged so that they can be sent to the
+// CDN in batches, rather than with one request per certificate. A
+// URL that is already queued isn't queued again. It is safe for concurrent
+// use.
+type PurgeQueue struct {
+	client Purger
+	log    blog.Logger
+
+	sync.Mutex
+	pending []string
+	queued  map[string]bool
+
+	queueDepth  prometheus.Gauge
+	purgedURLs  prometheus.Counter
+	failedPurge prometheus.Counter
+}
+
+// NewPurgeQueue creates a PurgeQueue that purges URLs with client.
+func NewPurgeQueue(client Purger, log blog.Logger, stats metrics.Scope) *PurgeQueue {
+	queueDepth := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "cdn_purge_queue_depth",
+		Help: "Number of URLs waiting to be purged from the CDN cache",
+	})
+	purgedURLs := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "cdn_purged_urls",
+		Help: "Number of URLs successfully purged from the CDN cache",
+	})
+	failedPurge := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "cdn_failed_purge_batches",
+		Help: "Number of batches of URLs that could not be purged from the CDN cache",
+	})
+	stats.MustRegister(queueDepth, purgedURLs, failedPurge)
+	return &PurgeQueue{
+		client:      client,
+		log:         log,
+		queued:      make(map[string]bool),
+		queueDepth:  queueDepth,
+		purgedURLs:  purgedURLs,
+		failedPurge: failedPurge,
+	}
+}
+
+// Add queues urls to be purged by a later call to PurgeBatch.
+func (q *PurgeQueue) Add(urls []string) {
+	q.Lock()
+	defer q.Unlock()
+	for _, url := range urls {
+		if q.queued[url] {
+			continue
+		}
+		q.queued[url] = true
+		q.pending = append(q.pending, url)
+	}
+	q.queueDepth.Set(float64(len(q.pending)))
+}
+
+// PurgeBatch purges up to batchSize of the queued URLs, oldest first. If the
+// purge fails with a retryable error the URLs are returned to the front of the
+// queue, and the error is returned so that the caller can back off before
+// trying again. URLs that can never be purged are dropped.
+func (q *PurgeQueue) PurgeBatch(_ context.Context, batchSize int) error {
+	q.Lock()
+	n := len(q.pending)
+	if n > batchSize {
+		n = batchSize
+	}
+	batch := q.pending[:n:n]
+	q.pending = q.pending[n:]
+	// URLs added again while the batch is being purged are queued again, as
+	// the purge may not cover responses cached after it was sent.
+	for _, url := range batch {
+		delete(q.queued, url)
+	}
+	q.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := q.client.Purge(batch)
+
+	q.Lock()
+	defer q.Unlock()
+	defer func() { q.queueDepth.Set(float64(len(q.pending))) }()
+	if err != nil {
+		q.failedPurge.Inc()
+		if isFatal(err) {
+			q.log.AuditErr(fmt.Sprintf("Dropping %d URLs that can't be purged: %s", len(batch), err))
+			return nil
+		}
+		var requeue []string
+		for _, url := range batch {
+			if !q.queued[url] {
+				q.queued[url] = true
+				requeue = append(requeue, url)
+			}
+		}
+		q.pending = append(requeue, q.pending...)
+		return err
+	}
+	q.purgedURLs.Add(float64(len(batch)))
+	return nil
+}
@@ -0,0 +1,82 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type mockPurger struct {
+	err     error
+	batches [][]string
+}
+
+func (mp *mockPurger) Purge(urls []string) error {
+	mp.batches = append(mp.batches, urls)
+	return mp.err
+}
+
+func TestPurgeQueue(t *testing.T) {
+	mp := &mockPurger{}
+	q := NewPurgeQueue(mp, blog.NewMock(), metrics.NewNoopScope())
+	ctx := context.Background()
+
+	// Nothing is sent for an empty queue
+	err := q.PurgeBatch(ctx, 2)
+	test.AssertNotError(t, err, "PurgeBatch failed")
+	test.AssertEquals(t, len(mp.batches), 0)
+
+	// Duplicates are only queued once
+	q.Add([]string{"a", "b"})
+	q.Add([]string{"b", "c"})
+	test.AssertEquals(t, test.GaugeValue(q.queueDepth), float64(3))
+
+	err = q.PurgeBatch(ctx, 2)
+	test.AssertNotError(t, err, "PurgeBatch failed")
+	test.AssertDeepEquals(t, mp.batches, [][]string{{"a", "b"}})
+	test.AssertEquals(t, test.GaugeValue(q.queueDepth), float64(1))
+
+	// A purged URL can be queued again
+	q.Add([]string{"a"})
+	err = q.PurgeBatch(ctx, 2)
+	test.AssertNotError(t, err, "PurgeBatch failed")
+	test.AssertDeepEquals(t, mp.batches[1], []string{"c", "a"})
+	test.AssertEquals(t, test.CountCounter(q.purgedURLs), 4)
+	test.AssertEquals(t, test.GaugeValue(q.queueDepth), float64(0))
+}
+
+func TestPurgeQueueFailures(t *testing.T) {
+	mp := &mockPurger{err: errors.New("retryable")}
+	q := NewPurgeQueue(mp, blog.NewMock(), metrics.NewNoopScope())
+	ctx := context.Background()
+
+	// A batch that fails with a retryable error is returned to the front of
+	// the queue
+	q.Add([]string{"a", "b", "c"})
+	err := q.PurgeBatch(ctx, 2)
+	test.AssertError(t, err, "PurgeBatch didn't fail")
+	test.AssertEquals(t, test.GaugeValue(q.queueDepth), float64(3))
+	test.AssertEquals(t, test.CountCounter(q.failedPurge), 1)
+
+	mp.err = nil
+	err = q.PurgeBatch(ctx, 2)
+	test.AssertNotError(t, err, "PurgeBatch failed")
+	test.AssertDeepEquals(t, mp.batches[1], []string{"a", "b"})
+
+	// One that can never succeed is dropped
+	mp.err = ErrFatal("forbidden")
+	err = q.PurgeBatch(ctx, 2)
+	test.AssertNotError(t, err, "PurgeBatch returned a fatal error")
+	test.AssertEquals(t, test.GaugeValue(q.queueDepth), float64(0))
+
+	// Other errors are retryable
+	mp.err = errors.New("oops")
+	q.Add([]string{"d"})
+	err = q.PurgeBatch(ctx, 2)
+	test.AssertError(t, err, "PurgeBatch didn't fail")
+	test.AssertEquals(t, test.GaugeValue(q.queueDepth), float64(1))
+}
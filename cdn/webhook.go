@@ -0,0 +1,43 @@
+package cdn
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+type webhookRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// WebhookPurger asks an HTTP endpoint run by the deployment to purge URLs, for
+// CDNs without a Purger of their own. Each purge is a POST of a JSON object
+// whose "urls" field lists the URLs, and succeeds if a 2xx status is returned.
+// Other 4xx statuses than 429 are treated as fatal.
+type WebhookPurger struct {
+	client *http.Client
+	url    string
+}
+
+// NewWebhookPurger creates a WebhookPurger that POSTs to url.
+func NewWebhookPurger(url string) *WebhookPurger {
+	return &WebhookPurger{
+		client: &http.Client{Timeout: requestTimeout},
+		url:    url,
+	}
+}
+
+// Purge implements Purger.
+func (wp *WebhookPurger) Purge(urls []string) error {
+	reqJSON, err := json.Marshal(webhookRequest{URLs: urls})
+	if err != nil {
+		return ErrFatal(err.Error())
+	}
+	req, err := http.NewRequest("POST", wp.url, bytes.NewReader(reqJSON))
+	if err != nil {
+		return ErrFatal(err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	_, err = do(wp.client, req)
+	return err
+}
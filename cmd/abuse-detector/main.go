@@ -0,0 +1,458 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/go-gorp/gorp.v2"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/features"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/psl"
+	"github.com/letsencrypt/boulder/ratelimit"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+// adminName is recorded as the addedBy of the rate limit overrides the
+// abuse-detector adds.
+const adminName = "abuse-detector"
+
+// maxIssuanceWindow bounds the issuance window, and so the number of
+// certificates each issuance check scans.
+const maxIssuanceWindow = 7 * 24 * time.Hour
+
+type config struct {
+	AbuseDetector struct {
+		cmd.DBConfig
+		// ReadReplica, if set, is a read-only database replica that the
+		// checks query instead of the primary, which is then only used to add
+		// rate limit overrides.
+		ReadReplica *cmd.DBConfig
+		DebugAddr   string
+
+		// Interval is how long to wait between analyses. Defaults to five
+		// minutes.
+		Interval cmd.ConfigDuration
+
+		// Issuance alerts about accounts that were issued certificates for
+		// at least MaxRegisteredDomains distinct registered domains (eTLD+1s)
+		// in the last Window. Defaults to 1000 domains in 24 hours. The Window
+		// can be at most 7 days.
+		Issuance struct {
+			Window               cmd.ConfigDuration
+			MaxRegisteredDomains int
+		}
+
+		// ValidationFailures alerts about hostnames whose validations failed
+		// against at least MaxIPs distinct IP addresses in the last Window.
+		// Defaults to 10 IP addresses in one hour. Requires the
+		// FailedValidationsTable feature and the
+		// AddFailedValidationsAddressUsed migration.
+		ValidationFailures struct {
+			Window cmd.ConfigDuration
+			MaxIPs int
+		}
+
+		// Tighten, if it has any Limits, adds a rate limit override with the
+		// given threshold for each named limit to every account alerted
+		// about, lasting Duration (default 24 hours). Accounts which already
+		// have an override of a limit, e.g. granted by hand, keep it.
+		Tighten struct {
+			Limits   map[string]int
+			Duration cmd.ConfigDuration
+		}
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+// failingHostname is a hostname whose validations failed against many IP
+// addresses.
+type failingHostname struct {
+	Hostname string
+	IPs      int64
+}
+
+// store is the database access needed by the abuse-detector.
+type store interface {
+	// heavyIssuers returns the registrations issued certificates for at
+	// least minNames distinct names since the given time. Since every
+	// registered domain has at least one name, these include every
+	// registration issued certificates for minNames registered domains.
+	heavyIssuers(since time.Time, minNames int) ([]int64, error)
+	issuedNames(regID int64, since time.Time) ([]string, error)
+	failingHostnames(since time.Time, minIPs int) ([]failingHostname, error)
+	failingRegistrations(hostname string, since time.Time) ([]int64, error)
+	// addOverride adds a rate limit override unless the registration already
+	// has an unexpired one of the limit, returning whether it was added.
+	addOverride(regID int64, limitName string, threshold int, now, expires time.Time, comment string) (bool, error)
+}
+
+// dbStore runs the checks' queries on replica, which may be the primary
+// dbMap, and adds overrides with dbMap.
+type dbStore struct {
+	dbMap   *gorp.DbMap
+	replica *gorp.DbMap
+}
+
+func (s dbStore) heavyIssuers(since time.Time, minNames int) ([]int64, error) {
+	var regIDs []int64
+	_, err := s.replica.Select(&regIDs,
+		`SELECT c.registrationID FROM certificates AS c
+		JOIN issuedNames AS n ON n.serial = c.serial
+		WHERE c.issued >= :since
+		GROUP BY c.registrationID
+		HAVING COUNT(DISTINCT n.reversedName) >= :minNames`,
+		map[string]interface{}{"since": since, "minNames": minNames})
+	return regIDs, err
+}
+
+func (s dbStore) issuedNames(regID int64, since time.Time) ([]string, error) {
+	var reversedNames []string
+	_, err := s.replica.Select(&reversedNames,
+		`SELECT DISTINCT n.reversedName FROM certificates AS c
+		JOIN issuedNames AS n ON n.serial = c.serial
+		WHERE c.registrationID = :regID AND c.issued >= :since`,
+		map[string]interface{}{"regID": regID, "since": since})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(reversedNames))
+	for i, reversed := range reversedNames {
+		names[i] = sa.ReverseName(reversed)
+	}
+	return names, nil
+}
+
+func (s dbStore) failingHostnames(since time.Time, minIPs int) ([]failingHostname, error) {
+	var hostnames []failingHostname
+	_, err := s.replica.Select(&hostnames,
+		`SELECT hostname, COUNT(DISTINCT addressUsed) AS ips FROM failedValidations
+		WHERE attempted >= :since
+		GROUP BY hostname
+		HAVING COUNT(DISTINCT addressUsed) >= :minIPs`,
+		map[string]interface{}{"since": since, "minIPs": minIPs})
+	return hostnames, err
+}
+
+func (s dbStore) failingRegistrations(hostname string, since time.Time) ([]int64, error) {
+	var regIDs []int64
+	_, err := s.replica.Select(&regIDs,
+		`SELECT DISTINCT registrationID FROM failedValidations
+		WHERE hostname = :hostname AND attempted >= :since`,
+		map[string]interface{}{"hostname": hostname, "since": since})
+	return regIDs, err
+}
+
+func (s dbStore) addOverride(regID int64, limitName string, threshold int, now, expires time.Time, comment string) (bool, error) {
+	// An existing override is only replaced once it has expired. MySQL
+	// applies the assignments in order, so expires is assigned last for the
+	// others to see its old value. It reports two affected rows for a
+	// replaced override and none for one left untouched.
+	result, err := s.dbMap.Exec(
+		`INSERT INTO rateLimitOverrides (registrationID, limitName, threshold, expires, added, addedBy, comment)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+		threshold = IF(expires <= VALUES(added), VALUES(threshold), threshold),
+		added = IF(expires <= VALUES(added), VALUES(added), added),
+		addedBy = IF(expires <= VALUES(added), VALUES(addedBy), addedBy),
+		comment = IF(expires <= VALUES(added), VALUES(comment), comment),
+		expires = IF(expires <= VALUES(added), VALUES(expires), expires)`,
+		regID, limitName, threshold, expires, now, adminName, comment)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// registeredDomains returns the number of distinct registered domains among
+// names. Names that are themselves public suffixes count as their own
+// registered domain.
+func registeredDomains(names []string) int {
+	domains := make(map[string]bool)
+	for _, name := range names {
+		domain, err := psl.Domain(name)
+		if err != nil {
+			domain = name
+		}
+		domains[domain] = true
+	}
+	return len(domains)
+}
+
+// detector looks for patterns of issuance and validation that suggest abuse,
+// alerting about them and optionally tightening the rate limits of the
+// accounts involved.
+type detector struct {
+	store store
+	clk   clock.Clock
+	log   blog.Logger
+
+	issuanceWindow       time.Duration
+	maxRegisteredDomains int
+	failureWindow        time.Duration
+	maxIPs               int
+	tighten              map[string]int
+	tightenFor           time.Duration
+
+	// alerted holds when each account or hostname was last alerted about, so
+	// the same activity isn't alerted about on every run. Entries are pruned
+	// once they no longer suppress alerts.
+	alerted map[string]time.Time
+
+	alerts         *prometheus.CounterVec
+	overridesAdded prometheus.Counter
+}
+
+func newDetector(s store, clk clock.Clock, logger blog.Logger, stats metrics.Scope) *detector {
+	alerts := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "abuse_alerts",
+		Help: "A counter of abuse alerts, by kind",
+	}, []string{"kind"})
+	stats.MustRegister(alerts)
+	overridesAdded := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "abuse_overrides_added",
+		Help: "A counter of rate limit overrides added to tighten the limits of alerted accounts",
+	})
+	stats.MustRegister(overridesAdded)
+	return &detector{
+		store:                s,
+		clk:                  clk,
+		log:                  logger,
+		issuanceWindow:       24 * time.Hour,
+		maxRegisteredDomains: 1000,
+		failureWindow:        time.Hour,
+		maxIPs:               10,
+		tightenFor:           24 * time.Hour,
+		alerted:              make(map[string]time.Time),
+		alerts:               alerts,
+		overridesAdded:       overridesAdded,
+	}
+}
+
+// invoke runs each check once.
+func (d *detector) invoke() error {
+	d.pruneAlerted()
+	if err := d.checkIssuance(); err != nil {
+		return fmt.Errorf("checking issuance: %s", err)
+	}
+	if !features.Enabled(features.FailedValidationsTable) {
+		return nil
+	}
+	if err := d.checkValidationFailures(); err != nil {
+		return fmt.Errorf("checking validation failures: %s", err)
+	}
+	return nil
+}
+
+func (d *detector) checkIssuance() error {
+	since := d.clk.Now().Add(-d.issuanceWindow)
+	regIDs, err := d.store.heavyIssuers(since, d.maxRegisteredDomains)
+	if err != nil {
+		return err
+	}
+	for _, regID := range regIDs {
+		names, err := d.store.issuedNames(regID, since)
+		if err != nil {
+			return err
+		}
+		domains := registeredDomains(names)
+		if domains < d.maxRegisteredDomains {
+			continue
+		}
+		d.alert("issuance", fmt.Sprintf("registration %d", regID), []int64{regID},
+			fmt.Sprintf("registration %d was issued certificates for %d names in %d registered domains in the last %s",
+				regID, len(names), domains, d.issuanceWindow))
+	}
+	return nil
+}
+
+func (d *detector) checkValidationFailures() error {
+	since := d.clk.Now().Add(-d.failureWindow)
+	hostnames, err := d.store.failingHostnames(since, d.maxIPs)
+	if err != nil {
+		return err
+	}
+	for _, h := range hostnames {
+		regIDs, err := d.store.failingRegistrations(h.Hostname, since)
+		if err != nil {
+			return err
+		}
+		sort.Slice(regIDs, func(i, j int) bool { return regIDs[i] < regIDs[j] })
+		d.alert("validation-failures", "hostname "+h.Hostname, regIDs,
+			fmt.Sprintf("hostname %s failed validation for %d registrations against %d IP addresses in the last %s",
+				h.Hostname, len(regIDs), h.IPs, d.failureWindow))
+	}
+	return nil
+}
+
+// suppressWindow is how long a subject isn't alerted about again for, the
+// longest of the check windows.
+func (d *detector) suppressWindow() time.Duration {
+	if d.failureWindow > d.issuanceWindow {
+		return d.failureWindow
+	}
+	return d.issuanceWindow
+}
+
+// pruneAlerted forgets the subjects whose alerts no longer suppress new ones.
+func (d *detector) pruneAlerted() {
+	now := d.clk.Now()
+	for key, last := range d.alerted {
+		if now.Sub(last) >= d.suppressWindow() {
+			delete(d.alerted, key)
+		}
+	}
+}
+
+// alert audit logs detail about subject, unless subject was already alerted
+// about within the suppress window, and tightens the rate limits of the
+// registrations involved.
+func (d *detector) alert(kind, subject string, regIDs []int64, detail string) {
+	now := d.clk.Now()
+	key := kind + ":" + subject
+	if last, ok := d.alerted[key]; ok && now.Sub(last) < d.suppressWindow() {
+		return
+	}
+	d.alerted[key] = now
+	d.alerts.WithLabelValues(kind).Inc()
+	d.log.AuditErr(fmt.Sprintf("Possible abuse: %s", detail))
+
+	if len(d.tighten) == 0 {
+		return
+	}
+	// Sort the limits so overrides are added in a stable order.
+	var limits []string
+	for name := range d.tighten {
+		limits = append(limits, name)
+	}
+	sort.Strings(limits)
+	comment := detail
+	if len(comment) > 255 {
+		comment = comment[:255]
+	}
+	for _, regID := range regIDs {
+		var added []string
+		for _, name := range limits {
+			ok, err := d.store.addOverride(regID, name, d.tighten[name], now, now.Add(d.tightenFor), comment)
+			if err != nil {
+				d.log.AuditErr(fmt.Sprintf("Failed to add %s override for registration %d: %s", name, regID, err))
+				continue
+			}
+			if ok {
+				added = append(added, name)
+				d.overridesAdded.Inc()
+			}
+		}
+		if len(added) > 0 {
+			d.log.AuditInfo(fmt.Sprintf("Tightened rate limits %s of registration %d for %s",
+				strings.Join(added, ", "), regID, d.tightenFor))
+		}
+	}
+}
+
+func main() {
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.AbuseDetector.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+	if c.AbuseDetector.Issuance.Window.Duration > maxIssuanceWindow {
+		cmd.FailOnError(fmt.Errorf("Issuance.Window can't be longer than %s", maxIssuanceWindow), "Invalid issuance config")
+	}
+	for name := range c.AbuseDetector.Tighten.Limits {
+		if !ratelimit.ValidLimitName(name) {
+			cmd.FailOnError(fmt.Errorf("unknown rate limit %q", name), "Invalid tighten config")
+		}
+	}
+
+	scope, logger := cmd.StatsAndLogging(c.Syslog, c.AbuseDetector.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+
+	dbURL, err := c.AbuseDetector.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbTLS, err := c.AbuseDetector.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.AbuseDetector.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.AbuseDetector.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.AbuseDetector.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
+	cmd.FailOnError(err, "Could not connect to database")
+	sa.SetSQLDebug(dbMap, logger)
+	go sa.ReportDbConnCount(dbMap, scope)
+
+	replica := dbMap
+	if replicaConf := c.AbuseDetector.ReadReplica; replicaConf != nil {
+		replicaURL, err := replicaConf.URL()
+		cmd.FailOnError(err, "Couldn't load replica DB URL")
+		replicaTLS, err := replicaConf.TLSConfig()
+		cmd.FailOnError(err, "Couldn't load DB TLS config")
+		replica, err = sa.NewDbMapWithSettings(replicaURL, sa.DbSettings{
+			MaxOpenConns:    replicaConf.MaxDBConns,
+			MaxIdleConns:    replicaConf.MaxIdleDBConns,
+			ConnMaxLifetime: replicaConf.ConnMaxLifetime.Duration,
+			TLS:             replicaTLS,
+		})
+		cmd.FailOnError(err, "Couldn't connect to replica database")
+		sa.SetSQLDebug(replica, logger)
+	}
+
+	clk := cmd.Clock()
+	d := newDetector(dbStore{dbMap: dbMap, replica: replica}, clk, logger, scope)
+	if w := c.AbuseDetector.Issuance.Window.Duration; w != 0 {
+		d.issuanceWindow = w
+	}
+	if m := c.AbuseDetector.Issuance.MaxRegisteredDomains; m != 0 {
+		d.maxRegisteredDomains = m
+	}
+	if w := c.AbuseDetector.ValidationFailures.Window.Duration; w != 0 {
+		d.failureWindow = w
+	}
+	if m := c.AbuseDetector.ValidationFailures.MaxIPs; m != 0 {
+		d.maxIPs = m
+	}
+	d.tighten = c.AbuseDetector.Tighten.Limits
+	if t := c.AbuseDetector.Tighten.Duration.Duration; t != 0 {
+		d.tightenFor = t
+	}
+	interval := c.AbuseDetector.Interval.Duration
+	if interval == 0 {
+		interval = 5 * time.Minute
+	}
+
+	go func() {
+		for {
+			err := d.invoke()
+			if err != nil {
+				logger.AuditErr(fmt.Sprintf("Failed to check for abuse: %s", err))
+			}
+			clk.Sleep(interval)
+		}
+	}()
+
+	cmd.CatchSignals(logger, nil)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/features"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type addedOverride struct {
+	regID     int64
+	limitName string
+	threshold int
+	expires   time.Time
+}
+
+type mockStore struct {
+	names     map[int64][]string
+	hostnames []failingHostname
+	failing   map[string][]int64
+	// existing holds when each registration's existing overrides expire
+	existing  map[int64]time.Time
+	overrides []addedOverride
+}
+
+func (s *mockStore) heavyIssuers(_ time.Time, minNames int) ([]int64, error) {
+	var regIDs []int64
+	for regID, names := range s.names {
+		if len(names) >= minNames {
+			regIDs = append(regIDs, regID)
+		}
+	}
+	return regIDs, nil
+}
+
+func (s *mockStore) issuedNames(regID int64, _ time.Time) ([]string, error) {
+	return s.names[regID], nil
+}
+
+func (s *mockStore) failingHostnames(_ time.Time, _ int) ([]failingHostname, error) {
+	return s.hostnames, nil
+}
+
+func (s *mockStore) failingRegistrations(hostname string, _ time.Time) ([]int64, error) {
+	return s.failing[hostname], nil
+}
+
+func (s *mockStore) addOverride(regID int64, limitName string, threshold int, now, expires time.Time, _ string) (bool, error) {
+	if existing, ok := s.existing[regID]; ok && existing.After(now) {
+		return false, nil
+	}
+	s.overrides = append(s.overrides, addedOverride{regID, limitName, threshold, expires})
+	return true, nil
+}
+
+func TestRegisteredDomains(t *testing.T) {
+	test.AssertEquals(t, registeredDomains(nil), 0)
+	test.AssertEquals(t, registeredDomains([]string{
+		"example.com", "www.example.com", "a.b.example.com",
+		"example.co.uk", "www.example.co.uk",
+		"co.uk",
+	}), 3)
+}
+
+func TestCheckIssuance(t *testing.T) {
+	fc := clock.NewFake()
+	log := blog.NewMock()
+	s := &mockStore{names: map[int64][]string{
+		// Many names in a single registered domain aren't alerted about
+		1: {"a.example.com", "b.example.com", "c.example.com"},
+		2: {"example.com", "example.net", "example.org"},
+	}}
+	d := newDetector(s, fc, log, metrics.NewNoopScope())
+	d.maxRegisteredDomains = 3
+
+	err := d.invoke()
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertEquals(t, test.CountCounter(d.alerts.WithLabelValues("issuance")), 1)
+	test.AssertEquals(t, len(log.GetAllMatching("registration 2 was issued certificates for 3 names in 3 registered domains")), 1)
+	test.AssertEquals(t, len(s.overrides), 0)
+
+	// The same account isn't alerted about again until the window has passed
+	err = d.invoke()
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertEquals(t, test.CountCounter(d.alerts.WithLabelValues("issuance")), 1)
+	fc.Add(d.issuanceWindow)
+	err = d.invoke()
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertEquals(t, test.CountCounter(d.alerts.WithLabelValues("issuance")), 2)
+}
+
+func TestCheckValidationFailures(t *testing.T) {
+	_ = features.Set(map[string]bool{"FailedValidationsTable": true})
+	defer features.Reset()
+
+	fc := clock.NewFake()
+	log := blog.NewMock()
+	s := &mockStore{
+		hostnames: []failingHostname{{Hostname: "victim.com", IPs: 12}},
+		failing:   map[string][]int64{"victim.com": {3, 1, 2}},
+		existing:  map[int64]time.Time{2: fc.Now().Add(time.Hour), 3: fc.Now().Add(-time.Hour)},
+	}
+	d := newDetector(s, fc, log, metrics.NewNoopScope())
+	d.tighten = map[string]int{"newOrdersPerAccount": 1, "failedValidationsPerAccount": 0}
+
+	err := d.invoke()
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertEquals(t, test.CountCounter(d.alerts.WithLabelValues("validation-failures")), 1)
+	test.AssertEquals(t, len(log.GetAllMatching("hostname victim.com failed validation for 3 registrations against 12 IP addresses")), 1)
+
+	// Registration 2 already has overrides, which are left alone, while
+	// registration 3's have expired and are replaced
+	expires := fc.Now().Add(24 * time.Hour)
+	test.AssertDeepEquals(t, s.overrides, []addedOverride{
+		{1, "failedValidationsPerAccount", 0, expires},
+		{1, "newOrdersPerAccount", 1, expires},
+		{3, "failedValidationsPerAccount", 0, expires},
+		{3, "newOrdersPerAccount", 1, expires},
+	})
+	test.AssertEquals(t, test.CountCounter(d.overridesAdded), 4)
+	test.AssertEquals(t, len(log.GetAllMatching("Tightened rate limits failedValidationsPerAccount, newOrdersPerAccount of registration 1")), 1)
+
+	// Without the feature validation failures aren't checked
+	features.Reset()
+	fc.Add(d.issuanceWindow)
+	err = d.invoke()
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertEquals(t, test.CountCounter(d.alerts.WithLabelValues("validation-failures")), 1)
+	// and the hostname, no longer suppressed, is forgotten
+	test.AssertEquals(t, len(d.alerted), 0)
+}
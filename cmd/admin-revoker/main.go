@@ -1,15 +1,24 @@
 package main
 
 import (
+	"bufio"
 	"crypto/x509"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/jmhodges/clock"
 	"golang.org/x/net/context"
 	"gopkg.in/go-gorp/gorp.v2"
 
@@ -32,15 +41,36 @@ admin-revoker serial-revoke --config <path> <serial> <reason-code>
 admin-revoker reg-revoke --config <path> <registration-id> <reason-code>
 admin-revoker list-reasons --config <path>
 admin-revoker auth-revoke --config <path> <domain>
+admin-revoker batch-revoke --config <path> (--serials-file <path> | --where <clause>) [--parallelism <n>] [--report <path>] <reason-code>
+admin-revoker key-revoke --config <path> [--dry-run] [--parallelism <n>] [--report <path>] <spki-hash> <reason-code>
+admin-revoker account-revoke --config <path> [--dry-run] [--parallelism <n>] [--report <path>] <registration-id> <reason-code>
 
 command descriptions:
   serial-revoke   Revoke a single certificate by the hex serial number
   reg-revoke      Revoke all certificates associated with a registration ID
   list-reasons    List all revocation reason codes
   auth-revoke     Revoke all pending/valid authorizations for a domain
+  batch-revoke    Revoke many certificates in parallel, by hex serial numbers
+                  listed one per line in a file or selected by a SQL WHERE
+                  clause on the certificates table
+  key-revoke      Revoke all unexpired certificates for the public key with
+                  the given base64 SHA-256 SPKI hash, e.g. after a key
+                  compromise
+  account-revoke  Revoke all unexpired certificates issued to a registration
+                  ID, e.g. after an account compromise
 
 args:
-  config    File path to the configuration file for this service
+  config        File path to the configuration file for this service
+  serials-file  File of serials to revoke, one per line; "-" reads stdin
+  where         SQL WHERE clause selecting the certificates to revoke, e.g.
+                "issued > '2018-03-01' AND issued < '2018-03-02'"
+  parallelism   Number of certificates revoked concurrently (default 5)
+  report        File the result for each serial is appended to as a line of
+                JSON. Serials with a final result in an existing report are
+                skipped, so an interrupted batch can be resumed by running it
+                again with the same report.
+  dry-run       List the certificates that would be revoked without revoking
+                them
 `
 
 type config struct {
@@ -72,7 +102,14 @@ func setupContext(c config) (core.RegistrationAuthority, blog.Logger, *gorp.DbMa
 
 	dbURL, err := c.Revoker.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
-	dbMap, err := sa.NewDbMap(dbURL, c.Revoker.DBConfig.MaxDBConns)
+	dbTLS, err := c.Revoker.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.Revoker.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.Revoker.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.Revoker.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
 	cmd.FailOnError(err, "Couldn't setup database connection")
 
 	saConn, err := bgrpc.ClientSetup(c.Revoker.SAService, tlsConfig, clientMetrics)
@@ -82,7 +119,7 @@ func setupContext(c config) (core.RegistrationAuthority, blog.Logger, *gorp.DbMa
 	return rac, logger, dbMap, sac
 }
 
-func revokeBySerial(ctx context.Context, serial string, reasonCode revocation.Reason, rac core.RegistrationAuthority, logger blog.Logger, tx *gorp.Transaction) (err error) {
+func revokeBySerial(ctx context.Context, serial string, reasonCode revocation.Reason, rac core.RegistrationAuthority, logger blog.Logger, tx gorp.SqlExecutor) (err error) {
 	if reasonCode < 0 || reasonCode == 7 || reasonCode > 10 {
 		panic(fmt.Sprintf("Invalid reason code: %d", reasonCode))
 	}
@@ -126,6 +163,211 @@ func revokeByReg(ctx context.Context, regID int64, reasonCode revocation.Reason,
 	return
 }
 
+// The results of revoking a certificate in a batch. All but resultFailed are
+// final: revocation isn't retried when a batch is resumed.
+const (
+	resultRevoked        = "revoked"
+	resultAlreadyRevoked = "already-revoked"
+	resultNotFound       = "not-found"
+	resultFailed         = "failed"
+)
+
+// resultWouldRevoke is the result of a dry run for a certificate that would
+// have been revoked.
+const resultWouldRevoke = "would-revoke"
+
+var (
+	errAlreadyRevoked = errors.New("certificate is already revoked")
+	errDryRun         = errors.New("certificate not revoked in a dry run")
+)
+
+// batchResult is the line of a batch revocation report for one serial.
+type batchResult struct {
+	Serial string `json:"serial"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// readSerials reads serials listed one per line, ignoring blank lines and
+// lines starting with #.
+func readSerials(r io.Reader) ([]string, error) {
+	var serials []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		serials = append(serials, line)
+	}
+	return serials, scanner.Err()
+}
+
+// readCheckpoint returns the serials with a final result in a batch
+// revocation report.
+func readCheckpoint(r io.Reader) (map[string]bool, error) {
+	done := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var result batchResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			// The last line may have been cut short if the batch was
+			// interrupted.
+			continue
+		}
+		done[result.Serial] = result.Result != resultFailed && result.Result != resultWouldRevoke
+	}
+	return done, scanner.Err()
+}
+
+// revokeUnrevoked revokes the certificate with the given serial unless it's
+// already revoked.
+func revokeUnrevoked(ctx context.Context, serial string, reasonCode revocation.Reason, rac core.RegistrationAuthority, logger blog.Logger, dbMap gorp.SqlExecutor) error {
+	status, err := sa.SelectCertificateStatus(dbMap, "WHERE serial = ?", serial)
+	if err == sql.ErrNoRows {
+		return berrors.NotFoundError("certificate with serial %q not found", serial)
+	}
+	if err != nil {
+		return err
+	}
+	if status.Status == core.OCSPStatusRevoked {
+		return errAlreadyRevoked
+	}
+	return revokeBySerial(ctx, serial, reasonCode, rac, logger, dbMap)
+}
+
+// checkUnrevoked is the dry run counterpart of revokeUnrevoked: it returns
+// errDryRun for certificates that would be revoked.
+func checkUnrevoked(serial string, dbMap gorp.SqlExecutor) error {
+	status, err := sa.SelectCertificateStatus(dbMap, "WHERE serial = ?", serial)
+	if err == sql.ErrNoRows {
+		return berrors.NotFoundError("certificate with serial %q not found", serial)
+	}
+	if err != nil {
+		return err
+	}
+	if status.Status == core.OCSPStatusRevoked {
+		return errAlreadyRevoked
+	}
+	return errDryRun
+}
+
+// serialsForKey returns the serials of the unexpired certificates for the
+// public key with the given SPKI hash.
+func serialsForKey(ctx context.Context, sac core.StorageAuthority, clk clock.Clock, keyHash string) ([]string, error) {
+	now := clk.Now().UnixNano()
+	resp, err := sac.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{
+		KeyHash:  &keyHash,
+		NotAfter: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Serials, nil
+}
+
+// serialsForAccount returns the serials of the unexpired certificates issued
+// to the given registration.
+func serialsForAccount(dbMap gorp.SqlExecutor, clk clock.Clock, regID int64) ([]string, error) {
+	var serials []string
+	_, err := dbMap.Select(&serials,
+		"SELECT serial FROM certificates WHERE registrationID = ? AND expires > ?",
+		regID, clk.Now())
+	return serials, err
+}
+
+// batchRevoke calls revoke for each of serials, using parallelism goroutines,
+// and writes the result for each to report. It returns the number of serials
+// with each result. Progress is logged every progressInterval.
+func batchRevoke(
+	ctx context.Context,
+	serials []string,
+	parallelism int,
+	revoke func(context.Context, string) error,
+	report io.Writer,
+	logger blog.Logger,
+	progressInterval time.Duration,
+) map[string]int {
+	work := make(chan string)
+	results := make(chan batchResult)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for serial := range work {
+				result := batchResult{Serial: serial, Result: resultRevoked}
+				err := revoke(ctx, serial)
+				switch {
+				case err == nil:
+				case err == errAlreadyRevoked:
+					result.Result = resultAlreadyRevoked
+				case err == errDryRun:
+					result.Result = resultWouldRevoke
+				case berrors.Is(err, berrors.NotFound):
+					result.Result = resultNotFound
+					result.Error = err.Error()
+				default:
+					result.Result = resultFailed
+					result.Error = err.Error()
+				}
+				results <- result
+			}
+		}()
+	}
+	go func() {
+		for _, serial := range serials {
+			work <- serial
+		}
+		close(work)
+		wg.Wait()
+		close(results)
+	}()
+
+	counts := make(map[string]int)
+	processed := 0
+	progress := time.NewTicker(progressInterval)
+	defer progress.Stop()
+	logProgress := func() {
+		revoked := fmt.Sprintf("%d revoked", counts[resultRevoked])
+		if counts[resultWouldRevoke] > 0 {
+			revoked = fmt.Sprintf("%d would be revoked", counts[resultWouldRevoke])
+		}
+		logger.Info(fmt.Sprintf("Processed %d of %d serials: %s, %d already revoked, %d not found, %d failed",
+			processed, len(serials), revoked, counts[resultAlreadyRevoked],
+			counts[resultNotFound], counts[resultFailed]))
+	}
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				logProgress()
+				return counts
+			}
+			processed++
+			counts[result.Result]++
+			switch result.Result {
+			case resultFailed:
+				logger.Warning(fmt.Sprintf("Failed to revoke certificate %s: %s", result.Serial, result.Error))
+			case resultWouldRevoke:
+				logger.Info(fmt.Sprintf("Would revoke certificate %s", result.Serial))
+			}
+			line, err := json.Marshal(result)
+			if err == nil {
+				_, err = fmt.Fprintf(report, "%s\n", line)
+			}
+			if err != nil {
+				logger.AuditErr(fmt.Sprintf("Writing result for %s to report: %s", result.Serial, err))
+			}
+		case <-progress.C:
+			logProgress()
+		}
+	}
+}
+
 // This abstraction is needed so that we can use sort.Sort below
 type revocationCodes []revocation.Reason
 
@@ -133,6 +375,62 @@ func (rc revocationCodes) Len() int           { return len(rc) }
 func (rc revocationCodes) Less(i, j int) bool { return rc[i] < rc[j] }
 func (rc revocationCodes) Swap(i, j int)      { rc[i], rc[j] = rc[j], rc[i] }
 
+// runBatch revokes the certificates with the given serials, or only checks
+// which would be revoked if dryRun is true, exiting if any can't be revoked.
+// If reportFile is set the results are appended to it, and serials with a
+// final result already in it are skipped.
+func runBatch(
+	ctx context.Context,
+	serials []string,
+	reason revocation.Reason,
+	parallelism int,
+	reportFile string,
+	dryRun bool,
+	rac core.RegistrationAuthority,
+	logger blog.Logger,
+	dbMap gorp.SqlExecutor,
+) {
+	report := ioutil.Discard
+	if reportFile != "" {
+		existing, err := os.Open(reportFile)
+		if err == nil {
+			done, err := readCheckpoint(existing)
+			cmd.FailOnError(err, "Couldn't read existing report")
+			_ = existing.Close()
+			var remaining []string
+			for _, serial := range serials {
+				if !done[serial] {
+					remaining = append(remaining, serial)
+				}
+			}
+			logger.Info(fmt.Sprintf("Resuming batch: %d of %d serials already done", len(serials)-len(remaining), len(serials)))
+			serials = remaining
+		} else if !os.IsNotExist(err) {
+			cmd.FailOnError(err, "Couldn't open existing report")
+		}
+		f, err := os.OpenFile(reportFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		cmd.FailOnError(err, "Couldn't open report")
+		defer func() { _ = f.Close() }()
+		report = f
+	}
+
+	revoke := func(ctx context.Context, serial string) error {
+		return revokeUnrevoked(ctx, serial, reason, rac, logger, dbMap)
+	}
+	if dryRun {
+		logger.Info(fmt.Sprintf("Dry run: checking %d certificates", len(serials)))
+		revoke = func(_ context.Context, serial string) error {
+			return checkUnrevoked(serial, dbMap)
+		}
+	} else {
+		logger.AuditInfo(fmt.Sprintf("Batch revoking %d certificates with reason '%s'", len(serials), revocation.ReasonToString[reason]))
+	}
+	counts := batchRevoke(ctx, serials, parallelism, revoke, report, logger, 10*time.Second)
+	if counts[resultFailed] > 0 {
+		cmd.FailOnError(fmt.Errorf("%d certificates", counts[resultFailed]), "Couldn't revoke some certificates")
+	}
+}
+
 func main() {
 	usage := func() {
 		fmt.Fprintf(os.Stderr, usageString)
@@ -145,6 +443,11 @@ func main() {
 	command := os.Args[1]
 	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
 	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	serialsFile := flagSet.String("serials-file", "", "File of serials to revoke with batch-revoke")
+	where := flagSet.String("where", "", "SQL WHERE clause selecting certificates to revoke with batch-revoke")
+	parallelism := flagSet.Int("parallelism", 5, "Number of certificates revoked concurrently by batch-revoke, key-revoke and account-revoke")
+	reportFile := flagSet.String("report", "", "File results are appended to by batch-revoke, key-revoke and account-revoke")
+	dryRun := flagSet.Bool("dry-run", false, "List the certificates key-revoke or account-revoke would revoke")
 	err := flagSet.Parse(os.Args[2:])
 	cmd.FailOnError(err, "Error parsing flagset")
 
@@ -233,6 +536,69 @@ func main() {
 			authsRevoked,
 		))
 
+	case command == "batch-revoke" && len(args) == 1:
+		// 1: reasonCode
+		reasonCode, err := strconv.Atoi(args[0])
+		cmd.FailOnError(err, "Reason code argument must be an integer")
+		reason := revocation.Reason(reasonCode)
+		if _, ok := revocation.ReasonToString[reason]; !ok || reason == 7 {
+			cmd.FailOnError(fmt.Errorf("invalid reason code %d", reasonCode), "Bad reason code")
+		}
+		if (*serialsFile == "") == (*where == "") {
+			usage()
+		}
+		if *parallelism < 1 {
+			cmd.FailOnError(fmt.Errorf("%d", *parallelism), "Parallelism must be at least 1")
+		}
+
+		rac, logger, dbMap, _ := setupContext(c)
+		defer logger.AuditPanic()
+
+		var serials []string
+		if *serialsFile != "" {
+			in := os.Stdin
+			if *serialsFile != "-" {
+				in, err = os.Open(*serialsFile)
+				cmd.FailOnError(err, "Couldn't open serials file")
+			}
+			serials, err = readSerials(in)
+			cmd.FailOnError(err, "Couldn't read serials")
+		} else {
+			_, err = dbMap.Select(&serials, "SELECT serial FROM certificates WHERE "+*where)
+			cmd.FailOnError(err, "Couldn't select serials")
+		}
+
+		runBatch(ctx, serials, reason, *parallelism, *reportFile, false, rac, logger, dbMap)
+
+	case (command == "key-revoke" || command == "account-revoke") && len(args) == 2:
+		// 1: SPKI hash or registration ID,  2: reasonCode
+		reasonCode, err := strconv.Atoi(args[1])
+		cmd.FailOnError(err, "Reason code argument must be an integer")
+		reason := revocation.Reason(reasonCode)
+		if _, ok := revocation.ReasonToString[reason]; !ok || reason == 7 {
+			cmd.FailOnError(fmt.Errorf("invalid reason code %d", reasonCode), "Bad reason code")
+		}
+		if *parallelism < 1 {
+			cmd.FailOnError(fmt.Errorf("%d", *parallelism), "Parallelism must be at least 1")
+		}
+
+		rac, logger, dbMap, sac := setupContext(c)
+		defer logger.AuditPanic()
+
+		var serials []string
+		if command == "key-revoke" {
+			serials, err = serialsForKey(ctx, sac, cmd.Clock(), args[0])
+			cmd.FailOnError(err, "Couldn't get serials for key")
+		} else {
+			regID, err := strconv.ParseInt(args[0], 10, 64)
+			cmd.FailOnError(err, "Registration ID argument must be an integer")
+			_, err = sac.GetRegistration(ctx, regID)
+			cmd.FailOnError(err, "Couldn't fetch registration")
+			serials, err = serialsForAccount(dbMap, cmd.Clock(), regID)
+			cmd.FailOnError(err, "Couldn't get serials for registration")
+		}
+		runBatch(ctx, serials, reason, *parallelism, *reportFile, *dryRun, rac, logger, dbMap)
+
 	default:
 		usage()
 	}
@@ -1 +1,132 @@
 package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	berrors "github.com/letsencrypt/boulder/errors"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/mocks"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestReadSerials(t *testing.T) {
+	serials, err := readSerials(strings.NewReader("# incident 1234\n00aa\n\n  00bb \n"))
+	test.AssertNotError(t, err, "readSerials failed")
+	test.AssertDeepEquals(t, serials, []string{"00aa", "00bb"})
+}
+
+func TestReadCheckpoint(t *testing.T) {
+	report := `{"serial":"00aa","result":"revoked"}
+{"serial":"00bb","result":"failed","error":"timeout"}
+{"serial":"00cc","result":"not-found"}
+{"serial":"00dd","res`
+	done, err := readCheckpoint(strings.NewReader(report))
+	test.AssertNotError(t, err, "readCheckpoint failed")
+	test.AssertDeepEquals(t, done, map[string]bool{"00aa": true, "00bb": false, "00cc": true})
+}
+
+func TestBatchRevoke(t *testing.T) {
+	var mu sync.Mutex
+	revoked := make(map[string]int)
+	revoke := func(_ context.Context, serial string) error {
+		switch serial {
+		case "00bb":
+			return errAlreadyRevoked
+		case "00cc":
+			return berrors.NotFoundError("certificate with serial %q not found", serial)
+		case "00dd":
+			return errors.New("RA unavailable")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		revoked[serial]++
+		return nil
+	}
+
+	log := blog.NewMock()
+	var report bytes.Buffer
+	serials := []string{"00aa", "00bb", "00cc", "00dd", "00ee", "00ff"}
+	counts := batchRevoke(context.Background(), serials, 3, revoke, &report, log, time.Hour)
+	test.AssertDeepEquals(t, counts, map[string]int{
+		resultRevoked:        3,
+		resultAlreadyRevoked: 1,
+		resultNotFound:       1,
+		resultFailed:         1,
+	})
+	test.AssertDeepEquals(t, revoked, map[string]int{"00aa": 1, "00ee": 1, "00ff": 1})
+	test.AssertEquals(t, len(log.GetAllMatching("Failed to revoke certificate 00dd: RA unavailable")), 1)
+	test.AssertEquals(t, len(log.GetAllMatching("Processed 6 of 6 serials: 3 revoked")), 1)
+
+	results := make(map[string]batchResult)
+	for _, line := range strings.Split(strings.TrimSpace(report.String()), "\n") {
+		var result batchResult
+		err := json.Unmarshal([]byte(line), &result)
+		test.AssertNotError(t, err, "Failed to parse report line")
+		results[result.Serial] = result
+	}
+	test.AssertEquals(t, len(results), 6)
+	test.AssertEquals(t, results["00bb"].Result, resultAlreadyRevoked)
+	test.AssertEquals(t, results["00dd"], batchResult{Serial: "00dd", Result: resultFailed, Error: "RA unavailable"})
+
+	// The report can be used to resume the batch, retrying only the failure
+	done, err := readCheckpoint(&report)
+	test.AssertNotError(t, err, "readCheckpoint failed")
+	var remaining []string
+	for _, serial := range serials {
+		if !done[serial] {
+			remaining = append(remaining, serial)
+		}
+	}
+	test.AssertDeepEquals(t, remaining, []string{"00dd"})
+}
+
+func TestBatchRevokeDryRun(t *testing.T) {
+	check := func(_ context.Context, serial string) error {
+		if serial == "00bb" {
+			return errAlreadyRevoked
+		}
+		return errDryRun
+	}
+	log := blog.NewMock()
+	var report bytes.Buffer
+	counts := batchRevoke(context.Background(), []string{"00aa", "00bb"}, 1, check, &report, log, time.Hour)
+	test.AssertDeepEquals(t, counts, map[string]int{resultWouldRevoke: 1, resultAlreadyRevoked: 1})
+	test.AssertEquals(t, len(log.GetAllMatching("Would revoke certificate 00aa")), 1)
+	test.AssertEquals(t, len(log.GetAllMatching("Processed 2 of 2 serials: 1 would be revoked, 1 already revoked")), 1)
+
+	// A dry run doesn't count as done when resuming with its report
+	done, err := readCheckpoint(&report)
+	test.AssertNotError(t, err, "readCheckpoint failed")
+	test.AssertDeepEquals(t, done, map[string]bool{"00aa": false, "00bb": true})
+}
+
+type keySerialsSA struct {
+	mocks.StorageAuthority
+	req *sapb.GetSerialsByKeyRequest
+}
+
+func (sa *keySerialsSA) GetSerialsByKey(_ context.Context, req *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error) {
+	sa.req = req
+	return &sapb.Serials{Serials: []string{"00aa", "00bb"}}, nil
+}
+
+func TestSerialsForKey(t *testing.T) {
+	fc := clock.NewFake()
+	sa := &keySerialsSA{}
+	serials, err := serialsForKey(context.Background(), sa, fc, "hash")
+	test.AssertNotError(t, err, "serialsForKey failed")
+	test.AssertDeepEquals(t, serials, []string{"00aa", "00bb"})
+	test.AssertEquals(t, *sa.req.KeyHash, "hash")
+	// Only unexpired certificates are revoked
+	test.AssertEquals(t, *sa.req.NotAfter, fc.Now().UnixNano())
+}
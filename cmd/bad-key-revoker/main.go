@@ -0,0 +1,501 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	netmail "net/mail"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+	"gopkg.in/go-gorp/gorp.v2"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
+	bmail "github.com/letsencrypt/boulder/mail"
+	"github.com/letsencrypt/boulder/metrics"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/revocation"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+const adminName = "bad-key-revoker"
+
+const defaultEmailSubject = "Certificates for a compromised key have been revoked"
+
+const defaultEmailTemplate = `Hello,
+
+The public key of the following certificates issued to your account has been
+blocked because it is known to be compromised, and the certificates have been
+revoked:
+{{range .}}
+  {{.Serial}} ({{join .Names ", "}})
+{{- end}}
+
+Please replace them with certificates for a newly generated key.
+`
+
+type config struct {
+	BadKeyRevoker struct {
+		cmd.DBConfig
+		DebugAddr string
+
+		TLS       cmd.TLSConfig
+		RAService *cmd.GRPCClientConfig
+
+		Mailer struct {
+			cmd.SMTPConfig
+			// Path to a file containing a list of trusted root certificates
+			// for use during the SMTP connection.
+			SMTPTrustedRootFile string
+
+			From string
+			// EmailSubject and EmailTemplate, a path to a text/template
+			// executed with the revoked certificates, have defaults.
+			EmailSubject  string
+			EmailTemplate string
+		}
+
+		// Interval is how long to wait before looking for newly blocked keys
+		// once there are none left to process. Defaults to one minute.
+		Interval cmd.ConfigDuration
+		// RevocationInterval is how long to wait between revocations, to
+		// limit the load on the RA and CA when a key has many certificates.
+		RevocationInterval cmd.ConfigDuration
+		// RetryInterval is how long to wait before retrying a key whose
+		// certificates couldn't all be revoked. It doubles with each failed
+		// attempt, up to a day. Defaults to ten minutes.
+		RetryInterval cmd.ConfigDuration
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+// blockedKey is a row of the blockedKeys table whose extant certificates
+// haven't been checked.
+type blockedKey struct {
+	ID            int64
+	KeyHash       string
+	CheckAttempts int
+}
+
+// maxRetryInterval is the longest a key whose certificates couldn't all be
+// revoked waits before being retried.
+const maxRetryInterval = 24 * time.Hour
+
+// unrevokedCert is an unexpired, unrevoked certificate for a blocked key.
+type unrevokedCert struct {
+	Serial         string
+	RegistrationID int64
+	DER            []byte
+}
+
+// store is the database access needed by the bad-key-revoker.
+type store interface {
+	// countUncheckedKeys returns the number of blocked keys whose extant
+	// certificates haven't been checked.
+	countUncheckedKeys() (int64, error)
+	// nextUncheckedKey returns the oldest blocked key whose extant
+	// certificates haven't been checked and which isn't waiting to be retried
+	// at now, or nil if there are none.
+	nextUncheckedKey(now time.Time) (*blockedKey, error)
+	unrevokedCertificates(keyHash string, now time.Time) ([]unrevokedCert, error)
+	emailsForRegistration(regID int64) ([]string, error)
+	markKeyChecked(id int64) error
+	// deferKey counts a failed attempt to check a key, leaving it to be
+	// retried once nextCheck has passed.
+	deferKey(id int64, nextCheck time.Time) error
+}
+
+type dbStore struct {
+	dbMap *gorp.DbMap
+}
+
+func (s dbStore) countUncheckedKeys() (int64, error) {
+	return s.dbMap.SelectInt("SELECT COUNT(1) FROM blockedKeys WHERE extantCertificatesChecked = false")
+}
+
+func (s dbStore) nextUncheckedKey(now time.Time) (*blockedKey, error) {
+	var key blockedKey
+	err := s.dbMap.SelectOne(&key,
+		`SELECT id, keyHash, checkAttempts FROM blockedKeys
+		WHERE extantCertificatesChecked = false
+		AND (nextCheck IS NULL OR nextCheck <= ?)
+		ORDER BY id LIMIT 1`, now)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s dbStore) unrevokedCertificates(keyHash string, now time.Time) ([]unrevokedCert, error) {
+	var certs []unrevokedCert
+	_, err := s.dbMap.Select(&certs,
+		`SELECT c.serial, c.registrationID, c.der
+		FROM keyHashToSerial AS k
+		JOIN certificates AS c ON c.serial = k.certSerial
+		JOIN certificateStatus AS cs ON cs.serial = k.certSerial
+		WHERE k.keyHash = ? AND k.certNotAfter > ? AND cs.status != ?
+		ORDER BY c.registrationID, k.id`,
+		keyHash, now, string(core.OCSPStatusRevoked))
+	return certs, err
+}
+
+func (s dbStore) emailsForRegistration(regID int64) ([]string, error) {
+	var reg struct {
+		Contact []byte
+	}
+	err := s.dbMap.SelectOne(&reg,
+		"SELECT contact FROM registrations WHERE contact != 'null' AND id = ?", regID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	err = json.Unmarshal(reg.Contact, &entries)
+	if err != nil {
+		return nil, err
+	}
+	var emails []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry, "mailto:") {
+			emails = append(emails, strings.TrimPrefix(entry, "mailto:"))
+		}
+	}
+	return emails, nil
+}
+
+func (s dbStore) markKeyChecked(id int64) error {
+	_, err := s.dbMap.Exec("UPDATE blockedKeys SET extantCertificatesChecked = true WHERE id = ?", id)
+	return err
+}
+
+func (s dbStore) deferKey(id int64, nextCheck time.Time) error {
+	_, err := s.dbMap.Exec(
+		"UPDATE blockedKeys SET checkAttempts = checkAttempts + 1, nextCheck = ? WHERE id = ?",
+		nextCheck, id)
+	return err
+}
+
+type revoker interface {
+	AdministrativelyRevokeCertificate(ctx context.Context, cert x509.Certificate, code revocation.Reason, adminName string) error
+}
+
+// revokedCert is passed to the email template for each revoked certificate.
+type revokedCert struct {
+	Serial string
+	Names  []string
+}
+
+// badKeyRevoker revokes the certificates issued for keys before they were
+// blocked, and tells the subscribers they were issued to.
+type badKeyRevoker struct {
+	store              store
+	rac                revoker
+	mailer             bmail.Mailer
+	emailSubject       string
+	emailTemplate      *template.Template
+	revocationInterval time.Duration
+	retryInterval      time.Duration
+	clk                clock.Clock
+	log                blog.Logger
+
+	keysToProcess prometheus.Gauge
+	keysProcessed *prometheus.CounterVec
+	certsRevoked  prometheus.Counter
+	mailErrors    prometheus.Counter
+}
+
+func newBadKeyRevoker(s store, rac revoker, mailer bmail.Mailer, subject string, tmpl *template.Template, revocationInterval, retryInterval time.Duration, clk clock.Clock, logger blog.Logger, stats metrics.Scope) *badKeyRevoker {
+	keysToProcess := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bad_keys_to_process",
+		Help: "A gauge of blocked keys whose extant certificates haven't been checked",
+	})
+	stats.MustRegister(keysToProcess)
+	keysProcessed := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bad_keys_processed",
+		Help: "A counter of blocked keys processed, by result (success or error)",
+	}, []string{"result"})
+	stats.MustRegister(keysProcessed)
+	certsRevoked := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "bad_keys_certs_revoked",
+		Help: "A counter of certificates revoked for blocked keys",
+	})
+	stats.MustRegister(certsRevoked)
+	mailErrors := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "bad_keys_mail_errors",
+		Help: "A counter of failures to email subscribers about revoked certificates",
+	})
+	stats.MustRegister(mailErrors)
+	return &badKeyRevoker{
+		store:              s,
+		rac:                rac,
+		mailer:             mailer,
+		emailSubject:       subject,
+		emailTemplate:      tmpl,
+		revocationInterval: revocationInterval,
+		retryInterval:      retryInterval,
+		clk:                clk,
+		log:                logger,
+		keysToProcess:      keysToProcess,
+		keysProcessed:      keysProcessed,
+		certsRevoked:       certsRevoked,
+		mailErrors:         mailErrors,
+	}
+}
+
+// invoke processes the oldest unchecked blocked key, returning true if there
+// were none to process. If the key can't be processed it is deferred, so that
+// it doesn't hold up the keys after it.
+func (bkr *badKeyRevoker) invoke(ctx context.Context) (bool, error) {
+	count, err := bkr.store.countUncheckedKeys()
+	if err != nil {
+		return false, fmt.Errorf("counting unchecked keys: %s", err)
+	}
+	bkr.keysToProcess.Set(float64(count))
+
+	key, err := bkr.store.nextUncheckedKey(bkr.clk.Now())
+	if err != nil {
+		return false, fmt.Errorf("finding unchecked key: %s", err)
+	}
+	if key == nil {
+		return true, nil
+	}
+	err = bkr.processKey(ctx, key)
+	if err != nil {
+		bkr.keysProcessed.WithLabelValues("error").Inc()
+		nextCheck := bkr.clk.Now().Add(bkr.retryDelay(key.CheckAttempts))
+		if deferErr := bkr.store.deferKey(key.ID, nextCheck); deferErr != nil {
+			return false, fmt.Errorf("processing key %s: %s, and deferring it: %s", key.KeyHash, err, deferErr)
+		}
+		return false, fmt.Errorf("processing key %s, retrying after %s: %s", key.KeyHash, nextCheck, err)
+	}
+	bkr.keysProcessed.WithLabelValues("success").Inc()
+	return false, nil
+}
+
+// retryDelay returns how long to wait before retrying a key that has already
+// failed attempts times, doubling retryInterval for each earlier failure.
+func (bkr *badKeyRevoker) retryDelay(attempts int) time.Duration {
+	delay := bkr.retryInterval
+	for i := 0; i < attempts && delay < maxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryInterval {
+		delay = maxRetryInterval
+	}
+	return delay
+}
+
+// processKey revokes the unexpired certificates for key and emails the
+// subscribers they were issued to, before marking the key checked. If
+// revocation fails the subscribers are still emailed about the certificates
+// revoked so far, and the key is left to be retried, skipping those
+// certificates.
+func (bkr *badKeyRevoker) processKey(ctx context.Context, key *blockedKey) error {
+	certs, err := bkr.store.unrevokedCertificates(key.KeyHash, bkr.clk.Now())
+	if err != nil {
+		return err
+	}
+
+	var regIDs []int64
+	revoked := make(map[int64][]revokedCert)
+	defer func() {
+		if len(regIDs) > 0 {
+			bkr.notify(regIDs, revoked)
+		}
+	}()
+	for i, c := range certs {
+		cert, err := x509.ParseCertificate(c.DER)
+		if err != nil {
+			return fmt.Errorf("parsing certificate %s: %s", c.Serial, err)
+		}
+		if i > 0 && bkr.revocationInterval > 0 {
+			bkr.clk.Sleep(bkr.revocationInterval)
+		}
+		err = bkr.rac.AdministrativelyRevokeCertificate(ctx, *cert, revocation.KeyCompromise, adminName)
+		if err != nil {
+			return fmt.Errorf("revoking certificate %s: %s", c.Serial, err)
+		}
+		bkr.certsRevoked.Inc()
+		bkr.log.AuditInfo(fmt.Sprintf("Revoked certificate %s for blocked key %s", c.Serial, key.KeyHash))
+		if _, ok := revoked[c.RegistrationID]; !ok {
+			regIDs = append(regIDs, c.RegistrationID)
+		}
+		revoked[c.RegistrationID] = append(revoked[c.RegistrationID], revokedCert{
+			Serial: c.Serial,
+			Names:  cert.DNSNames,
+		})
+	}
+	return bkr.store.markKeyChecked(key.ID)
+}
+
+// notify emails each registration about its revoked certificates. Failures
+// are logged and counted, but don't stop the key being marked checked, since
+// the certificates have been revoked.
+func (bkr *badKeyRevoker) notify(regIDs []int64, revoked map[int64][]revokedCert) {
+	err := bkr.mailer.Connect()
+	if err != nil {
+		bkr.log.AuditErr(fmt.Sprintf("Failed to connect to SMTP server: %s", err))
+		bkr.mailErrors.Add(float64(len(regIDs)))
+		return
+	}
+	defer func() {
+		_ = bkr.mailer.Close()
+	}()
+
+	for _, regID := range regIDs {
+		err := bkr.sendMessage(regID, revoked[regID])
+		if err != nil {
+			bkr.log.AuditErr(fmt.Sprintf("Failed to email registration %d about revoked certificates: %s", regID, err))
+			bkr.mailErrors.Inc()
+		}
+	}
+}
+
+func (bkr *badKeyRevoker) sendMessage(regID int64, certs []revokedCert) error {
+	emails, err := bkr.store.emailsForRegistration(regID)
+	if err != nil {
+		return err
+	}
+	if len(emails) == 0 {
+		bkr.log.Info(fmt.Sprintf("Registration %d has no email contact to notify", regID))
+		return nil
+	}
+	var body bytes.Buffer
+	err = bkr.emailTemplate.Execute(&body, certs)
+	if err != nil {
+		return err
+	}
+	return bkr.mailer.SendMail(emails, bkr.emailSubject, body.String())
+}
+
+// parseTemplate parses an email template, which can use a join function
+// like strings.Join.
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("email").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+}
+
+func main() {
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.BadKeyRevoker.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	scope, logger := cmd.StatsAndLogging(c.Syslog, c.BadKeyRevoker.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+
+	dbURL, err := c.BadKeyRevoker.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbTLS, err := c.BadKeyRevoker.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.BadKeyRevoker.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.BadKeyRevoker.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.BadKeyRevoker.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
+	cmd.FailOnError(err, "Could not connect to database")
+	sa.SetSQLDebug(dbMap, logger)
+	go sa.ReportDbConnCount(dbMap, scope)
+
+	tlsConfig, err := c.BadKeyRevoker.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+	clientMetrics := bgrpc.NewClientMetrics(scope)
+	raConn, err := bgrpc.ClientSetup(c.BadKeyRevoker.RAService, tlsConfig, clientMetrics)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to RA")
+	rac := bgrpc.NewRegistrationAuthorityClient(rapb.NewRegistrationAuthorityClient(raConn))
+
+	mc := c.BadKeyRevoker.Mailer
+	var smtpRoots *x509.CertPool
+	if mc.SMTPTrustedRootFile != "" {
+		pem, err := ioutil.ReadFile(mc.SMTPTrustedRootFile)
+		cmd.FailOnError(err, "Loading trusted roots file")
+		smtpRoots = x509.NewCertPool()
+		if !smtpRoots.AppendCertsFromPEM(pem) {
+			cmd.FailOnError(fmt.Errorf("no certificates found"), "Failed to parse root certs PEM")
+		}
+	}
+	fromAddress, err := netmail.ParseAddress(mc.From)
+	cmd.FailOnError(err, fmt.Sprintf("Could not parse from address: %s", mc.From))
+	smtpPassword, err := mc.PasswordConfig.Pass()
+	cmd.FailOnError(err, "Failed to load SMTP password")
+	mailClient := bmail.New(
+		mc.Server,
+		mc.Port,
+		mc.Username,
+		smtpPassword,
+		smtpRoots,
+		*fromAddress,
+		logger,
+		scope,
+		time.Second,
+		5*time.Minute)
+
+	templateText := defaultEmailTemplate
+	if mc.EmailTemplate != "" {
+		data, err := ioutil.ReadFile(mc.EmailTemplate)
+		cmd.FailOnError(err, fmt.Sprintf("Could not read email template file [%s]", mc.EmailTemplate))
+		templateText = string(data)
+	}
+	tmpl, err := parseTemplate(templateText)
+	cmd.FailOnError(err, "Could not parse email template")
+	subject := mc.EmailSubject
+	if subject == "" {
+		subject = defaultEmailSubject
+	}
+
+	interval := c.BadKeyRevoker.Interval.Duration
+	if interval == 0 {
+		interval = time.Minute
+	}
+	retryInterval := c.BadKeyRevoker.RetryInterval.Duration
+	if retryInterval == 0 {
+		retryInterval = 10 * time.Minute
+	}
+	clk := cmd.Clock()
+	bkr := newBadKeyRevoker(dbStore{dbMap}, rac, mailClient, subject, tmpl,
+		c.BadKeyRevoker.RevocationInterval.Duration, retryInterval, clk, logger, scope)
+
+	go func() {
+		for {
+			noWork, err := bkr.invoke(context.Background())
+			if err != nil {
+				logger.AuditErr(fmt.Sprintf("Failed to process blocked key: %s", err))
+			}
+			if err != nil || noWork {
+				clk.Sleep(interval)
+			}
+		}
+	}()
+
+	cmd.CatchSignals(logger, nil)
+}
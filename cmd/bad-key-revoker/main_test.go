@@ -0,0 +1,273 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/mocks"
+	"github.com/letsencrypt/boulder/revocation"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type mockStore struct {
+	keys      []blockedKey
+	certs     map[string][]unrevokedCert
+	emails    map[int64][]string
+	checked   []int64
+	nextCheck map[int64]time.Time
+}
+
+func (s *mockStore) countUncheckedKeys() (int64, error) {
+	return int64(len(s.keys)), nil
+}
+
+func (s *mockStore) nextUncheckedKey(now time.Time) (*blockedKey, error) {
+	for i, k := range s.keys {
+		if next, ok := s.nextCheck[k.ID]; ok && next.After(now) {
+			continue
+		}
+		return &s.keys[i], nil
+	}
+	return nil, nil
+}
+
+func (s *mockStore) unrevokedCertificates(keyHash string, _ time.Time) ([]unrevokedCert, error) {
+	return s.certs[keyHash], nil
+}
+
+func (s *mockStore) emailsForRegistration(regID int64) ([]string, error) {
+	return s.emails[regID], nil
+}
+
+func (s *mockStore) markKeyChecked(id int64) error {
+	s.checked = append(s.checked, id)
+	for i, k := range s.keys {
+		if k.ID == id {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *mockStore) deferKey(id int64, nextCheck time.Time) error {
+	for i, k := range s.keys {
+		if k.ID == id {
+			s.keys[i].CheckAttempts++
+		}
+	}
+	if s.nextCheck == nil {
+		s.nextCheck = make(map[int64]time.Time)
+	}
+	s.nextCheck[id] = nextCheck
+	return nil
+}
+
+// removeRevoked drops the certificates revoked by ra, as unrevokedCertificates
+// would.
+func (s *mockStore) removeRevoked(ra *mockRA) {
+	for hash, certs := range s.certs {
+		var left []unrevokedCert
+		for _, c := range certs {
+			revoked := false
+			for _, serial := range ra.revoked {
+				revoked = revoked || serial == c.Serial
+			}
+			if !revoked {
+				left = append(left, c)
+			}
+		}
+		s.certs[hash] = left
+	}
+}
+
+type mockRA struct {
+	revoked []string
+	err     error
+	// failAfter, if set, makes revocations fail with err once that many
+	// certificates have been revoked.
+	failAfter int
+}
+
+func (ra *mockRA) AdministrativelyRevokeCertificate(_ context.Context, cert x509.Certificate, code revocation.Reason, admin string) error {
+	if ra.err != nil && len(ra.revoked) >= ra.failAfter {
+		return ra.err
+	}
+	if code != revocation.KeyCompromise || admin != adminName {
+		return errors.New("unexpected revocation reason or admin")
+	}
+	ra.revoked = append(ra.revoked, core.SerialToString(cert.SerialNumber))
+	return nil
+}
+
+func makeCert(t *testing.T, serial int64, regID int64, names ...string) unrevokedCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		DNSNames:     names,
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "Failed to create certificate")
+	return unrevokedCert{
+		Serial:         core.SerialToString(big.NewInt(serial)),
+		RegistrationID: regID,
+		DER:            der,
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	a := makeCert(t, 1, 1, "a.example.com")
+	b := makeCert(t, 2, 2, "b.example.com", "www.b.example.com")
+	c := makeCert(t, 3, 1, "c.example.com")
+	s := &mockStore{
+		keys:  []blockedKey{{ID: 10, KeyHash: "one"}, {ID: 11, KeyHash: "two"}},
+		certs: map[string][]unrevokedCert{"one": {a, b, c}},
+		emails: map[int64][]string{
+			1: {"one@example.com"},
+		},
+	}
+	ra := &mockRA{}
+	mailer := &mocks.Mailer{}
+	tmpl, err := parseTemplate(defaultEmailTemplate)
+	test.AssertNotError(t, err, "Failed to parse default template")
+	fc := clock.NewFake()
+	log := blog.NewMock()
+	bkr := newBadKeyRevoker(s, ra, mailer, defaultEmailSubject, tmpl, time.Second, time.Minute, fc, log, metrics.NewNoopScope())
+
+	noWork, err := bkr.invoke(context.Background())
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertEquals(t, noWork, false)
+	test.AssertDeepEquals(t, ra.revoked, []string{a.Serial, b.Serial, c.Serial})
+	test.AssertDeepEquals(t, s.checked, []int64{10})
+	test.AssertEquals(t, test.CountCounter(bkr.certsRevoked), 3)
+	test.AssertEquals(t, test.CountCounter(bkr.keysProcessed.WithLabelValues("success")), 1)
+	// Revocations are paced
+	test.AssertEquals(t, fc.Now().Sub(clock.NewFake().Now()), 2*time.Second)
+
+	// Registration 2 has no email contact, so only registration 1 is sent a
+	// message listing both of its certificates
+	test.AssertEquals(t, len(mailer.Messages), 1)
+	test.AssertEquals(t, mailer.Messages[0].To, "one@example.com")
+	test.AssertEquals(t, mailer.Messages[0].Subject, defaultEmailSubject)
+	test.AssertContains(t, mailer.Messages[0].Body, "  "+a.Serial+" (a.example.com)\n  "+c.Serial+" (c.example.com)\n")
+	test.AssertEquals(t, len(log.GetAllMatching("Registration 2 has no email contact")), 1)
+
+	// A key without certificates is marked checked without sending mail
+	mailer.Clear()
+	noWork, err = bkr.invoke(context.Background())
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertEquals(t, noWork, false)
+	test.AssertDeepEquals(t, s.checked, []int64{10, 11})
+	test.AssertEquals(t, len(mailer.Messages), 0)
+
+	noWork, err = bkr.invoke(context.Background())
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertEquals(t, noWork, true)
+}
+
+func TestInvokeRevocationFailure(t *testing.T) {
+	s := &mockStore{
+		keys:  []blockedKey{{ID: 10, KeyHash: "one"}},
+		certs: map[string][]unrevokedCert{"one": {makeCert(t, 1, 1, "a.example.com")}},
+	}
+	ra := &mockRA{err: errors.New("RA unavailable")}
+	mailer := &mocks.Mailer{}
+	tmpl, err := parseTemplate(defaultEmailTemplate)
+	test.AssertNotError(t, err, "Failed to parse default template")
+	fc := clock.NewFake()
+	bkr := newBadKeyRevoker(s, ra, mailer, defaultEmailSubject, tmpl, 0, time.Minute, fc, blog.NewMock(), metrics.NewNoopScope())
+
+	// The key isn't marked checked, so it's retried once its retry interval
+	// has passed, which doubles with each failure
+	_, err = bkr.invoke(context.Background())
+	test.AssertError(t, err, "invoke didn't fail when revocation failed")
+	test.AssertEquals(t, len(s.checked), 0)
+	test.AssertEquals(t, len(mailer.Messages), 0)
+	test.AssertEquals(t, test.CountCounter(bkr.keysProcessed.WithLabelValues("error")), 1)
+	test.AssertEquals(t, s.nextCheck[10], fc.Now().Add(time.Minute))
+
+	noWork, err := bkr.invoke(context.Background())
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertEquals(t, noWork, true)
+
+	fc.Add(time.Minute)
+	_, err = bkr.invoke(context.Background())
+	test.AssertError(t, err, "invoke didn't fail when revocation failed")
+	test.AssertEquals(t, s.nextCheck[10], fc.Now().Add(2*time.Minute))
+
+	// Once revocation works again the key is checked
+	ra.err = nil
+	fc.Add(2 * time.Minute)
+	_, err = bkr.invoke(context.Background())
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertDeepEquals(t, s.checked, []int64{10})
+}
+
+func TestInvokePartialRevocationFailure(t *testing.T) {
+	a := makeCert(t, 1, 1, "a.example.com")
+	b := makeCert(t, 2, 1, "b.example.com")
+	c := makeCert(t, 3, 2, "c.example.com")
+	s := &mockStore{
+		keys: []blockedKey{{ID: 10, KeyHash: "one"}, {ID: 11, KeyHash: "two"}},
+		certs: map[string][]unrevokedCert{
+			"one": {a, b},
+			"two": {c},
+		},
+		emails: map[int64][]string{
+			1: {"one@example.com"},
+			2: {"two@example.com"},
+		},
+	}
+	ra := &mockRA{err: errors.New("RA unavailable"), failAfter: 1}
+	mailer := &mocks.Mailer{}
+	tmpl, err := parseTemplate(defaultEmailTemplate)
+	test.AssertNotError(t, err, "Failed to parse default template")
+	fc := clock.NewFake()
+	bkr := newBadKeyRevoker(s, ra, mailer, defaultEmailSubject, tmpl, 0, time.Minute, fc, blog.NewMock(), metrics.NewNoopScope())
+
+	// The subscriber is told about the certificate revoked before the failure
+	_, err = bkr.invoke(context.Background())
+	test.AssertError(t, err, "invoke didn't fail when revocation failed")
+	test.AssertDeepEquals(t, ra.revoked, []string{a.Serial})
+	test.AssertEquals(t, len(s.checked), 0)
+	test.AssertEquals(t, len(mailer.Messages), 1)
+	test.AssertContains(t, mailer.Messages[0].Body, a.Serial)
+	test.AssertNotContains(t, mailer.Messages[0].Body, b.Serial)
+	s.removeRevoked(ra)
+
+	// The deferred key doesn't block the next one
+	ra.err = nil
+	mailer.Clear()
+	_, err = bkr.invoke(context.Background())
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertDeepEquals(t, s.checked, []int64{11})
+	test.AssertEquals(t, len(mailer.Messages), 1)
+	test.AssertEquals(t, mailer.Messages[0].To, "two@example.com")
+	s.removeRevoked(ra)
+
+	// When the first key is retried only the remaining certificate is
+	// revoked and mentioned
+	mailer.Clear()
+	fc.Add(time.Minute)
+	_, err = bkr.invoke(context.Background())
+	test.AssertNotError(t, err, "invoke failed")
+	test.AssertDeepEquals(t, s.checked, []int64{11, 10})
+	test.AssertDeepEquals(t, ra.revoked, []string{a.Serial, c.Serial, b.Serial})
+	test.AssertEquals(t, len(mailer.Messages), 1)
+	test.AssertContains(t, mailer.Messages[0].Body, b.Serial)
+	test.AssertNotContains(t, mailer.Messages[0].Body, a.Serial)
+}
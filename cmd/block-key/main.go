@@ -0,0 +1,235 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+	"gopkg.in/go-gorp/gorp.v2"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/revocation"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+const usageString = `
+usage:
+block-key add --config <path> [--comment <comment>] [--revoke] [--reason <reason-code>] <pem-file>
+
+command descriptions:
+  add       Add the public key in a PEM file to the blocked key table, so that
+            it can no longer be used in CSRs or as an account key
+
+args:
+  config    File path to the configuration file for this service
+  comment   Comment stored alongside the blocked key, e.g. a bug reference
+  revoke    Also revoke all unexpired certificates for the key
+  reason    Revocation reason code used with --revoke (default 1, keyCompromise)
+  pem-file  File containing a PEM encoded certificate or public key
+`
+
+type config struct {
+	BlockKey struct {
+		cmd.DBConfig
+		TLS cmd.TLSConfig
+
+		// RAService is only used when revoking certificates for a blocked key.
+		RAService *cmd.GRPCClientConfig
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+// keyFromPEM returns the public key of the first certificate or public key
+// block in the PEM data.
+func keyFromPEM(data []byte) (crypto.PublicKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no CERTIFICATE or PUBLIC KEY PEM block found")
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return cert.PublicKey, nil
+		case "PUBLIC KEY":
+			return x509.ParsePKIXPublicKey(block.Bytes)
+		}
+	}
+}
+
+// blockKey adds keyHash to the blockedKeys table. It is not an error for the
+// key to already be blocked.
+func blockKey(dbMap *gorp.DbMap, clk clock.Clock, keyHash, addedBy, comment string) (bool, error) {
+	var count int64
+	err := dbMap.SelectOne(&count, "SELECT COUNT(1) FROM blockedKeys WHERE keyHash = ?", keyHash)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	var commentArg interface{}
+	if comment != "" {
+		commentArg = comment
+	}
+	_, err = dbMap.Exec(
+		"INSERT INTO blockedKeys (keyHash, added, addedBy, comment) VALUES (?, ?, ?, ?)",
+		keyHash, clk.Now(), addedBy, commentArg)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// revokeByKey revokes all unexpired, unrevoked certificates issued for the key
+// with the given hash, returning the number revoked.
+func revokeByKey(ctx context.Context, dbMap *gorp.DbMap, clk clock.Clock, keyHash string, reasonCode revocation.Reason, user string, rac core.RegistrationAuthority, logger blog.Logger) (int, error) {
+	var serials []string
+	_, err := dbMap.Select(&serials,
+		"SELECT certSerial FROM keyHashToSerial WHERE keyHash = ? AND certNotAfter > ?",
+		keyHash, clk.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	// Look up the statuses of all of the certificates at once, rather than
+	// one at a time.
+	alreadyRevoked := make(map[string]bool)
+	for start := 0; start < len(serials); start += sa.MaxStatusesPerRequest {
+		end := start + sa.MaxStatusesPerRequest
+		if end > len(serials) {
+			end = len(serials)
+		}
+		statuses, err := sa.SelectCertificateStatusesBySerial(dbMap, serials[start:end])
+		if err != nil {
+			return 0, err
+		}
+		for _, status := range statuses {
+			alreadyRevoked[status.Serial] = status.Status == core.OCSPStatusRevoked
+		}
+	}
+
+	revoked := 0
+	for _, serial := range serials {
+		if alreadyRevoked[serial] {
+			logger.Info(fmt.Sprintf("Certificate %s is already revoked", serial))
+			continue
+		}
+		certObj, err := sa.SelectCertificate(dbMap, "WHERE serial = ?", serial)
+		if err != nil {
+			return revoked, err
+		}
+		cert, err := x509.ParseCertificate(certObj.DER)
+		if err != nil {
+			return revoked, err
+		}
+		err = rac.AdministrativelyRevokeCertificate(ctx, *cert, reasonCode, user)
+		if err != nil {
+			return revoked, err
+		}
+		logger.Info(fmt.Sprintf("Revoked certificate %s with reason '%s'", serial, revocation.ReasonToString[reasonCode]))
+		revoked++
+	}
+	return revoked, nil
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	comment := flagSet.String("comment", "", "Comment stored alongside the blocked key")
+	revoke := flagSet.Bool("revoke", false, "Revoke all unexpired certificates for the key")
+	reason := flagSet.Int("reason", int(revocation.KeyCompromise), "Revocation reason code used with --revoke")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+
+	if *configFile == "" || command != "add" || len(flagSet.Args()) != 1 {
+		usage()
+	}
+	reasonCode := revocation.Reason(*reason)
+	if _, ok := revocation.ReasonToString[reasonCode]; !ok {
+		cmd.FailOnError(fmt.Errorf("invalid reason code %d", reasonCode), "Bad --reason")
+	}
+
+	var c config
+	err = cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.BlockKey.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	logger := cmd.NewLogger(c.Syslog)
+	defer logger.AuditPanic()
+
+	pemData, err := ioutil.ReadFile(flagSet.Arg(0))
+	cmd.FailOnError(err, "Couldn't read PEM file")
+	key, err := keyFromPEM(pemData)
+	cmd.FailOnError(err, "Couldn't parse public key")
+	keyHash, err := core.KeyDigest(key)
+	cmd.FailOnError(err, "Couldn't compute key hash")
+
+	u, err := user.Current()
+	cmd.FailOnError(err, "Couldn't determine current user")
+
+	dbURL, err := c.BlockKey.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbTLS, err := c.BlockKey.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.BlockKey.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.BlockKey.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.BlockKey.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
+	cmd.FailOnError(err, "Couldn't setup database connection")
+
+	clk := cmd.Clock()
+	added, err := blockKey(dbMap, clk, keyHash, u.Username, *comment)
+	cmd.FailOnError(err, "Couldn't add key to blocked keys")
+	if added {
+		logger.AuditInfo(fmt.Sprintf("Blocked key %s, added by %s: %q", keyHash, u.Username, *comment))
+	} else {
+		logger.Info(fmt.Sprintf("Key %s is already blocked", keyHash))
+	}
+
+	if !*revoke {
+		return
+	}
+	tlsConfig, err := c.BlockKey.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+	clientMetrics := bgrpc.NewClientMetrics(metrics.NewNoopScope())
+	raConn, err := bgrpc.ClientSetup(c.BlockKey.RAService, tlsConfig, clientMetrics)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to RA")
+	rac := bgrpc.NewRegistrationAuthorityClient(rapb.NewRegistrationAuthorityClient(raConn))
+
+	count, err := revokeByKey(context.Background(), dbMap, clk, keyHash, reasonCode, u.Username, rac, logger)
+	cmd.FailOnError(err, fmt.Sprintf("Couldn't revoke certificates for key, %d revoked before failing", count))
+	logger.Info(fmt.Sprintf("Revoked %d certificates for key %s", count, keyHash))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"testing"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestKeyFromPEM(t *testing.T) {
+	certPEM, err := ioutil.ReadFile("../../test/test-ca.pem")
+	test.AssertNotError(t, err, "Failed to read test certificate")
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	test.AssertNotError(t, err, "Failed to parse test certificate")
+
+	key, err := keyFromPEM(certPEM)
+	test.AssertNotError(t, err, "keyFromPEM failed on a certificate")
+	test.Assert(t, core.KeyDigestEquals(key, cert.PublicKey), "Wrong key returned for certificate")
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate key")
+	spki, err := x509.MarshalPKIXPublicKey(priv.Public())
+	test.AssertNotError(t, err, "Failed to marshal public key")
+	// Blocks that aren't keys or certificates are skipped
+	keyPEM := append(
+		pem.EncodeToMemory(&pem.Block{Type: "COMMENT", Bytes: []byte("hello")}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki})...)
+	key, err = keyFromPEM(keyPEM)
+	test.AssertNotError(t, err, "keyFromPEM failed on a public key")
+	test.Assert(t, core.KeyDigestEquals(key, priv.Public()), "Wrong key returned for public key")
+
+	_, err = keyFromPEM([]byte("not PEM"))
+	test.AssertError(t, err, "keyFromPEM accepted data without a PEM block")
+	_, err = keyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte{1, 2, 3}}))
+	test.AssertError(t, err, "keyFromPEM accepted a malformed public key")
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/ratelimit"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+const usageString = `
+usage:
+boulder-admin orphan-report --config <path> [--window <duration>] [--json]
+boulder-admin account-stats --config <path> --id <registration ID> [--json]
+boulder-admin replay-validation --config <path> --authz <ID> --type <challenge type> [--user-agent <UA>] [--json]
+
+command descriptions:
+  orphan-report   Report orders referencing missing authorizations, authorizations
+                  without parent orders, and certificates without orders, along
+                  with suggested repairs
+  account-stats   Report an account's recent issuance, failed validations and
+                  pending authorizations, and its consumption of per-account
+                  rate limits if rateLimitPoliciesFilename is configured
+  replay-validation
+                  Have the VA check an authorization's challenge again, without
+                  storing the result, and print what it did
+
+args:
+  config    File path to the configuration file for this service
+  window    How far back to look for certificates and orders (default 720h)
+  id        The registration ID of the account to report on
+  authz     The ID of the authorization whose challenge to replay
+  type      The type of the challenge to replay, e.g. http-01
+  user-agent
+            The User-Agent for HTTP-01 requests, instead of the VA's own
+  json      Output the report as JSON instead of text
+`
+
+type config struct {
+	Admin struct {
+		cmd.DBConfig
+
+		// RateLimitPoliciesFilename, if set, is the RA's rate limit policy
+		// file, used by account-stats to report rate limit consumption.
+		RateLimitPoliciesFilename string
+
+		// TLS and VAService are needed by replay-validation to call the VA.
+		TLS       cmd.TLSConfig
+		VAService *cmd.GRPCClientConfig
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprint(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	window := flagSet.Duration("window", 30*24*time.Hour, "How far back to look for certificates and orders")
+	jsonOutput := flagSet.Bool("json", false, "Output the report as JSON instead of text")
+	regID := flagSet.Int64("id", 0, "The registration ID of the account to report on")
+	authzID := flagSet.String("authz", "", "The ID of the authorization whose challenge to replay")
+	challengeType := flagSet.String("type", "", "The type of the challenge to replay")
+	userAgent := flagSet.String("user-agent", "", "The User-Agent for HTTP-01 requests, instead of the VA's own")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+
+	if *configFile == "" {
+		usage()
+	}
+
+	var c config
+	err = cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.Admin.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	logger := cmd.NewLogger(c.Syslog)
+	defer logger.AuditPanic()
+
+	switch command {
+	case "orphan-report":
+		dbURL, err := c.Admin.DBConfig.URL()
+		cmd.FailOnError(err, "Couldn't load DB URL")
+		dbTLS, err := c.Admin.DBConfig.TLSConfig()
+		cmd.FailOnError(err, "Couldn't load DB TLS config")
+		dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+			MaxOpenConns:    c.Admin.DBConfig.MaxDBConns,
+			MaxIdleConns:    c.Admin.DBConfig.MaxIdleDBConns,
+			ConnMaxLifetime: c.Admin.DBConfig.ConnMaxLifetime.Duration,
+			TLS:             dbTLS,
+		})
+		cmd.FailOnError(err, "Couldn't setup database connection")
+
+		r := &reconciler{
+			dbMap:  dbMap,
+			clk:    cmd.Clock(),
+			window: *window,
+		}
+		report, err := r.report()
+		cmd.FailOnError(err, "Couldn't generate orphan report")
+
+		if *jsonOutput {
+			out, err := json.MarshalIndent(report, "", "  ")
+			cmd.FailOnError(err, "Couldn't marshal orphan report")
+			fmt.Println(string(out))
+		} else {
+			report.writeText(os.Stdout)
+		}
+		logger.Info(fmt.Sprintf("Orphan report found %d problems", len(report.Orphans)))
+
+	case "account-stats":
+		if *regID == 0 {
+			usage()
+		}
+		dbURL, err := c.Admin.DBConfig.URL()
+		cmd.FailOnError(err, "Couldn't load DB URL")
+		dbTLS, err := c.Admin.DBConfig.TLSConfig()
+		cmd.FailOnError(err, "Couldn't load DB TLS config")
+		dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+			MaxOpenConns:    c.Admin.DBConfig.MaxDBConns,
+			MaxIdleConns:    c.Admin.DBConfig.MaxIdleDBConns,
+			ConnMaxLifetime: c.Admin.DBConfig.ConnMaxLifetime.Duration,
+			TLS:             dbTLS,
+		})
+		cmd.FailOnError(err, "Couldn't setup database connection")
+		ssa, err := sa.NewSQLStorageAuthority(dbMap, cmd.Clock(), logger, metrics.NewNoopScope(), 1)
+		cmd.FailOnError(err, "Failed to create SA")
+
+		r := &statsReporter{
+			sa:  ssa,
+			clk: cmd.Clock(),
+		}
+		if c.Admin.RateLimitPoliciesFilename != "" {
+			contents, err := ioutil.ReadFile(c.Admin.RateLimitPoliciesFilename)
+			cmd.FailOnError(err, "Couldn't read rate limit policies")
+			r.limits = ratelimit.New()
+			err = r.limits.LoadPolicies(contents)
+			cmd.FailOnError(err, "Couldn't load rate limit policies")
+		}
+		stats, err := r.report(context.Background(), *regID)
+		cmd.FailOnError(err, "Couldn't gather account statistics")
+
+		if *jsonOutput {
+			out, err := json.MarshalIndent(stats, "", "  ")
+			cmd.FailOnError(err, "Couldn't marshal account statistics")
+			fmt.Println(string(out))
+		} else {
+			stats.writeText(os.Stdout)
+		}
+		logger.Info(fmt.Sprintf("Reported statistics for registration %d", *regID))
+
+	case "replay-validation":
+		if *authzID == "" || *challengeType == "" || c.Admin.VAService == nil {
+			usage()
+		}
+		dbURL, err := c.Admin.DBConfig.URL()
+		cmd.FailOnError(err, "Couldn't load DB URL")
+		dbTLS, err := c.Admin.DBConfig.TLSConfig()
+		cmd.FailOnError(err, "Couldn't load DB TLS config")
+		dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+			MaxOpenConns:    c.Admin.DBConfig.MaxDBConns,
+			MaxIdleConns:    c.Admin.DBConfig.MaxIdleDBConns,
+			ConnMaxLifetime: c.Admin.DBConfig.ConnMaxLifetime.Duration,
+			TLS:             dbTLS,
+		})
+		cmd.FailOnError(err, "Couldn't setup database connection")
+		ssa, err := sa.NewSQLStorageAuthority(dbMap, cmd.Clock(), logger, metrics.NewNoopScope(), 1)
+		cmd.FailOnError(err, "Failed to create SA")
+
+		tlsConfig, err := c.Admin.TLS.Load()
+		cmd.FailOnError(err, "TLS config")
+		clientMetrics := bgrpc.NewClientMetrics(metrics.NewNoopScope())
+		vaConn, err := bgrpc.ClientSetup(c.Admin.VAService, tlsConfig, clientMetrics)
+		cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to VA")
+
+		r := &replayer{
+			sa:  ssa,
+			va:  bgrpc.NewValidationAuthorityGRPCClient(vaConn),
+			clk: cmd.Clock(),
+		}
+		t, err := r.replay(context.Background(), *authzID, *challengeType, *userAgent)
+		cmd.FailOnError(err, "Couldn't replay validation")
+
+		if *jsonOutput {
+			out, err := json.MarshalIndent(t, "", "  ")
+			cmd.FailOnError(err, "Couldn't marshal validation transcript")
+			fmt.Println(string(out))
+		} else {
+			t.writeText(os.Stdout)
+		}
+		logger.AuditInfo(fmt.Sprintf("Replayed %s validation of authorization %s", *challengeType, *authzID))
+
+	default:
+		usage()
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/probs"
+)
+
+// replaySA is the subset of the SA used to look up the challenge to replay.
+type replaySA interface {
+	GetAuthorization(ctx context.Context, id string) (core.Authorization, error)
+	GetRegistration(ctx context.Context, id int64) (core.Registration, error)
+}
+
+// replayVA is the subset of the VA used to replay validations.
+type replayVA interface {
+	ReplayValidation(ctx context.Context, domain string, challenge core.Challenge, userAgent string) ([]core.ValidationRecord, error)
+}
+
+// transcript is the result of a replay-validation run.
+type transcript struct {
+	AuthorizationID string                  `json:"authorizationID"`
+	Identifier      string                  `json:"identifier"`
+	ChallengeType   string                  `json:"challengeType"`
+	Started         time.Time               `json:"started"`
+	Duration        string                  `json:"duration"`
+	Records         []core.ValidationRecord `json:"records"`
+	Problem         *probs.ProblemDetails   `json:"problem,omitempty"`
+}
+
+func ipsString(ips []net.IP) string {
+	s := make([]string, len(ips))
+	for i, ip := range ips {
+		s[i] = ip.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+func (t *transcript) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Replayed %s validation of %s for authorization %s at %s, taking %s\n",
+		t.ChallengeType, t.Identifier, t.AuthorizationID, t.Started.Format(time.RFC3339), t.Duration)
+	for i, r := range t.Records {
+		fmt.Fprintf(w, "%d. %s\n", i+1, r.Hostname)
+		if r.URL != "" {
+			fmt.Fprintf(w, "  url: %s\n", r.URL)
+		}
+		if len(r.AddressesResolved) > 0 {
+			fmt.Fprintf(w, "  resolved: %s\n", ipsString(r.AddressesResolved))
+		}
+		if len(r.AddressesTried) > 0 {
+			fmt.Fprintf(w, "  tried: %s\n", ipsString(r.AddressesTried))
+		}
+		if r.AddressUsed != nil {
+			fmt.Fprintf(w, "  used: %s\n", r.AddressUsed)
+		}
+		if r.SourceAddress != nil {
+			fmt.Fprintf(w, "  source: %s\n", r.SourceAddress)
+		}
+		if r.UserAgent != "" {
+			fmt.Fprintf(w, "  user agent: %s\n", r.UserAgent)
+		}
+		if r.Accept != "" {
+			fmt.Fprintf(w, "  accept: %s\n", r.Accept)
+		}
+	}
+	if t.Problem != nil {
+		fmt.Fprintf(w, "Result: invalid: %s\n", t.Problem)
+	} else {
+		fmt.Fprintln(w, "Result: valid")
+	}
+}
+
+// replayer has the VA check an authorization's challenge again without
+// storing the result, so subscriber-reported failures can be reproduced.
+type replayer struct {
+	sa  replaySA
+	va  replayVA
+	clk clock.Clock
+}
+
+func (r *replayer) replay(ctx context.Context, authzID string, challengeType string, userAgent string) (*transcript, error) {
+	authz, err := r.sa.GetAuthorization(ctx, authzID)
+	if err != nil {
+		return nil, err
+	}
+	var challenge *core.Challenge
+	for i := range authz.Challenges {
+		if authz.Challenges[i].Type == challengeType {
+			challenge = &authz.Challenges[i]
+			break
+		}
+	}
+	if challenge == nil {
+		return nil, fmt.Errorf("authorization %s has no %s challenge", authzID, challengeType)
+	}
+	// The challenge may never have been attempted, so the key authorization
+	// the subscriber should be serving is computed from their account key.
+	reg, err := r.sa.GetRegistration(ctx, authz.RegistrationID)
+	if err != nil {
+		return nil, err
+	}
+	challenge.ProvidedKeyAuthorization, err = challenge.ExpectedKeyAuthorization(reg.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &transcript{
+		AuthorizationID: authzID,
+		Identifier:      authz.Identifier.Value,
+		ChallengeType:   challengeType,
+		Started:         r.clk.Now(),
+	}
+	records, err := r.va.ReplayValidation(ctx, authz.Identifier.Value, *challenge, userAgent)
+	t.Duration = r.clk.Now().Sub(t.Started).String()
+	t.Records = records
+	if err != nil {
+		prob, ok := err.(*probs.ProblemDetails)
+		if !ok {
+			return nil, err
+		}
+		t.Problem = prob
+	}
+	return t, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type fakeReplaySA struct {
+	authz core.Authorization
+	key   *jose.JSONWebKey
+}
+
+func (sa *fakeReplaySA) GetAuthorization(_ context.Context, id string) (core.Authorization, error) {
+	if id != sa.authz.ID {
+		return core.Authorization{}, berrors.NotFoundError("no authorization %q", id)
+	}
+	return sa.authz, nil
+}
+
+func (sa *fakeReplaySA) GetRegistration(_ context.Context, id int64) (core.Registration, error) {
+	return core.Registration{ID: id, Key: sa.key}, nil
+}
+
+type fakeReplayVA struct {
+	challenge core.Challenge
+	userAgent string
+	records   []core.ValidationRecord
+	prob      *probs.ProblemDetails
+}
+
+func (va *fakeReplayVA) ReplayValidation(_ context.Context, _ string, challenge core.Challenge, userAgent string) ([]core.ValidationRecord, error) {
+	va.challenge = challenge
+	va.userAgent = userAgent
+	if va.prob != nil {
+		return va.records, va.prob
+	}
+	return va.records, nil
+}
+
+func TestReplay(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate key")
+	jwk := &jose.JSONWebKey{Key: key.Public()}
+	sa := &fakeReplaySA{
+		authz: core.Authorization{
+			ID:             "abc",
+			RegistrationID: 1,
+			Identifier:     core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "example.com"},
+			Challenges: []core.Challenge{
+				{Type: core.ChallengeTypeDNS01, Token: "dns"},
+				{Type: core.ChallengeTypeHTTP01, Token: "http"},
+			},
+		},
+		key: jwk,
+	}
+	va := &fakeReplayVA{records: []core.ValidationRecord{{
+		Hostname:          "example.com",
+		Port:              "80",
+		URL:               "http://example.com/.well-known/acme-challenge/http",
+		AddressesResolved: []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")},
+		AddressUsed:       net.ParseIP("10.0.0.1"),
+		UserAgent:         "support",
+		Accept:            "*/*",
+	}}}
+	r := &replayer{sa: sa, va: va, clk: clock.NewFake()}
+
+	tr, err := r.replay(context.Background(), "abc", core.ChallengeTypeHTTP01, "support")
+	test.AssertNotError(t, err, "replay failed")
+	test.AssertEquals(t, va.challenge.Token, "http")
+	test.AssertEquals(t, va.userAgent, "support")
+	// The key authorization is computed from the account key
+	expected, err := va.challenge.ExpectedKeyAuthorization(jwk)
+	test.AssertNotError(t, err, "Failed to compute key authorization")
+	test.AssertEquals(t, va.challenge.ProvidedKeyAuthorization, expected)
+	test.Assert(t, tr.Problem == nil, "Successful replay had a problem")
+
+	var buf bytes.Buffer
+	tr.writeText(&buf)
+	test.AssertContains(t, buf.String(), "Replayed http-01 validation of example.com for authorization abc")
+	test.AssertContains(t, buf.String(), "  resolved: 10.0.0.1, 10.0.0.2\n  used: 10.0.0.1\n")
+	test.AssertContains(t, buf.String(), "Result: valid\n")
+
+	// A failed validation is part of the transcript rather than an error
+	va.prob = probs.Unauthorized("Invalid response")
+	tr, err = r.replay(context.Background(), "abc", core.ChallengeTypeHTTP01, "")
+	test.AssertNotError(t, err, "replay of a failing validation failed")
+	test.AssertEquals(t, tr.Problem, va.prob)
+
+	_, err = r.replay(context.Background(), "abc", core.ChallengeTypeTLSSNI01, "")
+	test.AssertError(t, err, "replayed a challenge the authorization doesn't have")
+	_, err = r.replay(context.Background(), "def", core.ChallengeTypeHTTP01, "")
+	test.AssertError(t, err, "replayed a missing authorization")
+}
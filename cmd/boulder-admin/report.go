@@ -0,0 +1,198 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jmhodges/clock"
+)
+
+// reportDB is the subset of the gorp.DbMap functions used to build the
+// orphan report. Using this interface allows tests to swap out the dbMap.
+type reportDB interface {
+	Select(i interface{}, query string, args ...interface{}) ([]interface{}, error)
+}
+
+type orphanKind string
+
+const (
+	orderMissingAuthz orphanKind = "order-missing-authorization"
+	authzWithoutOrder orphanKind = "authorization-without-order"
+	certWithoutOrder  orphanKind = "certificate-without-order"
+)
+
+// orphan describes a single row left behind by a partial write, along with
+// the suggested repair.
+type orphan struct {
+	Kind           orphanKind `json:"kind"`
+	ID             string     `json:"id"`
+	RegistrationID int64      `json:"registrationID"`
+	Detail         string     `json:"detail"`
+	Suggestion     string     `json:"suggestion"`
+}
+
+// orphanReport is the result of a reconciliation run.
+type orphanReport struct {
+	Generated time.Time `json:"generated"`
+	Since     time.Time `json:"since"`
+	Orphans   []orphan  `json:"orphans"`
+}
+
+func (r *orphanReport) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Orphan report generated %s covering %s onwards\n",
+		r.Generated.Format(time.RFC3339), r.Since.Format(time.RFC3339))
+	if len(r.Orphans) == 0 {
+		fmt.Fprintln(w, "No problems found")
+		return
+	}
+	for _, o := range r.Orphans {
+		fmt.Fprintf(w, "%s id=[%s] regID=[%d]\n  %s\n  suggestion: %s\n",
+			o.Kind, o.ID, o.RegistrationID, o.Detail, o.Suggestion)
+	}
+}
+
+type orderAuthzRow struct {
+	OrderID        int64  `db:"orderID"`
+	RegistrationID int64  `db:"registrationID"`
+	AuthzID        string `db:"authzID"`
+}
+
+type authzRow struct {
+	ID             string `db:"id"`
+	RegistrationID int64  `db:"registrationID"`
+}
+
+type certRow struct {
+	Serial         string `db:"serial"`
+	RegistrationID int64  `db:"registrationID"`
+}
+
+// reconciler finds orders, authorizations and certificates that are missing
+// the rows that should link them together, as can happen after a partial
+// write during an incident.
+type reconciler struct {
+	dbMap  reportDB
+	clk    clock.Clock
+	window time.Duration
+}
+
+func (r *reconciler) report() (*orphanReport, error) {
+	now := r.clk.Now()
+	report := &orphanReport{
+		Generated: now,
+		Since:     now.Add(-r.window),
+	}
+	for _, find := range []func(now, since time.Time) ([]orphan, error){
+		r.ordersMissingAuthzs,
+		r.authzsWithoutOrders,
+		r.certsWithoutOrders,
+	} {
+		orphans, err := find(now, report.Since)
+		if err != nil {
+			return nil, err
+		}
+		report.Orphans = append(report.Orphans, orphans...)
+	}
+	return report, nil
+}
+
+// ordersMissingAuthzs finds unexpired orders linked by orderToAuthz to an
+// authorization ID that exists in neither the pending nor final authorization
+// tables.
+func (r *reconciler) ordersMissingAuthzs(now, _ time.Time) ([]orphan, error) {
+	var rows []orderAuthzRow
+	_, err := r.dbMap.Select(
+		&rows,
+		`SELECT o.id AS orderID, o.registrationID, ota.authzID
+		FROM orders AS o
+		JOIN orderToAuthz AS ota ON ota.orderID = o.id
+		LEFT JOIN authz AS a ON a.id = ota.authzID
+		LEFT JOIN pendingAuthorizations AS pa ON pa.id = ota.authzID
+		WHERE o.expires > :now
+		AND a.id IS NULL
+		AND pa.id IS NULL`,
+		map[string]interface{}{"now": now},
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	var orphans []orphan
+	for _, row := range rows {
+		orphans = append(orphans, orphan{
+			Kind:           orderMissingAuthz,
+			ID:             fmt.Sprintf("%d", row.OrderID),
+			RegistrationID: row.RegistrationID,
+			Detail:         fmt.Sprintf("order references authorization %q which does not exist", row.AuthzID),
+			Suggestion:     "the order can never become ready; set an order error or let it expire and have the subscriber create a new order",
+		})
+	}
+	return orphans, nil
+}
+
+// authzsWithoutOrders finds unexpired authorizations, belonging to accounts
+// that have created orders in the window, which aren't linked to any order.
+// Authorizations created through the ACME v1 new-authz flow legitimately have
+// no order, so these are reported for review rather than as certain damage.
+func (r *reconciler) authzsWithoutOrders(now, since time.Time) ([]orphan, error) {
+	var orphans []orphan
+	for _, table := range []string{"pendingAuthorizations", "authz"} {
+		var rows []authzRow
+		_, err := r.dbMap.Select(
+			&rows,
+			`SELECT a.id, a.registrationID
+			FROM `+table+` AS a
+			LEFT JOIN orderToAuthz AS ota ON ota.authzID = a.id
+			WHERE a.expires > :now
+			AND ota.orderID IS NULL
+			AND a.registrationID IN
+				(SELECT DISTINCT registrationID FROM orders WHERE created >= :since)`,
+			map[string]interface{}{"now": now, "since": since},
+		)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		for _, row := range rows {
+			orphans = append(orphans, orphan{
+				Kind:           authzWithoutOrder,
+				ID:             row.ID,
+				RegistrationID: row.RegistrationID,
+				Detail:         fmt.Sprintf("authorization in %s is not linked to any order", table),
+				Suggestion:     "if it was not created by the ACME v1 new-authz flow, deactivate it with admin-revoker auth-revoke or let it expire",
+			})
+		}
+	}
+	return orphans, nil
+}
+
+// certsWithoutOrders finds certificates issued in the window, to accounts
+// that have created orders in the window, which no order refers to by serial.
+func (r *reconciler) certsWithoutOrders(_, since time.Time) ([]orphan, error) {
+	var rows []certRow
+	_, err := r.dbMap.Select(
+		&rows,
+		`SELECT c.serial, c.registrationID
+		FROM certificates AS c
+		LEFT JOIN orders AS o ON o.certificateSerial = c.serial
+		WHERE c.issued >= :since
+		AND o.id IS NULL
+		AND c.registrationID IN
+			(SELECT DISTINCT registrationID FROM orders WHERE created >= :since)`,
+		map[string]interface{}{"since": since},
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	var orphans []orphan
+	for _, row := range rows {
+		orphans = append(orphans, orphan{
+			Kind:           certWithoutOrder,
+			ID:             row.Serial,
+			RegistrationID: row.RegistrationID,
+			Detail:         "certificate is not referenced by any order",
+			Suggestion:     "if it was issued by finalizing an ACME v2 order, set that order's certificateSerial to this serial",
+		})
+	}
+	return orphans, nil
+}
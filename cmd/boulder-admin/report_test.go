@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// fakeReportDB returns canned rows based on the type of the holder passed to
+// Select, and records the queries it was given.
+type fakeReportDB struct {
+	orderAuthzs []orderAuthzRow
+	authzs      []authzRow
+	certs       []certRow
+	queries     []string
+}
+
+func (db *fakeReportDB) Select(i interface{}, query string, _ ...interface{}) ([]interface{}, error) {
+	db.queries = append(db.queries, query)
+	switch holder := i.(type) {
+	case *[]orderAuthzRow:
+		*holder = db.orderAuthzs
+	case *[]authzRow:
+		if strings.Contains(query, "FROM authz ") {
+			*holder = db.authzs
+		}
+	case *[]certRow:
+		*holder = db.certs
+	}
+	return nil, nil
+}
+
+func TestReport(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC))
+	db := &fakeReportDB{
+		orderAuthzs: []orderAuthzRow{{OrderID: 7, RegistrationID: 1, AuthzID: "missing"}},
+		authzs:      []authzRow{{ID: "lonely", RegistrationID: 2}},
+		certs:       []certRow{{Serial: "ff00", RegistrationID: 3}},
+	}
+	r := &reconciler{dbMap: db, clk: fc, window: 24 * time.Hour}
+
+	report, err := r.report()
+	test.AssertNotError(t, err, "Failed to generate report")
+	test.AssertEquals(t, report.Since, fc.Now().Add(-24*time.Hour))
+	test.AssertEquals(t, len(db.queries), 4)
+	test.AssertEquals(t, len(report.Orphans), 3)
+
+	test.AssertEquals(t, report.Orphans[0].Kind, orderMissingAuthz)
+	test.AssertEquals(t, report.Orphans[0].ID, "7")
+	test.Assert(t, strings.Contains(report.Orphans[0].Detail, `"missing"`), "Detail didn't name the missing authorization")
+	test.AssertEquals(t, report.Orphans[1].Kind, authzWithoutOrder)
+	test.AssertEquals(t, report.Orphans[1].ID, "lonely")
+	test.AssertEquals(t, report.Orphans[2].Kind, certWithoutOrder)
+	test.AssertEquals(t, report.Orphans[2].RegistrationID, int64(3))
+
+	var buf bytes.Buffer
+	report.writeText(&buf)
+	test.Assert(t, strings.Contains(buf.String(), "certificate-without-order id=[ff00] regID=[3]"), "Text report missing certificate")
+	test.Assert(t, strings.Contains(buf.String(), "suggestion: "), "Text report missing suggestions")
+}
+
+func TestReportEmpty(t *testing.T) {
+	r := &reconciler{dbMap: &fakeReportDB{}, clk: clock.NewFake(), window: time.Hour}
+	report, err := r.report()
+	test.AssertNotError(t, err, "Failed to generate report")
+	test.AssertEquals(t, len(report.Orphans), 0)
+
+	var buf bytes.Buffer
+	report.writeText(&buf)
+	test.Assert(t, strings.Contains(buf.String(), "No problems found"), "Empty report didn't say so")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/ratelimit"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+// statsSA is the subset of the SA used to gather account statistics. Using
+// this interface allows tests to swap out the SA.
+type statsSA interface {
+	GetAccountStats(ctx context.Context, req *sapb.RegistrationID) (*sapb.AccountStats, error)
+	CountOrders(ctx context.Context, acctID int64, earliest, latest time.Time) (int, error)
+}
+
+// rateLimitUsage is how much of a per-account rate limit an account has
+// consumed in the limit's current window.
+type rateLimitUsage struct {
+	Name      string `json:"name"`
+	Count     int64  `json:"count"`
+	Threshold int    `json:"threshold"`
+	Window    string `json:"window"`
+}
+
+// accountStats is the result of an account-stats run.
+type accountStats struct {
+	RegistrationID             int64            `json:"registrationID"`
+	Generated                  time.Time        `json:"generated"`
+	CertificatesLast7Days      int64            `json:"certificatesLast7Days"`
+	CertificatesLast30Days     int64            `json:"certificatesLast30Days"`
+	CertificatesLast90Days     int64            `json:"certificatesLast90Days"`
+	FailedValidationsLast7Days int64            `json:"failedValidationsLast7Days"`
+	PendingAuthorizations      int64            `json:"pendingAuthorizations"`
+	RateLimits                 []rateLimitUsage `json:"rateLimits,omitempty"`
+}
+
+func (s *accountStats) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Statistics for registration %d generated %s\n",
+		s.RegistrationID, s.Generated.Format(time.RFC3339))
+	fmt.Fprintf(w, "  certificates issued: %d (7 days), %d (30 days), %d (90 days)\n",
+		s.CertificatesLast7Days, s.CertificatesLast30Days, s.CertificatesLast90Days)
+	fmt.Fprintf(w, "  failed validations: %d (7 days)\n", s.FailedValidationsLast7Days)
+	fmt.Fprintf(w, "  pending authorizations: %d\n", s.PendingAuthorizations)
+	for _, rl := range s.RateLimits {
+		fmt.Fprintf(w, "  %s: %d of %d per %s\n", rl.Name, rl.Count, rl.Threshold, rl.Window)
+	}
+}
+
+// statsReporter gathers the counters support staff need when answering
+// questions about, or investigating abuse by, a single account.
+type statsReporter struct {
+	sa  statsSA
+	clk clock.Clock
+	// limits, if not nil, are the RA's rate limit policies, used to report how
+	// much of each enabled per-account limit the account has consumed.
+	limits ratelimit.Limits
+}
+
+func (r *statsReporter) report(ctx context.Context, regID int64) (*accountStats, error) {
+	pb, err := r.sa.GetAccountStats(ctx, &sapb.RegistrationID{Id: &regID})
+	if err != nil {
+		return nil, err
+	}
+	now := r.clk.Now()
+	stats := &accountStats{
+		RegistrationID:             regID,
+		Generated:                  now,
+		CertificatesLast7Days:      pb.GetCertificatesLast7Days(),
+		CertificatesLast30Days:     pb.GetCertificatesLast30Days(),
+		CertificatesLast90Days:     pb.GetCertificatesLast90Days(),
+		FailedValidationsLast7Days: pb.GetFailedValidationsLast7Days(),
+		PendingAuthorizations:      pb.GetPendingAuthorizations(),
+	}
+	if r.limits == nil {
+		return stats, nil
+	}
+
+	// Neither limit has a meaningful override key, matching the RA.
+	noKey := ""
+	if limit := r.limits.PendingAuthorizationsPerAccount(); limit.Enabled() {
+		stats.RateLimits = append(stats.RateLimits, rateLimitUsage{
+			Name:      "pendingAuthorizationsPerAccount",
+			Count:     stats.PendingAuthorizations,
+			Threshold: limit.GetThreshold(noKey, regID),
+			Window:    limit.Window.Duration.String(),
+		})
+	}
+	if limit := r.limits.NewOrdersPerAccount(); limit.Enabled() {
+		count, err := r.sa.CountOrders(ctx, regID, limit.WindowBegin(now), now)
+		if err != nil {
+			return nil, err
+		}
+		stats.RateLimits = append(stats.RateLimits, rateLimitUsage{
+			Name:      "newOrdersPerAccount",
+			Count:     int64(count),
+			Threshold: limit.GetThreshold(noKey, regID),
+			Window:    limit.Window.Duration.String(),
+		})
+	}
+	return stats, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/ratelimit"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type fakeStatsSA struct {
+	orders   int
+	earliest time.Time
+}
+
+func (sa *fakeStatsSA) GetAccountStats(_ context.Context, req *sapb.RegistrationID) (*sapb.AccountStats, error) {
+	one, two, three, four, five := int64(1), int64(2), int64(3), int64(4), int64(5)
+	return &sapb.AccountStats{
+		CertificatesLast7Days:      &one,
+		CertificatesLast30Days:     &two,
+		CertificatesLast90Days:     &three,
+		FailedValidationsLast7Days: &four,
+		PendingAuthorizations:      &five,
+	}, nil
+}
+
+func (sa *fakeStatsSA) CountOrders(_ context.Context, _ int64, earliest, _ time.Time) (int, error) {
+	sa.earliest = earliest
+	return sa.orders, nil
+}
+
+func TestAccountStats(t *testing.T) {
+	fc := clock.NewFake()
+	sa := &fakeStatsSA{orders: 7}
+	r := &statsReporter{sa: sa, clk: fc}
+
+	stats, err := r.report(context.Background(), 10)
+	test.AssertNotError(t, err, "report failed")
+	test.AssertEquals(t, stats.RegistrationID, int64(10))
+	test.AssertEquals(t, stats.CertificatesLast90Days, int64(3))
+	test.AssertEquals(t, len(stats.RateLimits), 0)
+
+	r.limits = ratelimit.New()
+	err = r.limits.LoadPolicies([]byte(`
+pendingAuthorizationsPerAccount:
+  window: 168h
+  threshold: 20
+newOrdersPerAccount:
+  window: 3h
+  threshold: 300
+  registrationOverrides:
+    10: 1000
+`))
+	test.AssertNotError(t, err, "Couldn't load rate limit policies")
+	stats, err = r.report(context.Background(), 10)
+	test.AssertNotError(t, err, "report failed")
+	test.AssertDeepEquals(t, stats.RateLimits, []rateLimitUsage{
+		{Name: "pendingAuthorizationsPerAccount", Count: 5, Threshold: 20, Window: "168h0m0s"},
+		{Name: "newOrdersPerAccount", Count: 7, Threshold: 1000, Window: "3h0m0s"},
+	})
+	test.AssertEquals(t, sa.earliest, fc.Now().Add(-3*time.Hour))
+
+	var buf bytes.Buffer
+	stats.writeText(&buf)
+	test.AssertContains(t, buf.String(), "certificates issued: 1 (7 days), 2 (30 days), 3 (90 days)\n")
+	test.AssertContains(t, buf.String(), "newOrdersPerAccount: 7 of 1000 per 3h0m0s\n")
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// manifestFile is the name of the manifest within a backup directory.
+const manifestFile = "manifest.json"
+
+// backupTable describes a table included in backups. Tables are exported in
+// key order, a chunk at a time, and restored in the order they are listed so
+// that foreign key constraints are satisfied.
+type backupTable struct {
+	Name string
+	Key  string
+}
+
+var backupTables = []backupTable{
+	{Name: "registrations", Key: "id"},
+	{Name: "certificates", Key: "serial"},
+	{Name: "certificateStatus", Key: "serial"},
+}
+
+// manifest records the contents of a backup. It is written last, so a backup
+// without one is incomplete.
+type manifest struct {
+	Created time.Time       `json:"created"`
+	Tables  []tableManifest `json:"tables"`
+}
+
+type tableManifest struct {
+	Name    string          `json:"name"`
+	Columns []string        `json:"columns"`
+	Rows    int64           `json:"rows"`
+	Chunks  []chunkManifest `json:"chunks"`
+}
+
+type chunkManifest struct {
+	File   string `json:"file"`
+	Rows   int64  `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// value is the JSON encoding of a single column value. Exactly one field is
+// set, except for empty byte strings which have none. NULL is encoded as a
+// JSON null rather than as a value.
+type value struct {
+	Bytes []byte     `json:"b,omitempty"`
+	Int   *int64     `json:"i,omitempty"`
+	Float *float64   `json:"f,omitempty"`
+	Time  *time.Time `json:"t,omitempty"`
+}
+
+// encodeRow encodes the values scanned from a row as a single line of JSON.
+func encodeRow(vals []interface{}) ([]byte, error) {
+	row := make([]*value, len(vals))
+	for i, v := range vals {
+		switch v := v.(type) {
+		case nil:
+		case []byte:
+			row[i] = &value{Bytes: v}
+		case string:
+			row[i] = &value{Bytes: []byte(v)}
+		case int64:
+			row[i] = &value{Int: &v}
+		case float64:
+			row[i] = &value{Float: &v}
+		case time.Time:
+			row[i] = &value{Time: &v}
+		default:
+			return nil, fmt.Errorf("unsupported column type %T", v)
+		}
+	}
+	line, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// decodeRow decodes a line written by encodeRow into values that can be
+// passed as query arguments.
+func decodeRow(line []byte) ([]interface{}, error) {
+	var row []*value
+	if err := json.Unmarshal(line, &row); err != nil {
+		return nil, err
+	}
+	vals := make([]interface{}, len(row))
+	for i, v := range row {
+		switch {
+		case v == nil:
+		case v.Int != nil:
+			vals[i] = *v.Int
+		case v.Float != nil:
+			vals[i] = *v.Float
+		case v.Time != nil:
+			vals[i] = *v.Time
+		case v.Bytes != nil:
+			vals[i] = v.Bytes
+		default:
+			vals[i] = []byte{}
+		}
+	}
+	return vals, nil
+}
+
+// queryer is satisfied by *sql.Tx, which export uses so that every table is
+// read from the same snapshot.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// exportTable writes table to dir in chunks of up to chunkSize rows, walking
+// the table in key order.
+func exportTable(ctx context.Context, q queryer, table backupTable, dir string, chunkSize int) (*tableManifest, error) {
+	tm := &tableManifest{Name: table.Name}
+	var lastKey interface{}
+	for {
+		query := fmt.Sprintf("SELECT * FROM `%s` ORDER BY `%s` LIMIT ?", table.Name, table.Key)
+		args := []interface{}{chunkSize}
+		if lastKey != nil {
+			query = fmt.Sprintf("SELECT * FROM `%s` WHERE `%s` > ? ORDER BY `%s` LIMIT ?",
+				table.Name, table.Key, table.Key)
+			args = []interface{}{lastKey, chunkSize}
+		}
+		rows, err := q.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("querying %s: %s", table.Name, err)
+		}
+		columns, lines, key, err := readChunk(rows, table.Key)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %s", table.Name, err)
+		}
+		if tm.Columns == nil {
+			tm.Columns = columns
+		}
+		if len(lines) == 0 {
+			return tm, nil
+		}
+		chunk, err := writeChunk(dir, fmt.Sprintf("%s-%06d.jsonl", table.Name, len(tm.Chunks)+1), lines)
+		if err != nil {
+			return nil, err
+		}
+		tm.Chunks = append(tm.Chunks, *chunk)
+		tm.Rows += chunk.Rows
+		if len(lines) < chunkSize {
+			return tm, nil
+		}
+		lastKey = key
+	}
+}
+
+// readChunk encodes each of rows, returning the column names and the value of
+// keyColumn in the last row.
+func readChunk(rows *sql.Rows, keyColumn string) ([]string, [][]byte, interface{}, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keyIndex := -1
+	for i, c := range columns {
+		if c == keyColumn {
+			keyIndex = i
+		}
+	}
+	if keyIndex == -1 {
+		return nil, nil, nil, fmt.Errorf("no %q column", keyColumn)
+	}
+	var lines [][]byte
+	var lastKey interface{}
+	for rows.Next() {
+		vals := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, nil, err
+		}
+		line, err := encodeRow(vals)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		lines = append(lines, line)
+		lastKey = vals[keyIndex]
+	}
+	return columns, lines, lastKey, rows.Err()
+}
+
+// writeChunk writes lines to a new file in dir and returns its manifest entry.
+func writeChunk(dir, name string, lines [][]byte) (*chunkManifest, error) {
+	data := bytes.Join(lines, nil)
+	if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return &chunkManifest{
+		File:   name,
+		Rows:   int64(len(lines)),
+		SHA256: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+func writeManifest(dir string, m *manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, manifestFile), append(data, '\n'), 0600)
+}
+
+func readManifest(dir string) (*manifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %s", err)
+	}
+	return &m, nil
+}
+
+// readRows checks a chunk against its manifest entry and calls fn with each
+// decoded row.
+func readRows(dir string, chunk chunkManifest, columns int, fn func([]interface{}) error) error {
+	if chunk.File != filepath.Base(chunk.File) {
+		return fmt.Errorf("chunk %q is outside the backup directory", chunk.File)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, chunk.File))
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != chunk.SHA256 {
+		return fmt.Errorf("chunk %s has SHA-256 %x, expected %s", chunk.File, sum, chunk.SHA256)
+	}
+	var rows int64
+	r := bufio.NewReader(bytes.NewReader(data))
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		} else if err != nil && err != io.EOF {
+			return err
+		}
+		vals, err := decodeRow(line)
+		if err != nil {
+			return fmt.Errorf("chunk %s row %d: %s", chunk.File, rows+1, err)
+		}
+		if len(vals) != columns {
+			return fmt.Errorf("chunk %s row %d has %d columns, expected %d",
+				chunk.File, rows+1, len(vals), columns)
+		}
+		if fn != nil {
+			if err := fn(vals); err != nil {
+				return fmt.Errorf("chunk %s row %d: %s", chunk.File, rows+1, err)
+			}
+		}
+		rows++
+	}
+	if rows != chunk.Rows {
+		return fmt.Errorf("chunk %s has %d rows, expected %d", chunk.File, rows, chunk.Rows)
+	}
+	return nil
+}
+
+// backedUpTable returns true if name is the name of one of backupTables. The
+// table names in a manifest are interpolated into SQL when it's restored, so
+// only these are accepted.
+func backedUpTable(name string) bool {
+	for _, table := range backupTables {
+		if table.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyBackup checks every chunk in the backup in dir against the manifest,
+// without touching a database.
+func verifyBackup(dir string) (*manifest, error) {
+	m, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, tm := range m.Tables {
+		if !backedUpTable(tm.Name) {
+			return nil, fmt.Errorf("table %q isn't one that is backed up", tm.Name)
+		}
+		var rows int64
+		for _, chunk := range tm.Chunks {
+			if err := readRows(dir, chunk, len(tm.Columns), nil); err != nil {
+				return nil, fmt.Errorf("table %s: %s", tm.Name, err)
+			}
+			rows += chunk.Rows
+		}
+		if rows != tm.Rows {
+			return nil, fmt.Errorf("table %s has %d rows in chunks, expected %d", tm.Name, rows, tm.Rows)
+		}
+	}
+	return m, nil
+}
+
+// insertStatement returns an INSERT statement for a row of tm.
+func insertStatement(tm tableManifest) string {
+	columns := make([]string, len(tm.Columns))
+	for i, c := range tm.Columns {
+		columns[i] = "`" + c + "`"
+	}
+	return fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)",
+		tm.Name,
+		strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+}
+
+// countRows returns the number of rows in table.
+func countRows(ctx context.Context, db *sql.DB, table string) (int64, error) {
+	var count int64
+	err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(1) FROM `%s`", table)).Scan(&count)
+	return count, err
+}
+
+// tableColumns returns the set of column names of table in db. The column
+// names in a manifest are interpolated into SQL when it's restored, so only
+// these are accepted.
+func tableColumns(ctx context.Context, db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+		table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns[column] = true
+	}
+	return columns, rows.Err()
+}
+
+// restoreBackup verifies the backup in dir and loads it into db, one
+// transaction per chunk. The tables being restored must be empty, and every
+// column in the backup must exist in them. Once loaded,
+// the row count of each table is checked against the manifest.
+func restoreBackup(ctx context.Context, db *sql.DB, dir string) (*manifest, error) {
+	m, err := verifyBackup(dir)
+	if err != nil {
+		return nil, fmt.Errorf("verifying backup: %s", err)
+	}
+	for _, tm := range m.Tables {
+		columns, err := tableColumns(ctx, db, tm.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range tm.Columns {
+			if !columns[c] {
+				return nil, fmt.Errorf("table %s has no column %q", tm.Name, c)
+			}
+		}
+		count, err := countRows(ctx, db, tm.Name)
+		if err != nil {
+			return nil, err
+		}
+		if count != 0 {
+			return nil, fmt.Errorf("table %s has %d rows, refusing to restore over it", tm.Name, count)
+		}
+	}
+	for _, tm := range m.Tables {
+		insert := insertStatement(tm)
+		for _, chunk := range tm.Chunks {
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+			err = readRows(dir, chunk, len(tm.Columns), func(vals []interface{}) error {
+				_, err := tx.ExecContext(ctx, insert, vals...)
+				return err
+			})
+			if err != nil {
+				_ = tx.Rollback()
+				return nil, fmt.Errorf("table %s: %s", tm.Name, err)
+			}
+			if err := tx.Commit(); err != nil {
+				return nil, err
+			}
+		}
+		count, err := countRows(ctx, db, tm.Name)
+		if err != nil {
+			return nil, err
+		}
+		if count != tm.Rows {
+			return nil, fmt.Errorf("table %s has %d rows after restore, expected %d", tm.Name, count, tm.Rows)
+		}
+	}
+	return m, nil
+}
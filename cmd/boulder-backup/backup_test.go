@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestEncodeRow(t *testing.T) {
+	now := time.Date(2018, 3, 20, 15, 4, 5, 0, time.UTC)
+	line, err := encodeRow([]interface{}{int64(1), []byte("abc"), []byte{}, nil, now, 1.5})
+	test.AssertNotError(t, err, "encodeRow failed")
+	vals, err := decodeRow(line)
+	test.AssertNotError(t, err, "decodeRow failed")
+	test.AssertDeepEquals(t, vals, []interface{}{int64(1), []byte("abc"), []byte{}, nil, now, 1.5})
+
+	_, err = encodeRow([]interface{}{true})
+	test.AssertError(t, err, "encodeRow accepted an unsupported type")
+}
+
+func TestInsertStatement(t *testing.T) {
+	test.AssertEquals(t,
+		insertStatement(tableManifest{Name: "certificateStatus", Columns: []string{"serial", "status"}}),
+		"INSERT INTO `certificateStatus` (`serial`, `status`) VALUES (?, ?)")
+}
+
+func TestVerifyBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boulder-backup")
+	test.AssertNotError(t, err, "creating temp dir")
+	defer os.RemoveAll(dir)
+
+	var lines [][]byte
+	for i := int64(1); i <= 3; i++ {
+		line, err := encodeRow([]interface{}{i, []byte("jwk")})
+		test.AssertNotError(t, err, "encodeRow failed")
+		lines = append(lines, line)
+	}
+	first, err := writeChunk(dir, "registrations-000001.jsonl", lines[:2])
+	test.AssertNotError(t, err, "writeChunk failed")
+	second, err := writeChunk(dir, "registrations-000002.jsonl", lines[2:])
+	test.AssertNotError(t, err, "writeChunk failed")
+	m := &manifest{Tables: []tableManifest{{
+		Name:    "registrations",
+		Columns: []string{"id", "jwk"},
+		Rows:    3,
+		Chunks:  []chunkManifest{*first, *second},
+	}}}
+	err = writeManifest(dir, m)
+	test.AssertNotError(t, err, "writeManifest failed")
+
+	verified, err := verifyBackup(dir)
+	test.AssertNotError(t, err, "verifyBackup failed")
+	test.AssertDeepEquals(t, verified, m)
+
+	// A manifest disagreeing with the chunks fails verification
+	m.Tables[0].Rows = 4
+	test.AssertNotError(t, writeManifest(dir, m), "writeManifest failed")
+	_, err = verifyBackup(dir)
+	test.AssertError(t, err, "verifyBackup passed with the wrong row count")
+
+	m.Tables[0].Rows = 3
+	m.Tables[0].Chunks[1].File = "../registrations-000002.jsonl"
+	test.AssertNotError(t, writeManifest(dir, m), "writeManifest failed")
+	_, err = verifyBackup(dir)
+	test.AssertError(t, err, "verifyBackup read a chunk outside the backup")
+
+	// Only the tables that are backed up can be restored
+	m.Tables[0].Chunks[1].File = second.File
+	m.Tables[0].Name = "registrations` (id) SELECT 1; --"
+	test.AssertNotError(t, writeManifest(dir, m), "writeManifest failed")
+	_, err = verifyBackup(dir)
+	test.AssertError(t, err, "verifyBackup passed with an unknown table")
+
+	// A corrupted chunk fails verification
+	m.Tables[0].Name = "registrations"
+	test.AssertNotError(t, writeManifest(dir, m), "writeManifest failed")
+	err = ioutil.WriteFile(filepath.Join(dir, second.File), []byte(`[4,{"b":"andr"}]`+"\n"), 0600)
+	test.AssertNotError(t, err, "corrupting chunk")
+	_, err = verifyBackup(dir)
+	test.AssertError(t, err, "verifyBackup passed with a corrupted chunk")
+
+	_, err = verifyBackup(filepath.Join(dir, "nonexistent"))
+	test.AssertError(t, err, "verifyBackup passed without a manifest")
+}
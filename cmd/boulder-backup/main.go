@@ -0,0 +1,154 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+const usageString = `
+usage:
+boulder-backup export --config <path> --dir <backup-dir>
+boulder-backup verify --dir <backup-dir>
+boulder-backup restore --config <path> --dir <backup-dir>
+
+command descriptions:
+  export    Write a consistent logical export of the registrations,
+            certificates and certificateStatus tables to a new directory. Each
+            table is written in chunks of JSON lines, and a manifest records the
+            row count and SHA-256 of every chunk
+  verify    Check every chunk of a backup against its manifest
+  restore   Verify a backup and load it into the configured database. The
+            tables being restored must be empty. Row counts are checked against
+            the manifest once loaded
+
+args:
+  config    File path to the configuration file for this service
+  dir       Directory the backup is written to or read from
+`
+
+type config struct {
+	Backup struct {
+		cmd.DBConfig
+
+		// ChunkSize is the maximum number of rows in each chunk of an export.
+		// Defaults to 10000.
+		ChunkSize int
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+// exportBackup writes the backup tables to dir, which must not already exist.
+// All of the tables are read in a single read-only, repeatable read
+// transaction, so that the export is a consistent snapshot.
+func exportBackup(ctx context.Context, db *sql.DB, clk clock.Clock, dir string, chunkSize int) (*manifest, error) {
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return nil, err
+	}
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	m := &manifest{Created: clk.Now()}
+	for _, table := range backupTables {
+		tm, err := exportTable(ctx, tx, table, dir, chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		m.Tables = append(m.Tables, *tm)
+	}
+	if err := writeManifest(dir, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	dir := flagSet.String("dir", "", "Directory the backup is written to or read from")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+
+	if *dir == "" || len(flagSet.Args()) != 0 {
+		usage()
+	}
+	switch command {
+	case "verify":
+		m, err := verifyBackup(*dir)
+		cmd.FailOnError(err, "Backup failed verification")
+		for _, tm := range m.Tables {
+			fmt.Printf("%s: %d rows in %d chunks\n", tm.Name, tm.Rows, len(tm.Chunks))
+		}
+		fmt.Printf("Backup created %s verified\n", m.Created)
+		return
+	case "export", "restore":
+	default:
+		usage()
+	}
+	if *configFile == "" {
+		usage()
+	}
+
+	var c config
+	err = cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.Backup.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+	if c.Backup.ChunkSize <= 0 {
+		c.Backup.ChunkSize = 10000
+	}
+
+	logger := cmd.NewLogger(c.Syslog)
+	defer logger.AuditPanic()
+
+	dbURL, err := c.Backup.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbTLS, err := c.Backup.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.Backup.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.Backup.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.Backup.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
+	cmd.FailOnError(err, "Couldn't setup database connection")
+
+	ctx := context.Background()
+	var m *manifest
+	if command == "export" {
+		m, err = exportBackup(ctx, dbMap.Db, cmd.Clock(), *dir, c.Backup.ChunkSize)
+		cmd.FailOnError(err, "Export failed")
+	} else {
+		m, err = restoreBackup(ctx, dbMap.Db, *dir)
+		cmd.FailOnError(err, "Restore failed")
+	}
+	for _, tm := range m.Tables {
+		logger.Info(fmt.Sprintf("%s: %d rows in %d chunks", tm.Name, tm.Rows, len(tm.Chunks)))
+	}
+	logger.AuditInfo(fmt.Sprintf("Completed %s of backup %q created %s", command, *dir, m.Created))
+}
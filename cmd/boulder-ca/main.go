@@ -21,6 +21,7 @@ import (
 	"github.com/letsencrypt/boulder/goodkey"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/policy"
+	"github.com/letsencrypt/boulder/psl"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
 
@@ -37,10 +38,23 @@ func loadIssuers(c config) ([]ca.Issuer, error) {
 	for _, issuerConfig := range c.CA.Issuers {
 		priv, cert, err := loadIssuer(issuerConfig)
 		cmd.FailOnError(err, "Couldn't load private key")
-		issuers = append(issuers, ca.Issuer{
-			Signer: priv,
-			Cert:   cert,
-		})
+		var chain []*x509.Certificate
+		for _, constraintFile := range issuerConfig.ConstraintCertFiles {
+			constraintCert, err := core.LoadCert(constraintFile)
+			cmd.FailOnError(err, fmt.Sprintf("Couldn't load constraint certificate %s", constraintFile))
+			chain = append(chain, constraintCert)
+		}
+		issuer := ca.Issuer{
+			Signer:       priv,
+			Cert:         cert,
+			Chain:        chain,
+			SerialPrefix: issuerConfig.SerialPrefix,
+		}
+		if issuerConfig.OCSPResponder != nil {
+			issuer.OCSPSigner, issuer.OCSPCert, err = loadIssuer(*issuerConfig.OCSPResponder)
+			cmd.FailOnError(err, "Couldn't load OCSP responder key")
+		}
+		issuers = append(issuers, issuer)
 	}
 	return issuers, nil
 }
@@ -120,12 +134,13 @@ func main() {
 	cmd.FailOnError(err, "Failed to set feature flags")
 
 	scope, logger := cmd.StatsAndLogging(c.Syslog, c.CA.DebugAddr)
+	cmd.SetupTracing(c.CA.Tracing, "boulder-ca", logger)
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
 	cmd.FailOnError(c.PA.CheckChallenges(), "Invalid PA configuration")
 
-	pa, err := policy.New(c.PA.Challenges)
+	pa, err := policy.New(c.PA.Challenges, scope)
 	cmd.FailOnError(err, "Couldn't create PA")
 
 	if c.CA.HostnamePolicyFile == "" {
@@ -134,12 +149,14 @@ func main() {
 	err = pa.SetHostnamePolicyFile(c.CA.HostnamePolicyFile)
 	cmd.FailOnError(err, "Couldn't load hostname policy file")
 
+	if c.PA.PublicSuffixListURL != "" {
+		pslUpdater := psl.NewUpdater(c.PA.PublicSuffixListURL, c.PA.PublicSuffixListUpdateInterval.Duration, cmd.Clock(), logger, scope)
+		go pslUpdater.UpdateForever()
+	}
+
 	issuers, err := loadIssuers(c)
 	cmd.FailOnError(err, "Couldn't load issuers")
 
-	kp, err := goodkey.NewKeyPolicy(c.CA.WeakKeyFile)
-	cmd.FailOnError(err, "Unable to create key policy")
-
 	tlsConfig, err := c.CA.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
 
@@ -148,6 +165,17 @@ func main() {
 	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
 	sa := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(conn))
 
+	var blockedKeyCheck goodkey.BlockedKeyCheckFunc
+	if features.Enabled(features.BlockedKeyTable) {
+		blockedKeyCheck = sa.KeyBlocked
+	}
+	kp, err := goodkey.NewKeyPolicy(c.CA.WeakKeyFile, blockedKeyCheck)
+	cmd.FailOnError(err, "Unable to create key policy")
+
+	if features.Enabled(features.PolicyOverrides) {
+		pa.SetOverrideCheck(sa.PolicyOverridden)
+	}
+
 	cai, err := ca.NewCertificateAuthorityImpl(
 		c.CA,
 		sa,
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+const usageString = `
+usage:
+boulder-migrate up --config <path>
+boulder-migrate up --db-connect <url> --dirs <dir>[,<dir>...]
+boulder-migrate status --config <path>
+
+command descriptions:
+  up        Apply the migrations that haven't been applied, in version order.
+            A database lock is held while they run, so that concurrent runs
+            wait for each other. Applied versions are recorded in the
+            goose_db_version table
+  status    List the migrations and whether each has been applied
+
+args:
+  config      File path to the configuration file for this service
+  db-connect  Database URL, overriding the configuration file
+  dirs        Comma separated migration directories, overriding the
+              configuration file
+`
+
+type config struct {
+	Migrate struct {
+		cmd.DBConfig
+
+		// MigrationDirs are the directories holding the migrations, such as
+		// "sa/_db/migrations". Migrations from all of them are applied in
+		// version order.
+		MigrationDirs []string
+
+		// LockTimeout is how long to wait for another run to release the
+		// migration lock. Defaults to one minute.
+		LockTimeout cmd.ConfigDuration
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	dbConnect := flagSet.String("db-connect", "", "Database URL, overriding the configuration file")
+	dirs := flagSet.String("dirs", "", "Comma separated migration directories, overriding the configuration file")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+	if len(flagSet.Args()) != 0 || (command != "up" && command != "status") {
+		usage()
+	}
+
+	var c config
+	if *configFile != "" {
+		err = cmd.ReadConfigFile(*configFile, &c)
+		cmd.FailOnError(err, "Reading JSON config file into config structure")
+	}
+	if *dbConnect != "" {
+		c.Migrate.DBConnect = *dbConnect
+		c.Migrate.DBConnectFile = ""
+	}
+	if *dirs != "" {
+		c.Migrate.MigrationDirs = strings.Split(*dirs, ",")
+	}
+	if len(c.Migrate.MigrationDirs) == 0 {
+		usage()
+	}
+	if c.Migrate.LockTimeout.Duration == 0 {
+		c.Migrate.LockTimeout.Duration = time.Minute
+	}
+
+	logger := cmd.NewLogger(c.Syslog)
+	defer logger.AuditPanic()
+
+	migrations, err := sa.LoadMigrations(c.Migrate.MigrationDirs...)
+	cmd.FailOnError(err, "Couldn't load migrations")
+
+	dbURL, err := c.Migrate.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbTLS, err := c.Migrate.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.Migrate.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.Migrate.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.Migrate.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
+	cmd.FailOnError(err, "Couldn't setup database connection")
+
+	ctx := context.Background()
+	if command == "status" {
+		pending, err := sa.PendingMigrations(ctx, dbMap.Db, migrations)
+		cmd.FailOnError(err, "Couldn't read applied migrations")
+		isPending := make(map[int64]bool)
+		for _, m := range pending {
+			isPending[m.Version] = true
+		}
+		for _, m := range migrations {
+			status := "applied"
+			if isPending[m.Version] {
+				status = "pending"
+			}
+			fmt.Printf("%d_%s: %s\n", m.Version, m.Name, status)
+		}
+		return
+	}
+
+	applied, err := sa.Migrate(ctx, dbMap.Db, migrations, c.Migrate.LockTimeout.Duration)
+	for _, m := range applied {
+		logger.AuditInfo(fmt.Sprintf("Applied migration %d_%s", m.Version, m.Name))
+	}
+	cmd.FailOnError(err, "Migration failed")
+	logger.Info(fmt.Sprintf("Applied %d of %d migrations", len(applied), len(migrations)))
+}
@@ -47,6 +47,7 @@ func main() {
 	cmd.FailOnError(err, "Failed to set feature flags")
 
 	scope, logger := cmd.StatsAndLogging(c.Syslog, c.Publisher.DebugAddr)
+	cmd.SetupTracing(c.Publisher.Tracing, "boulder-publisher", logger)
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
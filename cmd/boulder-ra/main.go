@@ -9,14 +9,17 @@ import (
 	"github.com/letsencrypt/boulder/bdns"
 	caPB "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/csr"
 	"github.com/letsencrypt/boulder/ctpolicy"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/policy"
+	"github.com/letsencrypt/boulder/psl"
 	pubPB "github.com/letsencrypt/boulder/publisher/proto"
 	"github.com/letsencrypt/boulder/ra"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/ratelimit"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	vaPB "github.com/letsencrypt/boulder/va/proto"
 )
@@ -43,9 +46,18 @@ type config struct {
 		CAService        *cmd.GRPCClientConfig
 		PublisherService *cmd.GRPCClientConfig
 
+		// MaxNames is the maximum number of names a certificate or order may
+		// contain. Zero means no limit. When the RateLimitOverrides feature is
+		// enabled it can be overridden per account with "maxNamesPerCertificate"
+		// rate limit overrides, up to the CA's MaxNames.
 		MaxNames     int
 		DoNotForceCN bool
 
+		// CSRPolicy configures how the names and common name in CSRs are
+		// checked. It should match the CSRPolicy of the CA. If its CommonName is
+		// unset it is "promote" unless DoNotForceCN is set.
+		CSRPolicy csr.Policy
+
 		// Controls behaviour of the RA when asked to create a new authz for
 		// a name/regID that already has a valid authz. False preserves historic
 		// behaviour and ignores the existing authz and creates a new one. True
@@ -53,6 +65,29 @@ type config struct {
 		// creating another.
 		ReuseValidAuthz bool
 
+		// ValidAuthzReuseWindow and PendingAuthzReuseWindow are how long an
+		// existing valid or pending authorization must have left before it
+		// expires to be reused instead of creating a new one. They default to
+		// 24 hours, except for pending authorizations reused by new-authz which
+		// default to 1 hour.
+		ValidAuthzReuseWindow   cmd.ConfigDuration
+		PendingAuthzReuseWindow cmd.ConfigDuration
+
+		// FinalizeWorkers is the number of goroutines issuing certificates for
+		// finalized orders when the AsyncFinalize feature is enabled, and
+		// FinalizeQueueSize the number of orders that can wait for one before
+		// finalization falls back to issuing synchronously. They default to 10
+		// and 100. FinalizeTimeout bounds issuance for each order and defaults
+		// to 5 minutes.
+		FinalizeWorkers   int
+		FinalizeQueueSize int
+		FinalizeTimeout   cmd.ConfigDuration
+
+		// RateLimitOverrideRefresh is how often per-account rate limit
+		// overrides are reloaded from the database when the RateLimitOverrides
+		// feature is enabled. Defaults to 5 minutes.
+		RateLimitOverrideRefresh cmd.ConfigDuration
+
 		// AuthorizationLifetimeDays defines how long authorizations will be
 		// considered valid for. Given a value of 300 days when used with a 90-day
 		// cert lifetime, this allows creation of certs that will cover a whole
@@ -78,11 +113,46 @@ type config struct {
 		// NOTE: CTLogGroups is depreciated in favor of CTLogGroups2.
 		CTLogGroups  [][]cmd.LogDescription
 		CTLogGroups2 []cmd.CTGroup
+		// CTRequiredGroups is the number of CTLogGroups2 groups, run by
+		// distinct operators, that SCTs are required from. If zero an SCT is
+		// required from every group.
+		CTRequiredGroups int
 		// InformationalCTLogs are a set of CT logs we will always submit to
 		// but won't ever use the SCTs from. This may be because we want to
 		// test them or because they are not yet approved by a browser/root
 		// program but we still want our certs to end up there.
 		InformationalCTLogs []cmd.LogDescription
+		// SCTDeadline is how long to wait for SCTs from CTLogGroups2 before
+		// issuing without embedded SCTs, when the CTContingency feature is
+		// enabled. The CA's profile must allow CT contingency issuance.
+		SCTDeadline cmd.ConfigDuration
+		// EnforceCTPolicy, if true, refuses to issue certificates unless SCTs
+		// for their precertificates were obtained from the required number of
+		// log groups. It requires the EmbedSCTs feature and CTLogGroups or
+		// CTLogGroups2, and can't be combined with the CTContingency feature.
+		EnforceCTPolicy bool
+
+		// RateLimitRedis, if present, is a Redis server in which the
+		// RegistrationsPerIP, RegistrationsPerIPRange and NewOrdersPerAccount
+		// rate limits are counted, so that they are shared between RA
+		// instances. If the server can't be reached the database is used.
+		RateLimitRedis *cmd.RedisConfig
+
+		// RenewalExemptionWindow, if set, only exempts a request from the
+		// CertificatesPerName limit if the same account was issued a
+		// certificate for exactly the same names within the window. The SA
+		// must have the AccountFQDNSets feature enabled, or requests over the
+		// limit fail with an internal error.
+		RenewalExemptionWindow cmd.ConfigDuration
+
+		// ShortLivedAccounts is a list of account IDs that are issued
+		// short-lived certificates without an OCSP URL. The CA must have a
+		// ShortLived profile configured.
+		ShortLivedAccounts []int64
+
+		// ValidationMethodPolicy restricts the challenge types that can be used
+		// to validate wildcard names or names under specific domains.
+		ValidationMethodPolicy ra.ValidationMethodPolicy
 
 		Features map[string]bool
 	}
@@ -114,13 +184,14 @@ func main() {
 	cmd.FailOnError(err, "Failed to set feature flags")
 
 	scope, logger := cmd.StatsAndLogging(c.Syslog, c.RA.DebugAddr)
+	cmd.SetupTracing(c.RA.Tracing, "boulder-ra", logger)
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
 	// Validate PA config and set defaults if needed
 	cmd.FailOnError(c.PA.CheckChallenges(), "Invalid PA configuration")
 
-	pa, err := policy.New(c.PA.Challenges)
+	pa, err := policy.New(c.PA.Challenges, scope)
 	cmd.FailOnError(err, "Couldn't create PA")
 
 	if c.RA.HostnamePolicyFile == "" {
@@ -136,6 +207,11 @@ func main() {
 		logger.Info("No challengesWhitelistFile given, not loading")
 	}
 
+	if c.PA.PublicSuffixListURL != "" {
+		pslUpdater := psl.NewUpdater(c.PA.PublicSuffixListURL, c.PA.PublicSuffixListUpdateInterval.Duration, cmd.Clock(), logger, scope)
+		go pslUpdater.UpdateForever()
+	}
+
 	tlsConfig, err := c.RA.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
 
@@ -162,6 +238,10 @@ func main() {
 	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to Publisher")
 	pubc = bgrpc.NewPublisherClientWrapper(pubPB.NewPublisherClient(conn))
 
+	saConn, err := bgrpc.ClientSetup(c.RA.SAService, tlsConfig, clientMetrics)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
+	sac := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(saConn))
+
 	if c.RA.CTLogGroups != nil {
 		groups := make([]cmd.CTGroup, len(c.RA.CTLogGroups))
 		for i, logs := range c.RA.CTLogGroups {
@@ -170,15 +250,11 @@ func main() {
 				Logs: logs,
 			}
 		}
-		ctp = ctpolicy.New(pubc, groups, nil, logger)
+		ctp = ctpolicy.New(pubc, sac, groups, nil, 0, logger, scope)
 	} else if c.RA.CTLogGroups2 != nil {
-		ctp = ctpolicy.New(pubc, c.RA.CTLogGroups2, c.RA.InformationalCTLogs, logger)
+		ctp = ctpolicy.New(pubc, sac, c.RA.CTLogGroups2, c.RA.InformationalCTLogs, c.RA.CTRequiredGroups, logger, scope)
 	}
 
-	saConn, err := bgrpc.ClientSetup(c.RA.SAService, tlsConfig, clientMetrics)
-	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
-	sac := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(saConn))
-
 	// TODO(patf): remove once RA.authorizationLifetimeDays is deployed
 	authorizationLifetime := 300 * 24 * time.Hour
 	if c.RA.AuthorizationLifetimeDays != 0 {
@@ -191,17 +267,37 @@ func main() {
 		pendingAuthorizationLifetime = time.Duration(c.RA.PendingAuthorizationLifetimeDays) * 24 * time.Hour
 	}
 
-	kp, err := goodkey.NewKeyPolicy(c.RA.WeakKeyFile)
+	var blockedKeyCheck goodkey.BlockedKeyCheckFunc
+	if features.Enabled(features.BlockedKeyTable) {
+		blockedKeyCheck = sac.KeyBlocked
+	}
+	kp, err := goodkey.NewKeyPolicy(c.RA.WeakKeyFile, blockedKeyCheck)
 	cmd.FailOnError(err, "Unable to create key policy")
 
+	if features.Enabled(features.PolicyOverrides) {
+		pa.SetOverrideCheck(sac.PolicyOverridden)
+	}
+
+	if c.RA.MaxNames < 0 {
+		cmd.FailOnError(fmt.Errorf("MaxNames can't be negative"), "Invalid RA configuration")
+	}
+	csrPolicy := c.RA.CSRPolicy
+	if csrPolicy.CommonName == "" && !c.RA.DoNotForceCN {
+		csrPolicy.CommonName = csr.CNPromote
+	}
+	err = csrPolicy.Validate()
+	cmd.FailOnError(err, "Invalid CSR policy")
+
+	err = c.RA.ValidationMethodPolicy.Validate()
+	cmd.FailOnError(err, "Invalid validation method policy")
+
 	rai := ra.NewRegistrationAuthorityImpl(
 		cmd.Clock(),
 		logger,
 		scope,
 		c.RA.MaxContactsPerRegistration,
 		kp,
-		c.RA.MaxNames,
-		c.RA.DoNotForceCN,
+		csrPolicy,
 		c.RA.ReuseValidAuthz,
 		authorizationLifetime,
 		pendingAuthorizationLifetime,
@@ -214,6 +310,66 @@ func main() {
 	policyErr := rai.SetRateLimitPoliciesFile(c.RA.RateLimitPoliciesFilename)
 	cmd.FailOnError(policyErr, "Couldn't load rate limit policies file")
 	rai.PA = pa
+	rai.ValidationMethods = c.RA.ValidationMethodPolicy
+
+	if c.RA.ValidAuthzReuseWindow.Duration >= authorizationLifetime {
+		cmd.FailOnError(fmt.Errorf("ValidAuthzReuseWindow must be shorter than the authorization lifetime"), "Invalid RA configuration")
+	}
+	if c.RA.PendingAuthzReuseWindow.Duration >= pendingAuthorizationLifetime {
+		cmd.FailOnError(fmt.Errorf("PendingAuthzReuseWindow must be shorter than the pending authorization lifetime"), "Invalid RA configuration")
+	}
+	rai.ValidAuthzReuseWindow = c.RA.ValidAuthzReuseWindow.Duration
+	rai.PendingAuthzReuseWindow = c.RA.PendingAuthzReuseWindow.Duration
+
+	if features.Enabled(features.AsyncFinalize) {
+		if c.RA.FinalizeWorkers <= 0 {
+			c.RA.FinalizeWorkers = 10
+		}
+		if c.RA.FinalizeQueueSize <= 0 {
+			c.RA.FinalizeQueueSize = 100
+		}
+		if c.RA.FinalizeTimeout.Duration <= 0 {
+			c.RA.FinalizeTimeout.Duration = 5 * time.Minute
+		}
+		rai.StartFinalizeWorkers(c.RA.FinalizeWorkers, c.RA.FinalizeQueueSize, c.RA.FinalizeTimeout.Duration)
+	}
+
+	if c.RA.EnforceCTPolicy {
+		if ctp == nil {
+			cmd.FailOnError(fmt.Errorf("no CTLogGroups or CTLogGroups2 are configured"), "Can't enforce the CT policy")
+		}
+		if !features.Enabled(features.EmbedSCTs) {
+			cmd.FailOnError(fmt.Errorf("the EmbedSCTs feature is disabled"), "Can't enforce the CT policy")
+		}
+		if features.Enabled(features.CTContingency) {
+			cmd.FailOnError(fmt.Errorf("the CTContingency feature is enabled"), "Can't enforce the CT policy")
+		}
+	}
+	rai.EnforceCTPolicy = c.RA.EnforceCTPolicy
+
+	if features.Enabled(features.CTContingency) {
+		rai.SCTDeadline = c.RA.SCTDeadline.Duration
+	}
+	rai.RenewalExemptionWindow = c.RA.RenewalExemptionWindow.Duration
+	rai.MaxNames = c.RA.MaxNames
+
+	if c.RA.RateLimitRedis != nil {
+		password, err := c.RA.RateLimitRedis.Pass()
+		cmd.FailOnError(err, "Couldn't read Redis password")
+		timeout := c.RA.RateLimitRedis.Timeout.Duration
+		if timeout <= 0 {
+			timeout = 100 * time.Millisecond
+		}
+		rai.RateLimitCounter, err = ratelimit.NewRedisCounter(c.RA.RateLimitRedis.Addr, password, timeout, c.RA.RateLimitRedis.MaxIdleConns)
+		cmd.FailOnError(err, "Couldn't configure Redis rate limit counter")
+	}
+
+	if len(c.RA.ShortLivedAccounts) > 0 {
+		rai.ShortLivedAccounts = make(map[int64]bool, len(c.RA.ShortLivedAccounts))
+		for _, id := range c.RA.ShortLivedAccounts {
+			rai.ShortLivedAccounts[id] = true
+		}
+	}
 
 	raDNSTimeout, err := time.ParseDuration(c.Common.DNSTimeout)
 	cmd.FailOnError(err, "Couldn't parse RA DNS timeout")
@@ -244,13 +400,24 @@ func main() {
 	err = rai.UpdateIssuedCountForever()
 	cmd.FailOnError(err, "Updating total issuance count")
 
+	if features.Enabled(features.RateLimitOverrides) {
+		if c.RA.RateLimitOverrideRefresh.Duration <= 0 {
+			c.RA.RateLimitOverrideRefresh.Duration = 5 * time.Minute
+		}
+		err = rai.RefreshRateLimitOverridesForever(c.RA.RateLimitOverrideRefresh.Duration)
+		cmd.FailOnError(err, "Loading rate limit overrides")
+	}
+
 	serverMetrics := bgrpc.NewServerMetrics(scope)
 	grpcSrv, listener, err := bgrpc.NewServer(c.RA.GRPC, tlsConfig, serverMetrics)
 	cmd.FailOnError(err, "Unable to setup RA gRPC server")
 	gw := bgrpc.NewRegistrationAuthorityServer(rai)
 	rapb.RegisterRegistrationAuthorityServer(grpcSrv, gw)
 
-	go cmd.CatchSignals(logger, grpcSrv.GracefulStop)
+	go cmd.CatchSignals(logger, func() {
+		grpcSrv.GracefulStop()
+		rai.StopFinalizeWorkers()
+	})
 
 	err = cmd.FilterShutdownErrors(grpcSrv.Serve(listener))
 	cmd.FailOnError(err, "RA gRPC service failed")
@@ -2,14 +2,19 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"gopkg.in/go-gorp/gorp.v2"
 
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/sa"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
-	"github.com/prometheus/client_golang/prometheus"
 )
 
 type config struct {
@@ -21,6 +26,22 @@ type config struct {
 
 		// Max simultaneous SQL queries caused by a single RPC.
 		ParallelismPerRPC int
+
+		// Replicas are read-only database replicas that serve lag-tolerant
+		// read queries, such as rate limit counts, instead of the primary.
+		Replicas []cmd.DBConfig
+		// ReplicaHealthCheckInterval is how often replicas are pinged to
+		// decide whether to send them queries. Defaults to 10 seconds.
+		ReplicaHealthCheckInterval cmd.ConfigDuration
+
+		// ProcessingOrderTimeout, if set, is how long an order can be
+		// processing before it is failed, in case the RA finalizing it stopped.
+		// It should be longer than the RA's finalize timeout, and needs the
+		// AsyncFinalize feature and the AddOrderBeganProcessingAt migration.
+		ProcessingOrderTimeout cmd.ConfigDuration
+		// ProcessingOrderSweepInterval is how often orders are checked against
+		// ProcessingOrderTimeout. Defaults to one minute.
+		ProcessingOrderSweepInterval cmd.ConfigDuration
 	}
 
 	Syslog cmd.SyslogConfig
@@ -28,6 +49,7 @@ type config struct {
 
 func main() {
 	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	checkSchema := flag.String("check-schema", "", "Comma separated migration directories. If set, refuse to start unless all of their migrations have been applied to the database")
 	flag.Parse()
 	if *configFile == "" {
 		flag.Usage()
@@ -42,6 +64,7 @@ func main() {
 	cmd.FailOnError(err, "Failed to set feature flags")
 
 	scope, logger := cmd.StatsAndLogging(c.Syslog, c.SA.DebugAddr)
+	cmd.SetupTracing(c.SA.Tracing, "boulder-sa", logger)
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
@@ -50,20 +73,21 @@ func main() {
 	dbURL, err := saConf.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
 
-	dbMap, err := sa.NewDbMap(dbURL, saConf.DBConfig.MaxDBConns)
+	dbTLS, err := saConf.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbSettings := sa.DbSettings{
+		MaxOpenConns:    saConf.DBConfig.MaxDBConns,
+		MaxIdleConns:    saConf.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: saConf.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	}
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, dbSettings)
 	cmd.FailOnError(err, "Couldn't connect to SA database")
-
-	// Export the MaxDBConns
-	dbConnStat := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "max_db_connections",
-		Help: "Maximum number of DB connections allowed.",
-	})
-	scope.MustRegister(dbConnStat)
-	dbConnStat.Set(float64(saConf.DBConfig.MaxDBConns))
-
-	if saConf.DBConfig.MaxIdleDBConns != 0 {
-		dbMap.Db.SetMaxIdleConns(saConf.DBConfig.MaxIdleDBConns)
+	if *checkSchema != "" {
+		err = sa.CheckSchema(context.Background(), dbMap.Db, strings.Split(*checkSchema, ",")...)
+		cmd.FailOnError(err, "Database schema check failed")
 	}
+	sa.InitDBMetrics(dbMap, scope, dbSettings, "primary")
 	go sa.ReportDbConnCount(dbMap, scope)
 
 	parallel := saConf.ParallelismPerRPC
@@ -73,10 +97,50 @@ func main() {
 	sai, err := sa.NewSQLStorageAuthority(dbMap, cmd.Clock(), logger, scope, parallel)
 	cmd.FailOnError(err, "Failed to create SA impl")
 
+	if len(saConf.Replicas) > 0 {
+		var replicaMaps []*gorp.DbMap
+		for i, replicaConf := range saConf.Replicas {
+			replicaURL, err := replicaConf.URL()
+			cmd.FailOnError(err, "Couldn't load replica DB URL")
+			replicaTLS, err := replicaConf.TLSConfig()
+			cmd.FailOnError(err, "Couldn't load DB TLS config")
+			replicaSettings := sa.DbSettings{
+				MaxOpenConns:    replicaConf.MaxDBConns,
+				MaxIdleConns:    replicaConf.MaxIdleDBConns,
+				ConnMaxLifetime: replicaConf.ConnMaxLifetime.Duration,
+				TLS:             replicaTLS,
+			}
+			replicaMap, err := sa.NewDbMapWithSettings(replicaURL, replicaSettings)
+			cmd.FailOnError(err, "Couldn't connect to SA replica database")
+			sa.InitDBMetrics(replicaMap, scope, replicaSettings, fmt.Sprintf("replica%d", i))
+			sa.SetSQLDebug(replicaMap, logger)
+			replicaMaps = append(replicaMaps, replicaMap)
+		}
+		replicas := sa.NewReplicas(replicaMaps, logger)
+		interval := saConf.ReplicaHealthCheckInterval.Duration
+		if interval <= 0 {
+			interval = 10 * time.Second
+		}
+		go replicas.CheckHealthForever(interval)
+		sai.UseReplicas(replicas)
+	}
+
+	if saConf.ProcessingOrderTimeout.Duration > 0 {
+		if !features.Enabled(features.AsyncFinalize) {
+			cmd.FailOnError(fmt.Errorf("the AsyncFinalize feature is disabled"), "Can't fail stale processing orders")
+		}
+		interval := saConf.ProcessingOrderSweepInterval.Duration
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		go sai.FailStaleProcessingOrdersForever(saConf.ProcessingOrderTimeout.Duration, interval)
+	}
+
 	tls, err := c.SA.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
 	serverMetrics := bgrpc.NewServerMetrics(scope)
-	grpcSrv, listener, err := bgrpc.NewServer(c.SA.GRPC, tls, serverMetrics)
+	methodMetrics := sa.NewMethodMetrics(scope, cmd.Clock())
+	grpcSrv, listener, err := bgrpc.NewServer(c.SA.GRPC, tls, serverMetrics, methodMetrics.Intercept)
 	cmd.FailOnError(err, "Unable to setup SA gRPC server")
 	gw := bgrpc.NewStorageAuthorityServer(sai)
 	sapb.RegisterStorageAuthorityServer(grpcSrv, gw)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -19,6 +20,10 @@ type config struct {
 		cmd.ServiceConfig
 
 		UserAgent string
+		// HTTP configures the Accept and any extra headers of HTTP-01
+		// requests, whether connections are kept alive across redirects, and
+		// the largest response accepted.
+		HTTP va.HTTPConfig
 
 		IssuerDomain string
 
@@ -36,6 +41,11 @@ type config struct {
 		RemoteVAs                   []cmd.GRPCClientConfig
 		MaxRemoteValidationFailures int
 
+		// SourceAddresses, if set, are the local IP addresses that HTTP-01 and
+		// TLS-SNI validation connections are made from. Each validation uses
+		// the next address of the appropriate family in turn.
+		SourceAddresses []string
+
 		Features map[string]bool
 	}
 
@@ -64,6 +74,7 @@ func main() {
 	cmd.FailOnError(err, "Failed to set feature flags")
 
 	scope, logger := cmd.StatsAndLogging(c.Syslog, c.VA.DebugAddr)
+	cmd.SetupTracing(c.VA.Tracing, "boulder-va", logger)
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
@@ -136,6 +147,12 @@ func main() {
 		scope,
 		clk,
 		logger)
+	vai.HTTP = c.VA.HTTP
+	if len(c.VA.SourceAddresses) > 0 {
+		vai.SourcePool, err = va.NewSourcePool(c.VA.SourceAddresses)
+		cmd.FailOnError(err, "Invalid source addresses")
+		logger.Info(fmt.Sprintf("Making validation connections from %s", strings.Join(c.VA.SourceAddresses, ", ")))
+	}
 
 	serverMetrics := bgrpc.NewServerMetrics(scope)
 	grpcSrv, l, err := bgrpc.NewServer(c.VA.GRPC, tlsConfig, serverMetrics)
@@ -32,11 +32,28 @@ type config struct {
 
 		ShutdownStopTimeout cmd.ConfigDuration
 
+		// RequestTimeout bounds the handling of each request, including the
+		// RPCs made for it. It defaults to five minutes.
+		RequestTimeout cmd.ConfigDuration
+
 		SubscriberAgreementURL string
 
+		// StaticAssets are files, keyed by name, served from /static/<name>.
+		// SubscriberAgreementURL may point at one of them.
+		StaticAssets map[string]cmd.StaticAssetConfig
+
 		AcceptRevocationReason bool
 		AllowAuthzDeactivation bool
 
+		// ProblemDocsURL, if set, is the base URL of the documentation for
+		// each kind of failure, e.g. "https://example.com/docs/errors/". Each
+		// problem document links to it followed by the problem's code.
+		ProblemDocsURL string
+
+		// NoncePoolSize, if non-zero, is the number of nonces each WFE keeps
+		// pre-generated for the Replay-Nonce header.
+		NoncePoolSize int
+
 		TLS cmd.TLSConfig
 
 		RAService *cmd.GRPCClientConfig
@@ -86,14 +103,19 @@ func main() {
 	cmd.FailOnError(err, "Failed to set feature flags")
 
 	scope, logger := cmd.StatsAndLogging(c.Syslog, c.WFE.DebugAddr)
+	cmd.SetupTracing(c.WFE.Tracing, "boulder-wfe", logger)
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
-	kp, err := goodkey.NewKeyPolicy("") // don't load any weak keys
+	rac, sac := setupWFE(c, logger, scope)
+	var blockedKeyCheck goodkey.BlockedKeyCheckFunc
+	if features.Enabled(features.BlockedKeyTable) {
+		blockedKeyCheck = sac.KeyBlocked
+	}
+	kp, err := goodkey.NewKeyPolicy("", blockedKeyCheck) // don't load any weak keys
 	cmd.FailOnError(err, "Unable to create key policy")
 	wfe, err := wfe.NewWebFrontEndImpl(scope, cmd.Clock(), kp, logger)
 	cmd.FailOnError(err, "Unable to create WFE")
-	rac, sac := setupWFE(c, logger, scope)
 	wfe.RA = rac
 	wfe.SA = sac
 
@@ -105,12 +127,18 @@ func main() {
 	}
 
 	wfe.AllowOrigins = c.WFE.AllowOrigins
+	wfe.RequestTimeout = c.WFE.RequestTimeout.Duration
 	wfe.AcceptRevocationReason = c.WFE.AcceptRevocationReason
 	wfe.AllowAuthzDeactivation = c.WFE.AllowAuthzDeactivation
+	wfe.StartNoncePool(c.WFE.NoncePoolSize)
+	wfe.ProblemDocsURL = c.WFE.ProblemDocsURL
 
 	wfe.IssuerCert, err = cmd.LoadCert(c.Common.IssuerCert)
 	cmd.FailOnError(err, fmt.Sprintf("Couldn't read issuer cert [%s]", c.Common.IssuerCert))
 
+	wfe.StaticAssets, err = cmd.LoadStaticAssets(c.WFE.StaticAssets)
+	cmd.FailOnError(err, "Couldn't load static assets")
+
 	logger.Info(fmt.Sprintf("WFE using key policy: %#v", kp))
 
 	logger.Info(fmt.Sprintf("Server running, listening on %s...\n", c.WFE.ListenAddress))
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"context"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	netmail "net/mail"
 	"os"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
@@ -17,6 +22,7 @@ import (
 	"github.com/letsencrypt/boulder/goodkey"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	blog "github.com/letsencrypt/boulder/log"
+	bmail "github.com/letsencrypt/boulder/mail"
 	"github.com/letsencrypt/boulder/metrics"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
@@ -36,11 +42,83 @@ type config struct {
 
 		ShutdownStopTimeout cmd.ConfigDuration
 
+		// RequestTimeout bounds the handling of each request, including the
+		// RPCs made for it. It defaults to five minutes.
+		RequestTimeout cmd.ConfigDuration
+
 		SubscriberAgreementURL string
+		// RequireCurrentAgreement refuses new orders and finalization from
+		// accounts that agreed to an earlier SubscriberAgreementURL until they
+		// agree to the current one.
+		RequireCurrentAgreement bool
+
+		// StaticAssets are files, keyed by name, served from /static/<name>.
+		// SubscriberAgreementURL may point at one of them.
+		StaticAssets map[string]cmd.StaticAssetConfig
 
 		AcceptRevocationReason bool
 		AllowAuthzDeactivation bool
 
+		// AccountCacheTTL is how long accounts looked up for requests
+		// authenticated with a Key ID are cached. Each WFE instance only
+		// invalidates the accounts it updates itself, so for up to this long
+		// after an account is deactivated, or its key is rolled over, through
+		// another instance, this instance still accepts requests signed by
+		// the account's old key. Keep it to a few seconds. Zero disables the
+		// cache.
+		AccountCacheTTL cmd.ConfigDuration
+		// AccountCacheSize is the maximum number of cached accounts.
+		AccountCacheSize int
+
+		// ProblemDocsURL, if set, is the base URL of the documentation for
+		// each kind of failure, e.g. "https://example.com/docs/errors/". Each
+		// problem document links to it followed by the problem's code.
+		ProblemDocsURL string
+
+		// NoncePoolSize, if non-zero, is the number of nonces each WFE keeps
+		// pre-generated for the Replay-Nonce header.
+		NoncePoolSize int
+
+		// OrderPollLimit, if MaxPolls is non-zero, limits how many times each
+		// order can be polled per Window before clients are sent a 429. At most
+		// MaxOrders orders are tracked, defaulting to 100000.
+		OrderPollLimit struct {
+			MaxPolls  int
+			Window    cmd.ConfigDuration
+			MaxOrders int
+		}
+
+		// ContactVerification, if KeyFile is set, sends the email contacts of
+		// new and updated accounts a link to verify them.
+		ContactVerification struct {
+			cmd.SMTPConfig
+			// Path to a file containing a list of trusted root certificates
+			// for use during the SMTP connection.
+			SMTPTrustedRootFile string
+			From                string
+			Subject             string
+			// Path to a text/template email template. See
+			// wfe2.DefaultContactVerificationTemplate for its fields.
+			EmailTemplate string
+
+			// KeyFile contains the hex encoded key, of at least 32 bytes, that
+			// verification links are MACed with. Every WFE must use the same
+			// key.
+			KeyFile string
+			// LinkBase is the URL of this WFE's /verify-contact/ path, which
+			// tokens are appended to.
+			LinkBase string
+			// LinkLifetime is how long links are valid for, defaulting to
+			// 72 hours.
+			LinkLifetime cmd.ConfigDuration
+			// QueueSize is how many accounts can be waiting for verification
+			// messages before further ones are dropped, defaulting to 1000.
+			QueueSize int
+			// SendInterval is the least time between verification messages,
+			// to limit the rate they are sent at. Defaults to 100ms.
+			SendInterval cmd.ConfigDuration
+		}
+
 		TLS cmd.TLSConfig
 
 		RAService *cmd.GRPCClientConfig
@@ -50,6 +128,10 @@ type config struct {
 		// Certificates are read into the chain in the order they are defined in the
 		// slice of filenames.
 		CertificateChains map[string][]string
+		// AlternateCertificateChains maps AIA issuer URLs to chains, each a
+		// list of certificate filenames like those of CertificateChains, that
+		// clients can request instead of the chain in CertificateChains.
+		AlternateCertificateChains map[string][][]string
 
 		Features map[string]bool
 	}
@@ -63,6 +145,75 @@ type config struct {
 	}
 }
 
+// setupContactVerifier returns a ContactVerifier configured by c, or nil if
+// contact verification isn't configured.
+func setupContactVerifier(c config, sa core.StorageAuthority, logger blog.Logger, scope metrics.Scope) *wfe2.ContactVerifier {
+	cv := c.WFE.ContactVerification
+	if cv.KeyFile == "" {
+		return nil
+	}
+	contents, err := ioutil.ReadFile(cv.KeyFile)
+	cmd.FailOnError(err, "Reading contact verification key")
+	key, err := hex.DecodeString(strings.TrimSpace(string(contents)))
+	cmd.FailOnError(err, "Decoding contact verification key")
+
+	var smtpRoots *x509.CertPool
+	if cv.SMTPTrustedRootFile != "" {
+		pem, err := ioutil.ReadFile(cv.SMTPTrustedRootFile)
+		cmd.FailOnError(err, "Loading trusted roots file")
+		smtpRoots = x509.NewCertPool()
+		if !smtpRoots.AppendCertsFromPEM(pem) {
+			cmd.FailOnError(fmt.Errorf("no certificates found"), "Failed to parse root certs PEM")
+		}
+	}
+	fromAddress, err := netmail.ParseAddress(cv.From)
+	cmd.FailOnError(err, fmt.Sprintf("Could not parse from address: %s", cv.From))
+	smtpPassword, err := cv.PasswordConfig.Pass()
+	cmd.FailOnError(err, "Failed to load SMTP password")
+	mailClient := bmail.New(
+		cv.Server,
+		cv.Port,
+		cv.Username,
+		smtpPassword,
+		smtpRoots,
+		*fromAddress,
+		logger,
+		scope,
+		time.Second,
+		5*time.Minute)
+
+	templateText := wfe2.DefaultContactVerificationTemplate
+	if cv.EmailTemplate != "" {
+		data, err := ioutil.ReadFile(cv.EmailTemplate)
+		cmd.FailOnError(err, fmt.Sprintf("Could not read email template file [%s]", cv.EmailTemplate))
+		templateText = string(data)
+	}
+	tmpl, err := template.New("contact-verification").Parse(templateText)
+	cmd.FailOnError(err, "Could not parse email template")
+	subject := cv.Subject
+	if subject == "" {
+		subject = wfe2.DefaultContactVerificationSubject
+	}
+	lifetime := cv.LinkLifetime.Duration
+	if lifetime == 0 {
+		lifetime = 72 * time.Hour
+	}
+	queueSize := cv.QueueSize
+	if queueSize == 0 {
+		queueSize = 1000
+	}
+	sendInterval := cv.SendInterval.Duration
+	if sendInterval == 0 {
+		sendInterval = 100 * time.Millisecond
+	}
+
+	verifier, err := wfe2.NewContactVerifier(key, cv.LinkBase, lifetime, sa, mailClient, subject, tmpl,
+		queueSize, sendInterval, cmd.Clock(), logger, scope)
+	cmd.FailOnError(err, "Unable to create contact verifier")
+	go verifier.Run()
+	return verifier
+}
+
 // loadCertificateFile loads a PEM certificate from the certFile provided. It
 // validates that the PEM is well-formed with no leftover bytes, and contains
 // only a well-formed X509 certificate. If the cert file meets these
@@ -154,6 +305,29 @@ func loadCertificateChains(chainConfig map[string][]string) (map[string][]byte,
 	return results, nil
 }
 
+// loadAlternateCertificateChains loads each of the alternate chains for each
+// AIA Issuer URL, in the same way as loadCertificateChains. Every AIA Issuer
+// URL with alternates must have a chain in CertificateChains too.
+func loadAlternateCertificateChains(chainConfig map[string][][]string, defaultChains map[string][]byte) (map[string][][]byte, error) {
+	results := make(map[string][][]byte, len(chainConfig))
+	for aiaIssuerURL, chains := range chainConfig {
+		if _, ok := defaultChains[aiaIssuerURL]; !ok {
+			return nil, fmt.Errorf(
+				"AlternateCertificateChains entry for AIA issuer url %q has no "+
+					"CertificateChains entry",
+				aiaIssuerURL)
+		}
+		for _, certFiles := range chains {
+			chain, err := loadCertificateChains(map[string][]string{aiaIssuerURL: certFiles})
+			if err != nil {
+				return nil, err
+			}
+			results[aiaIssuerURL] = append(results[aiaIssuerURL], chain[aiaIssuerURL])
+		}
+	}
+	return results, nil
+}
+
 func setupWFE(c config, logger blog.Logger, stats metrics.Scope) (core.RegistrationAuthority, core.StorageAuthority) {
 	tlsConfig, err := c.WFE.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
@@ -183,21 +357,29 @@ func main() {
 
 	certChains, err := loadCertificateChains(c.WFE.CertificateChains)
 	cmd.FailOnError(err, "Couldn't read configured CertificateChains")
+	alternateChains, err := loadAlternateCertificateChains(c.WFE.AlternateCertificateChains, certChains)
+	cmd.FailOnError(err, "Couldn't read configured AlternateCertificateChains")
 
 	err = features.Set(c.WFE.Features)
 	cmd.FailOnError(err, "Failed to set feature flags")
 
 	scope, logger := cmd.StatsAndLogging(c.Syslog, c.WFE.DebugAddr)
+	cmd.SetupTracing(c.WFE.Tracing, "boulder-wfe2", logger)
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
-	kp, err := goodkey.NewKeyPolicy("") // don't load any weak keys
+	rac, sac := setupWFE(c, logger, scope)
+	var blockedKeyCheck goodkey.BlockedKeyCheckFunc
+	if features.Enabled(features.BlockedKeyTable) {
+		blockedKeyCheck = sac.KeyBlocked
+	}
+	kp, err := goodkey.NewKeyPolicy("", blockedKeyCheck) // don't load any weak keys
 	cmd.FailOnError(err, "Unable to create key policy")
 	wfe, err := wfe2.NewWebFrontEndImpl(scope, cmd.Clock(), kp, certChains, logger)
 	cmd.FailOnError(err, "Unable to create WFE")
-	rac, sac := setupWFE(c, logger, scope)
 	wfe.RA = rac
 	wfe.SA = sac
+	wfe.AlternateCertificateChains = alternateChains
 
 	// TODO: remove this check once the production config uses the SubscriberAgreementURL in the wfe section
 	if c.WFE.SubscriberAgreementURL != "" {
@@ -206,13 +388,33 @@ func main() {
 		wfe.SubscriberAgreementURL = c.SubscriberAgreementURL
 	}
 
+	wfe.RequireCurrentAgreement = c.WFE.RequireCurrentAgreement
 	wfe.AllowOrigins = c.WFE.AllowOrigins
+	wfe.RequestTimeout = c.WFE.RequestTimeout.Duration
 	wfe.AcceptRevocationReason = c.WFE.AcceptRevocationReason
 	wfe.AllowAuthzDeactivation = c.WFE.AllowAuthzDeactivation
+	wfe.StartNoncePool(c.WFE.NoncePoolSize)
+	wfe.ProblemDocsURL = c.WFE.ProblemDocsURL
+	if c.WFE.AccountCacheTTL.Duration > 0 {
+		wfe.AccountCache = wfe2.NewAccountCache(cmd.Clock(), c.WFE.AccountCacheTTL.Duration, c.WFE.AccountCacheSize, scope)
+	}
+	if limit := c.WFE.OrderPollLimit; limit.MaxPolls > 0 {
+		if limit.Window.Duration <= 0 {
+			cmd.FailOnError(fmt.Errorf("OrderPollLimit.Window must be positive"), "Invalid WFE configuration")
+		}
+		if limit.MaxOrders <= 0 {
+			limit.MaxOrders = 100000
+		}
+		wfe.OrderPollLimiter = wfe2.NewOrderPollLimiter(cmd.Clock(), limit.MaxPolls, limit.Window.Duration, limit.MaxOrders, scope)
+	}
+	wfe.ContactVerifier = setupContactVerifier(c, sac, logger, scope)
 
 	wfe.IssuerCert, err = cmd.LoadCert(c.Common.IssuerCert)
 	cmd.FailOnError(err, fmt.Sprintf("Couldn't read issuer cert [%s]", c.Common.IssuerCert))
 
+	wfe.StaticAssets, err = cmd.LoadStaticAssets(c.WFE.StaticAssets)
+	cmd.FailOnError(err, "Couldn't load static assets")
+
 	logger.Info(fmt.Sprintf("WFE using key policy: %#v", kp))
 
 	logger.Info(fmt.Sprintf("Server running, listening on %s...\n", c.WFE.ListenAddress))
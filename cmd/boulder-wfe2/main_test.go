@@ -134,3 +134,35 @@ func TestLoadCertificateChains(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadAlternateCertificateChains(t *testing.T) {
+	certBytesA, err := ioutil.ReadFile("../../test/test-ca.pem")
+	test.AssertNotError(t, err, "Error reading../../test/test-ca.pem")
+	certBytesB, err := ioutil.ReadFile("../../test/test-ca2.pem")
+	test.AssertNotError(t, err, "Error reading../../test/test-ca2.pem")
+	defaults := map[string][]byte{"http://issuer.com": []byte("\n")}
+
+	result, err := loadAlternateCertificateChains(map[string][][]string{
+		"http://issuer.com": {
+			{"../../test/test-ca.pem"},
+			{"../../test/test-ca.pem", "../../test/test-ca2.pem"},
+		},
+	}, defaults)
+	test.AssertNotError(t, err, "loadAlternateCertificateChains failed")
+	test.AssertDeepEquals(t, result, map[string][][]byte{
+		"http://issuer.com": {
+			[]byte(fmt.Sprintf("\n%s", string(certBytesA))),
+			[]byte(fmt.Sprintf("\n%s\n%s", string(certBytesA), string(certBytesB))),
+		},
+	})
+
+	_, err = loadAlternateCertificateChains(map[string][][]string{
+		"http://other-issuer.com": {{"../../test/test-ca.pem"}},
+	}, defaults)
+	test.AssertError(t, err, "Loaded alternates for an issuer without a default chain")
+
+	_, err = loadAlternateCertificateChains(map[string][][]string{
+		"http://issuer.com": {{}},
+	}, defaults)
+	test.AssertError(t, err, "Loaded an empty alternate chain")
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// certType describes which kind of certificate a ceremony produces, it
+// determines which fields of the certificate profile are required.
+type certType int
+
+const (
+	rootCert certType = iota
+	intermediateCert
+	crossCert
+)
+
+// certProfile contains the information required to generate a certificate
+type certProfile struct {
+	// SignatureAlgorithm should contain one of the allowed signature
+	// algorithms, i.e. SHA256WithRSA, SHA384WithRSA, SHA512WithRSA,
+	// ECDSAWithSHA256, ECDSAWithSHA384 or ECDSAWithSHA512
+	SignatureAlgorithm string `yaml:"signature-algorithm"`
+
+	// CommonName should contain the requested subject common name. It must
+	// be empty for cross-signed certificates, which copy the subject of the
+	// certificate being cross-signed.
+	CommonName string `yaml:"common-name"`
+	// Organization should contain the requested subject organization
+	Organization string `yaml:"organization"`
+	// Country should contain the requested subject country code
+	Country string `yaml:"country"`
+
+	// NotBefore should contain the requested NotBefore date for the
+	// certificate in the format "2006-01-02 15:04:05". Dates will
+	// always be UTC.
+	NotBefore string `yaml:"not-before"`
+	// NotAfter should contain the requested NotAfter date for the
+	// certificate in the format "2006-01-02 15:04:05". Dates will
+	// always be UTC.
+	NotAfter string `yaml:"not-after"`
+
+	// OCSPURL should contain the URL at which a OCSP responder that
+	// can respond to OCSP requests for this certificate operates
+	OCSPURL string `yaml:"ocsp-url"`
+	// CRLURL should contain the URL at which CRLs for this certificate
+	// can be found
+	CRLURL string `yaml:"crl-url"`
+	// IssuerURL should contain the URL at which the issuing certificate
+	// can be found, this is only required if generating an intermediate
+	// or cross-signed certificate
+	IssuerURL string `yaml:"issuer-url"`
+
+	// PolicyOIDs should contain any OIDs to be inserted in a certificate
+	// policies extension. These should be formatted in the standard OID
+	// string format (i.e. "1.2.3")
+	PolicyOIDs []string `yaml:"policy-oids"`
+
+	// KeyUsages should contain the set of key usage bits to set, valid
+	// values are "Digital Signature", "Cert Sign" and "CRL Sign"
+	KeyUsages []string `yaml:"key-usages"`
+}
+
+const dateLayout = "2006-01-02 15:04:05"
+
+var stringToSigAlg = map[string]x509.SignatureAlgorithm{
+	"SHA256WithRSA":   x509.SHA256WithRSA,
+	"SHA384WithRSA":   x509.SHA384WithRSA,
+	"SHA512WithRSA":   x509.SHA512WithRSA,
+	"ECDSAWithSHA256": x509.ECDSAWithSHA256,
+	"ECDSAWithSHA384": x509.ECDSAWithSHA384,
+	"ECDSAWithSHA512": x509.ECDSAWithSHA512,
+}
+
+var stringToKeyUsage = map[string]x509.KeyUsage{
+	"Digital Signature": x509.KeyUsageDigitalSignature,
+	"CRL Sign":          x509.KeyUsageCRLSign,
+	"Cert Sign":         x509.KeyUsageCertSign,
+}
+
+func (profile *certProfile) verifyProfile(ct certType) error {
+	if profile.NotBefore == "" {
+		return errors.New("not-before is required")
+	}
+	if profile.NotAfter == "" {
+		return errors.New("not-after is required")
+	}
+	if profile.SignatureAlgorithm == "" {
+		return errors.New("signature-algorithm is required")
+	}
+	if _, present := stringToSigAlg[profile.SignatureAlgorithm]; !present {
+		return fmt.Errorf("unsupported signature-algorithm %q", profile.SignatureAlgorithm)
+	}
+	if len(profile.KeyUsages) == 0 {
+		return errors.New("key-usages is required")
+	}
+	for _, ku := range profile.KeyUsages {
+		if _, present := stringToKeyUsage[ku]; !present {
+			return fmt.Errorf("unsupported key usage %q", ku)
+		}
+	}
+
+	switch ct {
+	case crossCert:
+		if profile.CommonName != "" || profile.Organization != "" || profile.Country != "" {
+			return errors.New("common-name, organization and country cannot be set for a cross-signed certificate, the subject is copied from the certificate being cross-signed")
+		}
+	default:
+		if profile.CommonName == "" {
+			return errors.New("common-name is required")
+		}
+		if profile.Organization == "" {
+			return errors.New("organization is required")
+		}
+		if profile.Country == "" {
+			return errors.New("country is required")
+		}
+	}
+
+	if ct == rootCert {
+		if profile.OCSPURL != "" {
+			return errors.New("ocsp-url cannot be set for a root certificate")
+		}
+		if profile.CRLURL != "" {
+			return errors.New("crl-url cannot be set for a root certificate")
+		}
+		if profile.IssuerURL != "" {
+			return errors.New("issuer-url cannot be set for a root certificate")
+		}
+		if len(profile.PolicyOIDs) > 0 {
+			return errors.New("policy-oids cannot be set for a root certificate")
+		}
+	} else {
+		if profile.CRLURL == "" {
+			return errors.New("crl-url is required for subordinate certificates")
+		}
+		if profile.IssuerURL == "" {
+			return errors.New("issuer-url is required for subordinate certificates")
+		}
+	}
+	return nil
+}
+
+func parseOID(oidStr string) (asn1.ObjectIdentifier, error) {
+	var oid asn1.ObjectIdentifier
+	for _, a := range strings.Split(oidStr, ".") {
+		i, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, err
+		}
+		if i <= 0 {
+			return nil, errors.New("OID components must be >= 1")
+		}
+		oid = append(oid, i)
+	}
+	return oid, nil
+}
+
+// subjectKeyID computes the subject key identifier for the provided DER
+// encoded public key using method (1) from RFC 5280 section 4.2.1.2.
+func subjectKeyID(pubKeyDER []byte) ([]byte, error) {
+	var pkixPublicKey struct {
+		Algo      pkix.AlgorithmIdentifier
+		BitString asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(pubKeyDER, &pkixPublicKey); err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %s", err)
+	}
+	skid := sha1.Sum(pkixPublicKey.BitString.Bytes)
+	return skid[:], nil
+}
+
+// makeTemplate constructs the certificate template for the provided profile
+// and public key. randReader is used to generate the serial number, which
+// should be backed by the device's RNG. For cross-signed certificates the
+// caller is expected to set RawSubject to the subject of the certificate being
+// cross-signed.
+func makeTemplate(randReader io.Reader, profile *certProfile, pubKeyDER []byte, ct certType) (*x509.Certificate, error) {
+	notBefore, err := time.Parse(dateLayout, profile.NotBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse not-before: %s", err)
+	}
+	notAfter, err := time.Parse(dateLayout, profile.NotAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse not-after: %s", err)
+	}
+	if !notAfter.After(notBefore) {
+		return nil, errors.New("not-after must be after not-before")
+	}
+
+	var ocspServer []string
+	if profile.OCSPURL != "" {
+		ocspServer = []string{profile.OCSPURL}
+	}
+	var crlDistributionPoints []string
+	if profile.CRLURL != "" {
+		crlDistributionPoints = []string{profile.CRLURL}
+	}
+	var issuingCertificateURL []string
+	if profile.IssuerURL != "" {
+		issuingCertificateURL = []string{profile.IssuerURL}
+	}
+
+	var ku x509.KeyUsage
+	for _, kuStr := range profile.KeyUsages {
+		ku |= stringToKeyUsage[kuStr]
+	}
+
+	skid, err := subjectKeyID(pubKeyDER)
+	if err != nil {
+		return nil, err
+	}
+
+	serial := make([]byte, 16)
+	if _, err := io.ReadFull(randReader, serial); err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %s", err)
+	}
+
+	cert := &x509.Certificate{
+		SignatureAlgorithm:    stringToSigAlg[profile.SignatureAlgorithm],
+		SerialNumber:          big.NewInt(0).SetBytes(serial),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		OCSPServer:            ocspServer,
+		CRLDistributionPoints: crlDistributionPoints,
+		IssuingCertificateURL: issuingCertificateURL,
+		KeyUsage:              ku,
+		SubjectKeyId:          skid,
+	}
+	if ct != crossCert {
+		cert.Subject = pkix.Name{
+			CommonName:   profile.CommonName,
+			Organization: []string{profile.Organization},
+			Country:      []string{profile.Country},
+		}
+	}
+	if ct != rootCert {
+		cert.MaxPathLen = 0
+		cert.MaxPathLenZero = true
+		cert.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	}
+
+	for _, oidStr := range profile.PolicyOIDs {
+		oid, err := parseOID(oidStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy OID %q: %s", oidStr, err)
+		}
+		cert.PolicyIdentifiers = append(cert.PolicyIdentifiers, oid)
+	}
+
+	return cert, nil
+}
+
+// signCert signs the template with the provided signer and issuer, and then
+// parses the result and verifies its signature so that a certificate that
+// doesn't chain to the issuer is never emitted. For root certificates issuer
+// should be the template itself.
+func signCert(template, issuer *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(&failReader{}, template, issuer, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signed certificate: %s", err)
+	}
+	if issuer == template {
+		issuer = cert
+	}
+	if err := cert.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("failed to verify certificate signature: %s", err)
+	}
+	return cert, nil
+}
+
+// failReader is passed to x509.CreateCertificate as the source of randomness.
+// All randomness in a ceremony must come from the device, so any attempt to
+// use it is an error.
+type failReader struct{}
+
+func (fr *failReader) Read([]byte) (int, error) {
+	return 0, errors.New("empty reader used by x509.CreateCertificate")
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func validProfile() certProfile {
+	return certProfile{
+		SignatureAlgorithm: "SHA256WithRSA",
+		CommonName:         "common name",
+		Organization:       "organization",
+		Country:            "country",
+		NotBefore:          "2018-05-18 11:31:00",
+		NotAfter:           "2038-05-18 11:31:00",
+		CRLURL:             "http://crl",
+		IssuerURL:          "http://issuer",
+		KeyUsages:          []string{"Cert Sign", "CRL Sign"},
+	}
+}
+
+func TestVerifyProfile(t *testing.T) {
+	profile := validProfile()
+	test.AssertNotError(t, profile.verifyProfile(intermediateCert), "valid intermediate profile rejected")
+
+	err := profile.verifyProfile(rootCert)
+	test.AssertError(t, err, "root profile with crl-url accepted")
+	test.AssertEquals(t, err.Error(), "crl-url cannot be set for a root certificate")
+
+	profile.CRLURL, profile.IssuerURL = "", ""
+	test.AssertNotError(t, profile.verifyProfile(rootCert), "valid root profile rejected")
+	err = profile.verifyProfile(intermediateCert)
+	test.AssertError(t, err, "intermediate profile without crl-url accepted")
+
+	profile = validProfile()
+	err = profile.verifyProfile(crossCert)
+	test.AssertError(t, err, "cross-sign profile with subject accepted")
+	profile.CommonName, profile.Organization, profile.Country = "", "", ""
+	test.AssertNotError(t, profile.verifyProfile(crossCert), "valid cross-sign profile rejected")
+
+	profile = validProfile()
+	profile.SignatureAlgorithm = "MD5WithRSA"
+	err = profile.verifyProfile(intermediateCert)
+	test.AssertError(t, err, "profile with bad signature-algorithm accepted")
+
+	profile = validProfile()
+	profile.KeyUsages = []string{"Key Encipherment"}
+	err = profile.verifyProfile(intermediateCert)
+	test.AssertError(t, err, "profile with bad key-usages accepted")
+
+	profile = validProfile()
+	profile.NotAfter = ""
+	err = profile.verifyProfile(intermediateCert)
+	test.AssertError(t, err, "profile without not-after accepted")
+}
+
+func TestParseOID(t *testing.T) {
+	_, err := parseOID("")
+	test.AssertError(t, err, "parseOID accepted an empty OID")
+	_, err = parseOID("1.2.a")
+	test.AssertError(t, err, "parseOID accepted an OID with a non-numeric component")
+	_, err = parseOID("1.0.3")
+	test.AssertError(t, err, "parseOID accepted an OID with a zero component")
+	oid, err := parseOID("2.23.140.1.2.1")
+	test.AssertNotError(t, err, "parseOID failed with a valid OID")
+	test.AssertEquals(t, oid.String(), "2.23.140.1.2.1")
+}
+
+func TestMakeTemplate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "failed to generate test key")
+	pubDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	test.AssertNotError(t, err, "failed to marshal public key")
+
+	profile := validProfile()
+	profile.OCSPURL = "http://ocsp"
+	profile.PolicyOIDs = []string{"2.23.140.1.2.1"}
+	cert, err := makeTemplate(rand.Reader, &profile, pubDER, intermediateCert)
+	test.AssertNotError(t, err, "makeTemplate failed")
+	test.AssertEquals(t, cert.Subject.CommonName, profile.CommonName)
+	test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageCertSign|x509.KeyUsageCRLSign)
+	test.AssertEquals(t, cert.OCSPServer[0], profile.OCSPURL)
+	test.AssertEquals(t, cert.CRLDistributionPoints[0], profile.CRLURL)
+	test.AssertEquals(t, cert.IssuingCertificateURL[0], profile.IssuerURL)
+	test.AssertEquals(t, cert.PolicyIdentifiers[0].String(), "2.23.140.1.2.1")
+	test.Assert(t, cert.IsCA && cert.MaxPathLenZero, "intermediate template isn't a path length constrained CA")
+	test.AssertEquals(t, len(cert.SubjectKeyId), 20)
+
+	profile.NotBefore = "2038-05-18 11:31:00"
+	profile.NotAfter = "2018-05-18 11:31:00"
+	_, err = makeTemplate(rand.Reader, &profile, pubDER, intermediateCert)
+	test.AssertError(t, err, "makeTemplate didn't fail with not-after before not-before")
+
+	profile = validProfile()
+	_, err = makeTemplate(&failReader{}, &profile, pubDER, intermediateCert)
+	test.AssertError(t, err, "makeTemplate didn't fail with a failing rand reader")
+}
+
+func TestSignRootAndCrossSign(t *testing.T) {
+	rootKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "failed to generate test key")
+	rootPubDER, err := x509.MarshalPKIXPublicKey(rootKey.Public())
+	test.AssertNotError(t, err, "failed to marshal public key")
+	rootCtx := softRSACtx(rootKey)
+	rootSigner, err := newSigner(rootCtx, 0, 1, rootKey.Public())
+	test.AssertNotError(t, err, "newSigner failed")
+
+	profile := validProfile()
+	profile.CRLURL, profile.IssuerURL = "", ""
+	template, err := makeTemplate(&hsmRandReader{rootCtx, 0}, &profile, rootPubDER, rootCert)
+	test.AssertNotError(t, err, "makeTemplate failed")
+	template.PublicKey = rootKey.Public()
+	root, err := signCert(template, template, rootKey.Public(), rootSigner)
+	test.AssertNotError(t, err, "signCert failed for root")
+	test.AssertNotError(t, root.CheckSignatureFrom(root), "root isn't self-signed")
+
+	// cross-sign the root with a different key, the result must share the
+	// subject and public key of the root
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "failed to generate test key")
+	otherCtx := softRSACtx(otherKey)
+	otherSigner, err := newSigner(otherCtx, 0, 1, otherKey.Public())
+	test.AssertNotError(t, err, "newSigner failed")
+	otherPubDER, err := x509.MarshalPKIXPublicKey(otherKey.Public())
+	test.AssertNotError(t, err, "failed to marshal public key")
+	issuerTemplate, err := makeTemplate(rand.Reader, &profile, otherPubDER, rootCert)
+	test.AssertNotError(t, err, "makeTemplate failed")
+	issuerTemplate.Subject = pkix.Name{CommonName: "other root"}
+	issuerTemplate.PublicKey = otherKey.Public()
+	issuer, err := signCert(issuerTemplate, issuerTemplate, otherKey.Public(), otherSigner)
+	test.AssertNotError(t, err, "signCert failed for issuer")
+
+	crossProfile := validProfile()
+	crossProfile.CommonName, crossProfile.Organization, crossProfile.Country = "", "", ""
+	crossTemplate, err := makeTemplate(rand.Reader, &crossProfile, root.RawSubjectPublicKeyInfo, crossCert)
+	test.AssertNotError(t, err, "makeTemplate failed")
+	crossTemplate.RawSubject = root.RawSubject
+	cross, err := signCert(crossTemplate, issuer, root.PublicKey, otherSigner)
+	test.AssertNotError(t, err, "signCert failed for cross-sign")
+	test.Assert(t, bytes.Equal(cross.RawSubject, root.RawSubject), "cross-signed subject doesn't match")
+	test.Assert(t, bytes.Equal(cross.RawSubjectPublicKeyInfo, root.RawSubjectPublicKeyInfo), "cross-signed public key doesn't match")
+	test.AssertNotError(t, cross.CheckSignatureFrom(issuer), "cross-signed certificate doesn't verify")
+
+	// signing with a key that doesn't match the issuer must fail
+	_, err = signCert(crossTemplate, issuer, root.PublicKey, rootSigner)
+	test.AssertError(t, err, "signCert didn't fail with mismatched issuer key")
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+
+	"github.com/miekg/pkcs11"
+)
+
+type generateArgs struct {
+	mechanism    []*pkcs11.Mechanism
+	privateAttrs []*pkcs11.Attribute
+	publicAttrs  []*pkcs11.Attribute
+}
+
+// PKCtx is the subset of the PKCS#11 API used by the ceremony tool. It exists
+// so that the device can be mocked out in tests.
+type PKCtx interface {
+	GenerateKeyPair(pkcs11.SessionHandle, []*pkcs11.Mechanism, []*pkcs11.Attribute, []*pkcs11.Attribute) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error)
+	GetAttributeValue(pkcs11.SessionHandle, pkcs11.ObjectHandle, []*pkcs11.Attribute) ([]*pkcs11.Attribute, error)
+	SignInit(pkcs11.SessionHandle, []*pkcs11.Mechanism, pkcs11.ObjectHandle) error
+	Sign(pkcs11.SessionHandle, []byte) ([]byte, error)
+	GenerateRandom(pkcs11.SessionHandle, int) ([]byte, error)
+	FindObjectsInit(pkcs11.SessionHandle, []*pkcs11.Attribute) error
+	FindObjects(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error)
+	FindObjectsFinal(pkcs11.SessionHandle) error
+}
+
+func getRandomBytes(ctx PKCtx, session pkcs11.SessionHandle) ([]byte, error) {
+	r, err := ctx.GenerateRandom(session, 4)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// openSession loads the PKCS#11 module, opens a read/write session on the
+// provided slot and logs into it as the normal user.
+func openSession(module string, slot uint, pin string) (PKCtx, pkcs11.SessionHandle, error) {
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		return nil, 0, errors.New("failed to load module")
+	}
+	err := ctx.Initialize()
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't initialize context: %s", err)
+	}
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't open session: %s", err)
+	}
+
+	err = ctx.Login(session, pkcs11.CKU_USER, pin)
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't login: %s", err)
+	}
+
+	return ctx, session, nil
+}
+
+// findObject returns the handles of all objects on the device that match the
+// provided class and label.
+func findObject(ctx PKCtx, session pkcs11.SessionHandle, class uint, label string) ([]pkcs11.ObjectHandle, error) {
+	err := ctx.FindObjectsInit(session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize object search: %s", err)
+	}
+	handles, _, err := ctx.FindObjects(session, 2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for objects: %s", err)
+	}
+	err = ctx.FindObjectsFinal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finalize object search: %s", err)
+	}
+	return handles, nil
+}
+
+// keyGenConfig describes the key that should be generated on the device.
+type keyGenConfig struct {
+	Type         string `yaml:"type"`
+	RSAModLength uint   `yaml:"rsa-mod-length"`
+	ECDSACurve   string `yaml:"ecdsa-curve"`
+	// CompatMode should be set for devices that implement a pre-2.11 version
+	// of the PKCS#11 specification. It is only used for ECDSA keys.
+	CompatMode bool `yaml:"compat-mode"`
+}
+
+func (kgc keyGenConfig) validate() error {
+	switch kgc.Type {
+	case "rsa":
+		if kgc.RSAModLength != 2048 && kgc.RSAModLength != 4096 {
+			return errors.New("key.rsa-mod-length can only be 2048 or 4096")
+		}
+		if kgc.ECDSACurve != "" {
+			return errors.New("if key.type = 'rsa' then key.ecdsa-curve is not used")
+		}
+	case "ecdsa":
+		if _, present := stringToCurve[kgc.ECDSACurve]; !present {
+			return errors.New("key.ecdsa-curve can only be 'P-224', 'P-256', 'P-384', or 'P-521'")
+		}
+		if kgc.RSAModLength != 0 {
+			return errors.New("if key.type = 'ecdsa' then key.rsa-mod-length is not used")
+		}
+	default:
+		return errors.New("key.type can only be 'rsa' or 'ecdsa'")
+	}
+	return nil
+}
+
+// generatedKey contains the public part of a key pair generated on the device
+// along with the handle of its private part.
+type generatedKey struct {
+	pub    crypto.PublicKey
+	der    []byte
+	handle pkcs11.ObjectHandle
+}
+
+// generateKey generates a key pair on the device with the provided label,
+// refusing to do so if a private key with the same label already exists. The
+// public key is returned both as a Go public key and in its PKIX DER encoding.
+func generateKey(ctx PKCtx, session pkcs11.SessionHandle, label string, config keyGenConfig) (*generatedKey, error) {
+	existing, err := findObject(ctx, session, pkcs11.CKO_PRIVATE_KEY, label)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("a private key with the label %q already exists on the device", label)
+	}
+
+	var pub crypto.PublicKey
+	var handle pkcs11.ObjectHandle
+	switch config.Type {
+	case "rsa":
+		pub, handle, err = rsaGenerate(ctx, session, label, config.RSAModLength, 65537)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key pair: %s", err)
+		}
+	case "ecdsa":
+		pub, handle, err = ecGenerate(ctx, session, label, config.ECDSACurve, config.CompatMode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ECDSA key pair: %s", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", config.Type)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %s", err)
+	}
+	log.Printf("Public key PEM:\n%s\n", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	return &generatedKey{pub: pub, der: der, handle: handle}, nil
+}
+
+// loadSigningKey finds the private key with the provided label on the device
+// and returns a crypto.Signer that uses it. pub is the public key the private
+// key is expected to correspond to, normally taken from the issuer
+// certificate. Exactly one private key with the label must exist.
+func loadSigningKey(ctx PKCtx, session pkcs11.SessionHandle, label string, pub crypto.PublicKey) (*x509Signer, error) {
+	handles, err := findObject(ctx, session, pkcs11.CKO_PRIVATE_KEY, label)
+	if err != nil {
+		return nil, err
+	}
+	if len(handles) == 0 {
+		return nil, fmt.Errorf("no private key with the label %q found on the device", label)
+	}
+	if len(handles) > 1 {
+		return nil, fmt.Errorf("more than one private key with the label %q found on the device", label)
+	}
+	return newSigner(ctx, session, handles[0], pub)
+}
+
+// x509Signer is a crypto.Signer backed by a private key object on a PKCS#11
+// device. It is used to sign certificates with crypto/x509.
+type x509Signer struct {
+	ctx     PKCtx
+	session pkcs11.SessionHandle
+	handle  pkcs11.ObjectHandle
+	pub     crypto.PublicKey
+}
+
+func newSigner(ctx PKCtx, session pkcs11.SessionHandle, handle pkcs11.ObjectHandle, pub crypto.PublicKey) (*x509Signer, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return &x509Signer{ctx: ctx, session: session, handle: handle, pub: pub}, nil
+}
+
+// hashToPrefix maps hash functions to the DER encoded DigestInfo prefix that
+// must be prepended to a digest before it is signed with CKM_RSA_PKCS.
+var hashToPrefix = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// Sign signs the provided digest using the private key on the device. For RSA
+// keys the digest is wrapped in a DigestInfo structure and signed using
+// CKM_RSA_PKCS, for ECDSA keys the raw signature returned by CKM_ECDSA is
+// converted into the ASN.1 structure expected by crypto/x509.
+func (s *x509Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var mechanism uint
+	input := digest
+	switch s.pub.(type) {
+	case *rsa.PublicKey:
+		prefix, present := hashToPrefix[opts.HashFunc()]
+		if !present {
+			return nil, fmt.Errorf("unsupported hash function %s", opts.HashFunc())
+		}
+		mechanism = pkcs11.CKM_RSA_PKCS
+		input = append(append([]byte{}, prefix...), digest...)
+	case *ecdsa.PublicKey:
+		mechanism = pkcs11.CKM_ECDSA
+	}
+
+	err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, s.handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize signing operation: %s", err)
+	}
+	signature, err := s.ctx.Sign(s.session, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign data: %s", err)
+	}
+
+	if _, ok := s.pub.(*ecdsa.PublicKey); ok {
+		if len(signature) == 0 || len(signature)%2 != 0 {
+			return nil, errors.New("invalid ECDSA signature length returned")
+		}
+		r := big.NewInt(0).SetBytes(signature[:len(signature)/2])
+		s := big.NewInt(0).SetBytes(signature[len(signature)/2:])
+		return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	}
+	return signature, nil
+}
+
+// Public returns the public key corresponding to the private key on the
+// device.
+func (s *x509Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// hsmRandReader is an io.Reader that reads random bytes from the device, it is
+// used so that serial numbers are generated by the device's RNG.
+type hsmRandReader struct {
+	ctx     PKCtx
+	session pkcs11.SessionHandle
+}
+
+func (r *hsmRandReader) Read(p []byte) (int, error) {
+	random, err := r.ctx.GenerateRandom(r.session, len(p))
+	if err != nil {
+		return 0, err
+	}
+	return copy(p, random), nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+	"github.com/miekg/pkcs11"
+)
+
+type mockCtx struct {
+	GenerateKeyPairFunc   func(pkcs11.SessionHandle, []*pkcs11.Mechanism, []*pkcs11.Attribute, []*pkcs11.Attribute) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error)
+	GetAttributeValueFunc func(pkcs11.SessionHandle, pkcs11.ObjectHandle, []*pkcs11.Attribute) ([]*pkcs11.Attribute, error)
+	SignInitFunc          func(pkcs11.SessionHandle, []*pkcs11.Mechanism, pkcs11.ObjectHandle) error
+	SignFunc              func(pkcs11.SessionHandle, []byte) ([]byte, error)
+	GenerateRandomFunc    func(pkcs11.SessionHandle, int) ([]byte, error)
+	FindObjectsInitFunc   func(pkcs11.SessionHandle, []*pkcs11.Attribute) error
+	FindObjectsFunc       func(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error)
+	FindObjectsFinalFunc  func(pkcs11.SessionHandle) error
+}
+
+func (mc mockCtx) GenerateKeyPair(s pkcs11.SessionHandle, m []*pkcs11.Mechanism, a1 []*pkcs11.Attribute, a2 []*pkcs11.Attribute) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
+	return mc.GenerateKeyPairFunc(s, m, a1, a2)
+}
+func (mc mockCtx) GetAttributeValue(s pkcs11.SessionHandle, o pkcs11.ObjectHandle, a []*pkcs11.Attribute) ([]*pkcs11.Attribute, error) {
+	return mc.GetAttributeValueFunc(s, o, a)
+}
+func (mc mockCtx) SignInit(s pkcs11.SessionHandle, m []*pkcs11.Mechanism, o pkcs11.ObjectHandle) error {
+	return mc.SignInitFunc(s, m, o)
+}
+func (mc mockCtx) Sign(s pkcs11.SessionHandle, m []byte) ([]byte, error) {
+	return mc.SignFunc(s, m)
+}
+func (mc mockCtx) GenerateRandom(s pkcs11.SessionHandle, c int) ([]byte, error) {
+	return mc.GenerateRandomFunc(s, c)
+}
+func (mc mockCtx) FindObjectsInit(s pkcs11.SessionHandle, a []*pkcs11.Attribute) error {
+	return mc.FindObjectsInitFunc(s, a)
+}
+func (mc mockCtx) FindObjects(s pkcs11.SessionHandle, m int) ([]pkcs11.ObjectHandle, bool, error) {
+	return mc.FindObjectsFunc(s, m)
+}
+func (mc mockCtx) FindObjectsFinal(s pkcs11.SessionHandle) error {
+	return mc.FindObjectsFinalFunc(s)
+}
+
+// softRSACtx returns a mockCtx that behaves like a device holding the provided
+// RSA private key, with no objects found by searches.
+func softRSACtx(key *rsa.PrivateKey) mockCtx {
+	return mockCtx{
+		GenerateKeyPairFunc: func(pkcs11.SessionHandle, []*pkcs11.Mechanism, []*pkcs11.Attribute, []*pkcs11.Attribute) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
+			return 1, 2, nil
+		},
+		GetAttributeValueFunc: func(pkcs11.SessionHandle, pkcs11.ObjectHandle, []*pkcs11.Attribute) ([]*pkcs11.Attribute, error) {
+			return []*pkcs11.Attribute{
+				pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, big.NewInt(int64(key.E)).Bytes()),
+				pkcs11.NewAttribute(pkcs11.CKA_MODULUS, key.N.Bytes()),
+			}, nil
+		},
+		SignInitFunc: func(pkcs11.SessionHandle, []*pkcs11.Mechanism, pkcs11.ObjectHandle) error {
+			return nil
+		},
+		SignFunc: func(_ pkcs11.SessionHandle, msg []byte) ([]byte, error) {
+			// msg already contains the DigestInfo prefix so it is signed raw
+			return rsa.SignPKCS1v15(rand.Reader, key, crypto.Hash(0), msg)
+		},
+		GenerateRandomFunc: func(_ pkcs11.SessionHandle, n int) ([]byte, error) {
+			b := make([]byte, n)
+			_, err := rand.Read(b)
+			return b, err
+		},
+		FindObjectsInitFunc: func(pkcs11.SessionHandle, []*pkcs11.Attribute) error {
+			return nil
+		},
+		FindObjectsFunc: func(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error) {
+			return nil, false, nil
+		},
+		FindObjectsFinalFunc: func(pkcs11.SessionHandle) error {
+			return nil
+		},
+	}
+}
+
+func TestKeyGenConfigValidate(t *testing.T) {
+	cases := []struct {
+		config      keyGenConfig
+		expectedErr string
+	}{
+		{keyGenConfig{}, "key.type can only be 'rsa' or 'ecdsa'"},
+		{keyGenConfig{Type: "rsa", RSAModLength: 1024}, "key.rsa-mod-length can only be 2048 or 4096"},
+		{keyGenConfig{Type: "rsa", RSAModLength: 2048, ECDSACurve: "P-256"}, "if key.type = 'rsa' then key.ecdsa-curve is not used"},
+		{keyGenConfig{Type: "ecdsa", ECDSACurve: "bad"}, "key.ecdsa-curve can only be 'P-224', 'P-256', 'P-384', or 'P-521'"},
+		{keyGenConfig{Type: "ecdsa", ECDSACurve: "P-256", RSAModLength: 2048}, "if key.type = 'ecdsa' then key.rsa-mod-length is not used"},
+		{keyGenConfig{Type: "rsa", RSAModLength: 2048}, ""},
+		{keyGenConfig{Type: "ecdsa", ECDSACurve: "P-384"}, ""},
+	}
+	for _, tc := range cases {
+		err := tc.config.validate()
+		if tc.expectedErr == "" {
+			test.AssertNotError(t, err, "validate failed for valid config")
+		} else {
+			test.AssertError(t, err, "validate didn't fail for invalid config")
+			test.AssertEquals(t, err.Error(), tc.expectedErr)
+		}
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "failed to generate test key")
+	ctx := softRSACtx(key)
+
+	// test an existing key with the same label causes a failure
+	ctx.FindObjectsFunc = func(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error) {
+		return []pkcs11.ObjectHandle{1}, false, nil
+	}
+	_, err = generateKey(ctx, 0, "label", keyGenConfig{Type: "rsa", RSAModLength: 2048})
+	test.AssertError(t, err, "generateKey didn't fail with an existing key")
+
+	// test a failed search causes a failure
+	ctx.FindObjectsFunc = func(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error) {
+		return nil, false, errors.New("yup")
+	}
+	_, err = generateKey(ctx, 0, "label", keyGenConfig{Type: "rsa", RSAModLength: 2048})
+	test.AssertError(t, err, "generateKey didn't fail on FindObjects error")
+
+	// test a successful generation
+	ctx.FindObjectsFunc = func(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error) {
+		return nil, false, nil
+	}
+	gk, err := generateKey(ctx, 0, "label", keyGenConfig{Type: "rsa", RSAModLength: 2048})
+	test.AssertNotError(t, err, "generateKey failed")
+	test.AssertEquals(t, gk.handle, pkcs11.ObjectHandle(2))
+	test.AssertEquals(t, gk.pub.(*rsa.PublicKey).N.Cmp(key.N), 0)
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "failed to generate test key")
+	ctx := softRSACtx(key)
+
+	_, err = loadSigningKey(ctx, 0, "label", key.Public())
+	test.AssertError(t, err, "loadSigningKey didn't fail with no matching key")
+
+	ctx.FindObjectsFunc = func(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error) {
+		return []pkcs11.ObjectHandle{1, 2}, false, nil
+	}
+	_, err = loadSigningKey(ctx, 0, "label", key.Public())
+	test.AssertError(t, err, "loadSigningKey didn't fail with multiple matching keys")
+
+	ctx.FindObjectsFunc = func(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error) {
+		return []pkcs11.ObjectHandle{1}, false, nil
+	}
+	signer, err := loadSigningKey(ctx, 0, "label", key.Public())
+	test.AssertNotError(t, err, "loadSigningKey failed")
+	test.AssertEquals(t, signer.handle, pkcs11.ObjectHandle(1))
+}
+
+func TestSignerRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "failed to generate test key")
+	ctx := softRSACtx(key)
+	signer, err := newSigner(ctx, 0, 1, key.Public())
+	test.AssertNotError(t, err, "newSigner failed")
+
+	digest := sha256.Sum256([]byte("hello"))
+	sig, err := signer.Sign(nil, digest[:], crypto.SHA256)
+	test.AssertNotError(t, err, "Sign failed")
+	err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig)
+	test.AssertNotError(t, err, "signature didn't verify")
+
+	_, err = signer.Sign(nil, digest[:], crypto.MD5)
+	test.AssertError(t, err, "Sign didn't fail with unsupported hash")
+
+	ctx.SignFunc = func(pkcs11.SessionHandle, []byte) ([]byte, error) {
+		return nil, errors.New("yup")
+	}
+	signer.ctx = ctx
+	_, err = signer.Sign(nil, digest[:], crypto.SHA256)
+	test.AssertError(t, err, "Sign didn't fail on device error")
+}
+
+func TestSignerECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+	ctx := mockCtx{
+		SignInitFunc: func(pkcs11.SessionHandle, []*pkcs11.Mechanism, pkcs11.ObjectHandle) error {
+			return nil
+		},
+		SignFunc: func(_ pkcs11.SessionHandle, digest []byte) ([]byte, error) {
+			r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+			if err != nil {
+				return nil, err
+			}
+			sig := make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+			return sig, nil
+		},
+	}
+	signer, err := newSigner(ctx, 0, 1, key.Public())
+	test.AssertNotError(t, err, "newSigner failed")
+
+	digest := sha256.Sum256([]byte("hello"))
+	sig, err := signer.Sign(nil, digest[:], crypto.SHA256)
+	test.AssertNotError(t, err, "Sign failed")
+	test.Assert(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig), "signature didn't verify")
+
+	ctx.SignFunc = func(pkcs11.SessionHandle, []byte) ([]byte, error) {
+		return []byte{1, 2, 3}, nil
+	}
+	signer.ctx = ctx
+	_, err = signer.Sign(nil, digest[:], crypto.SHA256)
+	test.AssertError(t, err, "Sign didn't fail with malformed signature")
+
+	_, err = newSigner(ctx, 0, 1, "not a key")
+	test.AssertError(t, err, "newSigner didn't fail with unsupported key type")
+}
@@ -0,0 +1,451 @@
+// ceremony is a tool for performing key ceremonies. It drives a HSM using
+// PKCS#11 to generate root and intermediate keys, and to sign root,
+// intermediate and cross-signed certificates. Each ceremony is described by a
+// declarative YAML config file, so that the exact inputs of a ceremony can be
+// reviewed ahead of time and kept alongside its outputs as an audit record.
+// Every action taken is logged.
+//
+// The following ceremony types are supported:
+//   - root: generates a key pair on the device, writes the PEM public key and
+//     signs a self-signed root certificate with it.
+//   - key: generates a key pair on the device and writes the PEM public key,
+//     normally used to generate an intermediate key.
+//   - intermediate: signs a certificate for a public key produced by a key
+//     ceremony, using a root key already on the device.
+//   - cross-sign: signs a new certificate for the subject and public key of
+//     an existing certificate, using a key already on the device.
+//
+// Outputs are never overwritten: a ceremony will fail before touching the
+// device if any of its output files already exist. Every certificate is
+// parsed and has its signature verified against its issuer before it is
+// written.
+package main
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// keyGenPKCS11Config is the PKCS#11 configuration for ceremonies that generate
+// a key on the device.
+type keyGenPKCS11Config struct {
+	Module     string `yaml:"module"`
+	PIN        string `yaml:"pin"`
+	StoreSlot  uint   `yaml:"store-key-in-slot"`
+	StoreLabel string `yaml:"store-key-with-label"`
+}
+
+func (pc keyGenPKCS11Config) validate() error {
+	if pc.Module == "" {
+		return errors.New("pkcs11.module is required")
+	}
+	if pc.StoreLabel == "" {
+		return errors.New("pkcs11.store-key-with-label is required")
+	}
+	// key-slot is allowed to be 0 (which is a valid slot).
+	// PIN is allowed to be "", which will commonly happen when
+	// PIN entry is done via PED.
+	return nil
+}
+
+// signingPKCS11Config is the PKCS#11 configuration for ceremonies that sign
+// with a key that is already on the device.
+type signingPKCS11Config struct {
+	Module       string `yaml:"module"`
+	PIN          string `yaml:"pin"`
+	SigningSlot  uint   `yaml:"signing-key-slot"`
+	SigningLabel string `yaml:"signing-key-label"`
+}
+
+func (psc signingPKCS11Config) validate() error {
+	if psc.Module == "" {
+		return errors.New("pkcs11.module is required")
+	}
+	if psc.SigningLabel == "" {
+		return errors.New("pkcs11.signing-key-label is required")
+	}
+	return nil
+}
+
+type rootConfig struct {
+	CeremonyType string             `yaml:"ceremony-type"`
+	PKCS11       keyGenPKCS11Config `yaml:"pkcs11"`
+	Key          keyGenConfig       `yaml:"key"`
+	Outputs      struct {
+		PublicKeyPath   string `yaml:"public-key-path"`
+		CertificatePath string `yaml:"certificate-path"`
+	} `yaml:"outputs"`
+	CertProfile certProfile `yaml:"certificate-profile"`
+}
+
+func (rc rootConfig) validate() error {
+	if err := rc.PKCS11.validate(); err != nil {
+		return err
+	}
+	if err := rc.Key.validate(); err != nil {
+		return err
+	}
+	if rc.Outputs.PublicKeyPath == "" {
+		return errors.New("outputs.public-key-path is required")
+	}
+	if rc.Outputs.CertificatePath == "" {
+		return errors.New("outputs.certificate-path is required")
+	}
+	return rc.CertProfile.verifyProfile(rootCert)
+}
+
+type keyConfig struct {
+	CeremonyType string             `yaml:"ceremony-type"`
+	PKCS11       keyGenPKCS11Config `yaml:"pkcs11"`
+	Key          keyGenConfig       `yaml:"key"`
+	Outputs      struct {
+		PublicKeyPath string `yaml:"public-key-path"`
+	} `yaml:"outputs"`
+}
+
+func (kc keyConfig) validate() error {
+	if err := kc.PKCS11.validate(); err != nil {
+		return err
+	}
+	if err := kc.Key.validate(); err != nil {
+		return err
+	}
+	if kc.Outputs.PublicKeyPath == "" {
+		return errors.New("outputs.public-key-path is required")
+	}
+	return nil
+}
+
+type intermediateConfig struct {
+	CeremonyType string              `yaml:"ceremony-type"`
+	PKCS11       signingPKCS11Config `yaml:"pkcs11"`
+	Inputs       struct {
+		PublicKeyPath         string `yaml:"public-key-path"`
+		IssuerCertificatePath string `yaml:"issuer-certificate-path"`
+	} `yaml:"inputs"`
+	Outputs struct {
+		CertificatePath string `yaml:"certificate-path"`
+	} `yaml:"outputs"`
+	CertProfile certProfile `yaml:"certificate-profile"`
+}
+
+func (ic intermediateConfig) validate() error {
+	if err := ic.PKCS11.validate(); err != nil {
+		return err
+	}
+	if ic.Inputs.PublicKeyPath == "" {
+		return errors.New("inputs.public-key-path is required")
+	}
+	if ic.Inputs.IssuerCertificatePath == "" {
+		return errors.New("inputs.issuer-certificate-path is required")
+	}
+	if ic.Outputs.CertificatePath == "" {
+		return errors.New("outputs.certificate-path is required")
+	}
+	return ic.CertProfile.verifyProfile(intermediateCert)
+}
+
+type crossSignConfig struct {
+	CeremonyType string              `yaml:"ceremony-type"`
+	PKCS11       signingPKCS11Config `yaml:"pkcs11"`
+	Inputs       struct {
+		CertificateToCrossSignPath string `yaml:"certificate-to-cross-sign-path"`
+		IssuerCertificatePath      string `yaml:"issuer-certificate-path"`
+	} `yaml:"inputs"`
+	Outputs struct {
+		CertificatePath string `yaml:"certificate-path"`
+	} `yaml:"outputs"`
+	CertProfile certProfile `yaml:"certificate-profile"`
+}
+
+func (csc crossSignConfig) validate() error {
+	if err := csc.PKCS11.validate(); err != nil {
+		return err
+	}
+	if csc.Inputs.CertificateToCrossSignPath == "" {
+		return errors.New("inputs.certificate-to-cross-sign-path is required")
+	}
+	if csc.Inputs.IssuerCertificatePath == "" {
+		return errors.New("inputs.issuer-certificate-path is required")
+	}
+	if csc.Outputs.CertificatePath == "" {
+		return errors.New("outputs.certificate-path is required")
+	}
+	return csc.CertProfile.verifyProfile(crossCert)
+}
+
+// checkOutputs fails if any of the provided output paths already exist, so
+// that a ceremony never overwrites the results of a previous one.
+func checkOutputs(paths ...string) error {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("output file %q already exists", path)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to check output file %q: %s", path, err)
+		}
+	}
+	return nil
+}
+
+// writeFile writes the provided PEM block to path, failing if the file already
+// exists.
+func writeFile(path string, block *pem.Block) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(f, block); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func loadCert(path string) (*x509.Certificate, error) {
+	pemBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no CERTIFICATE PEM block found in %q", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func loadPubKey(path string) (crypto.PublicKey, []byte, error) {
+	pemBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, nil, fmt.Errorf("no PUBLIC KEY PEM block found in %q", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, block.Bytes, nil
+}
+
+// writeCert logs the signed certificate and its fingerprint and writes it to
+// path.
+func writeCert(path string, cert *x509.Certificate) error {
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}
+	log.Printf("Signed certificate PEM:\n%s", pem.EncodeToMemory(block))
+	log.Printf("Certificate SHA-256 fingerprint: %X", sha256.Sum256(cert.Raw))
+	if err := writeFile(path, block); err != nil {
+		return fmt.Errorf("failed to write certificate to %q: %s", path, err)
+	}
+	log.Printf("Certificate written to %q", path)
+	return nil
+}
+
+func writePubKey(path string, der []byte) error {
+	if err := writeFile(path, &pem.Block{Type: "PUBLIC KEY", Bytes: der}); err != nil {
+		return fmt.Errorf("failed to write public key to %q: %s", path, err)
+	}
+	log.Printf("Public key written to %q", path)
+	return nil
+}
+
+func rootCeremony(configBytes []byte) error {
+	var config rootConfig
+	if err := yaml.Unmarshal(configBytes, &config); err != nil {
+		return fmt.Errorf("failed to parse config: %s", err)
+	}
+	if err := config.validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	if err := checkOutputs(config.Outputs.PublicKeyPath, config.Outputs.CertificatePath); err != nil {
+		return err
+	}
+	ctx, session, err := openSession(config.PKCS11.Module, config.PKCS11.StoreSlot, config.PKCS11.PIN)
+	if err != nil {
+		return fmt.Errorf("failed to setup session and PKCS#11 context for slot %d: %s", config.PKCS11.StoreSlot, err)
+	}
+	log.Printf("Opened PKCS#11 session for slot %d\n", config.PKCS11.StoreSlot)
+
+	key, err := generateKey(ctx, session, config.PKCS11.StoreLabel, config.Key)
+	if err != nil {
+		return err
+	}
+	if err := writePubKey(config.Outputs.PublicKeyPath, key.der); err != nil {
+		return err
+	}
+
+	signer, err := newSigner(ctx, session, key.handle, key.pub)
+	if err != nil {
+		return err
+	}
+	template, err := makeTemplate(&hsmRandReader{ctx, session}, &config.CertProfile, key.der, rootCert)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate profile: %s", err)
+	}
+	template.PublicKey = key.pub
+	cert, err := signCert(template, template, key.pub, signer)
+	if err != nil {
+		return err
+	}
+	return writeCert(config.Outputs.CertificatePath, cert)
+}
+
+func keyCeremony(configBytes []byte) error {
+	var config keyConfig
+	if err := yaml.Unmarshal(configBytes, &config); err != nil {
+		return fmt.Errorf("failed to parse config: %s", err)
+	}
+	if err := config.validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	if err := checkOutputs(config.Outputs.PublicKeyPath); err != nil {
+		return err
+	}
+	ctx, session, err := openSession(config.PKCS11.Module, config.PKCS11.StoreSlot, config.PKCS11.PIN)
+	if err != nil {
+		return fmt.Errorf("failed to setup session and PKCS#11 context for slot %d: %s", config.PKCS11.StoreSlot, err)
+	}
+	log.Printf("Opened PKCS#11 session for slot %d\n", config.PKCS11.StoreSlot)
+
+	key, err := generateKey(ctx, session, config.PKCS11.StoreLabel, config.Key)
+	if err != nil {
+		return err
+	}
+	return writePubKey(config.Outputs.PublicKeyPath, key.der)
+}
+
+// openSigner opens a session on the signing slot and loads the key labelled
+// config.SigningLabel, which must correspond to the public key of issuer. The
+// returned io.Reader reads from the device's RNG.
+func openSigner(config signingPKCS11Config, issuer *x509.Certificate) (*x509Signer, io.Reader, error) {
+	ctx, session, err := openSession(config.Module, config.SigningSlot, config.PIN)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to setup session and PKCS#11 context for slot %d: %s", config.SigningSlot, err)
+	}
+	log.Printf("Opened PKCS#11 session for slot %d\n", config.SigningSlot)
+
+	signer, err := loadSigningKey(ctx, session, config.SigningLabel, issuer.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Printf("Loaded signing key %q\n", config.SigningLabel)
+	return signer, &hsmRandReader{ctx, session}, nil
+}
+
+func intermediateCeremony(configBytes []byte) error {
+	var config intermediateConfig
+	if err := yaml.Unmarshal(configBytes, &config); err != nil {
+		return fmt.Errorf("failed to parse config: %s", err)
+	}
+	if err := config.validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	if err := checkOutputs(config.Outputs.CertificatePath); err != nil {
+		return err
+	}
+	pub, pubDER, err := loadPubKey(config.Inputs.PublicKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load public key: %s", err)
+	}
+	issuer, err := loadCert(config.Inputs.IssuerCertificatePath)
+	if err != nil {
+		return fmt.Errorf("failed to load issuer certificate: %s", err)
+	}
+	signer, randReader, err := openSigner(config.PKCS11, issuer)
+	if err != nil {
+		return err
+	}
+	template, err := makeTemplate(randReader, &config.CertProfile, pubDER, intermediateCert)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate profile: %s", err)
+	}
+	cert, err := signCert(template, issuer, pub, signer)
+	if err != nil {
+		return err
+	}
+	return writeCert(config.Outputs.CertificatePath, cert)
+}
+
+func crossSignCeremony(configBytes []byte) error {
+	var config crossSignConfig
+	if err := yaml.Unmarshal(configBytes, &config); err != nil {
+		return fmt.Errorf("failed to parse config: %s", err)
+	}
+	if err := config.validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	if err := checkOutputs(config.Outputs.CertificatePath); err != nil {
+		return err
+	}
+	toCrossSign, err := loadCert(config.Inputs.CertificateToCrossSignPath)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate to cross-sign: %s", err)
+	}
+	issuer, err := loadCert(config.Inputs.IssuerCertificatePath)
+	if err != nil {
+		return fmt.Errorf("failed to load issuer certificate: %s", err)
+	}
+	signer, randReader, err := openSigner(config.PKCS11, issuer)
+	if err != nil {
+		return err
+	}
+	template, err := makeTemplate(randReader, &config.CertProfile, toCrossSign.RawSubjectPublicKeyInfo, crossCert)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate profile: %s", err)
+	}
+	template.RawSubject = toCrossSign.RawSubject
+	cert, err := signCert(template, issuer, toCrossSign.PublicKey, signer)
+	if err != nil {
+		return err
+	}
+	return writeCert(config.Outputs.CertificatePath, cert)
+}
+
+func main() {
+	configPath := flag.String("config", "", "Path to ceremony configuration file")
+	flag.Parse()
+
+	if *configPath == "" {
+		log.Fatal("--config is required")
+	}
+	configBytes, err := ioutil.ReadFile(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to read config file: %s", err)
+	}
+	var ct struct {
+		CeremonyType string `yaml:"ceremony-type"`
+	}
+	if err := yaml.Unmarshal(configBytes, &ct); err != nil {
+		log.Fatalf("Failed to parse config: %s", err)
+	}
+	log.Printf("Starting %q ceremony using config %q (SHA-256 %X)\n", ct.CeremonyType, *configPath, sha256.Sum256(configBytes))
+
+	switch ct.CeremonyType {
+	case "root":
+		err = rootCeremony(configBytes)
+	case "key":
+		err = keyCeremony(configBytes)
+	case "intermediate":
+		err = intermediateCeremony(configBytes)
+	case "cross-sign":
+		err = crossSignCeremony(configBytes)
+	default:
+		log.Fatalf("ceremony-type can only be 'root', 'key', 'intermediate' or 'cross-sign'")
+	}
+	if err != nil {
+		log.Fatalf("%s ceremony failed: %s", ct.CeremonyType, err)
+	}
+	log.Printf("%s ceremony completed\n", ct.CeremonyType)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+	"gopkg.in/yaml.v2"
+)
+
+func TestRootConfig(t *testing.T) {
+	configBytes := []byte(`
+ceremony-type: root
+pkcs11:
+  module: /usr/lib/softhsm/libsofthsm2.so
+  pin: 1234
+  store-key-in-slot: 0
+  store-key-with-label: root signing key
+key:
+  type: ecdsa
+  ecdsa-curve: P-384
+outputs:
+  public-key-path: /tmp/root-signing-pub.pem
+  certificate-path: /tmp/root-cert.pem
+certificate-profile:
+  signature-algorithm: ECDSAWithSHA384
+  common-name: CA root
+  organization: good guys
+  country: US
+  not-before: 2020-01-01 12:00:00
+  not-after: 2040-01-01 12:00:00
+  key-usages:
+    - Cert Sign
+    - CRL Sign
+`)
+	var config rootConfig
+	err := yaml.Unmarshal(configBytes, &config)
+	test.AssertNotError(t, err, "failed to parse config")
+	test.AssertNotError(t, config.validate(), "valid config rejected")
+	test.AssertEquals(t, config.PKCS11.StoreLabel, "root signing key")
+	test.AssertEquals(t, config.Key.ECDSACurve, "P-384")
+	test.AssertEquals(t, config.Outputs.CertificatePath, "/tmp/root-cert.pem")
+	test.AssertEquals(t, len(config.CertProfile.KeyUsages), 2)
+
+	config.Outputs.PublicKeyPath = ""
+	err = config.validate()
+	test.AssertError(t, err, "config without outputs.public-key-path accepted")
+	test.AssertEquals(t, err.Error(), "outputs.public-key-path is required")
+}
+
+func TestConfigValidate(t *testing.T) {
+	var ic intermediateConfig
+	ic.PKCS11 = signingPKCS11Config{Module: "module", SigningLabel: "label"}
+	err := ic.validate()
+	test.AssertError(t, err, "intermediate config without inputs accepted")
+	test.AssertEquals(t, err.Error(), "inputs.public-key-path is required")
+	ic.Inputs.PublicKeyPath = "pub"
+	ic.Inputs.IssuerCertificatePath = "issuer"
+	ic.Outputs.CertificatePath = "out"
+	ic.CertProfile = validProfile()
+	test.AssertNotError(t, ic.validate(), "valid intermediate config rejected")
+	ic.PKCS11.SigningLabel = ""
+	test.AssertError(t, ic.validate(), "intermediate config without signing-key-label accepted")
+
+	var csc crossSignConfig
+	csc.PKCS11 = signingPKCS11Config{Module: "module", SigningLabel: "label"}
+	csc.Inputs.CertificateToCrossSignPath = "cert"
+	csc.Inputs.IssuerCertificatePath = "issuer"
+	csc.Outputs.CertificatePath = "out"
+	csc.CertProfile = validProfile()
+	test.AssertError(t, csc.validate(), "cross-sign config with a subject accepted")
+	csc.CertProfile.CommonName, csc.CertProfile.Organization, csc.CertProfile.Country = "", "", ""
+	test.AssertNotError(t, csc.validate(), "valid cross-sign config rejected")
+
+	var kc keyConfig
+	kc.PKCS11 = keyGenPKCS11Config{Module: "module", StoreLabel: "label"}
+	kc.Key = keyGenConfig{Type: "rsa", RSAModLength: 4096}
+	test.AssertError(t, kc.validate(), "key config without outputs.public-key-path accepted")
+	kc.Outputs.PublicKeyPath = "pub"
+	test.AssertNotError(t, kc.validate(), "valid key config rejected")
+}
+
+func TestOutputsNotOverwritten(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ceremony")
+	test.AssertNotError(t, err, "failed to create temp dir")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.pem")
+
+	test.AssertNotError(t, checkOutputs(path), "checkOutputs failed for missing file")
+	block := &pem.Block{Type: "PUBLIC KEY", Bytes: []byte{1, 2, 3}}
+	test.AssertNotError(t, writeFile(path, block), "writeFile failed")
+	test.AssertError(t, checkOutputs(path), "checkOutputs didn't fail for existing file")
+	test.AssertError(t, writeFile(path, block), "writeFile overwrote an existing file")
+}
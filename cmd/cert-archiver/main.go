@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"gopkg.in/go-gorp/gorp.v2"
+
+	"github.com/letsencrypt/boulder/cmd"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+type config struct {
+	CertArchiver struct {
+		cmd.DBConfig
+
+		Syslog cmd.SyslogConfig
+
+		// RetentionPeriod is how long after expiry a certificate is kept in the
+		// certificates and certificateStatus tables before it's archived.
+		RetentionPeriod cmd.ConfigDuration
+		BatchSize       int
+		// MaxCerts is the maximum number of certificates archived in one run.
+		MaxCerts int
+		// BatchDelay is how long to wait between batches, to throttle the load
+		// the archiver puts on the database.
+		BatchDelay cmd.ConfigDuration
+	}
+}
+
+type certArchiver struct {
+	log blog.Logger
+	clk clock.Clock
+	db  *gorp.DbMap
+
+	batchSize  int
+	batchDelay time.Duration
+}
+
+type archivable struct {
+	ID     int64  `db:"id"`
+	Serial string `db:"serial"`
+}
+
+// archive moves certificates that expired before `archiveBefore`, and their
+// statuses, into the certificatesArchive and certificateStatusArchive tables,
+// `batchSize` at a time, waiting `batchDelay` between batches. At most `max`
+// certificates are archived. It returns the number of certificates archived.
+func (a *certArchiver) archive(archiveBefore time.Time, max int) (int, error) {
+	var id int64
+	var count int
+	for count < max {
+		limit := a.batchSize
+		if max-count < limit {
+			limit = max - count
+		}
+		var batch []archivable
+		_, err := a.db.Select(
+			&batch,
+			`SELECT id, serial FROM certificates
+			WHERE id > :id AND expires <= :expires
+			ORDER BY id LIMIT :limit`,
+			map[string]interface{}{
+				"id":      id,
+				"expires": archiveBefore,
+				"limit":   limit,
+			},
+		)
+		if err != nil && err != sql.ErrNoRows {
+			return count, fmt.Errorf("getting a batch: %s", err)
+		}
+		for _, cert := range batch {
+			// Start the next query after the highest id we saw in this batch, even
+			// if this certificate fails to archive, so that it's retried by the
+			// next run rather than this one.
+			id = cert.ID
+			if err := archiveCertificate(a.db, cert.Serial); err != nil {
+				a.log.AuditErr(fmt.Sprintf("Archiving %s: %s", cert.Serial, err))
+				continue
+			}
+			count++
+		}
+		a.log.Info(fmt.Sprintf("Archived %d certificates so far", count))
+		if len(batch) < limit {
+			break
+		}
+		a.clk.Sleep(a.batchDelay)
+	}
+	a.log.Info(fmt.Sprintf("Archived a total of %d certificates", count))
+	return count, nil
+}
+
+// archiveCertificate copies the certificates and certificateStatus rows for
+// serial into the archive tables and deletes them from the hot tables, all in
+// one transaction so that a certificate is never in both or neither.
+func archiveCertificate(db *gorp.DbMap, serial string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, query := range []string{
+		"INSERT INTO certificatesArchive SELECT * FROM certificates WHERE serial = ?",
+		"INSERT INTO certificateStatusArchive SELECT * FROM certificateStatus WHERE serial = ?",
+		"DELETE FROM certificateStatus WHERE serial = ?",
+		"DELETE FROM certificates WHERE serial = ?",
+	} {
+		if _, err := tx.Exec(query, serial); err != nil {
+			return sa.Rollback(tx, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func main() {
+	configPath := flag.String("config", "config.json", "Path to Boulder configuration file")
+	flag.Parse()
+
+	configJSON, err := ioutil.ReadFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read config file '%s': %s\n", *configPath, err)
+		os.Exit(1)
+	}
+
+	var c config
+	err = cmd.ParseConfig(configJSON, &c)
+	cmd.FailOnError(err, "Failed to parse config")
+
+	logger := cmd.NewLogger(c.CertArchiver.Syslog)
+	logger.Info(cmd.VersionString())
+
+	defer logger.AuditPanic()
+
+	dbURL, err := c.CertArchiver.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbTLS, err := c.CertArchiver.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.CertArchiver.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.CertArchiver.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.CertArchiver.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
+	cmd.FailOnError(err, "Could not connect to database")
+	sa.SetSQLDebug(dbMap, logger)
+
+	if c.CertArchiver.RetentionPeriod.Duration == 0 {
+		fmt.Fprintln(os.Stderr, "Retention period is 0, refusing to archive all expired certificates")
+		os.Exit(1)
+	}
+	if c.CertArchiver.BatchSize <= 0 {
+		fmt.Fprintln(os.Stderr, "BatchSize field in config must be set to non-zero")
+		os.Exit(1)
+	}
+
+	archiver := &certArchiver{
+		log:        logger,
+		clk:        cmd.Clock(),
+		db:         dbMap,
+		batchSize:  c.CertArchiver.BatchSize,
+		batchDelay: c.CertArchiver.BatchDelay.Duration,
+	}
+	archiveBefore := archiver.clk.Now().Add(-c.CertArchiver.RetentionPeriod.Duration)
+	logger.Info("Beginning archival")
+	_, err = archiver.archive(archiveBefore, c.CertArchiver.MaxCerts)
+	cmd.FailOnError(err, "Failed to archive certificates")
+}
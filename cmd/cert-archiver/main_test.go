@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/sa"
+	"github.com/letsencrypt/boulder/sa/satest"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/test/vars"
+)
+
+func TestArchive(t *testing.T) {
+	dbMap, err := sa.NewDbMap(vars.DBConnSAFullPerms, 0)
+	if err != nil {
+		t.Fatalf("Couldn't connect the database: %s", err)
+	}
+	log := blog.UseMock()
+	fc := clock.NewFake()
+	fc.Add(time.Hour)
+	ssa, err := sa.NewSQLStorageAuthority(dbMap, fc, log, metrics.NewNoopScope(), 1)
+	if err != nil {
+		t.Fatalf("unable to create SQLStorageAuthority: %s", err)
+	}
+	cleanUp := test.ResetSATestDatabase(t)
+	defer cleanUp()
+
+	a := certArchiver{log: log, clk: fc, db: dbMap, batchSize: 1, batchDelay: time.Second}
+
+	reg := satest.CreateWorkingRegistration(t, ssa)
+	addCert := func(serial string, expires time.Time) {
+		err := dbMap.Insert(&core.Certificate{
+			RegistrationID: reg.ID,
+			Serial:         serial,
+			Digest:         "digest",
+			DER:            []byte{1, 2, 3},
+			Issued:         expires.Add(-90 * 24 * time.Hour),
+			Expires:        expires,
+		})
+		test.AssertNotError(t, err, "inserting certificate")
+		_, err = dbMap.Exec(
+			`INSERT INTO certificateStatus
+			(serial, status, ocspLastUpdated, revokedDate, revokedReason, lastExpirationNagSent, notAfter)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			serial, string(core.OCSPStatusGood), time.Time{}, time.Time{}, 0, time.Time{}, expires)
+		test.AssertNotError(t, err, "inserting certificate status")
+	}
+	addCert("00", fc.Now().Add(-2*time.Hour))
+	addCert("01", fc.Now().Add(-time.Hour))
+	addCert("02", fc.Now().Add(time.Hour))
+
+	counts := func() []int64 {
+		var counts []int64
+		for _, table := range []string{"certificates", "certificateStatus", "certificatesArchive", "certificateStatusArchive"} {
+			count, err := dbMap.SelectInt("SELECT COUNT(1) FROM " + table)
+			test.AssertNotError(t, err, "dbMap.SelectInt failed")
+			counts = append(counts, count)
+		}
+		return counts
+	}
+
+	// The max limits how many certificates are archived
+	count, err := a.archive(fc.Now(), 1)
+	test.AssertNotError(t, err, "archive failed")
+	test.AssertEquals(t, count, 1)
+	test.AssertDeepEquals(t, counts(), []int64{2, 2, 1, 1})
+
+	count, err = a.archive(fc.Now(), 100)
+	test.AssertNotError(t, err, "archive failed")
+	test.AssertEquals(t, count, 1)
+	test.AssertDeepEquals(t, counts(), []int64{1, 1, 2, 2})
+
+	count, err = a.archive(fc.Now().Add(2*time.Hour), 100)
+	test.AssertNotError(t, err, "archive failed")
+	test.AssertEquals(t, count, 1)
+	test.AssertDeepEquals(t, counts(), []int64{0, 0, 3, 3})
+
+	for _, serial := range []string{"00", "01", "02"} {
+		archived, err := dbMap.SelectInt("SELECT COUNT(1) FROM certificatesArchive WHERE serial = ?", serial)
+		test.AssertNotError(t, err, "dbMap.SelectInt failed")
+		test.AssertEquals(t, archived, int64(1))
+	}
+}
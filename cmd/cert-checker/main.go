@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log/syslog"
+	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"runtime"
@@ -16,20 +19,18 @@ import (
 
 	"github.com/jmhodges/clock"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/context"
 
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/letsencrypt/boulder/lint"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/policy"
 	"github.com/letsencrypt/boulder/sa"
-
-	lintasn1 "github.com/globalsign/certlint/asn1"
-	"github.com/globalsign/certlint/certdata"
-	"github.com/globalsign/certlint/checks"
-	_ "github.com/globalsign/certlint/checks/certificate/all"
-	_ "github.com/globalsign/certlint/checks/extensions/all"
 )
 
 const (
@@ -68,6 +69,8 @@ type report struct {
 	GoodCerts int64                  `json:"good-certs"`
 	BadCerts  int64                  `json:"bad-certs"`
 	Entries   map[string]reportEntry `json:"entries"`
+	// ProblemCounts is the number of problems found by each check.
+	ProblemCounts map[string]int64 `json:"problem-counts"`
 }
 
 func (r *report) dump() error {
@@ -79,6 +82,18 @@ func (r *report) dump() error {
 	return nil
 }
 
+// save writes the report to a file in dir named for the period it covers,
+// returning the file's path.
+func (r *report) save(dir string) (string, error) {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	filename := filepath.Join(dir, fmt.Sprintf("%s-%s-report.json",
+		r.begin.Format(filenameLayout), r.end.Format(filenameLayout)))
+	return filename, ioutil.WriteFile(filename, content, 0644)
+}
+
 type reportEntry struct {
 	Valid    bool     `json:"valid"`
 	Problems []string `json:"problems,omitempty"`
@@ -96,25 +111,54 @@ type certDB interface {
 
 type certChecker struct {
 	pa           core.PolicyAuthority
+	kp           goodkey.KeyPolicy
 	dbMap        certDB
 	certs        chan core.Certificate
 	clock        clock.Clock
 	rMu          *sync.Mutex
 	issuedReport report
 	checkPeriod  time.Duration
-	stats        metrics.Scope
+	// checks are the checks run on each certificate, all of them unless
+	// configured otherwise.
+	checks []certCheck
+	// validityPeriod is the validity period certificates are expected to
+	// have.
+	validityPeriod time.Duration
+
+	certsChecked *prometheus.CounterVec
+	problems     *prometheus.CounterVec
 }
 
-func newChecker(saDbMap certDB, clk clock.Clock, pa core.PolicyAuthority, period time.Duration) certChecker {
+func newChecker(saDbMap certDB, clk clock.Clock, pa core.PolicyAuthority, period time.Duration, stats metrics.Scope) certChecker {
+	certsChecked := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "certs_checked",
+		Help: "A counter of certificates checked, by result (valid or invalid)",
+	}, []string{"result"})
+	stats.MustRegister(certsChecked)
+	problems := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cert_problems",
+		Help: "A counter of problems found in certificates, by the check that found them",
+	}, []string{"check"})
+	stats.MustRegister(problems)
+
+	// The key policy has no weak key list unless one is configured, and has
+	// no way to check for blocked keys.
+	kp, _ := goodkey.NewKeyPolicy("", nil)
 	c := certChecker{
-		pa:          pa,
-		dbMap:       saDbMap,
-		certs:       make(chan core.Certificate, batchSize),
-		rMu:         new(sync.Mutex),
-		clock:       clk,
-		checkPeriod: period,
+		pa:             pa,
+		kp:             kp,
+		dbMap:          saDbMap,
+		certs:          make(chan core.Certificate, batchSize),
+		rMu:            new(sync.Mutex),
+		clock:          clk,
+		checkPeriod:    period,
+		checks:         allChecks,
+		validityPeriod: expectedValidityPeriod,
+		certsChecked:   certsChecked,
+		problems:       problems,
 	}
 	c.issuedReport.Entries = make(map[string]reportEntry)
+	c.issuedReport.ProblemCounts = make(map[string]int64)
 
 	return c
 }
@@ -181,131 +225,238 @@ func (c *certChecker) processCerts(wg *sync.WaitGroup, badResultsOnly bool) {
 		c.rMu.Unlock()
 		if !valid {
 			atomic.AddInt64(&c.issuedReport.BadCerts, 1)
+			c.certsChecked.WithLabelValues(bad).Inc()
 		} else {
 			atomic.AddInt64(&c.issuedReport.GoodCerts, 1)
+			c.certsChecked.WithLabelValues(good).Inc()
 		}
 	}
 	wg.Done()
 }
 
+// certCheck is a named check of a stored certificate. parsed is the parsed
+// certificate, or nil if it couldn't be parsed, in which case checks that
+// need it find no problems.
+type certCheck struct {
+	name  string
+	check func(c *certChecker, cert core.Certificate, parsed *x509.Certificate) []string
+}
+
+// allChecks are the available checks, in the order they're run.
+var allChecks = []certCheck{
+	{"stored-fields", checkStoredFields},
+	{"lint", checkLint},
+	{"profile", checkProfile},
+	{"validity-period", checkValidityPeriod},
+	{"san-policy", checkSANPolicy},
+	{"key-quality", checkKeyQuality},
+}
+
+// selectChecks returns the checks with the given names, in the order of
+// allChecks, or all of them if names is empty.
+func selectChecks(names []string) ([]certCheck, error) {
+	if len(names) == 0 {
+		return allChecks, nil
+	}
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var checks []certCheck
+	for _, check := range allChecks {
+		if wanted[check.name] {
+			checks = append(checks, check)
+			delete(wanted, check.name)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("unknown check %q", name)
+	}
+	return checks, nil
+}
+
 func (c *certChecker) checkCert(cert core.Certificate) (problems []string) {
+	parsedCert, err := x509.ParseCertificate(cert.DER)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("Couldn't parse stored certificate: %s", err))
+	}
+	for _, check := range c.checks {
+		found := check.check(c, cert, parsedCert)
+		if len(found) == 0 {
+			continue
+		}
+		problems = append(problems, found...)
+		c.problems.WithLabelValues(check.name).Add(float64(len(found)))
+		c.rMu.Lock()
+		c.issuedReport.ProblemCounts[check.name] += int64(len(found))
+		c.rMu.Unlock()
+	}
+	return problems
+}
+
+// checkStoredFields checks that the certificate's row in the database
+// matches the certificate.
+func checkStoredFields(_ *certChecker, cert core.Certificate, parsedCert *x509.Certificate) (problems []string) {
 	// Check digests match
 	if cert.Digest != core.Fingerprint256(cert.DER) {
 		problems = append(problems, "Stored digest doesn't match certificate digest")
 	}
+	if parsedCert == nil {
+		return problems
+	}
+	// Check stored serial is correct
+	storedSerial, err := core.StringToSerial(cert.Serial)
+	if err != nil {
+		problems = append(problems, "Stored serial is invalid")
+	} else if parsedCert.SerialNumber.Cmp(storedSerial) != 0 {
+		problems = append(problems, "Stored serial doesn't match certificate serial")
+	}
+	// Check we have the right expiration time
+	if !parsedCert.NotAfter.Equal(cert.Expires) {
+		problems = append(problems, "Stored expiration doesn't match certificate NotAfter")
+	}
+	// Check the stored issuance time isn't too far back/forward dated
+	if parsedCert.NotBefore.Before(cert.Issued.Add(-6*time.Hour)) || parsedCert.NotBefore.After(cert.Issued.Add(6*time.Hour)) {
+		problems = append(problems, "Stored issuance date is outside of 6 hour window of certificate NotBefore")
+	}
+	return problems
+}
 
-	// Run linter
-	linter := new(lintasn1.Linter)
-	errs := linter.CheckStruct(cert.DER)
-	if errs != nil {
-		for _, err := range errs.List() {
-			problems = append(problems, err.Error())
+// checkLint runs the certlint ASN.1 and certificate checks.
+func checkLint(_ *certChecker, cert core.Certificate, _ *x509.Certificate) (problems []string) {
+	findings, err := lint.Run(cert.DER)
+	for _, finding := range findings {
+		// commonName has been deprecated for years, but common practice is still
+		// to include it for compatibility reasons. For instance, Chrome on macOS
+		// until very recently would error on an empty Subject (which is what we
+		// would have if we omitted CommonName). There have been proposals at
+		// CA/Browser Forum for an alternate contentless field whose purpose would
+		// just be to make Subject non-empty, but so far they have not been
+		// successful.
+		if finding.Message != "commonName field is deprecated" {
+			problems = append(problems, finding.Message)
 		}
 	}
-	d, err := certdata.Load(cert.DER)
 	if err != nil {
 		problems = append(problems, err.Error())
 	}
-	errs = checks.Certificate.Check(d)
-	if errs != nil {
-		for _, err := range errs.List() {
-			// commonName has been deprecated for years, but common practice is still
-			// to include it for compatibility reasons. For instance, Chrome on macOS
-			// until very recently would error on an empty Subject (which is what we
-			// would have if we omitted CommonName). There have been proposals at
-			// CA/Browser Forum for an alternate contentless field whose purpose would
-			// just be to make Subject non-empty, but so far they have not been
-			// successful.
-			if err.Error() != "commonName field is deprecated" {
-				problems = append(problems, err.Error())
-			}
-		}
+	return problems
+}
+
+// checkProfile checks the certificate's extensions and subject against our
+// certificate profile.
+func checkProfile(_ *certChecker, _ core.Certificate, parsedCert *x509.Certificate) (problems []string) {
+	if parsedCert == nil {
+		return nil
 	}
+	// Check basic constraints are set
+	if !parsedCert.BasicConstraintsValid {
+		problems = append(problems, "Certificate doesn't have basic constraints set")
+	}
+	// Check the cert isn't able to sign other certificates
+	if parsedCert.IsCA {
+		problems = append(problems, "Certificate can sign other certificates")
+	}
+	// Check CommonName is <= 64 characters
+	if len(parsedCert.Subject.CommonName) > 64 {
+		problems = append(
+			problems,
+			fmt.Sprintf("Certificate has common name >64 characters long (%d)", len(parsedCert.Subject.CommonName)),
+		)
+	}
+	// Check the cert has the correct key usage extensions
+	if !reflect.DeepEqual(parsedCert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}) {
+		problems = append(problems, "Certificate has incorrect key usage extensions")
+	}
+	return problems
+}
 
-	// Parse certificate
-	parsedCert, err := x509.ParseCertificate(cert.DER)
-	if err != nil {
-		problems = append(problems, fmt.Sprintf("Couldn't parse stored certificate: %s", err))
-	} else {
-		// Check stored serial is correct
-		storedSerial, err := core.StringToSerial(cert.Serial)
-		if err != nil {
-			problems = append(problems, "Stored serial is invalid")
-		} else if parsedCert.SerialNumber.Cmp(storedSerial) != 0 {
-			problems = append(problems, "Stored serial doesn't match certificate serial")
-		}
-		// Check we have the right expiration time
-		if !parsedCert.NotAfter.Equal(cert.Expires) {
-			problems = append(problems, "Stored expiration doesn't match certificate NotAfter")
-		}
-		// Check basic constraints are set
-		if !parsedCert.BasicConstraintsValid {
-			problems = append(problems, "Certificate doesn't have basic constraints set")
-		}
-		// Check the cert isn't able to sign other certificates
-		if parsedCert.IsCA {
-			problems = append(problems, "Certificate can sign other certificates")
-		}
-		// Check the cert has the correct validity period
-		validityPeriod := parsedCert.NotAfter.Sub(parsedCert.NotBefore)
-		if validityPeriod > expectedValidityPeriod {
-			problems = append(problems, fmt.Sprintf("Certificate has a validity period longer than %s", expectedValidityPeriod))
-		} else if validityPeriod < expectedValidityPeriod {
-			problems = append(problems, fmt.Sprintf("Certificate has a validity period shorter than %s", expectedValidityPeriod))
-		}
-		// Check the stored issuance time isn't too far back/forward dated
-		if parsedCert.NotBefore.Before(cert.Issued.Add(-6*time.Hour)) || parsedCert.NotBefore.After(cert.Issued.Add(6*time.Hour)) {
-			problems = append(problems, "Stored issuance date is outside of 6 hour window of certificate NotBefore")
-		}
-		// Check CommonName is <= 64 characters
-		if len(parsedCert.Subject.CommonName) > 64 {
-			problems = append(
-				problems,
-				fmt.Sprintf("Certificate has common name >64 characters long (%d)", len(parsedCert.Subject.CommonName)),
-			)
+// checkValidityPeriod checks that the certificate has the expected validity
+// period.
+func checkValidityPeriod(c *certChecker, _ core.Certificate, parsedCert *x509.Certificate) (problems []string) {
+	if parsedCert == nil {
+		return nil
+	}
+	validityPeriod := parsedCert.NotAfter.Sub(parsedCert.NotBefore)
+	if validityPeriod > c.validityPeriod {
+		problems = append(problems, fmt.Sprintf("Certificate has a validity period longer than %s", c.validityPeriod))
+	} else if validityPeriod < c.validityPeriod {
+		problems = append(problems, fmt.Sprintf("Certificate has a validity period shorter than %s", c.validityPeriod))
+	}
+	return problems
+}
+
+// checkSANPolicy checks that the PA is still willing to issue for each of
+// the certificate's names.
+func checkSANPolicy(c *certChecker, cert core.Certificate, parsedCert *x509.Certificate) (problems []string) {
+	if parsedCert == nil {
+		return nil
+	}
+	// Check that the PA is still willing to issue for each name in DNSNames + CommonName
+	for _, name := range append(parsedCert.DNSNames, parsedCert.Subject.CommonName) {
+		id := core.AcmeIdentifier{Type: core.IdentifierDNS, Value: name}
+		// TODO(https://github.com/letsencrypt/boulder/issues/3371): Distinguish
+		// between certificates issued by v1 and v2 API.
+		checkFunc := c.pa.WillingToIssue
+		if features.Enabled(features.WildcardDomains) {
+			checkFunc = c.pa.WillingToIssueWildcard
 		}
-		// Check that the PA is still willing to issue for each name in DNSNames + CommonName
-		for _, name := range append(parsedCert.DNSNames, parsedCert.Subject.CommonName) {
-			id := core.AcmeIdentifier{Type: core.IdentifierDNS, Value: name}
-			// TODO(https://github.com/letsencrypt/boulder/issues/3371): Distinguish
-			// between certificates issued by v1 and v2 API.
-			checkFunc := c.pa.WillingToIssue
-			if features.Enabled(features.WildcardDomains) {
-				checkFunc = c.pa.WillingToIssueWildcard
-			}
-			if err = checkFunc(id); err != nil {
-				problems = append(problems, fmt.Sprintf("Policy Authority isn't willing to issue for '%s': %s", name, err))
-			} else {
-				// For defense-in-depth, even if the PA was willing to issue for a name
-				// we double check it against a list of forbidden domains. This way even
-				// if the hostnamePolicyFile malfunctions we will flag the forbidden
-				// domain matches
-				if forbidden, pattern := isForbiddenDomain(name); forbidden {
-					problems = append(problems, fmt.Sprintf(
-						"Policy Authority was willing to issue but domain '%s' matches "+
-							"forbiddenDomains entry %q", name, pattern))
-				}
+		if err := checkFunc(context.Background(), id, cert.RegistrationID); err != nil {
+			problems = append(problems, fmt.Sprintf("Policy Authority isn't willing to issue for '%s': %s", name, err))
+		} else {
+			// For defense-in-depth, even if the PA was willing to issue for a name
+			// we double check it against a list of forbidden domains. This way even
+			// if the hostnamePolicyFile malfunctions we will flag the forbidden
+			// domain matches
+			if forbidden, pattern := isForbiddenDomain(name); forbidden {
+				problems = append(problems, fmt.Sprintf(
+					"Policy Authority was willing to issue but domain '%s' matches "+
+						"forbiddenDomains entry %q", name, pattern))
 			}
 		}
-		// Check the cert has the correct key usage extensions
-		if !reflect.DeepEqual(parsedCert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}) {
-			problems = append(problems, "Certificate has incorrect key usage extensions")
-		}
 	}
 	return problems
 }
 
+// checkKeyQuality checks the certificate's public key against the key
+// policy, which includes the weak key list if one is configured.
+func checkKeyQuality(c *certChecker, _ core.Certificate, parsedCert *x509.Certificate) []string {
+	if parsedCert == nil {
+		return nil
+	}
+	if err := c.kp.GoodKey(context.Background(), parsedCert.PublicKey); err != nil {
+		return []string{fmt.Sprintf("Certificate has a bad public key: %s", err)}
+	}
+	return nil
+}
+
 type config struct {
 	CertChecker struct {
 		cmd.DBConfig
 		cmd.HostnamePolicyConfig
 
-		Workers             int
+		Workers int
+		// ReportDirectoryPath, if set, is the directory the report is
+		// written to instead of stdout.
 		ReportDirectoryPath string
 		UnexpiredOnly       bool
 		BadResultsOnly      bool
 		CheckPeriod         cmd.ConfigDuration
 
+		// Checks are the names of the checks to run on each certificate. All
+		// of them are run if this is empty.
+		Checks []string
+		// ExpectedValidityPeriod is the validity period certificates should
+		// have. Defaults to 90 days.
+		ExpectedValidityPeriod cmd.ConfigDuration
+		// WeakKeyFile is the path to a JSON file containing truncated RSA
+		// modulus hashes of known easily enumerable keys, used by the
+		// key-quality check.
+		WeakKeyFile string
+		// DebugAddr, if set, is the address metrics are served on while
+		// certificates are being checked.
+		DebugAddr string
+
 		Features map[string]bool
 	}
 
@@ -323,6 +474,7 @@ func main() {
 	connect := flag.String("db-connect", "", "SQL URI if not provided in the configuration file")
 	cp := flag.Duration("check-period", time.Hour*2160, "How far back to check")
 	unexpiredOnly := flag.Bool("unexpired-only", false, "Only check currently unexpired certificates")
+	reportDir := flag.String("report-dir", "", "Directory to write the report to instead of stdout")
 
 	flag.Parse()
 	if *configFile == "" {
@@ -353,18 +505,34 @@ func main() {
 	config.CertChecker.UnexpiredOnly = *unexpiredOnly
 	config.CertChecker.BadResultsOnly = *badResultsOnly
 	config.CertChecker.CheckPeriod.Duration = *cp
+	if *reportDir != "" {
+		config.CertChecker.ReportDirectoryPath = *reportDir
+	}
 
 	// Validate PA config and set defaults if needed
 	cmd.FailOnError(config.PA.CheckChallenges(), "Invalid PA configuration")
 
 	saDbURL, err := config.CertChecker.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
-	saDbMap, err := sa.NewDbMap(saDbURL, config.CertChecker.DBConfig.MaxDBConns)
+	dbTLS, err := config.CertChecker.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	saDbMap, err := sa.NewDbMapWithSettings(saDbURL, sa.DbSettings{
+		MaxOpenConns:    config.CertChecker.DBConfig.MaxDBConns,
+		MaxIdleConns:    config.CertChecker.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: config.CertChecker.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
 	cmd.FailOnError(err, "Could not connect to database")
 	scope := metrics.NewPromScope(prometheus.DefaultRegisterer)
 	go sa.ReportDbConnCount(saDbMap, scope)
+	if config.CertChecker.DebugAddr != "" {
+		go func() {
+			err := http.ListenAndServe(config.CertChecker.DebugAddr, promhttp.Handler())
+			cmd.FailOnError(err, "Failed to serve metrics")
+		}()
+	}
 
-	pa, err := policy.New(config.PA.Challenges)
+	pa, err := policy.New(config.PA.Challenges, scope)
 	cmd.FailOnError(err, "Failed to create PA")
 	err = pa.SetHostnamePolicyFile(config.CertChecker.HostnamePolicyFile)
 	cmd.FailOnError(err, "Failed to load HostnamePolicyFile")
@@ -374,7 +542,15 @@ func main() {
 		cmd.Clock(),
 		pa,
 		config.CertChecker.CheckPeriod.Duration,
+		scope,
 	)
+	checker.checks, err = selectChecks(config.CertChecker.Checks)
+	cmd.FailOnError(err, "Invalid checks")
+	if config.CertChecker.ExpectedValidityPeriod.Duration != 0 {
+		checker.validityPeriod = config.CertChecker.ExpectedValidityPeriod.Duration
+	}
+	checker.kp, err = goodkey.NewKeyPolicy(config.CertChecker.WeakKeyFile, nil)
+	cmd.FailOnError(err, "Unable to create key policy")
 	fmt.Fprintf(os.Stderr, "# Getting certificates issued in the last %s\n", config.CertChecker.CheckPeriod)
 
 	// Since we grab certificates in batches we don't want this to block, when it
@@ -403,7 +579,13 @@ func main() {
 		checker.issuedReport.GoodCerts,
 		checker.issuedReport.BadCerts,
 	)
-	err = checker.issuedReport.dump()
-	cmd.FailOnError(err, "Failed to dump results: %s\n")
+	if config.CertChecker.ReportDirectoryPath != "" {
+		filename, err := checker.issuedReport.save(config.CertChecker.ReportDirectoryPath)
+		cmd.FailOnError(err, "Failed to save results")
+		fmt.Fprintf(os.Stderr, "# Saved report to %s\n", filename)
+	} else {
+		err = checker.issuedReport.dump()
+		cmd.FailOnError(err, "Failed to dump results: %s\n")
+	}
 
 }
@@ -5,10 +5,14 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"math/big"
 	mrand "math/rand"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -31,7 +35,7 @@ var pa *policy.AuthorityImpl
 
 func init() {
 	var err error
-	pa, err = policy.New(map[string]bool{})
+	pa, err = policy.New(map[string]bool{}, metrics.NewNoopScope())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -51,7 +55,7 @@ func BenchmarkCheckCert(b *testing.B) {
 		test.ResetSATestDatabase(b)()
 	}()
 
-	checker := newChecker(saDbMap, clock.Default(), pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, clock.Default(), pa, expectedValidityPeriod, metrics.NewNoopScope())
 	testKey, _ := rsa.GenerateKey(rand.Reader, 1024)
 	expiry := time.Now().AddDate(0, 0, 1)
 	serial := big.NewInt(1337)
@@ -91,7 +95,7 @@ func TestCheckWildcardCert(t *testing.T) {
 	testKey, _ := rsa.GenerateKey(rand.Reader, 2048)
 	fc := clock.NewFake()
 	fc.Add(time.Hour * 24 * 90)
-	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod, metrics.NewNoopScope())
 	issued := checker.clock.Now().Add(-time.Hour * 24 * 45)
 	goodExpiry := issued.Add(expectedValidityPeriod)
 	serial := big.NewInt(1337)
@@ -139,7 +143,7 @@ func TestCheckCert(t *testing.T) {
 	fc := clock.NewFake()
 	fc.Add(time.Hour * 24 * 90)
 
-	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod, metrics.NewNoopScope())
 
 	issued := checker.clock.Now().Add(-time.Hour * 24 * 45)
 	goodExpiry := issued.Add(expectedValidityPeriod)
@@ -233,7 +237,7 @@ func TestGetAndProcessCerts(t *testing.T) {
 	test.AssertNotError(t, err, "Couldn't connect to database")
 	fc := clock.NewFake()
 
-	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod, metrics.NewNoopScope())
 	sa, err := sa.NewSQLStorageAuthority(saDbMap, fc, blog.NewMock(), metrics.NewNoopScope(), 1)
 	test.AssertNotError(t, err, "Couldn't create SA to insert certificates")
 	saCleanUp := test.ResetSATestDatabase(t)
@@ -258,7 +262,7 @@ func TestGetAndProcessCerts(t *testing.T) {
 		rawCert.SerialNumber = big.NewInt(mrand.Int63())
 		certDER, err := x509.CreateCertificate(rand.Reader, &rawCert, &rawCert, &testKey.PublicKey, testKey)
 		test.AssertNotError(t, err, "Couldn't create certificate")
-		_, err = sa.AddCertificate(context.Background(), certDER, reg.ID, nil)
+		_, err = sa.AddCertificate(context.Background(), certDER, reg.ID, nil, nil)
 		test.AssertNotError(t, err, "Couldn't add certificate")
 	}
 
@@ -318,7 +322,7 @@ func TestGetCertsEmptyResults(t *testing.T) {
 	saDbMap, err := sa.NewDbMap(vars.DBConnSA, 0)
 	test.AssertNotError(t, err, "Couldn't connect to database")
 	fc := clock.NewFake()
-	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod, metrics.NewNoopScope())
 	checker.dbMap = mismatchedCountDB{}
 
 	batchSize = 3
@@ -350,6 +354,88 @@ func TestSaveReport(t *testing.T) {
 	test.AssertNotError(t, err, "Failed to dump results")
 }
 
+func TestSelectChecks(t *testing.T) {
+	checks, err := selectChecks(nil)
+	test.AssertNotError(t, err, "selecting all checks")
+	test.AssertEquals(t, len(checks), len(allChecks))
+
+	checks, err = selectChecks([]string{"key-quality", "stored-fields"})
+	test.AssertNotError(t, err, "selecting checks")
+	test.AssertEquals(t, len(checks), 2)
+	test.AssertEquals(t, checks[0].name, "stored-fields")
+	test.AssertEquals(t, checks[1].name, "key-quality")
+
+	_, err = selectChecks([]string{"stored-fields", "zlint"})
+	test.AssertError(t, err, "selected an unknown check")
+}
+
+func TestConfiguredChecks(t *testing.T) {
+	fc := clock.NewFake()
+	checker := newChecker(nil, fc, pa, expectedValidityPeriod, metrics.NewNoopScope())
+	checker.checks, _ = selectChecks([]string{"validity-period", "key-quality"})
+	checker.validityPeriod = 7 * 24 * time.Hour
+
+	// A 1024 bit key is too small for the key policy
+	testKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	issued := fc.Now()
+	rawCert := x509.Certificate{
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    issued,
+		NotAfter:     issued.Add(expectedValidityPeriod),
+		DNSNames:     []string{"example.com"},
+		SerialNumber: big.NewInt(1337),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &rawCert, &rawCert, &testKey.PublicKey, testKey)
+	test.AssertNotError(t, err, "Couldn't create certificate")
+
+	// Only the selected checks are run, so the missing digest and basic
+	// constraints aren't problems
+	problems := checker.checkCert(core.Certificate{Serial: "1337", DER: certDER})
+	test.AssertDeepEquals(t, problems, []string{
+		"Certificate has a validity period longer than 168h0m0s",
+		"Certificate has a bad public key: key too small: 1024",
+	})
+	test.AssertEquals(t, test.CountCounter(checker.problems.WithLabelValues("validity-period")), 1)
+	test.AssertEquals(t, test.CountCounter(checker.problems.WithLabelValues("key-quality")), 1)
+	test.AssertDeepEquals(t, checker.issuedReport.ProblemCounts, map[string]int64{
+		"validity-period": 1,
+		"key-quality":     1,
+	})
+
+	checker.certs <- core.Certificate{Serial: "1337", DER: certDER}
+	close(checker.certs)
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	checker.processCerts(wg, true)
+	test.AssertEquals(t, test.CountCounter(checker.certsChecked.WithLabelValues(bad)), 1)
+	test.AssertEquals(t, test.CountCounter(checker.certsChecked.WithLabelValues(good)), 0)
+}
+
+func TestSaveReportToDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert-checker")
+	test.AssertNotError(t, err, "creating temp dir")
+	defer os.RemoveAll(dir)
+
+	r := report{
+		begin:         time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		end:           time.Date(2018, 4, 1, 0, 0, 0, 0, time.UTC),
+		BadCerts:      1,
+		Entries:       map[string]reportEntry{"1337": {Problems: []string{"None really..."}}},
+		ProblemCounts: map[string]int64{"lint": 1},
+	}
+	filename, err := r.save(dir)
+	test.AssertNotError(t, err, "Failed to save results")
+	test.AssertEquals(t, filename, filepath.Join(dir, "20180101-20180401-report.json"))
+
+	contents, err := ioutil.ReadFile(filename)
+	test.AssertNotError(t, err, "Failed to read results")
+	var saved report
+	err = json.Unmarshal(contents, &saved)
+	test.AssertNotError(t, err, "Failed to parse results")
+	test.AssertEquals(t, saved.BadCerts, int64(1))
+	test.AssertDeepEquals(t, saved.ProblemCounts, r.ProblemCounts)
+}
+
 func TestIsForbiddenDomain(t *testing.T) {
 	// Note: These testcases are not an exhaustive representation of domains
 	// Boulder won't issue for, but are instead testing the defense-in-depth
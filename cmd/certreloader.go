@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+)
+
+// certReloadInterval is how often a certReloader checks whether its files
+// have changed.
+const certReloadInterval = time.Minute
+
+// certReloader holds a certificate and key loaded from disk and reloads them
+// when either file is modified, so that short-lived certificates can be
+// rotated without restarting the process.
+type certReloader struct {
+	certFile, keyFile string
+	clk               clock.Clock
+
+	sync.Mutex
+	cert            *tls.Certificate
+	certMod, keyMod time.Time
+	nextCheck       time.Time
+}
+
+// newCertReloader loads the key pair from certFile and keyFile, returning an
+// error if it can't be loaded.
+func newCertReloader(certFile, keyFile string, clk clock.Clock) (*certReloader, error) {
+	cr := &certReloader{certFile: certFile, keyFile: keyFile, clk: clk}
+	if err := cr.load(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// load reads the key pair and the modification times of its files.
+func (cr *certReloader) load() error {
+	certMod, keyMod, err := cr.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading key pair from %q and %q: %s",
+			cr.certFile, cr.keyFile, err)
+	}
+	cr.cert = &cert
+	cr.certMod, cr.keyMod = certMod, keyMod
+	cr.nextCheck = cr.clk.Now().Add(certReloadInterval)
+	return nil
+}
+
+func (cr *certReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(cr.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("loading key pair from %q and %q: %s",
+			cr.certFile, cr.keyFile, err)
+	}
+	keyInfo, err := os.Stat(cr.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("loading key pair from %q and %q: %s",
+			cr.certFile, cr.keyFile, err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// current returns the loaded certificate, first reloading it if the files
+// have changed since they were last checked. If the new files can't be
+// loaded, for instance because only one of them has been replaced so far, the
+// previous certificate keeps being used and loading is retried on the next
+// check.
+func (cr *certReloader) current() *tls.Certificate {
+	cr.Lock()
+	defer cr.Unlock()
+	if cr.clk.Now().Before(cr.nextCheck) {
+		return cr.cert
+	}
+	cr.nextCheck = cr.clk.Now().Add(certReloadInterval)
+	certMod, keyMod, err := cr.modTimes()
+	if err != nil || (certMod.Equal(cr.certMod) && keyMod.Equal(cr.keyMod)) {
+		return cr.cert
+	}
+	_ = cr.load()
+	return cr.cert
+}
+
+// getCertificate implements tls.Config.GetCertificate.
+func (cr *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return cr.current(), nil
+}
+
+// getClientCertificate implements tls.Config.GetClientCertificate.
+func (cr *certReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return cr.current(), nil
+}
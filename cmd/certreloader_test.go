@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func copyFile(t *testing.T, from, to string, mod time.Time) {
+	contents, err := ioutil.ReadFile(from)
+	test.AssertNotError(t, err, "reading "+from)
+	err = ioutil.WriteFile(to, contents, 0600)
+	test.AssertNotError(t, err, "writing "+to)
+	err = os.Chtimes(to, mod, mod)
+	test.AssertNotError(t, err, "setting times of "+to)
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certreloader")
+	test.AssertNotError(t, err, "creating temp dir")
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	fc := clock.NewFake()
+	first := fc.Now().Add(-time.Hour)
+	copyFile(t, "testdata/cert.pem", certFile, first)
+	copyFile(t, "testdata/key.pem", keyFile, first)
+
+	cr, err := newCertReloader(certFile, keyFile, fc)
+	test.AssertNotError(t, err, "newCertReloader failed")
+	original := cr.current()
+
+	// Replacing the files isn't noticed until the next check
+	second := fc.Now()
+	copyFile(t, "../grpc/creds/testdata/boulder-client/cert.pem", certFile, second)
+	test.AssertEquals(t, cr.current(), original)
+
+	// A half replaced key pair keeps the old certificate in use
+	fc.Add(certReloadInterval)
+	test.AssertEquals(t, cr.current(), original)
+
+	copyFile(t, "../grpc/creds/testdata/boulder-client/key.pem", keyFile, second)
+	fc.Add(certReloadInterval)
+	reloaded, err := cr.getClientCertificate(nil)
+	test.AssertNotError(t, err, "getClientCertificate failed")
+	test.Assert(t, !bytes.Equal(reloaded.Certificate[0], original.Certificate[0]),
+		"Certificate wasn't reloaded")
+	served, err := cr.getCertificate(nil)
+	test.AssertNotError(t, err, "getCertificate failed")
+	test.AssertEquals(t, served, reloaded)
+
+	// Missing files keep the current certificate in use
+	os.Remove(keyFile)
+	fc.Add(certReloadInterval)
+	test.AssertEquals(t, cr.current(), reloaded)
+}
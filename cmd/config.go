@@ -10,19 +10,29 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jmhodges/clock"
+
 	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/web"
 )
 
-// PasswordConfig either contains a password or the path to a file
-// containing a password
+// PasswordConfig either contains a password, the path to a file
+// containing a password, or a URI identifying a secret
 type PasswordConfig struct {
 	Password     string
 	PasswordFile string
+	// PasswordSecret is a secret URI, as accepted by ResolveSecret, for
+	// reading the password from the environment, a systemd credential or a
+	// secret store.
+	PasswordSecret string
 }
 
 // Pass returns a password, either directly from the configuration
-// struct or by reading from a specified file
+// struct, by reading from a specified file or by resolving a secret URI
 func (pc *PasswordConfig) Pass() (string, error) {
+	if pc.PasswordSecret != "" {
+		return ResolveSecret(pc.PasswordSecret)
+	}
 	if pc.PasswordFile != "" {
 		contents, err := ioutil.ReadFile(pc.PasswordFile)
 		if err != nil {
@@ -40,6 +50,23 @@ type ServiceConfig struct {
 	DebugAddr string
 	GRPC      *GRPCServerConfig
 	TLS       TLSConfig
+	// Tracing, if present, enables exporting trace spans.
+	Tracing *TracingConfig
+}
+
+// TracingConfig configures the export of trace spans to an OpenTelemetry
+// collector.
+type TracingConfig struct {
+	// Endpoint is the URL of the collector's OTLP/HTTP traces receiver, e.g.
+	// "http://localhost:4318/v1/traces".
+	Endpoint string `validate:"required"`
+	// SampleRate is the fraction of the traces started by this service that
+	// are exported. Traces continued from other services follow their
+	// decision.
+	SampleRate float64 `validate:"min=0,max=1"`
+	// ExportInterval is how often spans are sent to the collector. It
+	// defaults to five seconds.
+	ExportInterval ConfigDuration
 }
 
 // DBConfig defines how to connect to a database. The connect string may be
@@ -48,15 +75,85 @@ type ServiceConfig struct {
 type DBConfig struct {
 	DBConnect string
 	// A file containing a connect URL for the DB.
-	DBConnectFile  string
-	MaxDBConns     int
+	DBConnectFile string
+	// DBConnectSecret is a secret URI, as accepted by ResolveSecret,
+	// identifying the connect URL for the DB.
+	DBConnectSecret string
+	// MaxDBConns is the maximum number of open connections to the database,
+	// zero for unlimited.
+	MaxDBConns int
+	// MaxIdleDBConns is the maximum number of idle connections kept open, zero
+	// for the database/sql default.
 	MaxIdleDBConns int
+	// ConnMaxLifetime is how long a connection may be reused before it's
+	// closed, zero for forever.
+	ConnMaxLifetime ConfigDuration
+	// DBTLS, if present, requires connections to the database to use TLS and
+	// to verify the server's certificate.
+	DBTLS *DBTLSConfig
+}
+
+// DBTLSConfig configures TLS for connections to a database, for deployments
+// where the database is reached across networks that aren't trusted.
+type DBTLSConfig struct {
+	// CACertFile is a PEM bundle of the CAs trusted to issue the database
+	// server's certificate.
+	CACertFile string
+	// CertFile and KeyFile optionally hold a client certificate and key that
+	// are presented to the server.
+	CertFile string
+	KeyFile  string
+	// ServerName is the name the server's certificate is verified against. It
+	// defaults to the host in the connect URL.
+	ServerName string
+}
+
+// Load reads the files listed in the DBTLSConfig and returns a *tls.Config
+// suitable for connecting to the database.
+func (t *DBTLSConfig) Load() (*tls.Config, error) {
+	if t.CACertFile == "" {
+		return nil, fmt.Errorf("no CACertFile in database TLS config")
+	}
+	caCertBytes, err := ioutil.ReadFile(t.CACertFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA cert from %q: %s", t.CACertFile, err)
+	}
+	rootCAs := x509.NewCertPool()
+	if ok := rootCAs.AppendCertsFromPEM(caCertBytes); !ok {
+		return nil, fmt.Errorf("parsing CA certs from %s failed", t.CACertFile)
+	}
+	config := &tls.Config{
+		RootCAs:    rootCAs,
+		ServerName: t.ServerName,
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading key pair from %q and %q: %s",
+				t.CertFile, t.KeyFile, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// TLSConfig returns the *tls.Config described by the DBTLS section, or nil if
+// there is none and connections to the database don't use TLS.
+func (d *DBConfig) TLSConfig() (*tls.Config, error) {
+	if d.DBTLS == nil {
+		return nil, nil
+	}
+	return d.DBTLS.Load()
 }
 
 // URL returns the DBConnect URL represented by this DBConfig object, either
-// loading it from disk or returning a default value. Leading and trailing
-// whitespace is stripped.
+// resolving it as a secret, loading it from disk or returning a default value.
+// Leading and trailing whitespace is stripped.
 func (d *DBConfig) URL() (string, error) {
+	if d.DBConnectSecret != "" {
+		url, err := ResolveSecret(d.DBConnectSecret)
+		return strings.TrimSpace(url), err
+	}
 	if d.DBConnectFile != "" {
 		url, err := ioutil.ReadFile(d.DBConnectFile)
 		return strings.TrimSpace(string(url)), err
@@ -64,6 +161,18 @@ func (d *DBConfig) URL() (string, error) {
 	return d.DBConnect, nil
 }
 
+// RedisConfig defines how to connect to a Redis server. The password may be
+// stored in a file separate from the config.
+type RedisConfig struct {
+	PasswordConfig
+	// Addr is the host:port of the server.
+	Addr string
+	// Timeout is how long each command may take, including connecting.
+	Timeout ConfigDuration
+	// MaxIdleConns is how many connections are kept open between commands.
+	MaxIdleConns int
+}
+
 type SMTPConfig struct {
 	PasswordConfig
 	Server   string
@@ -79,6 +188,12 @@ type PAConfig struct {
 	EnforcePolicyWhitelist  bool
 	Challenges              map[string]bool
 	ChallengesWhitelistFile string
+
+	// PublicSuffixListURL, if set, is periodically fetched to replace the
+	// Public Suffix List compiled into Boulder, every
+	// PublicSuffixListUpdateInterval (default 24h).
+	PublicSuffixListURL            string
+	PublicSuffixListUpdateInterval ConfigDuration
 }
 
 // HostnamePolicyConfig specifies a file from which to load a policy regarding
@@ -101,13 +216,43 @@ func (pc PAConfig) CheckChallenges() error {
 	return nil
 }
 
-// TLSConfig represents certificates and a key for authenticated TLS.
+// TLSConfig represents certificates and a key for authenticated TLS. The
+// certificate and key are reloaded when their files change.
 type TLSConfig struct {
 	CertFile   *string
 	KeyFile    *string
 	CACertFile *string
 }
 
+// StaticAssetConfig describes a file, such as a subscriber agreement or an
+// intermediate certificate, that the WFE serves verbatim.
+type StaticAssetConfig struct {
+	// File is the path to the asset on disk.
+	File string
+	// SHA256 is the hex-encoded SHA-256 digest the file must have. The WFE
+	// refuses to start if it doesn't match.
+	SHA256 string
+	// ContentType is sent in the Content-Type header. If empty it is inferred
+	// from the file's extension.
+	ContentType string
+	// MaxAge is how long clients and caches may cache the asset.
+	MaxAge ConfigDuration
+}
+
+// LoadStaticAssets reads and verifies every configured static asset, keyed by
+// the name it is served under.
+func LoadStaticAssets(configs map[string]StaticAssetConfig) (web.StaticAssets, error) {
+	assets := make(web.StaticAssets, len(configs))
+	for name, c := range configs {
+		asset, err := web.LoadStaticAsset(c.File, c.SHA256, c.ContentType, c.MaxAge.Duration)
+		if err != nil {
+			return nil, err
+		}
+		assets[name] = asset
+	}
+	return assets, nil
+}
+
 // Load reads and parses the certificates and key listed in the TLSConfig, and
 // returns a *tls.Config suitable for either client or server use.
 func (t *TLSConfig) Load() (*tls.Config, error) {
@@ -131,16 +276,18 @@ func (t *TLSConfig) Load() (*tls.Config, error) {
 	if ok := rootCAs.AppendCertsFromPEM(caCertBytes); !ok {
 		return nil, fmt.Errorf("parsing CA certs from %s failed", *t.CACertFile)
 	}
-	cert, err := tls.LoadX509KeyPair(*t.CertFile, *t.KeyFile)
+	reloader, err := newCertReloader(*t.CertFile, *t.KeyFile, clock.Default())
 	if err != nil {
-		return nil, fmt.Errorf("loading key pair from %q and %q: %s",
-			*t.CertFile, *t.KeyFile, err)
+		return nil, err
 	}
+	// The key pair is served through the Get callbacks rather than
+	// Certificates so that it's picked up again when the files are replaced.
 	return &tls.Config{
-		RootCAs:      rootCAs,
-		ClientCAs:    rootCAs,
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		Certificates: []tls.Certificate{cert},
+		RootCAs:              rootCAs,
+		ClientCAs:            rootCAs,
+		ClientAuth:           tls.RequireAndVerifyClientCert,
+		GetCertificate:       reloader.getCertificate,
+		GetClientCertificate: reloader.getClientCertificate,
 	}, nil
 }
 
@@ -167,11 +314,39 @@ type OCSPUpdaterConfig struct {
 	MissingSCTBatchSize         int
 	RevokedCertificateBatchSize int
 
-	OCSPMinTimeToExpiry          ConfigDuration
-	OCSPStaleMaxAge              ConfigDuration
-	OldestIssuedSCT              ConfigDuration
+	// RecentRevocationWindow and RecentRevocationBatchSize, if both set,
+	// enable a loop dedicated to certificates revoked in the last
+	// RecentRevocationMaxAge, so that their revoked responses are generated
+	// within seconds rather than behind a backlog of older revocations.
+	// RecentRevocationMaxAge defaults to ten minutes.
+	RecentRevocationWindow    ConfigDuration
+	RecentRevocationBatchSize int
+	RecentRevocationMaxAge    ConfigDuration
+
+	OCSPMinTimeToExpiry ConfigDuration
+	OCSPStaleMaxAge     ConfigDuration
+	OldestIssuedSCT     ConfigDuration
+
+	// ParallelGenerateOCSPRequests is the number of workers signing and
+	// storing responses concurrently within each batch. Defaults to 1.
 	ParallelGenerateOCSPRequests int
 
+	// SerialPrefixes optionally restricts this updater to certificates whose
+	// serial numbers begin with one of the given prefix bytes, allowing OCSP
+	// generation to be sharded across updaters by CA instance or issuer.
+	SerialPrefixes []int
+
+	// ExpiredResponseGrace, if set, enables pruning: once a certificate has
+	// been expired for this long its final OCSP response is deleted, in
+	// batches of OldOCSPBatchSize every OldOCSPWindow. It should match the
+	// ocsp-responder's ExpiredResponseGrace.
+	ExpiredResponseGrace ConfigDuration
+
+	// OCSPRedis, if present, is a Redis server to which each newly stored
+	// OCSP response is also written, until its nextUpdate, so that
+	// ocsp-responder can serve it without querying the database.
+	OCSPRedis *RedisConfig
+
 	AkamaiBaseURL      string
 	AkamaiClientToken  string
 	AkamaiClientSecret string
@@ -183,9 +358,33 @@ type OCSPUpdaterConfig struct {
 	AkamaiPurgeRetries      int
 	AkamaiPurgeRetryBackoff ConfigDuration
 
+	// CDNPurge, if present and AkamaiBaseURL isn't set, configures the purging
+	// of OCSP responses from a CDN other than Akamai.
+	CDNPurge *CDNPurgeConfig
+	// PurgeBatchSize is the maximum number of URLs purged from the CDN with a
+	// single request, and PurgeBatchInterval the time between requests. URLs
+	// to purge are queued, deduplicated, and sent in batches; batches that
+	// fail are retried with backoff. They default to 100 and 1s.
+	PurgeBatchSize     int
+	PurgeBatchInterval ConfigDuration
+	// AkamaiPurgeBatchSize and AkamaiPurgeBatchInterval are the deprecated
+	// names of PurgeBatchSize and PurgeBatchInterval, used when those aren't
+	// set.
+	AkamaiPurgeBatchSize     int
+	AkamaiPurgeBatchInterval ConfigDuration
+
 	SignFailureBackoffFactor float64
 	SignFailureBackoffMax    ConfigDuration
 
+	// MissingSCTBackoffFactor, if non-zero, spaces out the resubmission of a
+	// certificate to a log that keeps failing to return an SCT: the delay
+	// starts at MissingSCTWindow and grows by this factor after each failed
+	// attempt, up to MissingSCTBackoffMax. If zero, missing SCTs are retried
+	// every MissingSCTWindow. The backoff requires the CTResubmissionQueue
+	// feature, which keeps the attempts in the database.
+	MissingSCTBackoffFactor float64
+	MissingSCTBackoffMax    ConfigDuration
+
 	Publisher            *GRPCClientConfig
 	SAService            *GRPCClientConfig
 	OCSPGeneratorService *GRPCClientConfig
@@ -193,6 +392,21 @@ type OCSPUpdaterConfig struct {
 	Features map[string]bool
 }
 
+// CDNPurgeConfig configures the purging of cached OCSP responses from a CDN.
+type CDNPurgeConfig struct {
+	// Provider is one of "fastly", "cloudflare" or "webhook".
+	Provider string
+	// FastlyAPIKey is sent with Fastly purges, if the service requires
+	// authenticated purging.
+	FastlyAPIKey string
+	// CloudflareZoneID and CloudflareAPIToken identify the Cloudflare zone to
+	// purge and authorize the purge.
+	CloudflareZoneID   string
+	CloudflareAPIToken string
+	// WebhookURL receives a POST listing the URLs to purge.
+	WebhookURL string
+}
+
 // GoogleSafeBrowsingConfig is the JSON config struct for the VA's use of the
 // Google Safe Browsing API.
 type GoogleSafeBrowsingConfig struct {
@@ -203,8 +417,16 @@ type GoogleSafeBrowsingConfig struct {
 
 // SyslogConfig defines the config for syslogging.
 type SyslogConfig struct {
-	StdoutLevel int
-	SyslogLevel int
+	StdoutLevel int `validate:"min=0,max=7"`
+	SyslogLevel int `validate:"min=0,max=7"`
+	// Format selects how log lines are written: "text", the default, or
+	// "json" for one JSON object per line.
+	Format string `validate:"oneof=text json"`
+	// SuppressRepeats, if non-zero, is the window within which repetitions
+	// of an identical warning message are counted rather than logged, and
+	// then summarized once the window has passed. Errors are audit logged and
+	// never suppressed.
+	SuppressRepeats ConfigDuration
 }
 
 // StatsdConfig defines the config for Statsd.
@@ -266,12 +488,62 @@ func (d *ConfigDuration) UnmarshalYAML(unmarshal func(interface{}) error) error
 type LogDescription struct {
 	URI string
 	Key string
+	// TemporalStart and TemporalEnd, if set, restrict a temporally sharded log
+	// to certificates whose NotAfter is at or after TemporalStart and before
+	// TemporalEnd. Certificates outside the shard aren't submitted to the log.
+	TemporalStart time.Time
+	TemporalEnd   time.Time
+	// SubmissionsPerSecond, if non-zero, limits the rate at which
+	// certificates are submitted to the log. Submissions beyond the limit
+	// are skipped, as if the log had failed, rather than queued.
+	SubmissionsPerSecond float64
+}
+
+// AcceptsExpiry returns true if a certificate expiring at notAfter falls in
+// the log's temporal shard, if it has one.
+func (ld LogDescription) AcceptsExpiry(notAfter time.Time) bool {
+	if !ld.TemporalStart.IsZero() && notAfter.Before(ld.TemporalStart) {
+		return false
+	}
+	if !ld.TemporalEnd.IsZero() && !notAfter.Before(ld.TemporalEnd) {
+		return false
+	}
+	return true
 }
 
 // GRPCClientConfig contains the information needed to talk to the gRPC service
 type GRPCClientConfig struct {
+	// ServerAddresses lists the host:port addresses of the backends. Requests
+	// are balanced across them round-robin.
 	ServerAddresses []string
-	Timeout         ConfigDuration
+	// SRVLookup, if set, is the name of a DNS SRV record, e.g.
+	// "_sa._tcp.boulder", whose targets are used as the backends instead of
+	// ServerAddresses. It's looked up again every SRVLookupInterval, which
+	// defaults to one minute.
+	SRVLookup         string
+	SRVLookupInterval ConfigDuration
+	// HealthCheckInterval, if non-zero, is how often each backend is checked
+	// with the gRPC health checking protocol. Backends that fail a check
+	// aren't sent requests until they pass one.
+	HealthCheckInterval ConfigDuration
+	// Timeout is the default deadline of calls to the service. Calls made on
+	// behalf of a request with an earlier deadline get that deadline instead.
+	Timeout ConfigDuration
+	// MethodTimeouts optionally overrides Timeout for individual methods,
+	// keyed by method name, e.g. "PerformValidation".
+	MethodTimeouts map[string]ConfigDuration
+	// FieldEncryption, if set, enables application-layer encryption of
+	// sensitive fields in SA requests and responses. The server must be
+	// configured with the same keys.
+	FieldEncryption *FieldEncryptionConfig
+}
+
+// Validate checks that the backends of the service are configured.
+func (c *GRPCClientConfig) Validate() error {
+	if len(c.ServerAddresses) == 0 && c.SRVLookup == "" {
+		return errors.New("one of serverAddresses and srvLookup is required")
+	}
+	return nil
 }
 
 // GRPCServerConfig contains the information needed to run a gRPC service
@@ -281,6 +553,38 @@ type GRPCServerConfig struct {
 	// (SANs). The server will reject clients that do not present a certificate
 	// with a SAN present on the `ClientNames` list.
 	ClientNames []string `json:"clientNames"`
+	// Services optionally restricts which clients may call each of the gRPC
+	// services served, keyed by full service name, e.g.
+	// "sa.StorageAuthority". Clients named here are accepted in addition to
+	// those in ClientNames, but may only call the services they're listed
+	// for unless they're also in ClientNames and the service isn't listed.
+	Services map[string]GRPCServiceConfig `json:"services"`
+	// FieldEncryption, if set, enables application-layer encryption of
+	// sensitive fields in SA requests and responses. Since responses are
+	// encrypted, every client must be configured with the same keys before
+	// this is enabled on the server.
+	FieldEncryption *FieldEncryptionConfig `json:"fieldEncryption"`
+}
+
+// GRPCServiceConfig contains the configuration particular to one of the
+// services of a gRPC server.
+type GRPCServiceConfig struct {
+	// ClientNames is a list of the client certificate SANs allowed to call
+	// the service.
+	ClientNames []string `json:"clientNames"`
+}
+
+// FieldEncryptionConfig configures the keyring used to encrypt sensitive
+// fields (account contacts and challenge validation records) of SA RPCs in
+// addition to TLS, for deployments where gRPC traffic crosses networks that
+// aren't trusted. Fields are encrypted with the active key and may be decrypted
+// with any key in the keyring, so keys are rotated by first adding the new key
+// everywhere and then making it active.
+type FieldEncryptionConfig struct {
+	// KeyFiles maps key IDs to files containing hex encoded 32 byte AES keys.
+	KeyFiles map[string]string `json:"keyFiles"`
+	// ActiveKeyID is the ID of the key used to encrypt fields.
+	ActiveKeyID string `json:"activeKeyID"`
 }
 
 // PortConfig specifies what ports the VA should call to on the remote
@@ -299,6 +603,8 @@ type CAADistributedResolverConfig struct {
 	Proxies     []string
 }
 
+// CTGroup is a set of CT logs, usually run by the same operator, of which
+// any one log's SCT is enough to satisfy the group.
 type CTGroup struct {
 	Name string
 	Logs []LogDescription
@@ -23,6 +23,11 @@ func TestDBConfigURL(t *testing.T) {
 			conf:     DBConfig{DBConnectFile: "testdata/test_dburl_newline"},
 			expected: "mysql+tcp://test@testhost:3306/testDB?readTimeout=800ms&writeTimeout=800ms",
 		},
+		{
+			// Test with a secret URI, which takes precedence over the file
+			conf:     DBConfig{DBConnectFile: "/dev/null", DBConnectSecret: "file:testdata/test_dburl_newline"},
+			expected: "mysql+tcp://test@testhost:3306/testDB?readTimeout=800ms&writeTimeout=800ms",
+		},
 	}
 
 	for _, tc := range tests {
@@ -41,6 +46,7 @@ func TestPasswordConfig(t *testing.T) {
 		{pc: PasswordConfig{Password: "config"}, expected: "config"},
 		{pc: PasswordConfig{Password: "config", PasswordFile: "testdata/test_secret"}, expected: "secret"},
 		{pc: PasswordConfig{PasswordFile: "testdata/test_secret"}, expected: "secret"},
+		{pc: PasswordConfig{PasswordFile: "/dev/null", PasswordSecret: "file:testdata/test_secret"}, expected: "secret"},
 	}
 
 	for _, tc := range tests {
@@ -98,3 +104,25 @@ func TestTLSConfigLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestDBConfigTLSConfig(t *testing.T) {
+	var d DBConfig
+	tlsConfig, err := d.TLSConfig()
+	test.AssertNotError(t, err, "TLSConfig failed without a DBTLS section")
+	test.Assert(t, tlsConfig == nil, "got a TLS config without a DBTLS section")
+
+	d.DBTLS = &DBTLSConfig{
+		CACertFile: "testdata/minica.pem",
+		CertFile:   "testdata/cert.pem",
+		KeyFile:    "testdata/key.pem",
+		ServerName: "boulder-mysql",
+	}
+	tlsConfig, err = d.TLSConfig()
+	test.AssertNotError(t, err, "TLSConfig failed")
+	test.AssertEquals(t, tlsConfig.ServerName, "boulder-mysql")
+	test.AssertEquals(t, len(tlsConfig.Certificates), 1)
+
+	d.DBTLS.CACertFile = "testdata/missing.pem"
+	_, err = d.TLSConfig()
+	test.AssertError(t, err, "TLSConfig didn't fail with a missing CA file")
+}
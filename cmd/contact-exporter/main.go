@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/features"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+// criteria select the registrations to export. Registrations must meet all
+// of the criteria that are set.
+type criteria struct {
+	// IssuedAfter and IssuedBefore select registrations that were issued a
+	// certificate in the range [IssuedAfter, IssuedBefore).
+	IssuedAfter  time.Time
+	IssuedBefore time.Time
+	// Names selects registrations holding a certificate, unexpired or
+	// expired within Grace, for at least one of the names.
+	Names []string
+	Grace time.Duration
+	// MinAccountAge and MaxAccountAge select registrations by how long ago
+	// they were created.
+	MinAccountAge time.Duration
+	MaxAccountAge time.Duration
+}
+
+// dbSelector is the part of gorp.DbMap used by the contact exporter.
+type dbSelector interface {
+	Select(holder interface{}, query string, args ...interface{}) ([]interface{}, error)
+}
+
+type contactExporter struct {
+	log   blog.Logger
+	dbMap dbSelector
+	clk   clock.Clock
+}
+
+// registration is a row selected by findContacts.
+type registration struct {
+	ID      int64
+	Contact []byte
+}
+
+// exportedContact is a registration and its email addresses.
+type exportedContact struct {
+	ID     int64
+	Emails []string
+}
+
+// query returns the SQL, and its arguments, selecting the valid registrations
+// with contacts that meet c.
+func (c criteria) query(now time.Time) (string, map[string]interface{}) {
+	conditions := []string{"contact != 'null'", "status = 'valid'"}
+	args := map[string]interface{}{}
+
+	if !c.IssuedAfter.IsZero() || !c.IssuedBefore.IsZero() {
+		var issued []string
+		if !c.IssuedAfter.IsZero() {
+			issued = append(issued, "issued >= :issuedAfter")
+			args["issuedAfter"] = c.IssuedAfter
+		}
+		if !c.IssuedBefore.IsZero() {
+			issued = append(issued, "issued < :issuedBefore")
+			args["issuedBefore"] = c.IssuedBefore
+		}
+		conditions = append(conditions, fmt.Sprintf(
+			"id IN (SELECT registrationID FROM certificates WHERE %s)",
+			strings.Join(issued, " AND ")))
+	}
+	if len(c.Names) > 0 {
+		var names []string
+		for i, name := range c.Names {
+			param := fmt.Sprintf("name%d", i)
+			names = append(names, ":"+param)
+			args[param] = sa.ReverseName(name)
+		}
+		conditions = append(conditions, fmt.Sprintf(
+			`id IN (SELECT registrationID FROM certificates
+				WHERE expires >= :expireCutoff AND serial IN (
+					SELECT serial FROM issuedNames WHERE reversedName IN (%s)))`,
+			strings.Join(names, ", ")))
+		args["expireCutoff"] = now.Add(-c.Grace)
+	}
+	if c.MinAccountAge > 0 {
+		conditions = append(conditions, "createdAt <= :createdBefore")
+		args["createdBefore"] = now.Add(-c.MinAccountAge)
+	}
+	if c.MaxAccountAge > 0 {
+		conditions = append(conditions, "createdAt >= :createdAfter")
+		args["createdAfter"] = now.Add(-c.MaxAccountAge)
+	}
+
+	query := fmt.Sprintf("SELECT id, contact FROM registrations WHERE %s ORDER BY id",
+		strings.Join(conditions, " AND\n\t"))
+	return query, args
+}
+
+// findContacts returns the registrations that meet crit and have at least
+// one email address.
+func (ce contactExporter) findContacts(crit criteria) ([]exportedContact, error) {
+	query, args := crit.query(ce.clk.Now())
+	var regs []registration
+	_, err := ce.dbMap.Select(&regs, query, args)
+	if err != nil {
+		ce.log.AuditErr(fmt.Sprintf("Error finding contacts: %s", err))
+		return nil, err
+	}
+
+	var contacts []exportedContact
+	for _, reg := range regs {
+		var entries []string
+		err := json.Unmarshal(reg.Contact, &entries)
+		if err != nil {
+			return nil, fmt.Errorf("parsing contact of registration %d: %s", reg.ID, err)
+		}
+		var emails []string
+		for _, entry := range entries {
+			if strings.HasPrefix(entry, "mailto:") {
+				emails = append(emails, strings.TrimPrefix(entry, "mailto:"))
+			}
+		}
+		if len(emails) > 0 {
+			contacts = append(contacts, exportedContact{ID: reg.ID, Emails: emails})
+		}
+	}
+	return contacts, nil
+}
+
+// writeJSON writes the registration IDs of contacts in the format of
+// notify-mailer's -toFile.
+func writeJSON(w io.Writer, contacts []exportedContact) error {
+	type id struct {
+		ID int64 `json:"id"`
+	}
+	ids := []id{}
+	for _, c := range contacts {
+		ids = append(ids, id{c.ID})
+	}
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// writeCSV writes a row for each email address of contacts, with an id and
+// email column. notify-mailer reads the id column of CSV recipient files.
+func writeCSV(w io.Writer, contacts []exportedContact) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"id", "email"})
+	if err != nil {
+		return err
+	}
+	for _, c := range contacts {
+		for _, email := range c.Emails {
+			err := cw.Write([]string{strconv.FormatInt(c.ID, 10), email})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// parseDate parses an RFC 3339 time or a date, which is taken to be
+// midnight UTC.
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+const usageIntro = `
+Introduction:
+
+The contact exporter selects the registrations to be sent a targeted
+notification and writes them in a format the notification mailer consumes.
+Only valid registrations with an email contact are exported. Registrations
+must meet every criteria given:
+
+  -issued-after, -issued-before   issued a certificate in the date range
+  -domains                        hold a certificate, unexpired or expired
+                                  within -grace, for a name in the file
+  -min-account-age,               created at least, or at most, this long
+  -max-account-age                ago
+
+With -format json (the default) the output is the registration IDs, in the
+form of the notification mailer's -toFile:
+
+  [
+   { "id": 1 },
+   ...
+   { "id": n }
+  ]
+
+With -format csv the output has a row for each email address, with an id and
+email column, for review before mailing. The notification mailer reads the id
+column of CSV recipient files, resolving the addresses again when it sends.
+
+Examples:
+  Export the registrations issued a certificate in March 2018 to "regs.json":
+
+  contact-exporter -config test/config/contact-exporter.json
+    -issued-after 2018-03-01 -issued-before 2018-04-01 -outfile regs.json
+
+  Export the registrations over a year old holding certificates for the names
+  in "domains.txt", as CSV:
+
+  contact-exporter -config test/config/contact-exporter.json
+    -domains domains.txt -min-account-age 8760h -format csv -outfile regs.csv
+
+Required arguments:
+- config
+- outfile`
+
+func main() {
+	outFile := flag.String("outfile", "", "File to write contacts to.")
+	format := flag.String("format", "json", "Output format, json or csv.")
+	issuedAfter := flag.String("issued-after", "", "Only export registrations issued a certificate at or after this date (YYYY-MM-DD or RFC 3339).")
+	issuedBefore := flag.String("issued-before", "", "Only export registrations issued a certificate before this date (YYYY-MM-DD or RFC 3339).")
+	domainsFile := flag.String("domains", "", "Only export registrations holding certificates for at least one of the domains in the file, one per line.")
+	grace := flag.Duration("grace", 2*24*time.Hour, "With -domains, include certificates that expired in < grace ago")
+	minAge := flag.Duration("min-account-age", 0, "Only export registrations created at least this long ago.")
+	maxAge := flag.Duration("max-account-age", 0, "Only export registrations created at most this long ago.")
+	type config struct {
+		ContactExporter struct {
+			cmd.DBConfig
+			cmd.PasswordConfig
+			Features map[string]bool
+		}
+	}
+	configFile := flag.String("config", "", "File containing a JSON config.")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageIntro)
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	if *outFile == "" || *configFile == "" || (*format != "json" && *format != "csv") {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	log := cmd.NewLogger(cmd.SyslogConfig{StdoutLevel: 7})
+
+	crit := criteria{
+		Grace:         *grace,
+		MinAccountAge: *minAge,
+		MaxAccountAge: *maxAge,
+	}
+	var err error
+	if *issuedAfter != "" {
+		crit.IssuedAfter, err = parseDate(*issuedAfter)
+		cmd.FailOnError(err, "Parsing -issued-after")
+	}
+	if *issuedBefore != "" {
+		crit.IssuedBefore, err = parseDate(*issuedBefore)
+		cmd.FailOnError(err, "Parsing -issued-before")
+	}
+	if *domainsFile != "" {
+		df, err := ioutil.ReadFile(*domainsFile)
+		cmd.FailOnError(err, fmt.Sprintf("Could not read domains file %q", *domainsFile))
+		for _, line := range strings.Split(string(df), "\n") {
+			if name := strings.TrimSpace(line); name != "" {
+				crit.Names = append(crit.Names, name)
+			}
+		}
+	}
+
+	configData, err := ioutil.ReadFile(*configFile)
+	cmd.FailOnError(err, fmt.Sprintf("Reading %q", *configFile))
+	var cfg config
+	err = cmd.ParseConfig(configData, &cfg)
+	cmd.FailOnError(err, "Unmarshaling config")
+	err = features.Set(cfg.ContactExporter.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	dbURL, err := cfg.ContactExporter.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbMap, err := sa.NewDbMap(dbURL, 10)
+	cmd.FailOnError(err, "Could not connect to database")
+
+	exporter := contactExporter{
+		log:   log,
+		dbMap: dbMap,
+		clk:   cmd.Clock(),
+	}
+	contacts, err := exporter.findContacts(crit)
+	cmd.FailOnError(err, "Could not find contacts")
+
+	f, err := os.Create(*outFile)
+	cmd.FailOnError(err, fmt.Sprintf("Could not create outfile %q", *outFile))
+	if *format == "csv" {
+		err = writeCSV(f, contacts)
+	} else {
+		err = writeJSON(f, contacts)
+	}
+	cmd.FailOnError(err, fmt.Sprintf("Could not write contacts to outfile %q", *outFile))
+	err = f.Close()
+	cmd.FailOnError(err, fmt.Sprintf("Could not write contacts to outfile %q", *outFile))
+	log.Info(fmt.Sprintf("Exported %d registrations to %q", len(contacts), *outFile))
+}
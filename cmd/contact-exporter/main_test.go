@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestQuery(t *testing.T) {
+	now := time.Date(2018, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	query, args := criteria{}.query(now)
+	test.AssertEquals(t, query, "SELECT id, contact FROM registrations WHERE contact != 'null' AND\n\tstatus = 'valid' ORDER BY id")
+	test.AssertEquals(t, len(args), 0)
+
+	query, args = criteria{
+		IssuedAfter:   time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+		Names:         []string{"example.com", "www.example.net"},
+		Grace:         time.Hour,
+		MinAccountAge: 24 * time.Hour,
+	}.query(now)
+	test.Assert(t, strings.Contains(query, "id IN (SELECT registrationID FROM certificates WHERE issued >= :issuedAfter)"), query)
+	test.Assert(t, !strings.Contains(query, "issuedBefore"), query)
+	test.Assert(t, strings.Contains(query, "reversedName IN (:name0, :name1)"), query)
+	test.Assert(t, strings.Contains(query, "createdAt <= :createdBefore"), query)
+	test.Assert(t, !strings.Contains(query, "createdAfter"), query)
+	test.AssertDeepEquals(t, args, map[string]interface{}{
+		"issuedAfter":   time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+		"name0":         "com.example",
+		"name1":         "net.example.www",
+		"expireCutoff":  now.Add(-time.Hour),
+		"createdBefore": now.Add(-24 * time.Hour),
+	})
+}
+
+type mockDB struct {
+	regs  []registration
+	query string
+}
+
+func (db *mockDB) Select(holder interface{}, query string, _ ...interface{}) ([]interface{}, error) {
+	regs, ok := holder.(*[]registration)
+	if !ok {
+		return nil, fmt.Errorf("incorrect output type %T", holder)
+	}
+	db.query = query
+	*regs = append(*regs, db.regs...)
+	return nil, nil
+}
+
+func TestFindContacts(t *testing.T) {
+	db := &mockDB{regs: []registration{
+		{ID: 1, Contact: []byte(`["mailto:one@example.com"]`)},
+		{ID: 2, Contact: []byte(`["tel:666-666-7777"]`)},
+		{ID: 3, Contact: []byte(`["mailto:three@example.com","tel:666-666-7777","mailto:three@example.net"]`)},
+	}}
+	ce := contactExporter{log: blog.NewMock(), dbMap: db, clk: clock.NewFake()}
+	contacts, err := ce.findContacts(criteria{MaxAccountAge: time.Hour})
+	test.AssertNotError(t, err, "findContacts failed")
+	test.Assert(t, strings.Contains(db.query, "createdAt >= :createdAfter"), db.query)
+	test.AssertDeepEquals(t, contacts, []exportedContact{
+		{ID: 1, Emails: []string{"one@example.com"}},
+		{ID: 3, Emails: []string{"three@example.com", "three@example.net"}},
+	})
+
+	db.regs = []registration{{ID: 4, Contact: []byte(`mailto:`)}}
+	_, err = ce.findContacts(criteria{})
+	test.AssertError(t, err, "findContacts didn't fail on a malformed contact")
+}
+
+func TestWrite(t *testing.T) {
+	contacts := []exportedContact{
+		{ID: 1, Emails: []string{"one@example.com"}},
+		{ID: 3, Emails: []string{"three@example.com", "three@example.net"}},
+	}
+
+	var buf bytes.Buffer
+	err := writeJSON(&buf, contacts)
+	test.AssertNotError(t, err, "writeJSON failed")
+	test.AssertEquals(t, buf.String(), `[{"id":1},{"id":3}]`+"\n")
+
+	buf.Reset()
+	err = writeJSON(&buf, nil)
+	test.AssertNotError(t, err, "writeJSON failed")
+	test.AssertEquals(t, buf.String(), "[]\n")
+
+	buf.Reset()
+	err = writeCSV(&buf, contacts)
+	test.AssertNotError(t, err, "writeCSV failed")
+	test.AssertEquals(t, buf.String(), "id,email\n1,one@example.com\n3,three@example.com\n3,three@example.net\n")
+}
+
+func TestParseDate(t *testing.T) {
+	d, err := parseDate("2018-03-01")
+	test.AssertNotError(t, err, "parsing date")
+	test.AssertEquals(t, d, time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC))
+	d, err = parseDate("2018-03-01T12:00:00Z")
+	test.AssertNotError(t, err, "parsing RFC 3339 time")
+	test.AssertEquals(t, d, time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC))
+	_, err = parseDate("March 1st")
+	test.AssertError(t, err, "parsed an invalid date")
+}
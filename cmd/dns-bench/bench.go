@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/bdns"
+)
+
+// Query types in the mix. Host lookups are made with LookupHost, which sends
+// A and AAAA queries in parallel, the way the VA looks up validation targets.
+const (
+	queryHost = "A/AAAA"
+	queryTXT  = "TXT"
+	queryCAA  = "CAA"
+)
+
+var queryTypes = []string{queryHost, queryTXT, queryCAA}
+
+// classOK is the error class of a successful lookup.
+const classOK = "ok"
+
+type query struct {
+	qtype string
+	name  string
+}
+
+// buildQueries returns n queries for names chosen at random, with query types
+// picked according to the weights in mix. TXT queries are made for the
+// _acme-challenge label of the name, as they would be for a DNS-01 challenge.
+func buildQueries(r *rand.Rand, names []string, mix map[string]int, n int) ([]query, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no names to query")
+	}
+	total := 0
+	for qtype, weight := range mix {
+		if !validQueryType(qtype) {
+			return nil, fmt.Errorf("unknown query type %q in mix, must be one of %s", qtype, strings.Join(queryTypes, ", "))
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("negative weight for query type %q", qtype)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return nil, errors.New("query mix has no positive weights")
+	}
+
+	queries := make([]query, n)
+	for i := range queries {
+		pick := r.Intn(total)
+		// Iterate in a fixed order so that the result only depends on r.
+		for _, qtype := range queryTypes {
+			if pick < mix[qtype] {
+				queries[i].qtype = qtype
+				break
+			}
+			pick -= mix[qtype]
+		}
+		name := names[r.Intn(len(names))]
+		if queries[i].qtype == queryTXT {
+			name = "_acme-challenge." + name
+		}
+		queries[i].name = name
+	}
+	return queries, nil
+}
+
+func validQueryType(qtype string) bool {
+	for _, t := range queryTypes {
+		if t == qtype {
+			return true
+		}
+	}
+	return false
+}
+
+// doQuery performs q with client and returns the number of records found.
+func doQuery(ctx context.Context, client bdns.DNSClient, q query) (int, error) {
+	switch q.qtype {
+	case queryHost:
+		addrs, err := client.LookupHost(ctx, q.name)
+		return len(addrs), err
+	case queryTXT:
+		txts, _, err := client.LookupTXT(ctx, q.name)
+		return len(txts), err
+	case queryCAA:
+		caas, err := client.LookupCAA(ctx, q.name)
+		return len(caas), err
+	}
+	return 0, fmt.Errorf("unknown query type %q", q.qtype)
+}
+
+// classifyError returns the class of a lookup error, using the same
+// descriptions that are shown to subscribers in validation problems.
+func classifyError(err error) string {
+	if err == nil {
+		return classOK
+	}
+	if dnsErr, ok := err.(*bdns.DNSError); ok {
+		return dnsErr.Detail()
+	}
+	return "other"
+}
+
+type result struct {
+	qtype   string
+	latency time.Duration
+	class   string
+}
+
+// runBench sends queries to client using concurrency workers and returns the
+// latency and error class of each.
+func runBench(ctx context.Context, client bdns.DNSClient, clk clock.Clock, queries []query, concurrency int) []result {
+	results := make([]result, len(queries))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				start := clk.Now()
+				_, err := doQuery(ctx, client, queries[i])
+				results[i] = result{
+					qtype:   queries[i].qtype,
+					latency: clk.Since(start),
+					class:   classifyError(err),
+				}
+			}
+		}()
+	}
+	for i := range queries {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
+
+// summary describes the results of all queries of a single type.
+type summary struct {
+	count     int
+	latencies []time.Duration
+	classes   map[string]int
+}
+
+// percentile returns the latency below which p percent of the queries
+// completed, using the nearest-rank method.
+func (s *summary) percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(s.latencies)) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(s.latencies) {
+		rank = len(s.latencies)
+	}
+	return s.latencies[rank-1]
+}
+
+func (s *summary) errors() int {
+	return s.count - s.classes[classOK]
+}
+
+// summarize groups results by query type, with the latencies of each sorted in
+// ascending order.
+func summarize(results []result) map[string]*summary {
+	summaries := make(map[string]*summary)
+	for _, r := range results {
+		s, present := summaries[r.qtype]
+		if !present {
+			s = &summary{classes: make(map[string]int)}
+			summaries[r.qtype] = s
+		}
+		s.count++
+		s.latencies = append(s.latencies, r.latency)
+		s.classes[r.class]++
+	}
+	for _, s := range summaries {
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	}
+	return summaries
+}
+
+// report formats the summaries for a single resolver as a table, followed by
+// the error classes seen for each query type.
+func report(summaries map[string]*summary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "  %-8s %8s %8s %10s %10s %10s %10s\n", "type", "queries", "errors", "p50", "p90", "p99", "max")
+	for _, qtype := range queryTypes {
+		s, present := summaries[qtype]
+		if !present {
+			continue
+		}
+		fmt.Fprintf(&b, "  %-8s %8d %8d %10s %10s %10s %10s\n", qtype, s.count, s.errors(),
+			s.percentile(50), s.percentile(90), s.percentile(99), s.percentile(100))
+	}
+	for _, qtype := range queryTypes {
+		s, present := summaries[qtype]
+		if !present || s.errors() == 0 {
+			continue
+		}
+		var classes []string
+		for class := range s.classes {
+			if class != classOK {
+				classes = append(classes, class)
+			}
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			fmt.Fprintf(&b, "  %s errors: %s: %d\n", qtype, class, s.classes[class])
+		}
+	}
+	return b.String()
+}
+
+// conformanceCheck describes a lookup with a known answer, used to check that
+// a resolver behaves the way the VA expects before validation traffic is sent
+// to it.
+type conformanceCheck struct {
+	// Name is the name to look up.
+	Name string
+	// Type is the query type, one of "A/AAAA", "TXT" or "CAA".
+	Type string
+	// Expect is the expected outcome, one of "records" (at least one record is
+	// returned), "empty" (the lookup succeeds with no records), or an error
+	// class such as "NXDOMAIN", "SERVFAIL" or "query timed out".
+	Expect string
+}
+
+func (c conformanceCheck) validate() error {
+	if c.Name == "" {
+		return errors.New("conformance check is missing a name")
+	}
+	if !validQueryType(c.Type) {
+		return fmt.Errorf("conformance check for %q has unknown type %q", c.Name, c.Type)
+	}
+	if c.Expect == "" {
+		return fmt.Errorf("conformance check for %q is missing an expectation", c.Name)
+	}
+	return nil
+}
+
+// run performs the check against client, returning an error describing the
+// mismatch if the outcome isn't the expected one.
+func (c conformanceCheck) run(ctx context.Context, client bdns.DNSClient) error {
+	n, err := doQuery(ctx, client, query{qtype: c.Type, name: c.Name})
+	var got string
+	switch {
+	case err != nil:
+		got = classifyError(err)
+	case n == 0:
+		got = "empty"
+	default:
+		got = "records"
+	}
+	if got != c.Expect {
+		if err != nil {
+			return fmt.Errorf("%s lookup for %s: expected %s, got %s (%s)", c.Type, c.Name, c.Expect, got, err)
+		}
+		return fmt.Errorf("%s lookup for %s: expected %s, got %s", c.Type, c.Name, c.Expect, got)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// fakeClient is a bdns.DNSClient whose lookups take a fixed amount of time on
+// a fake clock and fail for names in errs.
+type fakeClient struct {
+	clk     clock.FakeClock
+	latency map[string]time.Duration
+	errs    map[string]error
+}
+
+func (fc *fakeClient) lookup(qtype, name string) error {
+	fc.clk.Add(fc.latency[qtype])
+	return fc.errs[name]
+}
+
+func (fc *fakeClient) LookupTXT(_ context.Context, name string) ([]string, []string, error) {
+	if err := fc.lookup(queryTXT, name); err != nil {
+		return nil, nil, err
+	}
+	return []string{"txt"}, nil, nil
+}
+
+func (fc *fakeClient) LookupHost(_ context.Context, name string) ([]net.IP, error) {
+	if err := fc.lookup(queryHost, name); err != nil {
+		return nil, err
+	}
+	return []net.IP{net.ParseIP("192.0.2.1")}, nil
+}
+
+func (fc *fakeClient) LookupCAA(_ context.Context, name string) ([]*dns.CAA, error) {
+	if err := fc.lookup(queryCAA, name); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (fc *fakeClient) LookupMX(_ context.Context, name string) ([]string, error) {
+	return nil, nil
+}
+
+func TestBuildQueries(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	queries, err := buildQueries(r, []string{"example.com"}, map[string]int{queryHost: 3, queryTXT: 1}, 1000)
+	test.AssertNotError(t, err, "buildQueries failed")
+	test.AssertEquals(t, len(queries), 1000)
+
+	counts := make(map[string]int)
+	for _, q := range queries {
+		counts[q.qtype]++
+		if q.qtype == queryTXT {
+			test.AssertEquals(t, q.name, "_acme-challenge.example.com")
+		} else {
+			test.AssertEquals(t, q.name, "example.com")
+		}
+	}
+	test.AssertEquals(t, counts[queryCAA], 0)
+	test.Assert(t, counts[queryHost] > 2*counts[queryTXT], "query mix wasn't weighted")
+
+	_, err = buildQueries(r, []string{"example.com"}, map[string]int{"MX": 1}, 10)
+	test.AssertError(t, err, "buildQueries accepted an unknown query type")
+	_, err = buildQueries(r, []string{"example.com"}, map[string]int{queryHost: 0}, 10)
+	test.AssertError(t, err, "buildQueries accepted a mix without weights")
+	_, err = buildQueries(r, nil, map[string]int{queryHost: 1}, 10)
+	test.AssertError(t, err, "buildQueries accepted no names")
+}
+
+func TestRunBench(t *testing.T) {
+	clk := clock.NewFake()
+	client := &fakeClient{
+		clk:     clk,
+		latency: map[string]time.Duration{queryHost: 10 * time.Millisecond, queryCAA: 30 * time.Millisecond},
+		errs:    map[string]error{"broken.com": errors.New("broken")},
+	}
+	queries := []query{
+		{queryHost, "example.com"},
+		{queryHost, "example.com"},
+		{queryHost, "broken.com"},
+		{queryCAA, "example.com"},
+	}
+	summaries := summarize(runBench(context.Background(), client, clk, queries, 1))
+
+	host := summaries[queryHost]
+	test.AssertEquals(t, host.count, 3)
+	test.AssertEquals(t, host.errors(), 1)
+	test.AssertEquals(t, host.classes["other"], 1)
+	test.AssertEquals(t, host.percentile(50), 10*time.Millisecond)
+	test.AssertEquals(t, host.percentile(100), 10*time.Millisecond)
+	test.AssertEquals(t, summaries[queryCAA].percentile(99), 30*time.Millisecond)
+	_, present := summaries[queryTXT]
+	test.Assert(t, !present, "summary for a query type that wasn't sent")
+
+	out := report(summaries)
+	test.Assert(t, strings.Contains(out, "A/AAAA errors: other: 1"), "report is missing error classes")
+}
+
+func TestPercentile(t *testing.T) {
+	s := &summary{}
+	test.AssertEquals(t, s.percentile(50), time.Duration(0))
+	for i := 1; i <= 100; i++ {
+		s.latencies = append(s.latencies, time.Duration(i))
+	}
+	test.AssertEquals(t, s.percentile(50), time.Duration(50))
+	test.AssertEquals(t, s.percentile(99), time.Duration(99))
+	test.AssertEquals(t, s.percentile(100), time.Duration(100))
+	test.AssertEquals(t, s.percentile(0), time.Duration(1))
+}
+
+func TestConformanceCheck(t *testing.T) {
+	client := &fakeClient{
+		clk:  clock.NewFake(),
+		errs: map[string]error{"broken.com": errors.New("broken")},
+	}
+	ctx := context.Background()
+
+	test.AssertNotError(t, conformanceCheck{"example.com", queryHost, "records"}.run(ctx, client), "check failed")
+	test.AssertNotError(t, conformanceCheck{"example.com", queryCAA, "empty"}.run(ctx, client), "check failed")
+	test.AssertNotError(t, conformanceCheck{"broken.com", queryTXT, "other"}.run(ctx, client), "check failed")
+
+	err := conformanceCheck{"broken.com", queryHost, "records"}.run(ctx, client)
+	test.AssertError(t, err, "check passed with an unexpected error")
+	test.AssertEquals(t, err.Error(), "A/AAAA lookup for broken.com: expected records, got other (broken)")
+
+	test.AssertError(t, conformanceCheck{"example.com", "MX", "records"}.validate(), "accepted an unknown query type")
+	test.AssertError(t, conformanceCheck{"example.com", queryCAA, ""}.validate(), "accepted a missing expectation")
+}
@@ -0,0 +1,148 @@
+// dns-bench benchmarks and checks the conformance of DNS resolvers using the
+// same bdns client the VA uses. It is meant to qualify new resolver
+// infrastructure before validation traffic is pointed at it.
+//
+// Each configured resolver is benchmarked on its own with a mix of host
+// (A/AAAA), TXT and CAA lookups, and the latency distribution and error
+// classes seen for each query type are reported. Conformance checks, lookups
+// with a known expected outcome, are then run against each resolver. The tool
+// exits non-zero if any conformance check fails.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/bdns"
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+type config struct {
+	DNSBench struct {
+		// DNSResolvers is the list of resolvers to benchmark, as host:port.
+		// Each resolver is benchmarked separately.
+		DNSResolvers []string
+		// DNSTimeout is the read timeout of each query.
+		DNSTimeout cmd.ConfigDuration
+		// DNSTries is the number of times to try a query that has a temporary
+		// error, as in the VA config. A zero value will be turned into 1.
+		DNSTries int
+		// DNSAllowLoopbackAddresses allows loopback and private addresses to be
+		// returned by host lookups, for use with the integration test DNS server.
+		DNSAllowLoopbackAddresses bool
+
+		// Queries is the number of lookups to send to each resolver.
+		Queries int
+		// Concurrency is the number of lookups in flight at once.
+		Concurrency int
+		// Names is the list of names to look up. It should be representative
+		// of the names validation requests are made for.
+		Names []string
+		// Mix gives the relative weight of each query type, "A/AAAA", "TXT"
+		// and "CAA". Defaults to an equal mix of all three.
+		Mix map[string]int
+
+		// ConformanceChecks are lookups with known outcomes that each resolver
+		// must produce.
+		ConformanceChecks []conformanceCheck
+	}
+}
+
+func (c config) validate() error {
+	dc := c.DNSBench
+	if len(dc.DNSResolvers) == 0 {
+		return fmt.Errorf("at least one resolver must be configured in DNSResolvers")
+	}
+	if dc.DNSTimeout.Duration <= 0 {
+		return fmt.Errorf("DNSTimeout must be positive")
+	}
+	if dc.Queries < 0 || dc.Concurrency < 0 {
+		return fmt.Errorf("Queries and Concurrency can't be negative")
+	}
+	if dc.Queries > 0 && len(dc.Names) == 0 {
+		return fmt.Errorf("Names must not be empty when Queries is set")
+	}
+	for _, check := range dc.ConformanceChecks {
+		if err := check.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	seed := flag.Int64("seed", 0, "Seed used to pick queries, defaults to the current time")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	cmd.FailOnError(c.validate(), "Invalid config")
+	dc := c.DNSBench
+
+	if dc.DNSTries < 1 {
+		dc.DNSTries = 1
+	}
+	if dc.Concurrency < 1 {
+		dc.Concurrency = 1
+	}
+	if len(dc.Mix) == 0 {
+		dc.Mix = map[string]int{queryHost: 1, queryTXT: 1, queryCAA: 1}
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	var queries []query
+	if dc.Queries > 0 {
+		queries, err = buildQueries(rand.New(rand.NewSource(*seed)), dc.Names, dc.Mix, dc.Queries)
+		cmd.FailOnError(err, "Failed to build queries")
+		fmt.Printf("sending %d queries to each resolver, seed %d\n", len(queries), *seed)
+	}
+
+	clk := clock.Default()
+	ctx := context.Background()
+	failed := false
+	for _, resolver := range dc.DNSResolvers {
+		// Metrics aren't exported, the results are reported directly.
+		var client *bdns.DNSClientImpl
+		if dc.DNSAllowLoopbackAddresses {
+			client = bdns.NewTestDNSClientImpl(dc.DNSTimeout.Duration, []string{resolver}, metrics.NewNoopScope(), clk, dc.DNSTries)
+		} else {
+			client = bdns.NewDNSClientImpl(dc.DNSTimeout.Duration, []string{resolver}, metrics.NewNoopScope(), clk, dc.DNSTries)
+		}
+		fmt.Printf("resolver %s\n", resolver)
+
+		if len(queries) > 0 {
+			start := clk.Now()
+			results := runBench(ctx, client, clk, queries, dc.Concurrency)
+			elapsed := clk.Since(start)
+			fmt.Print(report(summarize(results)))
+			fmt.Printf("  %d queries in %s (%.1f queries/s)\n", len(results), elapsed, float64(len(results))/elapsed.Seconds())
+		}
+
+		for _, check := range dc.ConformanceChecks {
+			if err := check.run(ctx, client); err != nil {
+				fmt.Printf("  FAIL %s\n", err)
+				failed = true
+				continue
+			}
+			fmt.Printf("  PASS %s lookup for %s: %s\n", check.Type, check.Name, check.Expect)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
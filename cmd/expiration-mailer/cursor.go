@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// position is a point in the order certificates are processed in, which is
+// by notAfter and then serial.
+type position struct {
+	NotAfter time.Time `json:"notAfter"`
+	Serial   string    `json:"serial"`
+}
+
+// cursor records the last certificate processed in each nag group, so that a
+// run which is stopped by its time budget, or which can only process its cert
+// limit, is followed by one that carries on from there rather than going
+// through the same early certificates again. Once a nag group has been
+// processed to the end its position is cleared and the next run starts from
+// the beginning. A nil cursor records nothing.
+type cursor struct {
+	filename  string
+	positions map[string]position
+}
+
+// loadCursor reads a cursor file. It isn't an error for the file not to
+// exist yet.
+func loadCursor(filename string) (*cursor, error) {
+	c := &cursor{filename: filename, positions: make(map[string]position)}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &c.positions)
+	if err != nil {
+		return nil, fmt.Errorf("parsing cursor file %q: %s", filename, err)
+	}
+	return c, nil
+}
+
+// get returns the position reached in a nag group, and false if the group
+// should be processed from the beginning.
+func (c *cursor) get(nagGroup string) (position, bool) {
+	if c == nil {
+		return position{}, false
+	}
+	pos, ok := c.positions[nagGroup]
+	return pos, ok
+}
+
+// set records the position reached in a nag group.
+func (c *cursor) set(nagGroup string, pos position) error {
+	if c == nil {
+		return nil
+	}
+	c.positions[nagGroup] = pos
+	return c.write()
+}
+
+// clear forgets the position reached in a nag group.
+func (c *cursor) clear(nagGroup string) error {
+	if c == nil {
+		return nil
+	}
+	if _, ok := c.positions[nagGroup]; !ok {
+		return nil
+	}
+	delete(c.positions, nagGroup)
+	return c.write()
+}
+
+// write saves the positions by renaming a temporary file, so that the file is
+// never left half written.
+func (c *cursor) write() error {
+	data, err := json.Marshal(c.positions)
+	if err != nil {
+		return err
+	}
+	tmp := c.filename + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.filename)
+}
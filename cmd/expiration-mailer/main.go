@@ -53,6 +53,16 @@ type mailer struct {
 	limit           int
 	clk             clock.Clock
 	stats           mailerStats
+	// verifiedOnly restricts nags to the email contacts that have been
+	// verified through the WFE's contact verification links.
+	verifiedOnly bool
+	// maxRuntime, if non-zero, is the time budget of each run, after which
+	// no more registrations are processed. deadline is when the current run's
+	// budget runs out.
+	maxRuntime time.Duration
+	deadline   time.Time
+	// cursor records how far each nag group has been processed.
+	cursor *cursor
 }
 
 type mailerStats struct {
@@ -174,13 +184,50 @@ func (m *mailer) certIsRenewed(serial string) (renewed bool, err error) {
 	return present == 1, err
 }
 
-func (m *mailer) processCerts(allCerts []core.Certificate) {
+// verifiedContacts returns the contacts of the registration that are email
+// addresses it has verified.
+func (m *mailer) verifiedContacts(regID int64, contacts []string) ([]string, error) {
+	var emails []string
+	_, err := m.dbMap.Select(&emails,
+		"SELECT email FROM verifiedContacts WHERE registrationID = ?", regID)
+	if err != nil {
+		return nil, err
+	}
+	verified := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		verified["mailto:"+email] = true
+	}
+	var result []string
+	for _, contact := range contacts {
+		if verified[contact] {
+			result = append(result, contact)
+		}
+	}
+	return result, nil
+}
+
+// pastDeadline returns true if the current run's time budget has run out.
+func (m *mailer) pastDeadline() bool {
+	return !m.deadline.IsZero() && !m.clk.Now().Before(m.deadline)
+}
+
+// processCerts sends nags for allCerts a registration at a time, taking the
+// registrations in the order of their first certificate. If the time budget
+// runs out part way through it stops, and returns the number of certificates
+// before the first one that wasn't processed.
+func (m *mailer) processCerts(allCerts []core.Certificate) int {
 	ctx := context.Background()
 
 	regIDToCerts := make(map[int64][]core.Certificate)
-
-	for _, cert := range allCerts {
-		cs := regIDToCerts[cert.RegistrationID]
+	var regIDs []int64
+	firstCert := make(map[int64]int)
+
+	for i, cert := range allCerts {
+		cs, ok := regIDToCerts[cert.RegistrationID]
+		if !ok {
+			regIDs = append(regIDs, cert.RegistrationID)
+			firstCert[cert.RegistrationID] = i
+		}
 		cs = append(cs, cert)
 		regIDToCerts[cert.RegistrationID] = cs
 	}
@@ -188,13 +235,17 @@ func (m *mailer) processCerts(allCerts []core.Certificate) {
 	err := m.mailer.Connect()
 	if err != nil {
 		m.log.AuditErr(fmt.Sprintf("Error connecting to send nag emails: %s", err))
-		return
+		return 0
 	}
 	defer func() {
 		_ = m.mailer.Close()
 	}()
 
-	for regID, certs := range regIDToCerts {
+	for _, regID := range regIDs {
+		if m.pastDeadline() {
+			return firstCert[regID]
+		}
+		certs := regIDToCerts[regID]
 		reg, err := m.rs.GetRegistration(ctx, regID)
 		if err != nil {
 			m.log.AuditErr(fmt.Sprintf("Error fetching registration %d: %s", regID, err))
@@ -236,8 +287,17 @@ func (m *mailer) processCerts(allCerts []core.Certificate) {
 		if reg.Contact == nil {
 			continue
 		}
+		contacts := *reg.Contact
+		if m.verifiedOnly {
+			contacts, err = m.verifiedContacts(regID, contacts)
+			if err != nil {
+				m.log.AuditErr(fmt.Sprintf("Error fetching verified contacts of registration %d: %s", regID, err))
+				m.stats.errorCount.With(prometheus.Labels{"type": "VerifiedContacts"}).Inc()
+				continue
+			}
+		}
 
-		err = m.sendNags(*reg.Contact, parsedCerts)
+		err = m.sendNags(contacts, parsedCerts)
 		if err != nil {
 			m.stats.errorCount.With(prometheus.Labels{"type": "SendNags"}).Inc()
 			m.log.AuditErr(fmt.Sprintf("Error sending nag emails: %s", err))
@@ -253,19 +313,40 @@ func (m *mailer) processCerts(allCerts []core.Certificate) {
 			}
 		}
 	}
-	return
+	return len(allCerts)
 }
 
 func (m *mailer) findExpiringCertificates() error {
 	now := m.clk.Now()
+	m.deadline = time.Time{}
+	if m.maxRuntime > 0 {
+		m.deadline = now.Add(m.maxRuntime)
+	}
 	// E.g. m.nagTimes = [2, 4, 8, 15] days from expiration
 	for i, expiresIn := range m.nagTimes {
+		nagGroup := expiresIn.String()
+		if m.pastDeadline() {
+			m.log.Info(fmt.Sprintf("expiration-mailer: Time budget of %s used up, skipping nag group %s",
+				m.maxRuntime, nagGroup))
+			continue
+		}
+
 		left := now
 		if i > 0 {
 			left = left.Add(m.nagTimes[i-1])
 		}
 		right := now.Add(expiresIn)
 
+		// Carry on from the last certificate processed in this nag group, if
+		// an earlier run didn't get to the end of it.
+		from, resuming := m.cursor.get(nagGroup)
+		if !resuming {
+			from = position{NotAfter: left}
+		} else {
+			m.log.Info(fmt.Sprintf("expiration-mailer: Resuming nag group %s after certificate %s expiring %s",
+				nagGroup, from.Serial, from.NotAfter.UTC()))
+		}
+
 		m.log.Info(fmt.Sprintf("expiration-mailer: Searching for certificates that expire between %s and %s and had last nag >%s before expiry",
 			left.UTC(), right.UTC(), expiresIn))
 
@@ -273,23 +354,27 @@ func (m *mailer) findExpiringCertificates() error {
 		// nearing expiry meeting our criteria for email notification. We later
 		// sequentially fetch the certificate details. This avoids an expensive
 		// JOIN.
-		var serials []string
+		var positions []position
 		_, err := m.dbMap.Select(
-			&serials,
+			&positions,
 			`SELECT
-				cs.serial
+				cs.serial AS Serial,
+				cs.notAfter AS NotAfter
 				FROM certificateStatus AS cs
 				WHERE cs.notAfter > :cutoffA
 				AND cs.notAfter <= :cutoffB
+				AND (cs.notAfter > :fromNotAfter OR (cs.notAfter = :fromNotAfter AND cs.serial > :fromSerial))
 				AND cs.status != "revoked"
 				AND COALESCE(TIMESTAMPDIFF(SECOND, cs.lastExpirationNagSent, cs.notAfter) > :nagCutoff, 1)
-				ORDER BY cs.notAfter ASC
+				ORDER BY cs.notAfter ASC, cs.serial ASC
 				LIMIT :limit`,
 			map[string]interface{}{
-				"cutoffA":   left,
-				"cutoffB":   right,
-				"nagCutoff": expiresIn.Seconds(),
-				"limit":     m.limit,
+				"cutoffA":      left,
+				"cutoffB":      right,
+				"fromNotAfter": from.NotAfter,
+				"fromSerial":   from.Serial,
+				"nagCutoff":    expiresIn.Seconds(),
+				"limit":        m.limit,
 			},
 		)
 		if err != nil {
@@ -300,9 +385,9 @@ func (m *mailer) findExpiringCertificates() error {
 		// Now we can sequentially retrieve the certificate details for each of the
 		// certificate status rows
 		var certs []core.Certificate
-		for _, serial := range serials {
+		for _, pos := range positions {
 			var cert core.Certificate
-			cert, err := sa.SelectCertificate(m.dbMap, "WHERE serial = ?", serial)
+			cert, err := sa.SelectCertificate(m.dbMap, "WHERE serial = ?", pos.Serial)
 			if err != nil {
 				m.log.AuditErr(fmt.Sprintf("expiration-mailer: Error loading cert %q: %s", cert.Serial, err))
 				return err
@@ -314,7 +399,11 @@ func (m *mailer) findExpiringCertificates() error {
 			left.Format("2006-01-02 03:04"), right.Format("2006-01-02 03:04")))
 
 		if len(certs) == 0 {
-			continue // nothing to do
+			// The end of the nag group has been reached
+			if err := m.cursor.clear(nagGroup); err != nil {
+				return fmt.Errorf("writing cursor: %s", err)
+			}
+			continue
 		}
 
 		// If the `certs` result was exactly `m.limit` rows we need to increment
@@ -333,10 +422,23 @@ func (m *mailer) findExpiringCertificates() error {
 		}
 
 		processingStarted := m.clk.Now()
-		m.processCerts(certs)
+		processed := m.processCerts(certs)
 		processingEnded := m.clk.Now()
 		elapsed := processingEnded.Sub(processingStarted)
 		m.stats.processingLatency.Observe(elapsed.Seconds())
+
+		if processed < len(certs) {
+			m.log.Info(fmt.Sprintf("expiration-mailer: Time budget of %s used up after %d of %d certificates in nag group %s",
+				m.maxRuntime, processed, len(certs), nagGroup))
+		}
+		if processed == len(certs) && len(certs) < m.limit {
+			err = m.cursor.clear(nagGroup)
+		} else if processed > 0 {
+			err = m.cursor.set(nagGroup, positions[processed-1])
+		}
+		if err != nil {
+			return fmt.Errorf("writing cursor: %s", err)
+		}
 	}
 
 	return nil
@@ -373,9 +475,22 @@ type config struct {
 		NagCheckInterval string
 		// Path to a text/template email template
 		EmailTemplate string
+		// VerifiedContactsOnly skips email contacts that haven't been
+		// verified through the WFE's contact verification links. Accounts
+		// only get verification links from WFEs with ContactVerification
+		// configured.
+		VerifiedContactsOnly bool
 
 		Frequency cmd.ConfigDuration
 
+		// MaxRuntime, if set, is the time budget of each run. Once it is
+		// used up no more registrations are sent nags until the next run.
+		MaxRuntime cmd.ConfigDuration
+		// CursorFile, if set, records the last certificate processed in each
+		// nag group, so that the next run carries on from there instead of
+		// starting from the earliest expiring certificates again.
+		CursorFile string
+
 		TLS       cmd.TLSConfig
 		SAService *cmd.GRPCClientConfig
 
@@ -473,7 +588,14 @@ func main() {
 	// Configure DB
 	dbURL, err := c.Mailer.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
-	dbMap, err := sa.NewDbMap(dbURL, c.Mailer.DBConfig.MaxDBConns)
+	dbTLS, err := c.Mailer.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.Mailer.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.Mailer.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.Mailer.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
 	cmd.FailOnError(err, "Could not connect to database")
 	sa.SetSQLDebug(dbMap, logger)
 	go sa.ReportDbConnCount(dbMap, scope)
@@ -559,6 +681,12 @@ func main() {
 		limit:           c.Mailer.CertLimit,
 		clk:             cmd.Clock(),
 		stats:           initStats(scope),
+		verifiedOnly:    c.Mailer.VerifiedContactsOnly,
+		maxRuntime:      c.Mailer.MaxRuntime.Duration,
+	}
+	if c.Mailer.CursorFile != "" {
+		m.cursor, err = loadCursor(c.Mailer.CursorFile)
+		cmd.FailOnError(err, "Failed to load cursor")
 	}
 
 	// Prefill this labelled stat with the possible label values, so each value is
@@ -576,10 +704,12 @@ func main() {
 		t := time.NewTicker(c.Mailer.Frequency.Duration)
 		for range t.C {
 			err = m.findExpiringCertificates()
+			mailClient.LogDeliveryReport()
 			cmd.FailOnError(err, "expiration-mailer has failed")
 		}
 	} else {
 		err = m.findExpiringCertificates()
+		mailClient.LogDeliveryReport()
 		cmd.FailOnError(err, "expiration-mailer has failed")
 	}
 }
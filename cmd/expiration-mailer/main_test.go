@@ -8,8 +8,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"text/template"
@@ -29,6 +32,7 @@ import (
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/mocks"
 	"github.com/letsencrypt/boulder/sa"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/sa/satest"
 	"github.com/letsencrypt/boulder/test"
 	"github.com/letsencrypt/boulder/test/vars"
@@ -438,6 +442,92 @@ func TestFindCertsAtCapacity(t *testing.T) {
 	test.AssertEquals(t, len(testCtx.mc.Messages), 0)
 }
 
+// slowMailer is a mocks.Mailer that takes delay to send each message.
+type slowMailer struct {
+	*mocks.Mailer
+	clk   clock.FakeClock
+	delay time.Duration
+}
+
+func (m slowMailer) SendMail(to []string, subject, msg string) error {
+	m.clk.Add(m.delay)
+	return m.Mailer.SendMail(to, subject, msg)
+}
+
+func TestFindExpiringCertificatesTimeBudget(t *testing.T) {
+	testCtx := setup(t, []time.Duration{time.Hour * 24, time.Hour * 24 * 4, time.Hour * 24 * 7})
+	defer testCtx.cleanUp()
+
+	addExpiringCerts(t, testCtx)
+
+	dir, err := ioutil.TempDir("", "expiration-mailer")
+	test.AssertNotError(t, err, "Failed to create temporary directory")
+	defer os.RemoveAll(dir)
+	testCtx.m.cursor, err = loadCursor(filepath.Join(dir, "cursor.json"))
+	test.AssertNotError(t, err, "Failed to load cursor")
+	testCtx.m.mailer = slowMailer{Mailer: testCtx.mc, clk: testCtx.fc, delay: time.Minute}
+	testCtx.m.maxRuntime = time.Minute
+	testCtx.m.limit = 1
+
+	// Sending the first nag uses up the time budget, so the later nag groups
+	// are skipped. The first group was at its limit, so its cursor is kept.
+	err = testCtx.m.findExpiringCertificates()
+	test.AssertNotError(t, err, "Failed to find expiring certs")
+	test.AssertEquals(t, len(testCtx.mc.Messages), 1)
+	pos, ok := testCtx.m.cursor.get("48h0m0s")
+	test.Assert(t, ok, "No cursor for the first nag group")
+	test.AssertEquals(t, pos.Serial, serial1String)
+	_, ok = testCtx.m.cursor.get("120h0m0s")
+	test.Assert(t, !ok, "Cursor for a skipped nag group")
+
+	// The next run finds the end of the first nag group and carries on with
+	// the others.
+	testCtx.mc.Clear()
+	err = testCtx.m.findExpiringCertificates()
+	test.AssertNotError(t, err, "Failed to find expiring certs")
+	_, ok = testCtx.m.cursor.get("48h0m0s")
+	test.Assert(t, !ok, "Cursor for the first nag group wasn't cleared")
+	_, ok = testCtx.m.cursor.get("120h0m0s")
+	test.Assert(t, ok, "No cursor for the second nag group")
+}
+
+func TestCursor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "expiration-mailer")
+	test.AssertNotError(t, err, "Failed to create temporary directory")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "cursor.json")
+
+	c, err := loadCursor(filename)
+	test.AssertNotError(t, err, "Failed to load missing cursor")
+	_, ok := c.get("48h0m0s")
+	test.Assert(t, !ok, "Missing cursor had a position")
+
+	pos := position{NotAfter: time.Date(2018, 3, 20, 0, 0, 0, 0, time.UTC), Serial: serial1String}
+	test.AssertNotError(t, c.set("48h0m0s", pos), "Failed to set cursor")
+	test.AssertNotError(t, c.set("120h0m0s", pos), "Failed to set cursor")
+	test.AssertNotError(t, c.clear("120h0m0s"), "Failed to clear cursor")
+
+	c, err = loadCursor(filename)
+	test.AssertNotError(t, err, "Failed to load cursor")
+	got, ok := c.get("48h0m0s")
+	test.Assert(t, ok, "Cursor position wasn't saved")
+	test.AssertEquals(t, got, pos)
+	_, ok = c.get("120h0m0s")
+	test.Assert(t, !ok, "Cleared cursor position was saved")
+
+	// A nil cursor records nothing
+	var none *cursor
+	test.AssertNotError(t, none.set("48h0m0s", pos), "Failed to set nil cursor")
+	test.AssertNotError(t, none.clear("48h0m0s"), "Failed to clear nil cursor")
+	_, ok = none.get("48h0m0s")
+	test.Assert(t, !ok, "Nil cursor had a position")
+
+	err = ioutil.WriteFile(filename, []byte("not JSON"), 0644)
+	test.AssertNotError(t, err, "Failed to write cursor")
+	_, err = loadCursor(filename)
+	test.AssertError(t, err, "Loaded a malformed cursor")
+}
+
 func TestCertIsRenewed(t *testing.T) {
 	testCtx := setup(t, []time.Duration{time.Hour * 24, time.Hour * 24 * 4, time.Hour * 24 * 7})
 
@@ -795,6 +885,38 @@ func TestDedupOnRegistration(t *testing.T) {
 	test.AssertEquals(t, expected, testCtx.mc.Messages[0])
 }
 
+func TestVerifiedContacts(t *testing.T) {
+	testCtx := setup(t, []time.Duration{time.Hour * 24 * 7})
+	defer testCtx.cleanUp()
+
+	var keyA jose.JSONWebKey
+	err := json.Unmarshal(jsonKeyA, &keyA)
+	test.AssertNotError(t, err, "Failed to unmarshal public JWK")
+	reg, err := testCtx.ssa.NewRegistration(ctx, core.Registration{
+		Contact:   &[]string{emailA, emailB, "tel:867-5309"},
+		Key:       &keyA,
+		InitialIP: net.ParseIP("6.5.5.6"),
+	})
+	test.AssertNotError(t, err, "Couldn't store registration")
+
+	email := emailBRaw
+	verified := testCtx.fc.Now().UnixNano()
+	_, err = testCtx.ssa.AddVerifiedContact(ctx, &sapb.AddVerifiedContactRequest{
+		RegistrationID: &reg.ID,
+		Email:          &email,
+		Verified:       &verified,
+	})
+	test.AssertNotError(t, err, "Couldn't add verified contact")
+
+	contacts, err := testCtx.m.verifiedContacts(reg.ID, *reg.Contact)
+	test.AssertNotError(t, err, "verifiedContacts failed")
+	test.AssertDeepEquals(t, contacts, []string{emailB})
+
+	contacts, err = testCtx.m.verifiedContacts(reg.ID+1, *reg.Contact)
+	test.AssertNotError(t, err, "verifiedContacts failed")
+	test.AssertEquals(t, len(contacts), 0)
+}
+
 type testCtx struct {
 	dbMap   *gorp.DbMap
 	ssa     core.StorageAdder
@@ -11,11 +11,13 @@ import (
 	"time"
 
 	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/go-gorp/gorp.v2"
 
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/features"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/sa"
 )
 
@@ -29,6 +31,46 @@ type eapConfig struct {
 		BatchSize   int
 		MaxAuthzs   int
 		Parallelism uint
+		// BatchDelay is how long to wait between batches, to throttle the load
+		// the purger puts on the database.
+		BatchDelay cmd.ConfigDuration
+
+		// Tables are the authorization tables to purge, authz and
+		// pendingAuthorizations by default. Purging them from separate
+		// instances lets each have its own grace period and pacing.
+		Tables []string
+		// CheckpointFile, if set, records how far through each table the
+		// purger has got, so that it resumes there after a restart or after
+		// stopping at MaxAuthzs or MaxOrders.
+		CheckpointFile string
+		// Interval, if set, makes the purger run continuously, starting a new
+		// purge this long after each finishes. Otherwise it purges once and
+		// exits.
+		Interval  cmd.ConfigDuration
+		DebugAddr string
+
+		// PurgeOrders enables purging expired orders, along with their
+		// authorization links, requested names and FQDN sets, after the
+		// authorizations. At most MaxOrders orders are deleted.
+		PurgeOrders bool
+		MaxOrders   int
+
+		// PurgeFailedValidations enables purging the failed validations
+		// recorded with the FailedValidationsTable feature once they're older
+		// than FailedValidationsRetention, which must be longer than the
+		// failedValidationsPerAccount rate limit window. At most
+		// MaxFailedValidations are deleted.
+		PurgeFailedValidations     bool
+		FailedValidationsRetention cmd.ConfigDuration
+		MaxFailedValidations       int
+
+		// PurgeAccountFQDNSets enables purging the FQDN sets recorded for
+		// each account with the AccountFQDNSets feature once they're older
+		// than AccountFQDNSetsRetention, which must be longer than the RA's
+		// RenewalExemptionWindow. At most MaxAccountFQDNSets are deleted.
+		PurgeAccountFQDNSets     bool
+		AccountFQDNSetsRetention cmd.ConfigDuration
+		MaxAccountFQDNSets       int
 
 		Features map[string]bool
 	}
@@ -39,18 +81,103 @@ type expiredAuthzPurger struct {
 	clk clock.Clock
 	db  *gorp.DbMap
 
-	batchSize int64
+	batchSize  int64
+	batchDelay time.Duration
+	checkpoint *checkpoint
+
+	// purged counts the rows deleted from each table, and errors the rows
+	// that couldn't be deleted and the batches that couldn't be read.
+	purged *prometheus.CounterVec
+	errors *prometheus.CounterVec
+}
+
+func newExpiredAuthzPurger(log blog.Logger, clk clock.Clock, db *gorp.DbMap, batchSize int64, batchDelay time.Duration, cp *checkpoint, stats metrics.Scope) *expiredAuthzPurger {
+	purged := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "purged",
+		Help: "A counter of expired rows deleted, by table",
+	}, []string{"table"})
+	stats.MustRegister(purged)
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "purge_errors",
+		Help: "A counter of errors reading batches of expired rows or deleting them, by table",
+	}, []string{"table"})
+	stats.MustRegister(errors)
+	return &expiredAuthzPurger{
+		log:        log,
+		clk:        clk,
+		db:         db,
+		batchSize:  batchSize,
+		batchDelay: batchDelay,
+		checkpoint: cp,
+		purged:     purged,
+		errors:     errors,
+	}
+}
+
+// checkpoint records the id reached in each table, so that a purge which is
+// stopped, by a restart or by reaching its maximum, resumes where it left off
+// instead of scanning the rows it has already been through again. A nil
+// checkpoint records nothing.
+type checkpoint struct {
+	filename string
+	ids      map[string]string
+}
+
+// loadCheckpoint reads a checkpoint file. It isn't an error for the file not
+// to exist yet.
+func loadCheckpoint(filename string) (*checkpoint, error) {
+	cp := &checkpoint{filename: filename, ids: make(map[string]string)}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &cp.ids)
+	if err != nil {
+		return nil, fmt.Errorf("parsing checkpoint file %q: %s", filename, err)
+	}
+	return cp, nil
+}
+
+func (cp *checkpoint) get(table string) string {
+	if cp == nil {
+		return ""
+	}
+	return cp.ids[table]
+}
+
+// set records id for table, writing the file by renaming a temporary file
+// so that it's never left half written.
+func (cp *checkpoint) set(table, id string) error {
+	if cp == nil {
+		return nil
+	}
+	cp.ids[table] = id
+	data, err := json.Marshal(cp.ids)
+	if err != nil {
+		return err
+	}
+	tmp := cp.filename + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, cp.filename)
 }
 
-// purge looks up pending or finalized authzs (depending on the value of
-// `table`) that expire before `purgeBefore`, using `parallelism`
-// goroutines. It will delete a maximum of `max` authzs.
+// purge looks up pending or finalized authzs, or orders (depending on the value
+// of `table`) that expire before `purgeBefore`, and deletes them using
+// `parallelism` goroutines. It will delete a maximum of `max` rows, in batches
+// of batchSize, waiting `batchDelay` between batches. Each batch is deleted
+// before the next is read, so that the id reached can be checkpointed.
 // Neither table has an index on `expires` by itself, so we just iterate through
-// the table with LIMIT and OFFSET using the default ordering. Note that this
-// becomes expensive once the earliest set of authzs has been purged, since the
-// database will have to scan through many rows before it finds some that meet
-// the expiration criteria. When we move to better authz storage (#2620), we
-// will get an appropriate index that will make this cheaper.
+// the table by id. Note that this becomes expensive once the earliest set of
+// authzs has been purged, since the database will have to scan through many
+// rows before it finds some that meet the expiration criteria. When we move to
+// better authz storage (#2620), we will get an appropriate index that will
+// make this cheaper.
 func (p *expiredAuthzPurger) purge(table string, purgeBefore time.Time, parallelism int, max int) error {
 	var query string
 	switch table {
@@ -58,63 +185,100 @@ func (p *expiredAuthzPurger) purge(table string, purgeBefore time.Time, parallel
 		query = "SELECT id FROM pendingAuthorizations WHERE id >= :id AND expires <= :expires ORDER BY id LIMIT :limit"
 	case "authz":
 		query = "SELECT id FROM authz WHERE id >= :id AND expires <= :expires ORDER BY id LIMIT :limit"
+	case "orders":
+		// Order ids are integers, but reading them as strings lets them share
+		// the batching below. The initial id of "" is compared as 0.
+		query = "SELECT id FROM orders WHERE id >= :id AND expires <= :expires ORDER BY id LIMIT :limit"
+	default:
+		return fmt.Errorf("unknown table %q", table)
+	}
+	noun := "authorizations"
+	if table == "orders" {
+		noun = "orders"
 	}
 
-	done := make(chan int)
 	work := make(chan string)
-	go func() {
-		// id starts as "", which is smaller than all other ids.
-		var id string
-		var count int
-		for count < max {
-			var idBatch []string
-			_, err := p.db.Select(
-				&idBatch,
-				query,
-				map[string]interface{}{
-					"id":      id,
-					"expires": purgeBefore,
-					"limit":   p.batchSize,
-				},
-			)
-			if err != nil && err != sql.ErrNoRows {
-				p.log.AuditErr(fmt.Sprintf("Getting a batch: %s", err))
-				time.Sleep(10)
-				continue
-			}
-			for _, v := range idBatch {
-				work <- v
-				count += 1
-				// Start the next query at the highest id we saw in this batch.
-				id = v
-			}
-			p.log.Info(fmt.Sprintf("Deleted %d authzs from %s so far", count, table))
-			if len(idBatch) < int(p.batchSize) {
-				break
-			}
-		}
-		close(work)
-		done <- count
-	}()
-
-	wg := new(sync.WaitGroup)
+	var batch sync.WaitGroup
+	var workers sync.WaitGroup
 	for i := 0; i < parallelism; i++ {
-		wg.Add(1)
+		workers.Add(1)
 		go func() {
-			defer wg.Done()
+			defer workers.Done()
 			for id := range work {
-				err := deleteAuthorization(p.db, table, id)
+				var err error
+				if table == "orders" {
+					err = deleteOrder(p.db, id)
+				} else {
+					err = deleteAuthorization(p.db, table, id)
+				}
 				if err != nil {
 					p.log.AuditErr(fmt.Sprintf("Deleting %s: %s", id, err))
+					p.errors.WithLabelValues(table).Inc()
+				} else {
+					p.purged.WithLabelValues(table).Inc()
 				}
+				batch.Done()
 			}
 		}()
 	}
+	defer func() {
+		close(work)
+		workers.Wait()
+	}()
 
-	count := <-done
-	wg.Wait()
+	// id starts as "", which is smaller than all other ids, unless a previous
+	// purge was stopped part way through the table.
+	id := p.checkpoint.get(table)
+	if id != "" {
+		p.log.Info(fmt.Sprintf("Resuming purge of %s from id %s", table, id))
+	}
+	var count int
+	for count < max {
+		limit := p.batchSize
+		if remaining := int64(max - count); remaining < limit {
+			limit = remaining
+		}
+		var idBatch []string
+		_, err := p.db.Select(
+			&idBatch,
+			query,
+			map[string]interface{}{
+				"id":      id,
+				"expires": purgeBefore,
+				"limit":   limit,
+			},
+		)
+		if err != nil && err != sql.ErrNoRows {
+			p.log.AuditErr(fmt.Sprintf("Getting a batch: %s", err))
+			p.errors.WithLabelValues(table).Inc()
+			p.clk.Sleep(time.Second)
+			continue
+		}
+		batch.Add(len(idBatch))
+		for _, v := range idBatch {
+			work <- v
+			count += 1
+			// Start the next query at the highest id we saw in this batch.
+			id = v
+		}
+		batch.Wait()
+		p.log.Info(fmt.Sprintf("Deleted %d %s from %s so far", count, noun, table))
+		if int64(len(idBatch)) < limit {
+			// The end of the table has been reached, so the next purge
+			// starts from the beginning again.
+			id = ""
+		}
+		err = p.checkpoint.set(table, id)
+		if err != nil {
+			return fmt.Errorf("writing checkpoint: %s", err)
+		}
+		if id == "" {
+			break
+		}
+		p.clk.Sleep(p.batchDelay)
+	}
 
-	p.log.Info(fmt.Sprintf("Deleted a total of %d expired authorizations from %s", count, table))
+	p.log.Info(fmt.Sprintf("Deleted a total of %d expired %s from %s", count, noun, table))
 	return nil
 }
 
@@ -140,10 +304,83 @@ func deleteAuthorization(db *gorp.DbMap, table, id string) error {
 	return nil
 }
 
-func (p *expiredAuthzPurger) purgeAuthzs(purgeBefore time.Time, parallelism int, max int) error {
-	// Purge authz first because it tends to be bigger and in more need of
-	// purging.
-	for _, table := range []string{"authz", "pendingAuthorizations"} {
+func deleteOrder(db *gorp.DbMap, id string) error {
+	// Delete the rows referencing the order first so that the order can be
+	// deleted. requestedNames rows are deleted by the database when the order
+	// is.
+	_, err := db.Exec("DELETE FROM orderToAuthz WHERE orderID = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM orderFqdnSets WHERE orderID = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM orders WHERE id = ?", id)
+	return err
+}
+
+// purgeFailedValidations deletes up to max rows from the failedValidations
+// table that were attempted before purgeBefore.
+func (p *expiredAuthzPurger) purgeFailedValidations(purgeBefore time.Time, max int) error {
+	count, err := p.purgeRowsBefore("failedValidations", "attempted", purgeBefore, max)
+	if err != nil {
+		return err
+	}
+	p.log.Info(fmt.Sprintf("Deleted a total of %d failed validations", count))
+	return nil
+}
+
+// purgeAccountFQDNSets deletes up to max rows from the accountFQDNSets table
+// that were issued before purgeBefore.
+func (p *expiredAuthzPurger) purgeAccountFQDNSets(purgeBefore time.Time, max int) error {
+	count, err := p.purgeRowsBefore("accountFQDNSets", "issued", purgeBefore, max)
+	if err != nil {
+		return err
+	}
+	p.log.Info(fmt.Sprintf("Deleted a total of %d account FQDN sets", count))
+	return nil
+}
+
+// purgeRowsBefore deletes up to max rows from table whose column is before
+// purgeBefore, a batch at a time, returning how many were deleted. The rows
+// are found with an index on column, so unlike the authorization and order
+// tables there is no need to checkpoint.
+func (p *expiredAuthzPurger) purgeRowsBefore(table, column string, purgeBefore time.Time, max int) (int64, error) {
+	var count int64
+	for count < int64(max) {
+		limit := p.batchSize
+		if remaining := int64(max) - count; remaining < limit {
+			limit = remaining
+		}
+		result, err := p.db.Exec(
+			fmt.Sprintf("DELETE FROM %s WHERE %s <= ? LIMIT ?", table, column),
+			purgeBefore, limit)
+		if err != nil {
+			p.errors.WithLabelValues(table).Inc()
+			return count, err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return count, err
+		}
+		count += deleted
+		p.purged.WithLabelValues(table).Add(float64(deleted))
+		if deleted < limit {
+			break
+		}
+		p.clk.Sleep(p.batchDelay)
+	}
+	return count, nil
+}
+
+// authzTables are the tables of finalized and pending authorizations, in the
+// order they're purged by default. authz comes first because it tends to be
+// bigger and in more need of purging.
+var authzTables = []string{"authz", "pendingAuthorizations"}
+
+func (p *expiredAuthzPurger) purgeAuthzs(tables []string, purgeBefore time.Time, parallelism int, max int) error {
+	for _, table := range tables {
 		err := p.purge(table, purgeBefore, parallelism, max)
 		if err != nil {
 			return err
@@ -163,41 +400,94 @@ func main() {
 	}
 
 	var config eapConfig
-	err = json.Unmarshal(configJSON, &config)
+	err = cmd.ParseConfig(configJSON, &config)
 	cmd.FailOnError(err, "Failed to parse config")
 	err = features.Set(config.ExpiredAuthzPurger.Features)
 	cmd.FailOnError(err, "Failed to set feature flags")
 
-	logger := cmd.NewLogger(config.ExpiredAuthzPurger.Syslog)
+	c := config.ExpiredAuthzPurger
+	var logger blog.Logger
+	scope := metrics.NewNoopScope()
+	if c.DebugAddr != "" {
+		scope, logger = cmd.StatsAndLogging(c.Syslog, c.DebugAddr)
+	} else {
+		logger = cmd.NewLogger(c.Syslog)
+	}
 	logger.Info(cmd.VersionString())
 
 	defer logger.AuditPanic()
 
+	if c.GracePeriod.Duration == 0 {
+		fmt.Fprintln(os.Stderr, "Grace period is 0, refusing to purge all pending authorizations")
+		os.Exit(1)
+	}
+	if c.Parallelism == 0 {
+		fmt.Fprintln(os.Stderr, "Parallelism field in config must be set to non-zero")
+		os.Exit(1)
+	}
+	tables := c.Tables
+	if len(tables) == 0 {
+		tables = authzTables
+	}
+	for _, table := range tables {
+		if table != "authz" && table != "pendingAuthorizations" {
+			fmt.Fprintf(os.Stderr, "Unknown table %q in tables, must be authz or pendingAuthorizations\n", table)
+			os.Exit(1)
+		}
+	}
+
+	if c.PurgeFailedValidations && c.FailedValidationsRetention.Duration <= 0 {
+		fmt.Fprintln(os.Stderr, "FailedValidationsRetention must be positive to purge failed validations")
+		os.Exit(1)
+	}
+	if c.PurgeAccountFQDNSets && c.AccountFQDNSetsRetention.Duration <= 0 {
+		fmt.Fprintln(os.Stderr, "AccountFQDNSetsRetention must be positive to purge account FQDN sets")
+		os.Exit(1)
+	}
+
+	var cp *checkpoint
+	if c.CheckpointFile != "" {
+		cp, err = loadCheckpoint(c.CheckpointFile)
+		cmd.FailOnError(err, "Failed to load checkpoint")
+	}
+
 	// Configure DB
-	dbURL, err := config.ExpiredAuthzPurger.DBConfig.URL()
+	dbURL, err := c.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
-	dbMap, err := sa.NewDbMap(dbURL, config.ExpiredAuthzPurger.DBConfig.MaxDBConns)
+	dbTLS, err := c.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
 	cmd.FailOnError(err, "Could not connect to database")
 	sa.SetSQLDebug(dbMap, logger)
 
-	purger := &expiredAuthzPurger{
-		log:       logger,
-		clk:       cmd.Clock(),
-		db:        dbMap,
-		batchSize: int64(config.ExpiredAuthzPurger.BatchSize),
-	}
+	purger := newExpiredAuthzPurger(logger, cmd.Clock(), dbMap, int64(c.BatchSize),
+		c.BatchDelay.Duration, cp, scope)
 
-	if config.ExpiredAuthzPurger.GracePeriod.Duration == 0 {
-		fmt.Fprintln(os.Stderr, "Grace period is 0, refusing to purge all pending authorizations")
-		os.Exit(1)
-	}
-	if config.ExpiredAuthzPurger.Parallelism == 0 {
-		fmt.Fprintln(os.Stderr, "Parallelism field in config must be set to non-zero")
-		os.Exit(1)
+	for {
+		purgeBefore := purger.clk.Now().Add(-c.GracePeriod.Duration)
+		logger.Info("Beginning purge")
+		err = purger.purgeAuthzs(tables, purgeBefore, int(c.Parallelism), c.MaxAuthzs)
+		cmd.FailOnError(err, "Failed to purge authorizations")
+		if c.PurgeOrders {
+			err = purger.purge("orders", purgeBefore, int(c.Parallelism), c.MaxOrders)
+			cmd.FailOnError(err, "Failed to purge orders")
+		}
+		if c.PurgeFailedValidations {
+			err = purger.purgeFailedValidations(purger.clk.Now().Add(-c.FailedValidationsRetention.Duration), c.MaxFailedValidations)
+			cmd.FailOnError(err, "Failed to purge failed validations")
+		}
+		if c.PurgeAccountFQDNSets {
+			err = purger.purgeAccountFQDNSets(purger.clk.Now().Add(-c.AccountFQDNSetsRetention.Duration), c.MaxAccountFQDNSets)
+			cmd.FailOnError(err, "Failed to purge account FQDN sets")
+		}
+		if c.Interval.Duration == 0 {
+			return
+		}
+		purger.clk.Sleep(c.Interval.Duration)
 	}
-	purgeBefore := purger.clk.Now().Add(-config.ExpiredAuthzPurger.GracePeriod.Duration)
-	logger.Info("Beginning purge")
-	err = purger.purgeAuthzs(purgeBefore, int(config.ExpiredAuthzPurger.Parallelism),
-		int(config.ExpiredAuthzPurger.MaxAuthzs))
-	cmd.FailOnError(err, "Failed to purge authorizations")
 }
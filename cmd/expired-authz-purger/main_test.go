@@ -1,6 +1,9 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -8,6 +11,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/sa"
@@ -31,9 +35,9 @@ func TestPurgeAuthzs(t *testing.T) {
 	cleanUp := test.ResetSATestDatabase(t)
 	defer cleanUp()
 
-	p := expiredAuthzPurger{log, fc, dbMap, 1}
+	p := newExpiredAuthzPurger(log, fc, dbMap, 1, 0, nil, metrics.NewNoopScope())
 
-	err = p.purgeAuthzs(time.Time{}, 10, 100)
+	err = p.purgeAuthzs(authzTables, time.Time{}, 10, 100)
 	test.AssertNotError(t, err, "purgeAuthzs failed")
 
 	old, new := fc.Now().Add(-time.Hour), fc.Now().Add(time.Hour)
@@ -58,7 +62,7 @@ func TestPurgeAuthzs(t *testing.T) {
 	})
 	test.AssertNotError(t, err, "NewPendingAuthorization failed")
 
-	err = p.purgeAuthzs(fc.Now(), 10, 100)
+	err = p.purgeAuthzs(authzTables, fc.Now(), 10, 100)
 	test.AssertNotError(t, err, "purgeAuthzs failed")
 	count, err := dbMap.SelectInt("SELECT COUNT(1) FROM pendingAuthorizations")
 	test.AssertNotError(t, err, "dbMap.SelectInt failed")
@@ -67,7 +71,7 @@ func TestPurgeAuthzs(t *testing.T) {
 	test.AssertNotError(t, err, "dbMap.SelectInt failed")
 	test.AssertEquals(t, count, int64(1))
 
-	err = p.purgeAuthzs(fc.Now().Add(time.Hour), 10, 100)
+	err = p.purgeAuthzs(authzTables, fc.Now().Add(time.Hour), 10, 100)
 	test.AssertNotError(t, err, "purgeAuthzs failed")
 	count, err = dbMap.SelectInt("SELECT COUNT(1) FROM pendingAuthorizations")
 	test.AssertNotError(t, err, "dbMap.SelectInt failed")
@@ -75,5 +79,167 @@ func TestPurgeAuthzs(t *testing.T) {
 	count, err = dbMap.SelectInt("SELECT COUNT(1) FROM challenges")
 	test.AssertNotError(t, err, "dbMap.SelectInt failed")
 	test.AssertEquals(t, count, int64(0))
+}
+
+func TestPurgeOrders(t *testing.T) {
+	dbMap, err := sa.NewDbMap(vars.DBConnSAFullPerms, 0)
+	if err != nil {
+		t.Fatalf("Couldn't connect the database: %s", err)
+	}
+	log := blog.UseMock()
+	fc := clock.NewFake()
+	fc.Add(time.Hour)
+	ssa, err := sa.NewSQLStorageAuthority(dbMap, fc, log, metrics.NewNoopScope(), 1)
+	if err != nil {
+		t.Fatalf("unable to create SQLStorageAuthority: %s", err)
+	}
+	cleanUp := test.ResetSATestDatabase(t)
+	defer cleanUp()
+
+	dir, err := ioutil.TempDir("", "expired-authz-purger")
+	test.AssertNotError(t, err, "Failed to create temporary directory")
+	defer os.RemoveAll(dir)
+	cp, err := loadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	test.AssertNotError(t, err, "Failed to load checkpoint")
+	p := newExpiredAuthzPurger(log, fc, dbMap, 1, time.Second, cp, metrics.NewNoopScope())
+
+	reg := satest.CreateWorkingRegistration(t, ssa)
+	newOrder := func(expires time.Time, name string) {
+		expiresNanos := expires.UnixNano()
+		_, err := ssa.NewOrder(context.Background(), &corepb.Order{
+			RegistrationID: &reg.ID,
+			Expires:        &expiresNanos,
+			Names:          []string{name},
+			Authorizations: []string{"authz-" + name},
+		})
+		test.AssertNotError(t, err, "NewOrder failed")
+	}
+	newOrder(fc.Now().Add(-time.Hour), "old.example.com")
+	newOrder(fc.Now().Add(-time.Hour), "older.example.com")
+	newOrder(fc.Now().Add(time.Hour), "new.example.com")
+
+	counts := func() []int64 {
+		var counts []int64
+		for _, table := range []string{"orders", "orderToAuthz", "requestedNames", "orderFqdnSets"} {
+			count, err := dbMap.SelectInt("SELECT COUNT(1) FROM " + table)
+			test.AssertNotError(t, err, "dbMap.SelectInt failed")
+			counts = append(counts, count)
+		}
+		return counts
+	}
+
+	// The max limits how many orders are deleted, and the purge resumes
+	// from the checkpoint
+	err = p.purge("orders", fc.Now(), 10, 1)
+	test.AssertNotError(t, err, "purge failed")
+	test.AssertDeepEquals(t, counts(), []int64{2, 2, 2, 2})
+	test.Assert(t, cp.get("orders") != "", "Checkpoint wasn't recorded")
+
+	err = p.purge("orders", fc.Now(), 10, 100)
+	test.AssertNotError(t, err, "purge failed")
+	test.AssertDeepEquals(t, counts(), []int64{1, 1, 1, 1})
+	test.AssertEquals(t, cp.get("orders"), "")
+	test.AssertEquals(t, test.CountCounter(p.purged.WithLabelValues("orders")), 2)
+
+	err = p.purge("orders", fc.Now().Add(2*time.Hour), 10, 100)
+	test.AssertNotError(t, err, "purge failed")
+	test.AssertDeepEquals(t, counts(), []int64{0, 0, 0, 0})
+}
+
+func TestPurgeFailedValidations(t *testing.T) {
+	dbMap, err := sa.NewDbMap(vars.DBConnSAFullPerms, 0)
+	if err != nil {
+		t.Fatalf("Couldn't connect the database: %s", err)
+	}
+	log := blog.UseMock()
+	fc := clock.NewFake()
+	fc.Add(time.Hour)
+	cleanUp := test.ResetSATestDatabase(t)
+	defer cleanUp()
+
+	p := newExpiredAuthzPurger(log, fc, dbMap, 1, time.Second, nil, metrics.NewNoopScope())
+	for _, attempted := range []time.Time{fc.Now().Add(-time.Hour), fc.Now().Add(-time.Minute), fc.Now()} {
+		_, err = dbMap.Exec(
+			"INSERT INTO failedValidations (registrationID, hostname, attempted) VALUES (?, ?, ?)",
+			1, "example.com", attempted)
+		test.AssertNotError(t, err, "Failed to insert failed validation")
+	}
+	count := func() int64 {
+		count, err := dbMap.SelectInt("SELECT COUNT(1) FROM failedValidations")
+		test.AssertNotError(t, err, "dbMap.SelectInt failed")
+		return count
+	}
+
+	// The max limits how many rows are deleted
+	err = p.purgeFailedValidations(fc.Now().Add(-time.Second), 1)
+	test.AssertNotError(t, err, "purgeFailedValidations failed")
+	test.AssertEquals(t, count(), int64(2))
+
+	err = p.purgeFailedValidations(fc.Now().Add(-time.Second), 100)
+	test.AssertNotError(t, err, "purgeFailedValidations failed")
+	test.AssertEquals(t, count(), int64(1))
+	test.AssertEquals(t, test.CountCounter(p.purged.WithLabelValues("failedValidations")), 2)
+}
+
+func TestPurgeAccountFQDNSets(t *testing.T) {
+	dbMap, err := sa.NewDbMap(vars.DBConnSAFullPerms, 0)
+	if err != nil {
+		t.Fatalf("Couldn't connect the database: %s", err)
+	}
+	log := blog.UseMock()
+	fc := clock.NewFake()
+	fc.Add(time.Hour)
+	cleanUp := test.ResetSATestDatabase(t)
+	defer cleanUp()
+
+	p := newExpiredAuthzPurger(log, fc, dbMap, 1, time.Second, nil, metrics.NewNoopScope())
+	for _, issued := range []time.Time{fc.Now().Add(-time.Hour), fc.Now().Add(-time.Minute), fc.Now()} {
+		_, err = dbMap.Exec(
+			"INSERT INTO accountFQDNSets (setHash, registrationID, issued) VALUES (?, ?, ?)",
+			make([]byte, 32), 1, issued)
+		test.AssertNotError(t, err, "Failed to insert account FQDN set")
+	}
+	count := func() int64 {
+		count, err := dbMap.SelectInt("SELECT COUNT(1) FROM accountFQDNSets")
+		test.AssertNotError(t, err, "dbMap.SelectInt failed")
+		return count
+	}
+
+	err = p.purgeAccountFQDNSets(fc.Now().Add(-time.Second), 1)
+	test.AssertNotError(t, err, "purgeAccountFQDNSets failed")
+	test.AssertEquals(t, count(), int64(2))
+
+	err = p.purgeAccountFQDNSets(fc.Now().Add(-time.Second), 100)
+	test.AssertNotError(t, err, "purgeAccountFQDNSets failed")
+	test.AssertEquals(t, count(), int64(1))
+	test.AssertEquals(t, test.CountCounter(p.purged.WithLabelValues("accountFQDNSets")), 2)
+}
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "expired-authz-purger")
+	test.AssertNotError(t, err, "Failed to create temporary directory")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "checkpoint.json")
+
+	cp, err := loadCheckpoint(filename)
+	test.AssertNotError(t, err, "Failed to load missing checkpoint")
+	test.AssertEquals(t, cp.get("authz"), "")
+	test.AssertNotError(t, cp.set("authz", "abc"), "Failed to set checkpoint")
+	test.AssertNotError(t, cp.set("orders", "12"), "Failed to set checkpoint")
+
+	cp, err = loadCheckpoint(filename)
+	test.AssertNotError(t, err, "Failed to load checkpoint")
+	test.AssertEquals(t, cp.get("authz"), "abc")
+	test.AssertEquals(t, cp.get("orders"), "12")
+	test.AssertEquals(t, cp.get("pendingAuthorizations"), "")
+
+	// A nil checkpoint records nothing
+	var none *checkpoint
+	test.AssertNotError(t, none.set("authz", "abc"), "Failed to set nil checkpoint")
+	test.AssertEquals(t, none.get("authz"), "")
 
+	err = ioutil.WriteFile(filename, []byte("not JSON"), 0644)
+	test.AssertNotError(t, err, "Failed to write checkpoint")
+	_, err = loadCheckpoint(filename)
+	test.AssertError(t, err, "Loaded a malformed checkpoint")
 }
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/letsencrypt/boulder/features"
+)
+
+// featuresHandler serves the state of the feature flags as a JSON object on
+// GET, so that the flags in effect on each instance can be checked during a
+// rollout. It's read-only: the debug port isn't authenticated, and many flags
+// are only read when a component starts, so flags are changed in the config
+// and take effect on restart.
+func featuresHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := json.Marshal(features.All())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
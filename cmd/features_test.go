@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestFeaturesHandler(t *testing.T) {
+	defer features.Reset()
+	err := features.Set(map[string]bool{"AsyncFinalize": true})
+	test.AssertNotError(t, err, "setting features")
+	handler := featuresHandler()
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest("GET", "/debug/features", nil))
+	test.AssertEquals(t, rw.Code, http.StatusOK)
+	var state map[string]bool
+	err = json.Unmarshal(rw.Body.Bytes(), &state)
+	test.AssertNotError(t, err, "unmarshaling features")
+	test.AssertEquals(t, state["AsyncFinalize"], true)
+	test.AssertEquals(t, state["CTContingency"], false)
+
+	// Flags can't be changed
+	form := url.Values{"name": {"CTContingency"}, "enabled": {"true"}}
+	req := httptest.NewRequest("POST", "/debug/features", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rw = httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+	test.AssertEquals(t, rw.Code, http.StatusMethodNotAllowed)
+	test.Assert(t, !features.Enabled(features.CTContingency), "POST changed a feature flag")
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -54,34 +55,109 @@ func (c idExporter) findIDs() ([]id, error) {
 	return idsList, nil
 }
 
+// Find the registration IDs holding unexpired certificates for any of the
+// given hostnames. Certificates for a wildcard covering a hostname count as
+// covering it.
 func (c idExporter) findIDsForDomains(domains []string) ([]id, error) {
 	var idsList []id
 	for _, domain := range domains {
-		// Pass the same list in each time, gorp will happily just append to the slice
-		// instead of overwriting it each time
-		// https://github.com/go-gorp/gorp/blob/2ae7d174a4cf270240c4561092402affba25da5e/select.go#L348-L355
-		_, err := c.dbMap.Select(
-			&idsList,
-			`SELECT registrationID AS id FROM certificates
+		domain = strings.TrimSpace(domain)
+		if domain == "" {
+			continue
+		}
+		names := []string{domain}
+		if labels := strings.SplitN(domain, ".", 2); len(labels) == 2 && labels[0] != "*" {
+			names = append(names, "*."+labels[1])
+		}
+		for _, name := range names {
+			// Pass the same list in each time, gorp will happily just append to the slice
+			// instead of overwriting it each time
+			// https://github.com/go-gorp/gorp/blob/2ae7d174a4cf270240c4561092402affba25da5e/select.go#L348-L355
+			_, err := c.dbMap.Select(
+				&idsList,
+				`SELECT registrationID AS id FROM certificates
                          WHERE expires >= :expireCutoff AND
                          serial IN (
                            SELECT serial FROM issuedNames
                             WHERE reversedName = :reversedName
                          )`,
+				map[string]interface{}{
+					"expireCutoff": c.clk.Now().Add(-c.grace),
+					"reversedName": sa.ReverseName(name),
+				},
+			)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					continue
+				}
+				return nil, err
+			}
+		}
+	}
+
+	return uniqueIDs(idsList), nil
+}
+
+// regexBatchSize is the number of issued names examined by each query of
+// findIDsForRegex.
+const regexBatchSize = 10000
+
+// Find the registration IDs holding unexpired certificates for a name
+// matching re. Names can't be matched by the database, so the names of all
+// unexpired certificates are read in batches and matched here.
+func (c idExporter) findIDsForRegex(re *regexp.Regexp) ([]id, error) {
+	type issuedName struct {
+		ID             int64
+		ReversedName   string
+		RegistrationID int64
+	}
+	var idsList []id
+	var lastID int64
+	for {
+		var names []issuedName
+		_, err := c.dbMap.Select(
+			&names,
+			`SELECT n.id, n.reversedName, c.registrationID
+			FROM issuedNames AS n JOIN certificates AS c ON n.serial = c.serial
+			WHERE n.id > :lastID AND c.expires >= :expireCutoff
+			ORDER BY n.id
+			LIMIT :limit`,
 			map[string]interface{}{
+				"lastID":       lastID,
 				"expireCutoff": c.clk.Now().Add(-c.grace),
-				"reversedName": sa.ReverseName(domain),
+				"limit":        regexBatchSize,
 			},
 		)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				continue
-			}
 			return nil, err
 		}
+		for _, name := range names {
+			if re.MatchString(sa.ReverseName(name.ReversedName)) {
+				idsList = append(idsList, id{name.RegistrationID})
+			}
+			lastID = name.ID
+		}
+		if len(names) < regexBatchSize {
+			break
+		}
 	}
 
-	return idsList, nil
+	return uniqueIDs(idsList), nil
+}
+
+// uniqueIDs removes repeated IDs from idsList, keeping the first of each, so
+// that registrations with several matching certificates are only notified
+// once.
+func uniqueIDs(idsList []id) []id {
+	seen := make(map[int64]bool)
+	var unique []id
+	for _, i := range idsList {
+		if !seen[i.ID] {
+			seen[i.ID] = true
+			unique = append(unique, i)
+		}
+	}
+	return unique
 }
 
 // The `writeIDs` function produces a file containing JSON serialized
@@ -108,6 +184,13 @@ The ID exporter exists to retrieve the IDs of all registered
 users with currently unexpired certificates. This list of registration IDs can
 then be given as input to the notification mailer to send bulk notifications.
 
+The -domains parameter limits the export to registrations with unexpired
+certificates for any of the hostnames listed in a file, one per line, including
+certificates for a wildcard covering a hostname. The -hostname-regex parameter
+instead limits it to certificates with a name matching a regular expression,
+such as '(^|\.)example\.com$'. Either, but not both, can be used to find the
+subscribers affected by an incident involving particular domains.
+
 The -grace parameter can be used to allow registrations with certificates that
 have already expired to be included in the export. The argument is a Go duration
 obeying the usual suffix rules (e.g. 24h).
@@ -137,6 +220,12 @@ Examples:
   id-exporter -config test/config/id-exporter.json -grace 48h -outfile
     "regs.json"
 
+  Export the registration IDs with unexpired certificates for example.com or
+  any of its subdomains to "regs.json":
+
+  id-exporter -config test/config/id-exporter.json
+    -hostname-regex '(^|\.)example\.com$' -outfile "regs.json"
+
 Required arguments:
 - config
 - outfile`
@@ -145,6 +234,7 @@ func main() {
 	outFile := flag.String("outfile", "", "File to write contacts to (defaults to stdout).")
 	grace := flag.Duration("grace", 2*24*time.Hour, "Include contacts with certificates that expired in < grace ago")
 	domainsFile := flag.String("domains", "", "If provided only output contacts for certificates that contain at least one of the domains in the provided file. Provided file should contain one domain per line")
+	hostnameRegex := flag.String("hostname-regex", "", "If provided only output contacts for certificates that contain a name matching the regular expression")
 	type config struct {
 		ContactExporter struct {
 			cmd.DBConfig
@@ -161,7 +251,7 @@ func main() {
 	}
 
 	flag.Parse()
-	if *outFile == "" || *configFile == "" {
+	if *outFile == "" || *configFile == "" || (*domainsFile != "" && *hostnameRegex != "") {
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -171,7 +261,7 @@ func main() {
 	configData, err := ioutil.ReadFile(*configFile)
 	cmd.FailOnError(err, fmt.Sprintf("Reading %q", *configFile))
 	var cfg config
-	err = json.Unmarshal(configData, &cfg)
+	err = cmd.ParseConfig(configData, &cfg)
 	cmd.FailOnError(err, "Unmarshaling config")
 	err = features.Set(cfg.ContactExporter.Features)
 	cmd.FailOnError(err, "Failed to set feature flags")
@@ -189,7 +279,12 @@ func main() {
 	}
 
 	var ids []id
-	if *domainsFile != "" {
+	if *hostnameRegex != "" {
+		re, err := regexp.Compile(*hostnameRegex)
+		cmd.FailOnError(err, "Could not parse -hostname-regex")
+		ids, err = exporter.findIDsForRegex(re)
+		cmd.FailOnError(err, "Could not find IDs")
+	} else if *domainsFile != "" {
 		df, err := ioutil.ReadFile(*domainsFile)
 		cmd.FailOnError(err, fmt.Sprintf("Could not read domains file %q", *domainsFile))
 		ids, err = exporter.findIDsForDomains(strings.Split(string(df), "\n"))
@@ -12,6 +12,7 @@ import (
 	"math/big"
 	"net"
 	"os"
+	"regexp"
 	"testing"
 	"time"
 
@@ -104,6 +105,42 @@ func TestFindIDsForDomains(t *testing.T) {
 	test.AssertEquals(t, ids[0].ID, regA.ID)
 	test.AssertEquals(t, ids[1].ID, regC.ID)
 	test.AssertEquals(t, ids[2].ID, regD.ID)
+
+	// A hostname is covered by a wildcard certificate, and a registration is
+	// only exported once however many of its certificates match
+	_, err = testCtx.c.dbMap.Exec(
+		"INSERT INTO issuedNames (reversedName, serial, notBefore) VALUES (?,?,0)",
+		"com.example-c.*",
+		core.SerialToString(big.NewInt(1338)),
+	)
+	test.AssertNotError(t, err, "Couldn't add wildcard issued name")
+	ids, err = testCtx.c.findIDsForDomains([]string{"example-c.com", "www.example-c.com", ""})
+	test.AssertNotError(t, err, "findIDsForDomains() failed")
+	test.AssertEquals(t, len(ids), 1)
+	test.AssertEquals(t, ids[0].ID, regC.ID)
+}
+
+func TestFindIDsForRegex(t *testing.T) {
+	testCtx := setup(t)
+	defer testCtx.cleanUp()
+
+	testCtx.addRegistrations(t)
+	testCtx.addCertificates(t)
+
+	// example-b.com only has an expired certificate
+	ids, err := testCtx.c.findIDsForRegex(regexp.MustCompile(`^example-[abc]\.com$`))
+	test.AssertNotError(t, err, "findIDsForRegex() failed")
+	test.AssertEquals(t, len(ids), 2)
+	test.AssertEquals(t, ids[0].ID, regA.ID)
+	test.AssertEquals(t, ids[1].ID, regC.ID)
+
+	ids, err = testCtx.c.findIDsForRegex(regexp.MustCompile(`\.net$`))
+	test.AssertNotError(t, err, "findIDsForRegex() failed")
+	test.AssertEquals(t, len(ids), 0)
+}
+
+func TestUniqueIDs(t *testing.T) {
+	test.AssertDeepEquals(t, uniqueIDs([]id{{3}, {1}, {3}, {2}, {1}}), []id{{3}, {1}, {2}})
 }
 
 func exampleIds() []id {
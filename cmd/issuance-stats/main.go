@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+const usageString = `
+usage:
+issuance-stats rollup --config <path> [--day <YYYY-MM-DD>] [--days <n>]
+issuance-stats export --config <path> --from <YYYY-MM-DD> --to <YYYY-MM-DD> [--reg-id <id>] [--outfile <path>]
+
+command descriptions:
+  rollup    Compute the daily issuance statistics of every registration. This
+            is meant to be run shortly after midnight UTC, and replaces any
+            existing statistics for the days it covers.
+  export    Export daily issuance statistics as CSV
+
+args:
+  config    File path to the configuration file for this service
+  day       Last UTC day to roll up, defaults to yesterday
+  days      Number of days up to and including --day to roll up, defaults to 1
+  from      First UTC day to export
+  to        Last UTC day to export, inclusive
+  reg-id    Only export the statistics of this registration
+  outfile   File to write the CSV to, defaults to stdout
+`
+
+const dayFormat = "2006-01-02"
+
+type config struct {
+	IssuanceStats struct {
+		cmd.DBConfig
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+var csvHeader = []string{"registrationID", "day", "certificatesIssued", "namesIssued", "failedOrders"}
+
+// writeCSV writes stats to w as CSV, with a header row.
+func writeCSV(w io.Writer, stats []sa.IssuanceStats) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, s := range stats {
+		err := cw.Write([]string{
+			strconv.FormatInt(s.RegistrationID, 10),
+			s.Day.UTC().Format(dayFormat),
+			strconv.FormatInt(s.CertificatesIssued, 10),
+			strconv.FormatInt(s.NamesIssued, 10),
+			strconv.FormatInt(s.FailedOrders, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// rollupDays returns the days to roll up, oldest first, ending with last.
+func rollupDays(last time.Time, n int) []time.Time {
+	days := make([]time.Time, n)
+	for i := range days {
+		days[i] = last.AddDate(0, 0, i-n+1)
+	}
+	return days
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	dayArg := flagSet.String("day", "", "Last UTC day to roll up")
+	numDays := flagSet.Int("days", 1, "Number of days to roll up")
+	fromArg := flagSet.String("from", "", "First UTC day to export")
+	toArg := flagSet.String("to", "", "Last UTC day to export")
+	regID := flagSet.Int64("reg-id", 0, "Only export the statistics of this registration")
+	outFile := flagSet.String("outfile", "", "File to write the CSV to")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+
+	if *configFile == "" {
+		usage()
+	}
+
+	var c config
+	err = cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.IssuanceStats.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	logger := cmd.NewLogger(c.Syslog)
+	defer logger.AuditPanic()
+
+	dbURL, err := c.IssuanceStats.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbTLS, err := c.IssuanceStats.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.IssuanceStats.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.IssuanceStats.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.IssuanceStats.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
+	cmd.FailOnError(err, "Couldn't setup database connection")
+
+	switch command {
+	case "rollup":
+		last := cmd.Clock().Now().UTC().AddDate(0, 0, -1)
+		if *dayArg != "" {
+			last, err = time.Parse(dayFormat, *dayArg)
+			cmd.FailOnError(err, "Couldn't parse --day")
+		}
+		if *numDays < 1 {
+			usage()
+		}
+		for _, day := range rollupDays(last, *numDays) {
+			rows, err := sa.RollupIssuanceStats(dbMap, day)
+			cmd.FailOnError(err, fmt.Sprintf("Couldn't roll up issuance statistics for %s", day.Format(dayFormat)))
+			logger.Info(fmt.Sprintf("Rolled up issuance statistics for %d registrations on %s", rows, day.Format(dayFormat)))
+		}
+
+	case "export":
+		if *fromArg == "" || *toArg == "" {
+			usage()
+		}
+		from, err := time.Parse(dayFormat, *fromArg)
+		cmd.FailOnError(err, "Couldn't parse --from")
+		to, err := time.Parse(dayFormat, *toArg)
+		cmd.FailOnError(err, "Couldn't parse --to")
+
+		stats, err := sa.SelectIssuanceStats(dbMap, *regID, from, to)
+		cmd.FailOnError(err, "Couldn't select issuance statistics")
+
+		out := os.Stdout
+		if *outFile != "" {
+			out, err = os.Create(*outFile)
+			cmd.FailOnError(err, fmt.Sprintf("Couldn't create outfile %q", *outFile))
+			defer out.Close()
+		}
+		err = writeCSV(out, stats)
+		cmd.FailOnError(err, "Couldn't write CSV")
+
+	default:
+		usage()
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/sa"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeCSV(&buf, []sa.IssuanceStats{
+		{RegistrationID: 1, Day: time.Date(2018, 3, 13, 0, 0, 0, 0, time.UTC), CertificatesIssued: 2, NamesIssued: 5},
+		{RegistrationID: 7, Day: time.Date(2018, 3, 14, 0, 0, 0, 0, time.UTC), FailedOrders: 1},
+	})
+	test.AssertNotError(t, err, "writeCSV failed")
+	test.AssertEquals(t, buf.String(),
+		"registrationID,day,certificatesIssued,namesIssued,failedOrders\n"+
+			"1,2018-03-13,2,5,0\n"+
+			"7,2018-03-14,0,0,1\n")
+}
+
+func TestRollupDays(t *testing.T) {
+	last := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	days := rollupDays(last, 3)
+	test.AssertDeepEquals(t, days, []time.Time{
+		time.Date(2018, 2, 27, 0, 0, 0, 0, time.UTC),
+		time.Date(2018, 2, 28, 0, 0, 0, 0, time.UTC),
+		last,
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/cmd"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/log/tail"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+type config struct {
+	Syslog    cmd.SyslogConfig
+	DebugAddr string
+
+	// Files are the paths of the log files to validate.
+	Files []string
+	// PollInterval is how often each file is checked for new lines once
+	// the end has been reached. Defaults to one second.
+	PollInterval cmd.ConfigDuration
+}
+
+var (
+	errMissingChecksum = errors.New("line has no checksum")
+	errBadChecksum     = errors.New("checksum doesn't match message")
+)
+
+// validateLine checks the checksum of a log line written by a JSON format
+// Logger. The JSON object may be preceded by a syslog header.
+func validateLine(line string) error {
+	start := strings.Index(line, "{")
+	if start == -1 {
+		return errMissingChecksum
+	}
+	var entry struct {
+		Checksum *string `json:"checksum"`
+		Message  *string `json:"msg"`
+	}
+	if err := json.Unmarshal([]byte(line[start:]), &entry); err != nil {
+		// A line that was truncated or mangled in transit usually can't
+		// be parsed at all.
+		return errBadChecksum
+	}
+	if entry.Checksum == nil || entry.Message == nil {
+		return errMissingChecksum
+	}
+	if blog.LogLineChecksum(*entry.Message) != *entry.Checksum {
+		return errBadChecksum
+	}
+	return nil
+}
+
+// validator counts the valid, corrupted and unchecksummed lines of each
+// file. Only the lines that reach the file are checked: log lines don't carry
+// sequence numbers, so lines dropped entirely, e.g. by a full syslog queue,
+// go unnoticed rather than being counted as missing.
+type validator struct {
+	log   blog.Logger
+	lines *prometheus.CounterVec
+}
+
+func newValidator(log blog.Logger, stats metrics.Scope) *validator {
+	lines := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "log_lines",
+		Help: "A counter of log lines read by the log validator, by filename and status (valid, invalid or missing, for lines without a checksum; dropped lines aren't counted)",
+	}, []string{"filename", "status"})
+	stats.MustRegister(lines)
+	return &validator{log: log, lines: lines}
+}
+
+func (v *validator) handle(filename, line string) {
+	status := "valid"
+	switch err := validateLine(line); err {
+	case errMissingChecksum:
+		status = "missing"
+	case errBadChecksum:
+		status = "invalid"
+		v.log.Warning(fmt.Sprintf("%s: %s: %q", filename, err, line))
+	}
+	v.lines.With(prometheus.Labels{"filename": filename, "status": status}).Inc()
+}
+
+func main() {
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+
+	scope, logger := cmd.StatsAndLogging(c.Syslog, c.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+
+	if len(c.Files) == 0 {
+		logger.AuditErr("No log files to validate")
+		os.Exit(1)
+	}
+	interval := c.PollInterval.Duration
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	v := newValidator(logger, scope)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, filename := range c.Files {
+		wg.Add(1)
+		go func(filename string) {
+			defer wg.Done()
+			f := &tail.Follower{Filename: filename, Interval: interval, Log: logger}
+			f.Follow(func(line string) { v.handle(filename, line) }, stop)
+		}(filename)
+	}
+
+	cmd.CatchSignals(logger, func() {
+		close(stop)
+		wg.Wait()
+	})
+}
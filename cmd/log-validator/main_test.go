@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func jsonLine(msg string) string {
+	return fmt.Sprintf(`Oct 16 12:00:00 host boulder-ra[1]: {"level":"INFO","checksum":%q,"msg":%q}`,
+		blog.LogLineChecksum(msg), msg)
+}
+
+func TestValidateLine(t *testing.T) {
+	test.AssertNotError(t, validateLine(jsonLine("Certificate request - successful")), "valid line")
+	test.AssertEquals(t, validateLine("Oct 16 12:00:00 host boulder-ra[1]: some text"), errMissingChecksum)
+	test.AssertEquals(t, validateLine(`{"level":"INFO","msg":"no checksum"}`), errMissingChecksum)
+
+	tampered := fmt.Sprintf(`{"checksum":%q,"msg":"revoked"}`, blog.LogLineChecksum("issued"))
+	test.AssertEquals(t, validateLine(tampered), errBadChecksum)
+	truncated := jsonLine("Certificate request - successful")
+	test.AssertEquals(t, validateLine(truncated[:len(truncated)-10]), errBadChecksum)
+}
+
+func TestValidatorMetrics(t *testing.T) {
+	log := blog.NewMock()
+	v := newValidator(log, metrics.NewNoopScope())
+	v.handle("ra.log", jsonLine("one"))
+	v.handle("ra.log", jsonLine("two"))
+	v.handle("ra.log", "plain")
+	v.handle("ra.log", `{"checksum":"AAAAAA","msg":"three"}`)
+
+	count := func(status string) int {
+		return test.CountCounter(v.lines.With(prometheus.Labels{"filename": "ra.log", "status": status}))
+	}
+	test.AssertEquals(t, count("valid"), 2)
+	test.AssertEquals(t, count("missing"), 1)
+	test.AssertEquals(t, count("invalid"), 1)
+	test.AssertEquals(t, len(log.GetAllMatching("ra.log: checksum doesn't match message")), 1)
+}
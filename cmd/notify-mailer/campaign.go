@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/letsencrypt/boulder/cmd"
+	bmail "github.com/letsencrypt/boulder/mail"
+)
+
+// campaign describes a complete mailing in a single file, so that it can be
+// reviewed before it is sent instead of being spread over a long list of
+// flags. Campaign files are YAML, which includes JSON. Relative paths in a
+// campaign are relative to the directory of the campaign file.
+type campaign struct {
+	Name string `yaml:"name"`
+	From string `yaml:"from"`
+
+	Template struct {
+		Subject  string `yaml:"subject"`
+		BodyFile string `yaml:"body-file"`
+	} `yaml:"template"`
+
+	// Recipients selects the registrations to mail, either from a file in the
+	// format of the -toFile flag or with one of the recipientQueries, by name.
+	// Since is the time the issued-since query selects from.
+	Recipients struct {
+		File  string    `yaml:"file"`
+		Query string    `yaml:"query"`
+		Since time.Time `yaml:"since"`
+	} `yaml:"recipients"`
+
+	// Schedule is the window in which messages may be sent. The mailer waits
+	// for the window to open and stops when it closes.
+	Schedule struct {
+		NotBefore time.Time `yaml:"not-before"`
+		NotAfter  time.Time `yaml:"not-after"`
+	} `yaml:"schedule"`
+
+	RateLimit struct {
+		// Sleep overrides the -sleep flag if set.
+		Sleep *cmd.ConfigDuration `yaml:"sleep"`
+		// MaxMessages, if non-zero, stops the mailer after sending that many
+		// messages.
+		MaxMessages int `yaml:"max-messages"`
+		// InterleaveDomains sends consecutive messages to different
+		// recipient domains in turn.
+		InterleaveDomains bool `yaml:"interleave-domains"`
+		// Domains are the most messages per hour to send to each recipient
+		// domain. Setting any turns on interleaving.
+		Domains map[string]int `yaml:"domains"`
+	} `yaml:"rate-limit"`
+
+	// BCC, if BatchSize is set, sends each message to a batch of that many
+	// recipients by BCC, with To as the To header, rather than mailing each
+	// recipient individually. It overrides the -bccBatchSize and -bccTo
+	// flags.
+	BCC struct {
+		BatchSize int    `yaml:"batch-size"`
+		To        string `yaml:"to"`
+	} `yaml:"bcc"`
+
+	// SuppressionLists are files of email addresses, one per line, that must
+	// not be mailed. Blank lines and lines starting with # are ignored.
+	SuppressionLists []string `yaml:"suppression-lists"`
+
+	Report struct {
+		// File is written as a CSV of each address and whether it was sent,
+		// suppressed or failed.
+		File string `yaml:"file"`
+		// Recipients are sent a summary of the run once it finishes.
+		Recipients []string `yaml:"recipients"`
+	} `yaml:"report"`
+}
+
+// recipientQueries are the queries a campaign can select its recipients with,
+// by name. Campaigns can't provide their own SQL. Each query selects an id
+// column of registration IDs, and is given the named arguments now, the time
+// the mailer starts, and since, the campaign's recipients since time.
+var recipientQueries = map[string]string{
+	// Registrations that haven't been deactivated.
+	"valid-registrations": "SELECT id FROM registrations WHERE status = 'valid'",
+	// Registrations with an unexpired certificate.
+	"unexpired-certificates": "SELECT DISTINCT registrationID AS id FROM certificates WHERE expires > :now",
+	// Registrations issued a certificate since the since time.
+	"issued-since": "SELECT DISTINCT registrationID AS id FROM certificates WHERE issued >= :since",
+}
+
+// loadCampaign reads and validates a campaign file.
+func loadCampaign(filename string) (*campaign, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var c campaign
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing campaign %q: %s", filename, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid campaign %q: %s", filename, err)
+	}
+
+	// Resolve relative paths against the campaign file's directory
+	dir := filepath.Dir(filename)
+	resolve := func(path *string) {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+	resolve(&c.Template.BodyFile)
+	resolve(&c.Recipients.File)
+	resolve(&c.Report.File)
+	for i := range c.SuppressionLists {
+		resolve(&c.SuppressionLists[i])
+	}
+	return &c, nil
+}
+
+func (c *campaign) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("from %q: %s", c.From, err)
+	}
+	if c.Template.Subject == "" || c.Template.BodyFile == "" {
+		return fmt.Errorf("template subject and body-file are required")
+	}
+	if (c.Recipients.File == "") == (c.Recipients.Query == "") {
+		return fmt.Errorf("exactly one of recipients file and query is required")
+	}
+	if c.Recipients.Query != "" {
+		if _, ok := recipientQueries[c.Recipients.Query]; !ok {
+			return fmt.Errorf("unknown recipients query %q", c.Recipients.Query)
+		}
+		if c.Recipients.Query == "issued-since" && c.Recipients.Since.IsZero() {
+			return fmt.Errorf("recipients query %q requires since", c.Recipients.Query)
+		}
+	}
+	if !c.Schedule.NotBefore.IsZero() && !c.Schedule.NotAfter.IsZero() &&
+		!c.Schedule.NotBefore.Before(c.Schedule.NotAfter) {
+		return fmt.Errorf("schedule not-before (%s) must be before not-after (%s)",
+			c.Schedule.NotBefore, c.Schedule.NotAfter)
+	}
+	if c.RateLimit.Sleep != nil && c.RateLimit.Sleep.Duration < 0 {
+		return fmt.Errorf("rate-limit sleep (%s) is < 0", c.RateLimit.Sleep.Duration)
+	}
+	if c.RateLimit.MaxMessages < 0 {
+		return fmt.Errorf("rate-limit max-messages (%d) is < 0", c.RateLimit.MaxMessages)
+	}
+	if c.BCC.BatchSize < 0 {
+		return fmt.Errorf("bcc batch-size (%d) is < 0", c.BCC.BatchSize)
+	}
+	if c.BCC.BatchSize > 0 {
+		if _, err := mail.ParseAddress(c.BCC.To); err != nil {
+			return fmt.Errorf("bcc to %q: %s", c.BCC.To, err)
+		}
+	}
+	for domain, limit := range c.RateLimit.Domains {
+		if domain == "" || strings.Contains(domain, "@") {
+			return fmt.Errorf("rate-limit domain %q is not a domain", domain)
+		}
+		if limit <= 0 {
+			return fmt.Errorf("rate-limit for domain %q (%d) must be positive", domain, limit)
+		}
+	}
+	for _, addr := range c.Report.Recipients {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("report recipient %q: %s", addr, err)
+		}
+	}
+	return nil
+}
+
+// domainLimits returns the campaign's per-domain limits keyed by lower cased
+// domain.
+func (c *campaign) domainLimits() map[string]int {
+	limits := make(map[string]int, len(c.RateLimit.Domains))
+	for domain, limit := range c.RateLimit.Domains {
+		limits[strings.ToLower(domain)] = limit
+	}
+	return limits
+}
+
+// loadSuppressionLists returns the set of lower cased addresses in the
+// campaign's suppression lists.
+func (c *campaign) loadSuppressionLists() (map[string]bool, error) {
+	suppressed := make(map[string]bool)
+	for _, filename := range c.SuppressionLists {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			suppressed[strings.ToLower(line)] = true
+		}
+		err = scanner.Err()
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading suppression list %q: %s", filename, err)
+		}
+	}
+	return suppressed, nil
+}
+
+// Results recorded for each address a campaign reaches.
+const (
+	resultSent       = "sent"
+	resultSuppressed = "suppressed"
+	resultFailed     = "failed"
+)
+
+type sendResult struct {
+	address string
+	result  string
+}
+
+// writeReport writes the results of a run to the campaign's report file as
+// CSV.
+func (c *campaign) writeReport(results []sendResult) error {
+	if c.Report.File == "" {
+		return nil
+	}
+	f, err := os.Create(c.Report.File)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	err = w.Write([]string{"address", "result"})
+	for _, r := range results {
+		if err != nil {
+			break
+		}
+		err = w.Write([]string{r.address, r.result})
+	}
+	w.Flush()
+	if err == nil {
+		err = w.Error()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// summary describes the outcome of a run for the report recipients.
+func (c *campaign) summary(results []sendResult, total int, runErr error) string {
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.result]++
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Campaign %q finished.\n\n", c.Name)
+	fmt.Fprintf(&b, "Sent: %d\nSuppressed: %d\nFailed: %d\nNot attempted: %d\n",
+		counts[resultSent], counts[resultSuppressed], counts[resultFailed], total-len(results))
+	if runErr != nil {
+		fmt.Fprintf(&b, "\nThe run stopped early: %s\n", runErr)
+	}
+	if c.Report.File != "" {
+		fmt.Fprintf(&b, "\nThe full report was written to %s\n", c.Report.File)
+	}
+	return b.String()
+}
+
+// sendReport mails the summary of a run to the campaign's report recipients.
+func (c *campaign) sendReport(mailer bmail.Mailer, results []sendResult, total int, runErr error) error {
+	if len(c.Report.Recipients) == 0 {
+		return nil
+	}
+	if err := mailer.Connect(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = mailer.Close()
+	}()
+	return mailer.SendMail(
+		c.Report.Recipients,
+		fmt.Sprintf("notify-mailer campaign %q report", c.Name),
+		c.summary(results, total, runErr))
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/mocks"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestLoadCampaign(t *testing.T) {
+	c, err := loadCampaign("testdata/campaign.yaml")
+	test.AssertNotError(t, err, "loadCampaign failed")
+	test.AssertEquals(t, c.Name, "test-campaign")
+	test.AssertEquals(t, c.Template.BodyFile, filepath.Join("testdata", "test_msg_body.txt"))
+	test.AssertEquals(t, c.Recipients.File, filepath.Join("testdata", "test_msg_recipients.txt"))
+	test.AssertEquals(t, c.Schedule.NotBefore, time.Date(2018, 3, 20, 15, 0, 0, 0, time.UTC))
+	test.AssertEquals(t, c.RateLimit.Sleep.Duration, time.Second)
+	test.AssertEquals(t, c.RateLimit.MaxMessages, 4)
+	test.AssertDeepEquals(t, c.domainLimits(), map[string]int{"example.com": 60})
+
+	suppressed, err := c.loadSuppressionLists()
+	test.AssertNotError(t, err, "loadSuppressionLists failed")
+	test.AssertDeepEquals(t, suppressed, map[string]bool{
+		"test-example-updated@example.com": true,
+		"mail@example.com":                 true,
+	})
+
+	_, err = loadCampaign("testdata/nonexistent.yaml")
+	test.AssertError(t, err, "loadCampaign didn't fail on a missing file")
+}
+
+func TestCampaignValidate(t *testing.T) {
+	valid := func() *campaign {
+		var c campaign
+		c.Name = "test"
+		c.From = "hello@goodbye.com"
+		c.Template.Subject = "Hello"
+		c.Template.BodyFile = "body.txt"
+		c.Recipients.File = "recipients.json"
+		return &c
+	}
+	test.AssertNotError(t, valid().validate(), "valid campaign was invalid")
+
+	testCases := []struct {
+		name   string
+		modify func(*campaign)
+	}{
+		{"no name", func(c *campaign) { c.Name = "" }},
+		{"bad from", func(c *campaign) { c.From = "not an address" }},
+		{"no subject", func(c *campaign) { c.Template.Subject = "" }},
+		{"no recipients", func(c *campaign) { c.Recipients.File = "" }},
+		{"file and query", func(c *campaign) { c.Recipients.Query = "valid-registrations" }},
+		{"unknown query", func(c *campaign) {
+			c.Recipients.File = ""
+			c.Recipients.Query = "SELECT id FROM registrations"
+		}},
+		{"issued-since without since", func(c *campaign) {
+			c.Recipients.File = ""
+			c.Recipients.Query = "issued-since"
+		}},
+		{"empty schedule", func(c *campaign) {
+			c.Schedule.NotBefore = time.Date(2018, 3, 20, 0, 0, 0, 0, time.UTC)
+			c.Schedule.NotAfter = c.Schedule.NotBefore
+		}},
+		{"negative max messages", func(c *campaign) { c.RateLimit.MaxMessages = -1 }},
+		{"negative bcc batch size", func(c *campaign) { c.BCC.BatchSize = -1 }},
+		{"bcc without to", func(c *campaign) { c.BCC.BatchSize = 10 }},
+		{"zero domain limit", func(c *campaign) { c.RateLimit.Domains = map[string]int{"gmail.com": 0} }},
+		{"address domain limit", func(c *campaign) { c.RateLimit.Domains = map[string]int{"me@gmail.com": 10} }},
+		{"bad report recipient", func(c *campaign) { c.Report.Recipients = []string{"ops"} }},
+	}
+	for _, tc := range testCases {
+		c := valid()
+		tc.modify(c)
+		test.AssertError(t, c.validate(), tc.name)
+	}
+}
+
+func TestRunCampaign(t *testing.T) {
+	mc := &mocks.Mailer{}
+	fc := newFakeClock(t)
+	m := &mailer{
+		log:            blog.UseMock(),
+		mailer:         mc,
+		dbMap:          mockEmailResolver{},
+		subject:        "Test",
+		emailTemplate:  "Hi",
+		clk:            fc,
+		recipientQuery: recipientQueries["valid-registrations"],
+		suppressed:     map[string]bool{"test-example-updated@example.com": true},
+		notBefore:      fc.Now().Add(time.Hour),
+		notAfter:       fc.Now().Add(2 * time.Hour),
+		maxMessages:    3,
+		sleepInterval:  time.Second,
+	}
+
+	err := m.run()
+	test.AssertNotError(t, err, "run failed")
+	// The mailer waits for the window to open before sending
+	test.AssertEquals(t, fc.Now(), newFakeClock(t).Now().Add(time.Hour+3*time.Second))
+	test.AssertEquals(t, len(mc.Messages), 3)
+	test.AssertEquals(t, mc.Messages[0].To, "example@example.com")
+	test.AssertEquals(t, mc.Messages[1].To, "test-test-test@example.com")
+	test.AssertEquals(t, m.total, 6)
+	test.AssertDeepEquals(t, m.results, []sendResult{
+		{"test-example-updated@example.com", resultSuppressed},
+		{"example@example.com", resultSent},
+		{"test-test-test@example.com", resultSent},
+		{"example-example-example@example.com", resultSent},
+	})
+
+	// Once the window closes the run stops
+	mc.Clear()
+	m.results = nil
+	m.maxMessages = 0
+	m.sleepInterval = time.Hour
+	err = m.run()
+	test.AssertError(t, err, "run didn't stop when the window closed")
+	test.AssertEquals(t, len(mc.Messages), 1)
+
+	err = m.run()
+	test.AssertError(t, err, "run started after the window closed")
+}
+
+func TestCampaignReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notify-mailer")
+	test.AssertNotError(t, err, "creating temp dir")
+	defer os.RemoveAll(dir)
+
+	var c campaign
+	c.Name = "test"
+	c.Report.File = filepath.Join(dir, "report.csv")
+	c.Report.Recipients = []string{"operations@goodbye.com"}
+	results := []sendResult{
+		{"example@example.com", resultSent},
+		{"mail@example.com", resultSuppressed},
+		{"test-test-test@example.com", resultFailed},
+	}
+
+	err = c.writeReport(results)
+	test.AssertNotError(t, err, "writeReport failed")
+	report, err := ioutil.ReadFile(c.Report.File)
+	test.AssertNotError(t, err, "reading report")
+	test.AssertEquals(t, string(report), "address,result\n"+
+		"example@example.com,sent\n"+
+		"mail@example.com,suppressed\n"+
+		"test-test-test@example.com,failed\n")
+
+	mc := &mocks.Mailer{}
+	err = c.sendReport(mc, results, 5, errors.New("connection reset"))
+	test.AssertNotError(t, err, "sendReport failed")
+	test.AssertEquals(t, len(mc.Messages), 1)
+	test.AssertEquals(t, mc.Messages[0].To, "operations@goodbye.com")
+	body := mc.Messages[0].Body
+	for _, expected := range []string{"Sent: 1\n", "Suppressed: 1\n", "Failed: 1\n", "Not attempted: 2\n", "connection reset"} {
+		test.Assert(t, strings.Contains(body, expected), "report summary missing "+expected)
+	}
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"net/mail"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,6 +33,36 @@ type mailer struct {
 	destinations  []byte
 	checkpoint    interval
 	sleepInterval time.Duration
+
+	// The fields below are only set by campaigns.
+	//
+	// recipientQuery, if set, is one of the recipientQueries, used to select
+	// the registration IDs to mail instead of destinations. recipientSince is
+	// its since argument.
+	recipientQuery string
+	recipientSince time.Time
+	// suppressed holds lower cased addresses that must not be mailed.
+	suppressed map[string]bool
+	// Messages are only sent between notBefore and notAfter, when set.
+	notBefore time.Time
+	notAfter  time.Time
+	// maxMessages, if non-zero, is the number of messages to send before
+	// stopping.
+	maxMessages int
+	// interleave spreads consecutive messages over the recipient domains,
+	// and domainLimits are the most messages per hour to send to each lower
+	// cased domain when interleaving.
+	interleave   bool
+	domainLimits map[string]int
+	// bccBatchSize, if non-zero, is the number of recipients sent each
+	// message by BCC, with bccTo as the To header, instead of mailing each
+	// recipient individually. Each recipient counts towards maxMessages.
+	bccBatchSize int
+	bccTo        string
+	// results records what happened for each address, out of total
+	// destinations.
+	results []sendResult
+	total   int
 }
 
 type interval struct {
@@ -74,6 +107,21 @@ func (m *mailer) ok() error {
 			"sleep interval (%d) is < 0", m.sleepInterval)
 	}
 
+	// BCC batches need a To header
+	if m.bccBatchSize < 0 {
+		return fmt.Errorf(
+			"BCC batch size (%d) is < 0", m.bccBatchSize)
+	}
+	if m.bccBatchSize > 0 && m.bccTo == "" {
+		return fmt.Errorf("BCC batches require a To address")
+	}
+
+	// Don't start a run whose window has already closed
+	if !m.notAfter.IsZero() && !m.clk.Now().Before(m.notAfter) {
+		return fmt.Errorf(
+			"schedule window closed at %s", m.notAfter)
+	}
+
 	return nil
 }
 
@@ -99,6 +147,13 @@ func (m *mailer) run() error {
 	if err != nil {
 		return err
 	}
+	m.total = len(destinations)
+
+	// Wait for the schedule window to open
+	if now := m.clk.Now(); now.Before(m.notBefore) {
+		m.log.Info(fmt.Sprintf("Waiting until %s to start sending\n", m.notBefore))
+		m.clk.Sleep(m.notBefore.Sub(now))
+	}
 
 	err = m.mailer.Connect()
 	if err != nil {
@@ -108,17 +163,76 @@ func (m *mailer) run() error {
 		_ = m.mailer.Close()
 	}()
 
-	startTime := m.clk.Now()
-
-	for i, dest := range destinations {
-		m.printStatus(dest, i, len(destinations), startTime)
+	// Suppressed addresses are skipped up front so that they don't take up
+	// a domain's share of the sending rate.
+	var sendable []string
+	for _, dest := range destinations {
 		if strings.TrimSpace(dest) == "" {
 			continue
 		}
-		err := m.mailer.SendMail([]string{dest}, m.subject, m.emailTemplate)
+		if m.suppressed[strings.ToLower(dest)] {
+			m.results = append(m.results, sendResult{dest, resultSuppressed})
+			continue
+		}
+		sendable = append(sendable, dest)
+	}
+
+	startTime := m.clk.Now()
+	scheduler := newDomainScheduler(sendable, m.interleave, m.domainLimits)
+
+	sent := 0
+	for i := 0; !scheduler.empty(); {
+		if m.maxMessages > 0 && sent >= m.maxMessages {
+			m.log.Info(fmt.Sprintf("Stopping after %d messages\n", sent))
+			return nil
+		}
+		size := 1
+		if m.bccBatchSize > 0 {
+			size = m.bccBatchSize
+		}
+		if m.maxMessages > 0 && m.maxMessages-sent < size {
+			size = m.maxMessages - sent
+		}
+		// A batch is sent once every recipient in it may be mailed.
+		var batch []string
+		var at time.Time
+		for len(batch) < size {
+			dest, destAt, ok := scheduler.pop(m.clk.Now())
+			if !ok {
+				break
+			}
+			batch = append(batch, dest)
+			if destAt.After(at) {
+				at = destAt
+			}
+		}
+		m.printStatus(strings.Join(batch, ", "), i, len(sendable), startTime)
+		if wait := at.Sub(m.clk.Now()); wait > 0 {
+			m.clk.Sleep(wait)
+		}
+		if !m.notAfter.IsZero() && !m.clk.Now().Before(m.notAfter) {
+			return fmt.Errorf(
+				"schedule window closed at %s after %d of %d messages",
+				m.notAfter, i, len(sendable))
+		}
+		var err error
+		if m.bccBatchSize > 0 {
+			err = m.mailer.SendMailBCC(m.bccTo, batch, m.subject, m.emailTemplate)
+		} else {
+			err = m.mailer.SendMail(batch, m.subject, m.emailTemplate)
+		}
+		result := resultSent
+		if err != nil {
+			result = resultFailed
+		}
+		for _, dest := range batch {
+			m.results = append(m.results, sendResult{dest, result})
+		}
 		if err != nil {
 			return err
 		}
+		sent += len(batch)
+		i += len(batch)
 		m.clk.Sleep(m.sleepInterval)
 	}
 	return nil
@@ -127,9 +241,20 @@ func (m *mailer) run() error {
 // Resolves each reg ID to the most up-to-date contact email.
 func (m *mailer) resolveDestinations() ([]string, error) {
 	var regs []regID
-	err := json.Unmarshal(m.destinations, &regs)
-	if err != nil {
-		return nil, err
+	if m.recipientQuery != "" {
+		_, err := m.dbMap.Select(&regs, m.recipientQuery, map[string]interface{}{
+			"now":   m.clk.Now(),
+			"since": m.recipientSince,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		regs, err = parseRecipients(m.destinations)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// If there is no endpoint specified, use the total # of destinations
@@ -163,11 +288,50 @@ func (m *mailer) resolveDestinations() ([]string, error) {
 	return contactsList, nil
 }
 
-// Since the only thing we use from gorp is the SelectOne method on the
-// gorp.DbMap object, we just define an interface with that method
+// parseRecipients parses a list of registration IDs, either JSON of the form
+// [{"id": 1}, ...] or CSV with an id column, as written by the
+// contact-exporter. A CSV file may have a row for each address of a
+// registration, but each ID is only included once.
+func parseRecipients(data []byte) ([]regID, error) {
+	var regs []regID
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] == '[' {
+		err := json.Unmarshal(data, &regs)
+		return regs, err
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	column := -1
+	for i, name := range records[0] {
+		if strings.TrimSpace(name) == "id" {
+			column = i
+		}
+	}
+	if column == -1 {
+		return nil, fmt.Errorf("CSV recipients have no id column")
+	}
+	seen := make(map[int]bool)
+	for _, record := range records[1:] {
+		id, err := strconv.Atoi(record[column])
+		if err != nil {
+			return nil, fmt.Errorf("parsing id %q: %s", record[column], err)
+		}
+		if !seen[id] {
+			seen[id] = true
+			regs = append(regs, regID{ID: id})
+		}
+	}
+	return regs, nil
+}
+
+// Since the only things we use from gorp are the Select and SelectOne methods
+// on the gorp.DbMap object, we just define an interface with those methods
 // instead of importing all of gorp. This facilitates mock implementations for
 // unit tests
 type dbSelector interface {
+	Select(holder interface{}, query string, args ...interface{}) ([]interface{}, error)
 	SelectOne(holder interface{}, query string, args ...interface{}) error
 }
 
@@ -218,6 +382,8 @@ the -toFile argument as a path to a plaintext file containing JSON of the form:
    { "id": n }
   ]
 
+or CSV with an id column, as written by the contact-exporter.
+
 To help the operator gain confidence in the mailing run before committing fully
 three safety features are supported: dry runs, checkpointing and a sleep
 interval.
@@ -239,6 +405,65 @@ opportunity for the operator to terminate early in the event of error. The
 -sleep flag honours durations with a unit suffix (e.g. 1m for 1 minute, 10s for
 10 seconds, etc). Using -sleep=0 will disable the sleep and send at full speed.
 
+Instead of the -from, -subject, -body and -toFile arguments a campaign file can
+be provided via the -campaign argument, so that the whole mailing can be
+reviewed ahead of time. A campaign is YAML (or JSON) of the form:
+
+  name: example-campaign
+  from: hello@goodbye.com
+  template:
+    subject: Hello!
+    body-file: test_msg_body.txt
+  recipients:
+    # Either a file in the -toFile format, or the name of a predefined query:
+    # valid-registrations, unexpired-certificates or issued-since, which
+    # selects the registrations issued a certificate since the since time.
+    file: test_msg_recipients.json
+    # query: issued-since
+    # since: 2018-01-01T00:00:00Z
+  schedule:
+    not-before: 2018-03-20T15:00:00Z
+    not-after: 2018-03-21T00:00:00Z
+  rate-limit:
+    sleep: 10s
+    max-messages: 1000
+    interleave-domains: true
+    domains:
+      gmail.com: 500
+      yahoo.com: 200
+  bcc:
+    batch-size: 50
+    to: announcements@goodbye.com
+  suppression-lists:
+    - unsubscribed.txt
+  report:
+    file: example-campaign-report.csv
+    recipients:
+      - operations@goodbye.com
+
+Relative paths are relative to the campaign file. Messages are only sent within
+the schedule window: the mailer waits for it to open and stops when it closes.
+Addresses in the suppression lists, one per line, are skipped. Once the run is
+over the outcome for each address is written to the report file as CSV and a
+summary is mailed to the report recipients. The -start, -end and -dryRun
+arguments work the same with a campaign.
+
+With interleave-domains, consecutive messages are sent to different recipient
+domains in turn rather than in the order of the recipients, so that a sorted
+list doesn't send a long run of messages to one provider. The domains of the
+rate-limit are the most messages to send to each domain per hour, spaced out
+evenly over the hour, in addition to the sleep between every message. Other
+domains keep being mailed while a limited domain waits. Setting any domain
+limit turns on interleaving.
+
+For announcements that don't need to be sent individually the -bccBatchSize
+flag, or the bcc section of a campaign, sends each message to a batch of
+recipients by BCC, which takes far less time than one message per recipient.
+The To header of those messages is the -bccTo address, which isn't itself sent
+the message. Each recipient in a batch counts towards max-messages, and a
+batch with a domain limited recipient waits until that recipient may be
+mailed.
+
 Examples:
   Send an email with subject "Hello!" from the email "hello@goodbye.com" with
   the contents read from "test_msg_body.txt" to every email associated with the
@@ -265,12 +490,21 @@ Examples:
     -toFile cmd/notify-mailer/testdata/test_msg_recipients.json -subject "Hello!"
     -sleep 10s -start 200 -end 300 -dryRun=true
 
+  Send the campaign described by "campaign.yaml", as a dry-run:
+
+  notify-mailer -config test/config/notify-mailer.json
+    -campaign cmd/notify-mailer/testdata/campaign.yaml -dryRun=true
+
 Required arguments:
 - body
 - config
 - from
 - subject
-- toFile`
+- toFile
+
+or:
+- campaign
+- config`
 
 func main() {
 	from := flag.String("from", "", "From header for emails. Must be a bare email address.")
@@ -281,6 +515,8 @@ func main() {
 	sleep := flag.Duration("sleep", 60*time.Second, "How long to sleep between emails.")
 	start := flag.Int("start", 0, "Line of input file to start from.")
 	end := flag.Int("end", 99999999, "Line of input file to end before.")
+	bccBatchSize := flag.Int("bccBatchSize", 0, "If non-zero, send each message to this many recipients by BCC instead of mailing each recipient individually.")
+	bccTo := flag.String("bccTo", "", "To header for messages sent by BCC. Must be a bare email address.")
 	reconnBase := flag.Duration("reconnectBase", 1*time.Second, "Base sleep duration between reconnect attempts")
 	reconnMax := flag.Duration("reconnectMax", 5*60*time.Second, "Max sleep duration between reconnect attempts after exponential backoff")
 	type config struct {
@@ -293,6 +529,7 @@ func main() {
 		Syslog cmd.SyslogConfig
 	}
 	configFile := flag.String("config", "", "File containing a JSON config.")
+	campaignFile := flag.String("campaign", "", "File containing a YAML or JSON campaign, used instead of -from, -subject, -body and -toFile.")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "%s\n\n", usageIntro)
@@ -301,7 +538,27 @@ func main() {
 	}
 
 	flag.Parse()
-	if *from == "" || *subject == "" || *bodyFile == "" || *configFile == "" {
+	var camp *campaign
+	if *campaignFile != "" {
+		if *from != "" || *subject != "" || *bodyFile != "" || *toFile != "" || *configFile == "" {
+			flag.Usage()
+			os.Exit(1)
+		}
+		var err error
+		camp, err = loadCampaign(*campaignFile)
+		cmd.FailOnError(err, "Loading campaign")
+		*from = camp.From
+		*subject = camp.Template.Subject
+		*bodyFile = camp.Template.BodyFile
+		*toFile = camp.Recipients.File
+		if camp.RateLimit.Sleep != nil {
+			*sleep = camp.RateLimit.Sleep.Duration
+		}
+		if camp.BCC.BatchSize > 0 {
+			*bccBatchSize = camp.BCC.BatchSize
+			*bccTo = camp.BCC.To
+		}
+	} else if *from == "" || *subject == "" || *bodyFile == "" || *toFile == "" || *configFile == "" {
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -309,7 +566,7 @@ func main() {
 	configData, err := ioutil.ReadFile(*configFile)
 	cmd.FailOnError(err, fmt.Sprintf("Reading %q", *configFile))
 	var cfg config
-	err = json.Unmarshal(configData, &cfg)
+	err = cmd.ParseConfig(configData, &cfg)
 	cmd.FailOnError(err, "Unmarshaling config")
 	err = features.Set(cfg.NotifyMailer.Features)
 	cmd.FailOnError(err, "Failed to set feature flags")
@@ -329,15 +586,23 @@ func main() {
 	address, err := mail.ParseAddress(*from)
 	cmd.FailOnError(err, fmt.Sprintf("Parsing %q", *from))
 
-	toBody, err := ioutil.ReadFile(*toFile)
-	cmd.FailOnError(err, fmt.Sprintf("Reading %q", *toFile))
+	var toBody []byte
+	if *toFile != "" {
+		toBody, err = ioutil.ReadFile(*toFile)
+		cmd.FailOnError(err, fmt.Sprintf("Reading %q", *toFile))
+	}
+
+	if *bccBatchSize > 0 {
+		_, err = mail.ParseAddress(*bccTo)
+		cmd.FailOnError(err, fmt.Sprintf("Parsing %q", *bccTo))
+	}
 
 	checkpointRange := interval{
 		start: *start,
 		end:   *end,
 	}
 
-	var mailClient bmail.Mailer
+	var mailClient *bmail.MailerImpl
 	if *dryRun {
 		mailClient = bmail.NewDryRun(*address, log)
 	} else {
@@ -366,8 +631,30 @@ func main() {
 		emailTemplate: string(body),
 		checkpoint:    checkpointRange,
 		sleepInterval: *sleep,
+		bccBatchSize:  *bccBatchSize,
+		bccTo:         *bccTo,
+	}
+	if camp != nil {
+		m.recipientQuery = recipientQueries[camp.Recipients.Query]
+		m.recipientSince = camp.Recipients.Since
+		m.notBefore = camp.Schedule.NotBefore
+		m.notAfter = camp.Schedule.NotAfter
+		m.maxMessages = camp.RateLimit.MaxMessages
+		m.interleave = camp.RateLimit.InterleaveDomains || len(camp.RateLimit.Domains) > 0
+		m.domainLimits = camp.domainLimits()
+		m.suppressed, err = camp.loadSuppressionLists()
+		cmd.FailOnError(err, "Loading suppression lists")
 	}
 
 	err = m.run()
+	mailClient.LogDeliveryReport()
+	if camp != nil {
+		// Report on the run even if it failed part way through
+		reportErr := camp.writeReport(m.results)
+		cmd.FailOnError(reportErr, "Writing campaign report")
+		reportErr = camp.sendReport(mailClient, m.results, m.total, err)
+		cmd.FailOnError(reportErr, "Sending campaign report")
+		log.AuditInfo(fmt.Sprintf("Campaign %q: %d addresses reached of %d", camp.Name, len(m.results), m.total))
+	}
 	cmd.FailOnError(err, "mailer.send returned error")
 }
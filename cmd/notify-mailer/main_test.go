@@ -361,6 +361,19 @@ func (bs mockEmailResolver) SelectOne(output interface{}, _ string, args ...inte
 	return nil
 }
 
+// the `mockEmailResolver` select method returns every reg ID in the mock
+// data, whatever the query
+func (bs mockEmailResolver) Select(output interface{}, _ string, _ ...interface{}) ([]interface{}, error) {
+	outputPtr, ok := output.(*[]regID)
+	if !ok {
+		return nil, fmt.Errorf("incorrect output type %T", output)
+	}
+	for id := 1; id <= 6; id++ {
+		*outputPtr = append(*outputPtr, regID{ID: id})
+	}
+	return nil, nil
+}
+
 func TestResolveEmails(t *testing.T) {
 	// Start with three reg. IDs. Note: the IDs have been matched with fake
 	// results in the `db` slice in `mockEmailResolver`'s `SelectOne`. If you add
@@ -414,6 +427,21 @@ func TestResolveEmails(t *testing.T) {
 	}
 }
 
+func TestParseRecipients(t *testing.T) {
+	regs, err := parseRecipients([]byte(`[{"id": 1}, {"id": 3}]`))
+	test.AssertNotError(t, err, "parsing JSON recipients")
+	test.AssertDeepEquals(t, regs, []regID{{ID: 1}, {ID: 3}})
+
+	regs, err = parseRecipients([]byte("id,email\n1,one@example.com\n3,three@example.com\n3,three@example.net\n"))
+	test.AssertNotError(t, err, "parsing CSV recipients")
+	test.AssertDeepEquals(t, regs, []regID{{ID: 1}, {ID: 3}})
+
+	_, err = parseRecipients([]byte("email\none@example.com\n"))
+	test.AssertError(t, err, "parsed CSV recipients without an id column")
+	_, err = parseRecipients([]byte("id,email\none,one@example.com\n"))
+	test.AssertError(t, err, "parsed CSV recipients with an invalid id")
+}
+
 func newFakeClock(t *testing.T) clock.FakeClock {
 	const fakeTimeFormat = "2006-01-02T15:04:05.999999999Z"
 	ft, err := time.Parse(fakeTimeFormat, fakeTimeFormat)
@@ -424,3 +452,49 @@ func newFakeClock(t *testing.T) clock.FakeClock {
 	fc.Set(ft.UTC())
 	return fc
 }
+
+// batchMailer is a mocks.Mailer that also records the recipients of each
+// message sent by BCC.
+type batchMailer struct {
+	mocks.Mailer
+	to      []string
+	batches [][]string
+}
+
+func (m *batchMailer) SendMailBCC(to string, bcc []string, subject, msg string) error {
+	m.to = append(m.to, to)
+	m.batches = append(m.batches, bcc)
+	return m.Mailer.SendMailBCC(to, bcc, subject, msg)
+}
+
+func TestBCCBatches(t *testing.T) {
+	mc := &batchMailer{}
+	m := &mailer{
+		log:            blog.UseMock(),
+		mailer:         mc,
+		dbMap:          mockEmailResolver{},
+		subject:        "Test",
+		emailTemplate:  "Hi",
+		clk:            newFakeClock(t),
+		recipientQuery: recipientQueries["valid-registrations"],
+		suppressed:     map[string]bool{"test-test-test@example.com": true},
+		maxMessages:    4,
+		sleepInterval:  time.Second,
+		bccBatchSize:   3,
+	}
+	err := m.run()
+	test.AssertError(t, err, "run without a BCC To address didn't fail")
+
+	m.bccTo = "announce@example.com"
+	err = m.run()
+	test.AssertNotError(t, err, "run failed")
+	// The last batch is cut short by maxMessages
+	test.AssertDeepEquals(t, mc.batches, [][]string{
+		{"example@example.com", "test-example-updated@example.com", "example-example-example@example.com"},
+		{"youve.got.mail@example.com"},
+	})
+	test.AssertDeepEquals(t, mc.to, []string{"announce@example.com", "announce@example.com"})
+	test.AssertEquals(t, len(mc.Messages), 4)
+	// The sleep is between messages rather than recipients
+	test.AssertEquals(t, m.clk.Now(), newFakeClock(t).Now().Add(2*time.Second))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// domainQueue holds the destinations at one recipient domain, in the order
+// they were resolved.
+type domainQueue struct {
+	domain    string
+	addresses []string
+	// interval is the time to leave between messages to the domain, or zero
+	// if it isn't limited.
+	interval time.Duration
+	// ready is when the next message to the domain may be sent.
+	ready time.Time
+}
+
+// domainScheduler decides the order destinations are mailed in. When
+// interleaving, destinations are grouped by recipient domain and each message
+// goes to the next domain in turn, so that a recipient list sorted by address
+// doesn't send one provider a long run of messages. Domains with a limit of N
+// messages per hour are sent one message every hour/N at most, and are passed
+// over while other domains have messages that can be sent.
+type domainScheduler struct {
+	queues []*domainQueue
+	// next is the index of the queue to consider first for the next message.
+	next int
+}
+
+// newDomainScheduler returns a scheduler for destinations. limits are the
+// maximum messages per hour for each lower cased domain, which only apply
+// when interleaving. Without interleaving destinations are mailed in order.
+func newDomainScheduler(destinations []string, interleave bool, limits map[string]int) *domainScheduler {
+	s := &domainScheduler{}
+	if !interleave {
+		s.queues = []*domainQueue{{addresses: destinations}}
+		return s
+	}
+	byDomain := make(map[string]*domainQueue)
+	for _, address := range destinations {
+		domain := addressDomain(address)
+		q := byDomain[domain]
+		if q == nil {
+			q = &domainQueue{domain: domain}
+			if limit := limits[domain]; limit > 0 {
+				q.interval = time.Hour / time.Duration(limit)
+			}
+			byDomain[domain] = q
+			s.queues = append(s.queues, q)
+		}
+		q.addresses = append(q.addresses, address)
+	}
+	return s
+}
+
+// addressDomain returns the lower cased domain of an email address.
+func addressDomain(address string) string {
+	return strings.ToLower(address[strings.LastIndex(address, "@")+1:])
+}
+
+// empty returns true once every destination has been returned by pop.
+func (s *domainScheduler) empty() bool {
+	return len(s.queues) == 0
+}
+
+// pop returns the next destination to mail and the time at which it may be
+// sent, which is no earlier than now. ok is false once every destination has
+// been returned.
+func (s *domainScheduler) pop(now time.Time) (address string, at time.Time, ok bool) {
+	if len(s.queues) == 0 {
+		return "", time.Time{}, false
+	}
+	// Take the queue that is ready soonest, preferring the first in turn
+	// among those that are ready.
+	chosen := -1
+	for i := 0; i < len(s.queues); i++ {
+		idx := (s.next + i) % len(s.queues)
+		q := s.queues[idx]
+		if chosen == -1 || q.ready.Before(s.queues[chosen].ready) {
+			chosen = idx
+		}
+		if !q.ready.After(now) {
+			chosen = idx
+			break
+		}
+	}
+	q := s.queues[chosen]
+	at = q.ready
+	if at.Before(now) {
+		at = now
+	}
+	address = q.addresses[0]
+	q.addresses = q.addresses[1:]
+	q.ready = at.Add(q.interval)
+	if len(q.addresses) == 0 {
+		s.queues = append(s.queues[:chosen], s.queues[chosen+1:]...)
+		s.next = chosen
+	} else {
+		s.next = chosen + 1
+	}
+	if s.next >= len(s.queues) {
+		s.next = 0
+	}
+	return address, at, true
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestDomainSchedulerInOrder(t *testing.T) {
+	destinations := []string{"a@gmail.com", "b@gmail.com", "c@yahoo.com"}
+	s := newDomainScheduler(destinations, false, map[string]int{"gmail.com": 1})
+	now := time.Date(2018, 3, 20, 0, 0, 0, 0, time.UTC)
+	for _, expected := range destinations {
+		address, at, ok := s.pop(now)
+		test.Assert(t, ok, "pop ran out of destinations")
+		test.AssertEquals(t, address, expected)
+		// Limits don't apply without interleaving
+		test.AssertEquals(t, at, now)
+	}
+	_, _, ok := s.pop(now)
+	test.Assert(t, !ok, "pop returned more destinations than it was given")
+}
+
+func TestDomainSchedulerInterleave(t *testing.T) {
+	destinations := []string{
+		"a@gmail.com", "b@Gmail.com", "c@gmail.com", "d@gmail.com",
+		"e@example.com", "f@example.com",
+		"g@yahoo.com",
+	}
+	s := newDomainScheduler(destinations, true, map[string]int{"gmail.com": 4})
+	now := time.Date(2018, 3, 20, 0, 0, 0, 0, time.UTC)
+
+	type popped struct {
+		address string
+		at      time.Time
+	}
+	var got []popped
+	for {
+		address, at, ok := s.pop(now)
+		if !ok {
+			break
+		}
+		got = append(got, popped{address, at})
+		// Pretend each message takes a minute to send
+		if at.After(now) {
+			now = at
+		}
+		now = now.Add(time.Minute)
+	}
+	start := time.Date(2018, 3, 20, 0, 0, 0, 0, time.UTC)
+	// gmail.com is limited to one message every 15 minutes, so the other
+	// domains are mailed in the meantime.
+	test.AssertDeepEquals(t, got, []popped{
+		{"a@gmail.com", start},
+		{"e@example.com", start.Add(time.Minute)},
+		{"g@yahoo.com", start.Add(2 * time.Minute)},
+		{"f@example.com", start.Add(3 * time.Minute)},
+		{"b@Gmail.com", start.Add(15 * time.Minute)},
+		{"c@gmail.com", start.Add(30 * time.Minute)},
+		{"d@gmail.com", start.Add(45 * time.Minute)},
+	})
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"container/list"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	cfocsp "github.com/cloudflare/cfssl/ocsp"
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+// cacheEntry is a response held by a cachingSource.
+type cacheEntry struct {
+	key      string
+	response []byte
+	header   http.Header
+	// expires is when the entry stops being served: the earlier of the
+	// response's nextUpdate and the cache's TTL after it was fetched.
+	expires time.Time
+}
+
+// cachingSource is a cfocsp.Source that keeps the most recently requested
+// responses from another source in memory, so that hot serials are served
+// without a database lookup. It holds at most size responses, evicting the
+// least recently used, and serves each for at most ttl so that revocations
+// reach clients promptly.
+type cachingSource struct {
+	source cfocsp.Source
+	clk    clock.Clock
+	size   int
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+
+	lookups   *prometheus.CounterVec
+	sizeGauge prometheus.Gauge
+}
+
+func newCachingSource(source cfocsp.Source, clk clock.Clock, size int, ttl time.Duration, scope metrics.Scope) *cachingSource {
+	lookups := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ocsp_cache_lookups",
+			Help: "Number of OCSP responses looked up in the in-memory cache, by result (hit, miss or expired)",
+		},
+		[]string{"result"},
+	)
+	sizeGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ocsp_cache_size",
+		Help: "Number of OCSP responses held in the in-memory cache",
+	})
+	scope.MustRegister(lookups, sizeGauge)
+	return &cachingSource{
+		source:    source,
+		clk:       clk,
+		size:      size,
+		ttl:       ttl,
+		entries:   make(map[string]*list.Element),
+		lru:       list.New(),
+		lookups:   lookups,
+		sizeGauge: sizeGauge,
+	}
+}
+
+// Response returns the cached response for req if there is one that hasn't
+// expired, and otherwise fetches it from the underlying source and caches it.
+// Errors, including cfocsp.ErrNotFound, are never cached.
+func (cs *cachingSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
+	key := hex.EncodeToString(req.IssuerKeyHash) + ":" + core.SerialToString(req.SerialNumber)
+	entry, result := cs.get(key)
+	cs.lookups.WithLabelValues(result).Inc()
+	if entry != nil {
+		return entry.response, entry.header, nil
+	}
+
+	response, header, err := cs.source.Response(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	cs.add(key, response, header)
+	return response, header, nil
+}
+
+// get returns the unexpired entry for key, if any, and the lookup result for
+// metrics. Expired entries are removed.
+func (cs *cachingSource) get(key string) (*cacheEntry, string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	elem, present := cs.entries[key]
+	if !present {
+		return nil, "miss"
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !cs.clk.Now().Before(entry.expires) {
+		cs.remove(elem)
+		return nil, "expired"
+	}
+	cs.lru.MoveToFront(elem)
+	return entry, "hit"
+}
+
+// add caches response under key, unless it can't be parsed or isn't currently
+// valid according to its thisUpdate and nextUpdate.
+func (cs *cachingSource) add(key string, response []byte, header http.Header) {
+	parsed, err := ocsp.ParseResponse(response, nil)
+	if err != nil {
+		return
+	}
+	now := cs.clk.Now()
+	expires := now.Add(cs.ttl)
+	if !parsed.NextUpdate.IsZero() && parsed.NextUpdate.Before(expires) {
+		expires = parsed.NextUpdate
+	}
+	if now.Before(parsed.ThisUpdate) || !now.Before(expires) {
+		return
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if elem, present := cs.entries[key]; present {
+		cs.remove(elem)
+	}
+	cs.entries[key] = cs.lru.PushFront(&cacheEntry{
+		key:      key,
+		response: response,
+		header:   header,
+		expires:  expires,
+	})
+	for cs.lru.Len() > cs.size {
+		cs.remove(cs.lru.Back())
+	}
+	cs.sizeGauge.Set(float64(cs.lru.Len()))
+}
+
+// remove deletes elem from the cache. cs.mu must be held.
+func (cs *cachingSource) remove(elem *list.Element) {
+	cs.lru.Remove(elem)
+	delete(cs.entries, elem.Value.(*cacheEntry).key)
+	cs.sizeGauge.Set(float64(cs.lru.Len()))
+}
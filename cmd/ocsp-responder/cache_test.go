@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	cfocsp "github.com/cloudflare/cfssl/ocsp"
+	"github.com/jmhodges/clock"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// countingSource serves a fixed set of responses and counts lookups.
+type countingSource struct {
+	responses map[string][]byte
+	lookups   int
+}
+
+func (s *countingSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
+	s.lookups++
+	response, present := s.responses[req.SerialNumber.String()]
+	if !present {
+		return nil, nil, cfocsp.ErrNotFound
+	}
+	return response, nil, nil
+}
+
+func TestCachingSource(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "issuer"},
+		NotBefore:             fc.Now().Add(-time.Hour),
+		NotAfter:              fc.Now().Add(24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	issuerDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "creating issuer")
+	issuer, err := x509.ParseCertificate(issuerDER)
+	test.AssertNotError(t, err, "parsing issuer")
+
+	makeResponse := func(serial int64, thisUpdate, nextUpdate time.Time) []byte {
+		response, err := ocsp.CreateResponse(issuer, issuer, ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: big.NewInt(serial),
+			ThisUpdate:   thisUpdate,
+			NextUpdate:   nextUpdate,
+		}, key)
+		test.AssertNotError(t, err, "creating response")
+		return response
+	}
+	source := &countingSource{responses: map[string][]byte{
+		"1": makeResponse(1, fc.Now(), fc.Now().Add(4*time.Hour)),
+		"2": makeResponse(2, fc.Now(), fc.Now().Add(time.Minute)),
+		"3": makeResponse(3, fc.Now().Add(time.Hour), fc.Now().Add(4*time.Hour)),
+		"4": makeResponse(4, fc.Now(), fc.Now().Add(4*time.Hour)),
+		"6": makeResponse(6, fc.Now(), fc.Now().Add(4*time.Hour)),
+	}}
+	cs := newCachingSource(source, fc, 2, time.Hour, stats)
+
+	lookup := func(serial int64) error {
+		_, _, err := cs.Response(&ocsp.Request{
+			HashAlgorithm: crypto.SHA1,
+			SerialNumber:  big.NewInt(serial),
+		})
+		return err
+	}
+	expectLookups := func(expected int, msg string) {
+		t.Helper()
+		if source.lookups != expected {
+			t.Errorf("%s: expected %d lookups of the source, got %d", msg, expected, source.lookups)
+		}
+	}
+
+	test.AssertNotError(t, lookup(1), "looking up serial 1")
+	test.AssertNotError(t, lookup(1), "looking up serial 1")
+	expectLookups(1, "cached response")
+
+	test.AssertEquals(t, lookup(5), cfocsp.ErrNotFound)
+	test.AssertEquals(t, lookup(5), cfocsp.ErrNotFound)
+	expectLookups(3, "errors aren't cached")
+
+	test.AssertNotError(t, lookup(3), "looking up serial 3")
+	test.AssertNotError(t, lookup(3), "looking up serial 3")
+	expectLookups(5, "responses not yet valid aren't cached")
+
+	// Serial 2's nextUpdate is sooner than the TTL
+	test.AssertNotError(t, lookup(2), "looking up serial 2")
+	fc.Add(2 * time.Minute)
+	test.AssertNotError(t, lookup(2), "looking up serial 2")
+	expectLookups(7, "nextUpdate is honored")
+
+	// The cache holds only serial 1. Adding 4 and then 6 evicts 1, the least
+	// recently used.
+	test.AssertNotError(t, lookup(4), "looking up serial 4")
+	test.AssertNotError(t, lookup(6), "looking up serial 6")
+	test.AssertNotError(t, lookup(1), "looking up serial 1")
+	expectLookups(10, "least recently used response is evicted")
+	test.AssertNotError(t, lookup(6), "looking up serial 6")
+	expectLookups(10, "recently used response is kept")
+
+	fc.Add(time.Hour)
+	test.AssertNotError(t, lookup(1), "looking up serial 1")
+	expectLookups(11, "TTL is honored")
+}
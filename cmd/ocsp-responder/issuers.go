@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"net/http"
+
+	cfocsp "github.com/cloudflare/cfssl/ocsp"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// issuerConfig describes one of the issuers whose responses are served.
+type issuerConfig struct {
+	// CertFile is the path to the issuer's PEM certificate.
+	CertFile string
+	// SerialPrefixes optionally lists the serial prefix bytes of the
+	// certificates this issuer signs, as configured for the CA. Requests for
+	// serials with other prefixes are refused without a lookup.
+	SerialPrefixes []int
+}
+
+// responderIssuer is an issuer that issuerRouter routes requests to. Requests
+// name it by hashes of its subject and publicKey, the subjectPublicKey of its
+// certificate.
+type responderIssuer struct {
+	cert           *x509.Certificate
+	publicKey      []byte
+	serialPrefixes map[byte]bool
+	source         cfocsp.Source
+}
+
+// publicKeyBits returns the subjectPublicKey of cert, which an OCSP request's
+// issuerKeyHash is a hash of.
+func publicKeyBits(cert *x509.Certificate) ([]byte, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, err
+	}
+	return spki.PublicKey.RightAlign(), nil
+}
+
+// newResponderIssuer loads the issuer described by config, whose responses
+// are looked up by the source returned by makeSource, given the issuer's key
+// hash.
+func newResponderIssuer(config issuerConfig, makeSource func(keyHash []byte) (cfocsp.Source, error)) (*responderIssuer, error) {
+	cert, err := core.LoadCert(config.CertFile)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load issuer cert %s: %s", config.CertFile, err)
+	}
+	if len(cert.SubjectKeyId) == 0 {
+		return nil, fmt.Errorf("Issuer cert %s has an empty subjectKeyID", config.CertFile)
+	}
+	publicKey, err := publicKeyBits(cert)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse public key of issuer cert %s: %s", config.CertFile, err)
+	}
+	source, err := makeSource(cert.SubjectKeyId)
+	if err != nil {
+		return nil, err
+	}
+	iss := &responderIssuer{
+		cert:      cert,
+		publicKey: publicKey,
+		source:    source,
+	}
+	if len(config.SerialPrefixes) > 0 {
+		iss.serialPrefixes = make(map[byte]bool)
+		for _, prefix := range config.SerialPrefixes {
+			if prefix <= 0 || prefix > 255 {
+				return nil, fmt.Errorf("Issuer %s has an invalid serial prefix %d", config.CertFile, prefix)
+			}
+			iss.serialPrefixes[byte(prefix)] = true
+		}
+	}
+	return iss, nil
+}
+
+// matches returns true if req names this issuer by both its name hash and its
+// key hash, computed with the request's hash algorithm, and its serial has one
+// of the issuer's prefixes.
+func (iss *responderIssuer) matches(req *ocsp.Request) bool {
+	if !req.HashAlgorithm.Available() {
+		return false
+	}
+	h := req.HashAlgorithm.New()
+	_, _ = h.Write(iss.publicKey)
+	if !bytes.Equal(req.IssuerKeyHash, h.Sum(nil)) {
+		return false
+	}
+	h.Reset()
+	_, _ = h.Write(iss.cert.RawSubject)
+	if !bytes.Equal(req.IssuerNameHash, h.Sum(nil)) {
+		return false
+	}
+	if iss.serialPrefixes != nil {
+		serial := req.SerialNumber.Bytes()
+		if len(serial) == 0 || !iss.serialPrefixes[serial[0]] {
+			return false
+		}
+	}
+	return true
+}
+
+// issuerRouter is a cfocsp.Source that routes each request to the source of
+// the issuer it names, so that several intermediates can be served at once.
+// Requests for any other issuer get cfocsp.ErrNotFound, which is answered with
+// an unauthorized response rather than a misleading status.
+type issuerRouter struct {
+	issuers []*responderIssuer
+}
+
+// Response implements cfocsp.Source.
+func (ir *issuerRouter) Response(req *ocsp.Request) ([]byte, http.Header, error) {
+	for _, iss := range ir.issuers {
+		if iss.matches(req) {
+			return iss.source.Response(req)
+		}
+	}
+	return nil, nil, cfocsp.ErrNotFound
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cfocsp "github.com/cloudflare/cfssl/ocsp"
+	"golang.org/x/crypto/ocsp"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func makeIssuer(t *testing.T, name string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "creating issuer")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing issuer")
+	return cert
+}
+
+func TestIssuerRouter(t *testing.T) {
+	certA := makeIssuer(t, "issuer a")
+	certB := makeIssuer(t, "issuer b")
+	requestWithHash := func(issuer *x509.Certificate, serial int64, hash crypto.Hash) *ocsp.Request {
+		der, err := ocsp.CreateRequest(&x509.Certificate{SerialNumber: big.NewInt(serial)}, issuer, &ocsp.RequestOptions{Hash: hash})
+		test.AssertNotError(t, err, "creating request")
+		req, err := ocsp.ParseRequest(der)
+		test.AssertNotError(t, err, "parsing request")
+		return req
+	}
+	request := func(issuer *x509.Certificate, serial int64) *ocsp.Request {
+		return requestWithHash(issuer, serial, crypto.SHA1)
+	}
+	publicKey := func(issuer *x509.Certificate) []byte {
+		key, err := publicKeyBits(issuer)
+		test.AssertNotError(t, err, "parsing public key")
+		return key
+	}
+
+	router := &issuerRouter{issuers: []*responderIssuer{
+		{
+			cert:      certA,
+			publicKey: publicKey(certA),
+			source:    cfocsp.InMemorySource{"1": []byte("a1"), "2": []byte("a2")},
+		},
+		{
+			cert:           certB,
+			publicKey:      publicKey(certB),
+			serialPrefixes: map[byte]bool{0x42: true},
+			source:         cfocsp.InMemorySource{"66": []byte("b66"), "2": []byte("b2")},
+		},
+	}}
+
+	response, _, err := router.Response(request(certA, 2))
+	test.AssertNotError(t, err, "Response failed")
+	test.AssertEquals(t, string(response), "a2")
+
+	// Requests may hash the issuer with another algorithm
+	response, _, err = router.Response(requestWithHash(certA, 1, crypto.SHA256))
+	test.AssertNotError(t, err, "Response failed for a SHA-256 request")
+	test.AssertEquals(t, string(response), "a1")
+
+	// Serial 66 is 0x42
+	response, _, err = router.Response(request(certB, 66))
+	test.AssertNotError(t, err, "Response failed")
+	test.AssertEquals(t, string(response), "b66")
+
+	// Serial 2 doesn't have issuer B's prefix
+	_, _, err = router.Response(request(certB, 2))
+	test.AssertEquals(t, err, cfocsp.ErrNotFound)
+
+	// An unknown issuer
+	_, _, err = router.Response(request(makeIssuer(t, "issuer c"), 1))
+	test.AssertEquals(t, err, cfocsp.ErrNotFound)
+
+	// A known key hash with the wrong name hash
+	req := request(certA, 1)
+	req.IssuerNameHash = request(certB, 1).IssuerNameHash
+	_, _, err = router.Response(req)
+	test.AssertEquals(t, err, cfocsp.ErrNotFound)
+}
+
+func TestNewResponderIssuer(t *testing.T) {
+	var gotKeyHash []byte
+	makeSource := func(keyHash []byte) (cfocsp.Source, error) {
+		gotKeyHash = keyHash
+		return cfocsp.InMemorySource{}, nil
+	}
+	iss, err := newResponderIssuer(issuerConfig{CertFile: "./testdata/test-ca.der.pem", SerialPrefixes: []int{1, 255}}, makeSource)
+	test.AssertNotError(t, err, "newResponderIssuer failed")
+	test.AssertByteEquals(t, gotKeyHash, iss.cert.SubjectKeyId)
+	test.AssertEquals(t, len(iss.serialPrefixes), 2)
+
+	_, err = newResponderIssuer(issuerConfig{CertFile: "./testdata/test-ca.der.pem", SerialPrefixes: []int{256}}, makeSource)
+	test.AssertError(t, err, "newResponderIssuer accepted an invalid serial prefix")
+	_, err = newResponderIssuer(issuerConfig{CertFile: "./testdata/missing.pem"}, makeSource)
+	test.AssertError(t, err, "newResponderIssuer accepted a missing certificate")
+}
+
+func TestIssuerRouterDBSource(t *testing.T) {
+	makeSource := func(keyHash []byte) (cfocsp.Source, error) {
+		return NewSourceFromDatabase(mockSelector{}, keyHash, blog.NewMock())
+	}
+	iss, err := newResponderIssuer(issuerConfig{CertFile: "./testdata/test-ca.der.pem"}, makeSource)
+	test.AssertNotError(t, err, "newResponderIssuer failed")
+	router := &issuerRouter{issuers: []*responderIssuer{iss}}
+
+	// Requests hashing the issuer with SHA-256 reach the database, although
+	// the source was made with the issuer's SHA-1 subjectKeyID
+	reqDER, err := ocsp.CreateRequest(&x509.Certificate{SerialNumber: big.NewInt(1)}, iss.cert, &ocsp.RequestOptions{Hash: crypto.SHA256})
+	test.AssertNotError(t, err, "creating request")
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("POST", "/", bytes.NewReader(reqDER))
+	test.AssertNotError(t, err, "creating HTTP request")
+	cfocsp.NewResponder(router).ServeHTTP(w, r)
+	test.AssertEquals(t, w.Code, http.StatusOK)
+	test.AssertByteEquals(t, w.Body.Bytes(), resp.OCSPResponse)
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"crypto/x509"
 	"database/sql"
@@ -15,8 +14,10 @@ import (
 	"time"
 
 	cfocsp "github.com/cloudflare/cfssl/ocsp"
+	"github.com/jmhodges/clock"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/crypto/ocsp"
+	"gopkg.in/go-gorp/gorp.v2"
 
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
@@ -24,6 +25,7 @@ import (
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/metrics/measured_http"
+	"github.com/letsencrypt/boulder/redis"
 	"github.com/letsencrypt/boulder/sa"
 )
 
@@ -46,6 +48,12 @@ type DBSource struct {
 	dbMap     dbSelector
 	caKeyHash []byte
 	log       blog.Logger
+
+	// expiredGrace, if non-zero, is how long after a certificate expires its
+	// final response is still served. Later requests are answered as though
+	// the certificate was never issued.
+	expiredGrace time.Duration
+	clk          clock.Clock
 }
 
 // Since the only thing we use from gorp is the SelectOne method on the
@@ -66,16 +74,14 @@ func NewSourceFromDatabase(dbMap dbSelector, caKeyHash []byte, log blog.Logger)
 type dbResponse struct {
 	OCSPResponse    []byte
 	OCSPLastUpdated time.Time
+	// NotAfter is nil for rows written before the column was added.
+	NotAfter *time.Time
 }
 
-// Response is called by the HTTP server to handle a new OCSP request.
+// Response is called by the HTTP server to handle a new OCSP request. The
+// request is assumed to be for the source's CA, which issuerRouter checks
+// before passing it on using whichever hash algorithm the request names.
 func (src *DBSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
-	// Check that this request is for the proper CA
-	if bytes.Compare(req.IssuerKeyHash, src.caKeyHash) != 0 {
-		src.log.Debug(fmt.Sprintf("Request intended for CA Cert ID: %s", hex.EncodeToString(req.IssuerKeyHash)))
-		return nil, nil, cfocsp.ErrNotFound
-	}
-
 	serialString := core.SerialToString(req.SerialNumber)
 	src.log.Debug(fmt.Sprintf("Searching for OCSP issued by us for serial %s", serialString))
 
@@ -87,7 +93,7 @@ func (src *DBSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
 	}()
 	err := src.dbMap.SelectOne(
 		&response,
-		"SELECT ocspResponse, ocspLastUpdated FROM certificateStatus WHERE serial = :serial",
+		"SELECT ocspResponse, ocspLastUpdated, notAfter FROM certificateStatus WHERE serial = :serial",
 		map[string]interface{}{"serial": serialString},
 	)
 	if err == sql.ErrNoRows {
@@ -101,6 +107,16 @@ func (src *DBSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
 		src.log.Debug(fmt.Sprintf("OCSP Response not sent (ocspLastUpdated is zero) for CA=%s, Serial=%s", hex.EncodeToString(src.caKeyHash), serialString))
 		return nil, nil, cfocsp.ErrNotFound
 	}
+	if len(response.OCSPResponse) == 0 {
+		// The response was pruned by ocsp-updater after the certificate expired
+		src.log.Debug(fmt.Sprintf("OCSP Response not sent (response is empty) for CA=%s, Serial=%s", hex.EncodeToString(src.caKeyHash), serialString))
+		return nil, nil, cfocsp.ErrNotFound
+	}
+	if src.expiredGrace != 0 && response.NotAfter != nil &&
+		src.clk.Now().After(response.NotAfter.Add(src.expiredGrace)) {
+		src.log.Debug(fmt.Sprintf("OCSP Response not sent (certificate expired at %s) for CA=%s, Serial=%s", response.NotAfter, hex.EncodeToString(src.caKeyHash), serialString))
+		return nil, nil, cfocsp.ErrNotFound
+	}
 
 	return response.OCSPResponse, nil, nil
 }
@@ -134,6 +150,22 @@ type config struct {
 		// If DBConfig has non-empty fields, it takes precedence over this.
 		Source string
 
+		// Redis, if present, is a Redis server holding the responses written
+		// by ocsp-updater. Responses are served from it first, falling back
+		// to the database if one is configured.
+		Redis *cmd.RedisConfig
+
+		// ExpiredResponseGrace, if set, is how long after a certificate expires
+		// its final response is served from the database. Later requests get an
+		// unauthorized response, as for serials that were never issued.
+		ExpiredResponseGrace cmd.ConfigDuration
+
+		// Issuers lists the issuers whose responses are served. Requests are
+		// routed by their issuer name and key hashes, and requests naming any
+		// other issuer get an unauthorized response. If empty, the issuer is
+		// Common.IssuerCert.
+		Issuers []issuerConfig
+
 		Path          string
 		ListenAddress string
 		// MaxAge is the max-age to set in the Cache-Control response
@@ -142,6 +174,14 @@ type config struct {
 
 		ShutdownStopTimeout cmd.ConfigDuration
 
+		// CacheSize is the number of responses to keep in memory, so that hot
+		// serials are served without a database lookup. Zero disables the cache.
+		CacheSize int
+		// CacheTTL is the longest a response is served from the cache before
+		// it's looked up again, bounding how long a revocation can go unseen.
+		// Responses are never served from the cache past their nextUpdate.
+		CacheTTL cmd.ConfigDuration
+
 		Features map[string]bool
 	}
 
@@ -196,20 +236,80 @@ as generated by Boulder's single-ocsp command.
 		if dbConnect == "" {
 			dbConnect = config.Source
 		}
-		logger.Info(fmt.Sprintf("Loading OCSP Database for CA Cert: %s", c.Common.IssuerCert))
-		dbMap, err := sa.NewDbMap(dbConnect, config.DBConfig.MaxDBConns)
-		cmd.FailOnError(err, "Could not connect to database")
-		sa.SetSQLDebug(dbMap, logger)
-		go sa.ReportDbConnCount(dbMap, scope)
-		source, err = makeDBSource(dbMap, c.Common.IssuerCert, logger)
-		cmd.FailOnError(err, "Couldn't load OCSP DB")
-		// Export the MaxDBConns
-		dbConnStat := prometheus.NewGauge(prometheus.GaugeOpts{
-			Name: "max_db_connections",
-			Help: "Maximum number of DB connections allowed.",
-		})
-		scope.MustRegister(dbConnStat)
-		dbConnStat.Set(float64(config.DBConfig.MaxDBConns))
+		// Without Redis a database is required, so an empty dbConnect fails
+		// here.
+		var dbMap *gorp.DbMap
+		if dbConnect != "" || config.Redis == nil {
+			logger.Info("Loading OCSP Database")
+			dbTLS, err := config.DBConfig.TLSConfig()
+			cmd.FailOnError(err, "Couldn't load DB TLS config")
+			dbSettings := sa.DbSettings{
+				MaxOpenConns:    config.DBConfig.MaxDBConns,
+				MaxIdleConns:    config.DBConfig.MaxIdleDBConns,
+				ConnMaxLifetime: config.DBConfig.ConnMaxLifetime.Duration,
+				TLS:             dbTLS,
+			}
+			dbMap, err = sa.NewDbMapWithSettings(dbConnect, dbSettings)
+			cmd.FailOnError(err, "Could not connect to database")
+			sa.SetSQLDebug(dbMap, logger)
+			sa.InitDBMetrics(dbMap, scope, dbSettings, "primary")
+			go sa.ReportDbConnCount(dbMap, scope)
+		}
+
+		var client *redis.Client
+		var timeout time.Duration
+		var lookups *prometheus.CounterVec
+		if config.Redis != nil {
+			password, err := config.Redis.Pass()
+			cmd.FailOnError(err, "Couldn't read Redis password")
+			timeout = config.Redis.Timeout.Duration
+			if timeout <= 0 {
+				timeout = 100 * time.Millisecond
+			}
+			client, err = redis.NewClient(config.Redis.Addr, password, timeout, config.Redis.MaxIdleConns)
+			cmd.FailOnError(err, "Couldn't configure Redis client")
+			lookups = newRedisLookups(scope)
+		}
+
+		// Each issuer's responses are looked up in Redis, if configured, and
+		// then in the database.
+		makeSource := func(keyHash []byte) (cfocsp.Source, error) {
+			var src cfocsp.Source
+			if dbMap != nil {
+				dbSource, err := NewSourceFromDatabase(dbMap, keyHash, logger)
+				if err != nil {
+					return nil, err
+				}
+				dbSource.expiredGrace = config.ExpiredResponseGrace.Duration
+				dbSource.clk = cmd.Clock()
+				src = dbSource
+			}
+			if client != nil {
+				src = newRedisSource(client, timeout, src, logger, lookups)
+			}
+			return src, nil
+		}
+
+		issuers := config.Issuers
+		if len(issuers) == 0 {
+			issuers = []issuerConfig{{CertFile: c.Common.IssuerCert}}
+		}
+		router := &issuerRouter{}
+		for _, issConfig := range issuers {
+			logger.Info(fmt.Sprintf("Serving OCSP responses for CA Cert: %s", issConfig.CertFile))
+			iss, err := newResponderIssuer(issConfig, makeSource)
+			cmd.FailOnError(err, "Couldn't load issuer")
+			router.issuers = append(router.issuers, iss)
+		}
+		source = router
+	}
+
+	if config.CacheSize > 0 {
+		if config.CacheTTL.Duration <= 0 {
+			fmt.Fprintln(os.Stderr, "CacheTTL must be set when CacheSize is non-zero")
+			os.Exit(1)
+		}
+		source = newCachingSource(source, cmd.Clock(), config.CacheSize, config.CacheTTL.Duration, scope)
 	}
 
 	m := mux(scope, c.OCSPResponder.Path, source)
@@ -252,8 +352,36 @@ func (om *ocspMux) Handler(_ *http.Request) (http.Handler, string) {
 	return om.handler, "/"
 }
 
+// normalizeGETPath rewrites the path of an RFC 6960 GET request, the URL
+// encoding of the base64 encoding of the DER request, into the padded standard
+// base64 that cfocsp.Responder decodes. Clients that use the URL-safe base64
+// alphabet, omit padding, or percent-encode only some characters are all
+// accepted. Paths that can't be unescaped are returned as-is, for the responder
+// to reject.
+func normalizeGETPath(path string) string {
+	unescaped, err := url.PathUnescape(path)
+	if err != nil {
+		return path
+	}
+	b64 := strings.TrimLeft(unescaped, "/")
+	b64 = strings.NewReplacer("-", "+", "_", "/", " ", "+").Replace(b64)
+	b64 = strings.TrimRight(b64, "=")
+	if n := len(b64) % 4; n != 0 {
+		b64 += strings.Repeat("=", 4-n)
+	}
+	return b64
+}
+
 func mux(scope metrics.Scope, responderPath string, source cfocsp.Source) http.Handler {
-	stripPrefix := http.StripPrefix(responderPath, cfocsp.NewResponder(source))
+	responder := cfocsp.NewResponder(source)
+	normalized := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			r.URL.Path = normalizeGETPath(r.URL.Path)
+			r.URL.RawPath = ""
+		}
+		responder.ServeHTTP(w, r)
+	})
+	stripPrefix := http.StripPrefix(responderPath, normalized)
 	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "GET" && r.URL.Path == "/" {
 			w.Header().Set("Cache-Control", "max-age=43200") // Cache for 12 hours
@@ -14,6 +14,7 @@ import (
 	"golang.org/x/crypto/ocsp"
 
 	cfocsp "github.com/cloudflare/cfssl/ocsp"
+	"github.com/jmhodges/clock"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/test"
@@ -21,7 +22,7 @@ import (
 
 var (
 	req   = mustRead("./testdata/ocsp.req")
-	resp  = dbResponse{mustRead("./testdata/ocsp.resp"), time.Now()}
+	resp  = dbResponse{OCSPResponse: mustRead("./testdata/ocsp.resp"), OCSPLastUpdated: time.Now()}
 	stats = metrics.NewNoopScope()
 )
 
@@ -52,6 +53,10 @@ func TestMux(t *testing.T) {
 		{"POST", "/foobar/", req, resp.OCSPResponse},
 		{"GET", "/", nil, nil},
 		{"GET", "/foobar/MFMwUTBPME0wSzAJBgUrDgMCGgUABBR+5mrncpqz/PiiIGRsFqEtYHEIXQQUqEpqYwR93brm0Tm3pkVl7/Oo7KECEgO/AC2R1FW8hePAj4xp//8Jhw==", nil, resp.OCSPResponse},
+		// The same request, in unpadded base64url.
+		{"GET", "/foobar/MFMwUTBPME0wSzAJBgUrDgMCGgUABBR-5mrncpqz_PiiIGRsFqEtYHEIXQQUqEpqYwR93brm0Tm3pkVl7_Oo7KECEgO_AC2R1FW8hePAj4xp__8Jhw", nil, resp.OCSPResponse},
+		// And percent-encoded standard base64.
+		{"GET", "/foobar/MFMwUTBPME0wSzAJBgUrDgMCGgUABBR%2B5mrncpqz%2FPiiIGRsFqEtYHEIXQQUqEpqYwR93brm0Tm3pkVl7%2FOo7KECEgO%2FAC2R1FW8hePAj4xp%2F%2F8Jhw%3D%3D", nil, resp.OCSPResponse},
 	}
 	for i, mt := range mts {
 		w := httptest.NewRecorder()
@@ -70,6 +75,23 @@ func TestMux(t *testing.T) {
 	}
 }
 
+func TestNormalizeGETPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"/MEMwQTA_MD0wOzAJBgUrDgMCGgUA", "MEMwQTA/MD0wOzAJBgUrDgMCGgUA"},
+		{"MEMw-Q", "MEMw+Q=="},
+		{"MEMw+Q=", "MEMw+Q=="},
+		{"MEMw Q==", "MEMw+Q=="},
+		{"MEMw%2BQ%3D%3D", "MEMw+Q=="},
+		{"%zz", "%zz"},
+	}
+	for _, tc := range testCases {
+		test.AssertEquals(t, normalizeGETPath(tc.path), tc.expected)
+	}
+}
+
 func TestDBHandler(t *testing.T) {
 	src, err := makeDBSource(mockSelector{}, "./testdata/test-ca.der.pem", blog.NewMock())
 	if err != nil {
@@ -105,6 +127,53 @@ func TestDBHandler(t *testing.T) {
 	}
 }
 
+// fixedSelector returns the response it holds
+type fixedSelector struct {
+	response dbResponse
+}
+
+func (fs fixedSelector) SelectOne(output interface{}, _ string, _ ...interface{}) error {
+	*output.(*dbResponse) = fs.response
+	return nil
+}
+
+func TestDBSourceExpired(t *testing.T) {
+	ocspReq, err := ocsp.ParseRequest(req)
+	test.AssertNotError(t, err, "Failed to parse OCSP request")
+	fc := clock.NewFake()
+	notAfter := fc.Now().Add(-48 * time.Hour)
+	selector := &fixedSelector{dbResponse{
+		OCSPResponse:    resp.OCSPResponse,
+		OCSPLastUpdated: fc.Now().Add(-72 * time.Hour),
+		NotAfter:        &notAfter,
+	}}
+	src, err := makeDBSource(selector, "./testdata/test-ca.der.pem", blog.NewMock())
+	test.AssertNotError(t, err, "makeDBSource failed")
+	src.clk = fc
+
+	// Without a grace period expired certificates' responses are served
+	_, _, err = src.Response(ocspReq)
+	test.AssertNotError(t, err, "Response failed")
+
+	src.expiredGrace = 72 * time.Hour
+	_, _, err = src.Response(ocspReq)
+	test.AssertNotError(t, err, "Response failed within the grace period")
+
+	src.expiredGrace = 24 * time.Hour
+	_, _, err = src.Response(ocspReq)
+	test.AssertEquals(t, err, cfocsp.ErrNotFound)
+
+	// Rows without a notAfter are served
+	selector.response.NotAfter = nil
+	_, _, err = src.Response(ocspReq)
+	test.AssertNotError(t, err, "Response failed without a notAfter")
+
+	// Pruned responses aren't
+	selector.response.OCSPResponse = nil
+	_, _, err = src.Response(ocspReq)
+	test.AssertEquals(t, err, cfocsp.ErrNotFound)
+}
+
 // mockSelector always returns the same certificateStatus
 type mockSelector struct{}
 
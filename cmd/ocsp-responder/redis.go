@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	cfocsp "github.com/cloudflare/cfssl/ocsp"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+// ocspResponseGetter is the part of the *redis.Client used by redisSource.
+type ocspResponseGetter interface {
+	GetOCSPResponse(ctx context.Context, serial string) ([]byte, error)
+}
+
+// redisSource is a cfocsp.Source that serves the pre-signed responses written
+// to Redis by ocsp-updater. Responses missing from Redis, or that can't be
+// read because Redis is unavailable, are looked up in fallback if it isn't
+// nil.
+type redisSource struct {
+	client   ocspResponseGetter
+	timeout  time.Duration
+	fallback cfocsp.Source
+	log      blog.Logger
+
+	lookups *prometheus.CounterVec
+}
+
+// newRedisLookups creates and registers the counter of Redis lookups shared
+// by the redisSources for each issuer.
+func newRedisLookups(scope metrics.Scope) *prometheus.CounterVec {
+	lookups := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ocsp_redis_lookups",
+			Help: "Number of OCSP responses looked up in Redis, by result (hit, miss or error)",
+		},
+		[]string{"result"},
+	)
+	scope.MustRegister(lookups)
+	return lookups
+}
+
+func newRedisSource(client ocspResponseGetter, timeout time.Duration, fallback cfocsp.Source, log blog.Logger, lookups *prometheus.CounterVec) *redisSource {
+	return &redisSource{
+		client:   client,
+		timeout:  timeout,
+		fallback: fallback,
+		log:      log,
+		lookups:  lookups,
+	}
+}
+
+// Response is called by the HTTP server to handle a new OCSP request. Like
+// DBSource it relies on issuerRouter to only pass on requests for its issuer.
+func (src *redisSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
+	serial := core.SerialToString(req.SerialNumber)
+	ctx, cancel := context.WithTimeout(context.Background(), src.timeout)
+	defer cancel()
+	response, err := src.client.GetOCSPResponse(ctx, serial)
+	if err != nil {
+		src.lookups.WithLabelValues("error").Inc()
+		src.log.Warning(fmt.Sprintf("Looking up OCSP response for %s in Redis: %s", serial, err))
+	} else if response == nil {
+		src.lookups.WithLabelValues("miss").Inc()
+	} else {
+		src.lookups.WithLabelValues("hit").Inc()
+		return response, nil, nil
+	}
+	if src.fallback == nil {
+		return nil, nil, cfocsp.ErrNotFound
+	}
+	return src.fallback.Response(req)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	cfocsp "github.com/cloudflare/cfssl/ocsp"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type fakeGetter struct {
+	responses map[string][]byte
+	err       error
+}
+
+func (f fakeGetter) GetOCSPResponse(_ context.Context, serial string) ([]byte, error) {
+	return f.responses[serial], f.err
+}
+
+func TestRedisSource(t *testing.T) {
+	serial := big.NewInt(1)
+	getter := fakeGetter{responses: map[string][]byte{core.SerialToString(serial): []byte("from redis")}}
+	fallback := cfocsp.InMemorySource{big.NewInt(2).String(): []byte("from fallback")}
+
+	lookups := newRedisLookups(stats)
+	src := newRedisSource(getter, time.Second, fallback, blog.NewMock(), lookups)
+	response, _, err := src.Response(&ocsp.Request{SerialNumber: serial})
+	test.AssertNotError(t, err, "Response failed")
+	test.AssertEquals(t, string(response), "from redis")
+
+	response, _, err = src.Response(&ocsp.Request{SerialNumber: big.NewInt(2)})
+	test.AssertNotError(t, err, "Response failed")
+	test.AssertEquals(t, string(response), "from fallback")
+
+	// Errors from Redis fall back too
+	getter.err = errors.New("connection refused")
+	src = newRedisSource(getter, time.Second, fallback, blog.NewMock(), lookups)
+	response, _, err = src.Response(&ocsp.Request{SerialNumber: big.NewInt(2)})
+	test.AssertNotError(t, err, "Response failed")
+	test.AssertEquals(t, string(response), "from fallback")
+
+	// Without a fallback, misses aren't found
+	src = newRedisSource(getter, time.Second, nil, blog.NewMock(), lookups)
+	_, _, err = src.Response(&ocsp.Request{SerialNumber: big.NewInt(2)})
+	test.AssertEquals(t, err, cfocsp.ErrNotFound)
+}
@@ -7,19 +7,24 @@ import (
 	"crypto/x509"
 	"database/sql"
 	"encoding/base64"
+	"errors"
 	"flag"
 	"fmt"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/crypto/ocsp"
 	"golang.org/x/net/context"
 
 	"github.com/letsencrypt/boulder/akamai"
 	capb "github.com/letsencrypt/boulder/ca/proto"
+	"github.com/letsencrypt/boulder/cdn"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/features"
@@ -27,6 +32,7 @@ import (
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	pubPB "github.com/letsencrypt/boulder/publisher/proto"
+	"github.com/letsencrypt/boulder/redis"
 	"github.com/letsencrypt/boulder/sa"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
@@ -63,13 +69,57 @@ type OCSPUpdater struct {
 	// Maximum number of individual OCSP updates to attempt in parallel. Making
 	// these requests in parallel allows us to get higher total throughput.
 	parallelGenerateOCSPRequests int
+	// How far back the RecentRevocations loop looks for revoked certificates
+	recentRevocationMaxAge time.Duration
+	// How long after a certificate expires its final response is kept. Zero
+	// disables pruning.
+	expiredResponseGrace time.Duration
+	// pruneCursor is the notAfter up to which the PruneExpiredResponses loop
+	// has pruned responses, so that each tick carries on from there rather
+	// than scanning past the rows it already pruned. It is only kept in
+	// memory, so the first tick after a restart starts from the beginning.
+	pruneCursor time.Time
+	// Serial prefixes this updater is responsible for. If empty, all
+	// certificates are considered.
+	serialPrefixes []int
 	// Logs we expect to have SCT receipts for. Missing logs will be resubmitted to.
 	logs []*ctLog
+	// Backoff applied to resubmissions of a certificate to a log that hasn't
+	// returned an SCT, tracked in the ctResubmissions table with the
+	// CTResubmissionQueue feature enabled. If missingSCTBackoffFactor is zero
+	// there is none.
+	missingSCTWindow        time.Duration
+	missingSCTBackoffFactor float64
+	missingSCTBackoffMax    time.Duration
 
 	loops []*looper
 
-	ccu    *akamai.CachePurgeClient
-	issuer *x509.Certificate
+	purgeQueue *cdn.PurgeQueue
+	issuer     *x509.Certificate
+
+	// redis, if not nil, receives a copy of each stored OCSP response.
+	redis *redis.Client
+
+	// revocationLatency measures the time from a certificate's revocation to
+	// its revoked OCSP response being stored, and oldestPendingRevocation how
+	// long the oldest revocation found by the last tick has been waiting.
+	revocationLatency       prometheus.Histogram
+	oldestPendingRevocation prometheus.Gauge
+
+	// missingReceipts is the number of SCT receipts, one per certificate and
+	// log, found missing by the last MissingSCTReceipts tick, and
+	// receiptResubmissions counts the resulting submissions, by whether they
+	// were made or deferred by backoff.
+	missingReceipts      prometheus.Gauge
+	receiptResubmissions *prometheus.CounterVec
+}
+
+// resubmission is a row of the ctResubmissions table, recording the attempts
+// to get an SCT for a certificate from a log.
+type resubmission struct {
+	LogID       string    `db:"logID"`
+	Attempts    int       `db:"attempts"`
+	NextAttempt time.Time `db:"nextAttempt"`
 }
 
 // This is somewhat gross but can be pared down a bit once the publisher and this
@@ -86,15 +136,15 @@ func newUpdater(
 	issuerPath string,
 	log blog.Logger,
 ) (*OCSPUpdater, error) {
-	if config.NewCertificateBatchSize == 0 ||
-		config.OldOCSPBatchSize == 0 ||
-		config.MissingSCTBatchSize == 0 {
-		return nil, fmt.Errorf("Loop batch sizes must be non-zero")
+	if config.NewCertificateBatchSize <= 0 ||
+		config.OldOCSPBatchSize <= 0 ||
+		config.MissingSCTBatchSize <= 0 {
+		return nil, fmt.Errorf("Loop batch sizes must be positive")
 	}
-	if config.NewCertificateWindow.Duration == 0 ||
-		config.OldOCSPWindow.Duration == 0 ||
-		config.MissingSCTWindow.Duration == 0 {
-		return nil, fmt.Errorf("Loop window sizes must be non-zero")
+	if config.NewCertificateWindow.Duration <= 0 ||
+		config.OldOCSPWindow.Duration <= 0 ||
+		config.MissingSCTWindow.Duration <= 0 {
+		return nil, fmt.Errorf("Loop window sizes must be positive")
 	}
 	if config.OCSPStaleMaxAge.Duration == 0 {
 		// Default to 30 days
@@ -104,6 +154,10 @@ func newUpdater(
 		// Default to 1
 		config.ParallelGenerateOCSPRequests = 1
 	}
+	if config.RecentRevocationMaxAge.Duration == 0 {
+		// Default to 10 minutes
+		config.RecentRevocationMaxAge = cmd.ConfigDuration{Duration: 10 * time.Minute}
+	}
 
 	logs := make([]*ctLog, len(logConfigs))
 	for i, logConfig := range logConfigs {
@@ -127,8 +181,38 @@ func newUpdater(
 		ocspStaleMaxAge:              config.OCSPStaleMaxAge.Duration,
 		oldestIssuedSCT:              config.OldestIssuedSCT.Duration,
 		parallelGenerateOCSPRequests: config.ParallelGenerateOCSPRequests,
+		serialPrefixes:               config.SerialPrefixes,
+		expiredResponseGrace:         config.ExpiredResponseGrace.Duration,
+		recentRevocationMaxAge:       config.RecentRevocationMaxAge.Duration,
+		missingSCTWindow:             config.MissingSCTWindow.Duration,
+		missingSCTBackoffFactor:      config.MissingSCTBackoffFactor,
+		missingSCTBackoffMax:         config.MissingSCTBackoffMax.Duration,
 	}
 
+	updater.revocationLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ocsp_revocation_latency",
+		Help:    "Histogram of seconds from a certificate's revocation to its revoked OCSP response being stored",
+		Buckets: []float64{1, 2.5, 5, 10, 30, 60, 300, 900, 3600, 14400, 86400},
+	})
+	updater.oldestPendingRevocation = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ocsp_oldest_pending_revocation_seconds",
+		Help: "Seconds since the revocation of the oldest revoked certificate still awaiting a revoked OCSP response",
+	})
+	updater.missingReceipts = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ct_missing_receipts",
+		Help: "Number of SCT receipts, per certificate and CT log, missing at the last MissingSCTReceipts tick",
+	})
+	updater.receiptResubmissions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ct_receipt_resubmissions",
+		Help: "Number of certificates resubmitted to CT logs for missing SCT receipts, by result (submitted or deferred)",
+	}, []string{"result"})
+	stats.MustRegister(
+		updater.revocationLatency,
+		updater.oldestPendingRevocation,
+		updater.missingReceipts,
+		updater.receiptResubmissions,
+	)
+
 	// Setup loops
 	updater.loops = []*looper{
 		{
@@ -165,7 +249,33 @@ func newUpdater(
 			failureBackoffMax:    config.SignFailureBackoffMax.Duration,
 		})
 	}
-	if !features.Enabled(features.EmbedSCTs) {
+	if config.RecentRevocationBatchSize != 0 &&
+		config.RecentRevocationWindow.Duration != 0 {
+		updater.loops = append(updater.loops, &looper{
+			clk:                  clk,
+			stats:                stats.NewScope("RecentRevocations"),
+			batchSize:            config.RecentRevocationBatchSize,
+			tickDur:              config.RecentRevocationWindow.Duration,
+			tickFunc:             updater.recentRevocationsTick,
+			name:                 "RecentRevocations",
+			failureBackoffFactor: config.SignFailureBackoffFactor,
+			failureBackoffMax:    config.SignFailureBackoffMax.Duration,
+		})
+	}
+	if config.ExpiredResponseGrace.Duration != 0 {
+		updater.loops = append(updater.loops, &looper{
+			clk:       clk,
+			stats:     stats.NewScope("PruneExpiredResponses"),
+			batchSize: config.OldOCSPBatchSize,
+			tickDur:   config.OldOCSPWindow.Duration,
+			tickFunc:  updater.pruneExpiredResponsesTick,
+			name:      "PruneExpiredResponses",
+		})
+	}
+	// With EmbedSCTs the final certificates aren't submitted to CT logs at
+	// issuance, so the loop only runs if it can keep track of their
+	// resubmission in the ctResubmissions table.
+	if !features.Enabled(features.EmbedSCTs) || features.Enabled(features.CTResubmissionQueue) {
 		// The missing SCT loop doesn't need to know about failureBackoffFactor or
 		// failureBackoffMax as it doesn't make any calls to the CA
 		updater.loops = append(updater.loops, &looper{
@@ -178,9 +288,9 @@ func newUpdater(
 		})
 	}
 
-	// TODO(#1050): Remove this gate and the nil ccu checks below
+	// TODO(#1050): Remove this gate and the nil purgeQueue checks below
+	var purger cdn.Purger
 	if config.AkamaiBaseURL != "" {
-		issuer, err := core.LoadCert(issuerPath)
 		ccu, err := akamai.NewCachePurgeClient(
 			config.AkamaiBaseURL,
 			config.AkamaiClientToken,
@@ -195,8 +305,64 @@ func newUpdater(
 		if err != nil {
 			return nil, err
 		}
-		updater.ccu = ccu
+		purger = ccu
+	} else if config.CDNPurge != nil {
+		var err error
+		purger, err = newCDNPurger(*config.CDNPurge)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if purger != nil {
+		issuer, err := core.LoadCert(issuerPath)
+		if err != nil {
+			return nil, err
+		}
+		updater.purgeQueue = cdn.NewPurgeQueue(purger, log, stats)
 		updater.issuer = issuer
+
+		if config.AkamaiPurgeBatchSize != 0 || config.AkamaiPurgeBatchInterval.Duration != 0 {
+			log.Warning("AkamaiPurgeBatchSize and AkamaiPurgeBatchInterval are deprecated, use PurgeBatchSize and PurgeBatchInterval")
+		}
+		batchSize := config.PurgeBatchSize
+		if batchSize == 0 {
+			batchSize = config.AkamaiPurgeBatchSize
+		}
+		if batchSize == 0 {
+			batchSize = 100
+		}
+		interval := config.PurgeBatchInterval.Duration
+		if interval == 0 {
+			interval = config.AkamaiPurgeBatchInterval.Duration
+		}
+		if interval == 0 {
+			interval = time.Second
+		}
+		updater.loops = append(updater.loops, &looper{
+			clk:                  clk,
+			stats:                stats.NewScope("CDNPurge"),
+			batchSize:            batchSize,
+			tickDur:              interval,
+			tickFunc:             updater.purgeQueue.PurgeBatch,
+			name:                 "CDNPurge",
+			failureBackoffFactor: 1.3,
+			failureBackoffMax:    10 * time.Minute,
+		})
+	}
+
+	if config.OCSPRedis != nil {
+		password, err := config.OCSPRedis.Pass()
+		if err != nil {
+			return nil, err
+		}
+		timeout := config.OCSPRedis.Timeout.Duration
+		if timeout <= 0 {
+			timeout = 100 * time.Millisecond
+		}
+		updater.redis, err = redis.NewClient(config.OCSPRedis.Addr, password, timeout, config.OCSPRedis.MaxIdleConns)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &updater, nil
@@ -233,9 +399,29 @@ func generateOCSPCacheKeys(req []byte, ocspServer string) []string {
 	}
 }
 
-// sendPurge should only be called as a Goroutine as it will block until the purge
-// request is successful
-func (updater *OCSPUpdater) sendPurge(der []byte) {
+// newCDNPurger returns the cdn.Purger for the configured provider.
+func newCDNPurger(config cmd.CDNPurgeConfig) (cdn.Purger, error) {
+	switch config.Provider {
+	case "fastly":
+		return cdn.NewFastlyPurger(config.FastlyAPIKey), nil
+	case "cloudflare":
+		if config.CloudflareZoneID == "" || config.CloudflareAPIToken == "" {
+			return nil, fmt.Errorf("Cloudflare purging requires a zone ID and API token")
+		}
+		return cdn.NewCloudflarePurger(config.CloudflareZoneID, config.CloudflareAPIToken), nil
+	case "webhook":
+		if config.WebhookURL == "" {
+			return nil, fmt.Errorf("Webhook purging requires a URL")
+		}
+		return cdn.NewWebhookPurger(config.WebhookURL), nil
+	default:
+		return nil, fmt.Errorf("Unknown CDN purge provider %q", config.Provider)
+	}
+}
+
+// queuePurge queues the cache keys of the OCSP responses for the certificate
+// der to be purged from the CDN by the CDNPurge loop.
+func (updater *OCSPUpdater) queuePurge(der []byte) {
 	cert, err := x509.ParseCertificate(der)
 	if err != nil {
 		updater.log.AuditErr(fmt.Sprintf("Failed to parse certificate for cache purge: %s", err))
@@ -255,13 +441,33 @@ func (updater *OCSPUpdater) sendPurge(der []byte) {
 			ocspServer += "/"
 		}
 		// Generate GET url
-		urls = append(generateOCSPCacheKeys(req, ocspServer))
+		urls = append(urls, generateOCSPCacheKeys(req, ocspServer)...)
 	}
 
-	err = updater.ccu.Purge(urls)
-	if err != nil {
-		updater.log.AuditErr(fmt.Sprintf("Failed to purge OCSP response from CDN: %s", err))
+	updater.purgeQueue.Add(urls)
+}
+
+// serialPrefixFilter returns the condition and named arguments restricting a
+// certificateStatus query to the serial prefixes this updater is responsible
+// for. The condition is prefixed with AND so it may be appended directly to a
+// WHERE clause, and is empty if the updater isn't sharded.
+func (updater *OCSPUpdater) serialPrefixFilter(column string) (string, map[string]interface{}) {
+	filter, args := sa.SerialPrefixFilter(column, updater.serialPrefixes)
+	if filter != "" {
+		filter = "AND " + filter
 	}
+	return filter, args
+}
+
+// noOCSPFilter returns a condition excluding certificates that were issued
+// without an OCSP URL, such as short-lived certificates, from a
+// certificateStatus query. Like serialPrefixFilter it is prefixed with AND,
+// and it is empty unless the ShortLivedCertificates feature is enabled.
+func noOCSPFilter(column string) string {
+	if !features.Enabled(features.ShortLivedCertificates) {
+		return ""
+	}
+	return "AND NOT " + column + " "
 }
 
 func (updater *OCSPUpdater) findStaleOCSPResponses(oldestLastUpdatedTime time.Time, batchSize int) ([]core.CertificateStatus, error) {
@@ -271,6 +477,10 @@ func (updater *OCSPUpdater) findStaleOCSPResponses(oldestLastUpdatedTime time.Ti
 	now := updater.clk.Now()
 	maxAgeCutoff := now.Add(-updater.ocspStaleMaxAge)
 
+	prefixFilter, args := updater.serialPrefixFilter("cs.serial")
+	args["lastUpdate"] = oldestLastUpdatedTime
+	args["maxAge"] = maxAgeCutoff
+	args["limit"] = batchSize
 	_, err := updater.dbMap.Select(
 		&statuses,
 		`SELECT
@@ -282,13 +492,10 @@ func (updater *OCSPUpdater) findStaleOCSPResponses(oldestLastUpdatedTime time.Ti
 				WHERE cs.ocspLastUpdated > :maxAge
 				AND cs.ocspLastUpdated < :lastUpdate
 				AND NOT cs.isExpired
+				`+noOCSPFilter("cs.noOCSP")+prefixFilter+`
 				ORDER BY cs.ocspLastUpdated ASC
 				LIMIT :limit`,
-		map[string]interface{}{
-			"lastUpdate": oldestLastUpdatedTime,
-			"maxAge":     maxAgeCutoff,
-			"limit":      batchSize,
-		},
+		args,
 	)
 	if err == sql.ErrNoRows {
 		return statuses, nil
@@ -297,11 +504,12 @@ func (updater *OCSPUpdater) findStaleOCSPResponses(oldestLastUpdatedTime time.Ti
 }
 
 func (updater *OCSPUpdater) getCertificatesWithMissingResponses(batchSize int) ([]core.CertificateStatus, error) {
-	const query = "WHERE ocspLastUpdated = 0 LIMIT ?"
+	prefixFilter, args := updater.serialPrefixFilter("serial")
+	args["limit"] = batchSize
 	statuses, err := sa.SelectCertificateStatuses(
 		updater.dbMap,
-		query,
-		batchSize,
+		"WHERE ocspLastUpdated = 0 "+noOCSPFilter("noOCSP")+prefixFilter+" LIMIT :limit",
+		args,
 	)
 	if err == sql.ErrNoRows {
 		return statuses, nil
@@ -365,19 +573,19 @@ func (updater *OCSPUpdater) generateRevokedResponse(ctx context.Context, status
 	status.OCSPResponse = ocspResponse
 
 	// Purge OCSP response from CDN, gated on client having been initialized
-	if updater.ccu != nil {
-		go updater.sendPurge(cert.DER)
+	if updater.purgeQueue != nil {
+		updater.queuePurge(cert.DER)
 	}
 
 	return &status, nil
 }
 
-func (updater *OCSPUpdater) storeResponse(status *core.CertificateStatus) error {
+func (updater *OCSPUpdater) storeResponse(ctx context.Context, status *core.CertificateStatus) error {
 	// Update the certificateStatus table with the new OCSP response, the status
 	// WHERE is used make sure we don't overwrite a revoked response with a one
 	// containing a 'good' status and that we don't do the inverse when the OCSP
 	// status should be 'good'.
-	_, err := updater.dbMap.Exec(
+	result, err := updater.dbMap.Exec(
 		`UPDATE certificateStatus
 		 SET ocspResponse=?,ocspLastUpdated=?
 		 WHERE serial=?
@@ -387,7 +595,42 @@ func (updater *OCSPUpdater) storeResponse(status *core.CertificateStatus) error
 		status.Serial,
 		string(status.Status),
 	)
-	return err
+	if err != nil || updater.redis == nil {
+		return err
+	}
+	// Only copy the response to Redis if it was stored, so that Redis never
+	// holds a response the database refused.
+	if rows, err := result.RowsAffected(); err != nil || rows != 1 {
+		return err
+	}
+	updater.storeRedisResponse(ctx, status)
+	return nil
+}
+
+// storeRedisResponse writes the OCSP response in status to Redis, to expire at
+// its nextUpdate. Failures are logged rather than returned, since the
+// responder falls back to the database.
+func (updater *OCSPUpdater) storeRedisResponse(ctx context.Context, status *core.CertificateStatus) {
+	parsed, err := ocsp.ParseResponse(status.OCSPResponse, nil)
+	if err == nil {
+		expires := parsed.NextUpdate
+		// Like the database copy, a final response isn't kept past the grace
+		// period.
+		if updater.expiredResponseGrace != 0 && !status.NotAfter.IsZero() {
+			pruneAt := status.NotAfter.Add(updater.expiredResponseGrace)
+			if pruneAt.Before(expires) {
+				expires = pruneAt
+			}
+		}
+		if !expires.After(updater.clk.Now()) {
+			return
+		}
+		err = updater.redis.StoreOCSPResponse(ctx, status.Serial, status.OCSPResponse, expires.Sub(updater.clk.Now()))
+	}
+	if err != nil {
+		updater.stats.Inc("Errors.StoreRedisResponse", 1)
+		updater.log.AuditErr(fmt.Sprintf("Failed to store OCSP response for %s in Redis: %s", status.Serial, err))
+	}
 }
 
 // markExpired updates a given CertificateStatus to have `isExpired` set.
@@ -416,84 +659,214 @@ func (updater *OCSPUpdater) newCertificateTick(ctx context.Context, batchSize in
 	return updater.generateOCSPResponses(ctx, statuses, updater.stats.NewScope("newCertificateTick"))
 }
 
-func (updater *OCSPUpdater) findRevokedCertificatesToUpdate(batchSize int) ([]core.CertificateStatus, error) {
-	const query = "WHERE status = ? AND ocspLastUpdated <= revokedDate LIMIT ?"
+// findRevokedCertificatesToUpdate returns the statuses of certificates revoked
+// since the given time, or at any time if it's zero, whose revoked responses
+// haven't been generated yet, oldest revocation first. The status_revokedDate
+// index of the AddCertStatusRevokedDateIndex migration serves the query.
+func (updater *OCSPUpdater) findRevokedCertificatesToUpdate(since time.Time, batchSize int) ([]core.CertificateStatus, error) {
+	prefixFilter, args := updater.serialPrefixFilter("serial")
+	args["status"] = string(core.OCSPStatusRevoked)
+	args["limit"] = batchSize
+	sinceFilter := ""
+	if !since.IsZero() {
+		sinceFilter = " AND revokedDate >= :since"
+		args["since"] = since
+	}
 	statuses, err := sa.SelectCertificateStatuses(
 		updater.dbMap,
-		query,
-		string(core.OCSPStatusRevoked),
-		batchSize,
+		"WHERE status = :status"+sinceFilter+" AND ocspLastUpdated <= revokedDate "+noOCSPFilter("noOCSP")+prefixFilter+" ORDER BY revokedDate ASC LIMIT :limit",
+		args,
 	)
 	return statuses, err
 }
 
 func (updater *OCSPUpdater) revokedCertificatesTick(ctx context.Context, batchSize int) error {
-	statuses, err := updater.findRevokedCertificatesToUpdate(batchSize)
+	statuses, err := updater.findRevokedCertificatesToUpdate(time.Time{}, batchSize)
 	if err != nil {
 		updater.stats.Inc("Errors.FindRevokedCertificates", 1)
 		updater.log.AuditErr(fmt.Sprintf("Failed to find revoked certificates: %s", err))
 		return err
 	}
+	// The statuses are ordered by revocation date, so the first has waited
+	// longest.
+	var oldestPending time.Duration
+	if len(statuses) > 0 {
+		oldestPending = updater.clk.Now().Sub(statuses[0].RevokedDate)
+	}
+	updater.oldestPendingRevocation.Set(oldestPending.Seconds())
 
-	for _, status := range statuses {
+	return updater.updateRevokedResponses(ctx, statuses)
+}
+
+// recentRevocationsTick generates and stores the revoked responses of the
+// certificates revoked in the last recentRevocationMaxAge, so that a backlog
+// of older revocations in the RevokedCertificates loop doesn't hold them up.
+func (updater *OCSPUpdater) recentRevocationsTick(ctx context.Context, batchSize int) error {
+	since := updater.clk.Now().Add(-updater.recentRevocationMaxAge)
+	statuses, err := updater.findRevokedCertificatesToUpdate(since, batchSize)
+	if err != nil {
+		updater.stats.Inc("Errors.FindRecentRevocations", 1)
+		updater.log.AuditErr(fmt.Sprintf("Failed to find recently revoked certificates: %s", err))
+		return err
+	}
+	return updater.updateRevokedResponses(ctx, statuses)
+}
+
+// updateRevokedResponses generates and stores revoked responses for statuses,
+// observing the latency of each from its certificate's revocation.
+func (updater *OCSPUpdater) updateRevokedResponses(ctx context.Context, statuses []core.CertificateStatus) error {
+	failed := updater.processStatuses(ctx, statuses, updater.stats, func(ctx context.Context, status core.CertificateStatus) error {
 		meta, err := updater.generateRevokedResponse(ctx, status)
 		if err != nil {
-			updater.log.AuditErr(fmt.Sprintf("Failed to generate revoked OCSP response: %s", err))
+			updater.log.AuditErr(fmt.Sprintf("Failed to generate revoked OCSP response for %s: %s", status.Serial, err))
 			updater.stats.Inc("Errors.RevokedResponseGeneration", 1)
 			return err
 		}
-		err = updater.storeResponse(meta)
+		err = updater.storeResponse(ctx, meta)
 		if err != nil {
 			updater.stats.Inc("Errors.StoreRevokedResponse", 1)
-			updater.log.AuditErr(fmt.Sprintf("Failed to store OCSP response: %s", err))
-			continue
+			updater.log.AuditErr(fmt.Sprintf("Failed to store OCSP response for %s: %s", status.Serial, err))
+			return err
 		}
+		updater.revocationLatency.Observe(updater.clk.Now().Sub(status.RevokedDate).Seconds())
+		return nil
+	})
+	if failed > 0 && failed == len(statuses) {
+		return errBatchFailed
 	}
 	return nil
 }
 
+// errBatchFailed is returned by a tick when none of the responses in its batch
+// could be updated, so that the looper backs off, e.g. while the CA is down.
+var errBatchFailed = errors.New("failed to update every OCSP response in the batch")
+
+// processStatuses calls process for each of statuses from a pool of
+// parallelGenerateOCSPRequests workers. A failure is left to process to log
+// and record, and doesn't stop the rest of the batch, so that one bad serial
+// can't hold up a backlog. It returns the number of statuses that failed.
+func (updater *OCSPUpdater) processStatuses(
+	ctx context.Context,
+	statuses []core.CertificateStatus,
+	stats metrics.Scope,
+	process func(context.Context, core.CertificateStatus) error,
+) int {
+	workers := updater.parallelGenerateOCSPRequests
+	if workers > len(statuses) {
+		workers = len(statuses)
+	}
+	work := make(chan core.CertificateStatus)
+	var failed int64
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for status := range work {
+				start := updater.clk.Now()
+				if err := process(ctx, status); err != nil {
+					atomic.AddInt64(&failed, 1)
+				}
+				stats.TimingDuration("GenerateAndStore", updater.clk.Since(start))
+			}
+		}()
+	}
+	for _, status := range statuses {
+		work <- status
+	}
+	close(work)
+	wg.Wait()
+	return int(failed)
+}
+
+// generateOCSPResponses generates and stores a new OCSP response for each of
+// statuses in parallel. It returns errBatchFailed only if all of them failed.
 func (updater *OCSPUpdater) generateOCSPResponses(ctx context.Context, statuses []core.CertificateStatus, stats metrics.Scope) error {
-	// Use the semaphore pattern from
-	// https://github.com/golang/go/wiki/BoundingResourceUse to send a number of
-	// GenerateOCSP / storeResponse requests in parallel, while limiting the total number of
-	// outstanding requests. The number of outstanding requests equals the
-	// capacity of the channel.
-	sem := make(chan int, updater.parallelGenerateOCSPRequests)
-	wait := func() {
-		sem <- 1 // Block until there's capacity.
-	}
-	done := func(start time.Time) {
-		<-sem // Indicate there's more capacity.
-		stats.TimingDuration("GenerateAndStore", time.Since(start))
-	}
-
-	work := func(status core.CertificateStatus) {
-		defer done(updater.clk.Now())
+	failed := updater.processStatuses(ctx, statuses, stats, func(ctx context.Context, status core.CertificateStatus) error {
 		meta, err := updater.generateResponse(ctx, status)
 		if err != nil {
-			updater.log.AuditErr(fmt.Sprintf("Failed to generate OCSP response: %s", err))
+			updater.log.AuditErr(fmt.Sprintf("Failed to generate OCSP response for %s: %s", status.Serial, err))
 			stats.Inc("Errors.ResponseGeneration", 1)
-			return
+			return err
 		}
 		stats.Inc("GeneratedResponses", 1)
-		err = updater.storeResponse(meta)
+		err = updater.storeResponse(ctx, meta)
 		if err != nil {
-			updater.log.AuditErr(fmt.Sprintf("Failed to store OCSP response: %s", err))
+			updater.log.AuditErr(fmt.Sprintf("Failed to store OCSP response for %s: %s", status.Serial, err))
 			stats.Inc("Errors.StoreResponse", 1)
-			return
+			return err
 		}
 		stats.Inc("StoredResponses", 1)
+		return nil
+	})
+	stats.Inc("FailedResponses", int64(failed))
+	if failed > 0 && failed == len(statuses) {
+		return errBatchFailed
 	}
+	return nil
+}
 
-	for _, status := range statuses {
-		wait()
-		go work(status)
+// pruneExpiredResponsesTick deletes the final OCSP responses of certificates
+// that expired more than expiredResponseGrace ago. They are no longer
+// re-signed once expired, and the responder stops serving them after the
+// same grace period, so keeping them only costs space.
+//
+// Each tick finds the notAfter of the last of the next batch of responses to
+// prune, in notAfter order from pruneCursor using the isExpired_notAfter_idx
+// index, and prunes up to it. Certificates are marked expired well within the
+// grace period, so none are left behind the cursor.
+func (updater *OCSPUpdater) pruneExpiredResponsesTick(ctx context.Context, batchSize int) error {
+	prefixFilter, args := updater.serialPrefixFilter("serial")
+	args["cursor"] = updater.pruneCursor
+	args["cutoff"] = updater.clk.Now().Add(-updater.expiredResponseGrace)
+	args["limit"] = batchSize
+	var batch []struct {
+		NotAfter time.Time `db:"notAfter"`
+	}
+	_, err := updater.dbMap.Select(
+		&batch,
+		`SELECT notAfter
+		FROM certificateStatus
+		WHERE isExpired
+		AND notAfter >= :cursor
+		AND notAfter < :cutoff
+		AND ocspResponse IS NOT NULL
+		`+prefixFilter+`
+		ORDER BY notAfter ASC
+		LIMIT :limit`,
+		args,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		updater.stats.Inc("Errors.PruneExpiredResponses", 1)
+		updater.log.AuditErr(fmt.Sprintf("Failed to find expired OCSP responses to prune: %s", err))
+		return err
 	}
-	// Block until the channel reaches its full capacity again, indicating each
-	// goroutine has completed.
-	for i := 0; i < updater.parallelGenerateOCSPRequests; i++ {
-		wait()
+	if len(batch) == 0 {
+		return nil
 	}
+	last := batch[len(batch)-1].NotAfter
+	args["last"] = last
+	result, err := updater.dbMap.Exec(
+		`UPDATE certificateStatus
+		SET ocspResponse = NULL
+		WHERE isExpired
+		AND notAfter >= :cursor
+		AND notAfter <= :last
+		AND ocspResponse IS NOT NULL
+		`+prefixFilter,
+		args,
+	)
+	if err != nil {
+		updater.stats.Inc("Errors.PruneExpiredResponses", 1)
+		updater.log.AuditErr(fmt.Sprintf("Failed to prune expired OCSP responses: %s", err))
+		return err
+	}
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	updater.stats.Inc("PrunedResponses", pruned)
+	updater.pruneCursor = last
 	return nil
 }
 
@@ -589,7 +962,13 @@ func (updater *OCSPUpdater) missingLogs(logIDs []string) []*ctLog {
 }
 
 // missingReceiptsTick looks for certificates without the correct number of SCT
-// receipts and retrieves them
+// receipts and retrieves them, resubmitting each certificate to the configured
+// logs whose receipts are missing. The publisher stores the receipts of final
+// certificates, so with EmbedSCTs it's the final certificate, rather than the
+// precertificate whose SCTs it embeds, that gets each log's receipt. With the
+// CTResubmissionQueue feature enabled the attempts for each certificate and
+// log are kept in the ctResubmissions table and backed off between, otherwise
+// the certificate is resubmitted every tick.
 func (updater *OCSPUpdater) missingReceiptsTick(ctx context.Context, batchSize int) error {
 	now := updater.clk.Now()
 	since := now.Add(-updater.oldestIssuedSCT)
@@ -598,6 +977,25 @@ func (updater *OCSPUpdater) missingReceiptsTick(ctx context.Context, batchSize i
 		updater.log.AuditErr(fmt.Sprintf("Failed to get certificate serials: %s", err))
 		return err
 	}
+	queue := features.Enabled(features.CTResubmissionQueue)
+	if queue {
+		// Certificates that have aged out of the window are no longer resubmitted
+		// to, so neither are their resubmissions kept. A row is created no
+		// earlier than its certificate is issued.
+		_, err = updater.dbMap.Exec(
+			"DELETE FROM ctResubmissions WHERE created < ?",
+			since,
+		)
+		if err != nil {
+			updater.log.AuditErr(fmt.Sprintf("Failed to delete old CT resubmissions: %s", err))
+			return err
+		}
+	}
+
+	var missing int
+	defer func() {
+		updater.missingReceipts.Set(float64(missing))
+	}()
 
 	for _, serial := range serials {
 		// First find the logIDs that have provided a SCT for the serial
@@ -609,10 +1007,22 @@ func (updater *OCSPUpdater) missingReceiptsTick(ctx context.Context, batchSize i
 		}
 
 		// Next, check if any of the configured CT logs are missing from the list of
-		// logs that have given SCTs for this serial
+		// logs that have given SCTs for this serial, and which of those are due
+		// to be resubmitted to
 		missingLogs := updater.missingLogs(logIDs)
-		if len(missingLogs) == 0 {
-			// If all of the logs have provided a SCT we're done for this serial
+		missing += len(missingLogs)
+		dueLogs := missingLogs
+		if queue {
+			dueLogs, err = updater.dueResubmissions(serial, logIDs, missingLogs, now)
+			if err != nil {
+				updater.log.AuditErr(fmt.Sprintf(
+					"Failed to update CT resubmissions for certificate: %s", err))
+				continue
+			}
+		}
+		if len(dueLogs) == 0 {
+			// If all of the logs have provided a SCT, or are backing off, we're
+			// done for this serial
 			continue
 		}
 
@@ -623,13 +1033,88 @@ func (updater *OCSPUpdater) missingReceiptsTick(ctx context.Context, batchSize i
 			updater.log.AuditErr(fmt.Sprintf("Failed to get certificate: %s", err))
 			continue
 		}
-		for _, log := range missingLogs {
+		for _, log := range dueLogs {
+			updater.receiptResubmissions.WithLabelValues("submitted").Inc()
 			_ = updater.pubc.SubmitToSingleCT(ctx, log.uri, log.key, cert.DER)
 		}
 	}
 	return nil
 }
 
+// dueResubmissions returns the logs among missingLogs that serial is due to be
+// resubmitted to at now, recording the attempts in the ctResubmissions table,
+// and deletes the rows of the logs in logIDs that have since returned an SCT.
+func (updater *OCSPUpdater) dueResubmissions(serial string, logIDs []string, missingLogs []*ctLog, now time.Time) ([]*ctLog, error) {
+	var rows []resubmission
+	_, err := updater.dbMap.Select(
+		&rows,
+		`SELECT logID, attempts, nextAttempt
+		FROM ctResubmissions
+		WHERE serial = ?`,
+		serial,
+	)
+	if err != nil {
+		return nil, err
+	}
+	resubmissions := make(map[string]resubmission, len(rows))
+	for _, r := range rows {
+		resubmissions[r.LogID] = r
+	}
+
+	for _, logID := range logIDs {
+		if _, present := resubmissions[logID]; !present {
+			continue
+		}
+		_, err = updater.dbMap.Exec(
+			"DELETE FROM ctResubmissions WHERE serial = ? AND logID = ?",
+			serial,
+			logID,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var due []*ctLog
+	for _, log := range missingLogs {
+		r := resubmissions[log.logID]
+		if now.Before(r.NextAttempt) {
+			updater.receiptResubmissions.WithLabelValues("deferred").Inc()
+			continue
+		}
+		attempts := r.Attempts + 1
+		_, err = updater.dbMap.Exec(
+			`INSERT INTO ctResubmissions (serial, logID, attempts, nextAttempt, created)
+			VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE attempts = VALUES(attempts), nextAttempt = VALUES(nextAttempt)`,
+			serial,
+			log.logID,
+			attempts,
+			now.Add(updater.resubmissionBackoff(attempts)),
+			now,
+		)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, log)
+	}
+	return due, nil
+}
+
+// resubmissionBackoff returns how long to wait after the given number of
+// attempts to get an SCT for a certificate before trying again. Without a
+// backoff factor the certificate is resubmitted on the next tick.
+func (updater *OCSPUpdater) resubmissionBackoff(attempts int) time.Duration {
+	if updater.missingSCTBackoffFactor == 0 {
+		return 0
+	}
+	max := updater.missingSCTBackoffMax
+	if max == 0 {
+		max = updater.oldestIssuedSCT
+	}
+	return core.RetryBackoff(attempts, updater.missingSCTWindow, max, updater.missingSCTBackoffFactor)
+}
+
 type looper struct {
 	clk                  clock.Clock
 	stats                metrics.Scope
@@ -747,6 +1232,8 @@ func setupClients(c cmd.OCSPUpdaterConfig, stats metrics.Scope) (
 
 func main() {
 	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	simulate := flag.Bool("simulate", false, "Print a forecast of the number of OCSP responses to sign per hour as CSV and exit, without signing anything")
+	simulateDays := flag.Int("days", 7, "Number of days to forecast in -simulate mode")
 	flag.Parse()
 	if *configFile == "" {
 		flag.Usage()
@@ -768,10 +1255,39 @@ func main() {
 	// Configure DB
 	dbURL, err := conf.DBConfig.URL()
 	cmd.FailOnError(err, "Couldn't load DB URL")
-	dbMap, err := sa.NewDbMap(dbURL, conf.DBConfig.MaxDBConns)
+	dbTLS, err := conf.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    conf.DBConfig.MaxDBConns,
+		MaxIdleConns:    conf.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: conf.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
 	cmd.FailOnError(err, "Could not connect to database")
 	go sa.ReportDbConnCount(dbMap, scope)
 
+	if *simulate {
+		if *simulateDays < 1 {
+			cmd.FailOnError(fmt.Errorf("-days must be at least 1"), "Invalid -days")
+		}
+		updater, err := newUpdater(scope, cmd.Clock(), dbMap, nil, nil, nil, conf, nil, c.Common.IssuerCert, logger)
+		cmd.FailOnError(err, "Failed to create updater")
+		forecast, err := updater.forecastSigningLoad(*simulateDays, conf.OldOCSPBatchSize)
+		cmd.FailOnError(err, "Failed to forecast signing load")
+		err = forecast.writeCSV(os.Stdout)
+		cmd.FailOnError(err, "Failed to write forecast")
+
+		// The OldOCSPResponses loop signs at most one batch per window, which
+		// newUpdater has checked is positive. The summary goes to stderr so
+		// that stdout is only the CSV.
+		capacity := int64(float64(conf.OldOCSPBatchSize) * float64(time.Hour) / float64(conf.OldOCSPWindow.Duration))
+		peakHour, peak := forecast.peak()
+		fmt.Fprintf(os.Stderr,
+			"Forecast for %d days: peak of %d responses in the hour starting %s, capacity %d per hour, %d hours over capacity\n",
+			*simulateDays, peak, peakHour.UTC().Format(time.RFC3339), capacity, forecast.overCapacity(capacity))
+		return
+	}
+
 	cac, pubc, sac := setupClients(conf, scope)
 
 	updater, err := newUpdater(
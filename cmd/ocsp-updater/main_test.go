@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -186,7 +187,7 @@ func TestGenerateAndStoreOCSPResponse(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	parsedCert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	status, err := sa.GetCertificateStatus(ctx, core.SerialToString(parsedCert.SerialNumber))
@@ -194,12 +195,12 @@ func TestGenerateAndStoreOCSPResponse(t *testing.T) {
 
 	meta, err := updater.generateResponse(ctx, status)
 	test.AssertNotError(t, err, "Couldn't generate OCSP response")
-	err = updater.storeResponse(meta)
+	err = updater.storeResponse(ctx, meta)
 	test.AssertNotError(t, err, "Couldn't store certificate status")
 
 	secondMeta, err := updater.generateRevokedResponse(ctx, status)
 	test.AssertNotError(t, err, "Couldn't generate revoked OCSP response")
-	err = updater.storeResponse(secondMeta)
+	err = updater.storeResponse(ctx, secondMeta)
 	test.AssertNotError(t, err, "Couldn't store certificate status")
 
 	newStatus, err := sa.GetCertificateStatus(ctx, status.Serial)
@@ -214,11 +215,11 @@ func TestGenerateOCSPResponses(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	parsedCertA, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCertA.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCertA.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 	parsedCertB, err := core.LoadCert("test-cert-b.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCertB.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCertB.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert-b.pem")
 
 	// We need to set a fake "ocspLastUpdated" value for the two certs we created
@@ -256,6 +257,40 @@ func TestGenerateOCSPResponses(t *testing.T) {
 	test.AssertEquals(t, len(certs), 0)
 }
 
+func TestProcessStatuses(t *testing.T) {
+	updater := &OCSPUpdater{clk: clock.NewFake(), parallelGenerateOCSPRequests: 3}
+	var statuses []core.CertificateStatus
+	for i := 0; i < 10; i++ {
+		statuses = append(statuses, core.CertificateStatus{Serial: fmt.Sprintf("%02d", i)})
+	}
+
+	var mu sync.Mutex
+	var running, maxRunning int
+	processed := make(map[string]bool)
+	failed := updater.processStatuses(ctx, statuses, metrics.NewNoopScope(), func(_ context.Context, status core.CertificateStatus) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		processed[status.Serial] = true
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if status.Serial == "03" || status.Serial == "07" {
+			return errors.New("signing failed")
+		}
+		return nil
+	})
+	// Failures are skipped without stopping the rest of the batch
+	test.AssertEquals(t, failed, 2)
+	test.AssertEquals(t, len(processed), 10)
+	test.Assert(t, maxRunning <= 3, fmt.Sprintf("%d workers ran at once, expected at most 3", maxRunning))
+	test.Assert(t, maxRunning > 1, "statuses weren't processed in parallel")
+}
+
 func TestFindStaleOCSPResponses(t *testing.T) {
 	updater, sa, dbMap, fc, cleanUp := setup(t)
 	defer cleanUp()
@@ -263,7 +298,7 @@ func TestFindStaleOCSPResponses(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	parsedCert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	// We need to set a fake "ocspLastUpdated" value for the cert we created
@@ -285,7 +320,7 @@ func TestFindStaleOCSPResponses(t *testing.T) {
 
 	meta, err := updater.generateResponse(ctx, status)
 	test.AssertNotError(t, err, "Couldn't generate OCSP response")
-	err = updater.storeResponse(meta)
+	err = updater.storeResponse(ctx, meta)
 	test.AssertNotError(t, err, "Couldn't store OCSP response")
 
 	certs, err = updater.findStaleOCSPResponses(earliest, 10)
@@ -300,11 +335,11 @@ func TestFindStaleOCSPResponsesStaleMaxAge(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	parsedCertA, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCertA.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCertA.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 	parsedCertB, err := core.LoadCert("test-cert-b.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCertB.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCertB.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert-b.pem")
 
 	// Set a "ocspLastUpdated" value of 3 days ago for parsedCertA
@@ -340,7 +375,7 @@ func TestGetCertificatesWithMissingResponses(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	cert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, cert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, cert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	statuses, err := updater.getCertificatesWithMissingResponses(10)
@@ -355,17 +390,17 @@ func TestFindRevokedCertificatesToUpdate(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	cert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, cert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, cert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
-	statuses, err := updater.findRevokedCertificatesToUpdate(10)
+	statuses, err := updater.findRevokedCertificatesToUpdate(time.Time{}, 10)
 	test.AssertNotError(t, err, "Failed to find revoked certificates")
 	test.AssertEquals(t, len(statuses), 0)
 
 	err = sa.MarkCertificateRevoked(ctx, core.SerialToString(cert.SerialNumber), revocation.KeyCompromise)
 	test.AssertNotError(t, err, "Failed to revoke certificate")
 
-	statuses, err = updater.findRevokedCertificatesToUpdate(10)
+	statuses, err = updater.findRevokedCertificatesToUpdate(time.Time{}, 10)
 	test.AssertNotError(t, err, "Failed to find revoked certificates")
 	test.AssertEquals(t, len(statuses), 1)
 }
@@ -377,7 +412,7 @@ func TestNewCertificateTick(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	parsedCert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	prev := fc.Now().Add(-time.Hour)
@@ -396,7 +431,7 @@ func TestOldOCSPResponsesTick(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	parsedCert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	updater.ocspMinTimeToExpiry = 1 * time.Hour
@@ -428,7 +463,7 @@ func TestOldOCSPResponsesTickIsExpired(t *testing.T) {
 	serial := core.SerialToString(parsedCert.SerialNumber)
 
 	// Add a new test certificate
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	// We need to set a fake "ocspLastUpdated" value for the cert we created
@@ -463,6 +498,44 @@ func TestOldOCSPResponsesTickIsExpired(t *testing.T) {
 	test.AssertEquals(t, cs.IsExpired, true)
 }
 
+func TestPruneExpiredResponsesTick(t *testing.T) {
+	updater, sa, dbMap, fc, cleanUp := setup(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	parsedCert, err := core.LoadCert("test-cert.pem")
+	test.AssertNotError(t, err, "Couldn't read test certificate")
+	serial := core.SerialToString(parsedCert.SerialNumber)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, []byte{1, 2, 3}, nil)
+	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
+	_, err = dbMap.Exec("UPDATE certificateStatus SET isExpired = TRUE WHERE serial = ?", serial)
+	test.AssertNotError(t, err, "Couldn't mark certificate expired")
+
+	updater.expiredResponseGrace = 24 * time.Hour
+	responseLength := func() int {
+		cs, err := sa.GetCertificateStatus(ctx, serial)
+		test.AssertNotError(t, err, "Couldn't get certificate status")
+		return len(cs.OCSPResponse)
+	}
+
+	// Within the grace period the response is kept
+	fc.Set(parsedCert.NotAfter.Add(time.Hour))
+	err = updater.pruneExpiredResponsesTick(ctx, 10)
+	test.AssertNotError(t, err, "Couldn't run pruneExpiredResponsesTick")
+	test.AssertEquals(t, responseLength(), 3)
+
+	fc.Set(parsedCert.NotAfter.Add(25 * time.Hour))
+	err = updater.pruneExpiredResponsesTick(ctx, 10)
+	test.AssertNotError(t, err, "Couldn't run pruneExpiredResponsesTick")
+	test.AssertEquals(t, responseLength(), 0)
+	test.Assert(t, updater.pruneCursor.Equal(parsedCert.NotAfter), "Prune cursor wasn't moved to the pruned certificate's notAfter")
+
+	// Once everything is pruned ticks don't move the cursor
+	err = updater.pruneExpiredResponsesTick(ctx, 10)
+	test.AssertNotError(t, err, "Couldn't run pruneExpiredResponsesTick")
+	test.Assert(t, updater.pruneCursor.Equal(parsedCert.NotAfter), "Prune cursor moved without pruning")
+}
+
 func TestMissingReceiptsTick(t *testing.T) {
 	updater, sa, _, fc, cleanUp := setup(t)
 	defer cleanUp()
@@ -471,7 +544,7 @@ func TestMissingReceiptsTick(t *testing.T) {
 	parsedCert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
 	fc.Set(parsedCert.NotBefore.Add(time.Minute))
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	updater.oldestIssuedSCT = 2 * time.Hour
@@ -512,7 +585,7 @@ func TestMissingOnlyReceiptsTick(t *testing.T) {
 	parsedCert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
 	fc.Set(parsedCert.NotBefore.Add(time.Minute))
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	updater.oldestIssuedSCT = 2 * time.Hour
@@ -550,6 +623,95 @@ func TestMissingOnlyReceiptsTick(t *testing.T) {
 	test.AssertNotError(t, err, "Failed to run missingReceiptsTick")
 }
 
+func TestMissingReceiptsBackoff(t *testing.T) {
+	updater, sa, _, fc, cleanUp := setup(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	parsedCert, err := core.LoadCert("test-cert.pem")
+	test.AssertNotError(t, err, "Couldn't read test certificate")
+	fc.Set(parsedCert.NotBefore.Add(time.Minute))
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
+	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
+
+	updater.oldestIssuedSCT = 2 * time.Hour
+	updater.missingSCTBackoffFactor = 2
+	updater.missingSCTBackoffMax = time.Hour
+	_ = features.Set(map[string]bool{"CTResubmissionQueue": true})
+	defer features.Reset()
+
+	// A publisher whose logs never return SCTs, so the receipts stay missing
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockPub := mock_publisher.NewMockPublisher(ctrl)
+	updater.pubc = mockPub
+
+	// The first tick submits to each of the three logs
+	mockPub.EXPECT().SubmitToSingleCT(ctx, gomock.Any(), gomock.Any(), parsedCert.Raw).Times(3)
+	err = updater.missingReceiptsTick(ctx, 5)
+	test.AssertNotError(t, err, "Failed to run missingReceiptsTick")
+	test.AssertEquals(t, test.GaugeValue(updater.missingReceipts), float64(3))
+	test.AssertEquals(t, test.CountCounter(updater.receiptResubmissions.WithLabelValues("submitted")), 3)
+
+	// Before the backoff has passed they're deferred
+	err = updater.missingReceiptsTick(ctx, 5)
+	test.AssertNotError(t, err, "Failed to run missingReceiptsTick")
+	test.AssertEquals(t, test.CountCounter(updater.receiptResubmissions.WithLabelValues("deferred")), 3)
+
+	// After it they're resubmitted
+	fc.Add(2 * time.Second)
+	mockPub.EXPECT().SubmitToSingleCT(ctx, gomock.Any(), gomock.Any(), parsedCert.Raw).Times(3)
+	err = updater.missingReceiptsTick(ctx, 5)
+	test.AssertNotError(t, err, "Failed to run missingReceiptsTick")
+	test.AssertEquals(t, test.CountCounter(updater.receiptResubmissions.WithLabelValues("submitted")), 6)
+	var attempts []int
+	_, err = updater.dbMap.Select(&attempts, "SELECT attempts FROM ctResubmissions")
+	test.AssertNotError(t, err, "Failed to select CT resubmissions")
+	test.AssertEquals(t, len(attempts), 3)
+	for _, a := range attempts {
+		test.AssertEquals(t, a, 2)
+	}
+
+	// Once a log returns an SCT its resubmission is deleted
+	err = sa.AddSCTReceipt(ctx, core.SignedCertificateTimestamp{
+		LogID:             testLogBID,
+		Extensions:        []byte{},
+		Signature:         []byte{0},
+		CertificateSerial: core.SerialToString(parsedCert.SerialNumber),
+	})
+	test.AssertNotError(t, err, "Failed to AddSCTReceipt")
+	err = updater.missingReceiptsTick(ctx, 5)
+	test.AssertNotError(t, err, "Failed to run missingReceiptsTick")
+	test.AssertEquals(t, test.GaugeValue(updater.missingReceipts), float64(2))
+	var count int64
+	err = updater.dbMap.SelectOne(&count, "SELECT COUNT(*) FROM ctResubmissions")
+	test.AssertNotError(t, err, "Failed to count CT resubmissions")
+	test.AssertEquals(t, count, int64(2))
+
+	// And once the certificate is too old to be resubmitted so are the rest
+	fc.Add(3 * time.Hour)
+	err = updater.missingReceiptsTick(ctx, 5)
+	test.AssertNotError(t, err, "Failed to run missingReceiptsTick")
+	err = updater.dbMap.SelectOne(&count, "SELECT COUNT(*) FROM ctResubmissions")
+	test.AssertNotError(t, err, "Failed to count CT resubmissions")
+	test.AssertEquals(t, count, int64(0))
+}
+
+func TestResubmissionBackoff(t *testing.T) {
+	updater := &OCSPUpdater{
+		missingSCTWindow: time.Minute,
+		oldestIssuedSCT:  time.Hour,
+	}
+	test.AssertEquals(t, updater.resubmissionBackoff(5), time.Duration(0))
+
+	updater.missingSCTBackoffFactor = 2
+	// Allow for RetryBackoff's jitter
+	backoff := updater.resubmissionBackoff(2)
+	test.Assert(t, backoff > time.Minute && backoff < 3*time.Minute, "Wrong backoff after two attempts")
+	backoff = updater.resubmissionBackoff(20)
+	test.Assert(t, backoff <= time.Hour+time.Hour/4, "Backoff wasn't capped by oldestIssuedSCT")
+}
+
 /*
  * https://github.com/letsencrypt/boulder/issues/1872 identified that the
  * `getSerialsIssuedSince` function may never terminate if there are always new
@@ -605,13 +767,13 @@ func TestRevokedCertificatesTick(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	parsedCert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	err = sa.MarkCertificateRevoked(ctx, core.SerialToString(parsedCert.SerialNumber), revocation.KeyCompromise)
 	test.AssertNotError(t, err, "Failed to revoke certificate")
 
-	statuses, err := updater.findRevokedCertificatesToUpdate(10)
+	statuses, err := updater.findRevokedCertificatesToUpdate(time.Time{}, 10)
 	test.AssertNotError(t, err, "Failed to find revoked certificates")
 	test.AssertEquals(t, len(statuses), 1)
 
@@ -622,6 +784,40 @@ func TestRevokedCertificatesTick(t *testing.T) {
 	test.AssertNotError(t, err, "Failed to get certificate status")
 	test.AssertEquals(t, status.Status, core.OCSPStatusRevoked)
 	test.Assert(t, len(status.OCSPResponse) != 0, "Certificate status doesn't contain OCSP response")
+	test.AssertEquals(t, test.CountHistogramSamples(updater.revocationLatency), 1)
+}
+
+func TestRecentRevocationsTick(t *testing.T) {
+	updater, sa, _, fc, cleanUp := setup(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	parsedCert, err := core.LoadCert("test-cert.pem")
+	test.AssertNotError(t, err, "Couldn't read test certificate")
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
+	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
+	serial := core.SerialToString(parsedCert.SerialNumber)
+
+	err = sa.MarkCertificateRevoked(ctx, serial, revocation.KeyCompromise)
+	test.AssertNotError(t, err, "Failed to revoke certificate")
+
+	// A revocation older than recentRevocationMaxAge is left to the
+	// RevokedCertificates loop
+	updater.recentRevocationMaxAge = time.Minute
+	fc.Add(2 * time.Minute)
+	err = updater.recentRevocationsTick(ctx, 10)
+	test.AssertNotError(t, err, "Failed to run recentRevocationsTick")
+	status, err := sa.GetCertificateStatus(ctx, serial)
+	test.AssertNotError(t, err, "Failed to get certificate status")
+	test.AssertEquals(t, len(status.OCSPResponse), 0)
+
+	updater.recentRevocationMaxAge = time.Hour
+	err = updater.recentRevocationsTick(ctx, 10)
+	test.AssertNotError(t, err, "Failed to run recentRevocationsTick")
+	status, err = sa.GetCertificateStatus(ctx, serial)
+	test.AssertNotError(t, err, "Failed to get certificate status")
+	test.Assert(t, len(status.OCSPResponse) != 0, "Certificate status doesn't contain OCSP response")
+	test.AssertEquals(t, test.CountHistogramSamples(updater.revocationLatency), 1)
 }
 
 func TestStoreResponseGuard(t *testing.T) {
@@ -631,7 +827,7 @@ func TestStoreResponseGuard(t *testing.T) {
 	reg := satest.CreateWorkingRegistration(t, sa)
 	parsedCert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	status, err := sa.GetCertificateStatus(ctx, core.SerialToString(parsedCert.SerialNumber))
@@ -643,7 +839,7 @@ func TestStoreResponseGuard(t *testing.T) {
 	// Attempt to update OCSP response where status.Status is good but stored status
 	// is revoked, this should fail silently
 	status.OCSPResponse = []byte{0, 1, 1}
-	err = updater.storeResponse(&status)
+	err = updater.storeResponse(ctx, &status)
 	test.AssertNotError(t, err, "Failed to update certificate status")
 
 	// Make sure the OCSP response hasn't actually changed
@@ -653,7 +849,7 @@ func TestStoreResponseGuard(t *testing.T) {
 
 	// Changing the status to the stored status should allow the update to occur
 	status.Status = core.OCSPStatusRevoked
-	err = updater.storeResponse(&status)
+	err = updater.storeResponse(ctx, &status)
 	test.AssertNotError(t, err, "Failed to updated certificate status")
 
 	// Make sure the OCSP response has been updated
@@ -716,7 +912,7 @@ func TestGetSubmittedReceipts(t *testing.T) {
 	parsedCert, err := core.LoadCert("test-cert.pem")
 	test.AssertNotError(t, err, "Couldn't read test certificate")
 	fc.Set(parsedCert.NotBefore.Add(time.Minute))
-	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, parsedCert.Raw, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.pem")
 
 	// Before adding any SCTs, there should be no receipts or errors for serial 00
@@ -880,3 +1076,42 @@ func TestGenerateOCSPCacheKeys(t *testing.T) {
 		},
 	)
 }
+
+func TestDeprecatedPurgeBatchConfig(t *testing.T) {
+	log := blog.NewMock()
+	updater, err := newUpdater(
+		metrics.NewNoopScope(),
+		clock.NewFake(),
+		nil,
+		&mockCA{},
+		nil,
+		nil,
+		cmd.OCSPUpdaterConfig{
+			NewCertificateBatchSize:  1,
+			OldOCSPBatchSize:         1,
+			MissingSCTBatchSize:      1,
+			NewCertificateWindow:     cmd.ConfigDuration{Duration: time.Second},
+			OldOCSPWindow:            cmd.ConfigDuration{Duration: time.Second},
+			MissingSCTWindow:         cmd.ConfigDuration{Duration: time.Second},
+			CDNPurge:                 &cmd.CDNPurgeConfig{Provider: "webhook", WebhookURL: "http://localhost/purge"},
+			AkamaiPurgeBatchSize:     10,
+			AkamaiPurgeBatchInterval: cmd.ConfigDuration{Duration: time.Minute},
+		},
+		nil,
+		"test-cert.pem",
+		log,
+	)
+	test.AssertNotError(t, err, "Failed to create newUpdater")
+	test.AssertEquals(t, len(log.GetAllMatching("AkamaiPurgeBatchSize and AkamaiPurgeBatchInterval are deprecated")), 1)
+
+	// The deprecated keys are used in place of the new ones
+	var purgeLoop *looper
+	for _, l := range updater.loops {
+		if l.name == "CDNPurge" {
+			purgeLoop = l
+		}
+	}
+	test.Assert(t, purgeLoop != nil, "No CDNPurge loop")
+	test.AssertEquals(t, purgeLoop.batchSize, 10)
+	test.AssertEquals(t, purgeLoop.tickDur, time.Minute)
+}
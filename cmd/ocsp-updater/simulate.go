@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// signingForecast is the number of OCSP responses the updater is expected to
+// sign in each hour of a forecast period, given the certificates that are
+// currently in the certificateStatus table. Certificates issued after the
+// forecast is made aren't accounted for.
+type signingForecast struct {
+	start time.Time
+	hours []int64
+}
+
+func newSigningForecast(start time.Time, days int) *signingForecast {
+	return &signingForecast{
+		start: start.Truncate(time.Hour),
+		hours: make([]int64, days*24),
+	}
+}
+
+func (f *signingForecast) end() time.Time {
+	return f.start.Add(time.Duration(len(f.hours)) * time.Hour)
+}
+
+// add counts the responses that will be signed for a certificate over the
+// forecast period. This follows the updater's loops: a certificate without a
+// response is signed right away, and a response is signed again once it is
+// minTimeToExpiry old, until the certificate expires. Responses older than
+// staleMaxAge are never updated.
+func (f *signingForecast) add(status core.CertificateStatus, minTimeToExpiry, staleMaxAge time.Duration) {
+	next := f.start
+	if !status.OCSPLastUpdated.IsZero() {
+		if !status.OCSPLastUpdated.After(f.start.Add(-staleMaxAge)) {
+			return
+		}
+		next = status.OCSPLastUpdated.Add(minTimeToExpiry)
+		if next.Before(f.start) {
+			next = f.start
+		}
+	}
+	end := f.end()
+	for next.Before(end) && next.Before(status.NotAfter) {
+		f.hours[next.Sub(f.start)/time.Hour]++
+		next = next.Add(minTimeToExpiry)
+	}
+}
+
+// peak returns the start of the busiest hour and its number of responses.
+func (f *signingForecast) peak() (time.Time, int64) {
+	var peakHour int
+	for i, count := range f.hours {
+		if count > f.hours[peakHour] {
+			peakHour = i
+		}
+	}
+	return f.start.Add(time.Duration(peakHour) * time.Hour), f.hours[peakHour]
+}
+
+// overCapacity returns the number of hours in which more than capacity
+// responses need signing.
+func (f *signingForecast) overCapacity(capacity int64) int {
+	var hours int
+	for _, count := range f.hours {
+		if count > capacity {
+			hours++
+		}
+	}
+	return hours
+}
+
+// writeCSV writes the forecast to w as CSV, one row per hour.
+func (f *signingForecast) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"hour", "responses"}); err != nil {
+		return err
+	}
+	for i, count := range f.hours {
+		err := cw.Write([]string{
+			f.start.Add(time.Duration(i) * time.Hour).UTC().Format(time.RFC3339),
+			strconv.FormatInt(count, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// forecastSigningLoad scans the certificateStatus table, batchSize rows at a
+// time, and returns the number of OCSP responses that will need signing in
+// each hour of the next days days. Nothing is signed or written.
+func (updater *OCSPUpdater) forecastSigningLoad(days, batchSize int) (*signingForecast, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be positive to forecast signing load")
+	}
+	if updater.ocspMinTimeToExpiry <= 0 {
+		return nil, fmt.Errorf("OCSPMinTimeToExpiry must be positive to forecast signing load")
+	}
+	forecast := newSigningForecast(updater.clk.Now(), days)
+
+	lastSerial := ""
+	for {
+		prefixFilter, args := updater.serialPrefixFilter("serial")
+		args["lastSerial"] = lastSerial
+		args["start"] = forecast.start
+		args["limit"] = batchSize
+		var statuses []core.CertificateStatus
+		_, err := updater.dbMap.Select(
+			&statuses,
+			`SELECT serial, ocspLastUpdated, notAfter
+				FROM certificateStatus
+				WHERE serial > :lastSerial
+				AND notAfter > :start
+				AND NOT isExpired
+				`+noOCSPFilter("noOCSP")+prefixFilter+`
+				ORDER BY serial
+				LIMIT :limit`,
+			args,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			forecast.add(status, updater.ocspMinTimeToExpiry, updater.ocspStaleMaxAge)
+		}
+		if len(statuses) < batchSize {
+			return forecast, nil
+		}
+		lastSerial = statuses[len(statuses)-1].Serial
+	}
+}
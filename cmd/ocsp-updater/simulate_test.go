@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSigningForecast(t *testing.T) {
+	start := time.Date(2018, 3, 1, 10, 30, 0, 0, time.UTC)
+	minTimeToExpiry := 72 * time.Hour
+	staleMaxAge := 30 * 24 * time.Hour
+
+	forecast := newSigningForecast(start, 7)
+	test.AssertEquals(t, forecast.start, time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC))
+	test.AssertEquals(t, len(forecast.hours), 7*24)
+
+	// No response yet: signed in the first hour and every 72 hours after.
+	forecast.add(core.CertificateStatus{
+		NotAfter: start.Add(90 * 24 * time.Hour),
+	}, minTimeToExpiry, staleMaxAge)
+	// Updated 71 hours ago: due in an hour, then every 72 hours, until it
+	// expires on the fourth day.
+	forecast.add(core.CertificateStatus{
+		OCSPLastUpdated: start.Add(-71 * time.Hour),
+		NotAfter:        start.Add(4 * 24 * time.Hour),
+	}, minTimeToExpiry, staleMaxAge)
+	// Overdue: signed in the first hour.
+	forecast.add(core.CertificateStatus{
+		OCSPLastUpdated: start.Add(-100 * time.Hour),
+		NotAfter:        start.Add(90 * 24 * time.Hour),
+	}, minTimeToExpiry, staleMaxAge)
+	// Older than staleMaxAge: never updated.
+	forecast.add(core.CertificateStatus{
+		OCSPLastUpdated: start.Add(-31 * 24 * time.Hour),
+		NotAfter:        start.Add(90 * 24 * time.Hour),
+	}, minTimeToExpiry, staleMaxAge)
+
+	expected := map[int]int64{
+		0:   2,
+		1:   1,
+		72:  2,
+		73:  1,
+		144: 2,
+	}
+	for i, count := range forecast.hours {
+		test.AssertEquals(t, count, expected[i])
+	}
+
+	peakHour, peak := forecast.peak()
+	test.AssertEquals(t, peakHour, forecast.start)
+	test.AssertEquals(t, peak, int64(2))
+	test.AssertEquals(t, forecast.overCapacity(1), 3)
+	test.AssertEquals(t, forecast.overCapacity(2), 0)
+
+	var buf bytes.Buffer
+	err := forecast.writeCSV(&buf)
+	test.AssertNotError(t, err, "writeCSV failed")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	test.AssertEquals(t, len(lines), 7*24+1)
+	test.AssertEquals(t, lines[0], "hour,responses")
+	test.AssertEquals(t, lines[1], "2018-03-01T10:00:00Z,2")
+}
+
+func TestForecastSigningLoadBatchSize(t *testing.T) {
+	updater := &OCSPUpdater{ocspMinTimeToExpiry: 72 * time.Hour}
+	_, err := updater.forecastSigningLoad(7, 0)
+	test.AssertError(t, err, "forecastSigningLoad accepted a zero batch size")
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"crypto/x509"
 	"encoding/hex"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -11,15 +10,19 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/context"
 
+	capb "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/log/tail"
 	"github.com/letsencrypt/boulder/metrics"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
@@ -30,25 +33,46 @@ name:
 
 usage:
   orphan-finder parse-ca-log --config <path> --log-file <path>
+  orphan-finder watch-ca-log --config <path> --log-file <path>
   orphan-finder parse-der --config <path> --der-file <path> --regID <registration-id>
 
 command descriptions:
   parse-ca-log    Parses boulder-ca logs to add multiple orphaned certificates
+  watch-ca-log    Runs as a service, adding orphaned certificates from a boulder-ca
+                  log as it's written
   parse-der       Parses a single orphaned DER certificate file and adds it to the database
 `
 
 type config struct {
 	TLS       cmd.TLSConfig
 	SAService *cmd.GRPCClientConfig
-	Syslog    cmd.SyslogConfig
-	Features  map[string]bool
+	// OCSPGeneratorService, if set, is used to sign an OCSP response for
+	// each orphaned certificate as it's added. Otherwise the ocsp-updater
+	// signs the first response.
+	OCSPGeneratorService *cmd.GRPCClientConfig
+	// Backdate is how far the CA backdates the NotBefore of certificates,
+	// and should match its backdate setting. Orphaned certificates are
+	// stored with the time they were issued, their NotBefore plus Backdate,
+	// rather than the time they're found.
+	Backdate cmd.ConfigDuration
+	// DebugAddr is the address metrics are served on by watch-ca-log.
+	DebugAddr string
+	// PollInterval is how often watch-ca-log checks the log for new lines
+	// once the end has been reached. Defaults to one second.
+	PollInterval cmd.ConfigDuration
+	Syslog       cmd.SyslogConfig
+	Features     map[string]bool
 }
 
 type certificateStorage interface {
-	AddCertificate(context.Context, []byte, int64, []byte) (string, error)
+	AddCertificate(context.Context, []byte, int64, []byte, *time.Time) (string, error)
 	GetCertificate(ctx context.Context, serial string) (core.Certificate, error)
 }
 
+type ocspGenerator interface {
+	GenerateOCSP(context.Context, core.OCSPSigningRequest) ([]byte, error)
+}
+
 var (
 	derOrphan        = regexp.MustCompile(`cert=\[([0-9a-f]+)\]`)
 	regOrphan        = regexp.MustCompile(`regID=\[(\d+)\]`)
@@ -71,69 +95,148 @@ func checkDER(sai certificateStorage, der []byte) error {
 	return fmt.Errorf("Existing certificate lookup failed: %s", err)
 }
 
-func parseLogLine(sa certificateStorage, logger blog.Logger, line string) (found bool, added bool) {
+// orphanFinder adds orphaned certificates to the database.
+type orphanFinder struct {
+	sa       certificateStorage
+	ocsp     ocspGenerator
+	backdate time.Duration
+	log      blog.Logger
+	// orphans counts orphaned certificates by result: added, exists (for
+	// certificates that were already in the database) or error.
+	orphans *prometheus.CounterVec
+}
+
+func newOrphanFinder(sa certificateStorage, ocsp ocspGenerator, backdate time.Duration, logger blog.Logger, stats metrics.Scope) *orphanFinder {
+	orphans := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "orphans",
+		Help: "A counter of orphaned certificates found, by result (added, exists or error)",
+	}, []string{"result"})
+	stats.MustRegister(orphans)
+	return &orphanFinder{
+		sa:       sa,
+		ocsp:     ocsp,
+		backdate: backdate,
+		log:      logger,
+		orphans:  orphans,
+	}
+}
+
+// storeDER adds an orphaned certificate to the database, with the time it was
+// issued and, if possible, an OCSP response.
+func (of *orphanFinder) storeDER(der []byte, regID int64) error {
 	ctx := context.Background()
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("Failed to parse DER: %s", err)
+	}
+	issued := cert.NotBefore.Add(of.backdate)
+	var ocspResp []byte
+	if of.ocsp != nil {
+		ocspResp, err = of.ocsp.GenerateOCSP(ctx, core.OCSPSigningRequest{
+			CertDER: der,
+			Status:  string(core.OCSPStatusGood),
+		})
+		if err != nil {
+			// The ocsp-updater will generate the first response for a
+			// certificate stored without one.
+			of.log.Warning(fmt.Sprintf("Failed to generate OCSP response for orphan %s: %s",
+				core.SerialToString(cert.SerialNumber), err))
+			ocspResp = nil
+		}
+	}
+	_, err = of.sa.AddCertificate(ctx, der, regID, ocspResp, &issued)
+	return err
+}
+
+func (of *orphanFinder) parseLogLine(line string) (found bool, added bool) {
 	if !strings.Contains(line, "cert=") || !strings.Contains(line, "orphaning certificate") {
 		return false, false
 	}
+	result := of.addOrphan(line)
+	of.orphans.WithLabelValues(result).Inc()
+	return true, result == "added"
+}
+
+// addOrphan adds the certificate from an orphan log line to the database,
+// returning the result for the orphans metric.
+func (of *orphanFinder) addOrphan(line string) string {
+	logger := of.log
 	derStr := derOrphan.FindStringSubmatch(line)
 	if len(derStr) <= 1 {
 		logger.AuditErr(fmt.Sprintf("Didn't match regex for cert: %s", line))
-		return true, false
+		return "error"
 	}
 	der, err := hex.DecodeString(derStr[1])
 	if err != nil {
 		logger.AuditErr(fmt.Sprintf("Couldn't decode hex: %s, [%s]", err, line))
-		return true, false
+		return "error"
 	}
-	err = checkDER(sa, der)
-	if err != nil {
-		logFunc := logger.Err
-		if err == errAlreadyExists {
-			logFunc = logger.Info
-		}
-		logFunc(fmt.Sprintf("%s, [%s]", err, line))
-		return true, false
+	err = checkDER(of.sa, der)
+	if err == errAlreadyExists {
+		logger.Info(fmt.Sprintf("%s, [%s]", err, line))
+		return "exists"
+	} else if err != nil {
+		logger.Err(fmt.Sprintf("%s, [%s]", err, line))
+		return "error"
 	}
 	// extract the regID
 	regStr := regOrphan.FindStringSubmatch(line)
 	if len(regStr) <= 1 {
 		logger.AuditErr(fmt.Sprintf("regID variable is empty, [%s]", line))
-		return true, false
+		return "error"
 	}
 	regID, err := strconv.Atoi(regStr[1])
 	if err != nil {
 		logger.AuditErr(fmt.Sprintf("Couldn't parse regID: %s, [%s]", err, line))
-		return true, false
+		return "error"
 	}
-	// OCSP-Updater will do the first response generation for this cert so pass an
-	// empty OCSP response
-	_, err = sa.AddCertificate(ctx, der, int64(regID), nil)
+	err = of.storeDER(der, int64(regID))
 	if err != nil {
 		logger.AuditErr(fmt.Sprintf("Failed to store certificate: %s, [%s]", err, line))
-		return true, false
+		return "error"
 	}
-	return true, true
+	return "added"
 }
 
-func setup(configFile string) (blog.Logger, core.StorageAuthority) {
+// setup reads the config file and connects to the SA, and the CA if
+// configured. If stats is true the logger and metrics scope come from
+// cmd.StatsAndLogging, serving metrics on the debug address.
+func setup(configFile string, stats bool) (config, blog.Logger, core.StorageAuthority, *orphanFinder) {
 	configJSON, err := ioutil.ReadFile(configFile)
 	cmd.FailOnError(err, "Failed to read config file")
 	var conf config
-	err = json.Unmarshal(configJSON, &conf)
+	err = cmd.ParseConfig(configJSON, &conf)
 	cmd.FailOnError(err, "Failed to parse config file")
 	err = features.Set(conf.Features)
 	cmd.FailOnError(err, "Failed to set feature flags")
-	logger := cmd.NewLogger(conf.Syslog)
+
+	var logger blog.Logger
+	var scope metrics.Scope
+	if stats {
+		scope, logger = cmd.StatsAndLogging(conf.Syslog, conf.DebugAddr)
+	} else {
+		logger = cmd.NewLogger(conf.Syslog)
+		scope = metrics.NewNoopScope()
+	}
 
 	tlsConfig, err := conf.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
 
-	clientMetrics := bgrpc.NewClientMetrics(metrics.NewNoopScope())
+	clientMetrics := bgrpc.NewClientMetrics(scope)
 	conn, err := bgrpc.ClientSetup(conf.SAService, tlsConfig, clientMetrics)
 	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
 	sac := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(conn))
-	return logger, sac
+
+	var ocsp ocspGenerator
+	if conf.OCSPGeneratorService != nil {
+		caConn, err := bgrpc.ClientSetup(conf.OCSPGeneratorService, tlsConfig, clientMetrics)
+		cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to CA")
+		// Make a CA client that is only capable of signing OCSP.
+		ocsp = bgrpc.NewCertificateAuthorityClient(nil, capb.NewOCSPGeneratorClient(caConn))
+	}
+
+	of := newOrphanFinder(sac, ocsp, conf.Backdate.Duration, logger, scope)
+	return conf, logger, sac, of
 }
 
 func main() {
@@ -163,7 +266,7 @@ func main() {
 
 	switch command {
 	case "parse-ca-log":
-		logger, sa := setup(*configFile)
+		_, logger, _, of := setup(*configFile, false)
 		if *logPath == "" {
 			usage()
 		}
@@ -174,7 +277,7 @@ func main() {
 		orphansFound := int64(0)
 		orphansAdded := int64(0)
 		for _, line := range strings.Split(string(logData), "\n") {
-			found, added := parseLogLine(sa, logger, line)
+			found, added := of.parseLogLine(line)
 			if found {
 				orphansFound++
 				if added {
@@ -184,9 +287,40 @@ func main() {
 		}
 		logger.Info(fmt.Sprintf("Found %d orphans and added %d to the database\n", orphansFound, orphansAdded))
 
+	case "watch-ca-log":
+		if *logPath == "" {
+			usage()
+		}
+		conf, logger, _, of := setup(*configFile, true)
+		defer logger.AuditPanic()
+		logger.Info(cmd.VersionString())
+
+		interval := conf.PollInterval.Duration
+		if interval == 0 {
+			interval = time.Second
+		}
+		// The whole log is read at startup, so that orphans logged while
+		// the service wasn't running are found. Certificates that are
+		// already in the database are skipped.
+		follower := &tail.Follower{Filename: *logPath, Interval: interval, Log: logger, FromStart: true}
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			follower.Follow(func(line string) {
+				if found, added := of.parseLogLine(line); found && added {
+					logger.Info("Added orphaned certificate to the database")
+				}
+			}, stop)
+			close(done)
+		}()
+
+		cmd.CatchSignals(logger, func() {
+			close(stop)
+			<-done
+		})
+
 	case "parse-der":
-		ctx := context.Background()
-		_, sa := setup(*configFile)
+		_, _, sa, of := setup(*configFile, false)
 		if *derPath == "" || *regID == 0 {
 			usage()
 		}
@@ -194,7 +328,7 @@ func main() {
 		cmd.FailOnError(err, "Failed to read DER file")
 		err = checkDER(sa, der)
 		cmd.FailOnError(err, "Pre-AddCertificate checks failed")
-		_, err = sa.AddCertificate(ctx, der, int64(*regID), nil)
+		err = of.storeDER(der, int64(*regID))
 		cmd.FailOnError(err, "Failed to add certificate to database")
 
 	default:
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"gopkg.in/go-gorp/gorp.v2"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+const usageString = `
+usage:
+policy-override add --config <path> --domain <domain> [--reg-id <id>] [--comment <comment>]
+policy-override remove --config <path> --domain <domain> [--reg-id <id>]
+policy-override list --config <path> [--domain <domain>]
+
+command descriptions:
+  add       Allow issuance for a domain, and its subdomains, that the hostname
+            policy would otherwise forbid
+  remove    Remove a previously added override
+  list      List overrides, optionally only those for a single domain
+
+args:
+  config    File path to the configuration file for this service
+  domain    Domain the override applies to
+  reg-id    Registration ID the override applies to (default 0, all registrations)
+  comment   Comment stored alongside the override, e.g. a bug reference
+`
+
+type config struct {
+	PolicyOverride struct {
+		cmd.DBConfig
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+type override struct {
+	ID             int64
+	Domain         string
+	RegistrationID int64
+	Added          time.Time
+	AddedBy        string
+	Comment        *string
+}
+
+// normalizeDomain lower cases domain and strips any trailing dot. Overrides
+// apply to a domain and all of its subdomains, so wildcards are rejected.
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return "", fmt.Errorf("domain is empty")
+	}
+	if strings.Contains(domain, "*") {
+		return "", fmt.Errorf("domain %q contains a wildcard, overrides already apply to all subdomains", domain)
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" {
+			return "", fmt.Errorf("domain %q has an empty label", domain)
+		}
+	}
+	return domain, nil
+}
+
+// addOverride adds an override for domain and regID. It is not an error for
+// the override to already exist.
+func addOverride(dbMap *gorp.DbMap, clk clock.Clock, domain string, regID int64, addedBy, comment string) (bool, error) {
+	var count int64
+	err := dbMap.SelectOne(
+		&count,
+		"SELECT COUNT(1) FROM policyOverrides WHERE domain = ? AND registrationID = ?",
+		domain, regID)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	var commentArg interface{}
+	if comment != "" {
+		commentArg = comment
+	}
+	_, err = dbMap.Exec(
+		`INSERT INTO policyOverrides (domain, registrationID, added, addedBy, comment)
+		VALUES (?, ?, ?, ?, ?)`,
+		domain, regID, clk.Now(), addedBy, commentArg)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// removeOverride removes the override for domain and regID, returning whether
+// there was one.
+func removeOverride(dbMap *gorp.DbMap, domain string, regID int64) (bool, error) {
+	result, err := dbMap.Exec(
+		"DELETE FROM policyOverrides WHERE domain = ? AND registrationID = ?",
+		domain, regID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// listOverrides returns all overrides, or only those for domain if it isn't
+// empty.
+func listOverrides(dbMap *gorp.DbMap, domain string) ([]override, error) {
+	query := "SELECT id, domain, registrationID, added, addedBy, comment FROM policyOverrides"
+	var args []interface{}
+	if domain != "" {
+		query += " WHERE domain = ?"
+		args = append(args, domain)
+	}
+	query += " ORDER BY domain, registrationID"
+	var overrides []override
+	_, err := dbMap.Select(&overrides, query, args...)
+	return overrides, err
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprint(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	domainArg := flagSet.String("domain", "", "Domain the override applies to")
+	regID := flagSet.Int64("reg-id", 0, "Registration ID the override applies to, 0 for all registrations")
+	comment := flagSet.String("comment", "", "Comment stored alongside the override")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+
+	if *configFile == "" || *regID < 0 {
+		usage()
+	}
+	var domain string
+	switch command {
+	case "add", "remove":
+		if *domainArg == "" {
+			usage()
+		}
+		fallthrough
+	case "list":
+		if *domainArg != "" {
+			domain, err = normalizeDomain(*domainArg)
+			cmd.FailOnError(err, "Bad --domain")
+		}
+	default:
+		usage()
+	}
+
+	var c config
+	err = cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.PolicyOverride.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	logger := cmd.NewLogger(c.Syslog)
+	defer logger.AuditPanic()
+
+	dbURL, err := c.PolicyOverride.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbTLS, err := c.PolicyOverride.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.PolicyOverride.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.PolicyOverride.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.PolicyOverride.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
+	cmd.FailOnError(err, "Couldn't setup database connection")
+
+	switch command {
+	case "add":
+		u, err := user.Current()
+		cmd.FailOnError(err, "Couldn't determine current user")
+		added, err := addOverride(dbMap, cmd.Clock(), domain, *regID, u.Username, *comment)
+		cmd.FailOnError(err, "Couldn't add policy override")
+		if added {
+			logger.AuditInfo(fmt.Sprintf("Added policy override for %s, registration ID %d, added by %s: %q", domain, *regID, u.Username, *comment))
+		} else {
+			logger.Info(fmt.Sprintf("Policy override for %s, registration ID %d already exists", domain, *regID))
+		}
+
+	case "remove":
+		u, err := user.Current()
+		cmd.FailOnError(err, "Couldn't determine current user")
+		removed, err := removeOverride(dbMap, domain, *regID)
+		cmd.FailOnError(err, "Couldn't remove policy override")
+		if removed {
+			logger.AuditInfo(fmt.Sprintf("Removed policy override for %s, registration ID %d, removed by %s", domain, *regID, u.Username))
+		} else {
+			logger.Info(fmt.Sprintf("No policy override for %s, registration ID %d", domain, *regID))
+		}
+
+	case "list":
+		overrides, err := listOverrides(dbMap, domain)
+		cmd.FailOnError(err, "Couldn't list policy overrides")
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tREGISTRATION ID\tADDED\tADDED BY\tCOMMENT")
+		for _, o := range overrides {
+			var comment string
+			if o.Comment != nil {
+				comment = *o.Comment
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", o.Domain, o.RegistrationID, o.Added.Format(time.RFC3339), o.AddedBy, comment)
+		}
+		w.Flush()
+	}
+}
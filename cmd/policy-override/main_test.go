@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestNormalizeDomain(t *testing.T) {
+	testCases := []struct {
+		domain   string
+		expected string
+		valid    bool
+	}{
+		{"example.com", "example.com", true},
+		{" WWW.Example.COM. ", "www.example.com", true},
+		{"com", "com", true},
+		{"", "", false},
+		{".", "", false},
+		{"*.example.com", "", false},
+		{"www..example.com", "", false},
+		{".example.com", "", false},
+	}
+	for _, tc := range testCases {
+		domain, err := normalizeDomain(tc.domain)
+		if !tc.valid {
+			test.AssertError(t, err, tc.domain)
+			continue
+		}
+		test.AssertNotError(t, err, tc.domain)
+		test.AssertEquals(t, domain, tc.expected)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"gopkg.in/go-gorp/gorp.v2"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/ratelimit"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+const usageString = `
+usage:
+rate-limit-override grant --config <path> --reg-id <id> --limit <name> --threshold <n> [--duration <duration>] [--comment <comment>]
+rate-limit-override revoke --config <path> --reg-id <id> --limit <name>
+rate-limit-override list --config <path> [--reg-id <id>]
+
+command descriptions:
+  grant     Set the threshold of a rate limit for a registration, replacing any
+            existing override of that limit for it
+  revoke    Remove a previously granted override
+  list      List overrides, optionally only those for a single registration
+
+args:
+  config    File path to the configuration file for this service
+  reg-id    Registration ID the override applies to
+  limit     Name of the limit, as used in the rate limit policy file, e.g.
+            certificatesPerName, or maxNamesPerCertificate to override the
+            RA's maximum number of names per certificate
+  threshold Threshold of the limit for the registration
+  duration  How long the override lasts (default 2160h, 90 days)
+  comment   Comment stored alongside the override, e.g. a bug reference
+
+Overrides take effect when the RA next refreshes them.
+`
+
+type config struct {
+	RateLimitOverride struct {
+		cmd.DBConfig
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+type override struct {
+	ID             int64
+	RegistrationID int64
+	LimitName      string
+	Threshold      int64
+	Expires        time.Time
+	Added          time.Time
+	AddedBy        string
+	Comment        *string
+}
+
+// checkOverride returns an error if an override with the given arguments
+// couldn't be applied by the RA.
+func checkOverride(regID int64, limitName string, threshold int64, duration time.Duration) error {
+	if regID <= 0 {
+		return fmt.Errorf("registration ID must be positive")
+	}
+	if !ratelimit.ValidOverrideName(limitName) {
+		return fmt.Errorf("unknown rate limit %q", limitName)
+	}
+	if threshold < 0 {
+		return fmt.Errorf("threshold must not be negative")
+	}
+	if limitName == ratelimit.MaxNamesPerCertificate && threshold < 1 {
+		return fmt.Errorf("%s threshold must be at least 1", ratelimit.MaxNamesPerCertificate)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	return nil
+}
+
+// grantOverride sets the override of limitName for regID, replacing any
+// existing one. It returns whether an existing override was replaced.
+func grantOverride(dbMap *gorp.DbMap, clk clock.Clock, regID int64, limitName string, threshold int64, duration time.Duration, addedBy, comment string) (bool, error) {
+	var commentArg interface{}
+	if comment != "" {
+		commentArg = comment
+	}
+	now := clk.Now()
+	result, err := dbMap.Exec(
+		`INSERT INTO rateLimitOverrides (registrationID, limitName, threshold, expires, added, addedBy, comment)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE threshold = VALUES(threshold), expires = VALUES(expires),
+		added = VALUES(added), addedBy = VALUES(addedBy), comment = VALUES(comment)`,
+		regID, limitName, threshold, now.Add(duration), now, addedBy, commentArg)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	// MySQL reports two affected rows when an existing row is updated
+	return rows > 1, nil
+}
+
+// revokeOverride removes the override of limitName for regID, returning
+// whether there was one.
+func revokeOverride(dbMap *gorp.DbMap, regID int64, limitName string) (bool, error) {
+	result, err := dbMap.Exec(
+		"DELETE FROM rateLimitOverrides WHERE registrationID = ? AND limitName = ?",
+		regID, limitName)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// listOverrides returns all overrides, or only those for regID if it isn't
+// zero.
+func listOverrides(dbMap *gorp.DbMap, regID int64) ([]override, error) {
+	query := `SELECT id, registrationID, limitName, threshold, expires, added, addedBy, comment
+		FROM rateLimitOverrides`
+	var args []interface{}
+	if regID != 0 {
+		query += " WHERE registrationID = ?"
+		args = append(args, regID)
+	}
+	query += " ORDER BY registrationID, limitName"
+	var overrides []override
+	_, err := dbMap.Select(&overrides, query, args...)
+	return overrides, err
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprint(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	regID := flagSet.Int64("reg-id", 0, "Registration ID the override applies to")
+	limitName := flagSet.String("limit", "", "Name of the limit, as used in the rate limit policy file")
+	threshold := flagSet.Int64("threshold", -1, "Threshold of the limit for the registration")
+	duration := flagSet.Duration("duration", 90*24*time.Hour, "How long the override lasts")
+	comment := flagSet.String("comment", "", "Comment stored alongside the override")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+
+	if *configFile == "" || *regID < 0 {
+		usage()
+	}
+	switch command {
+	case "grant":
+		err = checkOverride(*regID, *limitName, *threshold, *duration)
+		cmd.FailOnError(err, "Bad override")
+	case "revoke":
+		err = checkOverride(*regID, *limitName, 0, time.Second)
+		cmd.FailOnError(err, "Bad override")
+	case "list":
+	default:
+		usage()
+	}
+
+	var c config
+	err = cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.RateLimitOverride.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	logger := cmd.NewLogger(c.Syslog)
+	defer logger.AuditPanic()
+
+	dbURL, err := c.RateLimitOverride.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbTLS, err := c.RateLimitOverride.DBConfig.TLSConfig()
+	cmd.FailOnError(err, "Couldn't load DB TLS config")
+	dbMap, err := sa.NewDbMapWithSettings(dbURL, sa.DbSettings{
+		MaxOpenConns:    c.RateLimitOverride.DBConfig.MaxDBConns,
+		MaxIdleConns:    c.RateLimitOverride.DBConfig.MaxIdleDBConns,
+		ConnMaxLifetime: c.RateLimitOverride.DBConfig.ConnMaxLifetime.Duration,
+		TLS:             dbTLS,
+	})
+	cmd.FailOnError(err, "Couldn't setup database connection")
+
+	switch command {
+	case "grant":
+		u, err := user.Current()
+		cmd.FailOnError(err, "Couldn't determine current user")
+		replaced, err := grantOverride(dbMap, cmd.Clock(), *regID, *limitName, *threshold, *duration, u.Username, *comment)
+		cmd.FailOnError(err, "Couldn't grant rate limit override")
+		verb := "Granted"
+		if replaced {
+			verb = "Replaced"
+		}
+		logger.AuditInfo(fmt.Sprintf("%s rate limit override of %s for registration ID %d: threshold %d for %s, added by %s: %q",
+			verb, *limitName, *regID, *threshold, *duration, u.Username, *comment))
+
+	case "revoke":
+		u, err := user.Current()
+		cmd.FailOnError(err, "Couldn't determine current user")
+		removed, err := revokeOverride(dbMap, *regID, *limitName)
+		cmd.FailOnError(err, "Couldn't revoke rate limit override")
+		if removed {
+			logger.AuditInfo(fmt.Sprintf("Revoked rate limit override of %s for registration ID %d, removed by %s", *limitName, *regID, u.Username))
+		} else {
+			logger.Info(fmt.Sprintf("No rate limit override of %s for registration ID %d", *limitName, *regID))
+		}
+
+	case "list":
+		overrides, err := listOverrides(dbMap, *regID)
+		cmd.FailOnError(err, "Couldn't list rate limit overrides")
+		now := cmd.Clock().Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "REGISTRATION ID\tLIMIT\tTHRESHOLD\tEXPIRES\tADDED\tADDED BY\tCOMMENT")
+		for _, o := range overrides {
+			var comment string
+			if o.Comment != nil {
+				comment = *o.Comment
+			}
+			expires := o.Expires.Format(time.RFC3339)
+			if !o.Expires.After(now) {
+				expires += " (expired)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\t%s\n",
+				o.RegistrationID, o.LimitName, o.Threshold, expires, o.Added.Format(time.RFC3339), o.AddedBy, comment)
+		}
+		w.Flush()
+	}
+}
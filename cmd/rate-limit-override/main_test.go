@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestCheckOverride(t *testing.T) {
+	testCases := []struct {
+		regID     int64
+		limitName string
+		threshold int64
+		duration  time.Duration
+		valid     bool
+	}{
+		{1, "certificatesPerName", 1000, time.Hour, true},
+		{1, "newOrdersPerAccount", 0, time.Hour, true},
+		{1, "maxNamesPerCertificate", 200, time.Hour, true},
+		{1, "maxNamesPerCertificate", 0, time.Hour, false},
+		{0, "certificatesPerName", 1000, time.Hour, false},
+		{1, "certificatesPerDomain", 1000, time.Hour, false},
+		{1, "", 1000, time.Hour, false},
+		{1, "certificatesPerName", -1, time.Hour, false},
+		{1, "certificatesPerName", 1000, 0, false},
+	}
+	for _, tc := range testCases {
+		err := checkOverride(tc.regID, tc.limitName, tc.threshold, tc.duration)
+		if tc.valid {
+			test.AssertNotError(t, err, tc.limitName)
+		} else {
+			test.AssertError(t, err, tc.limitName)
+		}
+	}
+}
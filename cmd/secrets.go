@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretResolver returns the secret identified by ref, the part of a secret
+// URI after the scheme.
+type SecretResolver func(ref string) (string, error)
+
+var (
+	secretResolversMu sync.RWMutex
+	secretResolvers   = map[string]SecretResolver{
+		"env":        envSecret,
+		"file":       fileSecret,
+		"credential": credentialSecret,
+		"vault":      vaultSecret,
+	}
+)
+
+// RegisterSecretResolver makes secret URIs with the given scheme resolve
+// using r, so that deployments can fetch secrets from stores, such as a
+// cloud KMS, that aren't supported out of the box.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	secretResolversMu.Lock()
+	defer secretResolversMu.Unlock()
+	secretResolvers[scheme] = r
+}
+
+// ResolveSecret returns the secret identified by uri, which is one of:
+//
+//	env:NAME                    the environment variable NAME
+//	file:/path                  the contents of a file
+//	credential:NAME             the systemd credential NAME, from the
+//	                            directory in $CREDENTIALS_DIRECTORY
+//	vault:path/to/secret#field  a field of a Vault KV secret, read from
+//	                            $VAULT_ADDR using $VAULT_TOKEN
+//
+// or a scheme added with RegisterSecretResolver. Trailing newlines are
+// removed from secrets read from files.
+func ResolveSecret(uri string) (string, error) {
+	fields := strings.SplitN(uri, ":", 2)
+	if len(fields) != 2 || fields[1] == "" {
+		return "", fmt.Errorf("malformed secret URI %q", uri)
+	}
+	secretResolversMu.RLock()
+	r, ok := secretResolvers[fields[0]]
+	secretResolversMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unsupported secret URI scheme %q", fields[0])
+	}
+	secret, err := r(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("resolving secret %q: %s", uri, err)
+	}
+	return secret, nil
+}
+
+func envSecret(name string) (string, error) {
+	secret, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s isn't set", name)
+	}
+	return secret, nil
+}
+
+func fileSecret(path string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(contents), "\n"), nil
+}
+
+// credentialSecret reads a credential passed to the service by systemd's
+// LoadCredential= or SetCredential= options.
+func credentialSecret(name string) (string, error) {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if dir == "" {
+		return "", fmt.Errorf("CREDENTIALS_DIRECTORY isn't set")
+	}
+	if strings.Contains(name, "/") {
+		return "", fmt.Errorf("invalid credential name %q", name)
+	}
+	return fileSecret(filepath.Join(dir, name))
+}
+
+// vaultClient is used to read secrets from Vault.
+var vaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// vaultSecret reads a field of a secret from Vault's KV secrets engine. Both
+// versions of the engine are supported: version 2 nests the fields of the
+// secret in a second "data" object.
+func vaultSecret(ref string) (string, error) {
+	fields := strings.SplitN(ref, "#", 2)
+	if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+		return "", fmt.Errorf("expected path#field")
+	}
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR isn't set")
+	}
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(fields[0], "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+	resp, err := vaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault returned status %d", resp.StatusCode)
+	}
+	var body struct {
+		Data map[string]interface{}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding Vault response: %s", err)
+	}
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	secret, ok := data[fields[1]].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", fields[1])
+	}
+	return secret, nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestResolveSecret(t *testing.T) {
+	os.Setenv("BOULDER_TEST_SECRET", "from-env")
+	defer os.Unsetenv("BOULDER_TEST_SECRET")
+	os.Setenv("CREDENTIALS_DIRECTORY", "testdata")
+	defer os.Unsetenv("CREDENTIALS_DIRECTORY")
+
+	testCases := []struct {
+		uri      string
+		expected string
+		err      string
+	}{
+		{uri: "env:BOULDER_TEST_SECRET", expected: "from-env"},
+		{uri: "env:BOULDER_TEST_UNSET", err: `resolving secret "env:BOULDER_TEST_UNSET": environment variable BOULDER_TEST_UNSET isn't set`},
+		{uri: "file:testdata/test_secret", expected: "secret"},
+		{uri: "credential:test_secret", expected: "secret"},
+		{uri: "credential:../testdata/test_secret", err: `resolving secret "credential:../testdata/test_secret": invalid credential name "../testdata/test_secret"`},
+		{uri: "kms:projects/boulder/secret", err: `unsupported secret URI scheme "kms"`},
+		{uri: "testdata/test_secret", err: `malformed secret URI "testdata/test_secret"`},
+	}
+	for _, tc := range testCases {
+		secret, err := ResolveSecret(tc.uri)
+		if tc.err != "" {
+			test.AssertError(t, err, fmt.Sprintf("ResolveSecret(%q) didn't fail", tc.uri))
+			test.AssertEquals(t, err.Error(), tc.err)
+			continue
+		}
+		test.AssertNotError(t, err, fmt.Sprintf("ResolveSecret(%q) failed", tc.uri))
+		test.AssertEquals(t, secret, tc.expected)
+	}
+
+	RegisterSecretResolver("kms", func(ref string) (string, error) { return "kms-" + ref, nil })
+	secret, err := ResolveSecret("kms:key")
+	test.AssertNotError(t, err, "ResolveSecret failed with a registered scheme")
+	test.AssertEquals(t, secret, "kms-key")
+}
+
+func TestVaultSecret(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/boulder/db":
+			fmt.Fprint(w, `{"data":{"data":{"password":"kv2"},"metadata":{"version":1}}}`)
+		case "/v1/kv/boulder/db":
+			fmt.Fprint(w, `{"data":{"password":"kv1"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	os.Setenv("VAULT_ADDR", srv.URL)
+	defer os.Unsetenv("VAULT_ADDR")
+	os.Setenv("VAULT_TOKEN", "token")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	secret, err := ResolveSecret("vault:secret/data/boulder/db#password")
+	test.AssertNotError(t, err, "reading KV version 2 secret")
+	test.AssertEquals(t, secret, "kv2")
+	secret, err = ResolveSecret("vault:kv/boulder/db#password")
+	test.AssertNotError(t, err, "reading KV version 1 secret")
+	test.AssertEquals(t, secret, "kv1")
+
+	_, err = ResolveSecret("vault:kv/boulder/db#username")
+	test.AssertError(t, err, "missing field didn't fail")
+	_, err = ResolveSecret("vault:kv/boulder/missing#password")
+	test.AssertError(t, err, "missing secret didn't fail")
+	_, err = ResolveSecret("vault:kv/boulder/db")
+	test.AssertError(t, err, "secret URI without a field didn't fail")
+
+	os.Setenv("VAULT_TOKEN", "wrong")
+	_, err = ResolveSecret("vault:kv/boulder/db#password")
+	test.AssertError(t, err, "wrong token didn't fail")
+}
@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
@@ -33,19 +34,39 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"google.golang.org/grpc/grpclog"
 
 	cfsslLog "github.com/cloudflare/cfssl/log"
 	"github.com/go-sql-driver/mysql"
+	"github.com/jmhodges/clock"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/letsencrypt/boulder/core"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/tracing"
 )
 
+// SetupTracing starts exporting the trace spans of the named service as
+// configured. Nothing is traced if conf is nil.
+func SetupTracing(conf *TracingConfig, service string, logger blog.Logger) {
+	if conf == nil {
+		return
+	}
+	if conf.Endpoint == "" {
+		FailOnError(errors.New("no Endpoint configured"), "Failed to set up tracing")
+	}
+	interval := conf.ExportInterval.Duration
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	exporter := tracing.NewOTLPExporter(conf.Endpoint)
+	tracing.Set(tracing.NewTracer(service, conf.SampleRate, exporter, interval, logger))
+}
+
 // Because we don't know when this init will be called with respect to
 // flag.Parse() and other flag definitions, we can't rely on the regular
 // flag mechanism. But this one is fine.
@@ -141,8 +162,19 @@ func NewLogger(logConf SyslogConfig) blog.Logger {
 	if logConf.SyslogLevel != 0 {
 		syslogLevel = logConf.SyslogLevel
 	}
-	logger, err := blog.New(syslogger, logConf.StdoutLevel, syslogLevel)
+	var logger blog.Logger
+	switch logConf.Format {
+	case "", "text":
+		logger, err = blog.New(syslogger, logConf.StdoutLevel, syslogLevel)
+	case "json":
+		logger, err = blog.NewJSON(syslogger, logConf.StdoutLevel, syslogLevel)
+	default:
+		err = fmt.Errorf("unknown log format %q", logConf.Format)
+	}
 	FailOnError(err, "Could not connect to Syslog")
+	if logConf.SuppressRepeats.Duration > 0 {
+		logger = blog.NewSuppressing(logger, logConf.SuppressRepeats.Duration, clock.Default())
+	}
 
 	_ = blog.Set(logger)
 	cfsslLog.SetLogger(cfsslLogger{logger})
@@ -173,6 +205,7 @@ func newScope(addr string, logger blog.Logger) metrics.Scope {
 	mux.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
 
 	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/debug/features", featuresHandler())
 	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{
 		ErrorLog: promLogger{logger},
 	}))
@@ -230,7 +263,20 @@ func ReadConfigFile(filename string, out interface{}) error {
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(configData, out)
+	return ParseConfig(configData, out)
+}
+
+// ParseConfig unmarshals a JSON config into out, which must be a pointer to a
+// config struct, and checks it with ValidateConfig. Keys that don't match any
+// field are rejected, so that a misspelt key is reported rather than leaving
+// the field it was meant for unset.
+func ParseConfig(configData []byte, out interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(configData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("parsing config: %s", err)
+	}
+	return ValidateConfig(out)
 }
 
 // VersionString produces a friendly Application version string.
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/ca"
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+var usageString = `
+name:
+  signing-log-checker - Reconciles the signing records in boulder-ca logs against the database
+
+usage:
+  signing-log-checker --config <path> --log-file <path>
+
+Each signed precertificate, certificate or OCSP response must be for a
+certificate stored in the database with the logged names. A precertificate
+without a certificate is a problem too: it may have been submitted to CT logs,
+so it has to be accounted for even if issuance failed after it was signed.
+Exits with status 1 if any problems are found.
+`
+
+type config struct {
+	TLS       cmd.TLSConfig
+	SAService *cmd.GRPCClientConfig
+	Syslog    cmd.SyslogConfig
+	Features  map[string]bool
+}
+
+type certificateStorage interface {
+	GetCertificate(ctx context.Context, serial string) (core.Certificate, error)
+}
+
+var errMalformedRecord = errors.New("malformed signing record")
+
+// parseRecord returns the signing record in a boulder-ca log line, and false
+// if the line doesn't have one. Both text and JSON format lines are
+// understood.
+func parseRecord(line string) (ca.SigningRecord, bool, error) {
+	var rec ca.SigningRecord
+	if !strings.Contains(line, ca.SigningRecordMessage) {
+		return rec, false, nil
+	}
+	if i := strings.Index(line, ca.SigningRecordMessage+" JSON="); i != -1 {
+		obj := line[i+len(ca.SigningRecordMessage+" JSON="):]
+		if err := json.Unmarshal([]byte(obj), &rec); err != nil {
+			return rec, true, errMalformedRecord
+		}
+		return rec, true, nil
+	}
+	// In JSON format the record is the fields of the log entry, which may
+	// be preceded by a syslog header.
+	start := strings.Index(line, "{")
+	if start == -1 {
+		return rec, true, errMalformedRecord
+	}
+	var entry struct {
+		Message string          `json:"msg"`
+		Fields  json.RawMessage `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(line[start:]), &entry); err != nil {
+		return rec, true, errMalformedRecord
+	}
+	if entry.Message != ca.SigningRecordMessage {
+		// The message merely mentions signing records.
+		return rec, false, nil
+	}
+	if err := json.Unmarshal(entry.Fields, &rec); err != nil {
+		return rec, true, errMalformedRecord
+	}
+	return rec, true, nil
+}
+
+// checker reconciles signing records against the certificates in the
+// database.
+type checker struct {
+	sa  certificateStorage
+	log blog.Logger
+	// results counts the records checked by result: ok or problem.
+	results map[string]int
+}
+
+func newChecker(sa certificateStorage, logger blog.Logger) *checker {
+	return &checker{sa: sa, log: logger, results: make(map[string]int)}
+}
+
+// checkRecord returns an error describing the problem with rec, or nil if
+// it matches the database.
+func (c *checker) checkRecord(ctx context.Context, rec ca.SigningRecord) error {
+	switch rec.Purpose {
+	case "precertificate", "certificate", "ocsp":
+	default:
+		return fmt.Errorf("unknown purpose %q", rec.Purpose)
+	}
+	stored, err := c.sa.GetCertificate(ctx, rec.Serial)
+	if berrors.Is(err, berrors.NotFound) {
+		return errors.New("certificate isn't in the database")
+	} else if err != nil {
+		return fmt.Errorf("looking up certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(stored.DER)
+	if err != nil {
+		return fmt.Errorf("parsing stored certificate: %s", err)
+	}
+	if hex.EncodeToString(core.HashNames(cert.DNSNames)) != rec.NamesHash {
+		return errors.New("names don't match the stored certificate")
+	}
+	return nil
+}
+
+// handle checks the signing record in line, if there is one, and counts the
+// result.
+func (c *checker) handle(ctx context.Context, line string) {
+	rec, found, err := parseRecord(line)
+	if !found {
+		return
+	}
+	if err == nil {
+		err = c.checkRecord(ctx, rec)
+	}
+	if err != nil {
+		c.results["problem"]++
+		c.log.AuditErr(fmt.Sprintf("Signing record problem: %s: purpose=[%s] serial=[%s] line=[%s]",
+			err, rec.Purpose, rec.Serial, line))
+		return
+	}
+	c.results["ok"]++
+}
+
+func main() {
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	logPath := flag.String("log-file", "", "Path to boulder-ca log file to check")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\nargs:\n", usageString)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *configFile == "" || *logPath == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var conf config
+	err := cmd.ReadConfigFile(*configFile, &conf)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(conf.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+	logger := cmd.NewLogger(conf.Syslog)
+
+	tlsConfig, err := conf.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+	clientMetrics := bgrpc.NewClientMetrics(metrics.NewNoopScope())
+	conn, err := bgrpc.ClientSetup(conf.SAService, tlsConfig, clientMetrics)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
+	sac := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(conn))
+
+	f, err := os.Open(*logPath)
+	cmd.FailOnError(err, "Failed to open log file")
+	defer func() { _ = f.Close() }()
+
+	c := newChecker(sac, logger)
+	ctx := context.Background()
+	scanner := bufio.NewScanner(f)
+	// Signing records are short, but they share the log with lines holding
+	// whole certificates and CSRs.
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		c.handle(ctx, scanner.Text())
+	}
+	cmd.FailOnError(scanner.Err(), "Failed to read log file")
+
+	logger.Info(fmt.Sprintf("Checked signing records: ok=%d problems=%d",
+		c.results["ok"], c.results["problem"]))
+	if c.results["problem"] > 0 {
+		os.Exit(1)
+	}
+}
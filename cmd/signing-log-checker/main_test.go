@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/ca"
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type mockSA struct {
+	certs map[string][]byte
+}
+
+func (sa *mockSA) GetCertificate(_ context.Context, serial string) (core.Certificate, error) {
+	der, ok := sa.certs[serial]
+	if !ok {
+		return core.Certificate{}, berrors.NotFoundError("no certificate %s", serial)
+	}
+	return core.Certificate{Serial: serial, DER: der}, nil
+}
+
+// recordLine returns a text format log line for rec.
+func recordLine(t *testing.T, rec ca.SigningRecord) string {
+	obj, err := json.Marshal(rec)
+	test.AssertNotError(t, err, "Failed to marshal record")
+	return fmt.Sprintf("Jan  1 00:00:00 ca boulder-ca[1]: 6 boulder-ca abcdef [AUDIT] %s JSON=%s", ca.SigningRecordMessage, obj)
+}
+
+func TestParseRecord(t *testing.T) {
+	rec := ca.SigningRecord{Purpose: "certificate", Serial: "00ff", Issuer: "issuer"}
+	parsed, found, err := parseRecord(recordLine(t, rec))
+	test.Assert(t, found, "Didn't find record in text line")
+	test.AssertNotError(t, err, "Failed to parse text line")
+	test.AssertDeepEquals(t, parsed, rec)
+
+	fields, _ := json.Marshal(rec)
+	jsonLine := fmt.Sprintf(`Jan  1 00:00:00 ca boulder-ca[1]: {"level":"INFO","msg":%q,"audit":true,"fields":%s}`, ca.SigningRecordMessage, fields)
+	parsed, found, err = parseRecord(jsonLine)
+	test.Assert(t, found, "Didn't find record in JSON line")
+	test.AssertNotError(t, err, "Failed to parse JSON line")
+	test.AssertDeepEquals(t, parsed, rec)
+
+	_, found, _ = parseRecord(`{"level":"ERR","msg":"Signing record problem: certificate isn't in the database"}`)
+	test.Assert(t, !found, "Found record in a line mentioning signing records")
+	_, found, _ = parseRecord("[AUDIT] Signing: serial=[00ff]")
+	test.Assert(t, !found, "Found record in a line without one")
+	_, found, err = parseRecord(ca.SigningRecordMessage + ` JSON={"purpose":`)
+	test.Assert(t, found, "Didn't find truncated record")
+	test.AssertEquals(t, err, errMalformedRecord)
+}
+
+func TestCheck(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	test.AssertNotError(t, err, "Failed to generate key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(255),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "Failed to create certificate")
+	serial := core.SerialToString(template.SerialNumber)
+	namesHash := hex.EncodeToString(core.HashNames(template.DNSNames))
+
+	log := blog.NewMock()
+	c := newChecker(&mockSA{certs: map[string][]byte{serial: der}}, log)
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		rec  ca.SigningRecord
+		err  string
+	}{
+		{
+			name: "certificate",
+			rec:  ca.SigningRecord{Purpose: "certificate", Serial: serial, NamesHash: namesHash, Issuer: "issuer"},
+		},
+		{
+			name: "ocsp",
+			rec:  ca.SigningRecord{Purpose: "ocsp", Serial: serial, NamesHash: namesHash, Issuer: "issuer"},
+		},
+		{
+			name: "precertificate",
+			rec:  ca.SigningRecord{Purpose: "precertificate", Serial: serial, NamesHash: namesHash, Issuer: "issuer"},
+		},
+		{
+			name: "unissued precertificate",
+			rec:  ca.SigningRecord{Purpose: "precertificate", Serial: "0123", NamesHash: namesHash, Issuer: "issuer"},
+			err:  "certificate isn't in the database",
+		},
+		{
+			name: "missing certificate",
+			rec:  ca.SigningRecord{Purpose: "certificate", Serial: "0123", NamesHash: namesHash, Issuer: "issuer"},
+			err:  "certificate isn't in the database",
+		},
+		{
+			name: "missing OCSP certificate",
+			rec:  ca.SigningRecord{Purpose: "ocsp", Serial: "0123", NamesHash: namesHash, Issuer: "issuer"},
+			err:  "certificate isn't in the database",
+		},
+		{
+			name: "different names",
+			rec:  ca.SigningRecord{Purpose: "precertificate", Serial: serial, NamesHash: hex.EncodeToString(core.HashNames([]string{"example.com"})), Issuer: "issuer"},
+			err:  "names don't match the stored certificate",
+		},
+		{
+			name: "unknown purpose",
+			rec:  ca.SigningRecord{Purpose: "crl", Serial: serial, Issuer: "issuer"},
+			err:  `unknown purpose "crl"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := c.checkRecord(ctx, tc.rec)
+			if tc.err == "" {
+				test.AssertNotError(t, err, "checkRecord failed")
+			} else {
+				test.AssertError(t, err, "checkRecord didn't fail")
+				test.AssertEquals(t, err.Error(), tc.err)
+			}
+			c.handle(ctx, recordLine(t, tc.rec))
+		})
+	}
+	test.AssertDeepEquals(t, c.results, map[string]int{"ok": 3, "problem": 5})
+	test.AssertEquals(t, len(log.GetAllMatching("Signing record problem: certificate isn't in the database")), 3)
+
+	// A mangled record is a problem, but other lines are ignored
+	c.handle(ctx, ca.SigningRecordMessage+` JSON={"purpose":`)
+	c.handle(ctx, "[AUDIT] Signing: serial=[00ff]")
+	test.AssertEquals(t, c.results["problem"], 6)
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ConfigValidator is implemented by config structs with constraints that
+// can't be expressed with validate tags, such as fields that are required
+// only in combination with others.
+type ConfigValidator interface {
+	Validate() error
+}
+
+// ValidateConfig checks the constraints on the fields of config, a pointer
+// to a config struct, returning an error describing every field that doesn't
+// meet them. Constraints are given by a field's validate tag, a comma
+// separated list of:
+//
+//	required    the field must not be the zero value
+//	min=N       a number or ConfigDuration must be at least N
+//	max=N       a number or ConfigDuration must be at most N
+//	oneof=A B   a string must be empty or one of the space separated values
+//
+// and by the Validate method of any struct implementing ConfigValidator.
+// Nested structs, and the elements of pointers, slices and maps, are checked
+// too.
+func ValidateConfig(config interface{}) error {
+	var problems []string
+	validateValue(reflect.ValueOf(config), "", &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+var configDurationType = reflect.TypeOf(ConfigDuration{})
+
+func validateValue(v reflect.Value, path string, problems *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			validateValue(v.Elem(), path, problems)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			validateValue(v.MapIndex(key), fmt.Sprintf("%s[%v]", path, key), problems)
+		}
+	case reflect.Struct:
+		if v.Type() == configDurationType {
+			return
+		}
+		validateStruct(v, path, problems)
+	}
+}
+
+func validateStruct(v reflect.Value, path string, problems *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// Unexported fields aren't set from the config file.
+			continue
+		}
+		fieldPath := path
+		if !field.Anonymous {
+			fieldPath = joinPath(path, jsonName(field))
+		}
+		if tag := field.Tag.Get("validate"); tag != "" {
+			for _, problem := range checkTag(v.Field(i), tag) {
+				*problems = append(*problems, fmt.Sprintf("%s %s", fieldPath, problem))
+			}
+		}
+		validateValue(v.Field(i), fieldPath, problems)
+	}
+
+	// Maps and slices hold values that aren't addressable, so Validate is
+	// only called on those whose method has a value receiver.
+	var validator ConfigValidator
+	if v.CanAddr() && v.Addr().CanInterface() {
+		validator, _ = v.Addr().Interface().(ConfigValidator)
+	} else if v.CanInterface() {
+		validator, _ = v.Interface().(ConfigValidator)
+	}
+	if validator != nil {
+		if err := validator.Validate(); err != nil {
+			name := path
+			if name == "" {
+				name = "config"
+			}
+			*problems = append(*problems, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+}
+
+// jsonName returns the key a field is given in config files: its JSON tag
+// if it has one, or otherwise its name with any leading initialism
+// lowercased, as our config files are written, e.g. "saService" for
+// SAService.
+func jsonName(field reflect.StructField) string {
+	if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" {
+		return tag
+	}
+	runes := []rune(field.Name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) {
+		// The last capital starts the next word.
+		upper--
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// checkTag returns a description of each constraint in tag that v doesn't
+// meet.
+func checkTag(v reflect.Value, tag string) []string {
+	var problems []string
+	for _, constraint := range strings.Split(tag, ",") {
+		kv := strings.SplitN(constraint, "=", 2)
+		switch kv[0] {
+		case "required":
+			if reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface()) {
+				problems = append(problems, "is required")
+			}
+		case "min", "max":
+			if len(kv) != 2 {
+				panic(fmt.Sprintf("validate tag %q has no bound", constraint))
+			}
+			value, bound, format, ok := numbers(v, kv[1])
+			if !ok {
+				panic(fmt.Sprintf("validate tag %q used on a %s", constraint, v.Type()))
+			}
+			if kv[0] == "min" && value < bound {
+				problems = append(problems, "must be at least "+format(bound))
+			} else if kv[0] == "max" && value > bound {
+				problems = append(problems, "must be at most "+format(bound))
+			}
+		case "oneof":
+			if len(kv) != 2 || v.Kind() != reflect.String {
+				panic(fmt.Sprintf("validate tag %q used on a %s", constraint, v.Type()))
+			}
+			allowed := strings.Fields(kv[1])
+			if v.String() == "" {
+				continue
+			}
+			found := false
+			for _, a := range allowed {
+				found = found || v.String() == a
+			}
+			if !found {
+				problems = append(problems, fmt.Sprintf("is %q, but must be one of %q", v.String(), allowed))
+			}
+		default:
+			panic(fmt.Sprintf("unknown validate constraint %q", constraint))
+		}
+	}
+	return problems
+}
+
+// numbers returns v and bound as float64s for comparison, along with a
+// function to format the bound in error messages.
+func numbers(v reflect.Value, bound string) (float64, float64, func(float64) string, bool) {
+	formatNumber := func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b, err := strconv.ParseFloat(bound, 64)
+		return float64(v.Int()), b, formatNumber, err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b, err := strconv.ParseFloat(bound, 64)
+		return float64(v.Uint()), b, formatNumber, err == nil
+	case reflect.Float32, reflect.Float64:
+		b, err := strconv.ParseFloat(bound, 64)
+		return v.Float(), b, formatNumber, err == nil
+	}
+	if v.Type() == configDurationType {
+		b, err := time.ParseDuration(bound)
+		formatDuration := func(f float64) string { return time.Duration(f).String() }
+		return float64(v.Interface().(ConfigDuration).Duration), float64(b), formatDuration, err == nil
+	}
+	return 0, 0, nil, false
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+type validatedBackend struct {
+	Name    string `validate:"required"`
+	Weight  int    `validate:"min=1,max=10"`
+	invalid bool
+}
+
+func (b validatedBackend) Validate() error {
+	if b.invalid {
+		return errors.New("backend is invalid")
+	}
+	return nil
+}
+
+type validatedConfig struct {
+	Service struct {
+		ServiceConfig
+		Timeout  ConfigDuration `validate:"min=1s,max=1m"`
+		Mode     string         `validate:"oneof=fast slow"`
+		Backends []validatedBackend
+		Named    map[string]*validatedBackend
+		Renamed  string `json:"otherName" validate:"required"`
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	var c validatedConfig
+	c.Service.Timeout = ConfigDuration{time.Second}
+	c.Service.Mode = "fast"
+	c.Service.Renamed = "set"
+	c.Service.Backends = []validatedBackend{{Name: "a", Weight: 1}}
+	c.Service.Tracing = &TracingConfig{Endpoint: "http://localhost:4318", SampleRate: 0.5}
+	test.AssertNotError(t, ValidateConfig(&c), "valid config was rejected")
+
+	c.Service.Timeout = ConfigDuration{time.Hour}
+	c.Service.Mode = "medium"
+	c.Service.Renamed = ""
+	c.Service.Backends = append(c.Service.Backends, validatedBackend{Weight: 11, invalid: true})
+	c.Service.Named = map[string]*validatedBackend{"b": {Name: "b"}}
+	c.Service.Tracing.SampleRate = 2
+	err := ValidateConfig(&c)
+	test.AssertError(t, err, "invalid config was accepted")
+	test.AssertEquals(t, err.Error(), "invalid config: "+
+		"service.tracing.sampleRate must be at most 1; "+
+		"service.timeout must be at most 1m0s; "+
+		`service.mode is "medium", but must be one of ["fast" "slow"]; `+
+		"service.backends[1].name is required; "+
+		"service.backends[1].weight must be at most 10; "+
+		"service.backends[1]: backend is invalid; "+
+		"service.named[b].weight must be at least 1; "+
+		"service.otherName is required")
+}
+
+func TestParseConfig(t *testing.T) {
+	var c struct {
+		Syslog    SyslogConfig
+		SAService *GRPCClientConfig
+	}
+	err := ParseConfig([]byte(`{"syslog": {"stdoutlevel": 6}}`), &c)
+	test.AssertNotError(t, err, "valid config was rejected")
+	test.AssertEquals(t, c.Syslog.StdoutLevel, 6)
+
+	err = ParseConfig([]byte(`{"syslog": {"stdoutLevle": 6}}`), &c)
+	test.AssertError(t, err, "misspelt key was accepted")
+	test.AssertEquals(t, err.Error(), `parsing config: json: unknown field "stdoutLevle"`)
+
+	err = ParseConfig([]byte(`{"syslog": {"stdoutlevel": 8}, "saService": {"timeout": "15s"}}`), &c)
+	test.AssertError(t, err, "invalid config was accepted")
+	test.AssertEquals(t, err.Error(), "invalid config: syslog.stdoutLevel must be at most 7; "+
+		"saService: one of serverAddresses and srvLookup is required")
+}
@@ -86,6 +86,9 @@ type RegistrationAuthority interface {
 	// [WebFrontEnd]
 	FinalizeOrder(ctx context.Context, req *rapb.FinalizeOrderRequest) (*corepb.Order, error)
 
+	// [WebFrontEnd]
+	CheckNewOrder(ctx context.Context, req *rapb.NewOrderRequest) error
+
 	// [AdminRevoker]
 	AdministrativelyRevokeCertificate(ctx context.Context, cert x509.Certificate, code revocation.Reason, adminName string) error
 }
@@ -106,8 +109,8 @@ type CertificateAuthority interface {
 
 // PolicyAuthority defines the public interface for the Boulder PA
 type PolicyAuthority interface {
-	WillingToIssue(domain AcmeIdentifier) error
-	WillingToIssueWildcard(domain AcmeIdentifier) error
+	WillingToIssue(ctx context.Context, domain AcmeIdentifier, registrationID int64) error
+	WillingToIssueWildcard(ctx context.Context, domain AcmeIdentifier, registrationID int64) error
 	ChallengesFor(domain AcmeIdentifier, registrationID int64, revalidation bool) (challenges []Challenge, validCombinations [][]int, err error)
 	ChallengeTypeEnabled(t string, registrationID int64) bool
 }
@@ -121,6 +124,7 @@ type StorageGetter interface {
 	GetPendingAuthorization(ctx context.Context, req *sapb.GetPendingAuthorizationRequest) (*Authorization, error)
 	GetCertificate(ctx context.Context, serial string) (Certificate, error)
 	GetCertificateStatus(ctx context.Context, serial string) (CertificateStatus, error)
+	GetCertificateStatuses(ctx context.Context, serials []string) ([]CertificateStatus, error)
 	CountCertificatesRange(ctx context.Context, earliest, latest time.Time) (int64, error)
 	CountCertificatesByNames(ctx context.Context, domains []string, earliest, latest time.Time) (countByDomain []*sapb.CountByNames_MapElement, err error)
 	CountCertificatesByExactNames(ctx context.Context, domains []string, earliest, latest time.Time) (countByDomain []*sapb.CountByNames_MapElement, err error)
@@ -129,14 +133,22 @@ type StorageGetter interface {
 	CountPendingAuthorizations(ctx context.Context, regID int64) (int, error)
 	CountOrders(ctx context.Context, acctID int64, earliest, latest time.Time) (int, error)
 	GetSCTReceipt(ctx context.Context, serial, logID string) (SignedCertificateTimestamp, error)
+	GetSCTReceipts(ctx context.Context, serial string) ([]SignedCertificateTimestamp, error)
 	CountFQDNSets(ctx context.Context, window time.Duration, domains []string) (count int64, err error)
 	FQDNSetExists(ctx context.Context, domains []string) (exists bool, err error)
+	FQDNSetIssuedForAccount(ctx context.Context, req *sapb.FQDNSetIssuedForAccountRequest) (*sapb.Exists, error)
 	PreviousCertificateExists(ctx context.Context, req *sapb.PreviousCertificateExistsRequest) (exists *sapb.Exists, err error)
 	GetOrder(ctx context.Context, req *sapb.OrderRequest) (*corepb.Order, error)
 	GetOrderForNames(ctx context.Context, req *sapb.GetOrderForNamesRequest) (*corepb.Order, error)
 	GetValidOrderAuthorizations(ctx context.Context, req *sapb.GetValidOrderAuthorizationsRequest) (map[string]*Authorization, error)
 	CountInvalidAuthorizations(ctx context.Context, req *sapb.CountInvalidAuthorizationsRequest) (count *sapb.Count, err error)
+	CountFailedValidations(ctx context.Context, req *sapb.CountFailedValidationsRequest) (*sapb.Count, error)
 	GetAuthorizations(ctx context.Context, req *sapb.GetAuthorizationsRequest) (*sapb.Authorizations, error)
+	KeyBlocked(ctx context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error)
+	GetSerialsByKey(ctx context.Context, req *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error)
+	GetRateLimitOverrides(ctx context.Context, req *corepb.Empty) (*sapb.RateLimitOverrides, error)
+	PolicyOverridden(ctx context.Context, req *sapb.PolicyOverriddenRequest) (*sapb.Exists, error)
+	GetAccountStats(ctx context.Context, req *sapb.RegistrationID) (*sapb.AccountStats, error)
 }
 
 // StorageAdder are the Boulder SA's write/update methods
@@ -147,16 +159,20 @@ type StorageAdder interface {
 	UpdatePendingAuthorization(ctx context.Context, authz Authorization) error
 	FinalizeAuthorization(ctx context.Context, authz Authorization) error
 	MarkCertificateRevoked(ctx context.Context, serial string, reasonCode revocation.Reason) error
-	AddCertificate(ctx context.Context, der []byte, regID int64, ocsp []byte) (digest string, err error)
+	AddCertificate(ctx context.Context, der []byte, regID int64, ocsp []byte, issued *time.Time) (digest string, err error)
 	AddSCTReceipt(ctx context.Context, sct SignedCertificateTimestamp) error
 	RevokeAuthorizationsByDomain(ctx context.Context, domain AcmeIdentifier) (finalized, pending int64, err error)
 	DeactivateRegistration(ctx context.Context, id int64) error
 	DeactivateAuthorization(ctx context.Context, id string) error
 	NewOrder(ctx context.Context, order *corepb.Order) (*corepb.Order, error)
+	NewOrderAndAuthzs(ctx context.Context, req *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error)
 	SetOrderProcessing(ctx context.Context, order *corepb.Order) error
 	FinalizeOrder(ctx context.Context, order *corepb.Order) error
 	AddPendingAuthorizations(ctx context.Context, req *sapb.AddPendingAuthorizationsRequest) (*sapb.AuthorizationIDs, error)
 	SetOrderError(ctx context.Context, order *corepb.Order) error
+	AddBlockedKey(ctx context.Context, req *sapb.AddBlockedKeyRequest) (*corepb.Empty, error)
+	AddVerifiedContact(ctx context.Context, req *sapb.AddVerifiedContactRequest) (*corepb.Empty, error)
+	AddCTSubmission(ctx context.Context, req *sapb.AddCTSubmissionRequest) (*corepb.Empty, error)
 }
 
 // StorageAuthority interface represents a simple key/value
@@ -12,6 +12,7 @@ import (
 
 	"gopkg.in/square/go-jose.v2"
 
+	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/probs"
 	"github.com/letsencrypt/boulder/revocation"
 )
@@ -26,7 +27,7 @@ type AcmeResource string
 type Buffer []byte
 
 // IdentifierType defines the available identification mechanisms for domains
-type IdentifierType string
+type IdentifierType = identifier.IdentifierType
 
 // OCSPStatus defines the state of OCSP for a domain
 type OCSPStatus string
@@ -44,7 +45,7 @@ const (
 
 // These types are the available identification mechanisms
 const (
-	IdentifierDNS = IdentifierType("dns")
+	IdentifierDNS = identifier.DNS
 )
 
 // The types of ACME resources
@@ -98,10 +99,7 @@ const DNSPrefix = "_acme-challenge"
 // types of identifier to be supported (DNS names, IP
 // addresses, etc.), but currently we only support
 // domain names.
-type AcmeIdentifier struct {
-	Type  IdentifierType `json:"type"`  // The type of identifier being encoded
-	Value string         `json:"value"` // The identifier itself
-}
+type AcmeIdentifier = identifier.ACMEIdentifier
 
 // CertificateRequest is just a CSR
 //
@@ -193,6 +191,15 @@ type ValidationRecord struct {
 	//   ...
 	// }
 	AddressesTried []net.IP `json:"addressesTried,omitempty"`
+	// SourceAddress is the local address the VA connected from, if it was
+	// bound to one from its configured source address pool.
+	SourceAddress net.IP `json:"sourceAddress,omitempty"`
+
+	// HTTP-01 only
+	// UserAgent and Accept are the headers the VA sent, so failures caused by
+	// CDNs and firewalls filtering requests on them can be diagnosed.
+	UserAgent string `json:"userAgent,omitempty"`
+	Accept    string `json:"accept,omitempty"`
 }
 
 func looksLikeKeyAuthorization(str string) error {
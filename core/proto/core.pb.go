@@ -118,8 +118,13 @@ type ValidationRecord struct {
 	// A list of addresses tried before the address used (see
 	// core/objects.go and the comment on the ValidationRecord structure
 	// definition for more information.
-	AddressesTried   [][]byte `protobuf:"bytes,7,rep,name=addressesTried" json:"addressesTried,omitempty"`
-	XXX_unrecognized []byte   `json:"-"`
+	AddressesTried [][]byte `protobuf:"bytes,7,rep,name=addressesTried" json:"addressesTried,omitempty"`
+	// The local address the VA connected from, if it was bound to one.
+	SourceAddress []byte `protobuf:"bytes,8,opt,name=sourceAddress" json:"sourceAddress,omitempty"`
+	// The User-Agent and Accept headers of HTTP-01 validation requests.
+	UserAgent        *string `protobuf:"bytes,9,opt,name=userAgent" json:"userAgent,omitempty"`
+	Accept           *string `protobuf:"bytes,10,opt,name=accept" json:"accept,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
 }
 
 func (m *ValidationRecord) Reset()                    { *m = ValidationRecord{} }
@@ -176,10 +181,33 @@ func (m *ValidationRecord) GetAddressesTried() [][]byte {
 	return nil
 }
 
+func (m *ValidationRecord) GetSourceAddress() []byte {
+	if m != nil {
+		return m.SourceAddress
+	}
+	return nil
+}
+
+func (m *ValidationRecord) GetUserAgent() string {
+	if m != nil && m.UserAgent != nil {
+		return *m.UserAgent
+	}
+	return ""
+}
+
+func (m *ValidationRecord) GetAccept() string {
+	if m != nil && m.Accept != nil {
+		return *m.Accept
+	}
+	return ""
+}
+
 type ProblemDetails struct {
 	ProblemType      *string `protobuf:"bytes,1,opt,name=problemType" json:"problemType,omitempty"`
 	Detail           *string `protobuf:"bytes,2,opt,name=detail" json:"detail,omitempty"`
 	HttpStatus       *int32  `protobuf:"varint,3,opt,name=httpStatus" json:"httpStatus,omitempty"`
+	IdentifierType   *string `protobuf:"bytes,4,opt,name=identifierType" json:"identifierType,omitempty"`
+	IdentifierValue  *string `protobuf:"bytes,5,opt,name=identifierValue" json:"identifierValue,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -209,6 +237,20 @@ func (m *ProblemDetails) GetHttpStatus() int32 {
 	return 0
 }
 
+func (m *ProblemDetails) GetIdentifierType() string {
+	if m != nil && m.IdentifierType != nil {
+		return *m.IdentifierType
+	}
+	return ""
+}
+
+func (m *ProblemDetails) GetIdentifierValue() string {
+	if m != nil && m.IdentifierValue != nil {
+		return *m.IdentifierValue
+	}
+	return ""
+}
+
 type Certificate struct {
 	RegistrationID   *int64  `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
 	Serial           *string `protobuf:"bytes,2,opt,name=serial" json:"serial,omitempty"`
@@ -516,51 +558,54 @@ func init() {
 func init() { proto1.RegisterFile("core/proto/core.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 723 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0x41, 0x6e, 0xdb, 0x3a,
-	0x10, 0x85, 0x2c, 0x2b, 0xb6, 0xc6, 0xfe, 0x89, 0x43, 0xe4, 0x07, 0xc2, 0xc7, 0x47, 0x20, 0x68,
-	0x51, 0x08, 0x41, 0x91, 0x00, 0xb9, 0x41, 0x9a, 0x74, 0x91, 0x55, 0x0d, 0x26, 0xed, 0xa2, 0x3b,
-	0x45, 0x9a, 0xda, 0x6c, 0x64, 0xc9, 0x20, 0xe9, 0xa0, 0xee, 0x1d, 0x7a, 0x90, 0x1e, 0xa6, 0xd7,
-	0xe8, 0xb2, 0x67, 0x28, 0x66, 0x28, 0xdb, 0xb2, 0x9d, 0xa2, 0xbb, 0x99, 0x47, 0xca, 0x9c, 0x79,
-	0xef, 0xcd, 0x18, 0xfe, 0xcd, 0x6b, 0x8d, 0x97, 0x73, 0x5d, 0xdb, 0xfa, 0x92, 0xc2, 0x0b, 0x0e,
-	0x45, 0x97, 0xe2, 0xe4, 0x5b, 0x07, 0xc2, 0x9b, 0x69, 0x56, 0x96, 0x58, 0x4d, 0x50, 0x1c, 0x42,
-	0x47, 0x15, 0x91, 0x17, 0x7b, 0xa9, 0x2f, 0x3b, 0xaa, 0x10, 0x02, 0xba, 0x76, 0x39, 0xc7, 0xa8,
-	0x13, 0x7b, 0x69, 0x28, 0x39, 0x16, 0xa7, 0x70, 0x60, 0x6c, 0x66, 0x17, 0x26, 0x3a, 0x60, 0xb4,
-	0xc9, 0xc4, 0x08, 0xfc, 0x85, 0x56, 0x51, 0xc8, 0x20, 0x85, 0xe2, 0x04, 0x02, 0x5b, 0x3f, 0x61,
-	0x15, 0xf9, 0x8c, 0xb9, 0x44, 0x9c, 0xc3, 0xe8, 0x09, 0x97, 0xd7, 0x0b, 0x3b, 0xad, 0xb5, 0xfa,
-	0x9a, 0x59, 0x55, 0x57, 0x51, 0xc0, 0x17, 0xf6, 0x70, 0x71, 0x0b, 0xc7, 0xcf, 0x59, 0xa9, 0x0a,
-	0xce, 0x34, 0xe6, 0xb5, 0x2e, 0x4c, 0x04, 0xb1, 0x9f, 0x0e, 0xae, 0x4e, 0x2f, 0xb8, 0x97, 0x0f,
-	0xeb, 0x63, 0xc9, 0xc7, 0x72, 0xff, 0x03, 0x71, 0x0e, 0x01, 0x6a, 0x5d, 0xeb, 0xa8, 0x17, 0x7b,
-	0xe9, 0xe0, 0xea, 0xc4, 0x7d, 0x39, 0xd6, 0xf5, 0x63, 0x89, 0xb3, 0x5b, 0xb4, 0x99, 0x2a, 0x8d,
-	0x74, 0x57, 0x92, 0x5f, 0x1e, 0x8c, 0x76, 0x7f, 0x53, 0xfc, 0x07, 0xfd, 0x69, 0x6d, 0x6c, 0x95,
-	0xcd, 0x90, 0xc9, 0x09, 0xe5, 0x3a, 0x27, 0x8a, 0xe6, 0xb5, 0xb6, 0x2b, 0x8a, 0x28, 0x16, 0xaf,
-	0xe1, 0x38, 0x2b, 0x0a, 0x8d, 0xc6, 0xa0, 0x91, 0x68, 0xea, 0xf2, 0x19, 0x8b, 0xc8, 0x8f, 0xfd,
-	0x74, 0x28, 0xf7, 0x0f, 0x44, 0x0c, 0x83, 0x06, 0x7c, 0x6f, 0xb0, 0x88, 0xba, 0xb1, 0x97, 0x0e,
-	0x65, 0x1b, 0xe2, 0x1b, 0x8e, 0x17, 0xab, 0xd0, 0x44, 0x41, 0xec, 0xa7, 0xa1, 0x6c, 0x43, 0x8e,
-	0xfc, 0xb2, 0x51, 0x84, 0x42, 0xf1, 0x0a, 0x0e, 0xd7, 0x4f, 0x3d, 0x68, 0x85, 0x45, 0xd4, 0xe3,
-	0x02, 0x76, 0xd0, 0xe4, 0x33, 0x1c, 0x6e, 0x33, 0x41, 0xaf, 0xcd, 0x1d, 0xf2, 0x40, 0xda, 0xbb,
-	0x86, 0xdb, 0x10, 0x59, 0xa0, 0xe0, 0xcb, 0x4d, 0xd7, 0x4d, 0x26, 0xce, 0x00, 0xa6, 0xd6, 0xce,
-	0xef, 0x9d, 0x3d, 0x48, 0xf5, 0x40, 0xb6, 0x90, 0xe4, 0xbb, 0x07, 0x83, 0x1b, 0xd4, 0x56, 0x7d,
-	0x52, 0x79, 0x66, 0x91, 0x6a, 0xd4, 0x38, 0x51, 0xc6, 0x6a, 0x66, 0xfb, 0xee, 0xb6, 0xb1, 0xde,
-	0x0e, 0xca, 0x96, 0x43, 0xad, 0xb2, 0xf5, 0x7b, 0x2e, 0xe3, 0x3a, 0xd4, 0x04, 0x8d, 0x6d, 0x1c,
-	0xd6, 0x64, 0xc4, 0x46, 0x81, 0xba, 0x61, 0x92, 0x42, 0xba, 0xa9, 0x8c, 0x59, 0x60, 0xc1, 0x56,
-	0xf3, 0x65, 0x93, 0x89, 0x08, 0x7a, 0xf8, 0x65, 0xae, 0x34, 0x3a, 0x37, 0xfb, 0x72, 0x95, 0x26,
-	0x3f, 0x3d, 0x18, 0xca, 0x56, 0x19, 0x7b, 0xb3, 0x31, 0x02, 0xff, 0x09, 0x97, 0x5c, 0xd1, 0x50,
-	0x52, 0x48, 0x3f, 0x96, 0xd7, 0x95, 0xcd, 0x72, 0xcb, 0x62, 0x87, 0x72, 0x95, 0x8a, 0x14, 0x8e,
-	0x9a, 0xd0, 0x8c, 0x35, 0x1a, 0xac, 0x2c, 0x17, 0xd7, 0x97, 0xbb, 0xb0, 0xf8, 0x1f, 0xc2, 0x6c,
-	0xa2, 0x11, 0x67, 0x74, 0xc7, 0x8d, 0xc5, 0x06, 0xa0, 0x53, 0x55, 0x29, 0xab, 0xb2, 0xf2, 0x6e,
-	0xcc, 0x05, 0x0f, 0xe5, 0x06, 0xa0, 0xd3, 0x5c, 0x63, 0x66, 0xb1, 0xb8, 0xb6, 0xec, 0x75, 0x5f,
-	0x6e, 0x80, 0xd6, 0xdc, 0xf6, 0xdb, 0x73, 0x4b, 0x8e, 0xff, 0x67, 0x7b, 0xea, 0x36, 0x9d, 0x86,
-	0xdc, 0xe9, 0x19, 0x80, 0x2a, 0xb0, 0x22, 0xd9, 0x50, 0x37, 0x12, 0xb4, 0x90, 0x17, 0x64, 0xf4,
-	0xff, 0x28, 0xa3, 0xab, 0xa0, 0xbb, 0xb5, 0x39, 0x5a, 0x22, 0x04, 0x5b, 0x22, 0x88, 0x4b, 0x80,
-	0x7c, 0xb5, 0x9c, 0x48, 0x21, 0x1a, 0xfc, 0x23, 0x37, 0xbe, 0xeb, 0xa5, 0x25, 0x5b, 0x57, 0x44,
-	0x02, 0xc3, 0xbc, 0x9e, 0x3d, 0xaa, 0x8a, 0xdf, 0x34, 0xcc, 0xc2, 0x50, 0x6e, 0x61, 0xc9, 0x8f,
-	0x0e, 0x04, 0xef, 0x34, 0xb9, 0x62, 0x57, 0xd2, 0xfd, 0x46, 0x3a, 0x2f, 0x36, 0xd2, 0x2a, 0xd8,
-	0xdf, 0x2e, 0x78, 0xbd, 0x6a, 0xba, 0x7f, 0x5d, 0x35, 0xb4, 0x25, 0xf2, 0xcd, 0x30, 0xdc, 0x3b,
-	0x83, 0x3b, 0xc9, 0xf7, 0x0f, 0x78, 0x9e, 0xdb, 0x2a, 0x39, 0x3a, 0x42, 0xb9, 0x83, 0xb6, 0x48,
-	0xee, 0x6d, 0x91, 0x7c, 0x02, 0x01, 0xed, 0x2b, 0x52, 0x9f, 0x3e, 0x73, 0x09, 0x19, 0xf3, 0x11,
-	0x27, 0x59, 0x35, 0xd6, 0x75, 0x8e, 0xc6, 0xa8, 0x6a, 0xc2, 0x0b, 0xbc, 0x2f, 0x77, 0x61, 0x36,
-	0xb7, 0xf3, 0x52, 0x04, 0xae, 0xe7, 0x26, 0x4d, 0x7a, 0x10, 0xbc, 0x9d, 0xcd, 0xed, 0xf2, 0x4d,
-	0xef, 0x63, 0xc0, 0x7f, 0x2d, 0xbf, 0x03, 0x00, 0x00, 0xff, 0xff, 0xf6, 0x75, 0x46, 0x28, 0x72,
-	0x06, 0x00, 0x00,
+	// 782 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x85, 0x55, 0x5d, 0x6e, 0xd3, 0x40,
+	0x10, 0x56, 0xe2, 0xb8, 0x89, 0x37, 0x69, 0x69, 0x57, 0xa5, 0xb2, 0x10, 0x42, 0x95, 0x85, 0x50,
+	0x84, 0x50, 0x2b, 0xf5, 0x06, 0xa1, 0xe5, 0xa1, 0x4f, 0x54, 0xdb, 0xc2, 0x03, 0x6f, 0xae, 0x3d,
+	0x24, 0xab, 0x3a, 0xb6, 0xb5, 0xbb, 0xa9, 0x28, 0x77, 0xe0, 0x20, 0xdc, 0x81, 0x13, 0x20, 0x71,
+	0x0d, 0x1e, 0x39, 0x03, 0x3b, 0xb3, 0x4e, 0xfc, 0x93, 0x22, 0xde, 0x66, 0xbe, 0x1d, 0xef, 0xfc,
+	0x7c, 0xdf, 0x8e, 0xd9, 0xd3, 0xa4, 0x50, 0x70, 0x5a, 0xaa, 0xc2, 0x14, 0xa7, 0x68, 0x9e, 0x90,
+	0xc9, 0x07, 0x68, 0x47, 0xdf, 0xfa, 0x2c, 0x38, 0x5f, 0xc4, 0x59, 0x06, 0xf9, 0x1c, 0xf8, 0x1e,
+	0xeb, 0xcb, 0x34, 0xec, 0x1d, 0xf7, 0xa6, 0x9e, 0xb0, 0x16, 0xe7, 0x6c, 0x60, 0x1e, 0x4a, 0x08,
+	0xfb, 0x16, 0x09, 0x04, 0xd9, 0xfc, 0x88, 0xed, 0x68, 0x13, 0x9b, 0x95, 0x0e, 0x77, 0x08, 0xad,
+	0x3c, 0xbe, 0xcf, 0xbc, 0x95, 0x92, 0x61, 0x40, 0x20, 0x9a, 0xfc, 0x90, 0xf9, 0xa6, 0xb8, 0x83,
+	0x3c, 0xf4, 0x08, 0x73, 0x0e, 0x7f, 0xcd, 0xf6, 0xef, 0xe0, 0x61, 0xb6, 0x32, 0x8b, 0x42, 0xc9,
+	0xaf, 0xb1, 0x91, 0x45, 0x1e, 0xfa, 0x14, 0xb0, 0x85, 0xf3, 0x0b, 0x76, 0x70, 0x1f, 0x67, 0x32,
+	0x25, 0x4f, 0x81, 0xad, 0x38, 0xd5, 0x21, 0x3b, 0xf6, 0xa6, 0xe3, 0xb3, 0xa3, 0x13, 0xea, 0xe5,
+	0xe3, 0xe6, 0x58, 0xd0, 0xb1, 0xd8, 0xfe, 0xc0, 0x66, 0xf4, 0x41, 0xa9, 0x42, 0x85, 0x43, 0x9b,
+	0x66, 0x7c, 0x76, 0xe8, 0xbe, 0xbc, 0x52, 0xc5, 0x6d, 0x06, 0xcb, 0x0b, 0x30, 0xb1, 0xcc, 0xb4,
+	0x70, 0x21, 0xd1, 0xcf, 0x3e, 0xdb, 0xef, 0xde, 0xc9, 0x9f, 0xb1, 0xd1, 0xa2, 0xd0, 0x26, 0x8f,
+	0x97, 0x40, 0xc3, 0x09, 0xc4, 0xc6, 0xc7, 0x11, 0x95, 0x85, 0x32, 0xeb, 0x11, 0xa1, 0xcd, 0xdf,
+	0xb0, 0x83, 0x38, 0x4d, 0x15, 0x68, 0x0d, 0x5a, 0x80, 0x2e, 0xb2, 0x7b, 0x48, 0xed, 0x10, 0xbc,
+	0xe9, 0x44, 0x6c, 0x1f, 0xf0, 0x63, 0x36, 0xae, 0xc0, 0x0f, 0xda, 0xc6, 0x0d, 0xec, 0x45, 0x13,
+	0xd1, 0x84, 0x28, 0xc2, 0xcd, 0xc5, 0x48, 0xd0, 0x76, 0x5a, 0x9e, 0x4d, 0xd5, 0x84, 0xdc, 0xf0,
+	0xb3, 0x8a, 0x11, 0x34, 0xf9, 0x2b, 0xb6, 0xb7, 0x49, 0x75, 0xa3, 0xa4, 0xbd, 0x78, 0x48, 0x05,
+	0x74, 0x50, 0xfe, 0x92, 0xed, 0xea, 0x62, 0xa5, 0x12, 0x98, 0x39, 0x3c, 0x1c, 0x51, 0xfe, 0x36,
+	0xc8, 0x9f, 0xb3, 0x60, 0xa5, 0x41, 0xcd, 0xe6, 0x90, 0x9b, 0x8a, 0xe2, 0x1a, 0x40, 0x49, 0xc4,
+	0x49, 0x02, 0xa5, 0xb1, 0xdc, 0x90, 0x24, 0x9c, 0x17, 0xfd, 0xe8, 0xb1, 0xbd, 0xf6, 0x98, 0xb1,
+	0x95, 0xd2, 0x21, 0x37, 0x28, 0x2c, 0x37, 0xcd, 0x26, 0x84, 0x97, 0xa5, 0x14, 0x5c, 0x8d, 0xb4,
+	0xf2, 0xf8, 0x0b, 0xc6, 0x16, 0xc6, 0x94, 0xd7, 0x4e, 0x7b, 0x28, 0x29, 0x5f, 0x34, 0x10, 0x6c,
+	0x58, 0xa6, 0xb6, 0x1a, 0xf9, 0x59, 0x82, 0xa2, 0xcb, 0x07, 0xf4, 0x7d, 0x07, 0xe5, 0x53, 0xf6,
+	0xa4, 0x46, 0x2c, 0xd5, 0x2b, 0xa8, 0xe4, 0xd7, 0x85, 0xa3, 0xef, 0x3d, 0x36, 0x3e, 0x07, 0x85,
+	0x50, 0x12, 0x1b, 0xc0, 0x0c, 0x0a, 0xe6, 0x52, 0x1b, 0x45, 0xe2, 0xb8, 0xbc, 0xa8, 0x5e, 0x4a,
+	0x07, 0xa5, 0x17, 0x02, 0x4a, 0xc6, 0x9b, 0x0e, 0x9c, 0x47, 0x9d, 0xc9, 0x39, 0x68, 0x53, 0x3d,
+	0x88, 0xca, 0x43, 0xf2, 0x52, 0x50, 0x15, 0xf1, 0x68, 0x62, 0xa4, 0xd4, 0x7a, 0x65, 0x49, 0xf3,
+	0x29, 0x43, 0xe5, 0xf1, 0x90, 0x0d, 0xe1, 0x4b, 0x29, 0x2d, 0x25, 0x44, 0xb5, 0x27, 0xd6, 0x6e,
+	0xf4, 0xbb, 0xc7, 0x26, 0xa2, 0x51, 0xc6, 0xd6, 0x53, 0xb6, 0x49, 0xec, 0xf3, 0xa2, 0x8a, 0x6c,
+	0x12, 0x6b, 0xe2, 0x65, 0x49, 0x91, 0x9b, 0x38, 0x31, 0xa4, 0xcd, 0x40, 0xac, 0x5d, 0x1c, 0x51,
+	0x65, 0xea, 0x2b, 0x7b, 0x39, 0x72, 0x8e, 0xc5, 0x8d, 0x44, 0x17, 0x46, 0x5d, 0xc4, 0x73, 0x05,
+	0xb0, 0xc4, 0x18, 0x37, 0xc6, 0x1a, 0xc0, 0x53, 0x99, 0x5b, 0x81, 0xc6, 0xd9, 0xe5, 0x15, 0x15,
+	0x3c, 0x11, 0x35, 0x80, 0xa7, 0x89, 0x02, 0x3b, 0xd8, 0x74, 0x66, 0xe8, 0x69, 0x7a, 0xa2, 0x06,
+	0x1a, 0x6b, 0x66, 0xd4, 0x5c, 0x33, 0xd1, 0x9f, 0x1e, 0xdb, 0x6d, 0x2f, 0x89, 0xba, 0xd3, 0x80,
+	0x3a, 0xb5, 0x42, 0xa9, 0x99, 0xac, 0x28, 0x68, 0x20, 0x8f, 0xd0, 0xe8, 0xfd, 0x93, 0x46, 0x57,
+	0xc1, 0xa0, 0xb5, 0xe8, 0x1a, 0x24, 0xf8, 0x2d, 0x12, 0xf8, 0x29, 0x63, 0xc9, 0x7a, 0x97, 0x22,
+	0x43, 0xb8, 0xa7, 0x9e, 0xb8, 0x6d, 0xb3, 0xd9, 0xb1, 0xa2, 0x11, 0xc2, 0x23, 0x36, 0x49, 0x8a,
+	0xe5, 0xad, 0xcc, 0x29, 0xa7, 0xa6, 0x29, 0x4c, 0x44, 0x0b, 0x8b, 0x7e, 0xf5, 0x99, 0xff, 0x5e,
+	0xa1, 0x2a, 0xba, 0x94, 0x6e, 0x37, 0xd2, 0x7f, 0xb4, 0x91, 0x46, 0xc1, 0x5e, 0xbb, 0xe0, 0xcd,
+	0x66, 0x1c, 0xfc, 0x77, 0x33, 0xe2, 0x52, 0x4b, 0xea, 0xc7, 0x70, 0xed, 0x04, 0xee, 0x28, 0xdf,
+	0x3e, 0xa0, 0xf5, 0xd3, 0x64, 0xc9, 0x8d, 0xc3, 0xbe, 0xc6, 0x36, 0xda, 0x18, 0xf2, 0xb0, 0x35,
+	0x64, 0xfb, 0xef, 0xc0, 0xf5, 0x8a, 0xec, 0xe3, 0x67, 0xce, 0x41, 0x61, 0xde, 0xc2, 0x3c, 0xce,
+	0x6d, 0x85, 0x89, 0x5d, 0x4b, 0x32, 0x9f, 0xd3, 0x32, 0xb2, 0xc2, 0xec, 0xc0, 0x24, 0x6e, 0xa7,
+	0x25, 0xda, 0x49, 0xb6, 0xe7, 0xca, 0x8d, 0x86, 0xcc, 0x7f, 0xb7, 0x2c, 0xcd, 0xc3, 0xdb, 0xe1,
+	0x27, 0x9f, 0xfe, 0x84, 0x7f, 0x01, 0x5f, 0x1e, 0xf5, 0x8a, 0x21, 0x07, 0x00, 0x00,
 }
@@ -0,0 +1,22 @@
+package core
+
+import "golang.org/x/net/context"
+
+type requestIDKey struct{}
+
+// NewRequestID returns a random ID for a request, which is used to correlate
+// the log lines of the components that handle it.
+func NewRequestID() string {
+	return RandomString(12)
+}
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if it has none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
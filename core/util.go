@@ -217,6 +217,14 @@ func UniqueLowerNames(names []string) (unique []string) {
 	return
 }
 
+// HashNames returns the SHA-256 hash of the unique lowercased names, sorted
+// and joined with commas. It identifies the set of names in a certificate
+// regardless of their order or case.
+func HashNames(names []string) []byte {
+	hash := sha256.Sum256([]byte(strings.Join(UniqueLowerNames(names), ",")))
+	return hash[:]
+}
+
 // LoadCertBundle loads a PEM bundle of certificates from disk
 func LoadCertBundle(filename string) ([]*x509.Certificate, error) {
 	bundleBytes, err := ioutil.ReadFile(filename)
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -112,3 +114,10 @@ func TestUniqueLowerNames(t *testing.T) {
 	sort.Strings(u)
 	test.AssertDeepEquals(t, []string{"a.com", "bar.com", "baz.com", "foobar.com"}, u)
 }
+
+func TestHashNames(t *testing.T) {
+	expected := sha256.Sum256([]byte("a.com,b.com"))
+	test.AssertByteEquals(t, HashNames([]string{"B.com", "a.com", "b.com"}), expected[:])
+	test.Assert(t, !bytes.Equal(HashNames([]string{"a.com"}), HashNames([]string{"a.com", "b.com"})),
+		"different sets of names had the same hash")
+}
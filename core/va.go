@@ -17,4 +17,8 @@ type ValidationAuthority interface {
 	// TODO(#1626): remove authz parameter
 	PerformValidation(ctx context.Context, domain string, challenge Challenge, authz Authorization) ([]ValidationRecord, error)
 	IsSafeDomain(ctx context.Context, req *vaPB.IsSafeDomainRequest) (resp *vaPB.IsDomainSafe, err error)
+	// ReplayValidation checks the challenge again, for debugging, and returns
+	// the ValidationRecords and problem like PerformValidation. If userAgent
+	// isn't empty it replaces the configured User-Agent.
+	ReplayValidation(ctx context.Context, domain string, challenge Challenge, userAgent string) ([]ValidationRecord, error)
 }
@@ -3,11 +3,15 @@ package csr
 import (
 	"crypto"
 	"crypto/x509"
-	"errors"
 	"fmt"
 	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/idna"
 
 	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
 )
@@ -32,26 +36,89 @@ var goodSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
 	x509.ECDSAWithSHA512: true,
 }
 
+// Each of the ways a CSR can fail verification has its own error, so that the
+// detail of the problem returned to the subscriber says exactly what is wrong.
+// Problems with the key are BadPublicKey or BadCSR errors, names the policy
+// authority won't issue for are RejectedIdentifier errors, and everything else
+// is a BadCSR error.
 var (
-	invalidPubKey       = errors.New("invalid public key in CSR")
-	unsupportedSigAlg   = errors.New("signature algorithm not supported")
-	invalidSig          = errors.New("invalid signature on CSR")
-	invalidEmailPresent = errors.New("CSR contains one or more email address fields")
-	invalidIPPresent    = errors.New("CSR contains one or more IP address fields")
-	invalidNoDNS        = errors.New("at least one DNS name is required")
+	invalidPubKey       = berrors.BadCSRError("invalid public key in CSR")
+	unsupportedSigAlg   = berrors.BadCSRError("signature algorithm not supported")
+	invalidSig          = berrors.BadCSRError("invalid signature on CSR")
+	invalidEmailPresent = berrors.BadCSRError("CSR contains one or more email address fields")
+	invalidIPPresent    = berrors.BadCSRError("CSR contains one or more IP address fields")
+	invalidNoDNS        = berrors.BadCSRError("at least one DNS name is required")
+	invalidNoCN         = berrors.BadCSRError("CSR must have a subject common name")
 )
 
+// CNPolicy determines how the subject common name of a CSR is handled.
+type CNPolicy string
+
+const (
+	// CNOptional allows CSRs with or without a common name. A common name is
+	// treated as one more DNS name. This is the default.
+	CNOptional = CNPolicy("optional")
+	// CNPromote is like CNOptional, but if the CSR has no common name the
+	// first DNS name is promoted to be the common name.
+	CNPromote = CNPolicy("promote")
+	// CNRequire rejects CSRs without a common name.
+	CNRequire = CNPolicy("require")
+	// CNIgnore removes the common name from CSRs so that only the DNS names
+	// are used.
+	CNIgnore = CNPolicy("ignore")
+)
+
+// Policy configures the checks made by VerifyCSR on the names in a CSR.
+type Policy struct {
+	// MaxNames is the maximum number of DNS names, including the common name,
+	// a CSR may contain. Zero means no limit. It isn't read from config, the
+	// CA sets it from its own MaxNames setting. The RA checks its limit
+	// separately since it can be overridden per account.
+	MaxNames int `json:"-"`
+	// CommonName determines how the subject common name is handled. An empty
+	// value is the same as CNOptional.
+	CommonName CNPolicy
+	// NormalizeIDN converts internationalized names in the CSR to lowercase
+	// punycode before they are checked, instead of rejecting them.
+	NormalizeIDN bool
+	// RejectMixedCaseDuplicates rejects CSRs containing names that differ only
+	// in case, e.g. "example.com" and "Example.com", instead of silently
+	// merging them.
+	RejectMixedCaseDuplicates bool
+}
+
+// Validate returns an error if the policy is not usable.
+func (p Policy) Validate() error {
+	switch p.CommonName {
+	case "", CNOptional, CNPromote, CNRequire, CNIgnore:
+	default:
+		return fmt.Errorf("unknown common name policy %q", p.CommonName)
+	}
+	if p.MaxNames < 0 {
+		return fmt.Errorf("MaxNames can't be negative")
+	}
+	return nil
+}
+
 // VerifyCSR checks the validity of a x509.CertificateRequest. Before doing checks it normalizes
-// the CSR which lowers the case of DNS names and subject CN, and if forceCNFromSAN is true it
-// will hoist a DNS name into the CN if it is empty.
-func VerifyCSR(csr *x509.CertificateRequest, maxNames int, keyPolicy *goodkey.KeyPolicy, pa core.PolicyAuthority, forceCNFromSAN bool, regID int64) error {
-	normalizeCSR(csr, forceCNFromSAN)
+// the CSR according to the policy, which lowers the case of DNS names and subject CN, and may
+// hoist a DNS name into an empty CN or remove the CN.
+func VerifyCSR(ctx context.Context, csr *x509.CertificateRequest, policy Policy, keyPolicy *goodkey.KeyPolicy, pa core.PolicyAuthority, regID int64) error {
+	if err := normalizeCSR(csr, policy); err != nil {
+		return err
+	}
 	key, ok := csr.PublicKey.(crypto.PublicKey)
 	if !ok {
 		return invalidPubKey
 	}
-	if err := keyPolicy.GoodKey(key); err != nil {
-		return fmt.Errorf("invalid public key in CSR: %s", err)
+	if err := keyPolicy.GoodKey(ctx, key); err != nil {
+		if berrors.Is(err, berrors.BadPublicKey) {
+			return berrors.BadPublicKeyError("invalid public key in CSR: %s", err)
+		}
+		if berrors.Is(err, berrors.InternalServer) {
+			return err
+		}
+		return berrors.BadCSRError("invalid public key in CSR: %s", err)
 	}
 	if !goodSignatureAlgorithms[csr.SignatureAlgorithm] {
 		return unsupportedSigAlg
@@ -68,11 +135,14 @@ func VerifyCSR(csr *x509.CertificateRequest, maxNames int, keyPolicy *goodkey.Ke
 	if len(csr.DNSNames) == 0 && csr.Subject.CommonName == "" {
 		return invalidNoDNS
 	}
+	if policy.CommonName == CNRequire && csr.Subject.CommonName == "" {
+		return invalidNoCN
+	}
 	if len(csr.Subject.CommonName) > maxCNLength {
-		return fmt.Errorf("CN was longer than %d bytes", maxCNLength)
+		return berrors.BadCSRError("CN was longer than %d bytes", maxCNLength)
 	}
-	if maxNames > 0 && len(csr.DNSNames) > maxNames {
-		return fmt.Errorf("CSR contains more than %d DNS names", maxNames)
+	if policy.MaxNames > 0 && len(csr.DNSNames) > policy.MaxNames {
+		return berrors.BadCSRError("CSR contains more than %d DNS names", policy.MaxNames)
 	}
 	badNames := []string{}
 	for _, name := range csr.DNSNames {
@@ -83,31 +153,99 @@ func VerifyCSR(csr *x509.CertificateRequest, maxNames int, keyPolicy *goodkey.Ke
 		var err error
 		// If wildcard names are enabled then use WillingToIssueWildcard
 		if features.Enabled(features.WildcardDomains) {
-			err = pa.WillingToIssueWildcard(ident)
+			err = pa.WillingToIssueWildcard(ctx, ident, regID)
 		} else {
 			// Otherwise use WillingToIssue
-			err = pa.WillingToIssue(ident)
+			err = pa.WillingToIssue(ctx, ident, regID)
+		}
+		if berrors.Is(err, berrors.InternalServer) {
+			return err
 		}
 		if err != nil {
 			badNames = append(badNames, fmt.Sprintf("%q", name))
 		}
 	}
 	if len(badNames) > 0 {
-		return fmt.Errorf("policy forbids issuing for: %s", strings.Join(badNames, ", "))
+		return berrors.RejectedIdentifierError("policy forbids issuing for: %s", strings.Join(badNames, ", "))
 	}
 	return nil
 }
 
-// normalizeCSR deduplicates and lowers the case of dNSNames and the subject CN.
-// If forceCNFromSAN is true it will also hoist a dNSName into the CN if it is empty.
-func normalizeCSR(csr *x509.CertificateRequest, forceCNFromSAN bool) {
-	if forceCNFromSAN && csr.Subject.CommonName == "" {
-		if len(csr.DNSNames) > 0 {
+// normalizeCSR deduplicates and lowers the case of dNSNames and the subject CN,
+// converting internationalized names to punycode if the policy allows them.
+// Depending on the common name policy it also hoists a dNSName into an empty
+// CN, or removes the CN.
+func normalizeCSR(csr *x509.CertificateRequest, policy Policy) error {
+	switch policy.CommonName {
+	case CNPromote:
+		if csr.Subject.CommonName == "" && len(csr.DNSNames) > 0 {
 			csr.Subject.CommonName = csr.DNSNames[0]
 		}
-	} else if csr.Subject.CommonName != "" {
+	case CNIgnore:
+		csr.Subject.CommonName = ""
+	}
+	if policy.NormalizeIDN {
+		for i, name := range csr.DNSNames {
+			ascii, err := toASCII(name)
+			if err != nil {
+				return err
+			}
+			csr.DNSNames[i] = ascii
+		}
+		if csr.Subject.CommonName != "" {
+			ascii, err := toASCII(csr.Subject.CommonName)
+			if err != nil {
+				return err
+			}
+			csr.Subject.CommonName = ascii
+		}
+	}
+	if csr.Subject.CommonName != "" {
 		csr.DNSNames = append(csr.DNSNames, csr.Subject.CommonName)
 	}
+	// The check is made once the CN is among the names, since it is lowered
+	// and merged with them below too.
+	if policy.RejectMixedCaseDuplicates {
+		if err := checkMixedCaseDuplicates(csr.DNSNames); err != nil {
+			return err
+		}
+	}
 	csr.Subject.CommonName = strings.ToLower(csr.Subject.CommonName)
 	csr.DNSNames = core.UniqueLowerNames(csr.DNSNames)
+	return nil
+}
+
+// toASCII converts a name with non-ASCII labels to lowercase punycode. ASCII
+// names are returned unchanged so that the PA can reject them as it would
+// otherwise.
+func toASCII(name string) (string, error) {
+	ascii := true
+	for _, r := range name {
+		if r >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return name, nil
+	}
+	converted, err := idna.Lookup.ToASCII(name)
+	if err != nil {
+		return "", berrors.BadCSRError("invalid internationalized name %q: %s", name, err)
+	}
+	return converted, nil
+}
+
+// checkMixedCaseDuplicates returns an error if two names differ only in case.
+// Exact duplicates are allowed.
+func checkMixedCaseDuplicates(names []string) error {
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		if other, present := seen[lower]; present && other != name {
+			return berrors.BadCSRError("CSR contains names that differ only in case: %q and %q", other, name)
+		}
+		seen[lower] = name
+	}
+	return nil
 }
@@ -10,7 +10,10 @@ import (
 	"strings"
 	"testing"
 
+	"golang.org/x/net/context"
+
 	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/goodkey"
 	"github.com/letsencrypt/boulder/test"
 )
@@ -27,14 +30,14 @@ func (pa *mockPA) ChallengesFor(identifier core.AcmeIdentifier, registrationID i
 	return
 }
 
-func (pa *mockPA) WillingToIssue(id core.AcmeIdentifier) error {
+func (pa *mockPA) WillingToIssue(ctx context.Context, id core.AcmeIdentifier, registrationID int64) error {
 	if id.Value == "bad-name.com" || id.Value == "other-bad-name.com" {
 		return errors.New("")
 	}
 	return nil
 }
 
-func (pa *mockPA) WillingToIssueWildcard(id core.AcmeIdentifier) error {
+func (pa *mockPA) WillingToIssueWildcard(ctx context.Context, id core.AcmeIdentifier, registrationID int64) error {
 	return nil
 }
 
@@ -114,7 +117,7 @@ func TestVerifyCSR(t *testing.T) {
 			testingPolicy,
 			&mockPA{},
 			0,
-			errors.New("CN was longer than 64 bytes"),
+			berrors.BadCSRError("CN was longer than 64 bytes"),
 		},
 		{
 			signedReqWithHosts,
@@ -122,7 +125,7 @@ func TestVerifyCSR(t *testing.T) {
 			testingPolicy,
 			&mockPA{},
 			0,
-			errors.New("CSR contains more than 1 DNS names"),
+			berrors.BadCSRError("CSR contains more than 1 DNS names"),
 		},
 		{
 			signedReqWithBadNames,
@@ -130,7 +133,7 @@ func TestVerifyCSR(t *testing.T) {
 			testingPolicy,
 			&mockPA{},
 			0,
-			errors.New("policy forbids issuing for: \"bad-name.com\", \"other-bad-name.com\""),
+			berrors.RejectedIdentifierError("policy forbids issuing for: \"bad-name.com\", \"other-bad-name.com\""),
 		},
 		{
 			signedReqWithEmailAddress,
@@ -151,7 +154,7 @@ func TestVerifyCSR(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		err := VerifyCSR(c.csr, c.maxNames, c.keyPolicy, c.pa, false, c.regID)
+		err := VerifyCSR(context.Background(), c.csr, Policy{MaxNames: c.maxNames}, c.keyPolicy, c.pa, c.regID)
 		test.AssertDeepEquals(t, c.expectedError, err)
 	}
 }
@@ -159,44 +162,111 @@ func TestVerifyCSR(t *testing.T) {
 func TestNormalizeCSR(t *testing.T) {
 	cases := []struct {
 		csr           *x509.CertificateRequest
-		forceCN       bool
+		policy        Policy
 		expectedCN    string
 		expectedNames []string
 	}{
 		{
 			&x509.CertificateRequest{DNSNames: []string{"a.com"}},
-			true,
+			Policy{CommonName: CNPromote},
 			"a.com",
 			[]string{"a.com"},
 		},
 		{
 			&x509.CertificateRequest{Subject: pkix.Name{CommonName: "A.com"}, DNSNames: []string{"a.com"}},
-			true,
+			Policy{CommonName: CNPromote},
 			"a.com",
 			[]string{"a.com"},
 		},
 		{
 			&x509.CertificateRequest{DNSNames: []string{"a.com"}},
-			false,
+			Policy{},
 			"",
 			[]string{"a.com"},
 		},
 		{
 			&x509.CertificateRequest{DNSNames: []string{"a.com", "a.com"}},
-			false,
+			Policy{},
 			"",
 			[]string{"a.com"},
 		},
 		{
 			&x509.CertificateRequest{Subject: pkix.Name{CommonName: "A.com"}, DNSNames: []string{"B.com"}},
-			false,
+			Policy{},
 			"a.com",
 			[]string{"a.com", "b.com"},
 		},
+		{
+			&x509.CertificateRequest{Subject: pkix.Name{CommonName: "a.com"}, DNSNames: []string{"b.com"}},
+			Policy{CommonName: CNIgnore},
+			"",
+			[]string{"b.com"},
+		},
+		{
+			&x509.CertificateRequest{Subject: pkix.Name{CommonName: "Bücher.example"}, DNSNames: []string{"a.com"}},
+			Policy{NormalizeIDN: true},
+			"xn--bcher-kva.example",
+			[]string{"a.com", "xn--bcher-kva.example"},
+		},
+		{
+			&x509.CertificateRequest{DNSNames: []string{"a.com", "a.com"}},
+			Policy{RejectMixedCaseDuplicates: true},
+			"",
+			[]string{"a.com"},
+		},
 	}
 	for _, c := range cases {
-		normalizeCSR(c.csr, c.forceCN)
+		err := normalizeCSR(c.csr, c.policy)
+		test.AssertNotError(t, err, "normalizeCSR failed")
 		test.AssertEquals(t, c.expectedCN, c.csr.Subject.CommonName)
 		test.AssertDeepEquals(t, c.expectedNames, c.csr.DNSNames)
 	}
 }
+
+func TestNormalizeCSRErrors(t *testing.T) {
+	err := normalizeCSR(
+		&x509.CertificateRequest{DNSNames: []string{"a.com", "A.com"}},
+		Policy{RejectMixedCaseDuplicates: true})
+	test.AssertError(t, err, "normalizeCSR allowed mixed case duplicates")
+	test.Assert(t, strings.Contains(err.Error(), "differ only in case"), "wrong error")
+	test.Assert(t, berrors.Is(err, berrors.BadCSR), "wrong error type")
+
+	// The common name is checked along with the DNS names
+	err = normalizeCSR(
+		&x509.CertificateRequest{Subject: pkix.Name{CommonName: "A.com"}, DNSNames: []string{"a.com"}},
+		Policy{RejectMixedCaseDuplicates: true})
+	test.AssertError(t, err, "normalizeCSR allowed a common name differing from a DNS name only in case")
+	test.Assert(t, strings.Contains(err.Error(), "differ only in case"), "wrong error")
+
+	err = normalizeCSR(
+		&x509.CertificateRequest{DNSNames: []string{"\u0301b.example"}},
+		Policy{NormalizeIDN: true})
+	test.AssertError(t, err, "normalizeCSR allowed an invalid internationalized name")
+	test.Assert(t, strings.Contains(err.Error(), "invalid internationalized name"), "wrong error")
+}
+
+func TestVerifyCSRCommonNamePolicy(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "error generating test key")
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{"a.com"}}, priv)
+	test.AssertNotError(t, err, "error creating CSR")
+
+	parse := func() *x509.CertificateRequest {
+		csr, err := x509.ParseCertificateRequest(csrBytes)
+		test.AssertNotError(t, err, "error parsing CSR")
+		return csr
+	}
+	err = VerifyCSR(context.Background(), parse(), Policy{CommonName: CNRequire}, testingPolicy, &mockPA{}, 0)
+	test.AssertEquals(t, err, invalidNoCN)
+	err = VerifyCSR(context.Background(), parse(), Policy{CommonName: CNPromote}, testingPolicy, &mockPA{}, 0)
+	test.AssertNotError(t, err, "VerifyCSR failed with promoted CN")
+	err = VerifyCSR(context.Background(), parse(), Policy{}, testingPolicy, &mockPA{}, 0)
+	test.AssertNotError(t, err, "VerifyCSR failed without CN")
+}
+
+func TestPolicyValidate(t *testing.T) {
+	test.AssertNotError(t, Policy{}.Validate(), "empty policy is invalid")
+	test.AssertNotError(t, Policy{CommonName: CNIgnore, MaxNames: 100}.Validate(), "valid policy is invalid")
+	test.AssertError(t, Policy{CommonName: "sometimes"}.Validate(), "unknown CN policy is valid")
+	test.AssertError(t, Policy{MaxNames: -1}.Validate(), "negative MaxNames is valid")
+}
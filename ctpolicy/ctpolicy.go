@@ -2,34 +2,166 @@ package ctpolicy
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/letsencrypt/boulder/canceled"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/features"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
 	pubpb "github.com/letsencrypt/boulder/publisher/proto"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
 
+// errRateLimited is the result of a submission skipped because the log's
+// SubmissionsPerSecond limit was reached.
+var errRateLimited = errors.New("submission rate limit reached")
+
 // CTPolicy is used to hold information about SCTs required from various
 // groupings
 type CTPolicy struct {
-	pub           core.Publisher
-	groups        []cmd.CTGroup
-	informational []cmd.LogDescription
-	log           blog.Logger
+	pub            core.Publisher
+	sa             core.StorageAdder
+	groups         []cmd.CTGroup
+	requiredGroups int
+	informational  []cmd.LogDescription
+	limiters       map[string]*limiter
+	log            blog.Logger
+	clk            clock.Clock
+
+	submissions *prometheus.CounterVec
 }
 
-// New creates a new CTPolicy struct
-func New(pub core.Publisher, groups []cmd.CTGroup, informational []cmd.LogDescription, log blog.Logger) *CTPolicy {
+// New creates a new CTPolicy struct. GetSCTs requires SCTs from requiredGroups
+// of the groups, or from all of them if requiredGroups is zero. With the
+// RecordCTSubmissions feature enabled the result of each submission is
+// recorded with sa.
+func New(pub core.Publisher, sa core.StorageAdder, groups []cmd.CTGroup, informational []cmd.LogDescription, requiredGroups int, log blog.Logger, stats metrics.Scope) *CTPolicy {
+	if requiredGroups <= 0 || requiredGroups > len(groups) {
+		requiredGroups = len(groups)
+	}
+
+	limiters := make(map[string]*limiter)
+	addLimiter := func(l cmd.LogDescription) {
+		if l.SubmissionsPerSecond > 0 && limiters[l.URI] == nil {
+			limiters[l.URI] = newLimiter(l.SubmissionsPerSecond)
+		}
+	}
+	for _, g := range groups {
+		for _, l := range g.Logs {
+			addLimiter(l)
+		}
+	}
+	for _, l := range informational {
+		addLimiter(l)
+	}
+
+	submissions := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ctpolicy_submissions",
+			Help: "Number of certificates submitted to each CT log, by result (success, failure, canceled or ratelimited)",
+		},
+		[]string{"log", "result"},
+	)
+	stats.MustRegister(submissions)
+
 	return &CTPolicy{
-		pub:           pub,
-		groups:        groups,
-		informational: informational,
-		log:           log,
+		pub:            pub,
+		sa:             sa,
+		groups:         groups,
+		requiredGroups: requiredGroups,
+		informational:  informational,
+		limiters:       limiters,
+		log:            log,
+		clk:            clock.Default(),
+		submissions:    submissions,
+	}
+}
+
+// RequiredGroups returns the number of log groups that GetSCTs gets SCTs
+// from when it succeeds.
+func (ctp *CTPolicy) RequiredGroups() int {
+	return ctp.requiredGroups
+}
+
+// submissionResult returns the result of a submission that returned err.
+func submissionResult(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case err == errRateLimited:
+		return "ratelimited"
+	case canceled.Is(err):
+		return "canceled"
+	}
+	return "failure"
+}
+
+// submit submits cert to a single log, unless the log's rate limit has been
+// reached, and counts the result.
+func (ctp *CTPolicy) submit(ctx context.Context, cert core.CertDER, l cmd.LogDescription, isPrecert bool) ([]byte, error) {
+	if lim := ctp.limiters[l.URI]; lim != nil && !lim.allow(ctp.clk.Now()) {
+		ctp.submissions.WithLabelValues(l.URI, submissionResult(errRateLimited)).Inc()
+		return nil, errRateLimited
+	}
+	sct, err := ctp.pub.SubmitToSingleCTWithResult(ctx, &pubpb.Request{
+		LogURL:       &l.URI,
+		LogPublicKey: &l.Key,
+		Der:          cert,
+		Precert:      &isPrecert,
+	})
+	ctp.submissions.WithLabelValues(l.URI, submissionResult(err)).Inc()
+	if err != nil {
+		return nil, err
+	}
+	return sct.Sct, nil
+}
+
+// record records the result of a submission of the certificate with the given
+// serial to a log, made at submitted, if the RecordCTSubmissions feature is
+// enabled. Submissions still in flight are canceled once GetSCTs returns, so
+// the background context is used. Note that a timeout is still imposed by our
+// RPC layer.
+func (ctp *CTPolicy) record(serial string, l cmd.LogDescription, isPrecert bool, submitted time.Time, err error) {
+	if !features.Enabled(features.RecordCTSubmissions) || serial == "" {
+		return
 	}
+	result := submissionResult(err)
+	submittedNS := submitted.UnixNano()
+	_, err = ctp.sa.AddCTSubmission(context.Background(), &sapb.AddCTSubmissionRequest{
+		Serial:    &serial,
+		LogURL:    &l.URI,
+		Precert:   &isPrecert,
+		Result:    &result,
+		Submitted: &submittedNS,
+	})
+	if err != nil {
+		ctp.log.Warning(fmt.Sprintf("recording ct submission to %q: %s", l.URI, err))
+	}
+}
+
+// eligibleLogs returns the logs whose temporal shard, if any, accepts
+// certificates expiring at notAfter. If notAfter is zero all logs are
+// eligible.
+func eligibleLogs(logs []cmd.LogDescription, notAfter time.Time) []cmd.LogDescription {
+	if notAfter.IsZero() {
+		return logs
+	}
+	var eligible []cmd.LogDescription
+	for _, l := range logs {
+		if l.AcceptsExpiry(notAfter) {
+			eligible = append(eligible, l)
+		}
+	}
+	return eligible
 }
 
 type result struct {
@@ -40,8 +172,12 @@ type result struct {
 // race submits an SCT to each log in a group and waits for the first response back,
 // once it has the first SCT it cancels all of the other submissions and returns.
 // It allows up to len(group)-1 of the submissions to fail as we only care about
-// getting a single SCT.
-func (ctp *CTPolicy) race(ctx context.Context, cert core.CertDER, group cmd.CTGroup) ([]byte, error) {
+// getting a single SCT. The result of each submission is recorded once it has
+// been passed on.
+func (ctp *CTPolicy) race(ctx context.Context, cert core.CertDER, serial string, group cmd.CTGroup) ([]byte, error) {
+	if len(group.Logs) == 0 {
+		return nil, errors.New("no logs accept this certificate")
+	}
 	results := make(chan result, len(group.Logs))
 	var subCtx context.Context
 	var cancel func()
@@ -54,21 +190,18 @@ func (ctp *CTPolicy) race(ctx context.Context, cert core.CertDER, group cmd.CTGr
 	isPrecert := features.Enabled(features.EmbedSCTs)
 	for _, l := range group.Logs {
 		go func(l cmd.LogDescription) {
-			sct, err := ctp.pub.SubmitToSingleCTWithResult(subCtx, &pubpb.Request{
-				LogURL:       &l.URI,
-				LogPublicKey: &l.Key,
-				Der:          cert,
-				Precert:      &isPrecert,
-			})
+			submitted := ctp.clk.Now()
+			sct, err := ctp.submit(subCtx, cert, l, isPrecert)
 			if err != nil {
 				// Only log the error if it is not a result of canceling subCtx
 				if !canceled.Is(err) {
 					ctp.log.Warning(fmt.Sprintf("ct submission to %q failed: %s", l.URI, err))
 				}
 				results <- result{err: err}
-				return
+			} else {
+				results <- result{sct: sct}
 			}
-			results <- result{sct: sct.Sct}
+			ctp.record(serial, l, isPrecert, submitted, err)
 		}(l)
 	}
 
@@ -91,8 +224,20 @@ func (ctp *CTPolicy) race(ctx context.Context, cert core.CertDER, group cmd.CTGr
 }
 
 // GetSCTs attempts to retrieve a SCT from each configured grouping of logs and returns
-// the set of SCTs to the caller.
+// the set of SCTs to the caller. Only the logs of each group whose temporal
+// shard accepts the certificate are used, and once SCTs have been retrieved
+// from the required number of groups the remaining submissions are canceled.
 func (ctp *CTPolicy) GetSCTs(ctx context.Context, cert core.CertDER) (core.SCTDERs, error) {
+	// Precertificates have a critical poison extension but parse like any
+	// other certificate. If the certificate can't be parsed no temporal
+	// shards are applied and the logs decide for themselves.
+	var notAfter time.Time
+	var serial string
+	if parsed, err := x509.ParseCertificate(cert); err == nil {
+		notAfter = parsed.NotAfter
+		serial = core.SerialToString(parsed.SerialNumber)
+	}
+
 	results := make(chan result, len(ctp.groups))
 	var subCtx context.Context
 	var cancel func()
@@ -104,39 +249,85 @@ func (ctp *CTPolicy) GetSCTs(ctx context.Context, cert core.CertDER) (core.SCTDE
 	defer cancel()
 	for i, g := range ctp.groups {
 		go func(i int, g cmd.CTGroup) {
-			sct, err := ctp.race(subCtx, cert, g)
-			// Only one of these will be non-nil
+			g.Logs = eligibleLogs(g.Logs, notAfter)
+			sct, err := ctp.race(subCtx, cert, serial, g)
 			if err != nil {
 				results <- result{err: fmt.Errorf("CT log group %q: %s", g.Name, err)}
+				return
 			}
 			results <- result{sct: sct}
 		}(i, g)
 	}
 	isPrecert := features.Enabled(features.EmbedSCTs)
-	for _, log := range ctp.informational {
+	for _, log := range eligibleLogs(ctp.informational, notAfter) {
 		go func(l cmd.LogDescription) {
-			_, err := ctp.pub.SubmitToSingleCTWithResult(subCtx, &pubpb.Request{
-				LogURL:       &l.URI,
-				LogPublicKey: &l.Key,
-				Der:          cert,
-				Precert:      &isPrecert,
-			})
+			submitted := ctp.clk.Now()
+			_, err := ctp.submit(subCtx, cert, l, isPrecert)
 			if err != nil {
 				ctp.log.Warning(fmt.Sprintf("ct submission to informational log %q failed: %s", l.URI, err))
 			}
+			ctp.record(serial, l, isPrecert, submitted, err)
 		}(log)
 	}
 
 	var ret core.SCTDERs
+	failed := 0
 	for i := 0; i < len(ctp.groups); i++ {
 		res := <-results
-		// If any one group fails to get a SCT then we fail out immediately
-		// cancel any other in progress work as we can't continue
 		if res.err != nil {
-			// Returning triggers the defer'd context cancellation method
-			return nil, res.err
+			// If so many groups have failed that the required number can no
+			// longer be reached then we fail out immediately, canceling any
+			// other in progress work as we can't continue
+			failed++
+			if len(ctp.groups)-failed < ctp.requiredGroups {
+				// Returning triggers the defer'd context cancellation method
+				return nil, res.err
+			}
+			continue
 		}
 		ret = append(ret, res.sct)
+		if len(ret) == ctp.requiredGroups {
+			break
+		}
 	}
 	return ret, nil
 }
+
+// limiter is a token bucket limiting the rate of submissions to a log. Its
+// burst is one second's worth of submissions, and at least one.
+type limiter struct {
+	sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64) *limiter {
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{rate: rate, burst: burst, tokens: burst}
+}
+
+// allow takes a token from the bucket at time now, returning false if there
+// were none.
+func (lim *limiter) allow(now time.Time) bool {
+	lim.Lock()
+	defer lim.Unlock()
+	if !lim.last.IsZero() && now.After(lim.last) {
+		lim.tokens += now.Sub(lim.last).Seconds() * lim.rate
+		if lim.tokens > lim.burst {
+			lim.tokens = lim.burst
+		}
+	}
+	if now.After(lim.last) {
+		lim.last = now
+	}
+	if lim.tokens < 1 {
+		return false
+	}
+	lim.tokens--
+	return true
+}
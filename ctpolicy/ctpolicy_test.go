@@ -2,15 +2,27 @@ package ctpolicy
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"errors"
+	"math/big"
 	"regexp"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/jmhodges/clock"
+
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/features"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/mocks"
 	pubpb "github.com/letsencrypt/boulder/publisher/proto"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -35,6 +47,26 @@ func (mp *alwaysFail) SubmitToSingleCTWithResult(_ context.Context, _ *pubpb.Req
 	return nil, errors.New("BAD")
 }
 
+// failLogs fails submissions to the logs in fail, and records which logs
+// certificates were submitted to.
+type failLogs struct {
+	mockPub
+	fail map[string]bool
+
+	sync.Mutex
+	submitted []string
+}
+
+func (mp *failLogs) SubmitToSingleCTWithResult(_ context.Context, req *pubpb.Request) (*pubpb.Result, error) {
+	mp.Lock()
+	mp.submitted = append(mp.submitted, *req.LogURL)
+	mp.Unlock()
+	if mp.fail[*req.LogURL] {
+		return nil, errors.New("BAD")
+	}
+	return &pubpb.Result{Sct: []byte(*req.LogURL)}, nil
+}
+
 func TestGetSCTs(t *testing.T) {
 	expired, cancel := context.WithDeadline(context.Background(), time.Now())
 	defer cancel()
@@ -116,7 +148,7 @@ func TestGetSCTs(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			ctp := New(tc.mock, tc.groups, nil, blog.NewMock())
+			ctp := New(tc.mock, nil, tc.groups, nil, 0, blog.NewMock(), metrics.NewNoopScope())
 			ret, err := ctp.GetSCTs(tc.ctx, []byte{0})
 			if tc.result != nil {
 				test.AssertDeepEquals(t, ret, tc.result)
@@ -128,3 +160,143 @@ func TestGetSCTs(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSCTsQuorum(t *testing.T) {
+	groups := []cmd.CTGroup{
+		{Name: "a", Logs: []cmd.LogDescription{{URI: "a1", Key: "a"}}},
+		{Name: "b", Logs: []cmd.LogDescription{{URI: "b1", Key: "b"}}},
+		{Name: "c", Logs: []cmd.LogDescription{{URI: "c1", Key: "c"}}},
+	}
+	pub := &failLogs{fail: map[string]bool{"b1": true}}
+
+	// Two of the three groups are enough, so the failure of one is tolerated.
+	ctp := New(pub, nil, groups, nil, 2, blog.NewMock(), metrics.NewNoopScope())
+	test.AssertEquals(t, ctp.RequiredGroups(), 2)
+	scts, err := ctp.GetSCTs(context.Background(), []byte{0})
+	test.AssertNotError(t, err, "GetSCTs failed with a quorum of groups")
+	test.AssertEquals(t, len(scts), 2)
+
+	// With all three groups required it isn't.
+	ctp = New(pub, nil, groups, nil, 0, blog.NewMock(), metrics.NewNoopScope())
+	test.AssertEquals(t, ctp.RequiredGroups(), 3)
+	_, err = ctp.GetSCTs(context.Background(), []byte{0})
+	test.AssertError(t, err, "GetSCTs succeeded without every group")
+	test.AssertEquals(t, test.CountCounter(ctp.submissions.WithLabelValues("b1", "failure")), 1)
+
+	// Nor with two required when two fail.
+	pub = &failLogs{fail: map[string]bool{"b1": true, "c1": true}}
+	ctp = New(pub, nil, groups, nil, 2, blog.NewMock(), metrics.NewNoopScope())
+	_, err = ctp.GetSCTs(context.Background(), []byte{0})
+	test.AssertError(t, err, "GetSCTs succeeded without a quorum of groups")
+}
+
+func TestGetSCTsTemporalShards(t *testing.T) {
+	k, err := rsa.GenerateKey(rand.Reader, 1024)
+	test.AssertNotError(t, err, "Failed to generate key")
+	notAfter := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, k.Public(), k)
+	test.AssertNotError(t, err, "Failed to create certificate")
+
+	groups := []cmd.CTGroup{{
+		Name: "a",
+		Logs: []cmd.LogDescription{
+			{
+				URI:         "2018",
+				Key:         "a",
+				TemporalEnd: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				URI:           "2019",
+				Key:           "a",
+				TemporalStart: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+				TemporalEnd:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}}
+	pub := &failLogs{}
+	ctp := New(pub, nil, groups, nil, 0, blog.NewMock(), metrics.NewNoopScope())
+	scts, err := ctp.GetSCTs(context.Background(), cert)
+	test.AssertNotError(t, err, "GetSCTs failed")
+	test.AssertDeepEquals(t, scts, core.SCTDERs{[]byte("2019")})
+	test.AssertDeepEquals(t, pub.submitted, []string{"2019"})
+
+	// A certificate outside every shard of a group can't satisfy it.
+	groups[0].Logs = groups[0].Logs[:1]
+	ctp = New(pub, nil, groups, nil, 0, blog.NewMock(), metrics.NewNoopScope())
+	_, err = ctp.GetSCTs(context.Background(), cert)
+	test.AssertError(t, err, "GetSCTs succeeded with no log accepting the certificate")
+}
+
+func TestGetSCTsRateLimit(t *testing.T) {
+	groups := []cmd.CTGroup{{
+		Name: "a",
+		Logs: []cmd.LogDescription{{URI: "abc", Key: "def", SubmissionsPerSecond: 0.5}},
+	}}
+	ctp := New(&mockPub{}, nil, groups, nil, 0, blog.NewMock(), metrics.NewNoopScope())
+	fc := clock.NewFake()
+	ctp.clk = fc
+
+	_, err := ctp.GetSCTs(context.Background(), []byte{0})
+	test.AssertNotError(t, err, "First submission was rate limited")
+	_, err = ctp.GetSCTs(context.Background(), []byte{0})
+	test.AssertError(t, err, "Second submission wasn't rate limited")
+	test.AssertEquals(t, test.CountCounter(ctp.submissions.WithLabelValues("abc", "ratelimited")), 1)
+
+	fc.Add(2 * time.Second)
+	_, err = ctp.GetSCTs(context.Background(), []byte{0})
+	test.AssertNotError(t, err, "Submission was rate limited after the bucket refilled")
+}
+
+// recordingSA sends the CT submissions recorded with it to submissions.
+type recordingSA struct {
+	mocks.StorageAuthority
+	submissions chan *sapb.AddCTSubmissionRequest
+}
+
+func (sa *recordingSA) AddCTSubmission(_ context.Context, req *sapb.AddCTSubmissionRequest) (*corepb.Empty, error) {
+	sa.submissions <- req
+	return &corepb.Empty{}, nil
+}
+
+func TestGetSCTsRecordsSubmissions(t *testing.T) {
+	_ = features.Set(map[string]bool{"RecordCTSubmissions": true})
+	defer features.Reset()
+
+	k, err := rsa.GenerateKey(rand.Reader, 1024)
+	test.AssertNotError(t, err, "Failed to generate key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, k.Public(), k)
+	test.AssertNotError(t, err, "Failed to create certificate")
+
+	groups := []cmd.CTGroup{
+		{Name: "a", Logs: []cmd.LogDescription{{URI: "a", Key: "a"}}},
+		{Name: "b", Logs: []cmd.LogDescription{{URI: "b", Key: "b"}}},
+	}
+	sa := &recordingSA{submissions: make(chan *sapb.AddCTSubmissionRequest, 2)}
+	ctp := New(&failLogs{fail: map[string]bool{"b": true}}, sa, groups, nil, 1, blog.NewMock(), metrics.NewNoopScope())
+	_, err = ctp.GetSCTs(context.Background(), cert)
+	test.AssertNotError(t, err, "GetSCTs failed")
+
+	// The submissions are recorded once their results have been passed on, so
+	// they may still be in flight when GetSCTs returns.
+	results := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		select {
+		case req := <-sa.submissions:
+			test.AssertEquals(t, req.GetSerial(), core.SerialToString(big.NewInt(1)))
+			results[req.GetLogURL()] = req.GetResult()
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for CT submissions to be recorded")
+		}
+	}
+	test.AssertDeepEquals(t, results, map[string]string{"a": "success", "b": "failure"})
+}
@@ -1,6 +1,10 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/letsencrypt/boulder/identifier"
+)
 
 // ErrorType provides a coarse category for BoulderErrors
 type ErrorType int
@@ -17,18 +21,47 @@ const (
 	ConnectionFailure
 	WrongAuthorizationState
 	CAA
+	BadPublicKey
+	// Timeout is used when a request's deadline passed before it could be
+	// completed.
+	Timeout
+	// CTUnavailable is used when a certificate can't be returned because the
+	// SCTs required by the CT policy couldn't be obtained.
+	CTUnavailable
+	// BadCSR is used when a CSR is unacceptable for reasons other than its
+	// key or the identifiers it names.
+	BadCSR
 )
 
 // BoulderError represents internal Boulder errors
 type BoulderError struct {
 	Type   ErrorType
 	Detail string
+	// SubErrors, if any, are the errors with the individual identifiers of a
+	// request for several.
+	SubErrors []SubBoulderError
+}
+
+// SubBoulderError is the error with one identifier of a request.
+type SubBoulderError struct {
+	*BoulderError
+	Identifier identifier.ACMEIdentifier
 }
 
 func (be *BoulderError) Error() string {
 	return be.Detail
 }
 
+// WithSubErrors returns a copy of be with subErrs appended to its SubErrors.
+// Many BoulderErrors are shared package variables, so be is never modified.
+func (be *BoulderError) WithSubErrors(subErrs []SubBoulderError) *BoulderError {
+	return &BoulderError{
+		Type:      be.Type,
+		Detail:    be.Detail,
+		SubErrors: append(append([]SubBoulderError(nil), be.SubErrors...), subErrs...),
+	}
+}
+
 // New is a convenience function for creating a new BoulderError
 func New(errType ErrorType, msg string, args ...interface{}) error {
 	return &BoulderError{
@@ -88,3 +121,19 @@ func WrongAuthorizationStateError(msg string, args ...interface{}) error {
 func CAAError(msg string, args ...interface{}) error {
 	return New(CAA, msg, args...)
 }
+
+func BadPublicKeyError(msg string, args ...interface{}) error {
+	return New(BadPublicKey, msg, args...)
+}
+
+func TimeoutError(msg string, args ...interface{}) error {
+	return New(Timeout, msg, args...)
+}
+
+func CTUnavailableError(msg string, args ...interface{}) error {
+	return New(CTUnavailable, msg, args...)
+}
+
+func BadCSRError(msg string, args ...interface{}) error {
+	return New(BadCSR, msg, args...)
+}
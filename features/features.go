@@ -34,6 +34,62 @@ const (
 	EnforceV2ContentType
 	// Reject new-orders that contain a hostname redundant with a wildcard.
 	EnforceOverlappingWildcards
+	// Record certificates issued without an OCSP URL in the noOCSP column of
+	// certificateStatus and exclude them from OCSP updates. Requires the
+	// AddCertStatusNoOCSP migration.
+	ShortLivedCertificates
+	// Consult the blockedKeys table in the SA's KeyBlocked method. Requires
+	// the AddBlockedKeys migration.
+	BlockedKeyTable
+	// Consult the policyOverrides table in the SA's PolicyOverridden method, so
+	// that administrators can allow issuance for names on the hostname policy
+	// blacklists. Requires the AddPolicyOverrides migration.
+	PolicyOverrides
+	// Issue certificates for finalized orders in the RA's finalize workers,
+	// returning the order in processing status for the client to poll instead
+	// of waiting for issuance.
+	AsyncFinalize
+	// Consult the rateLimitOverrides table in the SA's GetRateLimitOverrides
+	// method, so that per-account rate limit overrides can be managed without
+	// changing the rate limit policy file. Requires the AddRateLimitOverrides
+	// migration.
+	RateLimitOverrides
+	// Allow the RA to issue certificates without embedded SCTs, using CA
+	// profiles that permit it, when it can't get SCTs from the required CT log
+	// groups before its SCT deadline. Only meant to be enabled during
+	// widespread CT log outages.
+	CTContingency
+	// Record each failed validation in the failedValidations table when the
+	// SA finalizes an invalid authorization, and count them in the SA's
+	// CountFailedValidations method. Requires the AddFailedValidations and
+	// AddFailedValidationsAddressUsed migrations.
+	FailedValidationsTable
+	// Record the FQDN set hash and registration ID of each certificate in the
+	// accountFQDNSets table, and consult it in the SA's FQDNSetIssuedForAccount
+	// method. Requires the AddAccountFQDNSets migration.
+	AccountFQDNSets
+	// Store a new order and its new pending authorizations with the SA's
+	// NewOrderAndAuthzs method in one transaction, instead of calling
+	// AddPendingAuthorizations and then NewOrder.
+	BatchOrderCreation
+	// Serve the ACMEv2 check-order endpoint, advertised in the directory as
+	// "checkOrder", which checks a new-order request against the issuance
+	// policy and rate limits without creating an order.
+	CheckOrderEndpoint
+	// Keep the ocsp-updater's resubmissions of certificates to CT logs that
+	// haven't returned an SCT in the ctResubmissions table, so that their
+	// backoff survives restarts, and run its MissingSCTReceipts loop even with
+	// EmbedSCTs enabled. Requires the AddCTResubmissions migration.
+	CTResubmissionQueue
+	// Record the result of each submission of a certificate to a CT log made
+	// by the RA's CT policy in the ctSubmissions table with the SA's
+	// AddCTSubmission method. Requires the AddCTSubmissions migration.
+	RecordCTSubmissions
+	// Record the SPKI hash of each certificate's public key in the
+	// keyHashToSerial table when the SA adds it, so that GetSerialsByKey can
+	// find every certificate for a key. Requires the AddKeyHashToSerial
+	// migration.
+	StoreKeyHashes
 )
 
 // List of features and their default value, protected by fMu
@@ -53,6 +109,19 @@ var features = map[FeatureFlag]bool{
 	EnforceV2ContentType:        false,
 	ForceConsistentStatus:       false,
 	EnforceOverlappingWildcards: false,
+	ShortLivedCertificates:      false,
+	BlockedKeyTable:             false,
+	PolicyOverrides:             false,
+	AsyncFinalize:               false,
+	RateLimitOverrides:          false,
+	CTContingency:               false,
+	FailedValidationsTable:      false,
+	AccountFQDNSets:             false,
+	BatchOrderCreation:          false,
+	CheckOrderEndpoint:          false,
+	CTResubmissionQueue:         false,
+	RecordCTSubmissions:         false,
+	StoreKeyHashes:              false,
 }
 
 var fMu = new(sync.RWMutex)
@@ -97,6 +166,19 @@ func Enabled(n FeatureFlag) bool {
 	return v
 }
 
+// All returns the state of every feature, keyed by name.
+func All() map[string]bool {
+	fMu.RLock()
+	defer fMu.RUnlock()
+	all := make(map[string]bool, len(features))
+	for f, v := range features {
+		if f != unused {
+			all[f.String()] = v
+		}
+	}
+	return all
+}
+
 // Reset resets the features to their initial state
 func Reset() {
 	fMu.Lock()
@@ -19,6 +19,13 @@ func TestFeatures(t *testing.T) {
 	Reset()
 	test.Assert(t, !Enabled(unused), "'unused' shouldn't be enabled")
 
+	err = Set(map[string]bool{"WildcardDomains": true})
+	test.AssertNotError(t, err, "Set shouldn't have failed setting existing features")
+	test.Assert(t, All()["WildcardDomains"], "'WildcardDomains' should be enabled")
+	_, present := All()["unused"]
+	test.Assert(t, !present, "'unused' shouldn't be listed")
+	Reset()
+
 	err = Set(map[string]bool{"non-existent": true})
 	test.AssertError(t, err, "Set should've failed trying to enable a non-existent feature")
 
@@ -9,8 +9,11 @@ import (
 	"reflect"
 	"sync"
 
+	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/titanous/rocacheck"
+	"golang.org/x/net/context"
 )
 
 // To generate, run: primes 2 752 | tr '\n' ,
@@ -35,6 +38,11 @@ var (
 	smallPrimes          []*big.Int
 )
 
+// BlockedKeyCheckFunc is used to determine whether a key, identified by the
+// base64 SHA-256 hash of its SPKI, has been administratively blocked. It
+// matches the signature of the SA's KeyBlocked method.
+type BlockedKeyCheckFunc func(ctx context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error)
+
 // KeyPolicy determines which types of key may be used with various boulder
 // operations.
 type KeyPolicy struct {
@@ -42,17 +50,20 @@ type KeyPolicy struct {
 	AllowECDSANISTP256 bool // Whether ECDSA NISTP256 keys should be allowed.
 	AllowECDSANISTP384 bool // Whether ECDSA NISTP384 keys should be allowed.
 	weakRSAList        *WeakRSAKeys
+	blockedKeyCheck    BlockedKeyCheckFunc
 }
 
 // NewKeyPolicy returns a KeyPolicy that allows RSA, ECDSA256 and ECDSA384.
 // weakKeyFile contains the path to a JSON file containing truncated modulus
 // hashes of known weak RSA keys. If this argument is empty RSA modulus hash
-// checking will be disabled.
-func NewKeyPolicy(weakKeyFile string) (KeyPolicy, error) {
+// checking will be disabled. bkc is used to check whether a key has been
+// blocked, if it is nil blocked key checking will be disabled.
+func NewKeyPolicy(weakKeyFile string, bkc BlockedKeyCheckFunc) (KeyPolicy, error) {
 	kp := KeyPolicy{
 		AllowRSA:           true,
 		AllowECDSANISTP256: true,
 		AllowECDSANISTP384: true,
+		blockedKeyCheck:    bkc,
 	}
 	if weakKeyFile != "" {
 		keyList, err := LoadWeakRSASuffixes(weakKeyFile)
@@ -66,21 +77,47 @@ func NewKeyPolicy(weakKeyFile string) (KeyPolicy, error) {
 
 // GoodKey returns true if the key is acceptable for both TLS use and account
 // key use (our requirements are the same for either one), according to basic
-// strength and algorithm checking.
+// strength and algorithm checking, and hasn't been blocked.
 // TODO: Support JSONWebKeys once go-jose migration is done.
-func (policy *KeyPolicy) GoodKey(key crypto.PublicKey) error {
+func (policy *KeyPolicy) GoodKey(ctx context.Context, key crypto.PublicKey) error {
+	var err error
 	switch t := key.(type) {
 	case rsa.PublicKey:
-		return policy.goodKeyRSA(t)
+		err = policy.goodKeyRSA(t)
 	case *rsa.PublicKey:
-		return policy.goodKeyRSA(*t)
+		err = policy.goodKeyRSA(*t)
 	case ecdsa.PublicKey:
-		return policy.goodKeyECDSA(t)
+		err = policy.goodKeyECDSA(t)
 	case *ecdsa.PublicKey:
-		return policy.goodKeyECDSA(*t)
+		err = policy.goodKeyECDSA(*t)
 	default:
 		return berrors.MalformedError("unknown key type %s", reflect.TypeOf(key))
 	}
+	if err != nil {
+		return err
+	}
+	return policy.checkBlocked(ctx, key)
+}
+
+// checkBlocked returns a BadPublicKey error if the key has been blocked, or an
+// InternalServer error if that couldn't be checked. The key must already have
+// passed the other checks in GoodKey.
+func (policy *KeyPolicy) checkBlocked(ctx context.Context, key crypto.PublicKey) error {
+	if policy.blockedKeyCheck == nil {
+		return nil
+	}
+	keyHash, err := core.KeyDigest(key)
+	if err != nil {
+		return err
+	}
+	exists, err := policy.blockedKeyCheck(ctx, &sapb.KeyBlockedRequest{KeyHash: &keyHash})
+	if err != nil {
+		return berrors.InternalServerError("checking whether public key is blocked: %s", err)
+	}
+	if exists.GetExists() {
+		return berrors.BadPublicKeyError("public key is forbidden")
+	}
+	return nil
 }
 
 // GoodKeyECDSA determines if an ECDSA pubkey meets our requirements
@@ -200,7 +237,7 @@ func (policy *KeyPolicy) goodKeyRSA(key rsa.PublicKey) (err error) {
 		return berrors.MalformedError("RSA keys are not allowed")
 	}
 	if policy.weakRSAList != nil && policy.weakRSAList.Known(&key) {
-		return berrors.MalformedError("key is on a known weak RSA key list")
+		return berrors.BadPublicKeyError("key is on a known weak RSA key list")
 	}
 
 	// Baseline Requirements Appendix A
@@ -237,7 +274,7 @@ func (policy *KeyPolicy) goodKeyRSA(key rsa.PublicKey) (err error) {
 	// Check for weak keys generated by Infineon hardware
 	// (see https://crocs.fi.muni.cz/public/papers/rsa_ccs17)
 	if rocacheck.IsWeak(&key) {
-		return berrors.MalformedError("key generated by vulnerable Infineon-based hardware")
+		return berrors.BadPublicKeyError("key generated by vulnerable Infineon-based hardware")
 	}
 
 	return nil
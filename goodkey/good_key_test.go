@@ -5,9 +5,16 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/hex"
+	"errors"
 	"math/big"
 	"testing"
 
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -19,21 +26,21 @@ var testingPolicy = &KeyPolicy{
 
 func TestUnknownKeyType(t *testing.T) {
 	notAKey := struct{}{}
-	test.AssertError(t, testingPolicy.GoodKey(notAKey), "Should have rejected a key of unknown type")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), notAKey), "Should have rejected a key of unknown type")
 }
 
 func TestSmallModulus(t *testing.T) {
 	private, err := rsa.GenerateKey(rand.Reader, 2040)
 	test.AssertNotError(t, err, "Error generating key")
-	test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should have rejected too-short key.")
-	test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should have rejected too-short key.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should have rejected too-short key.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should have rejected too-short key.")
 }
 
 func TestLargeModulus(t *testing.T) {
 	private, err := rsa.GenerateKey(rand.Reader, 4097)
 	test.AssertNotError(t, err, "Error generating key")
-	test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should have rejected too-long key.")
-	test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should have rejected too-long key.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should have rejected too-long key.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should have rejected too-long key.")
 }
 
 func TestModulusModulo8(t *testing.T) {
@@ -42,7 +49,7 @@ func TestModulusModulo8(t *testing.T) {
 		N: bigOne.Lsh(bigOne, 2049),
 		E: 5,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected modulus with length not divisible by 8.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected modulus with length not divisible by 8.")
 }
 
 func TestSmallExponent(t *testing.T) {
@@ -51,7 +58,7 @@ func TestSmallExponent(t *testing.T) {
 		N: bigOne.Lsh(bigOne, 2048),
 		E: 5,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected small exponent.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected small exponent.")
 }
 
 func TestEvenExponent(t *testing.T) {
@@ -60,7 +67,7 @@ func TestEvenExponent(t *testing.T) {
 		N: bigOne.Lsh(bigOne, 2048),
 		E: 1 << 17,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected even exponent.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected even exponent.")
 }
 
 func TestEvenModulus(t *testing.T) {
@@ -69,7 +76,7 @@ func TestEvenModulus(t *testing.T) {
 		N: bigOne.Lsh(bigOne, 2048),
 		E: (1 << 17) + 1,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected even modulus.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected even modulus.")
 }
 
 func TestModulusDivisibleBy752(t *testing.T) {
@@ -81,7 +88,7 @@ func TestModulusDivisibleBy752(t *testing.T) {
 		N: N,
 		E: (1 << 17) + 1,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected modulus divisible by 751.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected modulus divisible by 751.")
 }
 
 func TestROCA(t *testing.T) {
@@ -93,21 +100,37 @@ func TestROCA(t *testing.T) {
 		N: n,
 		E: 65537,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected ROCA-weak key.")
+	err := testingPolicy.GoodKey(context.Background(), &key)
+	test.AssertError(t, err, "Should have rejected ROCA-weak key.")
+	test.Assert(t, berrors.Is(err, berrors.BadPublicKey), "Incorrect error type returned")
+}
+
+func TestWeakKeyList(t *testing.T) {
+	modBytes, err := hex.DecodeString("D673252AF6723C3F72529403EAB7C30DEF3C52F97E799825F4A70191C616ADCF1ECE1113F1625971074C492C592025FDEADBDB146A081826BDF0D77C3C913DCF1B6F0B3B78F5108D2E493AD0EEE8CA5C021711ADC13D358E61133870FCD19C8E5C22403959782AA82E72AEE53A3D491E3912CE27B27E1A85EA69C19A527D28F7934C9823B7E56FDD657DAC83FDC65BB22A98D843DF73238919781B714C81A5E2AFEC71F5C54AA2A27C590AD94C03C1062D50EFCFFAC743E3C8A3AE056846A1D756EB862BF4224169D467C35215ADE0AFCC11E85FE629AFB802C4786FF2E9C929BCCF502B3D3B8876C6A11785CC398B389F1D86BDD9CB0BD4EC13956EC3FA270D")
+	test.AssertNotError(t, err, "Failed to decode modulus bytes")
+	key := rsa.PublicKey{N: new(big.Int).SetBytes(modBytes), E: 65537}
+
+	policy := KeyPolicy{AllowRSA: true, weakRSAList: &WeakRSAKeys{suffixes: make(map[truncatedHash]struct{})}}
+	err = policy.weakRSAList.addSuffix("8df20e6961a16398b85a")
+	test.AssertNotError(t, err, "WeakRSAKeys.addSuffix failed")
+
+	err = policy.GoodKey(context.Background(), &key)
+	test.AssertError(t, err, "Should have rejected a key on the weak key list")
+	test.Assert(t, berrors.Is(err, berrors.BadPublicKey), "Incorrect error type returned")
 }
 
 func TestGoodKey(t *testing.T) {
 	private, err := rsa.GenerateKey(rand.Reader, 2048)
 	test.AssertNotError(t, err, "Error generating key")
-	test.AssertNotError(t, testingPolicy.GoodKey(&private.PublicKey), "Should have accepted good key.")
+	test.AssertNotError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should have accepted good key.")
 }
 
 func TestECDSABadCurve(t *testing.T) {
 	for _, curve := range invalidCurves {
 		private, err := ecdsa.GenerateKey(curve, rand.Reader)
 		test.AssertNotError(t, err, "Error generating key")
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should have rejected key with unsupported curve.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should have rejected key with unsupported curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should have rejected key with unsupported curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should have rejected key with unsupported curve.")
 	}
 }
 
@@ -125,8 +148,8 @@ func TestECDSAGoodKey(t *testing.T) {
 	for _, curve := range validCurves {
 		private, err := ecdsa.GenerateKey(curve, rand.Reader)
 		test.AssertNotError(t, err, "Error generating key")
-		test.AssertNotError(t, testingPolicy.GoodKey(&private.PublicKey), "Should have accepted good key.")
-		test.AssertNotError(t, testingPolicy.GoodKey(private.PublicKey), "Should have accepted good key.")
+		test.AssertNotError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should have accepted good key.")
+		test.AssertNotError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should have accepted good key.")
 	}
 }
 
@@ -137,8 +160,8 @@ func TestECDSANotOnCurveX(t *testing.T) {
 		test.AssertNotError(t, err, "Error generating key")
 
 		private.X.Add(private.X, big.NewInt(1))
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key not on the curve.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key not on the curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key not on the curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key not on the curve.")
 	}
 }
 
@@ -150,8 +173,8 @@ func TestECDSANotOnCurveY(t *testing.T) {
 
 		// Change the public key so that it is no longer on the curve.
 		private.Y.Add(private.Y, big.NewInt(1))
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key not on the curve.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key not on the curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key not on the curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key not on the curve.")
 	}
 }
 
@@ -162,14 +185,14 @@ func TestECDSANegative(t *testing.T) {
 		test.AssertNotError(t, err, "Error generating key")
 
 		private.X.Neg(private.X)
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key with negative X.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key with negative X.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key with negative X.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key with negative X.")
 
 		// Check that negative Y is not accepted.
 		private.X.Neg(private.X)
 		private.Y.Neg(private.Y)
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key with negative Y.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key with negative Y.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key with negative Y.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key with negative Y.")
 	}
 }
 
@@ -180,8 +203,8 @@ func TestECDSANegativeUnmodulatedX(t *testing.T) {
 		test.AssertNotError(t, err, "Error generating key")
 
 		private.X.Mul(private.X, private.Curve.Params().P)
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key with unmodulated X.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key with unmodulated X.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key with unmodulated X.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key with unmodulated X.")
 	}
 }
 
@@ -192,8 +215,8 @@ func TestECDSANegativeUnmodulatedY(t *testing.T) {
 		test.AssertNotError(t, err, "Error generating key")
 
 		private.X.Mul(private.Y, private.Curve.Params().P)
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key with unmodulated Y.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key with unmodulated Y.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key with unmodulated Y.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key with unmodulated Y.")
 	}
 }
 
@@ -206,7 +229,37 @@ func TestECDSAIdentity(t *testing.T) {
 			Y:     big.NewInt(0),
 		}
 
-		test.AssertError(t, testingPolicy.GoodKey(&public), "Should not have accepted key with point at infinity.")
-		test.AssertError(t, testingPolicy.GoodKey(public), "Should not have accepted key with point at infinity.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &public), "Should not have accepted key with point at infinity.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), public), "Should not have accepted key with point at infinity.")
 	}
 }
+
+func TestBlockedKeys(t *testing.T) {
+	blocked, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "Error generating key")
+	blockedHash, err := core.KeyDigest(blocked.Public())
+	test.AssertNotError(t, err, "Error computing key digest")
+	good, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "Error generating key")
+
+	var checkErr error
+	policy, err := NewKeyPolicy("", func(_ context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+		exists := req.GetKeyHash() == blockedHash
+		return &sapb.Exists{Exists: &exists}, checkErr
+	})
+	test.AssertNotError(t, err, "NewKeyPolicy failed")
+
+	err = policy.GoodKey(context.Background(), blocked.Public())
+	test.AssertError(t, err, "Accepted a blocked key")
+	test.Assert(t, berrors.Is(err, berrors.BadPublicKey), "Incorrect error type returned")
+	test.AssertNotError(t, policy.GoodKey(context.Background(), good.Public()), "Rejected a key that isn't blocked")
+
+	checkErr = errors.New("SA unavailable")
+	err = policy.GoodKey(context.Background(), good.Public())
+	test.AssertError(t, err, "Accepted a key when the blocked key check failed")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Incorrect error type returned for a failed blocked key check")
+
+	// Without a blocked key check function the key is only subject to the
+	// usual checks
+	test.AssertNotError(t, testingPolicy.GoodKey(context.Background(), blocked.Public()), "Rejected a blocked key with checking disabled")
+}
@@ -1,6 +1,13 @@
 package grpc
 
 import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/naming"
 )
 
@@ -45,3 +52,95 @@ func (sr *staticResolver) Next() ([]*naming.Update, error) {
 
 // Close does nothing
 func (sr *staticResolver) Close() {}
+
+// srvResolver implements the naming.Resolver interface, providing the targets
+// of a DNS SRV record as addresses. The record is looked up again every
+// interval, so that backends can be added and removed without restarting
+// clients.
+type srvResolver struct {
+	name     string
+	interval time.Duration
+	lookup   func(service, proto, name string) (string, []*net.SRV, error)
+}
+
+func newSRVResolver(name string, interval time.Duration) *srvResolver {
+	return &srvResolver{name: name, interval: interval, lookup: net.LookupSRV}
+}
+
+// addresses looks up the SRV record and returns the host:port address of each
+// of its targets.
+func (sr *srvResolver) addresses() ([]string, error) {
+	_, records, err := sr.lookup("", "", sr.name)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, r := range records {
+		host := strings.TrimSuffix(r.Target, ".")
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(r.Port))))
+	}
+	return addrs, nil
+}
+
+// Resolve implements naming.Resolver. The target is ignored in favour of the
+// configured SRV name.
+func (sr *srvResolver) Resolve(target string) (naming.Watcher, error) {
+	return &srvWatcher{
+		sr:      sr,
+		current: make(map[string]bool),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// srvWatcher is the naming.Watcher returned by srvResolver.
+type srvWatcher struct {
+	sr      *srvResolver
+	current map[string]bool
+	looked  bool
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Next implements naming.Watcher. The first call looks up the record
+// immediately, and later calls wait for the interval to pass before looking
+// it up again, returning once the targets have changed. Lookup failures and
+// empty answers leave the current addresses in place, since returning an
+// error would stop gRPC from watching for updates.
+func (sw *srvWatcher) Next() ([]*naming.Update, error) {
+	for {
+		if sw.looked {
+			select {
+			case <-sw.done:
+				return nil, grpc.ErrClientConnClosing
+			case <-time.After(sw.sr.interval):
+			}
+		}
+		sw.looked = true
+		addrs, err := sw.sr.addresses()
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		found := make(map[string]bool)
+		var updates []*naming.Update
+		for _, addr := range addrs {
+			found[addr] = true
+			if !sw.current[addr] {
+				updates = append(updates, &naming.Update{Op: naming.Add, Addr: addr})
+			}
+		}
+		for addr := range sw.current {
+			if !found[addr] {
+				updates = append(updates, &naming.Update{Op: naming.Delete, Addr: addr})
+			}
+		}
+		sw.current = found
+		if len(updates) > 0 {
+			return updates, nil
+		}
+	}
+}
+
+// Close implements naming.Watcher.
+func (sw *srvWatcher) Close() {
+	sw.once.Do(func() { close(sw.done) })
+}
@@ -1,6 +1,9 @@
 package grpc
 
 import (
+	"errors"
+	"net"
+	"sync"
 	"testing"
 	"time"
 
@@ -37,3 +40,51 @@ func TestStaticResolver(t *testing.T) {
 	case <-time.After(time.Millisecond * 500):
 	}
 }
+
+func TestSRVResolver(t *testing.T) {
+	var mu sync.Mutex
+	var records []*net.SRV
+	var lookupErr error
+	sr := newSRVResolver("_sa._tcp.boulder", time.Millisecond)
+	sr.lookup = func(service, proto, name string) (string, []*net.SRV, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		test.AssertEquals(t, name, "_sa._tcp.boulder")
+		return "", records, lookupErr
+	}
+	records = []*net.SRV{
+		{Target: "sa1.boulder.", Port: 9095},
+		{Target: "sa2.boulder.", Port: 9095},
+	}
+	watcher, err := sr.Resolve("")
+	test.AssertNotError(t, err, "srvResolver.Resolve failed")
+
+	updates, err := watcher.Next()
+	test.AssertNotError(t, err, "srvWatcher.Next failed")
+	test.AssertEquals(t, len(updates), 2)
+	test.AssertEquals(t, updates[0].Addr, "sa1.boulder:9095")
+	test.AssertEquals(t, updates[0].Op, naming.Add)
+	test.AssertEquals(t, updates[1].Addr, "sa2.boulder:9095")
+
+	// Failed lookups don't remove backends; the next change is returned once
+	// lookups succeed again.
+	mu.Lock()
+	lookupErr = errors.New("SERVFAIL")
+	mu.Unlock()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		records = []*net.SRV{{Target: "sa2.boulder.", Port: 9095}}
+		lookupErr = nil
+	}()
+	updates, err = watcher.Next()
+	test.AssertNotError(t, err, "srvWatcher.Next failed")
+	test.AssertEquals(t, len(updates), 1)
+	test.AssertEquals(t, updates[0].Addr, "sa1.boulder:9095")
+	test.AssertEquals(t, updates[0].Op, naming.Delete)
+
+	watcher.Close()
+	_, err = watcher.Next()
+	test.AssertError(t, err, "srvWatcher.Next didn't fail after Close")
+}
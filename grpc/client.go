@@ -3,10 +3,12 @@ package grpc
 import (
 	"crypto/tls"
 	"fmt"
+	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/naming"
 
 	"github.com/letsencrypt/boulder/cmd"
 	bcreds "github.com/letsencrypt/boulder/grpc/creds"
@@ -16,20 +18,60 @@ import (
 // a client certificate and validates the the server certificate based
 // on the provided *tls.Config.
 // It dials the remote service and returns a grpc.ClientConn if successful.
+// Requests are balanced across the configured backends, which may be found
+// with a DNS SRV lookup and are optionally health checked.
 func ClientSetup(c *cmd.GRPCClientConfig, tls *tls.Config, clientMetrics *grpc_prometheus.ClientMetrics) (*grpc.ClientConn, error) {
-	if len(c.ServerAddresses) == 0 {
+	if len(c.ServerAddresses) == 0 && c.SRVLookup == "" {
 		return nil, fmt.Errorf("boulder/grpc: ServerAddresses is empty")
 	}
 	if tls == nil {
 		return nil, errNilTLS
 	}
 
-	ci := clientInterceptor{c.Timeout.Duration, clientMetrics}
-	creds := bcreds.NewClientCredentials(tls.RootCAs, tls.Certificates)
+	ci := clientInterceptor{c.Timeout.Duration, clientMetrics, nil, nil}
+	if len(c.MethodTimeouts) > 0 {
+		ci.methodTimeouts = make(map[string]time.Duration)
+		for method, timeout := range c.MethodTimeouts {
+			ci.methodTimeouts[method] = timeout.Duration
+		}
+	}
+	if c.FieldEncryption != nil {
+		fields, err := newFieldCrypter(c.FieldEncryption)
+		if err != nil {
+			return nil, err
+		}
+		ci.fields = fields
+	}
+	creds := bcreds.NewClientCredentials(tls.RootCAs, tls.Certificates, tls.GetClientCertificate)
+	var resolver naming.Resolver = newStaticResolver(c.ServerAddresses)
+	if c.SRVLookup != "" {
+		interval := c.SRVLookupInterval.Duration
+		if interval == 0 {
+			interval = time.Minute
+		}
+		sr := newSRVResolver(c.SRVLookup, interval)
+		// Fail early if the record can't be used, rather than leaving every
+		// request to time out.
+		addrs, err := sr.addresses()
+		if err != nil {
+			return nil, fmt.Errorf("boulder/grpc: looking up %q: %s", c.SRVLookup, err)
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("boulder/grpc: no targets found for %q", c.SRVLookup)
+		}
+		resolver = sr
+	}
+	if c.HealthCheckInterval.Duration > 0 {
+		resolver = &healthCheckResolver{
+			r:        resolver,
+			checker:  newGRPCHealthChecker(creds),
+			interval: c.HealthCheckInterval.Duration,
+		}
+	}
 	return grpc.Dial(
-		"", // Since our staticResolver provides addresses we don't need to pass an address here
+		"", // Since our resolvers provide addresses we don't need to pass an address here
 		grpc.WithTransportCredentials(creds),
-		grpc.WithBalancer(grpc.RoundRobin(newStaticResolver(c.ServerAddresses))),
+		grpc.WithBalancer(grpc.RoundRobin(resolver)),
 		grpc.WithUnaryInterceptor(ci.intercept),
 	)
 }
@@ -40,11 +40,19 @@ func (e SANNotAcceptedErr) Error() string {
 type clientTransportCredentials struct {
 	roots   *x509.CertPool
 	clients []tls.Certificate
+	// getClientCert, if not nil, is used to choose the client certificate on
+	// each handshake instead of clients, so that it can be reloaded.
+	getClientCert func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
 }
 
-// NewClientCredentials returns a new initialized grpc/credentials.TransportCredentials for client usage
-func NewClientCredentials(rootCAs *x509.CertPool, clientCerts []tls.Certificate) credentials.TransportCredentials {
-	return &clientTransportCredentials{rootCAs, clientCerts}
+// NewClientCredentials returns a new initialized grpc/credentials.TransportCredentials for client usage.
+// If getClientCert is not nil it takes precedence over clientCerts.
+func NewClientCredentials(
+	rootCAs *x509.CertPool,
+	clientCerts []tls.Certificate,
+	getClientCert func(*tls.CertificateRequestInfo) (*tls.Certificate, error),
+) credentials.TransportCredentials {
+	return &clientTransportCredentials{rootCAs, clientCerts, getClientCert}
 }
 
 // ClientHandshake does the authentication handshake specified by the corresponding
@@ -59,11 +67,12 @@ func (tc *clientTransportCredentials) ClientHandshake(ctx context.Context, addr
 		return nil, nil, err
 	}
 	conn := tls.Client(rawConn, &tls.Config{
-		ServerName:   host,
-		RootCAs:      tc.roots,
-		Certificates: tc.clients,
-		MinVersion:   tls.VersionTLS12, // Override default of tls.VersionTLS10
-		MaxVersion:   tls.VersionTLS12, // Same as default in golang <= 1.6
+		ServerName:           host,
+		RootCAs:              tc.roots,
+		Certificates:         tc.clients,
+		GetClientCertificate: tc.getClientCert,
+		MinVersion:           tls.VersionTLS12, // Override default of tls.VersionTLS10
+		MaxVersion:           tls.VersionTLS12, // Same as default in golang <= 1.6
 	})
 	errChan := make(chan error, 1)
 	go func() {
@@ -107,7 +116,7 @@ func (tc *clientTransportCredentials) RequireTransportSecurity() bool {
 
 // Clone returns a copy of the clientTransportCredentials
 func (tc *clientTransportCredentials) Clone() credentials.TransportCredentials {
-	return NewClientCredentials(tc.roots, tc.clients)
+	return NewClientCredentials(tc.roots, tc.clients, tc.getClientCert)
 }
 
 // OverrideServerName is not implemented and here only to satisfy the interface
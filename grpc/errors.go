@@ -1,6 +1,7 @@
 package grpc
 
 import (
+	"encoding/json"
 	"errors"
 	"strconv"
 
@@ -10,6 +11,7 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/identifier"
 )
 
 var (
@@ -30,7 +32,15 @@ func wrapError(ctx context.Context, err error) error {
 		// Ignoring the error return here is safe because if setting the metadata
 		// fails, we'll still return an error, but it will be interpreted on the
 		// other side as an InternalServerError instead of a more specific one.
-		_ = grpc.SetTrailer(ctx, metadata.Pairs("errortype", strconv.Itoa(int(berr.Type))))
+		pairs := []string{"errortype", strconv.Itoa(int(berr.Type))}
+		if len(berr.SubErrors) > 0 {
+			// The sub-errors are sent as JSON in a trailer of their own,
+			// which older clients ignore.
+			if subErrs, err := json.Marshal(subErrorsToJSON(berr.SubErrors)); err == nil {
+				pairs = append(pairs, "suberrors", string(subErrs))
+			}
+		}
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(pairs...))
 		return grpc.Errorf(codes.Unknown, err.Error())
 	}
 	return grpc.Errorf(codes.Unknown, err.Error())
@@ -60,7 +70,45 @@ func unwrapError(err error, md metadata.MD) error {
 				unwrappedErr,
 			)
 		}
-		return berrors.New(berrors.ErrorType(errType), unwrappedErr)
+		berr := &berrors.BoulderError{Type: berrors.ErrorType(errType), Detail: unwrappedErr}
+		if subErrStrs, ok := md["suberrors"]; ok && len(subErrStrs) == 1 {
+			var subErrs []jsonSubError
+			if err := json.Unmarshal([]byte(subErrStrs[0]), &subErrs); err == nil {
+				berr.SubErrors = subErrorsFromJSON(subErrs)
+			}
+		}
+		return berr
 	}
 	return err
 }
+
+// jsonSubError is the form a berrors.SubBoulderError takes in the
+// "suberrors" trailer.
+type jsonSubError struct {
+	Type       berrors.ErrorType         `json:"type"`
+	Detail     string                    `json:"detail"`
+	Identifier identifier.ACMEIdentifier `json:"identifier"`
+}
+
+func subErrorsToJSON(subErrs []berrors.SubBoulderError) []jsonSubError {
+	out := make([]jsonSubError, len(subErrs))
+	for i, subErr := range subErrs {
+		out[i] = jsonSubError{
+			Type:       subErr.Type,
+			Detail:     subErr.Detail,
+			Identifier: subErr.Identifier,
+		}
+	}
+	return out
+}
+
+func subErrorsFromJSON(subErrs []jsonSubError) []berrors.SubBoulderError {
+	out := make([]berrors.SubBoulderError, len(subErrs))
+	for i, subErr := range subErrs {
+		out[i] = berrors.SubBoulderError{
+			BoulderError: &berrors.BoulderError{Type: subErr.Type, Detail: subErr.Detail},
+			Identifier:   subErr.Identifier,
+		}
+	}
+	return out
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
 	berrors "github.com/letsencrypt/boulder/errors"
 	testproto "github.com/letsencrypt/boulder/grpc/test_proto"
+	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -24,8 +25,8 @@ func (s *errorServer) Chill(_ context.Context, _ *testproto.Time) (*testproto.Ti
 }
 
 func TestErrorWrapping(t *testing.T) {
-	si := serverInterceptor{grpc_prometheus.NewServerMetrics()}
-	ci := clientInterceptor{time.Second, grpc_prometheus.NewClientMetrics()}
+	si := serverInterceptor{serverMetrics: grpc_prometheus.NewServerMetrics()}
+	ci := clientInterceptor{time.Second, grpc_prometheus.NewClientMetrics(), nil, nil}
 	srv := grpc.NewServer(grpc.UnaryInterceptor(si.intercept))
 	es := &errorServer{}
 	testproto.RegisterChillerServer(srv, es)
@@ -47,6 +48,23 @@ func TestErrorWrapping(t *testing.T) {
 	test.Assert(t, err != nil, fmt.Sprintf("nil error returned, expected: %s", err))
 	test.AssertDeepEquals(t, err, es.err)
 
+	es.err = (&berrors.BoulderError{
+		Type:   berrors.RejectedIdentifier,
+		Detail: "Policy forbids issuing for 2 names",
+	}).WithSubErrors([]berrors.SubBoulderError{
+		{
+			BoulderError: &berrors.BoulderError{Type: berrors.RejectedIdentifier, Detail: "forbidden"},
+			Identifier:   identifier.DNSIdentifier("example.com"),
+		},
+		{
+			BoulderError: &berrors.BoulderError{Type: berrors.Malformed, Detail: "invalid"},
+			Identifier:   identifier.DNSIdentifier("-example.net"),
+		},
+	})
+	_, err = client.Chill(context.Background(), &testproto.Time{})
+	test.Assert(t, err != nil, fmt.Sprintf("nil error returned, expected: %s", err))
+	test.AssertDeepEquals(t, err, es.err)
+
 	test.AssertEquals(t, wrapError(nil, nil), nil)
 	test.AssertEquals(t, unwrapError(nil, nil), nil)
 }
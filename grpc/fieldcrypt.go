@@ -0,0 +1,252 @@
+package grpc
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/letsencrypt/boulder/cmd"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	berrors "github.com/letsencrypt/boulder/errors"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+// fieldPrefix marks a field value as having been encrypted by a fieldCrypter.
+// An encrypted value has the form "bfe1:<key ID>:<base64 nonce||ciphertext>".
+// Values without the prefix are passed through unchanged when opening so that
+// encryption can be enabled on clients and servers independently during a
+// rollout.
+const fieldPrefix = "bfe1:"
+
+// fieldCrypter encrypts and decrypts sensitive fields of SA request and
+// response messages using AES-256-GCM, in addition to the protection already
+// given by TLS. Fields are sealed with the active key and can be opened with
+// any key in the keyring, which allows keys to be rotated by first
+// distributing a new key and then making it active. The name of the field is
+// used as additional data so that ciphertexts can't be moved between fields.
+type fieldCrypter struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+// newFieldCrypter constructs a fieldCrypter from the provided config, reading
+// each key from its file. Key files must contain a hex encoded 32 byte key.
+func newFieldCrypter(c *cmd.FieldEncryptionConfig) (*fieldCrypter, error) {
+	if _, present := c.KeyFiles[c.ActiveKeyID]; !present {
+		return nil, fmt.Errorf("boulder/grpc: active field encryption key %q not in KeyFiles", c.ActiveKeyID)
+	}
+	keys := make(map[string]cipher.AEAD, len(c.KeyFiles))
+	for id, path := range c.KeyFiles {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("boulder/grpc: invalid field encryption key ID %q", id)
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("boulder/grpc: reading field encryption key %q: %s", id, err)
+		}
+		key, err := hex.DecodeString(strings.TrimSpace(string(contents)))
+		if err != nil {
+			return nil, fmt.Errorf("boulder/grpc: decoding field encryption key %q: %s", id, err)
+		}
+		aead, err := newFieldAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("boulder/grpc: field encryption key %q: %s", id, err)
+		}
+		keys[id] = aead
+	}
+	return &fieldCrypter{activeID: c.ActiveKeyID, keys: keys}, nil
+}
+
+func newFieldAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (fc *fieldCrypter) seal(field string, plaintext []byte) ([]byte, error) {
+	aead := fc.keys[fc.activeID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(field))
+	return []byte(fieldPrefix + fc.activeID + ":" + base64.RawURLEncoding.EncodeToString(sealed)), nil
+}
+
+func (fc *fieldCrypter) open(field string, value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, []byte(fieldPrefix)) {
+		return value, nil
+	}
+	parts := strings.SplitN(string(value[len(fieldPrefix):]), ":", 2)
+	if len(parts) != 2 {
+		return nil, berrors.InternalServerError("malformed encrypted %s field", field)
+	}
+	aead, present := fc.keys[parts[0]]
+	if !present {
+		return nil, berrors.InternalServerError("unknown field encryption key %q for %s field", parts[0], field)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, berrors.InternalServerError("malformed encrypted %s field", field)
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(field))
+	if err != nil {
+		return nil, berrors.InternalServerError("failed to decrypt %s field: %s", field, err)
+	}
+	return plaintext, nil
+}
+
+// fieldTransform is applied to each protected field of a message, it is either
+// fieldCrypter.seal or fieldCrypter.open.
+type fieldTransform func(field string, value []byte) ([]byte, error)
+
+func transformString(f fieldTransform, field string, s *string) error {
+	if s == nil || *s == "" {
+		return nil
+	}
+	out, err := f(field, []byte(*s))
+	if err != nil {
+		return err
+	}
+	*s = string(out)
+	return nil
+}
+
+func transformBytes(f fieldTransform, field string, b *[]byte) error {
+	if len(*b) == 0 {
+		return nil
+	}
+	out, err := f(field, *b)
+	if err != nil {
+		return err
+	}
+	*b = out
+	return nil
+}
+
+func transformRegistration(f fieldTransform, reg *corepb.Registration) error {
+	if reg == nil {
+		return nil
+	}
+	for i := range reg.Contact {
+		if err := transformString(f, "contact", &reg.Contact[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transformAuthorization applies f to the validation records of each of the
+// authorization's challenges, which contain the evidence gathered by the VA.
+func transformAuthorization(f fieldTransform, authz *corepb.Authorization) error {
+	if authz == nil {
+		return nil
+	}
+	for _, chall := range authz.Challenges {
+		if chall == nil {
+			continue
+		}
+		for _, record := range chall.Validationrecords {
+			if record == nil {
+				continue
+			}
+			if err := transformString(f, "hostname", record.Hostname); err != nil {
+				return err
+			}
+			if err := transformString(f, "url", record.Url); err != nil {
+				return err
+			}
+			if err := transformBytes(f, "addressUsed", &record.AddressUsed); err != nil {
+				return err
+			}
+			for i := range record.AddressesResolved {
+				if err := transformBytes(f, "addressesResolved", &record.AddressesResolved[i]); err != nil {
+					return err
+				}
+			}
+			for i := range record.AddressesTried {
+				if err := transformBytes(f, "addressesTried", &record.AddressesTried[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// transformMessage applies f to the protected fields of msg, which is modified
+// in place. Messages that don't carry protected fields are left unchanged.
+func transformMessage(f fieldTransform, msg interface{}) error {
+	switch m := msg.(type) {
+	case *corepb.Registration:
+		return transformRegistration(f, m)
+	case *corepb.Authorization:
+		return transformAuthorization(f, m)
+	case *sapb.AddPendingAuthorizationsRequest:
+		for _, authz := range m.Authz {
+			if err := transformAuthorization(f, authz); err != nil {
+				return err
+			}
+		}
+	case *sapb.NewOrderAndAuthzsRequest:
+		for _, authz := range m.NewAuthzs {
+			if err := transformAuthorization(f, authz); err != nil {
+				return err
+			}
+		}
+	case *sapb.Authorizations:
+		for _, el := range m.Authz {
+			if el != nil {
+				if err := transformAuthorization(f, el.Authz); err != nil {
+					return err
+				}
+			}
+		}
+	case *sapb.ValidAuthorizations:
+		for _, el := range m.Valid {
+			if el != nil {
+				if err := transformAuthorization(f, el.Authz); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sealRequest returns a copy of req with its protected fields encrypted. The
+// caller's message is never modified.
+func (fc *fieldCrypter) sealRequest(req interface{}) (interface{}, error) {
+	pb, ok := req.(proto.Message)
+	if !ok {
+		return req, nil
+	}
+	clone := proto.Clone(pb)
+	if err := transformMessage(fc.seal, clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// sealMessage encrypts the protected fields of msg in place.
+func (fc *fieldCrypter) sealMessage(msg interface{}) error {
+	return transformMessage(fc.seal, msg)
+}
+
+// openMessage decrypts the protected fields of msg in place.
+func (fc *fieldCrypter) openMessage(msg interface{}) error {
+	return transformMessage(fc.open, msg)
+}
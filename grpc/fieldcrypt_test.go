@@ -0,0 +1,179 @@
+package grpc
+
+import (
+	"crypto/cipher"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/go-grpc-prometheus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/letsencrypt/boulder/cmd"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func testFieldCrypter(t *testing.T, active string, ids ...string) *fieldCrypter {
+	keys := make(map[string]cipher.AEAD)
+	for i, id := range ids {
+		key := make([]byte, 32)
+		key[0] = byte(i + 1)
+		aead, err := newFieldAEAD(key)
+		test.AssertNotError(t, err, "failed to create AEAD")
+		keys[id] = aead
+	}
+	return &fieldCrypter{activeID: active, keys: keys}
+}
+
+func testAuthz() *corepb.Authorization {
+	hostname := "example.com"
+	url := "http://example.com/.well-known/acme-challenge/token"
+	return &corepb.Authorization{
+		Challenges: []*corepb.Challenge{
+			{
+				Validationrecords: []*corepb.ValidationRecord{
+					{
+						Hostname:          &hostname,
+						Url:               &url,
+						AddressUsed:       []byte("10.0.0.1"),
+						AddressesResolved: [][]byte{[]byte("10.0.0.1"), []byte("::1")},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestNewFieldCrypter(t *testing.T) {
+	f, err := ioutil.TempFile("", "field-key")
+	test.AssertNotError(t, err, "failed to create temp file")
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(strings.Repeat("ab", 32) + "\n")
+	test.AssertNotError(t, err, "failed to write key")
+	f.Close()
+
+	_, err = newFieldCrypter(&cmd.FieldEncryptionConfig{
+		KeyFiles:    map[string]string{"a": f.Name()},
+		ActiveKeyID: "b",
+	})
+	test.AssertError(t, err, "newFieldCrypter accepted a missing active key")
+
+	_, err = newFieldCrypter(&cmd.FieldEncryptionConfig{
+		KeyFiles:    map[string]string{"a:b": f.Name()},
+		ActiveKeyID: "a:b",
+	})
+	test.AssertError(t, err, "newFieldCrypter accepted a key ID containing a colon")
+
+	_, err = newFieldCrypter(&cmd.FieldEncryptionConfig{
+		KeyFiles:    map[string]string{"a": "/does/not/exist"},
+		ActiveKeyID: "a",
+	})
+	test.AssertError(t, err, "newFieldCrypter accepted a missing key file")
+
+	fc, err := newFieldCrypter(&cmd.FieldEncryptionConfig{
+		KeyFiles:    map[string]string{"a": f.Name()},
+		ActiveKeyID: "a",
+	})
+	test.AssertNotError(t, err, "newFieldCrypter failed")
+	test.AssertEquals(t, fc.activeID, "a")
+
+	_, err = newFieldAEAD([]byte{1, 2, 3})
+	test.AssertError(t, err, "newFieldAEAD accepted a short key")
+}
+
+func TestFieldCrypterRoundTrip(t *testing.T) {
+	fc := testFieldCrypter(t, "a", "a")
+
+	reg := &corepb.Registration{Contact: []string{"mailto:someone@example.com", ""}}
+	sealed, err := fc.sealRequest(reg)
+	test.AssertNotError(t, err, "sealRequest failed")
+	sealedReg := sealed.(*corepb.Registration)
+	test.AssertEquals(t, reg.Contact[0], "mailto:someone@example.com")
+	test.Assert(t, strings.HasPrefix(sealedReg.Contact[0], "bfe1:a:"), "contact wasn't encrypted")
+	test.AssertEquals(t, sealedReg.Contact[1], "")
+	test.AssertNotError(t, fc.openMessage(sealedReg), "openMessage failed")
+	test.AssertDeepEquals(t, sealedReg, reg)
+
+	req := &sapb.AddPendingAuthorizationsRequest{Authz: []*corepb.Authorization{testAuthz()}}
+	sealed, err = fc.sealRequest(req)
+	test.AssertNotError(t, err, "sealRequest failed")
+	record := sealed.(*sapb.AddPendingAuthorizationsRequest).Authz[0].Challenges[0].Validationrecords[0]
+	test.Assert(t, strings.HasPrefix(*record.Hostname, fieldPrefix), "hostname wasn't encrypted")
+	test.Assert(t, strings.HasPrefix(*record.Url, fieldPrefix), "url wasn't encrypted")
+	test.Assert(t, strings.HasPrefix(string(record.AddressUsed), fieldPrefix), "addressUsed wasn't encrypted")
+	test.Assert(t, strings.HasPrefix(string(record.AddressesResolved[1]), fieldPrefix), "addressesResolved weren't encrypted")
+	test.AssertNotError(t, fc.openMessage(sealed), "openMessage failed")
+	test.Assert(t, proto.Equal(sealed.(proto.Message), req), "opened message doesn't match original")
+
+	// Messages without protected fields are left alone
+	id := "id"
+	authzID := &sapb.AuthorizationID{Id: &id}
+	sealed, err = fc.sealRequest(authzID)
+	test.AssertNotError(t, err, "sealRequest failed")
+	test.Assert(t, proto.Equal(sealed.(proto.Message), authzID), "unprotected message was modified")
+}
+
+func TestFieldCrypterOpen(t *testing.T) {
+	fc := testFieldCrypter(t, "a", "a")
+
+	// Plaintext values are passed through
+	plain, err := fc.open("contact", []byte("mailto:someone@example.com"))
+	test.AssertNotError(t, err, "open failed on plaintext")
+	test.AssertEquals(t, string(plain), "mailto:someone@example.com")
+
+	sealed, err := fc.seal("contact", []byte("mailto:someone@example.com"))
+	test.AssertNotError(t, err, "seal failed")
+
+	// A ciphertext can't be moved to another field
+	_, err = fc.open("hostname", sealed)
+	test.AssertError(t, err, "open succeeded with the wrong field name")
+
+	// A keyring without the key can't open it
+	other := testFieldCrypter(t, "b", "b")
+	_, err = other.open("contact", sealed)
+	test.AssertError(t, err, "open succeeded with an unknown key ID")
+
+	_, err = fc.open("contact", []byte(fieldPrefix+"a"))
+	test.AssertError(t, err, "open succeeded with a malformed value")
+	_, err = fc.open("contact", []byte(fieldPrefix+"a:!!!"))
+	test.AssertError(t, err, "open succeeded with malformed base64")
+
+	// After rotation values sealed with the old key can still be opened
+	rotated := testFieldCrypter(t, "b", "a", "b")
+	plain, err = rotated.open("contact", sealed)
+	test.AssertNotError(t, err, "open failed after rotation")
+	test.AssertEquals(t, string(plain), "mailto:someone@example.com")
+	resealed, err := rotated.seal("contact", plain)
+	test.AssertNotError(t, err, "seal failed after rotation")
+	test.Assert(t, strings.HasPrefix(string(resealed), fieldPrefix+"b:"), "value wasn't sealed with the active key")
+}
+
+func TestServerInterceptorFieldEncryption(t *testing.T) {
+	fc := testFieldCrypter(t, "a", "a")
+	si := serverInterceptor{serverMetrics: grpc_prometheus.NewServerMetrics(), fields: fc}
+
+	reg := &corepb.Registration{Contact: []string{"mailto:someone@example.com"}}
+	sealed, err := fc.sealRequest(reg)
+	test.AssertNotError(t, err, "sealRequest failed")
+
+	handler := func(_ context.Context, req interface{}) (interface{}, error) {
+		// The handler must see the plaintext request
+		test.AssertEquals(t, req.(*corepb.Registration).Contact[0], "mailto:someone@example.com")
+		return &corepb.Registration{Contact: []string{"mailto:someone@example.com"}}, nil
+	}
+	resp, err := si.intercept(context.Background(), sealed, &grpc.UnaryServerInfo{FullMethod: "-service-test"}, handler)
+	test.AssertNotError(t, err, "si.intercept failed")
+	test.Assert(t, strings.HasPrefix(resp.(*corepb.Registration).Contact[0], fieldPrefix), "response wasn't encrypted")
+
+	// The request was opened in place above, so seal it again
+	sealed, err = fc.sealRequest(reg)
+	test.AssertNotError(t, err, "sealRequest failed")
+	other := serverInterceptor{serverMetrics: grpc_prometheus.NewServerMetrics(), fields: testFieldCrypter(t, "b", "b")}
+	_, err = other.intercept(context.Background(), sealed, &grpc.UnaryServerInfo{FullMethod: "-service-test"}, handler)
+	test.AssertError(t, err, "si.intercept didn't fail on a request it can't decrypt")
+}
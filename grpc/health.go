@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/naming"
+
+	healthpb "github.com/letsencrypt/boulder/grpc/health_proto"
+)
+
+// healthServiceName is the name of the standard gRPC health checking service.
+const healthServiceName = "grpc.health.v1.Health"
+
+// healthServer implements the standard gRPC health checking protocol. Every
+// Boulder gRPC server reports itself as serving for as long as it's running.
+type healthServer struct{}
+
+// Check implements healthpb.HealthServer.
+func (healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// healthChecker checks the health of backends.
+type healthChecker interface {
+	// check returns an error if the backend at addr isn't healthy.
+	check(ctx context.Context, addr string) error
+	// forget releases any resources held for checking addr.
+	forget(addr string)
+}
+
+// healthCheckResolver is a naming.Resolver that wraps another, passing on
+// only the addresses of backends that pass health checks. Backends are
+// assumed to be healthy until a check fails, so that a new client doesn't
+// have to wait for the first round of checks.
+type healthCheckResolver struct {
+	r        naming.Resolver
+	checker  healthChecker
+	interval time.Duration
+}
+
+// Resolve implements naming.Resolver.
+func (hr *healthCheckResolver) Resolve(target string) (naming.Watcher, error) {
+	w, err := hr.r.Resolve(target)
+	if err != nil {
+		return nil, err
+	}
+	hw := &healthCheckWatcher{
+		w:        w,
+		checker:  hr.checker,
+		interval: hr.interval,
+		backends: make(map[string]bool),
+		updates:  make(chan []*naming.Update),
+		done:     make(chan struct{}),
+	}
+	go hw.watch()
+	go hw.checkLoop()
+	return hw, nil
+}
+
+// healthCheckWatcher is the naming.Watcher returned by healthCheckResolver.
+type healthCheckWatcher struct {
+	w        naming.Watcher
+	checker  healthChecker
+	interval time.Duration
+
+	sync.Mutex
+	// backends maps the addresses of all the backends provided by w to
+	// whether they're currently healthy.
+	backends map[string]bool
+	updates  chan []*naming.Update
+	done     chan struct{}
+	closed   bool
+}
+
+// Next implements naming.Watcher.
+func (hw *healthCheckWatcher) Next() ([]*naming.Update, error) {
+	select {
+	case updates := <-hw.updates:
+		return updates, nil
+	case <-hw.done:
+		return nil, grpc.ErrClientConnClosing
+	}
+}
+
+// Close implements naming.Watcher.
+func (hw *healthCheckWatcher) Close() {
+	hw.Lock()
+	defer hw.Unlock()
+	if !hw.closed {
+		hw.closed = true
+		close(hw.done)
+		hw.w.Close()
+		for addr := range hw.backends {
+			hw.checker.forget(addr)
+		}
+	}
+}
+
+// send passes updates on to Next, unless the watcher is closed first.
+func (hw *healthCheckWatcher) send(updates []*naming.Update) {
+	if len(updates) == 0 {
+		return
+	}
+	select {
+	case hw.updates <- updates:
+	case <-hw.done:
+	}
+}
+
+// watch passes on the updates from the wrapped watcher, leaving out the
+// removal of backends that were already removed as unhealthy.
+func (hw *healthCheckWatcher) watch() {
+	for {
+		updates, err := hw.w.Next()
+		if err != nil {
+			return
+		}
+		var passed []*naming.Update
+		hw.Lock()
+		for _, u := range updates {
+			switch u.Op {
+			case naming.Add:
+				hw.backends[u.Addr] = true
+				passed = append(passed, u)
+			case naming.Delete:
+				if hw.backends[u.Addr] {
+					passed = append(passed, u)
+				}
+				delete(hw.backends, u.Addr)
+				hw.checker.forget(u.Addr)
+			}
+		}
+		hw.Unlock()
+		hw.send(passed)
+	}
+}
+
+// checkLoop checks the health of every backend each interval until the
+// watcher is closed.
+func (hw *healthCheckWatcher) checkLoop() {
+	ticker := time.NewTicker(hw.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-hw.done:
+			return
+		case <-ticker.C:
+			hw.checkAll()
+		}
+	}
+}
+
+// checkAll checks every backend in parallel and sends an update removing
+// those that have become unhealthy and restoring those that have recovered.
+func (hw *healthCheckWatcher) checkAll() {
+	hw.Lock()
+	addrs := make([]string, 0, len(hw.backends))
+	for addr := range hw.backends {
+		addrs = append(addrs, addr)
+	}
+	hw.Unlock()
+
+	healthy := make([]bool, len(addrs))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), hw.interval)
+			defer cancel()
+			healthy[i] = hw.checker.check(ctx, addr) == nil
+		}(i, addr)
+	}
+	wg.Wait()
+
+	var updates []*naming.Update
+	hw.Lock()
+	for i, addr := range addrs {
+		wasHealthy, ok := hw.backends[addr]
+		if !ok || wasHealthy == healthy[i] {
+			// The backend was removed, or hasn't changed, while it was
+			// being checked.
+			continue
+		}
+		hw.backends[addr] = healthy[i]
+		op := naming.Delete
+		if healthy[i] {
+			op = naming.Add
+		}
+		updates = append(updates, &naming.Update{Op: op, Addr: addr})
+	}
+	hw.Unlock()
+	hw.send(updates)
+}
+
+// grpcHealthChecker is a healthChecker that calls the health checking
+// service of each backend over a connection of its own. Backends that don't
+// implement the health checking service are considered healthy, so that
+// clients can be upgraded before servers.
+type grpcHealthChecker struct {
+	creds credentials.TransportCredentials
+
+	sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+func newGRPCHealthChecker(creds credentials.TransportCredentials) *grpcHealthChecker {
+	return &grpcHealthChecker{creds: creds, conns: make(map[string]*grpc.ClientConn)}
+}
+
+func (hc *grpcHealthChecker) conn(addr string) (*grpc.ClientConn, error) {
+	hc.Lock()
+	defer hc.Unlock()
+	if conn, ok := hc.conns[addr]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(hc.creds))
+	if err != nil {
+		return nil, err
+	}
+	hc.conns[addr] = conn
+	return conn, nil
+}
+
+func (hc *grpcHealthChecker) check(ctx context.Context, addr string) error {
+	conn, err := hc.conn(addr)
+	if err != nil {
+		return err
+	}
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if grpc.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return grpc.Errorf(codes.Unavailable, "backend status is %s", resp.Status)
+	}
+	return nil
+}
+
+func (hc *grpcHealthChecker) forget(addr string) {
+	hc.Lock()
+	defer hc.Unlock()
+	if conn, ok := hc.conns[addr]; ok {
+		_ = conn.Close()
+		delete(hc.conns, addr)
+	}
+}
@@ -0,0 +1,3 @@
+package health_proto
+
+//go:generate sh -c "cd ../.. && protoc --go_out=plugins=grpc:. grpc/health_proto/health.proto"
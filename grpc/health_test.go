@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/naming"
+
+	healthpb "github.com/letsencrypt/boulder/grpc/health_proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestHealthServer(t *testing.T) {
+	resp, err := healthServer{}.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	test.AssertNotError(t, err, "Check failed")
+	test.AssertEquals(t, resp.Status, healthpb.HealthCheckResponse_SERVING)
+}
+
+// fakeChecker is a healthChecker whose backends are unhealthy if they're in
+// its unhealthy set.
+type fakeChecker struct {
+	sync.Mutex
+	unhealthy map[string]bool
+	forgotten []string
+}
+
+func (fc *fakeChecker) check(_ context.Context, addr string) error {
+	fc.Lock()
+	defer fc.Unlock()
+	if fc.unhealthy[addr] {
+		return errors.New("unhealthy")
+	}
+	return nil
+}
+
+func (fc *fakeChecker) forget(addr string) {
+	fc.Lock()
+	defer fc.Unlock()
+	fc.forgotten = append(fc.forgotten, addr)
+}
+
+func (fc *fakeChecker) setHealthy(addr string, healthy bool) {
+	fc.Lock()
+	defer fc.Unlock()
+	fc.unhealthy[addr] = !healthy
+}
+
+func TestHealthCheckResolver(t *testing.T) {
+	checker := &fakeChecker{unhealthy: make(map[string]bool)}
+	hr := &healthCheckResolver{
+		r:        newStaticResolver([]string{"a:1", "b:1"}),
+		checker:  checker,
+		interval: time.Millisecond,
+	}
+	watcher, err := hr.Resolve("")
+	test.AssertNotError(t, err, "Resolve failed")
+
+	// Backends are passed on straight away
+	updates, err := watcher.Next()
+	test.AssertNotError(t, err, "Next failed")
+	test.AssertEquals(t, len(updates), 2)
+
+	checker.setHealthy("b:1", false)
+	updates, err = watcher.Next()
+	test.AssertNotError(t, err, "Next failed")
+	test.AssertDeepEquals(t, updates, []*naming.Update{{Op: naming.Delete, Addr: "b:1"}})
+
+	checker.setHealthy("b:1", true)
+	updates, err = watcher.Next()
+	test.AssertNotError(t, err, "Next failed")
+	test.AssertDeepEquals(t, updates, []*naming.Update{{Op: naming.Add, Addr: "b:1"}})
+
+	watcher.Close()
+	_, err = watcher.Next()
+	test.AssertError(t, err, "Next didn't fail after Close")
+	checker.Lock()
+	test.AssertEquals(t, len(checker.forgotten), 2)
+	checker.Unlock()
+}
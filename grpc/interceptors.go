@@ -1,32 +1,210 @@
 package grpc
 
 import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"strings"
 	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
+	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/tracing"
 )
 
+// requestIDMetadataKey is the gRPC metadata key used to pass request IDs from
+// clients to servers.
+const requestIDMetadataKey = "request-id"
+
 // serverInterceptor is a gRPC interceptor that adds Prometheus
-// metrics to requests handled by a gRPC server, and wraps Boulder-specific
-// errors for transmission in a grpc/metadata trailer (see bcodes.go).
+// metrics to requests handled by a gRPC server, logs them, recovers from
+// panics in their handlers, and wraps Boulder-specific errors for
+// transmission in a grpc/metadata trailer (see bcodes.go).
 type serverInterceptor struct {
 	serverMetrics *grpc_prometheus.ServerMetrics
+	// fields, if not nil, is used to decrypt protected fields of requests
+	// and encrypt those of responses.
+	fields *fieldCrypter
+	// interceptors are service-specific interceptors run around the handler.
+	interceptors []grpc.UnaryServerInterceptor
+	// serviceClients, if not nil, maps gRPC service names to the client
+	// certificate SANs allowed to call them. Services that aren't present may
+	// only be called by clients in defaultClients.
+	serviceClients map[string]map[string]struct{}
+	defaultClients map[string]struct{}
+	// log, if not nil, is used to log each request at debug level and any
+	// panics at error level.
+	log blog.Logger
+}
+
+// rpcEvent is logged as JSON for each request handled by a server.
+type rpcEvent struct {
+	Method    string
+	RequestID string `json:",omitempty"`
+	Latency   float64
+	Error     string `json:",omitempty"`
 }
 
-func (si *serverInterceptor) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+func (si *serverInterceptor) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 	if info == nil {
 		return nil, berrors.InternalServerError("passed nil *grpc.UnaryServerInfo")
 	}
-	resp, err := si.serverMetrics.UnaryServerInterceptor()(ctx, req, info, handler)
+	var traceParent string
+	if md, ok := metadata.FromContext(ctx); ok {
+		if len(md[requestIDMetadataKey]) > 0 {
+			ctx = core.WithRequestID(ctx, md[requestIDMetadataKey][0])
+		}
+		if len(md[tracing.TraceParentHeader]) > 0 {
+			traceParent = md[tracing.TraceParentHeader][0]
+		}
+	}
+	ctx, span := tracing.StartRemoteSpan(ctx, traceParent, info.FullMethod, tracing.KindServer)
+	defer func() { span.Finish(err) }()
+	if si.log != nil {
+		start := time.Now()
+		defer func() {
+			event := rpcEvent{
+				Method:    info.FullMethod,
+				RequestID: core.RequestID(ctx),
+				Latency:   time.Since(start).Seconds(),
+			}
+			if err != nil {
+				event.Error = err.Error()
+			}
+			jsonEvent, jsonErr := json.Marshal(event)
+			if jsonErr != nil {
+				return
+			}
+			si.log.Debug(fmt.Sprintf("gRPC JSON=%s", jsonEvent))
+		}()
+	}
+	if err := si.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	// Don't start work that the client has already given up waiting for.
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, wrapError(ctx, berrors.TimeoutError("%s deadline passed before it was handled", info.FullMethod))
+	}
+	if si.fields != nil {
+		if err := si.fields.openMessage(req); err != nil {
+			return nil, wrapError(ctx, err)
+		}
+	}
+	for i := len(si.interceptors) - 1; i >= 0; i-- {
+		handler = chainHandler(si.interceptors[i], info, handler)
+	}
+	handler = si.recoverPanics(info.FullMethod, handler)
+	resp, err = si.serverMetrics.UnaryServerInterceptor()(ctx, req, info, handler)
 	if err != nil {
-		err = wrapError(ctx, err)
+		return resp, wrapError(ctx, err)
+	}
+	if si.fields != nil {
+		if err := si.fields.sealMessage(resp); err != nil {
+			return nil, wrapError(ctx, err)
+		}
+	}
+	return resp, nil
+}
+
+// recoverPanics returns a handler that calls next, turning any panic into an
+// internal server error so that it fails only the request that caused it
+// rather than the whole server.
+func (si *serverInterceptor) recoverPanics(method string, next grpc.UnaryHandler) grpc.UnaryHandler {
+	return func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				if si.log != nil {
+					si.log.AuditErr(fmt.Sprintf("Panic handling %s: %v\n%s", method, r, debug.Stack()))
+				}
+				resp, err = nil, berrors.InternalServerError("panic handling %s", method)
+			}
+		}()
+		return next(ctx, req)
+	}
+}
+
+// authorize checks that the client certificate presented by the peer in ctx
+// has a SAN that's allowed to call the service that fullMethod belongs to.
+// Errors are returned with the PermissionDenied code rather than wrapped, so
+// that they're distinguishable from errors returned by the service itself.
+func (si *serverInterceptor) authorize(ctx context.Context, fullMethod string) error {
+	if si.serviceClients == nil {
+		return nil
+	}
+	service := serviceName(fullMethod)
+	if service == healthServiceName {
+		// Any client may check the health of the server.
+		return nil
+	}
+	allowed, ok := si.serviceClients[service]
+	if !ok {
+		allowed = si.defaultClients
+	}
+	sans := peerSANs(ctx)
+	for _, name := range sans {
+		if _, ok := allowed[name]; ok {
+			return nil
+		}
+	}
+	return grpc.Errorf(codes.PermissionDenied,
+		"boulder/grpc: client with SANs %q may not call %s", sans, service)
+}
+
+// serviceName returns the service part of a full gRPC method name of the form
+// "/package.Service/Method".
+func serviceName(fullMethod string) string {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// peerSANs returns the DNS and IP address SANs of the leaf client certificate
+// presented by the peer in ctx, if any.
+func peerSANs(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil
+	}
+	leaf := tlsInfo.State.PeerCertificates[0]
+	sans := append([]string{}, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans
+}
+
+// ClientName returns the first SAN of the client certificate presented by the
+// peer in ctx, identifying the component that made a request, or "" if there
+// isn't one.
+func ClientName(ctx context.Context) string {
+	sans := peerSANs(ctx)
+	if len(sans) == 0 {
+		return ""
+	}
+	return sans[0]
+}
+
+// chainHandler returns a handler that calls interceptor with next as the
+// handler it wraps.
+func chainHandler(interceptor grpc.UnaryServerInterceptor, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) grpc.UnaryHandler {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return interceptor(ctx, req, info, next)
 	}
-	return resp, err
 }
 
 // clientInterceptor is a gRPC interceptor that adds Prometheus
@@ -39,6 +217,20 @@ func (si *serverInterceptor) intercept(ctx context.Context, req interface{}, inf
 type clientInterceptor struct {
 	timeout       time.Duration
 	clientMetrics *grpc_prometheus.ClientMetrics
+	// fields, if not nil, is used to encrypt protected fields of requests
+	// and decrypt those of responses.
+	fields *fieldCrypter
+	// methodTimeouts overrides timeout for the methods it names.
+	methodTimeouts map[string]time.Duration
+}
+
+// timeoutFor returns the timeout for calls to fullMethod.
+func (ci *clientInterceptor) timeoutFor(fullMethod string) time.Duration {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	if timeout, ok := ci.methodTimeouts[name]; ok {
+		return timeout
+	}
+	return ci.timeout
 }
 
 // intercept fulfils the grpc.UnaryClientInterceptor interface, it should be noted that while this API
@@ -51,17 +243,48 @@ func (ci *clientInterceptor) intercept(
 	cc *grpc.ClientConn,
 	invoker grpc.UnaryInvoker,
 	opts ...grpc.CallOption) error {
-	localCtx, cancel := context.WithTimeout(ctx, ci.timeout)
+	// The timeout only ever shortens the deadline of ctx, so that a deadline
+	// set by the caller, such as the WFE's request timeout, is propagated
+	// through every RPC made on its behalf.
+	localCtx, cancel := context.WithTimeout(ctx, ci.timeoutFor(method))
 	defer cancel()
+	// Pass on the ID of the request this call is made for, or start a new
+	// one, so the server's logs can be correlated with the client's.
+	requestID := core.RequestID(ctx)
+	if requestID == "" {
+		requestID = core.NewRequestID()
+	}
+	localCtx, span := tracing.StartSpan(localCtx, method, tracing.KindClient)
+	outgoing, _ := metadata.FromContext(localCtx)
+	outgoing = outgoing.Copy()
+	outgoing[requestIDMetadataKey] = []string{requestID}
+	if span != nil {
+		outgoing[tracing.TraceParentHeader] = []string{span.TraceParent()}
+	}
+	localCtx = metadata.NewContext(localCtx, outgoing)
 	// Disable fail-fast so RPCs will retry until deadline, even if all backends
 	// are down.
 	opts = append(opts, grpc.FailFast(false))
 	// Create grpc/metadata.Metadata to encode internal error type if one is returned
 	md := metadata.New(nil)
 	opts = append(opts, grpc.Trailer(&md))
+	if ci.fields != nil {
+		var err error
+		req, err = ci.fields.sealRequest(req)
+		if err != nil {
+			return err
+		}
+	}
 	err := ci.clientMetrics.UnaryClientInterceptor()(localCtx, method, req, reply, cc, invoker, opts...)
+	span.Finish(err)
 	if err != nil {
-		err = unwrapError(err, md)
+		if grpc.Code(err) == codes.DeadlineExceeded {
+			return berrors.TimeoutError("%s timed out", method)
+		}
+		return unwrapError(err, md)
+	}
+	if ci.fields != nil {
+		return ci.fields.openMessage(reply)
 	}
-	return err
+	return nil
 }
@@ -1,6 +1,8 @@
 package grpc
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"testing"
 	"time"
@@ -9,9 +11,16 @@ import (
 	"github.com/jmhodges/clock"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/grpc/test_proto"
+	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/tracing"
 )
 
 var fc = clock.NewFake()
@@ -33,7 +42,7 @@ func testInvoker(_ context.Context, method string, _, _ interface{}, _ *grpc.Cli
 }
 
 func TestServerInterceptor(t *testing.T) {
-	si := serverInterceptor{grpc_prometheus.NewServerMetrics()}
+	si := serverInterceptor{serverMetrics: grpc_prometheus.NewServerMetrics()}
 
 	_, err := si.intercept(context.Background(), nil, nil, testHandler)
 	test.AssertError(t, err, "si.intercept didn't fail with a nil grpc.UnaryServerInfo")
@@ -45,8 +54,62 @@ func TestServerInterceptor(t *testing.T) {
 	test.AssertError(t, err, "si.intercept didn't fail when handler returned a error")
 }
 
+func TestServerInterceptorChain(t *testing.T) {
+	var calls []string
+	record := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			calls = append(calls, name+":"+info.FullMethod)
+			return handler(ctx, req)
+		}
+	}
+	si := serverInterceptor{serverMetrics: grpc_prometheus.NewServerMetrics(), interceptors: []grpc.UnaryServerInterceptor{record("a"), record("b")}}
+
+	_, err := si.intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "-service-test"}, testHandler)
+	test.AssertNotError(t, err, "si.intercept failed")
+	test.AssertDeepEquals(t, calls, []string{"a:-service-test", "b:-service-test"})
+}
+
+func TestServerInterceptorAuthorization(t *testing.T) {
+	si := serverInterceptor{
+		serverMetrics: grpc_prometheus.NewServerMetrics(),
+		serviceClients: map[string]map[string]struct{}{
+			"sa.StorageAuthority": {"ra.boulder": {}},
+		},
+		defaultClients: map[string]struct{}{"wfe.boulder": {}},
+	}
+	clientCtx := func(names ...string) context.Context {
+		leaf := &x509.Certificate{DNSNames: names}
+		return peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{leaf},
+			}},
+		})
+	}
+	saInfo := &grpc.UnaryServerInfo{FullMethod: "/sa.StorageAuthority/GetRegistration"}
+	raInfo := &grpc.UnaryServerInfo{FullMethod: "/ra.RegistrationAuthority/NewRegistration"}
+
+	_, err := si.intercept(clientCtx("ra.boulder"), nil, saInfo, testHandler)
+	test.AssertNotError(t, err, "listed client was refused")
+
+	_, err = si.intercept(clientCtx("wfe.boulder"), nil, saInfo, testHandler)
+	test.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+
+	_, err = si.intercept(context.Background(), nil, saInfo, testHandler)
+	test.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+
+	// Services that aren't listed may be called by the default clients only
+	_, err = si.intercept(clientCtx("wfe.boulder"), nil, raInfo, testHandler)
+	test.AssertNotError(t, err, "default client was refused")
+
+	_, err = si.intercept(clientCtx("ra.boulder"), nil, raInfo, testHandler)
+	test.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+
+	test.AssertEquals(t, ClientName(clientCtx("ra.boulder", "ra2.boulder")), "ra.boulder")
+	test.AssertEquals(t, ClientName(context.Background()), "")
+}
+
 func TestClientInterceptor(t *testing.T) {
-	ci := clientInterceptor{time.Second, grpc_prometheus.NewClientMetrics()}
+	ci := clientInterceptor{time.Second, grpc_prometheus.NewClientMetrics(), nil, nil}
 	err := ci.intercept(context.Background(), "-service-test", nil, nil, nil, testInvoker)
 	test.AssertNotError(t, err, "ci.intercept failed with a non-nil grpc.UnaryServerInfo")
 
@@ -54,6 +117,121 @@ func TestClientInterceptor(t *testing.T) {
 	test.AssertError(t, err, "ci.intercept didn't fail when handler returned a error")
 }
 
+func TestClientInterceptorDeadlines(t *testing.T) {
+	ci := clientInterceptor{
+		timeout:        time.Minute,
+		clientMetrics:  grpc_prometheus.NewClientMetrics(),
+		methodTimeouts: map[string]time.Duration{"Slow": time.Hour},
+	}
+	var remaining time.Duration
+	invoker := func(ctx context.Context, method string, _, _ interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, ok := ctx.Deadline()
+		test.Assert(t, ok, "call had no deadline")
+		remaining = time.Until(deadline)
+		if method == "/test.Service/Expired" {
+			return grpc.Errorf(codes.DeadlineExceeded, "context deadline exceeded")
+		}
+		return nil
+	}
+
+	err := ci.intercept(context.Background(), "/test.Service/Fast", nil, nil, nil, invoker)
+	test.AssertNotError(t, err, "ci.intercept failed")
+	test.Assert(t, remaining <= time.Minute && remaining > 59*time.Second, "default timeout wasn't used")
+
+	err = ci.intercept(context.Background(), "/test.Service/Slow", nil, nil, nil, invoker)
+	test.AssertNotError(t, err, "ci.intercept failed")
+	test.Assert(t, remaining > time.Minute, "method timeout wasn't used")
+
+	// A caller's earlier deadline is kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = ci.intercept(ctx, "/test.Service/Slow", nil, nil, nil, invoker)
+	test.AssertNotError(t, err, "ci.intercept failed")
+	test.Assert(t, remaining <= time.Second, "caller's deadline wasn't kept")
+
+	err = ci.intercept(context.Background(), "/test.Service/Expired", nil, nil, nil, invoker)
+	test.Assert(t, berrors.Is(err, berrors.Timeout), "deadline exceeded wasn't a Timeout error")
+}
+
+func TestServerInterceptorExpired(t *testing.T) {
+	si := serverInterceptor{serverMetrics: grpc_prometheus.NewServerMetrics()}
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	called := false
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+	_, err := si.intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "-service-test"}, handler)
+	test.AssertError(t, err, "si.intercept didn't fail with an expired context")
+	test.Assert(t, !called, "handler was called with an expired context")
+}
+
+func TestServerInterceptorRecoversPanics(t *testing.T) {
+	log := blog.NewMock()
+	si := serverInterceptor{serverMetrics: grpc_prometheus.NewServerMetrics(), log: log}
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		panic("oops")
+	}
+	_, err := si.intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Panic"}, handler)
+	test.AssertError(t, err, "si.intercept didn't fail when the handler panicked")
+	test.AssertEquals(t, len(log.GetAllMatching("Panic handling /test.Service/Panic: oops")), 1)
+	test.AssertEquals(t, len(log.GetAllMatching(`"Method":"/test.Service/Panic".*"Error":`)), 1)
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	log := blog.NewMock()
+	si := serverInterceptor{serverMetrics: grpc_prometheus.NewServerMetrics(), log: log}
+	ci := clientInterceptor{time.Second, grpc_prometheus.NewClientMetrics(), nil, nil}
+	var handled string
+	// The invoker hands the outgoing context straight to the server
+	// interceptor, as gRPC's old metadata API uses the same key for both.
+	invoker := func(ctx context.Context, method string, req, _ interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		_, err := si.intercept(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			handled = core.RequestID(ctx)
+			return nil, nil
+		})
+		return err
+	}
+
+	ctx := core.WithRequestID(context.Background(), "abcd")
+	err := ci.intercept(ctx, "/test.Service/Method", nil, nil, nil, invoker)
+	test.AssertNotError(t, err, "ci.intercept failed")
+	test.AssertEquals(t, handled, "abcd")
+	test.AssertEquals(t, len(log.GetAllMatching(`"Method":"/test.Service/Method","RequestID":"abcd"`)), 1)
+
+	// Calls that aren't made for a request get a new ID
+	err = ci.intercept(context.Background(), "/test.Service/Method", nil, nil, nil, invoker)
+	test.AssertNotError(t, err, "ci.intercept failed")
+	test.Assert(t, handled != "" && handled != "abcd", "call didn't get a new request ID")
+}
+
+type nopExporter struct{}
+
+func (nopExporter) Export(string, []*tracing.Span) error { return nil }
+
+func TestTracePropagation(t *testing.T) {
+	tracing.Set(tracing.NewTracer("test", 1, nopExporter{}, time.Second, blog.NewMock()))
+	defer tracing.Set(nil)
+	si := serverInterceptor{serverMetrics: grpc_prometheus.NewServerMetrics()}
+	ci := clientInterceptor{time.Second, grpc_prometheus.NewClientMetrics(), nil, nil}
+	var handled *tracing.Span
+	invoker := func(ctx context.Context, method string, req, _ interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		_, err := si.intercept(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			handled = tracing.FromContext(ctx)
+			return nil, nil
+		})
+		return err
+	}
+
+	ctx, root := tracing.StartSpan(context.Background(), "root", tracing.KindServer)
+	err := ci.intercept(ctx, "/test.Service/Method", nil, nil, nil, invoker)
+	test.AssertNotError(t, err, "ci.intercept failed")
+	test.Assert(t, handled != nil, "server handler had no span")
+	test.AssertEquals(t, handled.TraceID, root.TraceID)
+	test.Assert(t, handled.ParentID != root.SpanID, "server span wasn't a child of the client span")
+}
+
 // testServer is used to implement InterceptorTest
 type testServer struct{}
 
@@ -70,7 +248,7 @@ func (s *testServer) Chill(ctx context.Context, in *test_proto.Time) (*test_prot
 // timeout is reached, i.e. that FailFast is set to false.
 // https://github.com/grpc/grpc/blob/master/doc/wait-for-ready.md
 func TestFailFastFalse(t *testing.T) {
-	ci := &clientInterceptor{100 * time.Millisecond, grpc_prometheus.NewClientMetrics()}
+	ci := &clientInterceptor{100 * time.Millisecond, grpc_prometheus.NewClientMetrics(), nil, nil}
 	conn, err := grpc.Dial("localhost:19876", // random, probably unused port
 		grpc.WithInsecure(),
 		grpc.WithBalancer(grpc.RoundRobin(newStaticResolver([]string{"localhost:19000"}))),
@@ -15,7 +15,9 @@ import (
 
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/revocation"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	vapb "github.com/letsencrypt/boulder/va/proto"
 )
@@ -63,11 +65,17 @@ func ProblemDetailsToPB(prob *probs.ProblemDetails) (*corepb.ProblemDetails, err
 	}
 	pt := string(prob.Type)
 	st := int32(prob.HTTPStatus)
-	return &corepb.ProblemDetails{
+	pb := &corepb.ProblemDetails{
 		ProblemType: &pt,
 		Detail:      &prob.Detail,
 		HttpStatus:  &st,
-	}, nil
+	}
+	if prob.Identifier != nil {
+		it := string(prob.Identifier.Type)
+		pb.IdentifierType = &it
+		pb.IdentifierValue = &prob.Identifier.Value
+	}
+	return pb, nil
 }
 
 func PBToProblemDetails(in *corepb.ProblemDetails) (*probs.ProblemDetails, error) {
@@ -85,6 +93,12 @@ func PBToProblemDetails(in *corepb.ProblemDetails) (*probs.ProblemDetails, error
 	if in.HttpStatus != nil {
 		prob.HTTPStatus = int(*in.HttpStatus)
 	}
+	if in.IdentifierType != nil && in.IdentifierValue != nil {
+		prob.Identifier = &identifier.ACMEIdentifier{
+			Type:  identifier.IdentifierType(*in.IdentifierType),
+			Value: *in.IdentifierValue,
+		}
+	}
 	return prob, nil
 }
 
@@ -158,6 +172,10 @@ func validationRecordToPB(record core.ValidationRecord) (*corepb.ValidationRecor
 	if err != nil {
 		return nil, err
 	}
+	sourceAddr, err := record.SourceAddress.MarshalText()
+	if err != nil {
+		return nil, err
+	}
 	return &corepb.ValidationRecord{
 		Hostname:          &record.Hostname,
 		Port:              &record.Port,
@@ -166,6 +184,9 @@ func validationRecordToPB(record core.ValidationRecord) (*corepb.ValidationRecor
 		Authorities:       record.Authorities,
 		Url:               &record.URL,
 		AddressesTried:    addrsTried,
+		SourceAddress:     sourceAddr,
+		UserAgent:         &record.UserAgent,
+		Accept:            &record.Accept,
 	}, nil
 }
 
@@ -189,6 +210,11 @@ func pbToValidationRecord(in *corepb.ValidationRecord) (record core.ValidationRe
 	if err != nil {
 		return
 	}
+	var sourceAddr net.IP
+	err = sourceAddr.UnmarshalText(in.SourceAddress)
+	if err != nil {
+		return
+	}
 	return core.ValidationRecord{
 		Hostname:          *in.Hostname,
 		Port:              *in.Port,
@@ -197,6 +223,9 @@ func pbToValidationRecord(in *corepb.ValidationRecord) (record core.ValidationRe
 		Authorities:       in.Authorities,
 		URL:               *in.Url,
 		AddressesTried:    addrsTried,
+		SourceAddress:     sourceAddr,
+		UserAgent:         in.GetUserAgent(),
+		Accept:            in.GetAccept(),
 	}, nil
 }
 
@@ -484,3 +513,42 @@ func pbToCert(pb *corepb.Certificate) (core.Certificate, error) {
 		Expires:        time.Unix(0, *pb.Expires),
 	}, nil
 }
+
+func certStatusToPB(certStatus core.CertificateStatus) *sapb.CertificateStatus {
+	ocspLastUpdatedNano := certStatus.OCSPLastUpdated.UnixNano()
+	revokedDateNano := certStatus.RevokedDate.UnixNano()
+	lastExpirationNagSentNano := certStatus.LastExpirationNagSent.UnixNano()
+	notAfterNano := certStatus.NotAfter.UnixNano()
+	reason := int64(certStatus.RevokedReason)
+	status := string(certStatus.Status)
+
+	return &sapb.CertificateStatus{
+		Serial:                &certStatus.Serial,
+		Status:                &status,
+		OcspLastUpdated:       &ocspLastUpdatedNano,
+		RevokedDate:           &revokedDateNano,
+		RevokedReason:         &reason,
+		LastExpirationNagSent: &lastExpirationNagSentNano,
+		OcspResponse:          certStatus.OCSPResponse,
+		NotAfter:              &notAfterNano,
+		IsExpired:             &certStatus.IsExpired,
+	}
+}
+
+func pbToCertStatus(pb *sapb.CertificateStatus) (core.CertificateStatus, error) {
+	if pb == nil || pb.Serial == nil || pb.Status == nil || pb.OcspLastUpdated == nil || pb.RevokedDate == nil || pb.RevokedReason == nil || pb.LastExpirationNagSent == nil || pb.OcspResponse == nil || pb.NotAfter == nil || pb.IsExpired == nil {
+		return core.CertificateStatus{}, errIncompleteResponse
+	}
+
+	return core.CertificateStatus{
+		Serial:                *pb.Serial,
+		Status:                core.OCSPStatus(*pb.Status),
+		OCSPLastUpdated:       time.Unix(0, *pb.OcspLastUpdated),
+		RevokedDate:           time.Unix(0, *pb.RevokedDate),
+		RevokedReason:         revocation.Reason(*pb.RevokedReason),
+		LastExpirationNagSent: time.Unix(0, *pb.LastExpirationNagSent),
+		OCSPResponse:          pb.OcspResponse,
+		NotAfter:              time.Unix(0, *pb.NotAfter),
+		IsExpired:             *pb.IsExpired,
+	}, nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/probs"
 	"github.com/letsencrypt/boulder/test"
 	vapb "github.com/letsencrypt/boulder/va/proto"
@@ -79,6 +80,15 @@ func TestProblemDetails(t *testing.T) {
 	test.AssertNotError(t, err, "PBToProblemDetails failed")
 	test.AssertDeepEquals(t, recon, prob)
 
+	ident := identifier.DNSIdentifier("example.com")
+	prob.Identifier = &ident
+	pb, err = ProblemDetailsToPB(prob)
+	test.AssertNotError(t, err, "problemDetailToPB failed")
+	test.AssertEquals(t, pb.GetIdentifierValue(), "example.com")
+	recon, err = PBToProblemDetails(pb)
+	test.AssertNotError(t, err, "PBToProblemDetails failed")
+	test.AssertDeepEquals(t, recon, prob)
+
 	recon, err = PBToProblemDetails(nil)
 	test.AssertNotError(t, err, "PBToProblemDetails failed")
 	test.Assert(t, recon == nil, "Returned core.PRoblemDetails is not nil")
@@ -153,6 +163,9 @@ func TestValidationRecord(t *testing.T) {
 		URL:               "url",
 		Authorities:       []string{"auth"},
 		AddressesTried:    []net.IP{ip},
+		SourceAddress:     net.ParseIP("10.0.0.1"),
+		UserAgent:         "Boulder",
+		Accept:            "*/*",
 	}
 
 	pb, err := validationRecordToPB(vr)
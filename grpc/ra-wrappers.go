@@ -189,6 +189,11 @@ func (ras *RegistrationAuthorityClientWrapper) NewOrder(ctx context.Context, req
 	return resp, nil
 }
 
+func (ras *RegistrationAuthorityClientWrapper) CheckNewOrder(ctx context.Context, request *rapb.NewOrderRequest) error {
+	_, err := ras.inner.CheckNewOrder(ctx, request)
+	return err
+}
+
 func (ras *RegistrationAuthorityClientWrapper) FinalizeOrder(ctx context.Context, request *rapb.FinalizeOrderRequest) (*corepb.Order, error) {
 	resp, err := ras.inner.FinalizeOrder(ctx, request)
 	if err != nil {
@@ -359,6 +364,17 @@ func (ras *RegistrationAuthorityServerWrapper) NewOrder(ctx context.Context, req
 	return ras.inner.NewOrder(ctx, request)
 }
 
+func (ras *RegistrationAuthorityServerWrapper) CheckNewOrder(ctx context.Context, request *rapb.NewOrderRequest) (*corepb.Empty, error) {
+	if request == nil || request.RegistrationID == nil {
+		return nil, errIncompleteRequest
+	}
+	err := ras.inner.CheckNewOrder(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
 func (ras *RegistrationAuthorityServerWrapper) FinalizeOrder(ctx context.Context, request *rapb.FinalizeOrderRequest) (*corepb.Order, error) {
 	if request == nil || request.Order == nil || request.Csr == nil {
 		return nil, errIncompleteRequest
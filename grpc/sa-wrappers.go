@@ -117,21 +117,26 @@ func (sac StorageAuthorityClientWrapper) GetCertificateStatus(ctx context.Contex
 		return core.CertificateStatus{}, err
 	}
 
-	if response == nil || response.Serial == nil || response.Status == nil || response.OcspLastUpdated == nil || response.RevokedDate == nil || response.RevokedReason == nil || response.LastExpirationNagSent == nil || response.OcspResponse == nil || response.NotAfter == nil || response.IsExpired == nil {
-		return core.CertificateStatus{}, errIncompleteResponse
+	return pbToCertStatus(response)
+}
+
+func (sac StorageAuthorityClientWrapper) GetCertificateStatuses(ctx context.Context, serials []string) ([]core.CertificateStatus, error) {
+	response, err := sac.inner.GetCertificateStatuses(ctx, &sapb.Serials{Serials: serials})
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, errIncompleteResponse
 	}
 
-	return core.CertificateStatus{
-		Serial:                *response.Serial,
-		Status:                core.OCSPStatus(*response.Status),
-		OCSPLastUpdated:       time.Unix(0, *response.OcspLastUpdated),
-		RevokedDate:           time.Unix(0, *response.RevokedDate),
-		RevokedReason:         revocation.Reason(*response.RevokedReason),
-		LastExpirationNagSent: time.Unix(0, *response.LastExpirationNagSent),
-		OCSPResponse:          response.OcspResponse,
-		NotAfter:              time.Unix(0, *response.NotAfter),
-		IsExpired:             *response.IsExpired,
-	}, nil
+	statuses := make([]core.CertificateStatus, len(response.Statuses))
+	for i, pb := range response.Statuses {
+		statuses[i], err = pbToCertStatus(pb)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return statuses, nil
 }
 
 func (sac StorageAuthorityClientWrapper) CountCertificatesRange(ctx context.Context, earliest, latest time.Time) (int64, error) {
@@ -280,6 +285,17 @@ func (sac StorageAuthorityClientWrapper) CountInvalidAuthorizations(ctx context.
 	return sac.inner.CountInvalidAuthorizations(ctx, request)
 }
 
+func (sac StorageAuthorityClientWrapper) CountFailedValidations(ctx context.Context, request *sapb.CountFailedValidationsRequest) (*sapb.Count, error) {
+	count, err := sac.inner.CountFailedValidations(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if count == nil || count.Count == nil {
+		return nil, errIncompleteResponse
+	}
+	return count, nil
+}
+
 func (sac StorageAuthorityClientWrapper) GetPendingAuthorization(ctx context.Context, request *sapb.GetPendingAuthorizationRequest) (*core.Authorization, error) {
 	authzPB, err := sac.inner.GetPendingAuthorization(ctx, request)
 	if err != nil {
@@ -292,6 +308,26 @@ func (sac StorageAuthorityClientWrapper) GetPendingAuthorization(ctx context.Con
 	return &authz, nil
 }
 
+func (sac StorageAuthorityClientWrapper) GetSCTReceipts(ctx context.Context, serial string) ([]core.SignedCertificateTimestamp, error) {
+	response, err := sac.inner.GetSCTReceipts(ctx, &sapb.Serial{Serial: &serial})
+	if err != nil {
+		return nil, err
+	}
+
+	if response == nil {
+		return nil, errIncompleteResponse
+	}
+
+	scts := make([]core.SignedCertificateTimestamp, len(response.Sct))
+	for i, sct := range response.Sct {
+		if sct == nil || !sctValid(sct) {
+			return nil, errIncompleteResponse
+		}
+		scts[i] = pbToSCT(sct)
+	}
+	return scts, nil
+}
+
 func (sac StorageAuthorityClientWrapper) GetSCTReceipt(ctx context.Context, serial, logID string) (core.SignedCertificateTimestamp, error) {
 	response, err := sac.inner.GetSCTReceipt(ctx, &sapb.GetSCTReceiptRequest{Serial: &serial, LogID: &logID})
 	if err != nil {
@@ -337,6 +373,113 @@ func (sac StorageAuthorityClientWrapper) PreviousCertificateExists(
 	return exists, err
 }
 
+func (sac StorageAuthorityClientWrapper) KeyBlocked(
+	ctx context.Context,
+	req *sapb.KeyBlockedRequest,
+) (*sapb.Exists, error) {
+	exists, err := sac.inner.KeyBlocked(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if exists == nil || exists.Exists == nil {
+		return nil, errIncompleteResponse
+	}
+	return exists, nil
+}
+
+func (sac StorageAuthorityClientWrapper) GetSerialsByKey(
+	ctx context.Context,
+	req *sapb.GetSerialsByKeyRequest,
+) (*sapb.Serials, error) {
+	serials, err := sac.inner.GetSerialsByKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if serials == nil {
+		return nil, errIncompleteResponse
+	}
+	return serials, nil
+}
+
+func (sac StorageAuthorityClientWrapper) GetRateLimitOverrides(
+	ctx context.Context,
+	req *corepb.Empty,
+) (*sapb.RateLimitOverrides, error) {
+	overrides, err := sac.inner.GetRateLimitOverrides(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if overrides == nil {
+		return nil, errIncompleteResponse
+	}
+	for _, o := range overrides.Overrides {
+		if o == nil || o.RegistrationID == nil || o.LimitName == nil || o.Threshold == nil || o.Expires == nil {
+			return nil, errIncompleteResponse
+		}
+	}
+	return overrides, nil
+}
+
+func (sac StorageAuthorityClientWrapper) AddBlockedKey(
+	ctx context.Context,
+	req *sapb.AddBlockedKeyRequest,
+) (*corepb.Empty, error) {
+	_, err := sac.inner.AddBlockedKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+func (sac StorageAuthorityClientWrapper) AddVerifiedContact(
+	ctx context.Context,
+	req *sapb.AddVerifiedContactRequest,
+) (*corepb.Empty, error) {
+	_, err := sac.inner.AddVerifiedContact(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+func (sac StorageAuthorityClientWrapper) GetAccountStats(ctx context.Context, req *sapb.RegistrationID) (*sapb.AccountStats, error) {
+	stats, err := sac.inner.GetAccountStats(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil || stats.CertificatesLast7Days == nil || stats.CertificatesLast30Days == nil ||
+		stats.CertificatesLast90Days == nil || stats.FailedValidationsLast7Days == nil ||
+		stats.PendingAuthorizations == nil {
+		return nil, errIncompleteResponse
+	}
+	return stats, nil
+}
+
+func (sac StorageAuthorityClientWrapper) AddCTSubmission(
+	ctx context.Context,
+	req *sapb.AddCTSubmissionRequest,
+) (*corepb.Empty, error) {
+	_, err := sac.inner.AddCTSubmission(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+func (sac StorageAuthorityClientWrapper) PolicyOverridden(
+	ctx context.Context,
+	req *sapb.PolicyOverriddenRequest,
+) (*sapb.Exists, error) {
+	exists, err := sac.inner.PolicyOverridden(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if exists == nil || exists.Exists == nil {
+		return nil, errIncompleteResponse
+	}
+	return exists, nil
+}
+
 func (sac StorageAuthorityClientWrapper) FQDNSetExists(ctx context.Context, domains []string) (bool, error) {
 	response, err := sac.inner.FQDNSetExists(ctx, &sapb.FQDNSetExistsRequest{Domains: domains})
 	if err != nil {
@@ -350,6 +493,17 @@ func (sac StorageAuthorityClientWrapper) FQDNSetExists(ctx context.Context, doma
 	return *response.Exists, nil
 }
 
+func (sac StorageAuthorityClientWrapper) FQDNSetIssuedForAccount(ctx context.Context, request *sapb.FQDNSetIssuedForAccountRequest) (*sapb.Exists, error) {
+	response, err := sac.inner.FQDNSetIssuedForAccount(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil || response.Exists == nil {
+		return nil, errIncompleteResponse
+	}
+	return response, nil
+}
+
 func (sac StorageAuthorityClientWrapper) NewRegistration(ctx context.Context, reg core.Registration) (core.Registration, error) {
 	regPB, err := registrationToPB(reg)
 	if err != nil {
@@ -442,12 +596,17 @@ func (sac StorageAuthorityClientWrapper) MarkCertificateRevoked(ctx context.Cont
 	return nil
 }
 
-func (sac StorageAuthorityClientWrapper) AddCertificate(ctx context.Context, der []byte, regID int64, ocspResponse []byte) (string, error) {
-	response, err := sac.inner.AddCertificate(ctx, &sapb.AddCertificateRequest{
+func (sac StorageAuthorityClientWrapper) AddCertificate(ctx context.Context, der []byte, regID int64, ocspResponse []byte, issued *time.Time) (string, error) {
+	req := &sapb.AddCertificateRequest{
 		Der:   der,
 		RegID: &regID,
 		Ocsp:  ocspResponse,
-	})
+	}
+	if issued != nil {
+		issuedNS := issued.UnixNano()
+		req.Issued = &issuedNS
+	}
+	response, err := sac.inner.AddCertificate(ctx, req)
 	if err != nil {
 		return "", err
 	}
@@ -510,6 +669,17 @@ func (sas StorageAuthorityClientWrapper) NewOrder(ctx context.Context, request *
 	return resp, nil
 }
 
+func (sac StorageAuthorityClientWrapper) NewOrderAndAuthzs(ctx context.Context, request *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error) {
+	resp, err := sac.inner.NewOrderAndAuthzs(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || !orderValid(resp) {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
 func (sac StorageAuthorityClientWrapper) SetOrderProcessing(ctx context.Context, order *corepb.Order) error {
 	if _, err := sac.inner.SetOrderProcessing(ctx, order); err != nil {
 		return err
@@ -715,24 +885,24 @@ func (sas StorageAuthorityServerWrapper) GetCertificateStatus(ctx context.Contex
 		return nil, err
 	}
 
-	ocspLastUpdatedNano := certStatus.OCSPLastUpdated.UnixNano()
-	revokedDateNano := certStatus.RevokedDate.UnixNano()
-	lastExpirationNagSentNano := certStatus.LastExpirationNagSent.UnixNano()
-	notAfterNano := certStatus.NotAfter.UnixNano()
-	reason := int64(certStatus.RevokedReason)
-	status := string(certStatus.Status)
+	return certStatusToPB(certStatus), nil
+}
+
+func (sas StorageAuthorityServerWrapper) GetCertificateStatuses(ctx context.Context, request *sapb.Serials) (*sapb.CertificateStatuses, error) {
+	if request == nil {
+		return nil, errIncompleteRequest
+	}
+
+	statuses, err := sas.inner.GetCertificateStatuses(ctx, request.Serials)
+	if err != nil {
+		return nil, err
+	}
 
-	return &sapb.CertificateStatus{
-		Serial:                &certStatus.Serial,
-		Status:                &status,
-		OcspLastUpdated:       &ocspLastUpdatedNano,
-		RevokedDate:           &revokedDateNano,
-		RevokedReason:         &reason,
-		LastExpirationNagSent: &lastExpirationNagSentNano,
-		OcspResponse:          certStatus.OCSPResponse,
-		NotAfter:              &notAfterNano,
-		IsExpired:             &certStatus.IsExpired,
-	}, nil
+	response := &sapb.CertificateStatuses{}
+	for _, certStatus := range statuses {
+		response.Statuses = append(response.Statuses, certStatusToPB(certStatus))
+	}
+	return response, nil
 }
 
 func (sas StorageAuthorityServerWrapper) CountCertificatesRange(ctx context.Context, request *sapb.Range) (*sapb.Count, error) {
@@ -846,6 +1016,13 @@ func (sas StorageAuthorityServerWrapper) CountInvalidAuthorizations(ctx context.
 	return sas.inner.CountInvalidAuthorizations(ctx, request)
 }
 
+func (sas StorageAuthorityServerWrapper) CountFailedValidations(ctx context.Context, request *sapb.CountFailedValidationsRequest) (*sapb.Count, error) {
+	if request == nil || request.RegistrationID == nil || request.Hostname == nil || request.Range == nil || request.Range.Earliest == nil || request.Range.Latest == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.CountFailedValidations(ctx, request)
+}
+
 func (sas StorageAuthorityServerWrapper) GetPendingAuthorization(ctx context.Context, request *sapb.GetPendingAuthorizationRequest) (*corepb.Authorization, error) {
 	authz, err := sas.inner.GetPendingAuthorization(ctx, request)
 	if err != nil {
@@ -871,6 +1048,23 @@ func (sas StorageAuthorityServerWrapper) GetSCTReceipt(ctx context.Context, requ
 	return sctToPB(sct), nil
 }
 
+func (sas StorageAuthorityServerWrapper) GetSCTReceipts(ctx context.Context, request *sapb.Serial) (*sapb.SignedCertificateTimestamps, error) {
+	if request == nil || request.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+
+	scts, err := sas.inner.GetSCTReceipts(ctx, *request.Serial)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &sapb.SignedCertificateTimestamps{}
+	for _, sct := range scts {
+		response.Sct = append(response.Sct, sctToPB(sct))
+	}
+	return response, nil
+}
+
 func (sas StorageAuthorityServerWrapper) CountFQDNSets(ctx context.Context, request *sapb.CountFQDNSetsRequest) (*sapb.Count, error) {
 	if request == nil || request.Window == nil || request.Domains == nil {
 		return nil, errIncompleteRequest
@@ -900,6 +1094,13 @@ func (sas StorageAuthorityServerWrapper) FQDNSetExists(ctx context.Context, requ
 	return &sapb.Exists{Exists: &exists}, nil
 }
 
+func (sas StorageAuthorityServerWrapper) FQDNSetIssuedForAccount(ctx context.Context, request *sapb.FQDNSetIssuedForAccountRequest) (*sapb.Exists, error) {
+	if request == nil || request.Domains == nil || request.RegistrationID == nil || request.Earliest == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.FQDNSetIssuedForAccount(ctx, request)
+}
+
 func (sac StorageAuthorityServerWrapper) PreviousCertificateExists(
 	ctx context.Context,
 	req *sapb.PreviousCertificateExistsRequest,
@@ -910,6 +1111,83 @@ func (sac StorageAuthorityServerWrapper) PreviousCertificateExists(
 	return sac.inner.PreviousCertificateExists(ctx, req)
 }
 
+func (sas StorageAuthorityServerWrapper) KeyBlocked(
+	ctx context.Context,
+	req *sapb.KeyBlockedRequest,
+) (*sapb.Exists, error) {
+	if req == nil || req.KeyHash == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.KeyBlocked(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetSerialsByKey(
+	ctx context.Context,
+	req *sapb.GetSerialsByKeyRequest,
+) (*sapb.Serials, error) {
+	if req == nil || req.KeyHash == nil || req.NotAfter == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetSerialsByKey(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetRateLimitOverrides(
+	ctx context.Context,
+	req *corepb.Empty,
+) (*sapb.RateLimitOverrides, error) {
+	if req == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetRateLimitOverrides(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) AddBlockedKey(
+	ctx context.Context,
+	req *sapb.AddBlockedKeyRequest,
+) (*corepb.Empty, error) {
+	if req == nil || req.KeyHash == nil || req.Added == nil || req.AddedBy == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.AddBlockedKey(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) AddVerifiedContact(
+	ctx context.Context,
+	req *sapb.AddVerifiedContactRequest,
+) (*corepb.Empty, error) {
+	if req == nil || req.RegistrationID == nil || req.Email == nil || req.Verified == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.AddVerifiedContact(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetAccountStats(ctx context.Context, req *sapb.RegistrationID) (*sapb.AccountStats, error) {
+	if req == nil || req.Id == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetAccountStats(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) AddCTSubmission(
+	ctx context.Context,
+	req *sapb.AddCTSubmissionRequest,
+) (*corepb.Empty, error) {
+	if req == nil || req.Serial == nil || req.LogURL == nil || req.Precert == nil || req.Result == nil || req.Submitted == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.AddCTSubmission(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) PolicyOverridden(
+	ctx context.Context,
+	req *sapb.PolicyOverriddenRequest,
+) (*sapb.Exists, error) {
+	if req == nil || req.Domain == nil || req.RegistrationID == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.PolicyOverridden(ctx, req)
+}
+
 func (sas StorageAuthorityServerWrapper) NewRegistration(ctx context.Context, request *corepb.Registration) (*corepb.Registration, error) {
 	if request == nil || !registrationValid(request) {
 		return nil, errIncompleteRequest
@@ -1018,7 +1296,12 @@ func (sas StorageAuthorityServerWrapper) AddCertificate(ctx context.Context, req
 		return nil, errIncompleteRequest
 	}
 
-	digest, err := sas.inner.AddCertificate(ctx, request.Der, *request.RegID, request.Ocsp)
+	var issued *time.Time
+	if request.Issued != nil {
+		t := time.Unix(0, *request.Issued)
+		issued = &t
+	}
+	digest, err := sas.inner.AddCertificate(ctx, request.Der, *request.RegID, request.Ocsp, issued)
 	if err != nil {
 		return nil, err
 	}
@@ -1086,6 +1369,26 @@ func (sas StorageAuthorityServerWrapper) NewOrder(ctx context.Context, request *
 	return sas.inner.NewOrder(ctx, request)
 }
 
+func (sas StorageAuthorityServerWrapper) NewOrderAndAuthzs(ctx context.Context, request *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error) {
+	if request == nil || request.NewOrder == nil {
+		return nil, errIncompleteRequest
+	}
+	order := request.NewOrder
+	if order.RegistrationID == nil || order.Expires == nil || order.Names == nil {
+		return nil, errIncompleteRequest
+	}
+	if len(order.Authorizations) == 0 && len(request.NewAuthzs) == 0 {
+		return nil, errIncompleteRequest
+	}
+	for _, authz := range request.NewAuthzs {
+		if authz == nil || authz.Identifier == nil || authz.RegistrationID == nil || authz.Status == nil || authz.Expires == nil {
+			return nil, errIncompleteRequest
+		}
+	}
+
+	return sas.inner.NewOrderAndAuthzs(ctx, request)
+}
+
 func (sas StorageAuthorityServerWrapper) SetOrderProcessing(ctx context.Context, order *corepb.Order) (*corepb.Empty, error) {
 	if order == nil || !orderValid(order) {
 		return nil, errIncompleteRequest
@@ -3,6 +3,7 @@ package grpc
 import (
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"net"
 
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/letsencrypt/boulder/cmd"
 	bcreds "github.com/letsencrypt/boulder/grpc/creds"
+	healthpb "github.com/letsencrypt/boulder/grpc/health_proto"
+	blog "github.com/letsencrypt/boulder/log"
 )
 
 // CodedError is a alias required to appease go vet
@@ -22,7 +25,13 @@ var errNilTLS = errors.New("boulder/grpc: received nil tls.Config")
 // verifies that clients present a certificate that (a) is signed by one of
 // the configured ClientCAs, and (b) contains at least one
 // subjectAlternativeName matching the accepted list from GRPCServerConfig.
-func NewServer(c *cmd.GRPCServerConfig, tls *tls.Config, serverMetrics *grpc_prometheus.ServerMetrics) (*grpc.Server, net.Listener, error) {
+// Calls to services listed in the config's Services are further restricted to
+// the clients named for that service.
+// Any interceptors given are run, in order, inside Boulder's own interceptor,
+// so they see requests after field decryption and errors before they are
+// wrapped for transmission. The server also implements the standard gRPC
+// health checking service, which clients may use to balance requests.
+func NewServer(c *cmd.GRPCServerConfig, tls *tls.Config, serverMetrics *grpc_prometheus.ServerMetrics, interceptors ...grpc.UnaryServerInterceptor) (*grpc.Server, net.Listener, error) {
 	if serverMetrics == nil {
 		return nil, nil, errNilMetrics
 	}
@@ -34,18 +43,47 @@ func NewServer(c *cmd.GRPCServerConfig, tls *tls.Config, serverMetrics *grpc_pro
 		acceptedSANs[name] = struct{}{}
 	}
 
+	si := &serverInterceptor{serverMetrics: serverMetrics, interceptors: interceptors, log: blog.Get()}
+	if len(c.Services) > 0 {
+		si.serviceClients = make(map[string]map[string]struct{})
+		for service, sc := range c.Services {
+			if len(sc.ClientNames) == 0 {
+				return nil, nil, fmt.Errorf("boulder/grpc: no clientNames configured for service %q", service)
+			}
+			si.serviceClients[service] = make(map[string]struct{})
+			for _, name := range sc.ClientNames {
+				si.serviceClients[service][name] = struct{}{}
+				acceptedSANs[name] = struct{}{}
+			}
+		}
+		// Copy the names accepted before the service-specific ones were
+		// added, which may call any service that isn't listed.
+		si.defaultClients = make(map[string]struct{})
+		for _, name := range c.ClientNames {
+			si.defaultClients[name] = struct{}{}
+		}
+	}
+
 	creds, err := bcreds.NewServerCredentials(tls, acceptedSANs)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if c.FieldEncryption != nil {
+		si.fields, err = newFieldCrypter(c.FieldEncryption)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	l, err := net.Listen("tcp", c.Address)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	si := &serverInterceptor{serverMetrics}
-	return grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(si.intercept)), l, nil
+	srv := grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(si.intercept))
+	healthpb.RegisterHealthServer(srv, healthServer{})
+	return srv, l, nil
 }
 
 // NewServerMetrics constructs a *grpc_prometheus.ServerMetrics, registered with
@@ -38,6 +38,24 @@ func (s *ValidationAuthorityGRPCServer) PerformValidation(ctx context.Context, i
 	return validationResultToPB(records, prob)
 }
 
+func (s *ValidationAuthorityGRPCServer) ReplayValidation(ctx context.Context, in *vaPB.ReplayValidationRequest) (*vaPB.ValidationResult, error) {
+	if in == nil || in.Domain == nil || in.UserAgent == nil {
+		return nil, ErrMissingParameters
+	}
+	challenge, err := pbToChallenge(in.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.impl.ReplayValidation(ctx, *in.Domain, challenge, *in.UserAgent)
+	// As with PerformValidation a problem is returned in the response along
+	// with the records.
+	prob, ok := err.(*probs.ProblemDetails)
+	if !ok && err != nil {
+		return nil, err
+	}
+	return validationResultToPB(records, prob)
+}
+
 func (s *ValidationAuthorityGRPCServer) IsSafeDomain(ctx context.Context, in *vaPB.IsSafeDomainRequest) (*vaPB.IsDomainSafe, error) {
 	return s.impl.IsSafeDomain(ctx, in)
 }
@@ -75,6 +93,32 @@ func (vac ValidationAuthorityGRPCClient) PerformValidation(ctx context.Context,
 	return records, prob
 }
 
+// ReplayValidation has the VA check the specified challenge again, without
+// the results being stored, and returns the validation records along with
+// any problem.
+func (vac ValidationAuthorityGRPCClient) ReplayValidation(ctx context.Context, domain string, challenge core.Challenge, userAgent string) ([]core.ValidationRecord, error) {
+	pbChall, err := ChallengeToPB(challenge)
+	if err != nil {
+		return nil, err
+	}
+	result, err := vac.gc.ReplayValidation(ctx, &vaPB.ReplayValidationRequest{
+		Domain:    &domain,
+		Challenge: pbChall,
+		UserAgent: &userAgent,
+	})
+	if err != nil {
+		return nil, err
+	}
+	records, prob, err := pbToValidationResult(result)
+	if err != nil {
+		return nil, err
+	}
+	if prob == nil {
+		return records, nil
+	}
+	return records, prob
+}
+
 // IsSafeDomain returns true if the domain given is determined to be safe by an
 // third-party safe browsing API.
 func (vac ValidationAuthorityGRPCClient) IsSafeDomain(ctx context.Context, req *vaPB.IsSafeDomainRequest) (*vaPB.IsDomainSafe, error) {
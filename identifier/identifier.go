@@ -0,0 +1,24 @@
+// Package identifier defines the ACME identifier types. They're kept apart
+// from core so that low-level packages such as probs and errors, which core
+// depends on, can refer to identifiers too.
+package identifier
+
+// IdentifierType defines the available identification mechanisms for domains
+type IdentifierType string
+
+// These types are the available identification mechanisms
+const (
+	DNS = IdentifierType("dns")
+)
+
+// ACMEIdentifier is the identifier of a subject of a certificate request, in
+// the form it takes in ACME objects. Only DNS names are supported.
+type ACMEIdentifier struct {
+	Type  IdentifierType `json:"type"`  // The type of identifier being encoded
+	Value string         `json:"value"` // The identifier itself
+}
+
+// DNSIdentifier returns the ACMEIdentifier for a DNS name.
+func DNSIdentifier(domain string) ACMEIdentifier {
+	return ACMEIdentifier{Type: DNS, Value: domain}
+}
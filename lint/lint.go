@@ -0,0 +1,104 @@
+// Package lint runs the certlint ASN.1 and certificate checks against
+// certificates. The CA uses it to refuse to sign certificates that fail them,
+// and cert-checker to report certificates that were issued anyway.
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	lintasn1 "github.com/globalsign/certlint/asn1"
+	"github.com/globalsign/certlint/certdata"
+	"github.com/globalsign/certlint/checks"
+	_ "github.com/globalsign/certlint/checks/certificate/all"
+	_ "github.com/globalsign/certlint/checks/extensions/all"
+	certlinterrors "github.com/globalsign/certlint/errors"
+)
+
+// Finding is a single problem certlint found with a certificate.
+type Finding struct {
+	Priority certlinterrors.Priority
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s (%s)", f.Message, strings.ToLower(f.Priority.String()))
+}
+
+// ParsePriority converts the name of a certlint priority, as used in
+// configuration, to a Priority. Names are case insensitive.
+func ParsePriority(name string) (certlinterrors.Priority, error) {
+	for p := certlinterrors.Debug; p <= certlinterrors.Emergency; p++ {
+		if strings.EqualFold(name, p.String()) {
+			return p, nil
+		}
+	}
+	return certlinterrors.Unknown, fmt.Errorf("unknown lint priority %q", name)
+}
+
+// Run runs every certlint check against the DER encoded certificate and
+// returns what they found. It only returns an error if the certificate
+// couldn't be parsed for the certificate checks.
+func Run(der []byte) ([]Finding, error) {
+	var findings []Finding
+	add := func(errs *certlinterrors.Errors) {
+		if errs == nil {
+			return
+		}
+		for _, err := range errs.List() {
+			findings = append(findings, Finding{Priority: err.Priority(), Message: err.Error()})
+		}
+	}
+	add(new(lintasn1.Linter).CheckStruct(der))
+	d, err := certdata.Load(der)
+	if err != nil {
+		return findings, err
+	}
+	add(checks.Certificate.Check(d))
+	return findings, nil
+}
+
+// Linter decides whether certificates pass linting.
+type Linter struct {
+	threshold certlinterrors.Priority
+	ignored   map[string]bool
+}
+
+// New returns a Linter that fails certificates with findings at or above the
+// named threshold priority, other than findings whose message is in ignored.
+func New(ignored []string, threshold string) (*Linter, error) {
+	p, err := ParsePriority(threshold)
+	if err != nil {
+		return nil, err
+	}
+	l := &Linter{threshold: p, ignored: make(map[string]bool, len(ignored))}
+	for _, message := range ignored {
+		l.ignored[message] = true
+	}
+	return l, nil
+}
+
+// Check lints the DER encoded certificate, returning the findings that
+// aren't ignored and an error if any of them met the threshold or the
+// certificate couldn't be linted at all.
+func (l *Linter) Check(der []byte) ([]Finding, error) {
+	all, err := Run(der)
+	if err != nil {
+		return nil, fmt.Errorf("certificate couldn't be linted: %s", err)
+	}
+	var findings []Finding
+	var failed []string
+	for _, finding := range all {
+		if l.ignored[finding.Message] {
+			continue
+		}
+		findings = append(findings, finding)
+		if finding.Priority >= l.threshold {
+			failed = append(failed, finding.String())
+		}
+	}
+	if len(failed) > 0 {
+		return findings, fmt.Errorf("certificate failed linting: %s", strings.Join(failed, ", "))
+	}
+	return findings, nil
+}
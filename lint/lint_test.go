@@ -0,0 +1,86 @@
+package lint
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	certlinterrors "github.com/globalsign/certlint/errors"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func makeCert(t *testing.T, mutate func(*x509.Certificate)) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "Failed to generate key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(0).Lsh(big.NewInt(1), 100),
+		Subject:               pkix.Name{CommonName: "example.com"},
+		DNSNames:              []string{"example.com", "www.example.com"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IssuingCertificateURL: []string{"http://example.com/issuer"},
+		OCSPServer:            []string{"http://example.com/ocsp"},
+	}
+	if mutate != nil {
+		mutate(template)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "Failed to create certificate")
+	return der
+}
+
+func TestParsePriority(t *testing.T) {
+	p, err := ParsePriority("Warning")
+	test.AssertNotError(t, err, "Failed to parse priority")
+	test.AssertEquals(t, p, certlinterrors.Warning)
+	_, err = ParsePriority("catastrophic")
+	test.AssertError(t, err, "Parsed unknown priority")
+	_, err = ParsePriority("unknown")
+	test.AssertError(t, err, "Parsed a priority no finding has")
+}
+
+func TestRun(t *testing.T) {
+	_, err := Run([]byte{1, 2, 3})
+	test.AssertError(t, err, "Linted a certificate that doesn't parse")
+
+	findings, err := Run(makeCert(t, func(c *x509.Certificate) { c.IsCA = true }))
+	test.AssertNotError(t, err, "Run failed")
+	var found bool
+	for _, f := range findings {
+		found = found || (f.Message == "Certificate has set CA true" && f.Priority == certlinterrors.Error)
+	}
+	test.Assert(t, found, "CA certificate wasn't found by certlint")
+}
+
+func TestCheck(t *testing.T) {
+	l, err := New([]string{"commonName field is deprecated"}, "error")
+	test.AssertNotError(t, err, "Failed to create linter")
+
+	findings, err := l.Check(makeCert(t, nil))
+	test.AssertNotError(t, err, "Good certificate failed linting")
+	for _, f := range findings {
+		test.Assert(t, f.Message != "commonName field is deprecated", "Ignored finding was returned")
+	}
+
+	noOCSP := makeCert(t, func(c *x509.Certificate) { c.OCSPServer = nil })
+	_, err = l.Check(noOCSP)
+	test.AssertError(t, err, "Bad certificate passed linting")
+	test.Assert(t, strings.Contains(err.Error(), "Certificate contains no CRL or OCSP server (error)"), "Error didn't name the finding")
+
+	l, err = New([]string{"Certificate contains no CRL or OCSP server"}, "error")
+	test.AssertNotError(t, err, "Failed to create linter")
+	_, err = l.Check(noOCSP)
+	test.AssertNotError(t, err, "Ignored finding failed linting")
+
+	_, err = New(nil, "severe")
+	test.AssertError(t, err, "Allowed an unknown threshold")
+}
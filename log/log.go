@@ -1,15 +1,19 @@
 package log
 
 import (
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"log/syslog"
 	"os"
 	"path"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/jmhodges/clock"
 )
@@ -31,6 +35,9 @@ type Logger interface {
 // impl implements Logger.
 type impl struct {
 	w writer
+	// json, if not nil, formats each message as a JSON object before it's
+	// handed to w.
+	json *jsonFormatter
 }
 
 // singleton defines the object of a Singleton pattern
@@ -51,7 +58,21 @@ func New(log *syslog.Writer, stdoutLogLevel int, syslogLogLevel int) (Logger, er
 		return nil, errors.New("Attempted to use a nil System Logger.")
 	}
 	return &impl{
-		&bothWriter{log, stdoutLogLevel, syslogLogLevel, clock.Default()},
+		w: &bothWriter{log, stdoutLogLevel, syslogLogLevel, clock.Default(), false},
+	}, nil
+}
+
+// NewJSON returns a new Logger like New, except that each message is written
+// as a JSON object holding its level, timestamp, component and checksum, so
+// that log pipelines don't have to parse free-form text.
+func NewJSON(log *syslog.Writer, stdoutLogLevel int, syslogLogLevel int) (Logger, error) {
+	if log == nil {
+		return nil, errors.New("Attempted to use a nil System Logger.")
+	}
+	clk := clock.Default()
+	return &impl{
+		w:    &bothWriter{log, stdoutLogLevel, syslogLogLevel, clk, true},
+		json: &jsonFormatter{component: path.Base(os.Args[0]), clk: clk},
 	}, nil
 }
 
@@ -103,12 +124,67 @@ type writer interface {
 	logAtLevel(syslog.Priority, string)
 }
 
+var levelName = map[syslog.Priority]string{
+	syslog.LOG_ERR:     "ERR",
+	syslog.LOG_WARNING: "WARNING",
+	syslog.LOG_INFO:    "INFO",
+	syslog.LOG_DEBUG:   "DEBUG",
+}
+
+// LogLineChecksum returns a short checksum of a log message, which is
+// included in JSON log lines so that truncated or corrupted messages can be
+// detected downstream.
+func LogLineChecksum(msg string) string {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], crc32.ChecksumIEEE([]byte(msg)))
+	return base64.RawURLEncoding.EncodeToString(buf[:])
+}
+
+// jsonEntry is the JSON form of a log message.
+type jsonEntry struct {
+	Level     string          `json:"level"`
+	Timestamp string          `json:"timestamp"`
+	Component string          `json:"component"`
+	Checksum  string          `json:"checksum"`
+	Audit     bool            `json:"audit,omitempty"`
+	Message   string          `json:"msg"`
+	Fields    json.RawMessage `json:"fields,omitempty"`
+}
+
+// jsonFormatter formats log messages as JSON objects.
+type jsonFormatter struct {
+	component string
+	clk       clock.Clock
+}
+
+// format returns msg as a JSON object. fields, if not nil, is an already
+// serialized object of key/value pairs describing the message.
+func (f *jsonFormatter) format(level syslog.Priority, msg string, audit bool, fields json.RawMessage) string {
+	entry := jsonEntry{
+		Level:     levelName[level],
+		Timestamp: f.clk.Now().UTC().Format(time.RFC3339Nano),
+		Component: f.component,
+		Checksum:  LogLineChecksum(msg),
+		Audit:     audit,
+		Message:   msg,
+		Fields:    fields,
+	}
+	// Every field is a string, a bool or already serialized JSON, so this
+	// can't fail.
+	line, _ := json.Marshal(entry)
+	return string(line)
+}
+
 // bothWriter implements writer and writes to both syslog and stdout.
 type bothWriter struct {
 	*syslog.Writer
 	stdoutLevel int
 	syslogLevel int
 	clk         clock.Clock
+	// raw messages are written to stdout as they are, without the level,
+	// timestamp and component that are otherwise added, because they're
+	// JSON objects that already include them.
+	raw bool
 }
 
 // Log the provided message at the appropriate level, writing to
@@ -154,17 +230,33 @@ func (w *bothWriter) logAtLevel(level syslog.Priority, msg string) {
 		reset = "\033[0m"
 	}
 
-	if int(level) <= w.stdoutLevel {
-		fmt.Printf("%s%s %s %s%s\n",
-			prefix,
-			w.clk.Now().Format("150405"),
-			path.Base(os.Args[0]),
-			msg,
-			reset)
+	if int(level) > w.stdoutLevel {
+		return
+	}
+	if w.raw {
+		fmt.Println(msg)
+		return
+	}
+	fmt.Printf("%s%s %s %s%s\n",
+		prefix,
+		w.clk.Now().Format("150405"),
+		path.Base(os.Args[0]),
+		msg,
+		reset)
+}
+
+func (log *impl) logAtLevel(level syslog.Priority, msg string) {
+	if log.json != nil {
+		msg = log.json.format(level, msg, false, nil)
 	}
+	log.w.logAtLevel(level, msg)
 }
 
 func (log *impl) auditAtLevel(level syslog.Priority, msg string) {
+	if log.json != nil {
+		log.w.logAtLevel(level, log.json.format(level, msg, true, nil))
+		return
+	}
 	text := fmt.Sprintf("%s %s", auditTag, msg)
 	log.w.logAtLevel(level, text)
 }
@@ -201,17 +293,17 @@ func (log *impl) Err(msg string) {
 
 // Warning level messages pass through normally.
 func (log *impl) Warning(msg string) {
-	log.w.logAtLevel(syslog.LOG_WARNING, msg)
+	log.logAtLevel(syslog.LOG_WARNING, msg)
 }
 
 // Info level messages pass through normally.
 func (log *impl) Info(msg string) {
-	log.w.logAtLevel(syslog.LOG_INFO, msg)
+	log.logAtLevel(syslog.LOG_INFO, msg)
 }
 
 // Debug level messages pass through normally.
 func (log *impl) Debug(msg string) {
-	log.w.logAtLevel(syslog.LOG_DEBUG, msg)
+	log.logAtLevel(syslog.LOG_DEBUG, msg)
 }
 
 // AuditInfo sends an INFO-severity message that is prefixed with the
@@ -229,6 +321,12 @@ func (log *impl) AuditObject(msg string, obj interface{}) {
 		return
 	}
 
+	if log.json != nil {
+		// In JSON mode the object's contents become the message's fields
+		// rather than being embedded in its text.
+		log.w.logAtLevel(syslog.LOG_INFO, log.json.format(syslog.LOG_INFO, msg, true, jsonObj))
+		return
+	}
 	log.auditAtLevel(syslog.LOG_INFO, fmt.Sprintf("%s JSON=%s", msg, jsonObj))
 }
 
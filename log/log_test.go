@@ -110,6 +110,36 @@ func ExampleLogger() {
 	// [33mW000000 log.test Warning Audit[0m
 }
 
+func ExampleNewJSON() {
+	writer, err := syslog.Dial("udp", "127.0.0.1:65530", syslog.LOG_INFO|syslog.LOG_LOCAL0, "")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err := NewJSON(writer, stdoutLevel, syslogLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	impl, ok := logger.(*impl)
+	if !ok {
+		log.Fatalf("Wrong type returned from NewJSON: %T", logger)
+	}
+
+	impl.json.clk = clock.NewFake()
+	impl.Info("Info message")
+	impl.AuditObject("Audit object", map[string]int{"serial": 5})
+	// Output:
+	// {"level":"INFO","timestamp":"1970-01-01T00:00:00Z","component":"log.test","checksum":"WTlBJQ","msg":"Info message"}
+	// {"level":"INFO","timestamp":"1970-01-01T00:00:00Z","component":"log.test","checksum":"iWL8DQ","audit":true,"msg":"Audit object","fields":{"serial":5}}
+}
+
+func TestLogLineChecksum(t *testing.T) {
+	t.Parallel()
+	test.AssertEquals(t, len(LogLineChecksum("message")), 6)
+	test.AssertEquals(t, LogLineChecksum("message"), LogLineChecksum("message"))
+	test.AssertNotEquals(t, LogLineChecksum("message"), LogLineChecksum("messagf"))
+}
+
 func TestSyslogMethods(t *testing.T) {
 	t.Parallel()
 	impl := setup(t)
@@ -15,7 +15,7 @@ func UseMock() *Mock {
 
 // NewMock creates a mock logger.
 func NewMock() *Mock {
-	return &Mock{impl{newMockWriter()}}
+	return &Mock{impl{w: newMockWriter()}}
 }
 
 // Mock is a logger that stores all log messages in memory to be examined by a
@@ -35,13 +35,6 @@ type mockWriter struct {
 	closeChan chan<- struct{}
 }
 
-var levelName = map[syslog.Priority]string{
-	syslog.LOG_ERR:     "ERR",
-	syslog.LOG_WARNING: "WARNING",
-	syslog.LOG_INFO:    "INFO",
-	syslog.LOG_DEBUG:   "DEBUG",
-}
-
 func (w *mockWriter) logAtLevel(p syslog.Priority, msg string) {
 	w.msgChan <- fmt.Sprintf("%s: %s", levelName[p&7], msg)
 }
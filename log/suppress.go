@@ -0,0 +1,120 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+)
+
+// maxSuppressed is how many distinct messages a suppressing Logger keeps
+// count of at once. Messages beyond this are logged without suppression,
+// so that a flood of unique messages can't exhaust memory.
+const maxSuppressed = 1000
+
+// repeat counts the repetitions of a message within the current window.
+type repeat struct {
+	msg   string
+	start time.Time
+	count int
+}
+
+// suppressing is a Logger that collapses repetitions of identical warning
+// messages into periodic summaries.
+type suppressing struct {
+	Logger
+	window time.Duration
+	clk    clock.Clock
+
+	sync.Mutex
+	repeats map[string]*repeat
+}
+
+// NewSuppressing returns a Logger that passes messages on to l, except that
+// a Warning message repeated within window of its first occurrence is counted
+// rather than logged. Once the window has passed a single summary with the
+// count is logged in place of the repetitions. This keeps a burst of
+// identical warnings, such as DNS timeouts during an outage, from drowning
+// out other messages. Err messages are audit logged, so they are never
+// suppressed, and neither are messages at any other level.
+func NewSuppressing(l Logger, window time.Duration, clk clock.Clock) Logger {
+	s := newSuppressing(l, window, clk)
+	go func() {
+		for {
+			<-clk.After(window)
+			s.flush()
+		}
+	}()
+	return s
+}
+
+func newSuppressing(l Logger, window time.Duration, clk clock.Clock) *suppressing {
+	return &suppressing{
+		Logger:  l,
+		window:  window,
+		clk:     clk,
+		repeats: make(map[string]*repeat),
+	}
+}
+
+// allow reports whether msg should be logged, counting it if not.
+func (s *suppressing) allow(msg string) bool {
+	now := s.clk.Now()
+	s.Lock()
+	r, ok := s.repeats[msg]
+	if !ok {
+		if len(s.repeats) < maxSuppressed {
+			s.repeats[msg] = &repeat{msg: msg, start: now}
+		}
+		s.Unlock()
+		return true
+	}
+	if now.Before(r.start.Add(s.window)) {
+		r.count++
+		s.Unlock()
+		return false
+	}
+	// The window has passed without a flush, so summarize it and start a
+	// new one with this message.
+	expired := *r
+	r.start, r.count = now, 0
+	s.Unlock()
+	s.summarize(expired)
+	return true
+}
+
+// flush logs a summary of each message whose window has passed and stops
+// tracking it.
+func (s *suppressing) flush() {
+	now := s.clk.Now()
+	var expired []repeat
+	s.Lock()
+	for key, r := range s.repeats {
+		if now.Before(r.start.Add(s.window)) {
+			continue
+		}
+		expired = append(expired, *r)
+		delete(s.repeats, key)
+	}
+	s.Unlock()
+	for _, r := range expired {
+		s.summarize(r)
+	}
+}
+
+// summarize logs the number of times r's message was suppressed, if any.
+func (s *suppressing) summarize(r repeat) {
+	if r.count == 0 {
+		return
+	}
+	s.Logger.Warning(fmt.Sprintf("%s [suppressed %d identical messages in %s]", r.msg, r.count, s.window))
+}
+
+// Warning logs msg at WARNING level unless it's a repetition being
+// suppressed.
+func (s *suppressing) Warning(msg string) {
+	if s.allow(msg) {
+		s.Logger.Warning(msg)
+	}
+}
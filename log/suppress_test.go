@@ -0,0 +1,68 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSuppressing(t *testing.T) {
+	t.Parallel()
+	mock := NewMock()
+	fc := clock.NewFake()
+	l := newSuppressing(mock, time.Hour, fc)
+
+	for i := 0; i < 5; i++ {
+		l.Err("DNS timeout")
+		l.Warning("DNS timeout")
+		l.AuditErr("DNS timeout")
+	}
+	l.Warning("other problem")
+	// Err messages are audit logged, so they're never suppressed
+	test.AssertEquals(t, len(mock.GetAllMatching("^ERR: \\[AUDIT\\] DNS timeout$")), 10)
+	test.AssertEquals(t, len(mock.GetAllMatching("^WARNING: DNS timeout$")), 1)
+	test.AssertEquals(t, len(mock.GetAllMatching("other problem")), 1)
+
+	// Nothing is summarized until the window has passed
+	l.flush()
+	test.AssertEquals(t, len(mock.GetAllMatching("suppressed")), 0)
+
+	fc.Add(time.Hour)
+	l.flush()
+	test.AssertEquals(t, len(mock.GetAllMatching("suppressed")), 1)
+	test.AssertEquals(t, len(mock.GetAllMatching(
+		"^WARNING: DNS timeout \\[suppressed 4 identical messages in 1h0m0s\\]$")), 1)
+
+	// A message that arrives after its window has passed but before the
+	// flush summarizes the previous window and is logged
+	mock.Clear()
+	l.Warning("DNS timeout")
+	l.Warning("DNS timeout")
+	fc.Add(time.Hour)
+	l.Warning("DNS timeout")
+	test.AssertDeepEquals(t, mock.GetAll(), []string{
+		"WARNING: DNS timeout",
+		"WARNING: DNS timeout [suppressed 1 identical messages in 1h0m0s]",
+		"WARNING: DNS timeout",
+	})
+}
+
+func TestSuppressingFlushesOnClock(t *testing.T) {
+	t.Parallel()
+	mock := NewMock()
+	fc := clock.NewFake()
+	l := NewSuppressing(mock, time.Minute, fc)
+
+	l.Warning("DNS timeout")
+	l.Warning("DNS timeout")
+	// Wait for the flushing goroutine to be waiting on the clock
+	for i := 0; i < 100 && len(mock.GetAllMatching("suppressed")) == 0; i++ {
+		fc.Add(time.Minute)
+		time.Sleep(10 * time.Millisecond)
+	}
+	test.AssertEquals(t, len(mock.GetAllMatching(
+		"^WARNING: DNS timeout \\[suppressed 1 identical messages in 1m0s\\]$")), 1)
+}
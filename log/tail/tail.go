@@ -0,0 +1,127 @@
+// Package tail follows log files as they're written.
+package tail
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// Follower follows a log file as it's written, including across rotation
+// and truncation.
+type Follower struct {
+	Filename string
+	// Interval is how often the file is checked for new lines once the end
+	// has been reached.
+	Interval time.Duration
+	Log      blog.Logger
+	// FromStart makes Follow read the lines already in the file when it's
+	// first opened, rather than only those appended later.
+	FromStart bool
+}
+
+// Follow calls handle with each complete line appended to the file after
+// Follow is called, or with every line if FromStart is set, until stop is
+// closed. If the file is replaced, lines are read from the start of the new
+// file.
+func (fl *Follower) Follow(handle func(string), stop <-chan struct{}) {
+	f, err := fl.open(!fl.FromStart)
+	for err != nil {
+		fl.Log.Warning(fmt.Sprintf("Opening %s: %s", fl.Filename, err))
+		select {
+		case <-stop:
+			return
+		case <-time.After(fl.Interval):
+		}
+		f, err = fl.open(false)
+	}
+	defer func() { _ = f.Close() }()
+
+	r := bufio.NewReader(f)
+	var partial string
+	for {
+		line, err := r.ReadString('\n')
+		if err == nil {
+			handle(strings.TrimSuffix(partial+line, "\n"))
+			partial = ""
+			continue
+		}
+		partial += line
+		if err != io.EOF {
+			fl.Log.Warning(fmt.Sprintf("Reading %s: %s", fl.Filename, err))
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(fl.Interval):
+		}
+
+		replaced, truncated := fl.changed(f)
+		if truncated {
+			_, _ = f.Seek(0, io.SeekStart)
+			r.Reset(f)
+			partial = ""
+		} else if replaced {
+			newFile, err := fl.open(false)
+			if err != nil {
+				// The new file may not have been created yet.
+				continue
+			}
+			// Read whatever was written to the old file before it was
+			// replaced, and then move on to the new one.
+			rest, _ := ioutil.ReadAll(r)
+			for _, line := range strings.SplitAfter(partial+string(rest), "\n") {
+				if strings.HasSuffix(line, "\n") {
+					handle(strings.TrimSuffix(line, "\n"))
+				}
+			}
+			_ = f.Close()
+			f = newFile
+			r.Reset(f)
+			partial = ""
+		}
+	}
+}
+
+// open opens the file, seeking to its end if atEnd is true.
+func (fl *Follower) open(atEnd bool) (*os.File, error) {
+	f, err := os.Open(fl.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if atEnd {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// changed reports whether the file at the Follower's path is no longer f, and
+// whether f has been truncated below the current read offset.
+func (fl *Follower) changed(f *os.File) (bool, bool) {
+	current, err := os.Stat(fl.Filename)
+	if err != nil {
+		return true, false
+	}
+	open, err := f.Stat()
+	if err != nil {
+		return true, false
+	}
+	if !os.SameFile(current, open) {
+		return true, false
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, false
+	}
+	return false, open.Size() < offset
+}
@@ -0,0 +1,89 @@
+package tail
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func appendLines(t *testing.T, filename string, lines ...string) {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	test.AssertNotError(t, err, "opening log file")
+	defer f.Close()
+	for _, line := range lines {
+		_, err = f.WriteString(line)
+		test.AssertNotError(t, err, "writing log file")
+	}
+}
+
+func TestFollow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	test.AssertNotError(t, err, "creating temp dir")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "boulder.log")
+	appendLines(t, filename, "old line\n")
+
+	lines := make(chan string, 10)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	tl := &Follower{Filename: filename, Interval: time.Millisecond, Log: blog.NewMock()}
+	go func() {
+		tl.Follow(func(line string) { lines <- line }, stop)
+		close(done)
+	}()
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a line")
+			return ""
+		}
+	}
+	// Give follow a chance to seek to the end before appending
+	time.Sleep(10 * time.Millisecond)
+
+	// Lines written before following started are skipped, and lines written
+	// in pieces are put back together
+	appendLines(t, filename, "first\n", "sec")
+	test.AssertEquals(t, next(), "first")
+	time.Sleep(10 * time.Millisecond)
+	appendLines(t, filename, "ond\n")
+	test.AssertEquals(t, next(), "second")
+
+	// A rotated file is followed from its start
+	appendLines(t, filename, "third\n")
+	err = os.Rename(filename, filename+".1")
+	test.AssertNotError(t, err, "rotating log file")
+	appendLines(t, filename, "fourth\n")
+	test.AssertEquals(t, next(), "third")
+	test.AssertEquals(t, next(), "fourth")
+
+	close(stop)
+	<-done
+}
+
+func TestFollowFromStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	test.AssertNotError(t, err, "creating temp dir")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "boulder.log")
+	appendLines(t, filename, "old line\n")
+
+	lines := make(chan string, 10)
+	stop := make(chan struct{})
+	defer close(stop)
+	tl := &Follower{Filename: filename, Interval: time.Millisecond, Log: blog.NewMock(), FromStart: true}
+	go tl.Follow(func(line string) { lines <- line }, stop)
+	select {
+	case line := <-lines:
+		test.AssertEquals(t, line, "old line")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a line")
+	}
+}
@@ -0,0 +1,255 @@
+package mail
+
+import (
+	"fmt"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+// metricsDomains are the destination domains that delivery metrics are
+// labelled with, which are those of the largest mailbox providers. Mail to any
+// other domain is counted as "other", so that the number of label values
+// stays small.
+var metricsDomains = map[string]bool{
+	"163.com":        true,
+	"aol.com":        true,
+	"gmail.com":      true,
+	"gmx.de":         true,
+	"googlemail.com": true,
+	"hotmail.com":    true,
+	"icloud.com":     true,
+	"live.com":       true,
+	"mail.ru":        true,
+	"me.com":         true,
+	"outlook.com":    true,
+	"protonmail.com": true,
+	"qq.com":         true,
+	"web.de":         true,
+	"yahoo.com":      true,
+	"yandex.ru":      true,
+}
+
+// maxReportDomains is the most domains listed individually in a delivery
+// report. The rest are summed into a single "other" line.
+const maxReportDomains = 20
+
+// Delivery results, as used for the result label of the delivery metrics.
+const (
+	resultSent     = "sent"
+	resultDeferred = "deferred"
+	resultRejected = "rejected"
+	resultError    = "error"
+)
+
+// deliveryResult classifies the outcome of submitting a message to the mail
+// server. SMTP replies with a 4xx code are temporary failures and those with a
+// 5xx code are permanent. These are the mail server's replies, so they only
+// show a provider deferring or rejecting our mail when the mail server
+// delivers it directly and relays the provider's reply.
+func deliveryResult(err error) string {
+	if err == nil {
+		return resultSent
+	}
+	if protoErr, ok := err.(*textproto.Error); ok {
+		if protoErr.Code >= 400 && protoErr.Code < 500 {
+			return resultDeferred
+		} else if protoErr.Code >= 500 {
+			return resultRejected
+		}
+	}
+	return resultError
+}
+
+// recipientDomain returns the lowercased domain of an email address.
+func recipientDomain(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at == -1 {
+		return "unknown"
+	}
+	return strings.ToLower(address[at+1:])
+}
+
+// DomainDeliveries summarises the mail submitted to the mail server for one
+// destination domain. A message counted as sent was accepted by the mail
+// server, which may still defer or bounce it when delivering it onward; those
+// later results aren't seen here.
+type DomainDeliveries struct {
+	Domain       string
+	Sent         int
+	Deferred     int
+	Rejected     int
+	Errors       int
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+}
+
+// Attempts returns the number of messages sent or that failed to send.
+func (d DomainDeliveries) Attempts() int {
+	return d.Sent + d.Deferred + d.Rejected + d.Errors
+}
+
+// FailureRate returns the fraction of attempts that failed.
+func (d DomainDeliveries) FailureRate() float64 {
+	if d.Attempts() == 0 {
+		return 0
+	}
+	return float64(d.Attempts()-d.Sent) / float64(d.Attempts())
+}
+
+func (d *DomainDeliveries) add(other DomainDeliveries) {
+	d.Sent += other.Sent
+	d.Deferred += other.Deferred
+	d.Rejected += other.Rejected
+	d.Errors += other.Errors
+	d.TotalLatency += other.TotalLatency
+	if other.MaxLatency > d.MaxLatency {
+		d.MaxLatency = other.MaxLatency
+	}
+}
+
+func (d DomainDeliveries) String() string {
+	var meanLatency time.Duration
+	if d.Attempts() > 0 {
+		meanLatency = d.TotalLatency / time.Duration(d.Attempts())
+	}
+	return fmt.Sprintf("domain=[%s] attempts=[%d] sent=[%d] deferred=[%d] rejected=[%d] errors=[%d] failureRate=[%.1f%%] meanLatency=[%s] maxLatency=[%s]",
+		d.Domain, d.Attempts(), d.Sent, d.Deferred, d.Rejected, d.Errors, 100*d.FailureRate(), meanLatency, d.MaxLatency)
+}
+
+// deliveryStats records the result and latency of submitting each message to
+// the mail server by destination domain, both as metrics and for a report at
+// the end of a run. Only the SMTP handoff to the mail server is measured, not
+// delivery to the recipient's mailbox provider: when the mail server is a
+// relay that queues mail, deferrals and bounces by the provider have to be
+// found in the relay's own logs.
+type deliveryStats struct {
+	latency    *prometheus.HistogramVec
+	deliveries *prometheus.CounterVec
+
+	mu      sync.Mutex
+	domains map[string]*DomainDeliveries
+}
+
+func newDeliveryStats(stats metrics.Scope) *deliveryStats {
+	latency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mail_delivery_latency",
+			Help:    "Time taken to submit a message to the mail server in seconds, by destination domain and result. Delivery onward from the mail server isn't measured",
+			Buckets: []float64{.1, .25, .5, 1, 2.5, 5, 7.5, 10, 15, 30, 45},
+		},
+		[]string{"domain", "result"})
+	stats.MustRegister(latency)
+	deliveries := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mail_deliveries",
+			Help: "A counter of messages submitted to the mail server, by destination domain and the mail server's result (sent, deferred, rejected or error)",
+		},
+		[]string{"domain", "result"})
+	stats.MustRegister(deliveries)
+	return &deliveryStats{
+		latency:    latency,
+		deliveries: deliveries,
+		domains:    make(map[string]*DomainDeliveries),
+	}
+}
+
+// record counts a message to the given recipients once for each of their
+// domains.
+func (s *deliveryStats) record(to []string, err error, latency time.Duration) {
+	result := deliveryResult(err)
+	seen := make(map[string]bool)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, address := range to {
+		domain := recipientDomain(address)
+		if seen[domain] {
+			continue
+		}
+		seen[domain] = true
+
+		label := domain
+		if !metricsDomains[domain] {
+			label = "other"
+		}
+		labels := prometheus.Labels{"domain": label, "result": result}
+		s.latency.With(labels).Observe(latency.Seconds())
+		s.deliveries.With(labels).Inc()
+
+		d := s.domains[domain]
+		if d == nil {
+			d = &DomainDeliveries{Domain: domain}
+			s.domains[domain] = d
+		}
+		switch result {
+		case resultSent:
+			d.Sent++
+		case resultDeferred:
+			d.Deferred++
+		case resultRejected:
+			d.Rejected++
+		default:
+			d.Errors++
+		}
+		d.TotalLatency += latency
+		if latency > d.MaxLatency {
+			d.MaxLatency = latency
+		}
+	}
+}
+
+// report returns the deliveries to each domain since the last report, busiest
+// first, and starts counting afresh.
+func (s *deliveryStats) report() []DomainDeliveries {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report := make([]DomainDeliveries, 0, len(s.domains))
+	for _, d := range s.domains {
+		report = append(report, *d)
+	}
+	s.domains = make(map[string]*DomainDeliveries)
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Attempts() != report[j].Attempts() {
+			return report[i].Attempts() > report[j].Attempts()
+		}
+		return report[i].Domain < report[j].Domain
+	})
+	return report
+}
+
+// DeliveryReport returns the deliveries to each destination domain since the
+// last report, busiest first, and starts counting afresh.
+func (m *MailerImpl) DeliveryReport() []DomainDeliveries {
+	return m.deliveryStats.report()
+}
+
+// LogDeliveryReport logs a summary of the submissions to the mail server for
+// each destination domain since the last report, and starts counting afresh.
+// As with the metrics, only the mail server's replies are counted.
+func (m *MailerImpl) LogDeliveryReport() {
+	logDeliveryReport(m.log, m.DeliveryReport())
+}
+
+func logDeliveryReport(log blog.Logger, report []DomainDeliveries) {
+	total := DomainDeliveries{Domain: "all"}
+	other := DomainDeliveries{Domain: "other"}
+	for i, d := range report {
+		total.add(d)
+		if i < maxReportDomains {
+			log.Info(fmt.Sprintf("Delivery report: %s", d))
+		} else {
+			other.add(d)
+		}
+	}
+	if other.Attempts() > 0 {
+		log.Info(fmt.Sprintf("Delivery report: %s", other))
+	}
+	log.Info(fmt.Sprintf("Delivery report: %s", total))
+}
@@ -45,6 +45,7 @@ func (s realSource) generate() *big.Int {
 // Mailer provides the interface for a mailer
 type Mailer interface {
 	SendMail([]string, string, string) error
+	SendMailBCC(string, []string, string, string) error
 	Connect() error
 	Close() error
 }
@@ -61,6 +62,9 @@ type MailerImpl struct {
 	stats         metrics.Scope
 	reconnectBase time.Duration
 	reconnectMax  time.Duration
+	// deliveryStats records the result of submitting each message to the mail
+	// server by destination domain.
+	deliveryStats *deliveryStats
 }
 
 type dialer interface {
@@ -118,6 +122,7 @@ func New(
 	stats metrics.Scope,
 	reconnectBase time.Duration,
 	reconnectMax time.Duration) *MailerImpl {
+	stats = stats.NewScope("Mailer")
 	return &MailerImpl{
 		dialer: &dialerImpl{
 			username: username,
@@ -130,9 +135,10 @@ func New(
 		from:          from,
 		clk:           clock.Default(),
 		csprgSource:   realSource{},
-		stats:         stats.NewScope("Mailer"),
+		stats:         stats,
 		reconnectBase: reconnectBase,
 		reconnectMax:  reconnectMax,
+		deliveryStats: newDeliveryStats(stats),
 	}
 }
 
@@ -141,11 +147,13 @@ func New(
 func NewDryRun(from mail.Address, logger blog.Logger) *MailerImpl {
 	stats := metrics.NewNoopScope()
 	return &MailerImpl{
-		dialer:      dryRunClient{logger},
-		from:        from,
-		clk:         clock.Default(),
-		csprgSource: realSource{},
-		stats:       stats,
+		log:           logger,
+		dialer:        dryRunClient{logger},
+		from:          from,
+		clk:           clock.Default(),
+		csprgSource:   realSource{},
+		stats:         stats,
+		deliveryStats: newDeliveryStats(stats),
 	}
 }
 
@@ -243,7 +251,9 @@ func (di *dialerImpl) Dial() (smtpClient, error) {
 	return client, nil
 }
 
-func (m *MailerImpl) sendOne(to []string, subject, msg string) error {
+// sendOne sends a single message with the given To header to each of
+// recipients.
+func (m *MailerImpl) sendOne(to, recipients []string, subject, msg string) error {
 	if m.client == nil {
 		return errors.New("call Connect before SendMail")
 	}
@@ -254,7 +264,7 @@ func (m *MailerImpl) sendOne(to []string, subject, msg string) error {
 	if err = m.client.Mail(m.from.String()); err != nil {
 		return err
 	}
-	for _, t := range to {
+	for _, t := range recipients {
 		if err = m.client.Rcpt(t); err != nil {
 			return err
 		}
@@ -277,12 +287,30 @@ func (m *MailerImpl) sendOne(to []string, subject, msg string) error {
 // SendMail sends an email to the provided list of recipients. The email body
 // is simple text.
 func (m *MailerImpl) SendMail(to []string, subject, msg string) error {
+	return m.send(to, to, subject, msg)
+}
+
+// SendMailBCC sends a single email to each of the bcc recipients, who are
+// left out of the message headers. The To header is set to the to address,
+// which isn't sent the email unless it is also in bcc. This suits
+// announcements to many recipients, which would otherwise all see each
+// other's addresses.
+func (m *MailerImpl) SendMailBCC(to string, bcc []string, subject, msg string) error {
+	if len(bcc) == 0 {
+		return errors.New("no BCC recipients")
+	}
+	return m.send([]string{to}, bcc, subject, msg)
+}
+
+func (m *MailerImpl) send(to, recipients []string, subject, msg string) error {
 	m.stats.Inc("SendMail.Attempts", 1)
 
 	for {
-		err := m.sendOne(to, subject, msg)
+		start := m.clk.Now()
+		err := m.sendOne(to, recipients, subject, msg)
 		if err == nil {
 			// If the error is nil, we sent the mail without issue. nice!
+			m.deliveryStats.record(recipients, nil, m.clk.Since(start))
 			break
 		} else if err == io.EOF {
 			// If the error is an EOF, we should try to reconnect on a backoff
@@ -316,6 +344,7 @@ func (m *MailerImpl) SendMail(to []string, subject, msg string) error {
 				// If it wasn't an EOF error or a SMTP 421 it is unexpected and we
 				// return from SendMail() with an error
 				m.stats.Inc("SendMail.Errors", 1)
+				m.deliveryStats.record(recipients, err, m.clk.Since(start))
 				return err
 			}
 		}
@@ -2,19 +2,23 @@ package mail
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/big"
 	"net"
 	"net/mail"
+	"net/textproto"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
 
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
@@ -283,3 +287,109 @@ func TestReconnectSMTP421(t *testing.T) {
 		t.Errorf("Expected SendMail() to not fail. Got err: %s", err)
 	}
 }
+
+// rcptErrClient is a smtpClient that accepts every message except for those
+// to the recipients in errs, whose RCPT commands fail with the given error.
+type rcptErrClient struct {
+	errs map[string]error
+}
+
+func (c rcptErrClient) Mail(string) error { return nil }
+
+func (c rcptErrClient) Rcpt(to string) error { return c.errs[to] }
+
+func (c rcptErrClient) Data() (io.WriteCloser, error) {
+	return dryRunClient{blog.NewMock()}, nil
+}
+
+func (c rcptErrClient) Close() error { return nil }
+
+func TestDeliveryReport(t *testing.T) {
+	fromAddress, _ := mail.ParseAddress("send@email.com")
+	log := blog.NewMock()
+	m := New("", "", "", "", nil, *fromAddress, log, metrics.NewNoopScope(), 0, 0)
+	m.client = rcptErrClient{errs: map[string]error{
+		"deferred@gmail.com": &textproto.Error{Code: 451, Msg: "try again later"},
+		"rejected@gmail.com": &textproto.Error{Code: 550, Msg: "no such user"},
+		"broken@example.com": errors.New("broken pipe"),
+	}}
+
+	for _, to := range [][]string{
+		{"a@gmail.com"},
+		{"deferred@gmail.com"},
+		{"rejected@gmail.com"},
+		// A message to several addresses in a domain counts once
+		{"a@Example.com", "b@example.com"},
+		{"broken@example.com"},
+		{"c@example.net"},
+	} {
+		_ = m.SendMail(to, "subject", "body")
+	}
+	test.AssertEquals(t, test.CountCounter(m.deliveryStats.deliveries.With(prometheus.Labels{"domain": "gmail.com", "result": "deferred"})), 1)
+	test.AssertEquals(t, test.CountCounter(m.deliveryStats.deliveries.With(prometheus.Labels{"domain": "other", "result": "sent"})), 2)
+
+	report := m.DeliveryReport()
+	test.AssertEquals(t, len(report), 3)
+	test.AssertEquals(t, report[0].Domain, "gmail.com")
+	test.AssertEquals(t, report[0].Attempts(), 3)
+	test.AssertEquals(t, report[0].Sent, 1)
+	test.AssertEquals(t, report[0].Deferred, 1)
+	test.AssertEquals(t, report[0].Rejected, 1)
+	test.AssertEquals(t, report[1].Domain, "example.com")
+	test.AssertEquals(t, report[1].Errors, 1)
+	test.AssertEquals(t, report[1].FailureRate(), 0.5)
+	test.AssertEquals(t, report[2].Domain, "example.net")
+
+	// Each report starts afresh
+	test.AssertEquals(t, len(m.DeliveryReport()), 0)
+	_ = m.SendMail([]string{"deferred@gmail.com"}, "subject", "body")
+	m.LogDeliveryReport()
+	test.AssertEquals(t, len(log.GetAllMatching(`Delivery report: domain=\[gmail.com\] attempts=\[1\] sent=\[0\] deferred=\[1\] rejected=\[0\] errors=\[0\] failureRate=\[100.0%\]`)), 1)
+	test.AssertEquals(t, len(log.GetAllMatching(`Delivery report: domain=\[all\] attempts=\[1\]`)), 1)
+}
+
+// recordingClient is a smtpClient that accepts every message and records
+// its recipients and contents.
+type recordingClient struct {
+	rcpts []string
+	data  *bytes.Buffer
+}
+
+func (c *recordingClient) Mail(string) error { return nil }
+
+func (c *recordingClient) Rcpt(to string) error {
+	c.rcpts = append(c.rcpts, to)
+	return nil
+}
+
+func (c *recordingClient) Data() (io.WriteCloser, error) {
+	c.data = new(bytes.Buffer)
+	return nopCloser{c.data}, nil
+}
+
+func (c *recordingClient) Close() error { return nil }
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestSendMailBCC(t *testing.T) {
+	fromAddress, _ := mail.ParseAddress("send@email.com")
+	m := New("", "", "", "", nil, *fromAddress, blog.NewMock(), metrics.NewNoopScope(), 0, 0)
+	m.csprgSource = fakeSource{}
+	client := &recordingClient{}
+	m.client = client
+
+	err := m.SendMailBCC("announce@email.com", []string{"a@gmail.com", "b@example.com"}, "subject", "body")
+	test.AssertNotError(t, err, "SendMailBCC failed")
+	test.AssertDeepEquals(t, client.rcpts, []string{"a@gmail.com", "b@example.com"})
+	message := client.data.String()
+	test.Assert(t, strings.HasPrefix(message, "To: \"announce@email.com\"\r\n"), "To header isn't the given address")
+	test.Assert(t, !strings.Contains(message, "a@gmail.com"), "BCC recipient is in the message")
+	test.AssertEquals(t, len(m.DeliveryReport()), 2)
+
+	err = m.SendMailBCC("announce@email.com", nil, "subject", "body")
+	test.AssertError(t, err, "SendMailBCC without recipients didn't fail")
+}
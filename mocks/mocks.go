@@ -308,8 +308,20 @@ func (sa *StorageAuthority) GetCertificateStatus(_ context.Context, serial strin
 	}
 }
 
+// GetCertificateStatuses is a mock
+func (sa *StorageAuthority) GetCertificateStatuses(ctx context.Context, serials []string) ([]core.CertificateStatus, error) {
+	var statuses []core.CertificateStatus
+	for _, serial := range serials {
+		if status, err := sa.GetCertificateStatus(ctx, serial); err == nil {
+			status.Serial = serial
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
 // AddCertificate is a mock
-func (sa *StorageAuthority) AddCertificate(_ context.Context, certDER []byte, regID int64, _ []byte) (digest string, err error) {
+func (sa *StorageAuthority) AddCertificate(_ context.Context, certDER []byte, regID int64, _ []byte, _ *time.Time) (digest string, err error) {
 	return
 }
 
@@ -348,6 +360,21 @@ func (sa *StorageAuthority) GetSCTReceipt(_ context.Context, serial string, logI
 	return
 }
 
+// GetSCTReceipts is a mock, it returns one SCT receipt for serial b2 and none
+// for any other serial
+func (sa *StorageAuthority) GetSCTReceipts(_ context.Context, serial string) ([]core.SignedCertificateTimestamp, error) {
+	if serial == "0000000000000000000000000000000000b2" {
+		return []core.SignedCertificateTimestamp{{
+			SCTVersion:        0,
+			LogID:             "3Zk0/KXnJIDJVmh9gTSZCEmySfe1adjHvKs/XMHzbmQ=",
+			Timestamp:         1500000000000,
+			Signature:         []byte{1, 2, 3},
+			CertificateSerial: serial,
+		}}, nil
+	}
+	return nil, nil
+}
+
 // AddSCTReceipt is a mock
 func (sa *StorageAuthority) AddSCTReceipt(_ context.Context, sct core.SignedCertificateTimestamp) (err error) {
 	if sct.Signature == nil {
@@ -366,6 +393,11 @@ func (sa *StorageAuthority) FQDNSetExists(_ context.Context, names []string) (bo
 	return false, nil
 }
 
+// FQDNSetIssuedForAccount is a mock, it reports no previous issuance
+func (sa *StorageAuthority) FQDNSetIssuedForAccount(_ context.Context, _ *sapb.FQDNSetIssuedForAccountRequest) (*sapb.Exists, error) {
+	return &sapb.Exists{Exists: new(bool)}, nil
+}
+
 func (sa *StorageAuthority) PreviousCertificateExists(
 	_ context.Context,
 	_ *sapb.PreviousCertificateExistsRequest,
@@ -376,6 +408,54 @@ func (sa *StorageAuthority) PreviousCertificateExists(
 	}, nil
 }
 
+// KeyBlocked is a mock, it reports no keys as blocked
+func (sa *StorageAuthority) KeyBlocked(_ context.Context, _ *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+	f := false
+	return &sapb.Exists{Exists: &f}, nil
+}
+
+// GetSerialsByKey is a mock, it returns no serials
+func (sa *StorageAuthority) GetSerialsByKey(_ context.Context, _ *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error) {
+	return &sapb.Serials{}, nil
+}
+
+// GetRateLimitOverrides is a mock, it returns no overrides
+func (sa *StorageAuthority) GetRateLimitOverrides(_ context.Context, _ *corepb.Empty) (*sapb.RateLimitOverrides, error) {
+	return &sapb.RateLimitOverrides{}, nil
+}
+
+// AddBlockedKey is a mock
+func (sa *StorageAuthority) AddBlockedKey(_ context.Context, _ *sapb.AddBlockedKeyRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+// AddVerifiedContact is a mock
+func (sa *StorageAuthority) AddVerifiedContact(_ context.Context, _ *sapb.AddVerifiedContactRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+// GetAccountStats is a mock, it returns zero for every counter
+func (sa *StorageAuthority) GetAccountStats(_ context.Context, _ *sapb.RegistrationID) (*sapb.AccountStats, error) {
+	return &sapb.AccountStats{
+		CertificatesLast7Days:      new(int64),
+		CertificatesLast30Days:     new(int64),
+		CertificatesLast90Days:     new(int64),
+		FailedValidationsLast7Days: new(int64),
+		PendingAuthorizations:      new(int64),
+	}, nil
+}
+
+// AddCTSubmission is a mock
+func (sa *StorageAuthority) AddCTSubmission(_ context.Context, _ *sapb.AddCTSubmissionRequest) (*corepb.Empty, error) {
+	return &corepb.Empty{}, nil
+}
+
+// PolicyOverridden is a mock, it reports no domains as overridden
+func (sa *StorageAuthority) PolicyOverridden(_ context.Context, _ *sapb.PolicyOverriddenRequest) (*sapb.Exists, error) {
+	f := false
+	return &sapb.Exists{Exists: &f}, nil
+}
+
 func (sa *StorageAuthority) GetPendingAuthorization(ctx context.Context, req *sapb.GetPendingAuthorizationRequest) (*core.Authorization, error) {
 	return nil, fmt.Errorf("GetPendingAuthorization not implemented")
 }
@@ -464,6 +544,16 @@ func (sa *StorageAuthority) NewOrder(_ context.Context, order *corepb.Order) (*c
 	return order, nil
 }
 
+// NewOrderAndAuthzs is a mock, it gives each new authorization a
+// sequential ID
+func (sa *StorageAuthority) NewOrderAndAuthzs(_ context.Context, req *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error) {
+	order := req.NewOrder
+	for i := range req.NewAuthzs {
+		order.Authorizations = append(order.Authorizations, fmt.Sprintf("new-authz-%d", i))
+	}
+	return order, nil
+}
+
 // SetOrderProcessing is a mock
 func (sa *StorageAuthority) SetOrderProcessing(_ context.Context, order *corepb.Order) error {
 	return nil
@@ -543,6 +633,11 @@ func (sa *StorageAuthority) GetAuthorizations(ctx context.Context, req *sapb.Get
 	return &sapb.Authorizations{}, nil
 }
 
+// CountFailedValidations is a mock, it returns a count of zero
+func (sa *StorageAuthority) CountFailedValidations(_ context.Context, _ *sapb.CountFailedValidationsRequest) (*sapb.Count, error) {
+	return &sapb.Count{Count: new(int64)}, nil
+}
+
 // CountInvalidAuthorizations is a mock
 func (sa *StorageAuthority) CountInvalidAuthorizations(ctx context.Context, req *sapb.CountInvalidAuthorizationsRequest) (count *sapb.Count, err error) {
 	return &sapb.Count{}, nil
@@ -602,6 +697,11 @@ func (m *Mailer) SendMail(to []string, subject, msg string) error {
 	return nil
 }
 
+// SendMailBCC is a mock
+func (m *Mailer) SendMailBCC(_ string, bcc []string, subject, msg string) error {
+	return m.SendMail(bcc, subject, msg)
+}
+
 // Close is a mock
 func (m *Mailer) Close() error {
 	return nil
@@ -11,6 +11,10 @@
 // The MaxUsed value determines how long a generated nonce can be used before it
 // is forgotten. To calculate that period, divide the MaxUsed value by average
 // redemption rate (valid POSTs per second).
+// Nonces can be pre-generated into a pool by a background goroutine, so that
+// handing one out doesn't wait on the cipher. The pool is first in, first out
+// and so is handed out in counter order, which means a pooled nonce is always
+// handed out before any later nonce can be redeemed and retire it.
 package nonce
 
 import (
@@ -24,6 +28,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/letsencrypt/boulder/metrics"
 )
 
@@ -44,6 +50,10 @@ type NonceService struct {
 	gcm      cipher.AEAD
 	maxUsed  int
 	stats    metrics.Scope
+
+	// pool holds pre-generated nonces, if StartPool has been called.
+	pool       chan string
+	poolMisses prometheus.Counter
 }
 
 type int64Heap []int64
@@ -141,8 +151,49 @@ func (ns *NonceService) decrypt(nonce string) (int64, error) {
 	return ctr.Int64(), nil
 }
 
-// Nonce provides a new Nonce.
-func (ns *NonceService) Nonce() (string, error) {
+// StartPool starts a goroutine that keeps up to size nonces pre-generated,
+// taking the next counter value for each and refilling the pool as nonces are
+// handed out. When the pool is empty Nonce generates a nonce itself. It must
+// only be called once, before the service is in use.
+func (ns *NonceService) StartPool(size int) {
+	if size <= 0 {
+		return
+	}
+	ns.pool = make(chan string, size)
+	depth := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "nonce_pool_depth",
+		Help: "Number of pre-generated nonces ready to be handed out",
+	}, func() float64 { return float64(len(ns.pool)) })
+	ns.stats.MustRegister(depth)
+	capacity := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "nonce_pool_size",
+		Help: "Maximum number of pre-generated nonces",
+	})
+	ns.stats.MustRegister(capacity)
+	capacity.Set(float64(size))
+	ns.poolMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "nonce_pool_misses",
+		Help: "Number of nonces generated on demand because the pool was empty",
+	})
+	ns.stats.MustRegister(ns.poolMisses)
+	go ns.refill()
+}
+
+// refill generates nonces into the pool, blocking whenever it is full.
+func (ns *NonceService) refill() {
+	for {
+		nonce, err := ns.generate()
+		if err != nil {
+			// Only reading random bytes can fail, so try again shortly
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		ns.pool <- nonce
+	}
+}
+
+// generate encrypts the next counter value.
+func (ns *NonceService) generate() (string, error) {
 	ns.mu.Lock()
 	ns.latest++
 	latest := ns.latest
@@ -151,6 +202,19 @@ func (ns *NonceService) Nonce() (string, error) {
 	return ns.encrypt(latest)
 }
 
+// Nonce provides a new Nonce, from the pool if there is one ready.
+func (ns *NonceService) Nonce() (string, error) {
+	if ns.pool != nil {
+		select {
+		case nonce := <-ns.pool:
+			return nonce, nil
+		default:
+			ns.poolMisses.Inc()
+		}
+	}
+	return ns.generate()
+}
+
 // Valid determines whether the provided Nonce string is valid, returning
 // true if so.
 func (ns *NonceService) Valid(nonce string) bool {
@@ -3,6 +3,9 @@ package nonce
 import (
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/test"
@@ -118,3 +121,46 @@ func BenchmarkNonces(b *testing.B) {
 		}
 	})
 }
+
+func TestPool(t *testing.T) {
+	ns, err := NewNonceService(metrics.NewNoopScope())
+	test.AssertNotError(t, err, "Could not create nonce service")
+	ns.StartPool(10)
+
+	// Wait for the pool to fill, at which point only the nonce waiting to be
+	// added is generated
+	for len(ns.pool) < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	ns.mu.Lock()
+	test.Assert(t, ns.latest <= 11, "Generated more nonces than fit in the pool")
+	ns.mu.Unlock()
+
+	// Pooled nonces are handed out in counter order, and are valid
+	var nonces []string
+	for i := int64(1); i <= 10; i++ {
+		n, err := ns.Nonce()
+		test.AssertNotError(t, err, "Could not create nonce")
+		c, err := ns.decrypt(n)
+		test.AssertNotError(t, err, "Could not decrypt nonce")
+		test.AssertEquals(t, c, i)
+		nonces = append(nonces, n)
+	}
+	for _, n := range nonces {
+		test.Assert(t, ns.Valid(n), "Did not recognize pooled nonce")
+	}
+	test.AssertEquals(t, test.CountCounter(ns.poolMisses), 0)
+}
+
+func TestPoolEmpty(t *testing.T) {
+	ns, err := NewNonceService(metrics.NewNoopScope())
+	test.AssertNotError(t, err, "Could not create nonce service")
+	// A pool that is never refilled
+	ns.pool = make(chan string, 1)
+	ns.poolMisses = prometheus.NewCounter(prometheus.CounterOpts{Name: "misses"})
+
+	n, err := ns.Nonce()
+	test.AssertNotError(t, err, "Could not create nonce")
+	test.Assert(t, ns.Valid(n), "Did not recognize nonce generated on demand")
+	test.AssertEquals(t, test.CountCounter(ns.poolMisses), 1)
+}
@@ -11,7 +11,9 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/weppos/publicsuffix-go/publicsuffix"
+	"golang.org/x/net/context"
 	"golang.org/x/net/idna"
 	"golang.org/x/text/unicode/norm"
 
@@ -19,9 +21,17 @@ import (
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/psl"
 	"github.com/letsencrypt/boulder/reloader"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
 
+// OverrideCheckFunc is used to determine whether an administrator has allowed
+// issuance for a domain that the hostname policy would otherwise forbid. It
+// matches the signature of the SA's PolicyOverridden method.
+type OverrideCheckFunc func(ctx context.Context, req *sapb.PolicyOverriddenRequest) (*sapb.Exists, error)
+
 // AuthorityImpl enforces CA policy decisions.
 type AuthorityImpl struct {
 	log blog.Logger
@@ -35,16 +45,48 @@ type AuthorityImpl struct {
 	enabledChallengesWhitelist map[string]map[int64]bool
 	pseudoRNG                  *rand.Rand
 	rngMu                      sync.Mutex
+
+	// reloads counts the loads of the hostname policy and challenges
+	// whitelist files, by file and result.
+	reloads *prometheus.CounterVec
+	// hostnamePolicyEntries is the number of entries in each list of the
+	// currently loaded hostname policy.
+	hostnamePolicyEntries *prometheus.GaugeVec
+
+	overrideCheck OverrideCheckFunc
 }
 
+// Values of the "file" label of the policy_reloads metric.
+const (
+	hostnamePolicyFile      = "hostname_policy"
+	challengesWhitelistFile = "challenges_whitelist"
+)
+
 // New constructs a Policy Authority.
-func New(challengeTypes map[string]bool) (*AuthorityImpl, error) {
+func New(challengeTypes map[string]bool, scope metrics.Scope) (*AuthorityImpl, error) {
+	reloads := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "policy_reloads",
+			Help: "Number of policy file loads, by file and result",
+		},
+		[]string{"file", "result"})
+	scope.MustRegister(reloads)
+
+	hostnamePolicyEntries := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hostname_policy_entries",
+			Help: "Number of entries in each list of the loaded hostname policy",
+		},
+		[]string{"list"})
+	scope.MustRegister(hostnamePolicyEntries)
 
 	pa := AuthorityImpl{
 		log:               blog.Get(),
 		enabledChallenges: challengeTypes,
 		// We don't need real randomness for this.
-		pseudoRNG: rand.New(rand.NewSource(99)),
+		pseudoRNG:             rand.New(rand.NewSource(99)),
+		reloads:               reloads,
+		hostnamePolicyEntries: hostnamePolicyEntries,
 	}
 
 	return &pa, nil
@@ -63,6 +105,7 @@ func (pa *AuthorityImpl) SetHostnamePolicyFile(f string) error {
 }
 
 func (pa *AuthorityImpl) hostnamePolicyLoadError(err error) {
+	pa.reloads.With(prometheus.Labels{"file": hostnamePolicyFile, "result": "failure"}).Inc()
 	pa.log.AuditErr(fmt.Sprintf("error loading hostname policy: %s", err))
 }
 
@@ -109,9 +152,20 @@ func (pa *AuthorityImpl) loadHostnamePolicy(b []byte) error {
 	pa.exactBlacklist = exactNameMap
 	pa.wildcardExactBlacklist = wildcardNameMap
 	pa.blacklistMu.Unlock()
+	pa.hostnamePolicyEntries.With(prometheus.Labels{"list": "blacklist"}).Set(float64(len(nameMap)))
+	pa.hostnamePolicyEntries.With(prometheus.Labels{"list": "exactBlacklist"}).Set(float64(len(exactNameMap)))
+	pa.hostnamePolicyEntries.With(prometheus.Labels{"list": "wildcardExactBlacklist"}).Set(float64(len(wildcardNameMap)))
+	pa.reloads.With(prometheus.Labels{"file": hostnamePolicyFile, "result": "success"}).Inc()
 	return nil
 }
 
+// SetOverrideCheck sets the function used to check whether a domain forbidden
+// by the hostname policy has been administratively allowed. If it is never
+// set, or set to nil, overrides are not checked.
+func (pa *AuthorityImpl) SetOverrideCheck(f OverrideCheckFunc) {
+	pa.overrideCheck = f
+}
+
 // SetChallengesWhitelistFile will load the given whitelist file, returning error if it
 // fails. It will also start a reloader in case the file changes.
 func (pa *AuthorityImpl) SetChallengesWhitelistFile(f string) error {
@@ -120,6 +174,7 @@ func (pa *AuthorityImpl) SetChallengesWhitelistFile(f string) error {
 }
 
 func (pa *AuthorityImpl) challengesWhitelistLoadError(err error) {
+	pa.reloads.With(prometheus.Labels{"file": challengesWhitelistFile, "result": "failure"}).Inc()
 	pa.log.AuditErr(fmt.Sprintf("error loading challenges whitelist: %s", err))
 }
 
@@ -145,6 +200,7 @@ func (pa *AuthorityImpl) loadChallengesWhitelist(b []byte) error {
 	pa.blacklistMu.Lock()
 	pa.enabledChallengesWhitelist = chalWl
 	pa.blacklistMu.Unlock()
+	pa.reloads.With(prometheus.Labels{"file": challengesWhitelistFile, "result": "success"}).Inc()
 
 	return nil
 }
@@ -214,11 +270,14 @@ var (
 //  * MUST end in a public suffix
 //  * MUST have at least one label in addition to the public suffix
 //  * MUST NOT be a label-wise suffix match for a name on the black list,
-//    where comparison is case-independent (normalized to lower case)
+//    where comparison is case-independent (normalized to lower case), unless
+//    an administrator has overridden the policy for the name or one of its
+//    parent domains, for the given registration or for all registrations
 //
 // If WillingToIssue returns an error, it will be of type MalformedRequestError
-// or RejectedIdentifierError
-func (pa *AuthorityImpl) WillingToIssue(id core.AcmeIdentifier) error {
+// or RejectedIdentifierError, or InternalServerError if overrides couldn't
+// be checked
+func (pa *AuthorityImpl) WillingToIssue(ctx context.Context, id core.AcmeIdentifier, regID int64) error {
 	if id.Type != core.IdentifierDNS {
 		return errInvalidIdentifier
 	}
@@ -301,7 +360,7 @@ func (pa *AuthorityImpl) WillingToIssue(id core.AcmeIdentifier) error {
 
 	// Require no match against blacklist
 	if err := pa.checkHostLists(domain); err != nil {
-		return err
+		return pa.checkOverride(ctx, domain, regID, err)
 	}
 
 	return nil
@@ -320,7 +379,7 @@ func (pa *AuthorityImpl) WillingToIssue(id core.AcmeIdentifier) error {
 //
 // If all of the above is true then the base domain (e.g. without the *.) is run
 // through WillingToIssue to catch other illegal things (blocked hosts, etc).
-func (pa *AuthorityImpl) WillingToIssueWildcard(ident core.AcmeIdentifier) error {
+func (pa *AuthorityImpl) WillingToIssueWildcard(ctx context.Context, ident core.AcmeIdentifier, regID int64) error {
 	// We're only willing to process DNS identifiers
 	if ident.Type != core.IdentifierDNS {
 		return errInvalidIdentifier
@@ -355,7 +414,9 @@ func (pa *AuthorityImpl) WillingToIssueWildcard(ident core.AcmeIdentifier) error
 		}
 		// The base domain can't be in the wildcard exact blacklist
 		if err := pa.checkWildcardHostList(baseDomain); err != nil {
-			return err
+			if err := pa.checkOverride(ctx, baseDomain, regID, err); err != nil {
+				return err
+			}
 		}
 		// Check that the PA is willing to issue for the base domain
 		// Since the base domain without the "*." may trip the exact hostname policy
@@ -366,13 +427,33 @@ func (pa *AuthorityImpl) WillingToIssueWildcard(ident core.AcmeIdentifier) error
 		// NOTE(@cpu): This is pretty hackish! Boulder issue #3323[0] describes
 		// a better follow-up that we should land to replace this code.
 		// [0] https://github.com/letsencrypt/boulder/issues/3323
-		return pa.WillingToIssue(core.AcmeIdentifier{
+		return pa.WillingToIssue(ctx, core.AcmeIdentifier{
 			Type:  core.IdentifierDNS,
 			Value: "x." + baseDomain,
-		})
+		}, regID)
 	}
 
-	return pa.WillingToIssue(ident)
+	return pa.WillingToIssue(ctx, ident, regID)
+}
+
+// checkOverride is called when domain is forbidden by the hostname policy with
+// policyErr. It returns nil if an administrator has overridden the policy for
+// domain, and policyErr otherwise.
+func (pa *AuthorityImpl) checkOverride(ctx context.Context, domain string, regID int64, policyErr error) error {
+	if pa.overrideCheck == nil || policyErr != errBlacklisted {
+		return policyErr
+	}
+	exists, err := pa.overrideCheck(ctx, &sapb.PolicyOverriddenRequest{
+		Domain:         &domain,
+		RegistrationID: &regID,
+	})
+	if err != nil {
+		return berrors.InternalServerError("checking policy overrides: %s", err)
+	}
+	if exists.GetExists() {
+		return nil
+	}
+	return policyErr
 }
 
 // checkWildcardHostList checks the wildcardExactBlacklist for a given domain.
@@ -481,7 +562,7 @@ func extractDomainIANASuffix(name string) (string, error) {
 		return "", fmt.Errorf("Blank name argument passed to ExtractDomainIANASuffix")
 	}
 
-	rule := publicsuffix.DefaultList.Find(name, &publicsuffix.FindOptions{IgnorePrivate: true, DefaultRule: nil})
+	rule := psl.List().Find(name, &publicsuffix.FindOptions{IgnorePrivate: true, DefaultRule: nil})
 	if rule == nil {
 		return "", fmt.Errorf("Domain %s has no IANA TLD", name)
 	}
@@ -2,18 +2,28 @@ package policy
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+
 	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/test"
 )
 
 var log = blog.UseMock()
 
+var ctx = context.Background()
+
 var enabledChallenges = map[string]bool{
 	core.ChallengeTypeHTTP01:   true,
 	core.ChallengeTypeTLSSNI01: true,
@@ -26,7 +36,7 @@ const (
 )
 
 func paImpl(t *testing.T) *AuthorityImpl {
-	pa, err := New(enabledChallenges)
+	pa, err := New(enabledChallenges, metrics.NewNoopScope())
 	if err != nil {
 		t.Fatalf("Couldn't create policy implementation: %s", err)
 	}
@@ -153,7 +163,7 @@ func TestWillingToIssue(t *testing.T) {
 
 	// Test for invalid identifier type
 	identifier := core.AcmeIdentifier{Type: "ip", Value: "example.com"}
-	err = pa.WillingToIssue(identifier)
+	err = pa.WillingToIssue(ctx, identifier, testRegID)
 	if err != errInvalidIdentifier {
 		t.Error("Identifier was not correctly forbidden: ", identifier)
 	}
@@ -161,27 +171,27 @@ func TestWillingToIssue(t *testing.T) {
 	// Test syntax errors
 	for _, tc := range testCases {
 		identifier := core.AcmeIdentifier{Type: core.IdentifierDNS, Value: tc.domain}
-		err := pa.WillingToIssue(identifier)
+		err := pa.WillingToIssue(ctx, identifier, testRegID)
 		if err != tc.err {
 			t.Errorf("WillingToIssue(%q) = %q, expected %q", tc.domain, err, tc.err)
 		}
 	}
 
 	// Invalid encoding
-	err = pa.WillingToIssue(core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "www.xn--m.com"})
+	err = pa.WillingToIssue(ctx, core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "www.xn--m.com"}, testRegID)
 	test.AssertError(t, err, "WillingToIssue didn't fail on a malformed IDN")
 	// Valid encoding
-	err = pa.WillingToIssue(core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "www.xn--mnich-kva.com"})
+	err = pa.WillingToIssue(ctx, core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "www.xn--mnich-kva.com"}, testRegID)
 	test.AssertNotError(t, err, "WillingToIssue failed on a properly formed IDN")
 	// IDN TLD
-	err = pa.WillingToIssue(core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "xn--example--3bhk5a.xn--p1ai"})
+	err = pa.WillingToIssue(ctx, core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "xn--example--3bhk5a.xn--p1ai"}, testRegID)
 	test.AssertNotError(t, err, "WillingToIssue failed on a properly formed domain with IDN TLD")
 	features.Reset()
 
 	// Test domains that are equal to public suffixes
 	for _, domain := range shouldBeTLDError {
 		identifier := core.AcmeIdentifier{Type: core.IdentifierDNS, Value: domain}
-		err := pa.WillingToIssue(identifier)
+		err := pa.WillingToIssue(ctx, identifier, testRegID)
 		if err != errICANNTLD {
 			t.Error("Identifier was not correctly forbidden: ", identifier, err)
 		}
@@ -190,7 +200,7 @@ func TestWillingToIssue(t *testing.T) {
 	// Test blacklisting
 	for _, domain := range shouldBeBlacklisted {
 		identifier := core.AcmeIdentifier{Type: core.IdentifierDNS, Value: domain}
-		err := pa.WillingToIssue(identifier)
+		err := pa.WillingToIssue(ctx, identifier, testRegID)
 		if err != errBlacklisted {
 			t.Error("Identifier was not correctly forbidden: ", identifier, err)
 		}
@@ -199,7 +209,7 @@ func TestWillingToIssue(t *testing.T) {
 	// Test acceptance of good names
 	for _, domain := range shouldBeAccepted {
 		identifier := core.AcmeIdentifier{Type: core.IdentifierDNS, Value: domain}
-		if err := pa.WillingToIssue(identifier); err != nil {
+		if err := pa.WillingToIssue(ctx, identifier, testRegID); err != nil {
 			t.Error("Identifier was incorrectly forbidden: ", identifier, err)
 		}
 	}
@@ -298,7 +308,7 @@ func TestWillingToIssueWildcard(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			result := pa.WillingToIssueWildcard(tc.Ident)
+			result := pa.WillingToIssueWildcard(ctx, tc.Ident, testRegID)
 			test.AssertEquals(t, result, tc.ExpectedErr)
 		})
 	}
@@ -370,7 +380,7 @@ func TestChallengesForWildcard(t *testing.T) {
 	}
 
 	mustConstructPA := func(t *testing.T, enabledChallenges map[string]bool) *AuthorityImpl {
-		pa, err := New(enabledChallenges)
+		pa, err := New(enabledChallenges, metrics.NewNoopScope())
 		test.AssertNotError(t, err, "Couldn't create policy implementation")
 		return pa
 	}
@@ -496,3 +506,83 @@ func TestMalformedExactBlacklist(t *testing.T) {
 	test.AssertError(t, err, "Loaded invalid exact blacklist content without error")
 	test.AssertEquals(t, err.Error(), "Malformed exact blacklist entry, only one label: \"com\"")
 }
+
+func TestHostnamePolicyReload(t *testing.T) {
+	pa := paImpl(t)
+
+	load := func(bl blacklistJSON) error {
+		b, err := json.Marshal(bl)
+		test.AssertNotError(t, err, "Couldn't serialize hostname policy")
+		return pa.loadHostnamePolicy(b)
+	}
+	ident := core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "www.example.com"}
+
+	err := load(blacklistJSON{Blacklist: []string{"example.com"}})
+	test.AssertNotError(t, err, "Couldn't load hostname policy")
+	test.AssertEquals(t, pa.WillingToIssue(ctx, ident, testRegID), errBlacklisted)
+
+	// A reload replaces the previous lists
+	err = load(blacklistJSON{
+		Blacklist:      []string{"example.net"},
+		ExactBlacklist: []string{"www.example.org", "mail.example.org"},
+	})
+	test.AssertNotError(t, err, "Couldn't reload hostname policy")
+	test.AssertNotError(t, pa.WillingToIssue(ctx, ident, testRegID), "Old hostname policy still in effect")
+	test.AssertEquals(t, test.CountCounter(pa.reloads.With(prometheus.Labels{"file": hostnamePolicyFile, "result": "success"})), 2)
+	test.AssertEquals(t, test.GaugeValue(pa.hostnamePolicyEntries.With(prometheus.Labels{"list": "blacklist"})), float64(1))
+	test.AssertEquals(t, test.GaugeValue(pa.hostnamePolicyEntries.With(prometheus.Labels{"list": "exactBlacklist"})), float64(2))
+	// Both exact entries are in example.org
+	test.AssertEquals(t, test.GaugeValue(pa.hostnamePolicyEntries.With(prometheus.Labels{"list": "wildcardExactBlacklist"})), float64(1))
+
+	// A failed reload leaves the previous lists in place
+	err = load(blacklistJSON{})
+	test.AssertError(t, err, "Loaded an empty hostname policy")
+	pa.hostnamePolicyLoadError(err)
+	test.AssertEquals(t, test.CountCounter(pa.reloads.With(prometheus.Labels{"file": hostnamePolicyFile, "result": "failure"})), 1)
+	test.AssertEquals(t, pa.WillingToIssue(ctx, core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "example.net"}, testRegID), errBlacklisted)
+}
+
+func TestPolicyOverride(t *testing.T) {
+	pa := paImpl(t)
+	b, err := json.Marshal(blacklistJSON{
+		Blacklist:      []string{"zombo.gov.us", "example.com"},
+		ExactBlacklist: []string{"highvalue.letsdecrypt.org"},
+	})
+	test.AssertNotError(t, err, "Couldn't serialize hostname policy")
+	err = pa.loadHostnamePolicy(b)
+	test.AssertNotError(t, err, "Couldn't load hostname policy")
+
+	// zombo.gov.us and letsdecrypt.org are overridden for testRegID only
+	var checked []string
+	var checkErr error
+	pa.SetOverrideCheck(func(_ context.Context, req *sapb.PolicyOverriddenRequest) (*sapb.Exists, error) {
+		checked = append(checked, req.GetDomain())
+		if checkErr != nil {
+			return nil, checkErr
+		}
+		exists := req.GetRegistrationID() == testRegID &&
+			(strings.HasSuffix(req.GetDomain(), "zombo.gov.us") || req.GetDomain() == "letsdecrypt.org")
+		return &sapb.Exists{Exists: &exists}, nil
+	})
+
+	dnsIdent := func(domain string) core.AcmeIdentifier {
+		return core.AcmeIdentifier{Type: core.IdentifierDNS, Value: domain}
+	}
+
+	test.AssertNotError(t, pa.WillingToIssue(ctx, dnsIdent("www.zombo.gov.us"), testRegID), "Overridden domain was forbidden")
+	test.AssertEquals(t, pa.WillingToIssue(ctx, dnsIdent("www.zombo.gov.us"), testRegIDWhitelisted), errBlacklisted)
+	test.AssertEquals(t, pa.WillingToIssue(ctx, dnsIdent("www.example.com"), testRegID), errBlacklisted)
+	test.AssertNotError(t, pa.WillingToIssueWildcard(ctx, dnsIdent("*.zombo.gov.us"), testRegID), "Overridden wildcard was forbidden")
+	test.AssertNotError(t, pa.WillingToIssueWildcard(ctx, dnsIdent("*.letsdecrypt.org"), testRegID), "Overridden exact blacklist wildcard was forbidden")
+	test.AssertEquals(t, pa.WillingToIssueWildcard(ctx, dnsIdent("*.letsdecrypt.org"), testRegIDWhitelisted), errBlacklisted)
+
+	// Errors other than the hostname policy can't be overridden
+	checked = nil
+	test.AssertEquals(t, pa.WillingToIssue(ctx, dnsIdent("www.zombo_.gov.us"), testRegID), errInvalidDNSCharacter)
+	test.AssertEquals(t, len(checked), 0)
+
+	checkErr = errors.New("database is down")
+	err = pa.WillingToIssue(ctx, dnsIdent("www.zombo.gov.us"), testRegID)
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Failed override check didn't return an internal error")
+	test.AssertNotError(t, pa.WillingToIssue(ctx, dnsIdent("www.letsencrypt.org"), testRegID), "Allowed domain was forbidden")
+}
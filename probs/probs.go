@@ -3,6 +3,8 @@ package probs
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/letsencrypt/boulder/identifier"
 )
 
 // Error types that can be used in ACME payloads
@@ -19,6 +21,10 @@ const (
 	RejectedIdentifierProblem  = ProblemType("rejectedIdentifier")
 	AccountDoesNotExistProblem = ProblemType("accountDoesNotExist")
 	CAAProblem                 = ProblemType("caa")
+	BadPublicKeyProblem        = ProblemType("badPublicKey")
+	BadCSRProblem              = ProblemType("badCSR")
+	ServerTimeoutProblem       = ProblemType("serverTimeout")
+	UserActionRequiredProblem  = ProblemType("userActionRequired")
 
 	V1ErrorNS = "urn:acme:error:"
 	V2ErrorNS = "urn:ietf:params:acme:error:"
@@ -35,12 +41,45 @@ type ProblemDetails struct {
 	// HTTPStatus is the HTTP status code the ProblemDetails should probably be sent
 	// as.
 	HTTPStatus int `json:"status,omitempty"`
+	// Identifier, if set, is the identifier the problem relates to.
+	Identifier *identifier.ACMEIdentifier `json:"identifier,omitempty"`
+	// SubProblems break down a problem with a request for several
+	// identifiers into the problems with each of them, as described in
+	// RFC 8555 section 6.7.1. Each has its Identifier set.
+	SubProblems []SubProblemDetails `json:"subproblems,omitempty"`
+	// Instance, if set, is a URL the client should direct a human user to
+	// visit to resolve the problem, e.g. to agree to new terms of service.
+	Instance string `json:"instance,omitempty"`
+	// Code is a stable, machine readable name for the kind of failure, for
+	// the problems that share a Type with failures needing different
+	// remediation. When a problem is sent it defaults to the Type without its
+	// namespace.
+	Code string `json:"code,omitempty"`
+	// Documentation, if set, is a URL describing the failure and how to
+	// resolve it, which is the WFE's documentation base URL followed by Code.
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// SubProblemDetails is the problem with one of the identifiers of a request.
+type SubProblemDetails struct {
+	Type       ProblemType               `json:"type,omitempty"`
+	Detail     string                    `json:"detail,omitempty"`
+	Identifier identifier.ACMEIdentifier `json:"identifier"`
+	Code       string                    `json:"code,omitempty"`
 }
 
 func (pd *ProblemDetails) Error() string {
 	return fmt.Sprintf("%s :: %s", pd.Type, pd.Detail)
 }
 
+// WithSubProblems returns a copy of pd with subProbs appended to its
+// subproblems.
+func (pd *ProblemDetails) WithSubProblems(subProbs []SubProblemDetails) *ProblemDetails {
+	result := *pd
+	result.SubProblems = append(append([]SubProblemDetails(nil), pd.SubProblems...), subProbs...)
+	return &result
+}
+
 // statusTooManyRequests is the HTTP status code meant for rate limiting
 // errors. It's not currently in the net/http library so we add it here.
 const statusTooManyRequests = 429
@@ -61,16 +100,21 @@ func ProblemDetailsToStatusCode(prob *ProblemDetails) int {
 		BadNonceProblem,
 		InvalidEmailProblem,
 		RejectedIdentifierProblem,
-		AccountDoesNotExistProblem:
+		AccountDoesNotExistProblem,
+		BadPublicKeyProblem,
+		BadCSRProblem:
 		return http.StatusBadRequest
 	case ServerInternalProblem:
 		return http.StatusInternalServerError
 	case
 		UnauthorizedProblem,
-		CAAProblem:
+		CAAProblem,
+		UserActionRequiredProblem:
 		return http.StatusForbidden
 	case RateLimitedProblem:
 		return statusTooManyRequests
+	case ServerTimeoutProblem:
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
@@ -103,6 +147,7 @@ func Conflict(detail string) *ProblemDetails {
 		Type:       MalformedProblem,
 		Detail:     detail,
 		HTTPStatus: http.StatusConflict,
+		Code:       "conflict",
 	}
 }
 
@@ -126,6 +171,7 @@ func NotFound(detail string) *ProblemDetails {
 		Type:       MalformedProblem,
 		Detail:     detail,
 		HTTPStatus: http.StatusNotFound,
+		Code:       "notFound",
 	}
 }
 
@@ -156,6 +202,7 @@ func MethodNotAllowed() *ProblemDetails {
 		Type:       MalformedProblem,
 		Detail:     "Method not allowed",
 		HTTPStatus: http.StatusMethodNotAllowed,
+		Code:       "methodNotAllowed",
 	}
 }
 
@@ -166,6 +213,7 @@ func ContentLengthRequired() *ProblemDetails {
 		Type:       MalformedProblem,
 		Detail:     "missing Content-Length header",
 		HTTPStatus: http.StatusLengthRequired,
+		Code:       "contentLengthRequired",
 	}
 }
 
@@ -176,6 +224,7 @@ func InvalidContentType(detail string) *ProblemDetails {
 		Type:       MalformedProblem,
 		Detail:     detail,
 		HTTPStatus: http.StatusUnsupportedMediaType,
+		Code:       "invalidContentType",
 	}
 }
 
@@ -244,3 +293,44 @@ func CAA(detail string) *ProblemDetails {
 		HTTPStatus: http.StatusForbidden,
 	}
 }
+
+// BadPublicKey returns a ProblemDetails representing a BadPublicKeyProblem
+func BadPublicKey(detail string) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       BadPublicKeyProblem,
+		Detail:     detail,
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+// BadCSR returns a ProblemDetails representing a BadCSRProblem
+func BadCSR(detail string) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       BadCSRProblem,
+		Detail:     detail,
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+// ServerTimeout returns a ProblemDetails representing a ServerTimeoutProblem
+// with a 503 Service Unavailable status code, for requests that couldn't be
+// completed in time. Clients may retry them later.
+func ServerTimeout(detail string) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       ServerTimeoutProblem,
+		Detail:     detail,
+		HTTPStatus: http.StatusServiceUnavailable,
+	}
+}
+
+// UserActionRequired returns a ProblemDetails representing a
+// UserActionRequiredProblem with a 403 Forbidden status code, for requests
+// that can't proceed until a human user visits the instance URL.
+func UserActionRequired(detail, instance string) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       UserActionRequiredProblem,
+		Detail:     detail,
+		HTTPStatus: http.StatusForbidden,
+		Instance:   instance,
+	}
+}
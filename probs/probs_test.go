@@ -1,10 +1,12 @@
 package probs
 
 import (
+	"encoding/json"
 	"testing"
 
 	"net/http"
 
+	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -35,6 +37,9 @@ func TestProblemDetailsToStatusCode(t *testing.T) {
 		{&ProblemDetails{Type: "foo", HTTPStatus: 200}, 200},
 		{&ProblemDetails{Type: ConnectionProblem, HTTPStatus: 200}, 200},
 		{&ProblemDetails{Type: AccountDoesNotExistProblem}, http.StatusBadRequest},
+		{&ProblemDetails{Type: BadPublicKeyProblem}, http.StatusBadRequest},
+		{&ProblemDetails{Type: ServerTimeoutProblem}, http.StatusServiceUnavailable},
+		{&ProblemDetails{Type: UserActionRequiredProblem}, http.StatusForbidden},
 	}
 
 	for _, c := range testCases {
@@ -63,6 +68,9 @@ func TestProblemDetailsConvenience(t *testing.T) {
 		{TLSError("TLS error detail"), TLSProblem, http.StatusBadRequest, "TLS error detail"},
 		{RejectedIdentifier("rejected identifier detail"), RejectedIdentifierProblem, http.StatusBadRequest, "rejected identifier detail"},
 		{AccountDoesNotExist("no account detail"), AccountDoesNotExistProblem, http.StatusBadRequest, "no account detail"},
+		{BadPublicKey("bad public key detail"), BadPublicKeyProblem, http.StatusBadRequest, "bad public key detail"},
+		{ServerTimeout("timeout detail"), ServerTimeoutProblem, http.StatusServiceUnavailable, "timeout detail"},
+		{UserActionRequired("agree detail", "https://example.com/tos"), UserActionRequiredProblem, http.StatusForbidden, "agree detail"},
 	}
 
 	for _, c := range testCases {
@@ -79,3 +87,22 @@ func TestProblemDetailsConvenience(t *testing.T) {
 		}
 	}
 }
+
+func TestWithSubProblems(t *testing.T) {
+	topProb := RejectedIdentifier("Cannot issue for 2 names")
+	sub := []SubProblemDetails{
+		{Type: MalformedProblem, Detail: "invalid", Identifier: identifier.DNSIdentifier("-example.com")},
+		{Type: RejectedIdentifierProblem, Detail: "forbidden", Identifier: identifier.DNSIdentifier("example.net")},
+	}
+	withSub := topProb.WithSubProblems(sub[:1])
+	test.AssertEquals(t, len(topProb.SubProblems), 0)
+	withSub = withSub.WithSubProblems(sub[1:])
+	test.AssertDeepEquals(t, withSub.SubProblems, sub)
+	test.AssertEquals(t, withSub.Type, RejectedIdentifierProblem)
+
+	doc, err := json.Marshal(withSub)
+	test.AssertNotError(t, err, "marshaling problem")
+	test.AssertEquals(t, string(doc), `{"type":"rejectedIdentifier","detail":"Cannot issue for 2 names","status":400,`+
+		`"subproblems":[{"type":"malformed","detail":"invalid","identifier":{"type":"dns","value":"-example.com"}},`+
+		`{"type":"rejectedIdentifier","detail":"forbidden","identifier":{"type":"dns","value":"example.net"}}]}`)
+}
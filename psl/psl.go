@@ -0,0 +1,35 @@
+// Package psl holds the Public Suffix List used by the policy authority and
+// for rate limiting, and can keep it up to date without a rebuild.
+package psl
+
+import (
+	"sync/atomic"
+
+	"github.com/weppos/publicsuffix-go/publicsuffix"
+)
+
+// current holds the *publicsuffix.List in use. It starts out as the list
+// compiled into the publicsuffix package.
+var current atomic.Value
+
+func init() {
+	current.Store(publicsuffix.DefaultList)
+}
+
+// List returns the Public Suffix List currently in use.
+func List() *publicsuffix.List {
+	return current.Load().(*publicsuffix.List)
+}
+
+// set replaces the Public Suffix List in use. Lookups that are already in
+// progress keep using the previous list.
+func set(l *publicsuffix.List) {
+	current.Store(l)
+}
+
+// Domain returns the registered domain (eTLD+1) of name using the current
+// list. Like publicsuffix.Domain it returns an error if name is itself a
+// public suffix.
+func Domain(name string) (string, error) {
+	return publicsuffix.DomainFromListWithOptions(List(), name, publicsuffix.DefaultFindOptions)
+}
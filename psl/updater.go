@@ -0,0 +1,179 @@
+package psl
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/weppos/publicsuffix-go/publicsuffix"
+	"golang.org/x/net/context"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+const (
+	// defaultUpdateInterval is used when NewUpdater is given no interval.
+	defaultUpdateInterval = 24 * time.Hour
+	// maxListSize bounds how much of a response is read. The list is currently
+	// around 200KB.
+	maxListSize = 10 << 20
+)
+
+// canaries are checked against every fetched list, mapping names to their
+// expected registered domain, or to "" if the name is a public suffix. A list
+// that disagrees is assumed to be truncated or otherwise broken.
+var canaries = map[string]string{
+	"com":               "",
+	"co.uk":             "",
+	"www.example.com":   "example.com",
+	"www.example.co.uk": "example.co.uk",
+}
+
+// Updater periodically fetches the Public Suffix List from a URL and, if it
+// passes validation, swaps it in as the list returned by List.
+type Updater struct {
+	url      string
+	client   *http.Client
+	interval time.Duration
+	clk      clock.Clock
+	log      blog.Logger
+	// minRules is the number of rules a fetched list must have. It defaults to
+	// 90% of the size of the list in use when the Updater is created, so that
+	// a truncated list is rejected.
+	minRules int
+	// etag is the ETag of the last list fetched, used to skip unchanged lists.
+	etag string
+
+	updates    *prometheus.CounterVec
+	rules      prometheus.Gauge
+	lastUpdate prometheus.Gauge
+}
+
+// NewUpdater returns an Updater fetching the Public Suffix List from url every
+// interval.
+func NewUpdater(url string, interval time.Duration, clk clock.Clock, logger blog.Logger, scope metrics.Scope) *Updater {
+	if interval <= 0 {
+		interval = defaultUpdateInterval
+	}
+	updates := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "psl_updates",
+			Help: "Number of Public Suffix List update attempts by result",
+		},
+		[]string{"result"})
+	scope.MustRegister(updates)
+	rules := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "psl_rules",
+		Help: "Number of rules in the Public Suffix List in use",
+	})
+	scope.MustRegister(rules)
+	lastUpdate := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "psl_last_update_seconds",
+		Help: "Unix time of the last successful Public Suffix List update",
+	})
+	scope.MustRegister(lastUpdate)
+	rules.Set(float64(List().Size()))
+
+	return &Updater{
+		url:        url,
+		client:     &http.Client{Timeout: time.Minute},
+		interval:   interval,
+		clk:        clk,
+		log:        logger,
+		minRules:   List().Size() * 9 / 10,
+		updates:    updates,
+		rules:      rules,
+		lastUpdate: lastUpdate,
+	}
+}
+
+// UpdateForever updates the list every interval, starting right away. Failed
+// updates are logged and leave the list in use unchanged. It never returns, so
+// should be run in its own goroutine.
+func (u *Updater) UpdateForever() {
+	for {
+		if err := u.Update(context.Background()); err != nil {
+			u.log.Err(fmt.Sprintf("updating Public Suffix List from %s: %s", u.url, err))
+		}
+		u.clk.Sleep(u.interval)
+	}
+}
+
+// Update fetches the list once and swaps it in if it has changed and is valid.
+func (u *Updater) Update(ctx context.Context) error {
+	l, etag, err := u.fetch(ctx)
+	if err != nil {
+		u.updates.With(prometheus.Labels{"result": "failure"}).Inc()
+		return err
+	}
+	if l == nil {
+		u.updates.With(prometheus.Labels{"result": "unchanged"}).Inc()
+		return nil
+	}
+	if err := u.validate(l); err != nil {
+		u.updates.With(prometheus.Labels{"result": "invalid"}).Inc()
+		return err
+	}
+	set(l)
+	u.etag = etag
+	u.updates.With(prometheus.Labels{"result": "success"}).Inc()
+	u.rules.Set(float64(l.Size()))
+	u.lastUpdate.Set(float64(u.clk.Now().Unix()))
+	u.log.Info(fmt.Sprintf("Updated Public Suffix List from %s, %d rules", u.url, l.Size()))
+	return nil
+}
+
+// fetch fetches and parses the list. It returns a nil list if the server
+// reports that the list hasn't changed since the last successful update.
+func (u *Updater) fetch(ctx context.Context) (*publicsuffix.List, string, error) {
+	req, err := http.NewRequest("GET", u.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req = req.WithContext(ctx)
+	if u.etag != "" {
+		req.Header.Set("If-None-Match", u.etag)
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, "", nil
+	default:
+		return nil, "", fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+	l := publicsuffix.NewList()
+	_, err = l.Load(io.LimitReader(resp.Body, maxListSize), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing list: %s", err)
+	}
+	return l, resp.Header.Get("ETag"), nil
+}
+
+// validate checks that l is large enough and gives the expected results for
+// the canaries.
+func (u *Updater) validate(l *publicsuffix.List) error {
+	if l.Size() < u.minRules {
+		return fmt.Errorf("list has %d rules, expected at least %d", l.Size(), u.minRules)
+	}
+	for name, expected := range canaries {
+		domain, err := publicsuffix.DomainFromListWithOptions(l, name, publicsuffix.DefaultFindOptions)
+		if err != nil && expected != "" {
+			return fmt.Errorf("list has no registered domain for %q: %s", name, err)
+		}
+		if domain != expected {
+			return fmt.Errorf("list has registered domain %q for %q, expected %q", domain, name, expected)
+		}
+	}
+	return nil
+}
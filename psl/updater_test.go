@@ -0,0 +1,88 @@
+package psl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/weppos/publicsuffix-go/publicsuffix"
+	"golang.org/x/net/context"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+const testList = `// ===BEGIN ICANN DOMAINS===
+com
+uk
+co.uk
+// ===END ICANN DOMAINS===
+// ===BEGIN PRIVATE DOMAINS===
+boulder-test.com
+// ===END PRIVATE DOMAINS===
+`
+
+func TestUpdate(t *testing.T) {
+	defer set(publicsuffix.DefaultList)
+
+	list := testList
+	etag := `"v1"`
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(status)
+		fmt.Fprint(w, list)
+	}))
+	defer srv.Close()
+
+	domain, err := Domain("www.boulder-test.com")
+	test.AssertNotError(t, err, "Domain failed")
+	test.AssertEquals(t, domain, "boulder-test.com")
+
+	u := NewUpdater(srv.URL, 0, clock.NewFake(), blog.UseMock(), metrics.NewNoopScope())
+	test.AssertEquals(t, u.interval, defaultUpdateInterval)
+	// A list that small would normally be rejected
+	err = u.Update(context.Background())
+	test.AssertError(t, err, "Truncated list was accepted")
+	test.AssertEquals(t, test.CountCounter(u.updates.With(prometheus.Labels{"result": "invalid"})), 1)
+	test.AssertEquals(t, List(), publicsuffix.DefaultList)
+
+	u.minRules = 4
+	err = u.Update(context.Background())
+	test.AssertNotError(t, err, "Update failed")
+	test.AssertEquals(t, test.CountCounter(u.updates.With(prometheus.Labels{"result": "success"})), 1)
+	test.AssertEquals(t, List().Size(), 4)
+	test.AssertEquals(t, test.GaugeValue(u.rules), float64(4))
+	domain, err = Domain("www.boulder-test.com")
+	test.AssertNotError(t, err, "Domain failed")
+	test.AssertEquals(t, domain, "www.boulder-test.com")
+	_, err = Domain("boulder-test.com")
+	test.AssertError(t, err, "Domain didn't fail for a public suffix")
+
+	// An unchanged list isn't parsed again
+	err = u.Update(context.Background())
+	test.AssertNotError(t, err, "Update failed")
+	test.AssertEquals(t, test.CountCounter(u.updates.With(prometheus.Labels{"result": "unchanged"})), 1)
+
+	// A list that fails the canaries is rejected
+	updated := List()
+	etag = `"v2"`
+	list = "com\nuk\nexample.com\nboulder-test.com\n"
+	err = u.Update(context.Background())
+	test.AssertError(t, err, "List failing canaries was accepted")
+	test.AssertEquals(t, List(), updated)
+
+	status = http.StatusInternalServerError
+	err = u.Update(context.Background())
+	test.AssertError(t, err, "Update didn't fail on an HTTP error")
+	test.AssertEquals(t, test.CountCounter(u.updates.With(prometheus.Labels{"result": "failure"})), 1)
+	test.AssertEquals(t, List(), updated)
+}
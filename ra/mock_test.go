@@ -83,6 +83,13 @@ func (sa *mockInvalidAuthorizationsAuthority) CountInvalidAuthorizations(ctx con
 	}, nil
 }
 
+func (sa *mockInvalidAuthorizationsAuthority) CountFailedValidations(ctx context.Context, in *sapb.CountFailedValidationsRequest, opts ...grpc.CallOption) (*sapb.Count, error) {
+	count := int64(1)
+	return &sapb.Count{
+		Count: &count,
+	}, nil
+}
+
 func (sa *mockInvalidAuthorizationsAuthority) GetSCTReceipt(ctx context.Context, in *sapb.GetSCTReceiptRequest, opts ...grpc.CallOption) (*sapb.SignedCertificateTimestamp, error) {
 	return nil, nil
 }
@@ -106,6 +113,10 @@ func (sa *mockInvalidAuthorizationsAuthority) FQDNSetExists(ctx context.Context,
 	return nil, nil
 }
 
+func (sa *mockInvalidAuthorizationsAuthority) FQDNSetIssuedForAccount(ctx context.Context, in *sapb.FQDNSetIssuedForAccountRequest, opts ...grpc.CallOption) (*sapb.Exists, error) {
+	return nil, nil
+}
+
 func (sa *mockInvalidAuthorizationsAuthority) NewRegistration(ctx context.Context, in *core.Registration, opts ...grpc.CallOption) (*core.Registration, error) {
 	return nil, nil
 }
@@ -154,6 +165,10 @@ func (sa *mockInvalidAuthorizationsAuthority) NewOrder(ctx context.Context, in *
 	return nil, nil
 }
 
+func (sa *mockInvalidAuthorizationsAuthority) NewOrderAndAuthzs(ctx context.Context, in *sapb.NewOrderAndAuthzsRequest, opts ...grpc.CallOption) (*core.Order, error) {
+	return nil, nil
+}
+
 func (sa *mockInvalidAuthorizationsAuthority) GetOrder(ctx context.Context, in *sapb.OrderRequest, opts ...grpc.CallOption) (*core.Order, error) {
 	return nil, nil
 }
@@ -185,3 +200,43 @@ func (sa *mockInvalidAuthorizationsAuthority) SetOrderError(ctx context.Context,
 func (sa *mockInvalidAuthorizationsAuthority) FinalizeOrder(ctx context.Context, in *core.Order, opts ...grpc.CallOption) (*core.Empty, error) {
 	return nil, nil
 }
+
+func (sa *mockInvalidAuthorizationsAuthority) KeyBlocked(ctx context.Context, in *sapb.KeyBlockedRequest, opts ...grpc.CallOption) (*sapb.Exists, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) PolicyOverridden(ctx context.Context, in *sapb.PolicyOverriddenRequest, opts ...grpc.CallOption) (*sapb.Exists, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) AddBlockedKey(ctx context.Context, in *sapb.AddBlockedKeyRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) AddVerifiedContact(ctx context.Context, in *sapb.AddVerifiedContactRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) AddCTSubmission(ctx context.Context, in *sapb.AddCTSubmissionRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) GetAccountStats(ctx context.Context, in *sapb.RegistrationID, opts ...grpc.CallOption) (*sapb.AccountStats, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) GetCertificateStatuses(ctx context.Context, in *sapb.Serials, opts ...grpc.CallOption) (*sapb.CertificateStatuses, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) GetSerialsByKey(ctx context.Context, in *sapb.GetSerialsByKeyRequest, opts ...grpc.CallOption) (*sapb.Serials, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) GetSCTReceipts(ctx context.Context, in *sapb.Serial, opts ...grpc.CallOption) (*sapb.SignedCertificateTimestamps, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) GetRateLimitOverrides(ctx context.Context, in *core.Empty, opts ...grpc.CallOption) (*sapb.RateLimitOverrides, error) {
+	return nil, nil
+}
@@ -156,10 +156,12 @@ type RevokeCertificateWithRegRequest struct {
 	XXX_unrecognized []byte `json:"-"`
 }
 
-func (m *RevokeCertificateWithRegRequest) Reset()                    { *m = RevokeCertificateWithRegRequest{} }
-func (m *RevokeCertificateWithRegRequest) String() string            { return proto1.CompactTextString(m) }
-func (*RevokeCertificateWithRegRequest) ProtoMessage()               {}
-func (*RevokeCertificateWithRegRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (m *RevokeCertificateWithRegRequest) Reset()         { *m = RevokeCertificateWithRegRequest{} }
+func (m *RevokeCertificateWithRegRequest) String() string { return proto1.CompactTextString(m) }
+func (*RevokeCertificateWithRegRequest) ProtoMessage()    {}
+func (*RevokeCertificateWithRegRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{4}
+}
 
 func (m *RevokeCertificateWithRegRequest) GetCert() []byte {
 	if m != nil {
@@ -192,8 +194,10 @@ type AdministrativelyRevokeCertificateRequest struct {
 func (m *AdministrativelyRevokeCertificateRequest) Reset() {
 	*m = AdministrativelyRevokeCertificateRequest{}
 }
-func (m *AdministrativelyRevokeCertificateRequest) String() string { return proto1.CompactTextString(m) }
-func (*AdministrativelyRevokeCertificateRequest) ProtoMessage()    {}
+func (m *AdministrativelyRevokeCertificateRequest) String() string {
+	return proto1.CompactTextString(m)
+}
+func (*AdministrativelyRevokeCertificateRequest) ProtoMessage() {}
 func (*AdministrativelyRevokeCertificateRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor0, []int{5}
 }
@@ -302,6 +306,7 @@ type RegistrationAuthorityClient interface {
 	AdministrativelyRevokeCertificate(ctx context.Context, in *AdministrativelyRevokeCertificateRequest, opts ...grpc.CallOption) (*core.Empty, error)
 	NewOrder(ctx context.Context, in *NewOrderRequest, opts ...grpc.CallOption) (*core.Order, error)
 	FinalizeOrder(ctx context.Context, in *FinalizeOrderRequest, opts ...grpc.CallOption) (*core.Order, error)
+	CheckNewOrder(ctx context.Context, in *NewOrderRequest, opts ...grpc.CallOption) (*core.Empty, error)
 }
 
 type registrationAuthorityClient struct {
@@ -411,6 +416,15 @@ func (c *registrationAuthorityClient) FinalizeOrder(ctx context.Context, in *Fin
 	return out, nil
 }
 
+func (c *registrationAuthorityClient) CheckNewOrder(ctx context.Context, in *NewOrderRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/ra.RegistrationAuthority/CheckNewOrder", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for RegistrationAuthority service
 
 type RegistrationAuthorityServer interface {
@@ -425,6 +439,7 @@ type RegistrationAuthorityServer interface {
 	AdministrativelyRevokeCertificate(context.Context, *AdministrativelyRevokeCertificateRequest) (*core.Empty, error)
 	NewOrder(context.Context, *NewOrderRequest) (*core.Order, error)
 	FinalizeOrder(context.Context, *FinalizeOrderRequest) (*core.Order, error)
+	CheckNewOrder(context.Context, *NewOrderRequest) (*core.Empty, error)
 }
 
 func RegisterRegistrationAuthorityServer(s *grpc.Server, srv RegistrationAuthorityServer) {
@@ -629,6 +644,24 @@ func _RegistrationAuthority_FinalizeOrder_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RegistrationAuthority_CheckNewOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistrationAuthorityServer).CheckNewOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ra.RegistrationAuthority/CheckNewOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistrationAuthorityServer).CheckNewOrder(ctx, req.(*NewOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _RegistrationAuthority_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "ra.RegistrationAuthority",
 	HandlerType: (*RegistrationAuthorityServer)(nil),
@@ -677,6 +710,10 @@ var _RegistrationAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "FinalizeOrder",
 			Handler:    _RegistrationAuthority_FinalizeOrder_Handler,
 		},
+		{
+			MethodName: "CheckNewOrder",
+			Handler:    _RegistrationAuthority_CheckNewOrder_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "ra/proto/ra.proto",
@@ -686,41 +723,41 @@ func init() { proto1.RegisterFile("ra/proto/ra.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
 	// 585 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x6b, 0x6f, 0xd2, 0x50,
-	0x18, 0x06, 0x3a, 0xe6, 0xf6, 0x4e, 0xd9, 0xf6, 0x32, 0x5c, 0x57, 0x6f, 0xac, 0x26, 0x0b, 0x5e,
-	0xc2, 0x92, 0x7d, 0x32, 0x59, 0x8c, 0xce, 0xe1, 0x12, 0xa2, 0x61, 0x49, 0x13, 0x63, 0xb2, 0x2f,
-	0x7a, 0x2c, 0xaf, 0xd0, 0x08, 0x2d, 0x9e, 0x1e, 0x40, 0xf8, 0x13, 0xfe, 0x01, 0x7f, 0xac, 0xe9,
-	0x39, 0x87, 0xf5, 0x42, 0xc9, 0x5c, 0xfc, 0x76, 0xfa, 0x5e, 0x9e, 0xf7, 0xf6, 0x3c, 0x29, 0xec,
-	0x72, 0x76, 0x3c, 0xe2, 0x81, 0x08, 0x8e, 0x39, 0x6b, 0xca, 0x07, 0x96, 0x38, 0xb3, 0x6a, 0x6e,
-	0xc0, 0x49, 0x3b, 0xa2, 0xa7, 0x72, 0xd9, 0x57, 0xb0, 0xdf, 0xa1, 0xe9, 0xd9, 0x58, 0xf4, 0x03,
-	0xee, 0xcd, 0x99, 0xf0, 0x02, 0xdf, 0xa1, 0x9f, 0x63, 0x0a, 0x05, 0x3e, 0x83, 0x32, 0x1b, 0x8b,
-	0xfe, 0xdc, 0x2c, 0xd6, 0x8b, 0x8d, 0xad, 0x93, 0x6a, 0x53, 0xa6, 0xa5, 0x43, 0x55, 0x04, 0xee,
-	0x41, 0x99, 0x53, 0xaf, 0xdd, 0x32, 0x4b, 0xf5, 0x62, 0xc3, 0x70, 0xd4, 0x87, 0xfd, 0x06, 0x6a,
-	0x1d, 0x9a, 0x9e, 0x13, 0x17, 0xde, 0x77, 0xcf, 0x65, 0x82, 0x16, 0xc8, 0x3b, 0x60, 0xb8, 0x21,
-	0x97, 0xb8, 0x77, 0x9d, 0xe8, 0xb9, 0x02, 0x20, 0x80, 0x83, 0x4f, 0xa3, 0xae, 0x4c, 0xec, 0x79,
-	0xa1, 0xe0, 0xa9, 0xf6, 0x8e, 0x60, 0xed, 0x1b, 0x0b, 0x49, 0x77, 0x87, 0xaa, 0xbb, 0x54, 0xa0,
-	0xf4, 0xe3, 0x73, 0x58, 0x1f, 0x4b, 0x10, 0x89, 0x9d, 0x1f, 0xa9, 0x23, 0xec, 0x3f, 0x45, 0xb0,
-	0x54, 0xc5, 0xff, 0xdd, 0xc8, 0x11, 0x54, 0xdc, 0x3e, 0x1b, 0x0c, 0xc8, 0xef, 0x51, 0xdb, 0xef,
-	0xd2, 0x2f, 0x3d, 0x59, 0xc6, 0x8a, 0x2f, 0x60, 0x83, 0x53, 0x38, 0x0a, 0xfc, 0x90, 0x4c, 0x43,
-	0xa2, 0x6e, 0x2b, 0xd4, 0xf3, 0x45, 0x9c, 0x73, 0x1d, 0x60, 0x7f, 0x81, 0x27, 0x0e, 0x4d, 0x82,
-	0x1f, 0x94, 0xd8, 0xe9, 0x67, 0x4f, 0xf4, 0x1d, 0xea, 0x2d, 0x5a, 0x44, 0x58, 0x73, 0x89, 0x0b,
-	0xbd, 0x5b, 0xf9, 0x96, 0xb6, 0xa0, 0x4b, 0xba, 0x03, 0xf9, 0x8e, 0x17, 0x6e, 0x24, 0x17, 0x3e,
-	0x82, 0xc6, 0x59, 0x77, 0xe8, 0xf9, 0x7a, 0x33, 0x13, 0x1a, 0xcc, 0x96, 0x0a, 0xde, 0xb6, 0xd2,
-	0x43, 0xd8, 0x64, 0x11, 0x66, 0x87, 0x0d, 0xd5, 0x88, 0x9b, 0x4e, 0x6c, 0xb0, 0x2f, 0x61, 0xbb,
-	0x43, 0xd3, 0x4b, 0xde, 0x25, 0x1e, 0x1f, 0xb6, 0xc2, 0x13, 0xc7, 0x69, 0xb7, 0x64, 0x09, 0xc3,
-	0xc9, 0x58, 0xa3, 0x11, 0x7c, 0x36, 0xa4, 0xd0, 0x2c, 0xd5, 0x8d, 0xc6, 0xa6, 0xa3, 0x3e, 0xec,
-	0x0f, 0xb0, 0x77, 0xe1, 0xf9, 0x6c, 0xe0, 0xcd, 0x29, 0x85, 0x7a, 0x08, 0xe5, 0x20, 0xfa, 0xd6,
-	0xb7, 0xdb, 0x52, 0x5b, 0x56, 0x21, 0xca, 0xb3, 0xa0, 0x65, 0xe9, 0x9a, 0x96, 0x27, 0xbf, 0xd7,
-	0xa1, 0x96, 0x24, 0x8a, 0x3e, 0xb5, 0x98, 0xe1, 0xa9, 0xec, 0x3b, 0xe9, 0xc3, 0x1c, 0x62, 0x59,
-	0x39, 0x36, 0xbb, 0x80, 0x17, 0xb0, 0x93, 0x15, 0x1d, 0x3e, 0x68, 0x72, 0xd6, 0x5c, 0x21, 0x45,
-	0x2b, 0x8f, 0x69, 0x76, 0x01, 0xdf, 0x42, 0x25, 0x2d, 0x30, 0x3c, 0xd0, 0x28, 0xcb, 0xf7, 0xb2,
-	0x76, 0x35, 0xaf, 0x62, 0x8f, 0x5d, 0xc0, 0x36, 0xe0, 0xb2, 0xc2, 0xf0, 0x51, 0x84, 0xb2, 0x52,
-	0x79, 0x2b, 0x86, 0xfa, 0x08, 0xd5, 0x1c, 0xe9, 0xe0, 0xe3, 0x18, 0xeb, 0x36, 0xa3, 0x75, 0xc0,
-	0x5c, 0x45, 0x75, 0x7c, 0x1a, 0x41, 0xde, 0x20, 0x04, 0x4b, 0x1f, 0xf8, 0xfd, 0x70, 0x24, 0x66,
-	0x76, 0x01, 0x4f, 0xe1, 0x7e, 0x8b, 0x98, 0x2b, 0xbc, 0x49, 0x76, 0xd8, 0xbc, 0xb3, 0x65, 0x92,
-	0x5f, 0xc3, 0x7e, 0x9c, 0x9c, 0x1e, 0x2f, 0xaf, 0xfd, 0x6c, 0xfa, 0x57, 0x38, 0xbc, 0x51, 0x55,
-	0xf8, 0x32, 0x1a, 0xea, 0x5f, 0xc5, 0x97, 0xad, 0xd0, 0x84, 0x8d, 0x85, 0x8a, 0xb0, 0xaa, 0x29,
-	0x90, 0x64, 0xbf, 0x95, 0xa4, 0xbb, 0x5d, 0xc0, 0x57, 0x70, 0x2f, 0x25, 0x12, 0x34, 0xa3, 0xa4,
-	0x3c, 0xdd, 0x64, 0x32, 0xdf, 0xdd, 0xb9, 0x2a, 0xcb, 0x1f, 0xc7, 0xdf, 0x00, 0x00, 0x00, 0xff,
-	0xff, 0x13, 0x2f, 0xf4, 0x68, 0x67, 0x06, 0x00, 0x00,
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa5, 0x55, 0xd9, 0x4e, 0xdb, 0x40,
+	0x14, 0x6d, 0x62, 0xc2, 0x72, 0x29, 0x01, 0x06, 0x52, 0x82, 0xbb, 0x62, 0x24, 0x44, 0x17, 0x05,
+	0x89, 0xbe, 0x54, 0x42, 0x55, 0x4b, 0x43, 0x91, 0xa2, 0x56, 0x41, 0xb2, 0x84, 0x2a, 0xf1, 0xd2,
+	0x4e, 0x9d, 0xdb, 0xc4, 0x22, 0xb1, 0xdd, 0xf1, 0x84, 0x36, 0xf9, 0x96, 0x7e, 0x4e, 0x3f, 0xac,
+	0xe3, 0x99, 0x31, 0x5e, 0x62, 0x0b, 0x50, 0xdf, 0xc6, 0x77, 0x39, 0x77, 0x3b, 0x47, 0x86, 0x75,
+	0x46, 0x0f, 0x02, 0xe6, 0x73, 0xff, 0x80, 0xd1, 0x96, 0x7c, 0x90, 0x2a, 0xa3, 0x66, 0xc3, 0xf1,
+	0x19, 0x6a, 0x47, 0xf4, 0x54, 0x2e, 0xeb, 0x02, 0xb6, 0xba, 0xf8, 0xeb, 0x78, 0xcc, 0x07, 0x3e,
+	0x73, 0xa7, 0x94, 0xbb, 0xbe, 0x67, 0xe3, 0xcf, 0x31, 0x86, 0x9c, 0x3c, 0x87, 0x1a, 0x15, 0xf6,
+	0x69, 0xb3, 0xf2, 0xac, 0xb2, 0xbf, 0x7c, 0xb8, 0xd1, 0x92, 0x69, 0xd9, 0x50, 0x15, 0x41, 0x36,
+	0xa1, 0xc6, 0xb0, 0xdf, 0x39, 0x69, 0x56, 0x45, 0xa8, 0x61, 0xab, 0x0f, 0xeb, 0x1d, 0x34, 0x04,
+	0x76, 0x1b, 0x19, 0x77, 0x7f, 0xb8, 0x0e, 0xe5, 0x18, 0x23, 0xaf, 0x81, 0xe1, 0x84, 0x4c, 0xe2,
+	0xde, 0xb7, 0xa3, 0x67, 0x09, 0x80, 0x0f, 0xdb, 0xe7, 0x41, 0x4f, 0x26, 0xf6, 0xdd, 0x90, 0xb3,
+	0x4c, 0x7b, 0x7b, 0x30, 0xf7, 0x9d, 0x86, 0xa8, 0xbb, 0x23, 0xaa, 0xbb, 0x4c, 0xa0, 0xf4, 0x93,
+	0x17, 0x30, 0x3f, 0x96, 0x20, 0x12, 0xbb, 0x38, 0x52, 0x47, 0x58, 0x7f, 0x2a, 0x60, 0xaa, 0x8a,
+	0xff, 0xbb, 0x91, 0x3d, 0xa8, 0x3b, 0x03, 0x3a, 0x1c, 0xa2, 0xd7, 0xc7, 0x8e, 0xd7, 0xc3, 0xdf,
+	0x7a, 0xb2, 0x9c, 0x95, 0xbc, 0x84, 0x45, 0x86, 0x61, 0xe0, 0x7b, 0x62, 0x12, 0x43, 0xa2, 0xae,
+	0x2a, 0xd4, 0x76, 0x1c, 0x67, 0x5f, 0x07, 0x58, 0x5f, 0xe1, 0xa9, 0x8d, 0x57, 0xfe, 0x25, 0xa6,
+	0x76, 0xfa, 0xc5, 0xe5, 0x03, 0x31, 0x4b, 0xdc, 0x22, 0x81, 0x39, 0x47, 0x38, 0xf5, 0x6e, 0xe5,
+	0x5b, 0xda, 0xfc, 0x1e, 0xea, 0x0e, 0xe4, 0x3b, 0x59, 0xb8, 0x91, 0x5e, 0x78, 0x00, 0xfb, 0xc7,
+	0xbd, 0x91, 0xeb, 0xe9, 0xcd, 0x5c, 0xe1, 0x70, 0x32, 0x53, 0xf0, 0xae, 0x95, 0x1e, 0xc1, 0x12,
+	0x8d, 0x30, 0xbb, 0x74, 0xa4, 0x46, 0x5c, 0xb2, 0x13, 0x83, 0x75, 0x06, 0xab, 0x82, 0x23, 0x67,
+	0xac, 0x87, 0x2c, 0x39, 0x6c, 0x9d, 0xa5, 0x8e, 0x23, 0x7a, 0xac, 0xa8, 0xd5, 0x65, 0xad, 0xd1,
+	0x08, 0x9e, 0x80, 0x08, 0x45, 0x35, 0x43, 0x80, 0xaa, 0x0f, 0xeb, 0x13, 0x6c, 0x9e, 0xba, 0x1e,
+	0x1d, 0xba, 0x53, 0xcc, 0xa0, 0xee, 0x40, 0xcd, 0x8f, 0xbe, 0xf5, 0xed, 0x96, 0xd5, 0x96, 0x55,
+	0x88, 0xf2, 0xc4, 0xb4, 0xac, 0x5e, 0xd3, 0xf2, 0xf0, 0xef, 0x3c, 0x34, 0xd2, 0x44, 0xd1, 0xa7,
+	0xe6, 0x13, 0x72, 0x24, 0xfb, 0x4e, 0xfb, 0x48, 0x01, 0xb1, 0xcc, 0x02, 0x9b, 0x75, 0x8f, 0x9c,
+	0xc2, 0x5a, 0x5e, 0x74, 0xe4, 0x61, 0x4b, 0xc8, 0xb5, 0x44, 0x8a, 0x66, 0x11, 0xd3, 0x04, 0xce,
+	0x7b, 0xa8, 0x67, 0x05, 0x46, 0xb6, 0x35, 0xca, 0xec, 0xbd, 0xcc, 0x75, 0xcd, 0xab, 0xc4, 0x23,
+	0x10, 0x3a, 0x40, 0x66, 0x15, 0x46, 0x1e, 0x47, 0x28, 0xa5, 0xca, 0x2b, 0x19, 0xea, 0x33, 0x6c,
+	0x14, 0x48, 0x87, 0x3c, 0x49, 0xb0, 0xee, 0x32, 0x5a, 0x17, 0x9a, 0x65, 0x54, 0x27, 0xbb, 0x11,
+	0xe4, 0x0d, 0x42, 0x30, 0xf5, 0x81, 0x3f, 0x8e, 0x02, 0x3e, 0x11, 0x78, 0x47, 0xf0, 0xe0, 0x04,
+	0xa9, 0x23, 0x38, 0x9d, 0x1f, 0xb6, 0xe8, 0x6c, 0xb9, 0xe4, 0xb7, 0xb0, 0x95, 0x24, 0x67, 0xc7,
+	0x2b, 0x6a, 0x3f, 0x9f, 0xfe, 0x0d, 0x76, 0x6e, 0x54, 0x15, 0x79, 0x15, 0x0d, 0x75, 0x5b, 0xf1,
+	0xe5, 0x2b, 0xb4, 0x60, 0x31, 0x56, 0x91, 0xe8, 0x48, 0x51, 0x20, 0xcd, 0x7e, 0x33, 0x4d, 0x77,
+	0x11, 0xff, 0x06, 0x56, 0x32, 0x22, 0x21, 0xcd, 0x28, 0xa9, 0x48, 0x37, 0xf9, 0xcc, 0xd7, 0xb0,
+	0xd2, 0x1e, 0xa0, 0x73, 0x79, 0xab, 0x72, 0xba, 0xbd, 0x0f, 0x0b, 0x17, 0x35, 0xf9, 0xb7, 0xf9,
+	0x07, 0xb1, 0xa3, 0xce, 0x8c, 0x9c, 0x06, 0x00, 0x00,
 }
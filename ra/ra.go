@@ -10,17 +10,19 @@ import (
 	"net/url"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/jmhodges/clock"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/weppos/publicsuffix-go/publicsuffix"
 	"golang.org/x/net/context"
 
 	"github.com/letsencrypt/boulder/bdns"
 	caPB "github.com/letsencrypt/boulder/ca/proto"
+	"github.com/letsencrypt/boulder/canceled"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	csrlib "github.com/letsencrypt/boulder/csr"
@@ -29,9 +31,11 @@ import (
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/identifier"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/psl"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
 	"github.com/letsencrypt/boulder/ratelimit"
 	"github.com/letsencrypt/boulder/reloader"
@@ -67,6 +71,44 @@ type RegistrationAuthorityImpl struct {
 	PA        core.PolicyAuthority
 	publisher core.Publisher
 	caa       caaChecker
+	// ShortLivedAccounts contains the IDs of accounts that are issued
+	// short-lived certificates without an OCSP URL.
+	ShortLivedAccounts map[int64]bool
+	// ValidationMethods restricts the challenge types that can be used to
+	// validate some names.
+	ValidationMethods ValidationMethodPolicy
+	// ValidAuthzReuseWindow and PendingAuthzReuseWindow are how long an
+	// existing valid or pending authorization must have left before it expires
+	// to be reused for a new authorization or order. If unset the defaults
+	// described in authzReuseCutoff are used.
+	ValidAuthzReuseWindow   time.Duration
+	PendingAuthzReuseWindow time.Duration
+	// RateLimitCounter, if set, counts the RegistrationsPerIP,
+	// RegistrationsPerIPRange and NewOrdersPerAccount limits in windows shared
+	// with other RA instances instead of in the database. Registrations and
+	// orders are reserved in it when their limits are checked, and released
+	// if they then fail to be stored. If it fails the database is used.
+	RateLimitCounter ratelimit.Counter
+	// SCTDeadline is how long to wait for SCTs for a precertificate before
+	// issuing the certificate without embedded SCTs, when the CTContingency
+	// feature is enabled. If unset there is no deadline beyond the request's.
+	SCTDeadline time.Duration
+	// EnforceCTPolicy, if set, refuses to issue a certificate unless SCTs for
+	// its precertificate were obtained from as many log groups as the CT
+	// policy requires. It requires the EmbedSCTs feature, so that the SCTs are
+	// checked before the certificate is signed, and takes precedence over the
+	// CTContingency feature.
+	EnforceCTPolicy bool
+	// RenewalExemptionWindow, if set, limits the exemption of renewals from the
+	// CertificatesPerName limit to requests whose exact set of names was issued
+	// to the same account within the window. If unset a previous issuance of
+	// the names to any account at any time is enough.
+	RenewalExemptionWindow time.Duration
+	// MaxNames is the maximum number of names a certificate or order may
+	// contain. Zero means no limit. It can be overridden per registration with
+	// ratelimit.MaxNamesPerCertificate overrides, see
+	// RefreshRateLimitOverridesForever.
+	MaxNames int
 
 	stats     metrics.Scope
 	DNSClient bdns.DNSClient
@@ -82,10 +124,13 @@ type RegistrationAuthorityImpl struct {
 	totalIssuedCount      int
 	totalIssuedLastUpdate time.Time
 	maxContactsPerReg     int
-	maxNames              int
-	forceCNFromSAN        bool
+	csrPolicy             csrlib.Policy
 	reuseValidAuthz       bool
 	orderLifetime         time.Duration
+	// mnMu protects maxNamesOverrides, the per-registration overrides of
+	// MaxNames.
+	mnMu              sync.RWMutex
+	maxNamesOverrides map[int64]int
 
 	regByIPStats           metrics.Scope
 	regByIPRangeStats      metrics.Scope
@@ -97,6 +142,19 @@ type RegistrationAuthorityImpl struct {
 
 	ctpolicy        *ctpolicy.CTPolicy
 	ctpolicyResults *prometheus.HistogramVec
+	issuanceLatency *prometheus.HistogramVec
+	// orderIssuanceLatency is the time from the creation of an order to the
+	// storage of its certificate, observed when the order is finalized.
+	orderIssuanceLatency *prometheus.HistogramVec
+
+	// finalizeQueue holds orders waiting for a finalize worker when the
+	// AsyncFinalize feature is enabled. It is nil until StartFinalizeWorkers
+	// is called. It is only held in memory, so orders in it when the RA stops
+	// are left processing until the SA's FailStaleProcessingOrders fails
+	// them.
+	finalizeQueue   chan finalizeJob
+	finalizeWG      sync.WaitGroup
+	finalizeTimeout time.Duration
 }
 
 // NewRegistrationAuthorityImpl constructs a new RA object.
@@ -106,8 +164,7 @@ func NewRegistrationAuthorityImpl(
 	stats metrics.Scope,
 	maxContactsPerReg int,
 	keyPolicy goodkey.KeyPolicy,
-	maxNames int,
-	forceCNFromSAN bool,
+	csrPolicy csrlib.Policy,
 	reuseValidAuthz bool,
 	authorizationLifetime time.Duration,
 	pendingAuthorizationLifetime time.Duration,
@@ -126,6 +183,26 @@ func NewRegistrationAuthorityImpl(
 	)
 	stats.MustRegister(ctpolicyResults)
 
+	issuanceLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "certificate_issuance_latency",
+			Help:    "Histogram of seconds taken to handle certificate requests, from CSR checks to SCTs, by result",
+			Buckets: []float64{.1, .25, .5, 1, 2.5, 5, 7.5, 10, 15, 30, 45, 60},
+		},
+		[]string{"result"},
+	)
+	stats.MustRegister(issuanceLatency)
+
+	orderIssuanceLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "order_issuance_latency",
+			Help:    "Histogram of seconds from order creation to certificate storage, by certificate profile and number of names",
+			Buckets: []float64{1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800, 3600, 21600, 86400, 604800},
+		},
+		[]string{"profile", "names"},
+	)
+	stats.MustRegister(orderIssuanceLatency)
+
 	ra := &RegistrationAuthorityImpl{
 		stats: stats,
 		clk:   clk,
@@ -136,8 +213,7 @@ func NewRegistrationAuthorityImpl(
 		tiMu:                         new(sync.RWMutex),
 		maxContactsPerReg:            maxContactsPerReg,
 		keyPolicy:                    keyPolicy,
-		maxNames:                     maxNames,
-		forceCNFromSAN:               forceCNFromSAN,
+		csrPolicy:                    csrPolicy,
 		reuseValidAuthz:              reuseValidAuthz,
 		regByIPStats:                 stats.NewScope("RateLimit", "RegistrationsByIP"),
 		regByIPRangeStats:            stats.NewScope("RateLimit", "RegistrationsByIPRange"),
@@ -151,6 +227,8 @@ func NewRegistrationAuthorityImpl(
 		orderLifetime:                orderLifetime,
 		ctpolicy:                     ctp,
 		ctpolicyResults:              ctpolicyResults,
+		issuanceLatency:              issuanceLatency,
+		orderIssuanceLatency:         orderIssuanceLatency,
 	}
 	return ra
 }
@@ -207,6 +285,90 @@ func (ra *RegistrationAuthorityImpl) updateIssuedCount() error {
 	return nil
 }
 
+// RefreshRateLimitOverridesForever periodically loads the per-registration
+// rate limit overrides stored by the SA, applying them on top of those in the
+// rate limit policy file. It will run one refresh before returning, and return
+// an error if that refresh failed. Overrides that expire or are removed remain
+// in effect until the next refresh.
+func (ra *RegistrationAuthorityImpl) RefreshRateLimitOverridesForever(interval time.Duration) error {
+	if err := ra.refreshRateLimitOverrides(); err != nil {
+		return err
+	}
+	go func() {
+		for {
+			time.Sleep(interval)
+			_ = ra.refreshRateLimitOverrides()
+		}
+	}()
+	return nil
+}
+
+func (ra *RegistrationAuthorityImpl) refreshRateLimitOverrides() error {
+	// We don't have a Context here, so use the background context. Note that a
+	// timeout is still imposed by our RPC layer.
+	resp, err := ra.SA.GetRateLimitOverrides(context.Background(), &corepb.Empty{})
+	if err != nil {
+		ra.log.AuditErr(fmt.Sprintf("refreshing rate limit overrides: %s", err))
+		return err
+	}
+	overrides := make(ratelimit.RegistrationOverrides)
+	maxNamesOverrides := make(map[int64]int)
+	for _, o := range resp.Overrides {
+		name := o.GetLimitName()
+		if name == ratelimit.MaxNamesPerCertificate {
+			// A threshold below one would lift the limit rather than lower it
+			if o.GetThreshold() < 1 {
+				ra.log.Warning(fmt.Sprintf("ignoring %s override below 1 for registration ID %d",
+					name, o.GetRegistrationID()))
+				continue
+			}
+			maxNamesOverrides[o.GetRegistrationID()] = int(o.GetThreshold())
+			continue
+		}
+		if !ratelimit.ValidLimitName(name) {
+			ra.log.Warning(fmt.Sprintf("ignoring override of unknown rate limit %q for registration ID %d",
+				name, o.GetRegistrationID()))
+			continue
+		}
+		if overrides[name] == nil {
+			overrides[name] = make(map[int64]int)
+		}
+		overrides[name][o.GetRegistrationID()] = int(o.GetThreshold())
+	}
+	if err := ra.rlPolicies.SetRegistrationOverrides(overrides); err != nil {
+		ra.log.AuditErr(fmt.Sprintf("applying rate limit overrides: %s", err))
+		return err
+	}
+	ra.mnMu.Lock()
+	ra.maxNamesOverrides = maxNamesOverrides
+	ra.mnMu.Unlock()
+	ra.log.Info(fmt.Sprintf("loaded %d rate limit overrides", len(resp.Overrides)))
+	return nil
+}
+
+// maxNamesFor returns the maximum number of names a certificate or order for
+// regID may contain, or zero if there is no limit.
+func (ra *RegistrationAuthorityImpl) maxNamesFor(regID int64) int {
+	ra.mnMu.RLock()
+	defer ra.mnMu.RUnlock()
+	if max, ok := ra.maxNamesOverrides[regID]; ok {
+		return max
+	}
+	return ra.MaxNames
+}
+
+// checkMaxNames returns a malformed error, stating the limit that applies, if
+// names has more than the maximum number of names for regID.
+func (ra *RegistrationAuthorityImpl) checkMaxNames(names []string, regID int64) error {
+	max := ra.maxNamesFor(regID)
+	if max > 0 && len(names) > max {
+		return berrors.MalformedError(
+			"too many names: %d requested, this account may request at most %d names per certificate",
+			len(names), max)
+	}
+	return nil
+}
+
 var (
 	unparseableEmailError = berrors.InvalidEmailError("not a valid e-mail address")
 	emptyDNSResponseError = berrors.InvalidEmailError(
@@ -277,6 +439,11 @@ type certificateRequestEvent struct {
 	RequestTime    time.Time `json:",omitempty"`
 	ResponseTime   time.Time `json:",omitempty"`
 	Error          string    `json:",omitempty"`
+	ShortLived     bool      `json:",omitempty"`
+	CTContingency  bool      `json:",omitempty"`
+	// ValidationMethods maps each name in an order to the challenge type its
+	// authorization was validated with.
+	ValidationMethods map[string]string `json:",omitempty"`
 }
 
 // noRegistrationID is used for the regID parameter to GetThreshold when no
@@ -287,77 +454,163 @@ const noRegistrationID = -1
 // ra.SA.CountRegistrationsByIP or ra.SA.CountRegistrationsByIPRange
 type registrationCounter func(context.Context, net.IP, time.Time, time.Time) (int, error)
 
+// countSharedLimit returns the number of events counted for key against the
+// limit called name by RateLimitCounter. If there is no RateLimitCounter or it
+// fails, ok is false and the caller should count in the database instead.
+func (ra *RegistrationAuthorityImpl) countSharedLimit(ctx context.Context, name, key string, limit ratelimit.RateLimitPolicy) (count int, ok bool) {
+	if ra.RateLimitCounter == nil {
+		return 0, false
+	}
+	count, err := ra.RateLimitCounter.Count(ctx, name+":"+key, limit.Window.Duration, ra.clk.Now())
+	if err != nil {
+		ra.log.Warning(fmt.Sprintf("Shared rate limit counter failed for %s, counting in the database: %s", name, err))
+		return 0, false
+	}
+	return count, true
+}
+
+// sharedLimitReservation is an event reserved against a limit with
+// RateLimitCounter, which is released if the operation it was reserved for
+// fails.
+type sharedLimitReservation struct {
+	key string
+	id  string
+}
+
+// reserveSharedLimit reserves an event for key against the limit called name
+// with RateLimitCounter, unless threshold events are already counted, in which
+// case the returned reservation is nil. If there is no RateLimitCounter or it
+// fails, ok is false and the caller should count in the database instead.
+func (ra *RegistrationAuthorityImpl) reserveSharedLimit(ctx context.Context, name, key string, limit ratelimit.RateLimitPolicy, threshold int) (res *sharedLimitReservation, ok bool) {
+	if ra.RateLimitCounter == nil {
+		return nil, false
+	}
+	key = name + ":" + key
+	id, err := ra.RateLimitCounter.Reserve(ctx, key, limit.Window.Duration, ra.clk.Now(), threshold)
+	if err != nil {
+		ra.log.Warning(fmt.Sprintf("Shared rate limit counter failed for %s, counting in the database: %s", name, err))
+		return nil, false
+	}
+	if id == "" {
+		return nil, true
+	}
+	return &sharedLimitReservation{key: key, id: id}, true
+}
+
+// releaseSharedLimits releases reservations made by reserveSharedLimit for an
+// operation that failed. Nil reservations are skipped.
+func (ra *RegistrationAuthorityImpl) releaseSharedLimits(reservations ...*sharedLimitReservation) {
+	for _, res := range reservations {
+		if res == nil {
+			continue
+		}
+		// The operation may have failed because the request's context is
+		// done, so the release isn't bound by it.
+		err := ra.RateLimitCounter.Release(context.Background(), res.key, res.id)
+		if err != nil {
+			ra.log.Warning(fmt.Sprintf("Shared rate limit counter failed to release %s: %s", res.key, err))
+		}
+	}
+}
+
+// registrationIPRange returns the range of addresses that the
+// RegistrationsPerIPRange limit counts ip in. Only IPv6 addresses are limited
+// by range, so it returns nil for IPv4 addresses.
+func registrationIPRange(ip net.IP) *net.IPNet {
+	// Per https://golang.org/pkg/net/#IP.To4 "If ip is not an IPv4 address, To4
+	// returns nil"
+	if ip.To4() != nil {
+		return nil
+	}
+	return &net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}
+}
+
 // checkRegistrationIPLimit checks a specific registraton limit by using the
 // provided registrationCounter function to determine if the limit has been
-// exceeded for a given IP or IP range
+// exceeded for a given IP or IP range. If the limit is counted by
+// RateLimitCounter the registration is reserved in it, and the reservation is
+// returned.
 func (ra *RegistrationAuthorityImpl) checkRegistrationIPLimit(
 	ctx context.Context,
 	limit ratelimit.RateLimitPolicy,
+	limitName string,
+	sharedKey string,
 	ip net.IP,
-	counter registrationCounter) error {
+	counter registrationCounter) (*sharedLimitReservation, error) {
 
 	if !limit.Enabled() {
-		return nil
+		return nil, nil
+	}
+
+	threshold := limit.GetThreshold(ip.String(), noRegistrationID)
+	if res, ok := ra.reserveSharedLimit(ctx, limitName, sharedKey, limit, threshold); ok {
+		if res == nil {
+			return nil, berrors.RateLimitError("too many registrations for this IP")
+		}
+		return res, nil
 	}
 
 	now := ra.clk.Now()
 	windowBegin := limit.WindowBegin(now)
 	count, err := counter(ctx, ip, windowBegin, now)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if count >= limit.GetThreshold(ip.String(), noRegistrationID) {
-		return berrors.RateLimitError("too many registrations for this IP")
+	if count >= threshold {
+		return nil, berrors.RateLimitError("too many registrations for this IP")
 	}
 
-	return nil
+	return nil, nil
 }
 
 // checkRegistrationLimits enforces the RegistrationsPerIP and
-// RegistrationsPerIPRange limits
-func (ra *RegistrationAuthorityImpl) checkRegistrationLimits(ctx context.Context, ip net.IP) error {
+// RegistrationsPerIPRange limits. It returns the registration's reservations
+// against the limits counted by RateLimitCounter, which the caller must
+// release if the registration isn't stored.
+func (ra *RegistrationAuthorityImpl) checkRegistrationLimits(ctx context.Context, ip net.IP) ([]*sharedLimitReservation, error) {
 	// Check the registrations per IP limit using the CountRegistrationsByIP SA
 	// function that matches IP addresses exactly
 	exactRegLimit := ra.rlPolicies.RegistrationsPerIP()
-	err := ra.checkRegistrationIPLimit(ctx, exactRegLimit, ip, ra.SA.CountRegistrationsByIP)
+	exactRes, err := ra.checkRegistrationIPLimit(ctx, exactRegLimit, "registrationsPerIP", ip.String(), ip, ra.SA.CountRegistrationsByIP)
 	if err != nil {
 		ra.regByIPStats.Inc("Exceeded", 1)
 		ra.log.Info(fmt.Sprintf("Rate limit exceeded, RegistrationsByIP, IP: %s", ip))
-		return err
+		return nil, err
 	}
 	ra.regByIPStats.Inc("Pass", 1)
 
 	// We only apply the fuzzy reg limit to IPv6 addresses.
-	// Per https://golang.org/pkg/net/#IP.To4 "If ip is not an IPv4 address, To4
-	// returns nil"
-	if ip.To4() != nil {
-		return nil
+	ipRange := registrationIPRange(ip)
+	if ipRange == nil {
+		return []*sharedLimitReservation{exactRes}, nil
 	}
 
 	// Check the registrations per IP range limit using the
 	// CountRegistrationsByIPRange SA function that fuzzy-matches IPv6 addresses
 	// within a larger address range
 	fuzzyRegLimit := ra.rlPolicies.RegistrationsPerIPRange()
-	err = ra.checkRegistrationIPLimit(ctx, fuzzyRegLimit, ip, ra.SA.CountRegistrationsByIPRange)
+	fuzzyRes, err := ra.checkRegistrationIPLimit(ctx, fuzzyRegLimit, "registrationsPerIPRange", ipRange.String(), ip, ra.SA.CountRegistrationsByIPRange)
 	if err != nil {
+		ra.releaseSharedLimits(exactRes)
 		ra.regByIPRangeStats.Inc("Exceeded", 1)
 		ra.log.Info(fmt.Sprintf("Rate limit exceeded, RegistrationsByIPRange, IP: %s", ip))
 		// For the fuzzyRegLimit we use a new error message that specifically
 		// mentions that the limit being exceeded is applied to a *range* of IPs
-		return berrors.RateLimitError("too many registrations for this IP range")
+		return nil, berrors.RateLimitError("too many registrations for this IP range")
 	}
 	ra.regByIPRangeStats.Inc("Pass", 1)
 
-	return nil
+	return []*sharedLimitReservation{exactRes, fuzzyRes}, nil
 }
 
 // NewRegistration constructs a new Registration from a request.
 func (ra *RegistrationAuthorityImpl) NewRegistration(ctx context.Context, init core.Registration) (core.Registration, error) {
-	if err := ra.keyPolicy.GoodKey(init.Key.Key); err != nil {
-		return core.Registration{}, berrors.MalformedError("invalid public key: %s", err.Error())
+	if err := ra.keyPolicy.GoodKey(ctx, init.Key.Key); err != nil {
+		return core.Registration{}, keyPolicyError(err)
 	}
-	if err := ra.checkRegistrationLimits(ctx, init.InitialIP); err != nil {
+	reservations, err := ra.checkRegistrationLimits(ctx, init.InitialIP)
+	if err != nil {
 		return core.Registration{}, err
 	}
 
@@ -372,12 +625,14 @@ func (ra *RegistrationAuthorityImpl) NewRegistration(ctx context.Context, init c
 	reg.InitialIP = init.InitialIP
 
 	if err := ra.validateContacts(ctx, reg.Contact); err != nil {
+		ra.releaseSharedLimits(reservations...)
 		return core.Registration{}, err
 	}
 
 	// Store the authorization object, then return it
-	reg, err := ra.SA.NewRegistration(ctx, reg)
+	reg, err = ra.SA.NewRegistration(ctx, reg)
 	if err != nil {
+		ra.releaseSharedLimits(reservations...)
 		return core.Registration{}, err
 	}
 
@@ -486,28 +741,74 @@ func (ra *RegistrationAuthorityImpl) checkInvalidAuthorizationLimit(ctx context.
 	return nil
 }
 
+// checkFailedValidationsLimit enforces the rlPolicies
+// `FailedValidationsPerAccount` rate limit. Unlike the invalid authorizations
+// limit, which counts authorizations by when they expire, this counts failed
+// validations of hostname by when they failed, so that a client stuck retrying
+// a validation that can't succeed is stopped from creating new authorizations
+// until the window has passed.
+func (ra *RegistrationAuthorityImpl) checkFailedValidationsLimit(ctx context.Context, regID int64, hostname string) error {
+	limit := ra.rlPolicies.FailedValidationsPerAccount()
+	if !limit.Enabled() {
+		return nil
+	}
+	latest := ra.clk.Now()
+	earliest := limit.WindowBegin(latest)
+	latestNanos := latest.UnixNano()
+	earliestNanos := earliest.UnixNano()
+	count, err := ra.SA.CountFailedValidations(ctx, &sapb.CountFailedValidationsRequest{
+		RegistrationID: &regID,
+		Hostname:       &hostname,
+		Range: &sapb.Range{
+			Earliest: &earliestNanos,
+			Latest:   &latestNanos,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	// There is no meaningful override key to use for this rate limit
+	noKey := ""
+	if *count.Count >= int64(limit.GetThreshold(noKey, regID)) {
+		ra.log.Info(fmt.Sprintf("Rate limit exceeded, FailedValidationsByRegID, regID: %d, hostname: %s", regID, hostname))
+		return berrors.RateLimitError("too many failed validations of this hostname recently")
+	}
+	return nil
+}
+
 // checkNewOrdersPerAccountLimit enforces the rlPolicies `NewOrdersPerAccount`
 // rate limit. This rate limit ensures a client can not create more than the
-// specified threshold of new orders within the specified time window.
-func (ra *RegistrationAuthorityImpl) checkNewOrdersPerAccountLimit(ctx context.Context, acctID int64) error {
+// specified threshold of new orders within the specified time window. If the
+// limit is counted by RateLimitCounter the new order is reserved in it, and
+// the caller must release the returned reservation if the order isn't stored.
+func (ra *RegistrationAuthorityImpl) checkNewOrdersPerAccountLimit(ctx context.Context, acctID int64) (*sharedLimitReservation, error) {
 	limit := ra.rlPolicies.NewOrdersPerAccount()
 	if !limit.Enabled() {
-		return nil
+		return nil, nil
+	}
+	// There is no meaningful override key to use for this rate limit
+	noKey := ""
+	threshold := limit.GetThreshold(noKey, acctID)
+	if res, ok := ra.reserveSharedLimit(ctx, "newOrdersPerAccount", strconv.FormatInt(acctID, 10), limit, threshold); ok {
+		if res == nil {
+			ra.newOrderByRegIDStats.Inc("Exceeded", 1)
+			return nil, berrors.RateLimitError("too many new orders recently")
+		}
+		ra.newOrderByRegIDStats.Inc("Pass", 1)
+		return res, nil
 	}
 	latest := ra.clk.Now()
 	earliest := latest.Add(-limit.Window.Duration)
 	count, err := ra.SA.CountOrders(ctx, acctID, earliest, latest)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// There is no meaningful override key to use for this rate limit
-	noKey := ""
-	if count >= limit.GetThreshold(noKey, acctID) {
+	if count >= threshold {
 		ra.newOrderByRegIDStats.Inc("Exceeded", 1)
-		return berrors.RateLimitError("too many new orders recently")
+		return nil, berrors.RateLimitError("too many new orders recently")
 	}
 	ra.newOrderByRegIDStats.Inc("Pass", 1)
-	return nil
+	return nil, nil
 }
 
 // NewAuthorization constructs a new Authz from a request. Values (domains) in
@@ -517,7 +818,7 @@ func (ra *RegistrationAuthorityImpl) NewAuthorization(ctx context.Context, reque
 	identifier.Value = strings.ToLower(identifier.Value)
 
 	// Check that the identifier is present and appropriate
-	if err := ra.PA.WillingToIssue(identifier); err != nil {
+	if err := ra.PA.WillingToIssue(ctx, identifier, regID); err != nil {
 		return core.Authorization{}, err
 	}
 
@@ -529,6 +830,10 @@ func (ra *RegistrationAuthorityImpl) NewAuthorization(ctx context.Context, reque
 		return core.Authorization{}, err
 	}
 
+	if err := ra.checkFailedValidationsLimit(ctx, regID, identifier.Value); err != nil {
+		return core.Authorization{}, err
+	}
+
 	if ra.reuseValidAuthz {
 		auths, err := ra.SA.GetValidAuthorizations(ctx, regID, []string{identifier.Value}, ra.clk.Now())
 		if err != nil {
@@ -556,9 +861,9 @@ func (ra *RegistrationAuthorityImpl) NewAuthorization(ctx context.Context, reque
 				return core.Authorization{}, outErr
 			}
 			if ra.authzValidChallengeEnabled(&populatedAuthz) {
-				// The existing authorization must not expire within the next 24 hours for
-				// it to be OK for reuse
-				reuseCutOff := ra.clk.Now().Add(time.Hour * 24)
+				// The existing authorization must not expire within the reuse window
+				// for it to be OK for reuse
+				reuseCutOff := ra.authzReuseCutoff(core.StatusValid, false)
 				if populatedAuthz.Expires.After(reuseCutOff) {
 					ra.stats.Inc("ReusedValidAuthz", 1)
 					return populatedAuthz, nil
@@ -567,7 +872,7 @@ func (ra *RegistrationAuthorityImpl) NewAuthorization(ctx context.Context, reque
 		}
 	}
 	if features.Enabled(features.ReusePendingAuthz) {
-		nowishNano := ra.clk.Now().Add(time.Hour).UnixNano()
+		nowishNano := ra.authzReuseCutoff(core.StatusPending, false).UnixNano()
 		identifierTypeString := string(identifier.Type)
 		pendingAuth, err := ra.SA.GetPendingAuthorization(ctx, &sapb.GetPendingAuthorizationRequest{
 			RegistrationID:  &regID,
@@ -582,6 +887,7 @@ func (ra *RegistrationAuthorityImpl) NewAuthorization(ctx context.Context, reque
 				identifier.Value,
 				err)
 		} else if err == nil {
+			ra.stats.Inc("ReusedPendingAuthz", 1)
 			return *pendingAuth, nil
 		}
 		// Fall through to normal creation flow.
@@ -628,7 +934,7 @@ func (ra *RegistrationAuthorityImpl) MatchesCSR(parsedCertificate *x509.Certific
 	if !core.KeyDigestEquals(parsedCertificate.PublicKey, csr.PublicKey) {
 		return berrors.InternalServerError("generated certificate public key doesn't match CSR public key")
 	}
-	if !ra.forceCNFromSAN && len(csr.Subject.CommonName) > 0 &&
+	if ra.csrPolicy.CommonName != csrlib.CNPromote && len(csr.Subject.CommonName) > 0 &&
 		parsedCertificate.Subject.CommonName != strings.ToLower(csr.Subject.CommonName) {
 		return berrors.InternalServerError("generated certificate CommonName doesn't match CSR CommonName")
 	}
@@ -670,13 +976,16 @@ func (ra *RegistrationAuthorityImpl) MatchesCSR(parsedCertificate *x509.Certific
 
 // checkOrderAuthorizations verifies that a provided set of names associated
 // with a specific order and account has all of the required valid, unexpired
-// authorizations to proceed with issuance. It is the ACME v2 equivalent of
+// authorizations, validated with challenge types permitted by the validation
+// method policy, to proceed with issuance. The challenge type used for each
+// name is recorded in logEvent. It is the ACME v2 equivalent of
 // `checkAuthorizations`.
 func (ra *RegistrationAuthorityImpl) checkOrderAuthorizations(
 	ctx context.Context,
 	names []string,
 	acctID accountID,
-	orderID orderID) error {
+	orderID orderID,
+	logEvent *certificateRequestEvent) error {
 	acctIDInt := int64(acctID)
 	orderIDInt := int64(orderID)
 	// Get all of the valid authorizations for this account/order
@@ -692,7 +1001,41 @@ func (ra *RegistrationAuthorityImpl) checkOrderAuthorizations(
 	// Ensure the names from the CSR are free of duplicates & lowercased.
 	names = core.UniqueLowerNames(names)
 	// Check the authorizations to ensure validity for the names required.
-	return ra.checkAuthorizationsCAA(ctx, names, authzs, acctIDInt, ra.clk.Now())
+	if err := ra.checkAuthorizationsCAA(ctx, names, authzs, acctIDInt, ra.clk.Now()); err != nil {
+		return err
+	}
+	return ra.checkValidationMethods(names, authzs, logEvent)
+}
+
+// checkValidationMethods checks that the authorization for each name was
+// validated with a challenge type permitted by the validation method policy,
+// recording the challenge types in logEvent.
+func (ra *RegistrationAuthorityImpl) checkValidationMethods(
+	names []string,
+	authzs map[string]*core.Authorization,
+	logEvent *certificateRequestEvent) error {
+	logEvent.ValidationMethods = make(map[string]string, len(names))
+	var badNames []string
+	for _, name := range names {
+		var challType string
+		for _, chall := range authzs[name].Challenges {
+			if chall.Status == core.StatusValid {
+				challType = chall.Type
+				break
+			}
+		}
+		logEvent.ValidationMethods[name] = challType
+		if !ra.ValidationMethods.permitted(name, challType) {
+			badNames = append(badNames, name)
+		}
+	}
+	if len(badNames) > 0 {
+		return berrors.UnauthorizedError(
+			"authorizations for these names were not validated with a method permitted by policy: %s",
+			strings.Join(badNames, ", "),
+		)
+	}
+	return nil
 }
 
 // checkAuthorizations checks that each requested name has a valid authorization
@@ -752,10 +1095,22 @@ func (ra *RegistrationAuthorityImpl) checkAuthorizationsCAA(
 	}
 
 	if len(badNames) > 0 {
-		return berrors.UnauthorizedError(
-			"authorizations for these names not found or expired: %s",
-			strings.Join(badNames, ", "),
-		)
+		err := &berrors.BoulderError{
+			Type: berrors.Unauthorized,
+			Detail: fmt.Sprintf("authorizations for these names not found or expired: %s",
+				strings.Join(badNames, ", ")),
+		}
+		subErrs := make([]berrors.SubBoulderError, len(badNames))
+		for i, name := range badNames {
+			subErrs[i] = berrors.SubBoulderError{
+				BoulderError: &berrors.BoulderError{
+					Type:   berrors.Unauthorized,
+					Detail: "authorization not found or expired",
+				},
+				Identifier: identifier.DNSIdentifier(name),
+			}
+		}
+		return err.WithSubErrors(subErrs)
 	}
 
 	return nil
@@ -809,6 +1164,30 @@ func (ra *RegistrationAuthorityImpl) recheckCAA(ctx context.Context, names []str
 	return nil
 }
 
+// policyErrorForNames combines the errors returned by the PA for the names of
+// an order. A single error is returned as it is. Several are summarized by an
+// error with each of them as a sub-error: a RejectedIdentifier error if any
+// of the names is forbidden by policy, or else a Malformed error.
+func policyErrorForNames(subErrs []berrors.SubBoulderError) error {
+	switch len(subErrs) {
+	case 0:
+		return nil
+	case 1:
+		return subErrs[0].BoulderError
+	}
+	errType := berrors.Malformed
+	for _, subErr := range subErrs {
+		if subErr.Type == berrors.RejectedIdentifier {
+			errType = berrors.RejectedIdentifier
+		}
+	}
+	err := &berrors.BoulderError{
+		Type:   errType,
+		Detail: fmt.Sprintf("Cannot issue for %d names, see subproblems for details", len(subErrs)),
+	}
+	return err.WithSubErrors(subErrs)
+}
+
 // failOrder marks an order as failed by setting the problem details field of
 // the order & persisting it through the SA. If an error occurs doing this we
 // log it and return the order as-is. There aren't any alternatives if we can't
@@ -861,8 +1240,11 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 		return nil, err
 	}
 
-	if err := csrlib.VerifyCSR(csrOb, ra.maxNames, &ra.keyPolicy, ra.PA, ra.forceCNFromSAN, *req.Order.RegistrationID); err != nil {
-		return nil, berrors.MalformedError(err.Error())
+	if err := csrlib.VerifyCSR(ctx, csrOb, ra.csrPolicy, &ra.keyPolicy, ra.PA, *req.Order.RegistrationID); err != nil {
+		return nil, csrError(err)
+	}
+	if err := ra.checkMaxNames(csrOb.DNSNames, *req.Order.RegistrationID); err != nil {
+		return nil, err
 	}
 
 	// Dedupe, lowercase and sort both the names from the CSR and the names in the
@@ -882,9 +1264,7 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 		}
 	}
 
-	// Update the order to be status processing - we issue synchronously at the
-	// present time so this is somewhat artificial/unnecessary but allows planning
-	// for the future.
+	// Update the order to be status processing.
 	//
 	// NOTE(@cpu): After this point any errors that are encountered must update
 	// the state of the order to invalid by setting the order's error field.
@@ -898,10 +1278,37 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 		return nil, err
 	}
 
+	// With AsyncFinalize the order is handed to a finalize worker and returned
+	// in processing status. The worker gets its own copy of the order since
+	// this one is being returned to the caller.
+	if features.Enabled(features.AsyncFinalize) {
+		job := finalizeJob{
+			order:    proto.Clone(order).(*corepb.Order),
+			csrBytes: req.Csr,
+			csr:      csrOb,
+		}
+		if ra.enqueueFinalize(job) {
+			processingStatus := string(core.StatusProcessing)
+			order.Status = &processingStatus
+			return order, nil
+		}
+	}
+
+	return ra.issueCertificateForOrder(ctx, order, req.Csr, csrOb)
+}
+
+// issueCertificateForOrder issues a certificate for an order in processing
+// status and finalizes the order with its serial. If issuance fails the error
+// is recorded on the order, making it invalid.
+func (ra *RegistrationAuthorityImpl) issueCertificateForOrder(
+	ctx context.Context,
+	order *corepb.Order,
+	csrBytes []byte,
+	csrOb *x509.CertificateRequest) (*corepb.Order, error) {
 	// Attempt issuance for the order. If the order isn't fully authorized this
 	// will return an error.
 	issueReq := core.CertificateRequest{
-		Bytes: req.Csr,
+		Bytes: csrBytes,
 		CSR:   csrOb,
 	}
 	cert, err := ra.issueCertificate(ctx, issueReq, accountID(*order.RegistrationID), orderID(*order.Id))
@@ -934,6 +1341,7 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 		ra.failOrder(ctx, order, probs.ServerInternal("Error persisting finalized order"))
 		return nil, err
 	}
+	ra.observeOrderIssuanceLatency(order)
 
 	// Update the order status locally since the SA doesn't return the updated
 	// order itself after setting the status
@@ -942,11 +1350,120 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 	return order, nil
 }
 
+// observeOrderIssuanceLatency records the time between the creation of a
+// finalized order and now, when its certificate has been stored, in the
+// orderIssuanceLatency histogram. Everything needed is already on the order,
+// so finalization isn't slowed by further lookups.
+func (ra *RegistrationAuthorityImpl) observeOrderIssuanceLatency(order *corepb.Order) {
+	if order.Created == nil || order.RegistrationID == nil {
+		return
+	}
+	profile := "default"
+	if ra.ShortLivedAccounts[*order.RegistrationID] {
+		profile = "shortLived"
+	}
+	ra.orderIssuanceLatency.With(prometheus.Labels{
+		"profile": profile,
+		"names":   namesBucket(len(order.Names)),
+	}).Observe(ra.clk.Now().Sub(time.Unix(0, *order.Created)).Seconds())
+}
+
+// namesBucket returns the orderIssuanceLatency "names" label for a
+// certificate with n names, grouping them so the number of label values stays
+// small.
+func namesBucket(n int) string {
+	switch {
+	case n <= 1:
+		return "1"
+	case n <= 10:
+		return "2-10"
+	default:
+		return "11-100"
+	}
+}
+
+// finalizeJob is an order in processing status waiting for a finalize worker
+// to issue its certificate.
+type finalizeJob struct {
+	order    *corepb.Order
+	csrBytes []byte
+	csr      *x509.CertificateRequest
+}
+
+// StartFinalizeWorkers starts the workers that issue certificates for orders
+// finalized with the AsyncFinalize feature enabled. Up to queueSize orders
+// wait for a free worker, beyond that FinalizeOrder issues synchronously.
+// Issuance for each order is bounded by timeout.
+func (ra *RegistrationAuthorityImpl) StartFinalizeWorkers(workers, queueSize int, timeout time.Duration) {
+	ra.finalizeQueue = make(chan finalizeJob, queueSize)
+	ra.finalizeTimeout = timeout
+	for i := 0; i < workers; i++ {
+		ra.finalizeWG.Add(1)
+		go ra.finalizeWorker()
+	}
+}
+
+// StopFinalizeWorkers waits for the finalize workers to drain the queue and
+// exit. It must only be called once FinalizeOrder can no longer be called,
+// e.g. after the gRPC server has been gracefully stopped.
+func (ra *RegistrationAuthorityImpl) StopFinalizeWorkers() {
+	if ra.finalizeQueue == nil {
+		return
+	}
+	close(ra.finalizeQueue)
+	ra.finalizeWG.Wait()
+}
+
+// enqueueFinalize queues job for a finalize worker, returning false if the
+// workers aren't running or the queue is full.
+func (ra *RegistrationAuthorityImpl) enqueueFinalize(job finalizeJob) bool {
+	if ra.finalizeQueue == nil {
+		return false
+	}
+	select {
+	case ra.finalizeQueue <- job:
+		ra.stats.Inc("FinalizeQueued", 1)
+		return true
+	default:
+		ra.stats.Inc("FinalizeQueueFull", 1)
+		return false
+	}
+}
+
+func (ra *RegistrationAuthorityImpl) finalizeWorker() {
+	defer ra.finalizeWG.Done()
+	for job := range ra.finalizeQueue {
+		ra.finalizeAsync(job)
+	}
+}
+
+// finalizeAsync issues the certificate for a queued order. Failures are
+// recorded on the order for the client to find when it next polls.
+func (ra *RegistrationAuthorityImpl) finalizeAsync(job finalizeJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), ra.finalizeTimeout)
+	defer cancel()
+	_, err := ra.issueCertificateForOrder(ctx, job.order, job.csrBytes, job.csr)
+	if err == nil {
+		ra.stats.Inc("FinalizeAsync.Valid", 1)
+		return
+	}
+	ra.stats.Inc("FinalizeAsync.Invalid", 1)
+	ra.log.Warning(fmt.Sprintf("Finalizing order %d failed: %s", *job.order.Id, err))
+	if ctx.Err() != nil {
+		// The order error couldn't have been saved with the expired context, so
+		// try again with a fresh one rather than leaving the order processing.
+		ra.failOrder(context.Background(), job.order, probs.ServerInternal("Timed out finalizing order"))
+	}
+}
+
 // NewCertificate requests the issuance of a certificate.
 func (ra *RegistrationAuthorityImpl) NewCertificate(ctx context.Context, req core.CertificateRequest, regID int64) (core.Certificate, error) {
 	// Verify the CSR
-	if err := csrlib.VerifyCSR(req.CSR, ra.maxNames, &ra.keyPolicy, ra.PA, ra.forceCNFromSAN, regID); err != nil {
-		return core.Certificate{}, berrors.MalformedError(err.Error())
+	if err := csrlib.VerifyCSR(ctx, req.CSR, ra.csrPolicy, &ra.keyPolicy, ra.PA, regID); err != nil {
+		return core.Certificate{}, csrError(err)
+	}
+	if err := ra.checkMaxNames(req.CSR.DNSNames, regID); err != nil {
+		return core.Certificate{}, err
 	}
 	// NewCertificate provides an order ID of 0, indicating this is a classic ACME
 	// v1 issuance request from the new certificate endpoint that is not
@@ -982,6 +1499,7 @@ func (ra *RegistrationAuthorityImpl) issueCertificate(
 	} else {
 		result = "successful"
 	}
+	ra.issuanceLatency.With(prometheus.Labels{"result": result}).Observe(ra.clk.Since(logEvent.RequestTime).Seconds())
 	ra.log.AuditObject(fmt.Sprintf("Certificate request - %s", result), logEvent)
 	return cert, err
 }
@@ -1039,7 +1557,7 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 		// Otherwise, if the orderID is not 0 we need to follow the order based
 		// issuance process and check that this specific order is fully authorized
 		// and associated with the expected account ID
-		err = ra.checkOrderAuthorizations(ctx, names, acctID, oID)
+		err = ra.checkOrderAuthorizations(ctx, names, acctID, oID, logEvent)
 	}
 	if err != nil {
 		return emptyCert, err
@@ -1056,6 +1574,11 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 		RegistrationID: &acctIDInt,
 		OrderID:        &orderIDInt,
 	}
+	if ra.ShortLivedAccounts[acctIDInt] {
+		shortLived := true
+		issueReq.ShortLived = &shortLived
+		logEvent.ShortLived = true
+	}
 
 	var cert core.Certificate
 	if features.Enabled(features.EmbedSCTs) {
@@ -1064,22 +1587,66 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 			logEvent.Error = err.Error()
 			return emptyCert, err
 		}
-		scts, err := ra.getSCTs(ctx, precert.DER)
-		if err != nil {
-			logEvent.Error = err.Error()
-			return emptyCert, err
+		scts, err := ra.getSCTsForIssuance(ctx, precert.DER)
+		if err == nil {
+			err = ra.checkSCTQuorum(scts)
+			if err != nil {
+				logEvent.Error = err.Error()
+				return emptyCert, err
+			}
 		}
-		cert, err = ra.CA.IssueCertificateForPrecertificate(ctx, &caPB.IssueCertificateForPrecertificateRequest{
-			DER:            precert.DER,
-			SCTs:           scts,
-			RegistrationID: &acctIDInt,
-			OrderID:        &orderIDInt,
-		})
-		if err != nil {
+		if err != nil && features.Enabled(features.CTContingency) && !ra.EnforceCTPolicy && ctLogFailure(ctx, err) {
+			// Without SCT quorum the precertificate can't become a certificate
+			// that browsers accept, so issue a new certificate without
+			// embedded SCTs instead, if the CA's profile allows it.
+			precertSerial := "unknown"
+			if parsed, parseErr := x509.ParseCertificate(precert.DER); parseErr == nil {
+				precertSerial = core.SerialToString(parsed.SerialNumber)
+			}
+			ra.log.AuditErr(fmt.Sprintf("CT contingency: issuing without embedded SCTs for regID=[%d] orderID=[%d] names=[%s], abandoning precertificate serial=[%s]: %s",
+				acctIDInt, orderIDInt, strings.Join(names, ", "), precertSerial, err))
+			// The abandoned precertificate is stored like an orphaned
+			// certificate, so that it gets a certificateStatus row and the
+			// ocsp-updater keeps an OCSP response for its serial.
+			issued := ra.clk.Now()
+			_, err = ra.SA.AddCertificate(ctx, precert.DER, acctIDInt, nil, &issued)
+			if err != nil {
+				ra.log.AuditErr(fmt.Sprintf("CT contingency: failed to store abandoned precertificate serial=[%s]: %s", precertSerial, err))
+				logEvent.Error = err.Error()
+				return emptyCert, err
+			}
+			ctContingency := true
+			issueReq.CtContingency = &ctContingency
+			logEvent.CTContingency = true
+			cert, err = ra.CA.IssueCertificate(ctx, issueReq)
+			if err != nil {
+				logEvent.Error = err.Error()
+				return emptyCert, err
+			}
+		} else if err != nil {
 			logEvent.Error = err.Error()
+			if ra.EnforceCTPolicy {
+				return emptyCert, ctUnavailableError()
+			}
 			return emptyCert, err
+		} else {
+			cert, err = ra.CA.IssueCertificateForPrecertificate(ctx, &caPB.IssueCertificateForPrecertificateRequest{
+				DER:            precert.DER,
+				SCTs:           scts,
+				RegistrationID: &acctIDInt,
+				OrderID:        &orderIDInt,
+			})
+			if err != nil {
+				logEvent.Error = err.Error()
+				return emptyCert, err
+			}
 		}
 	} else {
+		if ra.EnforceCTPolicy {
+			// Without a precertificate the SCTs could only be checked after
+			// the certificate had been signed and stored.
+			return emptyCert, berrors.InternalServerError("the CT policy can only be enforced with the EmbedSCTs feature enabled")
+		}
 		cert, err = ra.CA.IssueCertificate(ctx, issueReq)
 		if err != nil {
 			logEvent.Error = err.Error()
@@ -1113,13 +1680,59 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	return cert, nil
 }
 
+// getSCTsForIssuance gets the SCTs to embed in the certificate for a
+// precertificate. When the CTContingency feature is enabled it gives up after
+// SCTDeadline so that issuance can continue without them.
+func (ra *RegistrationAuthorityImpl) getSCTsForIssuance(ctx context.Context, precert []byte) (core.SCTDERs, error) {
+	if features.Enabled(features.CTContingency) && ra.SCTDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ra.SCTDeadline)
+		defer cancel()
+	}
+	return ra.getSCTs(ctx, precert)
+}
+
+// ctLogFailure returns true if err, from getting SCTs for a request whose
+// context is ctx, was caused by the CT logs rather than by the request being
+// canceled or timing out.
+func ctLogFailure(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !canceled.Is(err)
+}
+
+// ctUnavailableError is returned in place of a certificate when the SCTs
+// required by the CT policy couldn't be obtained.
+func ctUnavailableError() error {
+	return berrors.CTUnavailableError("unable to obtain the SCTs required by the CT policy, the CT logs may be unavailable; try again later")
+}
+
+// checkSCTQuorum returns an error if EnforceCTPolicy is set and scts, as
+// returned by a successful getSCTs, come from fewer log groups than the CT
+// policy requires. That can only happen if no log groups are configured or
+// the publisher returns empty results, which would otherwise go unnoticed.
+func (ra *RegistrationAuthorityImpl) checkSCTQuorum(scts core.SCTDERs) error {
+	if !ra.EnforceCTPolicy {
+		return nil
+	}
+	required := ra.ctpolicy.RequiredGroups()
+	var valid int
+	for _, sct := range scts {
+		if len(sct) > 0 {
+			valid++
+		}
+	}
+	if required == 0 || valid < required {
+		ra.log.AuditErr(fmt.Sprintf("CT policy not met: got %d valid SCTs, %d log groups required", valid, required))
+		return ctUnavailableError()
+	}
+	return nil
+}
+
 func (ra *RegistrationAuthorityImpl) getSCTs(ctx context.Context, cert []byte) (core.SCTDERs, error) {
 	started := ra.clk.Now()
 	scts, err := ra.ctpolicy.GetSCTs(ctx, cert)
 	took := ra.clk.Since(started)
-	// The final cert has already been issued so actually return it to the
-	// user even if this fails since we aren't actually doing anything with
-	// the SCTs yet.
+	// Whether a failure stops the certificate being returned is up to the
+	// caller.
 	if err != nil {
 		state := "failure"
 		if err == context.DeadlineExceeded {
@@ -1139,10 +1752,10 @@ func (ra *RegistrationAuthorityImpl) getSCTs(ctx context.Context, cert []byte) (
 func domainsForRateLimiting(names []string) ([]string, error) {
 	var domains []string
 	for _, name := range names {
-		domain, err := publicsuffix.Domain(name)
+		domain, err := psl.Domain(name)
 		if err != nil {
 			// The only possible errors are:
-			// (1) publicsuffix.Domain is giving garbage values
+			// (1) psl.Domain is giving garbage values
 			// (2) the public suffix is the domain itself
 			// We assume 2 and do not include it in the result.
 			continue
@@ -1157,10 +1770,10 @@ func domainsForRateLimiting(names []string) ([]string, error) {
 func suffixesForRateLimiting(names []string) ([]string, error) {
 	var suffixMatches []string
 	for _, name := range names {
-		_, err := publicsuffix.Domain(name)
+		_, err := psl.Domain(name)
 		if err != nil {
 			// Like `domainsForRateLimiting`, the only possible errors here are:
-			// (1) publicsuffix.Domain is giving garbage values
+			// (1) psl.Domain is giving garbage values
 			// (2) the public suffix is the domain itself
 			// We assume 2 and collect it into the result
 			suffixMatches = append(suffixMatches, name)
@@ -1244,7 +1857,7 @@ func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.C
 		// check if there is already a existing certificate for
 		// the exact name set we are issuing for. If so bypass the
 		// the certificatesPerName limit.
-		exists, err := ra.SA.FQDNSetExists(ctx, names)
+		exists, err := ra.isRenewal(ctx, names, regID)
 		if err != nil {
 			return fmt.Errorf("checking renewal exemption for %q: %s", names, err)
 		}
@@ -1265,6 +1878,25 @@ func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.C
 	return nil
 }
 
+// isRenewal returns true if a certificate for exactly the set of names has
+// been issued before. If RenewalExemptionWindow is set the certificate must
+// have been issued to regID within the window.
+func (ra *RegistrationAuthorityImpl) isRenewal(ctx context.Context, names []string, regID int64) (bool, error) {
+	if ra.RenewalExemptionWindow <= 0 {
+		return ra.SA.FQDNSetExists(ctx, names)
+	}
+	earliest := ra.clk.Now().Add(-ra.RenewalExemptionWindow).UnixNano()
+	exists, err := ra.SA.FQDNSetIssuedForAccount(ctx, &sapb.FQDNSetIssuedForAccountRequest{
+		Domains:        names,
+		RegistrationID: &regID,
+		Earliest:       &earliest,
+	})
+	if err != nil {
+		return false, err
+	}
+	return *exists.Exists, nil
+}
+
 func (ra *RegistrationAuthorityImpl) checkCertificatesPerFQDNSetLimit(ctx context.Context, names []string, limit ratelimit.RateLimitPolicy, regID int64) error {
 	count, err := ra.SA.CountFQDNSets(ctx, limit.Window.Duration, names)
 	if err != nil {
@@ -1333,6 +1965,7 @@ func (ra *RegistrationAuthorityImpl) checkLimits(ctx context.Context, names []st
 // is responsible for making sure that update.Key is only different from base.Key
 // if it is being called from the WFE key change endpoint.
 func (ra *RegistrationAuthorityImpl) UpdateRegistration(ctx context.Context, base core.Registration, update core.Registration) (core.Registration, error) {
+	oldKey := base.Key
 	if changed := mergeUpdate(&base, update); !changed {
 		// If merging the update didn't actually change the base then our work is
 		// done, we can return before calling ra.SA.UpdateRegistration since theres
@@ -1340,6 +1973,14 @@ func (ra *RegistrationAuthorityImpl) UpdateRegistration(ctx context.Context, bas
 		return base, nil
 	}
 
+	// A key change replaces the account key without going through
+	// NewRegistration, so the new key needs to be checked here.
+	if base.Key != oldKey {
+		if err := ra.keyPolicy.GoodKey(ctx, base.Key.Key); err != nil {
+			return core.Registration{}, keyPolicyError(err)
+		}
+	}
+
 	err := ra.validateContacts(ctx, base.Contact)
 	if err != nil {
 		return core.Registration{}, err
@@ -1357,6 +1998,30 @@ func (ra *RegistrationAuthorityImpl) UpdateRegistration(ctx context.Context, bas
 	return base, nil
 }
 
+// keyPolicyError wraps an error returned by the key policy for an account key.
+// Keys that are known to be weak or compromised keep their BadPublicKey type
+// so that subscribers get the specific problem type, and failures to check the
+// key stay internal errors.
+func keyPolicyError(err error) error {
+	if berrors.Is(err, berrors.BadPublicKey) {
+		return berrors.BadPublicKeyError("invalid public key: %s", err)
+	}
+	if berrors.Is(err, berrors.InternalServer) {
+		return err
+	}
+	return berrors.MalformedError("invalid public key: %s", err)
+}
+
+// csrError wraps an error returned by csr.VerifyCSR, preserving the type of
+// the errors that already have one so that each failure keeps its own problem
+// type.
+func csrError(err error) error {
+	if _, ok := err.(*berrors.BoulderError); ok {
+		return err
+	}
+	return berrors.MalformedError(err.Error())
+}
+
 func contactsEqual(r *core.Registration, other core.Registration) bool {
 	// If there is no existing contact slice, or the contact slice lengths
 	// differ, then the other contact is not equal
@@ -1566,6 +2231,48 @@ func (ra *RegistrationAuthorityImpl) UpdateAuthorization(
 	return authz, nil
 }
 
+// checkRevocationAuthorizations checks that a third party requesting
+// revocation holds a valid, unexpired authorization for each of the names in
+// the certificate. A wildcard name is also covered by an authorization for its
+// base domain. Unlike checkAuthorizations CAA is not rechecked, since nothing
+// is being issued.
+func (ra *RegistrationAuthorityImpl) checkRevocationAuthorizations(ctx context.Context, names []string, regID int64) error {
+	now := ra.clk.Now()
+	lowerNames := make([]string, len(names))
+	lookupNames := make([]string, 0, len(names))
+	for i, name := range names {
+		lowerNames[i] = strings.ToLower(name)
+		lookupNames = append(lookupNames, lowerNames[i])
+		if strings.HasPrefix(lowerNames[i], "*.") {
+			lookupNames = append(lookupNames, strings.TrimPrefix(lowerNames[i], "*."))
+		}
+	}
+	authzs, err := ra.SA.GetValidAuthorizations(ctx, regID, core.UniqueLowerNames(lookupNames), now)
+	if err != nil {
+		return err
+	}
+	authorized := func(name string) bool {
+		authz := authzs[name]
+		return authz != nil && authz.Expires != nil && !authz.Expires.Before(now)
+	}
+	var badNames []string
+	for _, name := range lowerNames {
+		if authorized(name) {
+			continue
+		}
+		if strings.HasPrefix(name, "*.") && authorized(strings.TrimPrefix(name, "*.")) {
+			continue
+		}
+		badNames = append(badNames, name)
+	}
+	if len(badNames) > 0 {
+		return berrors.UnauthorizedError(
+			"requester does not hold valid authorizations for these names in the certificate: %s",
+			strings.Join(badNames, ", "))
+	}
+	return nil
+}
+
 func revokeEvent(state, serial, cn string, names []string, revocationCode revocation.Reason) string {
 	return fmt.Sprintf(
 		"Revocation - State: %s, Serial: %s, CN: %s, DNS Names: %s, Reason: %s",
@@ -1578,8 +2285,42 @@ func revokeEvent(state, serial, cn string, names []string, revocationCode revoca
 }
 
 // RevokeCertificateWithReg terminates trust in the certificate provided.
+//
+// The revocation reason must be one that the requester is allowed to give: a
+// registration ID of 0 means the request was authenticated by the
+// certificate's own key, otherwise the requester is either the subscriber or a
+// third party holding authorizations for the certificate's names. Since the
+// WFE only gives a registration ID of 0 once the request has been shown to be
+// signed by the certificate's key, such a request without a reason is taken
+// as proof of key compromise.
 func (ra *RegistrationAuthorityImpl) RevokeCertificateWithReg(ctx context.Context, cert x509.Certificate, revocationCode revocation.Reason, regID int64) error {
 	serialString := core.SerialToString(cert.SerialNumber)
+	requester := revocation.KeyHolder
+	if regID != 0 {
+		certObj, err := ra.SA.GetCertificate(ctx, serialString)
+		if err != nil {
+			return err
+		}
+		requester = revocation.ThirdParty
+		if certObj.RegistrationID == regID {
+			requester = revocation.Subscriber
+		}
+	}
+	if requester == revocation.ThirdParty {
+		err := ra.checkRevocationAuthorizations(ctx, cert.DNSNames, regID)
+		if err != nil {
+			return err
+		}
+	}
+	if requester == revocation.KeyHolder && revocationCode == revocation.Unspecified {
+		revocationCode = revocation.KeyCompromise
+	}
+	if !revocation.AllowedFor(requester, revocationCode) {
+		return berrors.MalformedError(
+			"revocation reason %q is not allowed for this requester",
+			revocation.ReasonToString[revocationCode])
+	}
+
 	err := ra.SA.MarkCertificateRevoked(ctx, serialString, revocationCode)
 
 	state := "Failure"
@@ -1604,6 +2345,9 @@ func (ra *RegistrationAuthorityImpl) RevokeCertificateWithReg(ctx context.Contex
 	}
 
 	state = "Success"
+	if revocationCode == revocation.KeyCompromise {
+		ra.handleKeyCompromise(ctx, &cert, fmt.Sprintf("registration ID %d", regID))
+	}
 	return nil
 }
 
@@ -1612,6 +2356,12 @@ func (ra *RegistrationAuthorityImpl) RevokeCertificateWithReg(ctx context.Contex
 // called from the admin-revoker tool.
 func (ra *RegistrationAuthorityImpl) AdministrativelyRevokeCertificate(ctx context.Context, cert x509.Certificate, revocationCode revocation.Reason, user string) error {
 	serialString := core.SerialToString(cert.SerialNumber)
+	if !revocation.AllowedFor(revocation.Admin, revocationCode) {
+		return berrors.MalformedError(
+			"revocation reason %q is not allowed for administrative revocation",
+			revocation.ReasonToString[revocationCode])
+	}
+
 	err := ra.SA.MarkCertificateRevoked(ctx, serialString, revocationCode)
 
 	state := "Failure"
@@ -1637,9 +2387,76 @@ func (ra *RegistrationAuthorityImpl) AdministrativelyRevokeCertificate(ctx conte
 
 	state = "Success"
 	ra.stats.Inc("RevokedCertificates", 1)
+	if revocationCode == revocation.KeyCompromise {
+		ra.handleKeyCompromise(ctx, &cert, fmt.Sprintf("admin-revoker user %s", user))
+	}
 	return nil
 }
 
+// handleKeyCompromise is called once a certificate has been revoked for key
+// compromise. It adds the certificate's key to the blocked keys so that it
+// can't be used for new certificates or accounts, and revokes any other
+// unexpired certificates for the same key. The certificate itself is already
+// revoked, so failures are logged rather than returned. Nothing is done unless
+// the BlockedKeyTable feature is enabled.
+func (ra *RegistrationAuthorityImpl) handleKeyCompromise(ctx context.Context, cert *x509.Certificate, requester string) {
+	if !features.Enabled(features.BlockedKeyTable) {
+		return
+	}
+	serialString := core.SerialToString(cert.SerialNumber)
+	keyHash, err := core.KeyDigest(cert.PublicKey)
+	if err != nil {
+		ra.log.AuditErr(fmt.Sprintf("Failed to hash key of compromised certificate %s: %s", serialString, err))
+		return
+	}
+
+	added := ra.clk.Now().UnixNano()
+	addedBy := fmt.Sprintf("RA: keyCompromise revocation of %s by %s", serialString, requester)
+	_, err = ra.SA.AddBlockedKey(ctx, &sapb.AddBlockedKeyRequest{
+		KeyHash: &keyHash,
+		Added:   &added,
+		AddedBy: &addedBy,
+	})
+	if err != nil {
+		ra.log.AuditErr(fmt.Sprintf("Failed to block key %s of compromised certificate %s: %s", keyHash, serialString, err))
+	} else {
+		ra.log.AuditInfo(fmt.Sprintf("Blocked key %s of compromised certificate %s", keyHash, serialString))
+		ra.stats.Inc("BlockedCompromisedKeys", 1)
+	}
+
+	serials, err := ra.SA.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{
+		KeyHash:  &keyHash,
+		NotAfter: &added,
+	})
+	if err != nil {
+		ra.log.AuditErr(fmt.Sprintf("Failed to find certificates sharing key %s with %s: %s", keyHash, serialString, err))
+		return
+	}
+	for _, serial := range serials.Serials {
+		if serial == serialString {
+			continue
+		}
+		status, err := ra.SA.GetCertificateStatus(ctx, serial)
+		if err != nil {
+			ra.log.AuditErr(fmt.Sprintf("Failed to get status of certificate %s sharing key with %s: %s", serial, serialString, err))
+			continue
+		}
+		if status.Status == core.OCSPStatusRevoked {
+			continue
+		}
+		err = ra.SA.MarkCertificateRevoked(ctx, serial, revocation.KeyCompromise)
+		if err != nil {
+			ra.log.AuditErr(fmt.Sprintf("Failed to revoke certificate %s sharing key with %s: %s", serial, serialString, err))
+			continue
+		}
+		ra.log.AuditInfo(fmt.Sprintf(
+			"Revocation - State: Success, Serial: %s, Reason: %s, Shares compromised key with: %s",
+			serial, revocation.ReasonToString[revocation.KeyCompromise], serialString))
+		ra.stats.Inc("RevokedCertificates", 1)
+		ra.stats.Inc("RevokedKeyCompromiseSiblings", 1)
+	}
+}
+
 // onValidationUpdate saves a validation's new status after receiving an
 // authorization back from the VA.
 func (ra *RegistrationAuthorityImpl) onValidationUpdate(ctx context.Context, authz core.Authorization) error {
@@ -1707,106 +2524,127 @@ func (ra *RegistrationAuthorityImpl) DeactivateAuthorization(ctx context.Context
 	return nil
 }
 
-// NewOrder creates a new order object
-func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.NewOrderRequest) (*corepb.Order, error) {
-	order := &corepb.Order{
-		RegistrationID: req.RegistrationID,
-		Names:          core.UniqueLowerNames(req.Names),
+// checkOrderNames checks that an order for names, which must already be
+// deduplicated and lowercased, would be allowed by the maximum number of names
+// and the issuance policy.
+func (ra *RegistrationAuthorityImpl) checkOrderNames(ctx context.Context, names []string, regID int64) error {
+	if err := ra.checkMaxNames(names, regID); err != nil {
+		return err
 	}
 
-	// Validate that our policy allows issuing for each of the names in the order
-	for _, name := range order.Names {
+	// Validate that our policy allows issuing for each of the names in the
+	// order, collecting the problems with all of them so that they can be
+	// reported together
+	var subErrs []berrors.SubBoulderError
+	for _, name := range names {
 		id := core.AcmeIdentifier{Value: name, Type: core.IdentifierDNS}
+		var err error
 		if features.Enabled(features.WildcardDomains) {
-			if err := ra.PA.WillingToIssueWildcard(id); err != nil {
-				return nil, err
-			}
-		} else if err := ra.PA.WillingToIssue(id); err != nil {
-			return nil, err
+			err = ra.PA.WillingToIssueWildcard(ctx, id, regID)
+		} else {
+			err = ra.PA.WillingToIssue(ctx, id, regID)
+		}
+		if err == nil {
+			continue
 		}
+		berr, ok := err.(*berrors.BoulderError)
+		if !ok || berr.Type == berrors.InternalServer {
+			return err
+		}
+		subErrs = append(subErrs, berrors.SubBoulderError{BoulderError: berr, Identifier: id})
+	}
+	if err := policyErrorForNames(subErrs); err != nil {
+		return err
 	}
 
 	if features.Enabled(features.EnforceOverlappingWildcards) {
-		if err := wildcardOverlap(order.Names); err != nil {
-			return nil, err
+		if err := wildcardOverlap(names); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	// See if there is an existing, pending, unexpired order that can be reused
-	// for this account
-	existingOrder, err := ra.SA.GetOrderForNames(ctx, &sapb.GetOrderForNamesRequest{
-		AcctID: order.RegistrationID,
-		Names:  order.Names,
-	})
-	// If there was an error and it wasn't an acceptable "NotFound" error, return
-	// immediately
-	if err != nil && !berrors.Is(err, berrors.NotFound) {
-		return nil, err
+// reusableOrderAuthz returns true if authz, an existing authorization for
+// name, can be reused by a new order. Authorizations expiring before the cutoff
+// for their status can't be.
+func (ra *RegistrationAuthorityImpl) reusableOrderAuthz(name string, authz *corepb.Authorization, validCutoff, pendingCutoff time.Time) bool {
+	// The existing authz can't be reused if it expires within the reuse
+	// window for its status.
+	cutoff := pendingCutoff
+	if authz.GetStatus() == string(core.StatusValid) {
+		cutoff = validCutoff
+	}
+	if time.Unix(0, authz.GetExpires()).Before(cutoff) {
+		return false
 	}
-	// If there was an order, return it
-	if existingOrder != nil {
-		return existingOrder, nil
+	// The existing authz can't be reused if it was, or could be, validated
+	// with a method the validation method policy doesn't permit for the name.
+	if !ra.orderAuthzPermitted(name, authz) {
+		return false
 	}
-	// Otherwise we were unable to find an order to reuse, continue creating a new
-	// order
-
-	// Check if there is rate limit space for a new order within the current window
-	if err := ra.checkNewOrdersPerAccountLimit(ctx, *order.RegistrationID); err != nil {
-		return nil, err
+	// If the identifier isn't a wildcard, we can reuse any authz
+	if !strings.HasPrefix(name, "*.") {
+		return true
 	}
+	// If the identifier is a wildcard and the existing authz only has one
+	// DNS-01 type challenge we can reuse it. In theory we will
+	// never get back an authorization for a domain with a wildcard prefix
+	// that doesn't meet this criteria from SA.GetAuthorizations but we verify
+	// again to be safe.
+	return len(authz.Challenges) == 1 && *authz.Challenges[0].Type == core.ChallengeTypeDNS01
+}
 
+// orderAuthzs finds the existing authorizations of the account regID that a
+// new order for names can reuse, keyed by name, and the names that have none.
+func (ra *RegistrationAuthorityImpl) orderAuthzs(ctx context.Context, regID int64, names []string) (map[string]*corepb.Authorization, []string, error) {
 	// An order's lifetime is effectively bound by the shortest remaining lifetime
 	// of its associated authorizations. For that reason it would be Uncool if
 	// `sa.GetAuthorizations` returned an authorization that was very close to
 	// expiry. The resulting pending order that references it would itself end up
 	// expiring very soon.
-	// To prevent this we only return authorizations that are at least 1 day away
-	// from expiring.
-	authzExpiryCutoff := ra.clk.Now().AddDate(0, 0, 1).UnixNano()
+	// To prevent this we only reuse authorizations that won't expire within the
+	// reuse window for their status, by default 1 day. The SA is asked for
+	// authorizations expiring after the earlier of the two cutoffs and the
+	// later one is applied below.
+	validCutoff := ra.authzReuseCutoff(core.StatusValid, true)
+	pendingCutoff := ra.authzReuseCutoff(core.StatusPending, true)
+	authzExpiryCutoff := validCutoff.UnixNano()
+	if pendingCutoff.Before(validCutoff) {
+		authzExpiryCutoff = pendingCutoff.UnixNano()
+	}
 
 	// We do not want any legacy V1 API authorizations not associated with an
 	// order to be returned from the SA so we set requireV2Authzs to true
 	requireV2Authzs := true
 	existingAuthz, err := ra.SA.GetAuthorizations(ctx, &sapb.GetAuthorizationsRequest{
-		RegistrationID:  order.RegistrationID,
+		RegistrationID:  &regID,
 		Now:             &authzExpiryCutoff,
-		Domains:         order.Names,
+		Domains:         names,
 		RequireV2Authzs: &requireV2Authzs,
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Collect up the authorizations we found into a map keyed by the domains the
 	// authorizations correspond to
-	nameToExistingAuthz := make(map[string]*corepb.Authorization, len(order.Names))
+	nameToExistingAuthz := make(map[string]*corepb.Authorization, len(names))
 	for _, v := range existingAuthz.Authz {
 		nameToExistingAuthz[*v.Domain] = v.Authz
 	}
 
-	// For each of the names in the order, if there is an acceptable
-	// existing authz, append it to the order to reuse it. Otherwise track
-	// that there is a missing authz for that name.
+	// For each of the names in the order, if there isn't an acceptable
+	// existing authz track that there is a missing authz for that name.
 	var missingAuthzNames []string
-	for _, name := range order.Names {
+	for _, name := range names {
 		// If there isn't an existing authz, note that its missing and continue
 		if _, exists := nameToExistingAuthz[name]; !exists {
 			missingAuthzNames = append(missingAuthzNames, name)
 			continue
 		}
 		authz := nameToExistingAuthz[name]
-		// If the identifier is a wildcard and the existing authz only has one
-		// DNS-01 type challenge we can reuse it. In theory we will
-		// never get back an authorization for a domain with a wildcard prefix
-		// that doesn't meet this criteria from SA.GetAuthorizations but we verify
-		// again to be safe.
-		if strings.HasPrefix(name, "*.") &&
-			len(authz.Challenges) == 1 && *authz.Challenges[0].Type == core.ChallengeTypeDNS01 {
-			order.Authorizations = append(order.Authorizations, *authz.Id)
-			continue
-		} else if !strings.HasPrefix(name, "*.") {
-			// If the identifier isn't a wildcard, we can reuse any authz
-			order.Authorizations = append(order.Authorizations, *authz.Id)
+		if ra.reusableOrderAuthz(name, authz, validCutoff, pendingCutoff) {
 			continue
 		}
 
@@ -1816,6 +2654,136 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 		// reuse and we need to mark the name as requiring a new pending authz
 		missingAuthzNames = append(missingAuthzNames, name)
 	}
+	return nameToExistingAuthz, missingAuthzNames, nil
+}
+
+// CheckNewOrder checks whether a new order for the requested names would be
+// allowed by the issuance policy and rate limits, and whether a certificate
+// for them would be allowed by the issuance rate limits, without creating an
+// order or counting towards any limit other than CheckOrdersPerAccount. Like
+// NewOrder, the pending and invalid authorization limits are only checked if
+// some of the names have no authorization to reuse.
+func (ra *RegistrationAuthorityImpl) CheckNewOrder(ctx context.Context, req *rapb.NewOrderRequest) error {
+	names := core.UniqueLowerNames(req.Names)
+	regID := *req.RegistrationID
+	if err := ra.checkCheckOrdersPerAccountLimit(ctx, regID); err != nil {
+		return err
+	}
+	if err := ra.checkOrderNames(ctx, names, regID); err != nil {
+		return err
+	}
+
+	limit := ra.rlPolicies.NewOrdersPerAccount()
+	if limit.Enabled() {
+		count, ok := ra.countSharedLimit(ctx, "newOrdersPerAccount", strconv.FormatInt(regID, 10), limit)
+		if !ok {
+			latest := ra.clk.Now()
+			var err error
+			count, err = ra.SA.CountOrders(ctx, regID, limit.WindowBegin(latest), latest)
+			if err != nil {
+				return err
+			}
+		}
+		// There is no meaningful override key to use for this rate limit
+		noKey := ""
+		if count >= limit.GetThreshold(noKey, regID) {
+			return berrors.RateLimitError("too many new orders recently")
+		}
+	}
+
+	_, missingAuthzNames, err := ra.orderAuthzs(ctx, regID, names)
+	if err != nil {
+		return err
+	}
+	if len(missingAuthzNames) > 0 {
+		if err := ra.checkPendingAuthorizationLimit(ctx, regID); err != nil {
+			return err
+		}
+	}
+	for _, name := range missingAuthzNames {
+		if err := ra.checkInvalidAuthorizationLimit(ctx, regID, name); err != nil {
+			return err
+		}
+		if err := ra.checkFailedValidationsLimit(ctx, regID, name); err != nil {
+			return err
+		}
+	}
+	return ra.checkLimits(ctx, names, regID)
+}
+
+// checkCheckOrdersPerAccountLimit enforces the rlPolicies
+// `CheckOrdersPerAccount` rate limit, and counts the request towards it. Check
+// order requests aren't stored, so the limit is only enforced when there is a
+// RateLimitCounter to count them with.
+func (ra *RegistrationAuthorityImpl) checkCheckOrdersPerAccountLimit(ctx context.Context, acctID int64) error {
+	limit := ra.rlPolicies.CheckOrdersPerAccount()
+	if !limit.Enabled() {
+		return nil
+	}
+	// There is no meaningful override key to use for this rate limit
+	noKey := ""
+	threshold := limit.GetThreshold(noKey, acctID)
+	res, ok := ra.reserveSharedLimit(ctx, "checkOrdersPerAccount", strconv.FormatInt(acctID, 10), limit, threshold)
+	if ok && res == nil {
+		ra.log.Info(fmt.Sprintf("Rate limit exceeded, CheckOrdersByRegID, regID: %d", acctID))
+		return berrors.RateLimitError("too many check order requests recently")
+	}
+	return nil
+}
+
+// NewOrder creates a new order object
+func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.NewOrderRequest) (*corepb.Order, error) {
+	order := &corepb.Order{
+		RegistrationID: req.RegistrationID,
+		Names:          core.UniqueLowerNames(req.Names),
+	}
+
+	if err := ra.checkOrderNames(ctx, order.Names, *req.RegistrationID); err != nil {
+		return nil, err
+	}
+
+	// See if there is an existing, pending, unexpired order that can be reused
+	// for this account
+	existingOrder, err := ra.SA.GetOrderForNames(ctx, &sapb.GetOrderForNamesRequest{
+		AcctID: order.RegistrationID,
+		Names:  order.Names,
+	})
+	// If there was an error and it wasn't an acceptable "NotFound" error, return
+	// immediately
+	if err != nil && !berrors.Is(err, berrors.NotFound) {
+		return nil, err
+	}
+	// If there was an order, return it
+	if existingOrder != nil {
+		return existingOrder, nil
+	}
+	// Otherwise we were unable to find an order to reuse, continue creating a new
+	// order
+
+	// Check if there is rate limit space for a new order within the current window
+	reservation, err := ra.checkNewOrdersPerAccountLimit(ctx, *order.RegistrationID)
+	if err != nil {
+		return nil, err
+	}
+	stored := false
+	defer func() {
+		if !stored {
+			ra.releaseSharedLimits(reservation)
+		}
+	}()
+
+	nameToExistingAuthz, missingAuthzNames, err := ra.orderAuthzs(ctx, *order.RegistrationID, order.Names)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range order.Names {
+		if authz, ok := nameToExistingAuthz[name]; ok {
+			order.Authorizations = append(order.Authorizations, *authz.Id)
+		}
+	}
+	// Only count the reused authorizations once the order referencing them
+	// has been stored
+	reusedAuthzs := int64(len(order.Authorizations))
 
 	// If the order isn't fully authorized we need to check that the client has
 	// rate limit room for more pending authorizations
@@ -1833,6 +2801,9 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 		if err := ra.checkInvalidAuthorizationLimit(ctx, *order.RegistrationID, name); err != nil {
 			return nil, err
 		}
+		if err := ra.checkFailedValidationsLimit(ctx, *order.RegistrationID, name); err != nil {
+			return nil, err
+		}
 		pb, err := ra.createPendingAuthz(ctx, *order.RegistrationID, core.AcmeIdentifier{
 			Type:  core.IdentifierDNS,
 			Value: name,
@@ -1864,17 +2835,9 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 		}
 	}
 
-	// If new authorizations are needed, call AddPendingAuthorizations. Also check
-	// whether the newly created pending authz's have an expiry lower than minExpiry
+	// If the newly created pending authz's have an expiry closer than the
+	// minExpiry the minExpiry is the pending authz expiry.
 	if len(newAuthzs) > 0 {
-		authzIDs, err := ra.SA.AddPendingAuthorizations(ctx, &sapb.AddPendingAuthorizationsRequest{Authz: newAuthzs})
-		if err != nil {
-			return nil, err
-		}
-		order.Authorizations = append(order.Authorizations, authzIDs.Ids...)
-
-		// If the newly created pending authz's have an expiry closer than the
-		// minExpiry the minExpiry is the pending authz expiry.
 		newPendingAuthzExpires := ra.clk.Now().Add(ra.pendingAuthorizationLifetime)
 		if newPendingAuthzExpires.Before(minExpiry) {
 			minExpiry = newPendingAuthzExpires
@@ -1884,10 +2847,38 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 	// Set the order's expiry to the minimum expiry
 	minExpiryTS := minExpiry.UnixNano()
 	order.Expires = &minExpiryTS
+
+	// Store the order and its new authorizations in a single SA call if
+	// enabled
+	if features.Enabled(features.BatchOrderCreation) {
+		storedOrder, err := ra.SA.NewOrderAndAuthzs(ctx, &sapb.NewOrderAndAuthzsRequest{
+			NewOrder:  order,
+			NewAuthzs: newAuthzs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		stored = true
+		ra.stats.Inc("ReusedOrderAuthz", reusedAuthzs)
+		return storedOrder, nil
+	}
+
+	// Otherwise if new authorizations are needed, call AddPendingAuthorizations
+	// before storing the order
+	if len(newAuthzs) > 0 {
+		authzIDs, err := ra.SA.AddPendingAuthorizations(ctx, &sapb.AddPendingAuthorizationsRequest{Authz: newAuthzs})
+		if err != nil {
+			return nil, err
+		}
+		order.Authorizations = append(order.Authorizations, authzIDs.Ids...)
+	}
+
 	storedOrder, err := ra.SA.NewOrder(ctx, order)
 	if err != nil {
 		return nil, err
 	}
+	stored = true
+	ra.stats.Inc("ReusedOrderAuthz", reusedAuthzs)
 
 	return storedOrder, nil
 }
@@ -1943,6 +2934,13 @@ func (ra *RegistrationAuthorityImpl) createPendingAuthz(ctx context.Context, reg
 		// want to treat this as an internal server error.
 		return nil, berrors.InternalServerError(err.Error())
 	}
+	// Only offer the challenges permitted by the validation method policy. As
+	// above, having none left is a configuration error.
+	challenges, combinations = ra.ValidationMethods.filterChallenges(identifier.Value, challenges, combinations)
+	if len(challenges) == 0 {
+		return nil, berrors.InternalServerError(
+			"no challenges permitted by the validation method policy for %q", identifier.Value)
+	}
 	// Check each challenge for sanity.
 	for _, challenge := range challenges {
 		if err := challenge.CheckConsistencyForClientOffer(); err != nil {
@@ -1966,16 +2964,54 @@ func (ra *RegistrationAuthorityImpl) createPendingAuthz(ctx context.Context, reg
 }
 
 // authzValidChallengeEnabled checks whether the valid challenge in an authorization uses a type
-// which is still enabled for given regID
+// which is still enabled for given regID and permitted by the validation method policy
 func (ra *RegistrationAuthorityImpl) authzValidChallengeEnabled(authz *core.Authorization) bool {
 	for _, chall := range authz.Challenges {
 		if chall.Status == core.StatusValid {
-			return ra.PA.ChallengeTypeEnabled(chall.Type, authz.RegistrationID)
+			return ra.PA.ChallengeTypeEnabled(chall.Type, authz.RegistrationID) &&
+				ra.ValidationMethods.permitted(authz.Identifier.Value, chall.Type)
 		}
 	}
 	return false
 }
 
+// authzReuseCutoff returns the time an existing authorization with the given
+// status must not expire before to be reused. It is now plus the RA's reuse
+// window for the status or, if that is unset, a default: 24 hours, except for
+// pending authorizations reused by NewAuthorization, which use 1 hour.
+func (ra *RegistrationAuthorityImpl) authzReuseCutoff(status core.AcmeStatus, forOrder bool) time.Time {
+	window := 24 * time.Hour
+	switch {
+	case status == core.StatusValid && ra.ValidAuthzReuseWindow > 0:
+		window = ra.ValidAuthzReuseWindow
+	case status == core.StatusPending && ra.PendingAuthzReuseWindow > 0:
+		window = ra.PendingAuthzReuseWindow
+	case status == core.StatusPending && !forOrder:
+		window = time.Hour
+	}
+	return ra.clk.Now().Add(window)
+}
+
+// orderAuthzPermitted checks whether an existing authorization for name can be
+// added to an order under the validation method policy: a valid authorization
+// must have been validated with a permitted challenge type and a pending one
+// must only offer permitted challenge types.
+func (ra *RegistrationAuthorityImpl) orderAuthzPermitted(name string, authz *corepb.Authorization) bool {
+	if !ra.ValidationMethods.restricted(name) {
+		return true
+	}
+	valid := authz.GetStatus() == string(core.StatusValid)
+	for _, chall := range authz.Challenges {
+		if valid && chall.GetStatus() != string(core.StatusValid) {
+			continue
+		}
+		if !ra.ValidationMethods.permitted(name, chall.GetType()) {
+			return false
+		}
+	}
+	return true
+}
+
 // wildcardOverlap takes a slice of domain names and returns an error if any of
 // them is a non-wildcard FQDN that overlaps with a wildcard domain in the map.
 func wildcardOverlap(dnsNames []string) error {
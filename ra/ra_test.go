@@ -8,6 +8,7 @@ import (
 	"crypto/x509/pkix"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -15,6 +16,7 @@ import (
 	"net/url"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -22,14 +24,17 @@ import (
 
 	"github.com/jmhodges/clock"
 	"github.com/letsencrypt/boulder/bdns"
+	caPB "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
+	csrlib "github.com/letsencrypt/boulder/csr"
 	"github.com/letsencrypt/boulder/ctpolicy"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
 	sagrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/identifier"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/mocks"
@@ -38,6 +43,7 @@ import (
 	pubpb "github.com/letsencrypt/boulder/publisher/proto"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
 	"github.com/letsencrypt/boulder/ratelimit"
+	"github.com/letsencrypt/boulder/revocation"
 	"github.com/letsencrypt/boulder/sa"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/test"
@@ -63,6 +69,10 @@ func (dva *DummyValidationAuthority) PerformValidation(ctx context.Context, doma
 	return dva.RecordsReturn, dva.ProblemReturn
 }
 
+func (dva *DummyValidationAuthority) ReplayValidation(ctx context.Context, domain string, challenge core.Challenge, userAgent string) ([]core.ValidationRecord, error) {
+	return dva.RecordsReturn, dva.ProblemReturn
+}
+
 func (dva *DummyValidationAuthority) IsSafeDomain(ctx context.Context, req *vaPB.IsSafeDomainRequest) (*vaPB.IsDomainSafe, error) {
 	if dva.IsSafeDomainErr != nil {
 		return nil, dva.IsSafeDomainErr
@@ -177,7 +187,9 @@ type dummyRateLimitConfig struct {
 	PendingAuthorizationsPerAccountPolicy ratelimit.RateLimitPolicy
 	PendingOrdersPerAccountPolicy         ratelimit.RateLimitPolicy
 	NewOrdersPerAccountPolicy             ratelimit.RateLimitPolicy
+	CheckOrdersPerAccountPolicy           ratelimit.RateLimitPolicy
 	InvalidAuthorizationsPerAccountPolicy ratelimit.RateLimitPolicy
+	FailedValidationsPerAccountPolicy     ratelimit.RateLimitPolicy
 	CertificatesPerFQDNSetPolicy          ratelimit.RateLimitPolicy
 }
 
@@ -209,10 +221,18 @@ func (r *dummyRateLimitConfig) NewOrdersPerAccount() ratelimit.RateLimitPolicy {
 	return r.NewOrdersPerAccountPolicy
 }
 
+func (r *dummyRateLimitConfig) CheckOrdersPerAccount() ratelimit.RateLimitPolicy {
+	return r.CheckOrdersPerAccountPolicy
+}
+
 func (r *dummyRateLimitConfig) InvalidAuthorizationsPerAccount() ratelimit.RateLimitPolicy {
 	return r.InvalidAuthorizationsPerAccountPolicy
 }
 
+func (r *dummyRateLimitConfig) FailedValidationsPerAccount() ratelimit.RateLimitPolicy {
+	return r.FailedValidationsPerAccountPolicy
+}
+
 func (r *dummyRateLimitConfig) CertificatesPerFQDNSet() ratelimit.RateLimitPolicy {
 	return r.CertificatesPerFQDNSetPolicy
 }
@@ -221,6 +241,10 @@ func (r *dummyRateLimitConfig) LoadPolicies(contents []byte) error {
 	return nil // NOP - unrequired behaviour for this mock
 }
 
+func (r *dummyRateLimitConfig) SetRegistrationOverrides(overrides ratelimit.RegistrationOverrides) error {
+	return nil // NOP - unrequired behaviour for this mock
+}
+
 func initAuthorities(t *testing.T) (*DummyValidationAuthority, *sa.SQLStorageAuthority, *RegistrationAuthorityImpl, clock.FakeClock, func()) {
 	err := json.Unmarshal(AccountKeyJSONA, &AccountKeyA)
 	test.AssertNotError(t, err, "Failed to unmarshal public JWK")
@@ -252,7 +276,7 @@ func initAuthorities(t *testing.T) (*DummyValidationAuthority, *sa.SQLStorageAut
 
 	va := &DummyValidationAuthority{argument: make(chan core.Authorization, 1)}
 
-	pa, err := policy.New(SupportedChallenges)
+	pa, err := policy.New(SupportedChallenges, metrics.NewNoopScope())
 	test.AssertNotError(t, err, "Couldn't create PA")
 	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
 	test.AssertNotError(t, err, "Couldn't set hostname policy")
@@ -275,12 +299,12 @@ func initAuthorities(t *testing.T) (*DummyValidationAuthority, *sa.SQLStorageAut
 		Status:    core.StatusValid,
 	})
 
-	ctp := ctpolicy.New(&mocks.Publisher{}, nil, nil, log)
+	ctp := ctpolicy.New(&mocks.Publisher{}, nil, nil, nil, 0, log, metrics.NewNoopScope())
 
 	ra := NewRegistrationAuthorityImpl(fc,
 		log,
 		stats,
-		1, testKeyPolicy, 0, true, false, 300*24*time.Hour, 7*24*time.Hour, nil, noopCAA{}, 0, ctp)
+		1, testKeyPolicy, csrlib.Policy{CommonName: csrlib.CNPromote}, false, 300*24*time.Hour, 7*24*time.Hour, nil, noopCAA{}, 0, ctp)
 	ra.SA = ssa
 	ra.VA = va
 	ra.CA = ca
@@ -1136,7 +1160,7 @@ func TestTotalCertRateLimit(t *testing.T) {
 	// should mock out the SA and have it return the cert count that we want.
 	cert, err := ra.NewCertificate(ctx, certRequest, Registration.ID)
 	test.AssertNotError(t, err, "Failed to issue certificate")
-	_, err = sa.AddCertificate(ctx, cert.DER, Registration.ID, nil)
+	_, err = sa.AddCertificate(ctx, cert.DER, Registration.ID, nil, nil)
 	test.AssertNotError(t, err, "Failed to store certificate")
 
 	fc.Add(time.Hour)
@@ -1251,6 +1275,175 @@ func TestAuthzFailedRateLimiting(t *testing.T) {
 	test.AssertEquals(t, err.Error(), "too many failed authorizations recently: see https://letsencrypt.org/docs/rate-limits/")
 }
 
+func TestFailedValidationsRateLimiting(t *testing.T) {
+	_, _, ra, _, cleanUp := initAuthorities(t)
+	defer cleanUp()
+
+	ra.rlPolicies = &dummyRateLimitConfig{
+		FailedValidationsPerAccountPolicy: ratelimit.RateLimitPolicy{
+			Threshold: 1,
+			Window:    cmd.ConfigDuration{Duration: 1 * time.Hour},
+		},
+	}
+
+	// mockInvalidAuthorizationsAuthority counts one failed validation for
+	// every hostname
+	ra.SA = sagrpc.NewStorageAuthorityClient(&mockInvalidAuthorizationsAuthority{})
+	_, err := ra.NewAuthorization(ctx, AuthzRequest, Registration.ID)
+	test.AssertError(t, err, "NewAuthorization did not encounter expected rate limit error")
+	test.AssertEquals(t, err.Error(), "too many failed validations of this hostname recently: see https://letsencrypt.org/docs/rate-limits/")
+
+	// A registration override above the count lets it through the limit
+	ra.rlPolicies = &dummyRateLimitConfig{
+		FailedValidationsPerAccountPolicy: ratelimit.RateLimitPolicy{
+			Threshold:             1,
+			Window:                cmd.ConfigDuration{Duration: 1 * time.Hour},
+			RegistrationOverrides: map[int64]int{Registration.ID: 2},
+		},
+	}
+	err = ra.checkFailedValidationsLimit(ctx, Registration.ID, "not-example.com")
+	test.AssertNotError(t, err, "Rate limit applied despite registration override")
+}
+
+// mockRateLimitCounter is a ratelimit.Counter that counts count events for
+// every key or returns err, recording the keys it counts and the keys it
+// reserves and releases events for.
+type mockRateLimitCounter struct {
+	count    int
+	err      error
+	keys     []string
+	reserved []string
+	released []string
+}
+
+func (c *mockRateLimitCounter) Count(_ context.Context, key string, _ time.Duration, _ time.Time) (int, error) {
+	c.keys = append(c.keys, key)
+	return c.count, c.err
+}
+
+func (c *mockRateLimitCounter) Reserve(_ context.Context, key string, _ time.Duration, _ time.Time, threshold int) (string, error) {
+	c.keys = append(c.keys, key)
+	if c.err != nil || c.count >= threshold {
+		return "", c.err
+	}
+	c.reserved = append(c.reserved, key)
+	return strconv.Itoa(len(c.reserved)), nil
+}
+
+func (c *mockRateLimitCounter) Release(_ context.Context, key, _ string) error {
+	c.released = append(c.released, key)
+	return c.err
+}
+
+func TestSharedRateLimits(t *testing.T) {
+	fc := clock.NewFake()
+	ra := NewRegistrationAuthorityImpl(fc,
+		log,
+		metrics.NewNoopScope(),
+		1, testKeyPolicy, csrlib.Policy{CommonName: csrlib.CNPromote}, false, 300*24*time.Hour, 7*24*time.Hour, nil, noopCAA{}, 0, nil)
+	ra.SA = &mocks.StorageAuthority{}
+	ra.rlPolicies = &dummyRateLimitConfig{
+		RegistrationsPerIPPolicy: ratelimit.RateLimitPolicy{
+			Threshold: 10,
+			Window:    cmd.ConfigDuration{Duration: time.Hour},
+		},
+		RegistrationsPerIPRangePolicy: ratelimit.RateLimitPolicy{
+			Threshold: 5,
+			Window:    cmd.ConfigDuration{Duration: time.Hour},
+		},
+		NewOrdersPerAccountPolicy: ratelimit.RateLimitPolicy{
+			Threshold:             10,
+			Window:                cmd.ConfigDuration{Duration: time.Hour},
+			RegistrationOverrides: map[int64]int{1: 20},
+		},
+	}
+
+	// Checking the limits reserves an event against each of them
+	counter := &mockRateLimitCounter{count: 4}
+	ra.RateLimitCounter = counter
+	res, err := ra.checkNewOrdersPerAccountLimit(ctx, 1)
+	test.AssertNotError(t, err, "New order refused by shared limit")
+	test.Assert(t, res != nil, "New order wasn't reserved")
+	reservations, err := ra.checkRegistrationLimits(ctx, net.ParseIP("2001:db8:1:2::3"))
+	test.AssertNotError(t, err, "Registration refused by shared limit")
+	test.AssertEquals(t, len(reservations), 2)
+	test.AssertDeepEquals(t, counter.reserved, []string{
+		"newOrdersPerAccount:1",
+		"registrationsPerIP:2001:db8:1:2::3",
+		"registrationsPerIPRange:2001:db8:1::/48",
+	})
+
+	// IPv4 addresses aren't limited by range
+	counter.reserved = nil
+	_, err = ra.checkRegistrationLimits(ctx, net.ParseIP("1.2.3.4"))
+	test.AssertNotError(t, err, "Registration refused by shared limit")
+	test.AssertDeepEquals(t, counter.reserved, []string{"registrationsPerIP:1.2.3.4"})
+
+	// The account's override applies to the shared count
+	counter.count = 19
+	_, err = ra.checkNewOrdersPerAccountLimit(ctx, 1)
+	test.AssertNotError(t, err, "New order refused under the overridden shared limit")
+
+	// When the shared count reaches the threshold the limit is exceeded
+	counter.count = 20
+	_, err = ra.checkNewOrdersPerAccountLimit(ctx, 1)
+	test.AssertError(t, err, "New order allowed over the shared limit")
+	test.Assert(t, berrors.Is(err, berrors.RateLimit), "Incorrect error type returned")
+	counter.count = 10
+	_, err = ra.checkRegistrationLimits(ctx, net.ParseIP("1.2.3.4"))
+	test.AssertError(t, err, "Registration allowed over the shared limit")
+	test.Assert(t, berrors.Is(err, berrors.RateLimit), "Incorrect error type returned")
+
+	// If the range limit is exceeded the reservation against the IP's limit
+	// is released
+	counter.count = 5
+	counter.reserved = nil
+	_, err = ra.checkRegistrationLimits(ctx, net.ParseIP("2001:db8:1:2::3"))
+	test.AssertError(t, err, "Registration allowed over the shared range limit")
+	test.AssertDeepEquals(t, counter.reserved, []string{"registrationsPerIP:2001:db8:1:2::3"})
+	test.AssertDeepEquals(t, counter.released, []string{"registrationsPerIP:2001:db8:1:2::3"})
+
+	// Stored registrations keep their reservations
+	counter.count = 0
+	counter.reserved = nil
+	counter.released = nil
+	var key jose.JSONWebKey
+	err = json.Unmarshal(AccountKeyJSONA, &key)
+	test.AssertNotError(t, err, "Failed to unmarshal account key")
+	_, err = ra.NewRegistration(ctx, core.Registration{Key: &key, InitialIP: net.ParseIP("2001:db8:1:2::3")})
+	test.AssertNotError(t, err, "NewRegistration failed")
+	_, err = ra.NewRegistration(ctx, core.Registration{Key: &key, InitialIP: net.ParseIP("1.2.3.4")})
+	test.AssertNotError(t, err, "NewRegistration failed")
+	test.AssertDeepEquals(t, counter.reserved, []string{
+		"registrationsPerIP:2001:db8:1:2::3",
+		"registrationsPerIPRange:2001:db8:1::/48",
+		"registrationsPerIP:1.2.3.4",
+	})
+	test.AssertEquals(t, len(counter.released), 0)
+
+	// A registration that fails after its limits were checked releases them
+	counter.reserved = nil
+	contacts := []string{"tel:867-5309"}
+	_, err = ra.NewRegistration(ctx, core.Registration{Key: &key, InitialIP: net.ParseIP("2001:db8:1:2::3"), Contact: &contacts})
+	test.AssertError(t, err, "NewRegistration accepted an unsupported contact")
+	test.AssertEquals(t, len(counter.released), 2)
+	test.AssertDeepEquals(t, counter.released, counter.reserved)
+
+	// A refused registration isn't reserved
+	counter.reserved = nil
+	counter.count = 10
+	_, err = ra.NewRegistration(ctx, core.Registration{Key: &key, InitialIP: net.ParseIP("1.2.3.4")})
+	test.AssertError(t, err, "NewRegistration allowed over the shared limit")
+	test.AssertEquals(t, len(counter.reserved), 0)
+
+	// When it fails the database counts are used instead
+	counter.err = fmt.Errorf("connection refused")
+	_, err = ra.checkNewOrdersPerAccountLimit(ctx, 1)
+	test.AssertNotError(t, err, "New order refused when falling back to the database")
+	_, err = ra.checkRegistrationLimits(ctx, net.ParseIP("1.2.3.4"))
+	test.AssertNotError(t, err, "Registration refused when falling back to the database")
+}
+
 func TestDomainsForRateLimiting(t *testing.T) {
 	domains, err := domainsForRateLimiting([]string{})
 	test.AssertNotError(t, err, "failed on empty")
@@ -1645,13 +1838,16 @@ func TestRegistrationKeyUpdate(t *testing.T) {
 }
 
 // A mockSAWithFQDNSet is a mock StorageAuthority that supports
-// CountCertificatesByName as well as FQDNSetExists. This allows testing
-// checkCertificatesPerNameRateLimit's FQDN exemption logic.
+// CountCertificatesByName as well as FQDNSetExists and FQDNSetIssuedForAccount.
+// This allows testing checkCertificatesPerNameRateLimit's FQDN exemption logic.
 type mockSAWithFQDNSet struct {
 	mocks.StorageAuthority
-	fqdnSet    map[string]bool
-	nameCounts map[string]*sapb.CountByNames_MapElement
-	t          *testing.T
+	fqdnSet map[string]bool
+	// accountFQDNSets maps an FQDN set hash and registration ID to when the
+	// set was issued to the registration.
+	accountFQDNSets map[string]time.Time
+	nameCounts      map[string]*sapb.CountByNames_MapElement
+	t               *testing.T
 }
 
 // Construct the FQDN Set key the same way as the SA - by using
@@ -1677,6 +1873,18 @@ func (m mockSAWithFQDNSet) FQDNSetExists(_ context.Context, names []string) (boo
 	return false, nil
 }
 
+// Record the issuance of a set of domain names to a registration
+func (m mockSAWithFQDNSet) addAccountFQDNSet(names []string, regID int64, issued time.Time) {
+	m.accountFQDNSets[fmt.Sprintf("%s/%d", m.hashNames(names), regID)] = issued
+}
+
+// Search for an issuance of a set of domain names to a registration
+func (m mockSAWithFQDNSet) FQDNSetIssuedForAccount(_ context.Context, req *sapb.FQDNSetIssuedForAccountRequest) (*sapb.Exists, error) {
+	issued, ok := m.accountFQDNSets[fmt.Sprintf("%s/%d", m.hashNames(req.Domains), *req.RegistrationID)]
+	exists := ok && issued.After(time.Unix(0, *req.Earliest))
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
 // Return a map of domain -> certificate count.
 func (m mockSAWithFQDNSet) CountCertificatesByNames(ctx context.Context, names []string, earliest, latest time.Time) (ret []*sapb.CountByNames_MapElement, err error) {
 	var results []*sapb.CountByNames_MapElement
@@ -1742,6 +1950,52 @@ func TestCheckFQDNSetRateLimitOverride(t *testing.T) {
 	test.AssertNotError(t, err, "FQDN set certificate per name exemption not applied correctly")
 }
 
+// With a RenewalExemptionWindow the FQDN set exemption from the certificates
+// per name limit only applies if the same account was issued the set of names
+// within the window.
+func TestCheckFQDNSetRateLimitOverrideForAccount(t *testing.T) {
+	_, _, ra, fc, cleanUp := initAuthorities(t)
+	defer cleanUp()
+
+	ra.RenewalExemptionWindow = 30 * 24 * time.Hour
+
+	certsPerNamePolicy := ratelimit.RateLimitPolicy{
+		Threshold: 1,
+		Window:    cmd.ConfigDuration{Duration: 24 * time.Hour},
+	}
+
+	names := []string{"www.example.com", "example.com"}
+	mockSA := &mockSAWithFQDNSet{
+		nameCounts: map[string]*sapb.CountByNames_MapElement{
+			"example.com": nameCount("example.com", 100),
+		},
+		fqdnSet:         map[string]bool{},
+		accountFQDNSets: map[string]time.Time{},
+		t:               t,
+	}
+	ra.SA = mockSA
+
+	// Issuance of the set to any account is no longer enough
+	mockSA.addFQDNSet(names)
+	err := ra.checkCertificatesPerNameLimit(ctx, names, certsPerNamePolicy, 99)
+	test.AssertError(t, err, "FQDN set exemption applied without an issuance to the account")
+
+	// Nor is issuance of the set to another account
+	mockSA.addAccountFQDNSet(names, 98, fc.Now())
+	err = ra.checkCertificatesPerNameLimit(ctx, names, certsPerNamePolicy, 99)
+	test.AssertError(t, err, "FQDN set exemption applied for another account's issuance")
+
+	// Issuance to the same account within the window is
+	mockSA.addAccountFQDNSet(names, 99, fc.Now())
+	err = ra.checkCertificatesPerNameLimit(ctx, names, certsPerNamePolicy, 99)
+	test.AssertNotError(t, err, "FQDN set exemption not applied for the account's issuance")
+
+	// Once the issuance leaves the window the limit applies again
+	fc.Add(31 * 24 * time.Hour)
+	err = ra.checkCertificatesPerNameLimit(ctx, names, certsPerNamePolicy, 99)
+	test.AssertError(t, err, "FQDN set exemption applied for an issuance outside the window")
+}
+
 // TestExactPublicSuffixCertLimit tests the behaviour of issue #2681 with and
 // without the feature flag for the fix enabled.
 // See https://github.com/letsencrypt/boulder/issues/2681
@@ -2127,6 +2381,43 @@ func TestNewOrder(t *testing.T) {
 	test.AssertEquals(t, err.Error(), "DNS name does not have enough labels")
 }
 
+func TestNewOrderBatchOrderCreation(t *testing.T) {
+	_, sa, ra, fc, cleanUp := initAuthorities(t)
+	defer cleanUp()
+	ra.orderLifetime = time.Hour
+
+	_ = features.Set(map[string]bool{"BatchOrderCreation": true})
+	defer features.Reset()
+
+	id := int64(1)
+	orderA, err := ra.NewOrder(context.Background(), &rapb.NewOrderRequest{
+		RegistrationID: &id,
+		Names:          []string{"b.com", "a.com"},
+	})
+	test.AssertNotError(t, err, "ra.NewOrder failed")
+	test.AssertEquals(t, *orderA.Expires, fc.Now().Add(time.Hour).UnixNano())
+	test.AssertEquals(t, len(orderA.Authorizations), 2)
+	for _, authzID := range orderA.Authorizations {
+		authz, err := sa.GetAuthorization(ctx, authzID)
+		test.AssertNotError(t, err, "Couldn't get the order's authorization")
+		test.AssertEquals(t, authz.Status, core.StatusPending)
+		test.Assert(t, len(authz.Challenges) > 0, "Authorization was stored without challenges")
+	}
+
+	// Existing authorizations are reused alongside new ones
+	orderB, err := ra.NewOrder(context.Background(), &rapb.NewOrderRequest{
+		RegistrationID: &id,
+		Names:          []string{"a.com", "b.com", "c.com"},
+	})
+	test.AssertNotError(t, err, "ra.NewOrder failed")
+	test.AssertNotEquals(t, *orderB.Id, *orderA.Id)
+	test.AssertEquals(t, len(orderB.Authorizations), 3)
+	existing := orderB.Authorizations[:2]
+	sort.Strings(existing)
+	sort.Strings(orderA.Authorizations)
+	test.AssertDeepEquals(t, existing, orderA.Authorizations)
+}
+
 // TestNewOrderLegacyAuthzReuse tests that a legacy acme v1 authorization from
 // the `new-authz` endpoint isn't reused by a V2 order created by the same
 // account.
@@ -2477,7 +2768,7 @@ func TestNewOrderWildcard(t *testing.T) {
 		core.ChallengeTypeTLSSNI01: true,
 		core.ChallengeTypeDNS01:    true,
 	}
-	pa, err := policy.New(supportedChallenges)
+	pa, err := policy.New(supportedChallenges, metrics.NewNoopScope())
 	test.AssertNotError(t, err, "Couldn't create PA")
 	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
 	test.AssertNotError(t, err, "Couldn't set hostname policy")
@@ -2744,6 +3035,56 @@ func TestNewOrderExpiry(t *testing.T) {
 	test.AssertEquals(t, *order.Expires, expectedOrderExpiry)
 }
 
+func TestNewOrderAuthzReuseWindow(t *testing.T) {
+	_, _, ra, clk, cleanUp := initAuthorities(t)
+	defer cleanUp()
+
+	regA := int64(1)
+	orderReq := &rapb.NewOrderRequest{
+		RegistrationID: &regA,
+		Names:          []string{"zombo.com"},
+	}
+	// The mock SA always returns a valid authz for "zombo.com" expiring in 35
+	// hours, which is outside of the default reuse window.
+	ra.SA = &mockSANearExpiredAuthz{expiry: clk.Now().Add(35 * time.Hour)}
+
+	order, err := ra.NewOrder(ctx, orderReq)
+	test.AssertNotError(t, err, "NewOrder failed")
+	test.AssertDeepEquals(t, order.Authorizations, []string{"near-expired-authz"})
+
+	// With a longer window the authz is no longer reused
+	ra.ValidAuthzReuseWindow = 48 * time.Hour
+	order, err = ra.NewOrder(ctx, orderReq)
+	test.AssertNotError(t, err, "NewOrder failed")
+	for _, id := range order.Authorizations {
+		test.AssertNotEquals(t, id, "near-expired-authz")
+	}
+
+	// The pending window doesn't apply to valid authzs
+	ra.ValidAuthzReuseWindow = 0
+	ra.PendingAuthzReuseWindow = 48 * time.Hour
+	order, err = ra.NewOrder(ctx, orderReq)
+	test.AssertNotError(t, err, "NewOrder failed")
+	test.AssertDeepEquals(t, order.Authorizations, []string{"near-expired-authz"})
+}
+
+func TestAuthzReuseCutoff(t *testing.T) {
+	fc := clock.NewFake()
+	ra := &RegistrationAuthorityImpl{clk: fc}
+	now := fc.Now()
+
+	test.AssertEquals(t, ra.authzReuseCutoff(core.StatusValid, false), now.Add(24*time.Hour))
+	test.AssertEquals(t, ra.authzReuseCutoff(core.StatusValid, true), now.Add(24*time.Hour))
+	test.AssertEquals(t, ra.authzReuseCutoff(core.StatusPending, false), now.Add(time.Hour))
+	test.AssertEquals(t, ra.authzReuseCutoff(core.StatusPending, true), now.Add(24*time.Hour))
+
+	ra.ValidAuthzReuseWindow = 72 * time.Hour
+	ra.PendingAuthzReuseWindow = 4 * time.Hour
+	test.AssertEquals(t, ra.authzReuseCutoff(core.StatusValid, false), now.Add(72*time.Hour))
+	test.AssertEquals(t, ra.authzReuseCutoff(core.StatusPending, false), now.Add(4*time.Hour))
+	test.AssertEquals(t, ra.authzReuseCutoff(core.StatusPending, true), now.Add(4*time.Hour))
+}
+
 func TestFinalizeOrder(t *testing.T) {
 	_, sa, ra, _, cleanUp := initAuthorities(t)
 	defer cleanUp()
@@ -3099,7 +3440,7 @@ func TestFinalizeOrderWildcard(t *testing.T) {
 		core.ChallengeTypeTLSSNI01: true,
 		core.ChallengeTypeDNS01:    true,
 	}
-	pa, err := policy.New(supportedChallenges)
+	pa, err := policy.New(supportedChallenges, metrics.NewNoopScope())
 	test.AssertNotError(t, err, "Couldn't create PA")
 	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
 	test.AssertNotError(t, err, "Couldn't set hostname policy")
@@ -3170,6 +3511,10 @@ func TestFinalizeOrderWildcard(t *testing.T) {
 		"wildcard order")
 	test.AssertEquals(t, err.Error(), "authorizations for these names not "+
 		"found or expired: *.zombo.com")
+	berr, ok := err.(*berrors.BoulderError)
+	test.Assert(t, ok, "FinalizeOrder error wasn't a BoulderError")
+	test.AssertEquals(t, len(berr.SubErrors), 1)
+	test.AssertEquals(t, berr.SubErrors[0].Identifier, identifier.DNSIdentifier("*.zombo.com"))
 
 	// Creating another order for the wildcard name
 	validOrder, err := ra.NewOrder(context.Background(), wildcardOrderRequest)
@@ -3196,6 +3541,83 @@ func TestFinalizeOrderWildcard(t *testing.T) {
 		"wildcard order")
 }
 
+func TestFinalizeOrderAsync(t *testing.T) {
+	_, sa, ra, _, cleanUp := initAuthorities(t)
+	defer cleanUp()
+
+	_ = features.Set(map[string]bool{"AsyncFinalize": true})
+	defer features.Reset()
+
+	testKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "Error creating test RSA key")
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		PublicKey:          testKey.PublicKey,
+		SignatureAlgorithm: x509.SHA256WithRSA,
+		DNSNames:           []string{"zombo.com"},
+	}, testKey)
+	test.AssertNotError(t, err, "Error creating CSR")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1338),
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().AddDate(0, 0, 1),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"zombo.com"},
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, testKey.Public(), testKey)
+	test.AssertNotError(t, err, "Error creating test certificate")
+	ra.CA = &mocks.MockCA{
+		PEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}),
+	}
+
+	order, err := ra.NewOrder(ctx, &rapb.NewOrderRequest{
+		RegistrationID: &Registration.ID,
+		Names:          []string{"zombo.com"},
+	})
+	test.AssertNotError(t, err, "NewOrder failed")
+	authz, err := sa.GetAuthorization(ctx, order.Authorizations[0])
+	test.AssertNotError(t, err, "GetAuthorization failed")
+	authz.Status = "valid"
+	authz.Challenges[0].Status = "valid"
+	err = sa.FinalizeAuthorization(ctx, authz)
+	test.AssertNotError(t, err, "Could not finalize order's pending authorization")
+
+	// Without workers running the order is finalized synchronously
+	syncOrder, err := ra.NewOrder(ctx, &rapb.NewOrderRequest{
+		RegistrationID: &Registration.ID,
+		Names:          []string{"zombo.com"},
+	})
+	test.AssertNotError(t, err, "NewOrder failed")
+	finalized, err := ra.FinalizeOrder(ctx, &rapb.FinalizeOrderRequest{Order: syncOrder, Csr: csr})
+	test.AssertNotError(t, err, "FinalizeOrder failed")
+	test.AssertEquals(t, *finalized.Status, string(core.StatusValid))
+
+	ra.StartFinalizeWorkers(1, 1, time.Minute)
+	finalized, err = ra.FinalizeOrder(ctx, &rapb.FinalizeOrderRequest{Order: order, Csr: csr})
+	test.AssertNotError(t, err, "FinalizeOrder failed")
+	test.AssertEquals(t, *finalized.Status, string(core.StatusProcessing))
+	test.Assert(t, finalized.CertificateSerial == nil, "Processing order has a certificate serial")
+
+	// Once the workers have drained the queue the order is valid
+	ra.StopFinalizeWorkers()
+	updated, err := sa.GetOrder(ctx, &sapb.OrderRequest{Id: order.Id})
+	test.AssertNotError(t, err, "GetOrder failed")
+	test.AssertEquals(t, *updated.Status, string(core.StatusValid))
+	test.AssertEquals(t, *updated.CertificateSerial, core.SerialToString(big.NewInt(1338)))
+}
+
+func TestEnqueueFinalize(t *testing.T) {
+	ra := &RegistrationAuthorityImpl{stats: metrics.NewNoopScope()}
+	test.Assert(t, !ra.enqueueFinalize(finalizeJob{}), "Queued an order without finalize workers")
+	// Stopping workers that were never started does nothing
+	ra.StopFinalizeWorkers()
+
+	ra.finalizeQueue = make(chan finalizeJob, 1)
+	test.Assert(t, ra.enqueueFinalize(finalizeJob{}), "Failed to queue an order")
+	test.Assert(t, !ra.enqueueFinalize(finalizeJob{}), "Queued an order beyond the queue size")
+}
+
 // TestUpdateMissingAuthorization tests the race condition where a challenge is
 // updated to valid concurrently with another attempt to have the challenge
 // updated. Previously this would return a `berrors.InternalServer` error when
@@ -3263,7 +3685,7 @@ func TestNewAuthzTLSSNIRevalidation(t *testing.T) {
 	_ = features.Set(map[string]bool{
 		"TLSSNIRevalidation": true,
 	})
-	pa, err := policy.New(challenges)
+	pa, err := policy.New(challenges, metrics.NewNoopScope())
 	test.AssertNotError(t, err, "Couldn't create PA")
 	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
 	test.AssertNotError(t, err, "Couldn't set hostname policy")
@@ -3329,7 +3751,7 @@ func TestValidChallengeStillGood(t *testing.T) {
 	defer cleanUp()
 	pa, err := policy.New(map[string]bool{
 		core.ChallengeTypeTLSSNI01: true,
-	})
+	}, metrics.NewNoopScope())
 	test.AssertNotError(t, err, "Couldn't create PA")
 	ra.PA = pa
 
@@ -3343,7 +3765,7 @@ func TestValidChallengeStillGood(t *testing.T) {
 func TestUpdateAuthorizationBadChallengeType(t *testing.T) {
 	_, _, ra, fc, cleanUp := initAuthorities(t)
 	defer cleanUp()
-	pa, err := policy.New(map[string]bool{})
+	pa, err := policy.New(map[string]bool{}, metrics.NewNoopScope())
 	test.AssertNotError(t, err, "Couldn't create PA")
 	ra.PA = pa
 
@@ -3370,7 +3792,7 @@ func TestCTPolicyMeasurements(t *testing.T) {
 	va, ssa, _, fc, cleanup := initAuthorities(t)
 	defer cleanup()
 
-	pa, err := policy.New(SupportedChallenges)
+	pa, err := policy.New(SupportedChallenges, metrics.NewNoopScope())
 	test.AssertNotError(t, err, "Couldn't create PA")
 	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
 	test.AssertNotError(t, err, "Couldn't set hostname policy")
@@ -3381,11 +3803,11 @@ func TestCTPolicyMeasurements(t *testing.T) {
 		PEM: eeCertPEM,
 	}
 
-	ctp := ctpolicy.New(&timeoutPub{}, []cmd.CTGroup{{}}, nil, log)
+	ctp := ctpolicy.New(&timeoutPub{}, nil, []cmd.CTGroup{{}}, nil, 0, log, metrics.NewNoopScope())
 	ra := NewRegistrationAuthorityImpl(fc,
 		log,
 		stats,
-		1, testKeyPolicy, 0, true, false, 300*24*time.Hour, 7*24*time.Hour, nil, noopCAA{}, 0, ctp)
+		1, testKeyPolicy, csrlib.Policy{CommonName: csrlib.CNPromote}, false, 300*24*time.Hour, 7*24*time.Hour, nil, noopCAA{}, 0, ctp)
 	ra.SA = ssa
 	ra.VA = va
 	ra.CA = ca
@@ -3415,6 +3837,163 @@ func TestCTPolicyMeasurements(t *testing.T) {
 	test.AssertEquals(t, test.CountHistogramSamples(ra.ctpolicyResults.With(prometheus.Labels{"result": "failure"})), 1)
 }
 
+// mockContingencyCA issues precertificates and records the requests for
+// certificates issued without them.
+type mockContingencyCA struct {
+	mocks.MockCA
+	issueReqs []*caPB.IssueCertificateRequest
+}
+
+func (ca *mockContingencyCA) IssuePrecertificate(ctx context.Context, req *caPB.IssueCertificateRequest) (*caPB.IssuePrecertificateResponse, error) {
+	cert, err := ca.MockCA.IssueCertificate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &caPB.IssuePrecertificateResponse{DER: cert.DER}, nil
+}
+
+func (ca *mockContingencyCA) IssueCertificate(ctx context.Context, req *caPB.IssueCertificateRequest) (core.Certificate, error) {
+	ca.issueReqs = append(ca.issueReqs, req)
+	return ca.MockCA.IssueCertificate(ctx, req)
+}
+
+func TestCTContingency(t *testing.T) {
+	va, ssa, _, fc, cleanup := initAuthorities(t)
+	defer cleanup()
+
+	pa, err := policy.New(SupportedChallenges, metrics.NewNoopScope())
+	test.AssertNotError(t, err, "Couldn't create PA")
+	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
+	test.AssertNotError(t, err, "Couldn't set hostname policy")
+
+	ca := &mockContingencyCA{MockCA: mocks.MockCA{PEM: eeCertPEM}}
+	ctp := ctpolicy.New(&timeoutPub{}, nil, []cmd.CTGroup{{}}, nil, 0, log, metrics.NewNoopScope())
+	ra := NewRegistrationAuthorityImpl(fc,
+		log,
+		metrics.NewNoopScope(),
+		1, testKeyPolicy, csrlib.Policy{CommonName: csrlib.CNPromote}, false, 300*24*time.Hour, 7*24*time.Hour, nil, noopCAA{}, 0, ctp)
+	ra.SA = ssa
+	ra.VA = va
+	ra.CA = ca
+	ra.PA = pa
+	ra.DNSClient = &bdns.MockDNSClient{}
+	ra.SCTDeadline = time.Millisecond
+
+	AuthzFinal.RegistrationID = Registration.ID
+	AuthzFinal, err := ssa.NewPendingAuthorization(ctx, AuthzFinal)
+	test.AssertNotError(t, err, "Could not store test data")
+	err = ssa.FinalizeAuthorization(ctx, AuthzFinal)
+	test.AssertNotError(t, err, "Could not store test data")
+	authzFinalWWW := AuthzFinal
+	authzFinalWWW.Identifier.Value = "www.not-example.com"
+	authzFinalWWW, err = ssa.NewPendingAuthorization(ctx, authzFinalWWW)
+	test.AssertNotError(t, err, "Could not store test data")
+	err = ssa.FinalizeAuthorization(ctx, authzFinalWWW)
+	test.AssertNotError(t, err, "Could not store test data")
+
+	_ = features.Set(map[string]bool{"EmbedSCTs": true})
+	defer features.Reset()
+
+	// Without the CTContingency feature failing to get SCTs fails issuance
+	_, err = ra.issueCertificate(ctx, core.CertificateRequest{CSR: ExampleCSR}, accountID(Registration.ID), 0)
+	test.AssertError(t, err, "ra.issueCertificate succeeded without SCTs")
+	test.AssertEquals(t, len(ca.issueReqs), 0)
+
+	// With it the certificate is issued without embedded SCTs
+	_ = features.Set(map[string]bool{"EmbedSCTs": true, "CTContingency": true})
+	log.Clear()
+	_, err = ra.issueCertificate(ctx, core.CertificateRequest{CSR: ExampleCSR}, accountID(Registration.ID), 0)
+	test.AssertNotError(t, err, "ra.issueCertificate failed in CT contingency mode")
+	test.AssertEquals(t, len(ca.issueReqs), 1)
+	test.Assert(t, ca.issueReqs[0].GetCtContingency(), "CA wasn't asked for a CT contingency certificate")
+	test.AssertEquals(t, len(log.GetAllMatching("CT contingency: issuing without embedded SCTs")), 1)
+
+	// The abandoned precertificate is stored so that OCSP is served for it
+	block, _ := pem.Decode(eeCertPEM)
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	test.AssertNotError(t, err, "Failed to parse test certificate")
+	_, err = ssa.GetCertificateStatus(ctx, core.SerialToString(parsed.SerialNumber))
+	test.AssertNotError(t, err, "Abandoned precertificate has no certificate status")
+}
+
+func TestCTLogFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	test.Assert(t, ctLogFailure(ctx, errors.New("CT log group \"a\": all submissions failed")), "Log failure not recognised")
+	test.Assert(t, ctLogFailure(ctx, context.DeadlineExceeded), "SCT deadline not recognised as a log failure")
+	test.Assert(t, !ctLogFailure(ctx, context.Canceled), "Canceled submission treated as a log failure")
+	cancel()
+	test.Assert(t, !ctLogFailure(ctx, context.Canceled), "Canceled request treated as a log failure")
+	test.Assert(t, !ctLogFailure(ctx, errors.New("all submissions failed")), "Failure of a canceled request treated as a log failure")
+}
+
+func TestEnforceCTPolicy(t *testing.T) {
+	va, ssa, _, fc, cleanup := initAuthorities(t)
+	defer cleanup()
+
+	pa, err := policy.New(SupportedChallenges, metrics.NewNoopScope())
+	test.AssertNotError(t, err, "Couldn't create PA")
+	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
+	test.AssertNotError(t, err, "Couldn't set hostname policy")
+
+	ctp := ctpolicy.New(&timeoutPub{}, nil, []cmd.CTGroup{{}}, nil, 0, log, metrics.NewNoopScope())
+	ra := NewRegistrationAuthorityImpl(fc,
+		log,
+		metrics.NewNoopScope(),
+		1, testKeyPolicy, csrlib.Policy{CommonName: csrlib.CNPromote}, false, 300*24*time.Hour, 7*24*time.Hour, nil, noopCAA{}, 0, ctp)
+	ra.SA = ssa
+	ra.VA = va
+	ca := &mockContingencyCA{MockCA: mocks.MockCA{PEM: eeCertPEM}}
+	ra.CA = ca
+	ra.PA = pa
+	ra.DNSClient = &bdns.MockDNSClient{}
+
+	AuthzFinal.RegistrationID = Registration.ID
+	AuthzFinal, err := ssa.NewPendingAuthorization(ctx, AuthzFinal)
+	test.AssertNotError(t, err, "Could not store test data")
+	err = ssa.FinalizeAuthorization(ctx, AuthzFinal)
+	test.AssertNotError(t, err, "Could not store test data")
+	authzFinalWWW := AuthzFinal
+	authzFinalWWW.Identifier.Value = "www.not-example.com"
+	authzFinalWWW, err = ssa.NewPendingAuthorization(ctx, authzFinalWWW)
+	test.AssertNotError(t, err, "Could not store test data")
+	err = ssa.FinalizeAuthorization(ctx, authzFinalWWW)
+	test.AssertNotError(t, err, "Could not store test data")
+
+	// Without enforcement the error getting SCTs is returned as it is
+	_ = features.Set(map[string]bool{"EmbedSCTs": true})
+	defer features.Reset()
+	_, err = ra.issueCertificate(ctx, core.CertificateRequest{CSR: ExampleCSR}, accountID(Registration.ID), 0)
+	test.AssertError(t, err, "ra.issueCertificate succeeded without SCTs")
+	test.Assert(t, !berrors.Is(err, berrors.CTUnavailable), "Error was mapped without an enforced CT policy")
+
+	// With it the failure is reported as the CT logs being unavailable, and
+	// no certificate is issued even in CT contingency mode
+	ra.EnforceCTPolicy = true
+	_ = features.Set(map[string]bool{"EmbedSCTs": true, "CTContingency": true})
+	_, err = ra.issueCertificate(ctx, core.CertificateRequest{CSR: ExampleCSR}, accountID(Registration.ID), 0)
+	test.AssertError(t, err, "ra.issueCertificate succeeded without SCTs")
+	test.Assert(t, berrors.Is(err, berrors.CTUnavailable), "Wrong error type")
+	test.AssertEquals(t, len(ca.issueReqs), 0)
+
+	// Without precertificates the policy can't be enforced, so nothing is
+	// issued
+	features.Reset()
+	_, err = ra.issueCertificate(ctx, core.CertificateRequest{CSR: ExampleCSR}, accountID(Registration.ID), 0)
+	test.AssertError(t, err, "ra.issueCertificate succeeded without EmbedSCTs")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), "Wrong error type")
+	test.AssertEquals(t, len(ca.issueReqs), 0)
+	_ = features.Set(map[string]bool{"EmbedSCTs": true})
+
+	// With no log groups configured GetSCTs trivially succeeds, but the
+	// policy can't be met
+	ra.ctpolicy = ctpolicy.New(&mocks.Publisher{}, nil, nil, nil, 0, log, metrics.NewNoopScope())
+	log.Clear()
+	_, err = ra.issueCertificate(ctx, core.CertificateRequest{CSR: ExampleCSR}, accountID(Registration.ID), 0)
+	test.AssertError(t, err, "ra.issueCertificate succeeded without any log groups")
+	test.Assert(t, berrors.Is(err, berrors.CTUnavailable), "Wrong error type")
+	test.AssertEquals(t, len(log.GetAllMatching("CT policy not met: got 0 valid SCTs, 0 log groups required")), 1)
+}
+
 func TestWildcardOverlap(t *testing.T) {
 	_ = features.Set(map[string]bool{"EnforceOverlappingWildcards": true})
 	defer features.Reset()
@@ -3593,3 +4172,505 @@ xGUhoOJp0T++nz6R3TX7Rwk7KmG6xX3vWr/MFu5A3c8fvkqj987Vti5BeBezCXfs
 rA==
 -----END CERTIFICATE-----
 `)
+
+// mockSARevocation records revocations and blocked keys. Certificates with
+// serial 01 belong to registration 1, every serial in keyHashSerials is
+// returned for any key, and registrations hold valid authorizations for the
+// names in authzNames.
+type mockSARevocation struct {
+	mocks.StorageAuthority
+	keyHashSerials []string
+	authzNames     map[int64][]string
+	statuses       map[string]core.OCSPStatus
+	revoked        map[string]revocation.Reason
+	blockedKeys    []string
+}
+
+func (sa *mockSARevocation) GetCertificate(_ context.Context, serial string) (core.Certificate, error) {
+	return core.Certificate{Serial: serial, RegistrationID: 1}, nil
+}
+
+func (sa *mockSARevocation) GetCertificateStatus(_ context.Context, serial string) (core.CertificateStatus, error) {
+	return core.CertificateStatus{Serial: serial, Status: sa.statuses[serial]}, nil
+}
+
+func (sa *mockSARevocation) GetValidAuthorizations(_ context.Context, regID int64, names []string, now time.Time) (map[string]*core.Authorization, error) {
+	expires := now.Add(time.Hour)
+	authzs := make(map[string]*core.Authorization)
+	requested := make(map[string]bool, len(names))
+	for _, name := range names {
+		requested[name] = true
+	}
+	for _, name := range sa.authzNames[regID] {
+		if !requested[name] {
+			continue
+		}
+		authzs[name] = &core.Authorization{
+			Identifier:     core.AcmeIdentifier{Type: core.IdentifierDNS, Value: name},
+			RegistrationID: regID,
+			Status:         core.StatusValid,
+			Expires:        &expires,
+		}
+	}
+	return authzs, nil
+}
+
+func (sa *mockSARevocation) MarkCertificateRevoked(_ context.Context, serial string, reasonCode revocation.Reason) error {
+	sa.revoked[serial] = reasonCode
+	return nil
+}
+
+func (sa *mockSARevocation) AddBlockedKey(_ context.Context, req *sapb.AddBlockedKeyRequest) (*corepb.Empty, error) {
+	sa.blockedKeys = append(sa.blockedKeys, *req.KeyHash)
+	return &corepb.Empty{}, nil
+}
+
+func (sa *mockSARevocation) GetSerialsByKey(_ context.Context, req *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error) {
+	return &sapb.Serials{Serials: sa.keyHashSerials}, nil
+}
+
+func TestRevocationReasonPolicy(t *testing.T) {
+	mockSA := &mockSARevocation{
+		revoked:    make(map[string]revocation.Reason),
+		authzNames: map[int64][]string{2: {"example.com"}},
+	}
+	ra := &RegistrationAuthorityImpl{
+		SA:    mockSA,
+		clk:   clock.NewFake(),
+		log:   blog.NewMock(),
+		stats: metrics.NewNoopScope(),
+	}
+	ctx := context.Background()
+	cert := x509.Certificate{SerialNumber: big.NewInt(1), DNSNames: []string{"example.com"}}
+	serial := core.SerialToString(cert.SerialNumber)
+
+	testCases := []struct {
+		regID   int64
+		reason  revocation.Reason
+		allowed bool
+	}{
+		// The subscriber
+		{1, revocation.Superseded, true},
+		{1, revocation.CACompromise, false},
+		// A third party holding authorizations for the names
+		{2, revocation.CessationOfOperation, true},
+		{2, revocation.KeyCompromise, false},
+		// The holder of the certificate's key
+		{0, revocation.KeyCompromise, true},
+		{0, revocation.Superseded, false},
+	}
+	for _, tc := range testCases {
+		delete(mockSA.revoked, serial)
+		err := ra.RevokeCertificateWithReg(ctx, cert, tc.reason, tc.regID)
+		if tc.allowed {
+			test.AssertNotError(t, err, fmt.Sprintf("regID %d reason %d rejected", tc.regID, tc.reason))
+			test.AssertEquals(t, mockSA.revoked[serial], tc.reason)
+		} else {
+			test.AssertError(t, err, fmt.Sprintf("regID %d reason %d allowed", tc.regID, tc.reason))
+			test.Assert(t, berrors.Is(err, berrors.Malformed), "Wrong error type")
+			_, present := mockSA.revoked[serial]
+			test.Assert(t, !present, "Certificate revoked with disallowed reason")
+		}
+	}
+
+	err := ra.AdministrativelyRevokeCertificate(ctx, cert, revocation.CACompromise, "root")
+	test.AssertNotError(t, err, "AdministrativelyRevokeCertificate failed")
+	err = ra.AdministrativelyRevokeCertificate(ctx, cert, revocation.CertificateHold, "root")
+	test.AssertError(t, err, "AdministrativelyRevokeCertificate allowed certificateHold")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "Wrong error type")
+}
+
+func TestRevokeThirdParty(t *testing.T) {
+	mockSA := &mockSARevocation{
+		revoked: make(map[string]revocation.Reason),
+		authzNames: map[int64][]string{
+			2: {"example.com", "www.example.net"},
+			3: {"example.com"},
+			4: {"*.example.com", "www.example.net"},
+		},
+	}
+	ra := &RegistrationAuthorityImpl{
+		SA:    mockSA,
+		clk:   clock.NewFake(),
+		log:   blog.NewMock(),
+		stats: metrics.NewNoopScope(),
+	}
+	ctx := context.Background()
+	cert := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"example.com", "*.example.com", "WWW.example.net"},
+	}
+	serial := core.SerialToString(cert.SerialNumber)
+
+	// Registration 3 only holds an authorization for the base domain, which
+	// also covers the wildcard name
+	err := ra.RevokeCertificateWithReg(ctx, cert, revocation.CessationOfOperation, 3)
+	test.AssertError(t, err, "Third party without authorizations for all names revoked certificate")
+	test.Assert(t, berrors.Is(err, berrors.Unauthorized), "Wrong error type")
+	test.AssertContains(t, err.Error(), "certificate: www.example.net")
+	_, present := mockSA.revoked[serial]
+	test.Assert(t, !present, "Certificate revoked by unauthorized third party")
+
+	// A wildcard authorization doesn't cover its base domain
+	err = ra.RevokeCertificateWithReg(ctx, cert, revocation.CessationOfOperation, 4)
+	test.AssertError(t, err, "Third party without authorizations for all names revoked certificate")
+	test.AssertContains(t, err.Error(), "certificate: example.com")
+	_, present = mockSA.revoked[serial]
+	test.Assert(t, !present, "Certificate revoked by unauthorized third party")
+
+	// Registration 2 holds authorizations for every name
+	err = ra.RevokeCertificateWithReg(ctx, cert, revocation.CessationOfOperation, 2)
+	test.AssertNotError(t, err, "Third party with authorizations for all names couldn't revoke certificate")
+	test.AssertEquals(t, mockSA.revoked[serial], revocation.Reason(revocation.CessationOfOperation))
+}
+
+func TestRevokeKeyCompromise(t *testing.T) {
+	testKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "error generating test key")
+	keyHash, err := core.KeyDigest(&testKey.PublicKey)
+	test.AssertNotError(t, err, "KeyDigest failed")
+	cert := x509.Certificate{SerialNumber: big.NewInt(1), PublicKey: &testKey.PublicKey}
+	serial := core.SerialToString(cert.SerialNumber)
+	sibling := core.SerialToString(big.NewInt(2))
+	alreadyRevoked := core.SerialToString(big.NewInt(3))
+
+	newRA := func() (*RegistrationAuthorityImpl, *mockSARevocation) {
+		mockSA := &mockSARevocation{
+			keyHashSerials: []string{serial, sibling, alreadyRevoked},
+			statuses:       map[string]core.OCSPStatus{alreadyRevoked: core.OCSPStatusRevoked},
+			revoked:        make(map[string]revocation.Reason),
+		}
+		return &RegistrationAuthorityImpl{
+			SA:    mockSA,
+			clk:   clock.NewFake(),
+			log:   blog.NewMock(),
+			stats: metrics.NewNoopScope(),
+		}, mockSA
+	}
+
+	// Without the BlockedKeyTable feature only the certificate is revoked
+	ra, mockSA := newRA()
+	err = ra.RevokeCertificateWithReg(context.Background(), cert, revocation.KeyCompromise, 0)
+	test.AssertNotError(t, err, "RevokeCertificateWithReg failed")
+	test.AssertDeepEquals(t, mockSA.revoked, map[string]revocation.Reason{serial: revocation.KeyCompromise})
+	test.AssertEquals(t, len(mockSA.blockedKeys), 0)
+
+	_ = features.Set(map[string]bool{"BlockedKeyTable": true})
+	defer features.Reset()
+
+	ra, mockSA = newRA()
+	err = ra.RevokeCertificateWithReg(context.Background(), cert, revocation.KeyCompromise, 0)
+	test.AssertNotError(t, err, "RevokeCertificateWithReg failed")
+	test.AssertDeepEquals(t, mockSA.blockedKeys, []string{keyHash})
+	test.AssertDeepEquals(t, mockSA.revoked, map[string]revocation.Reason{
+		serial:  revocation.KeyCompromise,
+		sibling: revocation.KeyCompromise,
+	})
+
+	// A request signed by the certificate's key without a reason is proof of
+	// key compromise, while one from the subscriber isn't
+	ra, mockSA = newRA()
+	err = ra.RevokeCertificateWithReg(context.Background(), cert, revocation.Unspecified, 0)
+	test.AssertNotError(t, err, "RevokeCertificateWithReg failed")
+	test.AssertDeepEquals(t, mockSA.blockedKeys, []string{keyHash})
+	test.AssertEquals(t, mockSA.revoked[serial], revocation.Reason(revocation.KeyCompromise))
+
+	ra, mockSA = newRA()
+	err = ra.RevokeCertificateWithReg(context.Background(), cert, revocation.Unspecified, 1)
+	test.AssertNotError(t, err, "RevokeCertificateWithReg failed")
+	test.AssertEquals(t, len(mockSA.blockedKeys), 0)
+	test.AssertDeepEquals(t, mockSA.revoked, map[string]revocation.Reason{serial: revocation.Unspecified})
+
+	ra, mockSA = newRA()
+	err = ra.AdministrativelyRevokeCertificate(context.Background(), cert, revocation.KeyCompromise, "root")
+	test.AssertNotError(t, err, "AdministrativelyRevokeCertificate failed")
+	test.AssertDeepEquals(t, mockSA.blockedKeys, []string{keyHash})
+	test.AssertEquals(t, mockSA.revoked[sibling], revocation.Reason(revocation.KeyCompromise))
+
+	// Other reasons don't block the key or revoke siblings
+	ra, mockSA = newRA()
+	err = ra.AdministrativelyRevokeCertificate(context.Background(), cert, revocation.Superseded, "root")
+	test.AssertNotError(t, err, "AdministrativelyRevokeCertificate failed")
+	test.AssertEquals(t, len(mockSA.blockedKeys), 0)
+	test.AssertEquals(t, len(mockSA.revoked), 1)
+}
+
+type mockSAWithRateLimitOverrides struct {
+	mocks.StorageAuthority
+	overrides []*sapb.RateLimitOverride
+}
+
+func (sa *mockSAWithRateLimitOverrides) GetRateLimitOverrides(_ context.Context, _ *corepb.Empty) (*sapb.RateLimitOverrides, error) {
+	return &sapb.RateLimitOverrides{Overrides: sa.overrides}, nil
+}
+
+func TestRefreshRateLimitOverrides(t *testing.T) {
+	override := func(regID int64, limitName string, threshold int64) *sapb.RateLimitOverride {
+		expires := time.Now().Add(time.Hour).UnixNano()
+		return &sapb.RateLimitOverride{
+			RegistrationID: &regID,
+			LimitName:      &limitName,
+			Threshold:      &threshold,
+			Expires:        &expires,
+		}
+	}
+	mockSA := &mockSAWithRateLimitOverrides{
+		overrides: []*sapb.RateLimitOverride{
+			override(1, "certificatesPerName", 1000),
+			override(2, "certificatesPerName", 5),
+			override(1, "newOrdersPerAccount", 500),
+			override(1, "certificatesPerDomain", 10),
+		},
+	}
+	ra := &RegistrationAuthorityImpl{
+		SA:         mockSA,
+		log:        blog.NewMock(),
+		rlPolicies: ratelimit.New(),
+	}
+	err := ra.rlPolicies.LoadPolicies([]byte(`
+certificatesPerName:
+  window: 2160h
+  threshold: 20
+newOrdersPerAccount:
+  window: 3h
+  threshold: 300
+`))
+	test.AssertNotError(t, err, "LoadPolicies failed")
+
+	err = ra.refreshRateLimitOverrides()
+	test.AssertNotError(t, err, "refreshRateLimitOverrides failed")
+	certsPerName := ra.rlPolicies.CertificatesPerName()
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 1), 1000)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 2), 5)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 3), 20)
+	newOrders := ra.rlPolicies.NewOrdersPerAccount()
+	test.AssertEquals(t, newOrders.GetThreshold("", 1), 500)
+
+	// Overrides no longer returned by the SA are removed
+	mockSA.overrides = mockSA.overrides[1:2]
+	err = ra.refreshRateLimitOverrides()
+	test.AssertNotError(t, err, "refreshRateLimitOverrides failed")
+	certsPerName = ra.rlPolicies.CertificatesPerName()
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 1), 20)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 2), 5)
+}
+
+func TestMaxNames(t *testing.T) {
+	expires := time.Now().Add(time.Hour).UnixNano()
+	regID, limitName, threshold := int64(2), ratelimit.MaxNamesPerCertificate, int64(3)
+	// An override of zero would lift the limit, so it's ignored
+	zeroRegID, zero := int64(3), int64(0)
+	mockSA := &mockSAWithRateLimitOverrides{
+		overrides: []*sapb.RateLimitOverride{
+			{
+				RegistrationID: &regID,
+				LimitName:      &limitName,
+				Threshold:      &threshold,
+				Expires:        &expires,
+			},
+			{
+				RegistrationID: &zeroRegID,
+				LimitName:      &limitName,
+				Threshold:      &zero,
+				Expires:        &expires,
+			},
+		},
+	}
+	ra := &RegistrationAuthorityImpl{
+		SA:         mockSA,
+		log:        blog.NewMock(),
+		rlPolicies: ratelimit.New(),
+		MaxNames:   2,
+	}
+	err := ra.refreshRateLimitOverrides()
+	test.AssertNotError(t, err, "refreshRateLimitOverrides failed")
+	test.AssertEquals(t, ra.maxNamesFor(1), 2)
+	test.AssertEquals(t, ra.maxNamesFor(2), 3)
+	test.AssertEquals(t, ra.maxNamesFor(3), 2)
+
+	names := []string{"a.com", "b.com", "c.com"}
+	err = ra.checkMaxNames(names, 1)
+	test.AssertError(t, err, "checkMaxNames allowed too many names")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "wrong error type")
+	test.AssertEquals(t, err.Error(),
+		"too many names: 3 requested, this account may request at most 2 names per certificate")
+	test.AssertNotError(t, ra.checkMaxNames(names, 2), "checkMaxNames ignored override")
+
+	// Orders are rejected before anything else is checked
+	id := int64(1)
+	_, err = ra.NewOrder(context.Background(), &rapb.NewOrderRequest{
+		RegistrationID: &id,
+		Names:          names,
+	})
+	test.AssertError(t, err, "NewOrder allowed too many names")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "wrong error type")
+
+	// Without a limit any number of names is allowed
+	ra.MaxNames = 0
+	test.AssertNotError(t, ra.checkMaxNames(names, 1), "checkMaxNames without a limit failed")
+}
+
+type mockSAWithOrderCount struct {
+	mocks.StorageAuthority
+	orders  int
+	pending int
+}
+
+func (sa *mockSAWithOrderCount) CountOrders(_ context.Context, _ int64, _, _ time.Time) (int, error) {
+	return sa.orders, nil
+}
+
+func (sa *mockSAWithOrderCount) CountPendingAuthorizations(_ context.Context, _ int64) (int, error) {
+	return sa.pending, nil
+}
+
+func TestCheckNewOrder(t *testing.T) {
+	fc := clock.NewFake()
+	ra := NewRegistrationAuthorityImpl(fc,
+		log,
+		metrics.NewNoopScope(),
+		1, testKeyPolicy, csrlib.Policy{CommonName: csrlib.CNPromote}, false, 300*24*time.Hour, 7*24*time.Hour, nil, noopCAA{}, 0, nil)
+	pa, err := policy.New(SupportedChallenges, metrics.NewNoopScope())
+	test.AssertNotError(t, err, "Couldn't create PA")
+	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
+	test.AssertNotError(t, err, "Couldn't set hostname policy")
+	ra.PA = pa
+	mockSA := &mockSAWithOrderCount{}
+	ra.SA = mockSA
+	counter := &mockRateLimitCounter{}
+	ra.RateLimitCounter = counter
+	ra.MaxNames = 2
+	err = ra.rlPolicies.LoadPolicies([]byte(`
+newOrdersPerAccount:
+  window: 3h
+  threshold: 2
+checkOrdersPerAccount:
+  window: 3h
+  threshold: 5
+pendingAuthorizationsPerAccount:
+  window: 0s
+  threshold: 1
+`))
+	test.AssertNotError(t, err, "LoadPolicies failed")
+
+	id := int64(1)
+	check := func(names ...string) error {
+		return ra.CheckNewOrder(context.Background(), &rapb.NewOrderRequest{
+			RegistrationID: &id,
+			Names:          names,
+		})
+	}
+
+	test.AssertNotError(t, check("example.com", "WWW.example.com", "www.example.com"), "CheckNewOrder failed")
+	err = check("a.example.com", "b.example.com", "c.example.com")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "too many names weren't rejected")
+	err = check("example.com", "example.net")
+	test.AssertError(t, err, "forbidden name wasn't rejected")
+
+	// The rate limit is counted by the shared counter without reserving an
+	// order, only the check order requests are reserved
+	counter.count = 2
+	err = check("example.com")
+	test.Assert(t, berrors.Is(err, berrors.RateLimit), "order over the rate limit wasn't rejected")
+	test.AssertEquals(t, len(counter.reserved), 4)
+	for _, key := range counter.reserved {
+		test.AssertEquals(t, key, "checkOrdersPerAccount:1")
+	}
+
+	// Check order requests over their own limit are rejected
+	counter.count = 5
+	err = check("example.com")
+	test.Assert(t, berrors.Is(err, berrors.RateLimit), "check order over the rate limit wasn't rejected")
+	test.AssertEquals(t, len(counter.reserved), 4)
+
+	// Names without an authorization to reuse are subject to the pending
+	// authorization limit
+	counter.count = 0
+	mockSA.pending = 1
+	err = check("example.com")
+	test.Assert(t, berrors.Is(err, berrors.RateLimit), "order over the pending authorization limit wasn't rejected")
+	mockSA.pending = 0
+
+	// If the shared counter fails the database is used
+	counter.count = 0
+	counter.err = errors.New("connection refused")
+	err = check("example.com")
+	test.AssertNotError(t, err, "order under the database rate limit was rejected")
+	mockSA.orders = 2
+	err = check("example.com")
+	test.Assert(t, berrors.Is(err, berrors.RateLimit), "order over the rate limit wasn't rejected")
+}
+
+func TestPolicyErrorForNames(t *testing.T) {
+	test.AssertEquals(t, policyErrorForNames(nil), nil)
+
+	malformed := berrors.SubBoulderError{
+		BoulderError: &berrors.BoulderError{Type: berrors.Malformed, Detail: "DNS name ends in a period"},
+		Identifier:   identifier.DNSIdentifier("example.com."),
+	}
+	rejected := berrors.SubBoulderError{
+		BoulderError: &berrors.BoulderError{Type: berrors.RejectedIdentifier, Detail: "Policy forbids issuing for name"},
+		Identifier:   identifier.DNSIdentifier("forbidden.com"),
+	}
+
+	// A single error is returned unchanged
+	err := policyErrorForNames([]berrors.SubBoulderError{malformed})
+	test.AssertEquals(t, err, error(malformed.BoulderError))
+
+	err = policyErrorForNames([]berrors.SubBoulderError{malformed, malformed})
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "expected a Malformed error")
+	test.AssertEquals(t, len(err.(*berrors.BoulderError).SubErrors), 2)
+
+	// Any forbidden name makes the error RejectedIdentifier
+	err = policyErrorForNames([]berrors.SubBoulderError{malformed, rejected})
+	test.Assert(t, berrors.Is(err, berrors.RejectedIdentifier), "expected a RejectedIdentifier error")
+	test.AssertEquals(t, err.Error(), "Cannot issue for 2 names, see subproblems for details")
+	test.AssertDeepEquals(t, err.(*berrors.BoulderError).SubErrors, []berrors.SubBoulderError{malformed, rejected})
+}
+
+func TestObserveOrderIssuanceLatency(t *testing.T) {
+	fc := clock.NewFake()
+	ra := NewRegistrationAuthorityImpl(fc,
+		blog.NewMock(),
+		metrics.NewNoopScope(),
+		1, testKeyPolicy, csrlib.Policy{}, false, 300*24*time.Hour, 7*24*time.Hour, nil, noopCAA{}, 0, nil)
+	ra.ShortLivedAccounts = map[int64]bool{2: true}
+
+	created := fc.Now().UnixNano()
+	fc.Add(90 * time.Second)
+	regID := int64(1)
+	ra.observeOrderIssuanceLatency(&corepb.Order{
+		RegistrationID: &regID,
+		Created:        &created,
+		Names:          []string{"example.com"},
+	})
+	shortLivedRegID := int64(2)
+	ra.observeOrderIssuanceLatency(&corepb.Order{
+		RegistrationID: &shortLivedRegID,
+		Created:        &created,
+		Names:          []string{"example.com", "www.example.com"},
+	})
+	// Orders without a creation time aren't observed
+	ra.observeOrderIssuanceLatency(&corepb.Order{RegistrationID: &regID})
+
+	hist := ra.orderIssuanceLatency.With(prometheus.Labels{"profile": "default", "names": "1"})
+	test.AssertEquals(t, test.CountHistogramSamples(hist), 1)
+	hist = ra.orderIssuanceLatency.With(prometheus.Labels{"profile": "shortLived", "names": "2-10"})
+	test.AssertEquals(t, test.CountHistogramSamples(hist), 1)
+}
+
+func TestNamesBucket(t *testing.T) {
+	testCases := []struct {
+		n        int
+		expected string
+	}{
+		{1, "1"},
+		{2, "2-10"},
+		{10, "2-10"},
+		{11, "11-100"},
+		{100, "11-100"},
+	}
+	for _, tc := range testCases {
+		test.AssertEquals(t, namesBucket(tc.n), tc.expected)
+	}
+}
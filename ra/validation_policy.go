@@ -0,0 +1,133 @@
+package ra
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// ValidationMethodRule restricts the challenge types that can be used to
+// validate a class of names.
+type ValidationMethodRule struct {
+	// Wildcard makes the rule apply to wildcard names, e.g. "*.example.com".
+	Wildcard bool
+	// Domains makes the rule apply to names that are equal to, or a subdomain
+	// of, one of these domains. Wildcard names match on their base domain.
+	Domains []string
+	// Challenges lists the challenge types permitted for names the rule applies
+	// to.
+	Challenges []string
+}
+
+// ValidationMethodPolicy restricts the challenge types that can be used to
+// validate some names. Names no rule applies to can be validated with any
+// challenge type the PA offers, and names more than one rule applies to must
+// be validated with a challenge type permitted by all of them.
+//
+// The policy is applied when authorizations are created, so that only
+// permitted challenges are offered, and when authorizations are reused. When
+// an order is finalized each name must have been validated with a permitted
+// challenge type.
+type ValidationMethodPolicy struct {
+	Rules []ValidationMethodRule
+}
+
+// Validate checks that every rule applies to some names and only permits
+// known challenge types.
+func (p ValidationMethodPolicy) Validate() error {
+	for i, rule := range p.Rules {
+		if !rule.Wildcard && len(rule.Domains) == 0 {
+			return fmt.Errorf("validation method rule %d applies to no names", i)
+		}
+		for _, domain := range rule.Domains {
+			if domain == "" || domain != strings.ToLower(domain) || strings.Contains(domain, "*") {
+				return fmt.Errorf("validation method rule %d has invalid domain %q", i, domain)
+			}
+		}
+		if len(rule.Challenges) == 0 {
+			return fmt.Errorf("validation method rule %d permits no challenge types", i)
+		}
+		for _, challType := range rule.Challenges {
+			if !core.ValidChallenge(challType) {
+				return fmt.Errorf("validation method rule %d has unknown challenge type %q", i, challType)
+			}
+		}
+	}
+	return nil
+}
+
+func (rule ValidationMethodRule) appliesTo(name string) bool {
+	if strings.HasPrefix(name, "*.") {
+		if rule.Wildcard {
+			return true
+		}
+		name = strings.TrimPrefix(name, "*.")
+	}
+	for _, domain := range rule.Domains {
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// restricted returns whether any rule applies to name.
+func (p ValidationMethodPolicy) restricted(name string) bool {
+	for _, rule := range p.Rules {
+		if rule.appliesTo(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// permitted returns whether name may be validated with challType.
+func (p ValidationMethodPolicy) permitted(name, challType string) bool {
+	for _, rule := range p.Rules {
+		if !rule.appliesTo(name) {
+			continue
+		}
+		var found bool
+		for _, t := range rule.Challenges {
+			if t == challType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterChallenges removes the challenges that aren't permitted for name,
+// along with any combinations that include them. The indices of the remaining
+// combinations are updated to refer to the remaining challenges.
+func (p ValidationMethodPolicy) filterChallenges(name string, challenges []core.Challenge, combinations [][]int) ([]core.Challenge, [][]int) {
+	if !p.restricted(name) {
+		return challenges, combinations
+	}
+	var filtered []core.Challenge
+	newIndex := make(map[int]int, len(challenges))
+	for i, chall := range challenges {
+		if p.permitted(name, chall.Type) {
+			newIndex[i] = len(filtered)
+			filtered = append(filtered, chall)
+		}
+	}
+	var filteredCombos [][]int
+	for _, combo := range combinations {
+		newCombo := make([]int, 0, len(combo))
+		for _, i := range combo {
+			if j, ok := newIndex[i]; ok {
+				newCombo = append(newCombo, j)
+			}
+		}
+		if len(newCombo) == len(combo) {
+			filteredCombos = append(filteredCombos, newCombo)
+		}
+	}
+	return filtered, filteredCombos
+}
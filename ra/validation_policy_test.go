@@ -0,0 +1,137 @@
+package ra
+
+import (
+	"testing"
+
+	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/test"
+)
+
+var testValidationMethods = ValidationMethodPolicy{
+	Rules: []ValidationMethodRule{
+		{Wildcard: true, Challenges: []string{core.ChallengeTypeDNS01}},
+		{Domains: []string{"bank.example"}, Challenges: []string{core.ChallengeTypeDNS01, core.ChallengeTypeTLSSNI01}},
+	},
+}
+
+func TestValidationMethodPolicyValidate(t *testing.T) {
+	test.AssertNotError(t, ValidationMethodPolicy{}.Validate(), "Empty policy was invalid")
+	test.AssertNotError(t, testValidationMethods.Validate(), "Valid policy was invalid")
+
+	for _, rule := range []ValidationMethodRule{
+		{Challenges: []string{core.ChallengeTypeDNS01}},
+		{Wildcard: true},
+		{Wildcard: true, Challenges: []string{"dns-02"}},
+		{Domains: []string{""}, Challenges: []string{core.ChallengeTypeDNS01}},
+		{Domains: []string{"Bank.example"}, Challenges: []string{core.ChallengeTypeDNS01}},
+		{Domains: []string{"*.bank.example"}, Challenges: []string{core.ChallengeTypeDNS01}},
+	} {
+		p := ValidationMethodPolicy{Rules: []ValidationMethodRule{rule}}
+		test.AssertError(t, p.Validate(), "Invalid policy was valid")
+	}
+}
+
+func TestValidationMethodPolicyPermitted(t *testing.T) {
+	p := testValidationMethods
+	testCases := []struct {
+		name      string
+		challType string
+		permitted bool
+	}{
+		{"example.com", core.ChallengeTypeHTTP01, true},
+		{"notbank.example", core.ChallengeTypeHTTP01, true},
+		{"bank.example", core.ChallengeTypeHTTP01, false},
+		{"www.bank.example", core.ChallengeTypeHTTP01, false},
+		{"www.bank.example", core.ChallengeTypeTLSSNI01, true},
+		{"*.example.com", core.ChallengeTypeHTTP01, false},
+		{"*.example.com", core.ChallengeTypeDNS01, true},
+		// Both rules apply to a wildcard under bank.example
+		{"*.bank.example", core.ChallengeTypeTLSSNI01, false},
+		{"*.bank.example", core.ChallengeTypeDNS01, true},
+	}
+	for _, tc := range testCases {
+		if p.permitted(tc.name, tc.challType) != tc.permitted {
+			t.Errorf("permitted(%q, %q) = %t, expected %t", tc.name, tc.challType, !tc.permitted, tc.permitted)
+		}
+	}
+	test.Assert(t, !p.restricted("example.com"), "Unrestricted name was restricted")
+	test.Assert(t, p.restricted("www.bank.example"), "Restricted name wasn't restricted")
+}
+
+func TestValidationMethodPolicyFilterChallenges(t *testing.T) {
+	challenges := []core.Challenge{
+		{Type: core.ChallengeTypeHTTP01},
+		{Type: core.ChallengeTypeDNS01},
+		{Type: core.ChallengeTypeTLSSNI01},
+	}
+	combinations := [][]int{{2}, {0}, {1}}
+
+	filtered, combos := testValidationMethods.filterChallenges("example.com", challenges, combinations)
+	test.AssertDeepEquals(t, filtered, challenges)
+	test.AssertDeepEquals(t, combos, combinations)
+
+	filtered, combos = testValidationMethods.filterChallenges("www.bank.example", challenges, combinations)
+	test.AssertDeepEquals(t, filtered, []core.Challenge{{Type: core.ChallengeTypeDNS01}, {Type: core.ChallengeTypeTLSSNI01}})
+	test.AssertDeepEquals(t, combos, [][]int{{1}, {0}})
+
+	filtered, combos = ValidationMethodPolicy{
+		Rules: []ValidationMethodRule{{Domains: []string{"example.com"}, Challenges: []string{core.ChallengeTypeTLSSNI01}}},
+	}.filterChallenges("example.com", challenges[:2], [][]int{{0, 1}})
+	test.AssertEquals(t, len(filtered), 0)
+	test.AssertEquals(t, len(combos), 0)
+}
+
+func TestCheckValidationMethods(t *testing.T) {
+	ra := &RegistrationAuthorityImpl{ValidationMethods: testValidationMethods}
+	validAuthz := func(challType string) *core.Authorization {
+		return &core.Authorization{
+			Challenges: []core.Challenge{
+				{Type: core.ChallengeTypeHTTP01, Status: core.StatusPending},
+				{Type: challType, Status: core.StatusValid},
+			},
+		}
+	}
+	authzs := map[string]*core.Authorization{
+		"example.com":      validAuthz(core.ChallengeTypeHTTP01),
+		"www.bank.example": validAuthz(core.ChallengeTypeTLSSNI01),
+		"*.example.com":    validAuthz(core.ChallengeTypeDNS01),
+	}
+	names := []string{"*.example.com", "example.com", "www.bank.example"}
+
+	var logEvent certificateRequestEvent
+	err := ra.checkValidationMethods(names, authzs, &logEvent)
+	test.AssertNotError(t, err, "Permitted validation methods were rejected")
+	test.AssertDeepEquals(t, logEvent.ValidationMethods, map[string]string{
+		"example.com":      core.ChallengeTypeHTTP01,
+		"www.bank.example": core.ChallengeTypeTLSSNI01,
+		"*.example.com":    core.ChallengeTypeDNS01,
+	})
+
+	authzs["www.bank.example"] = validAuthz(core.ChallengeTypeHTTP01)
+	err = ra.checkValidationMethods(names, authzs, &logEvent)
+	test.Assert(t, berrors.Is(err, berrors.Unauthorized), "Forbidden validation method wasn't rejected")
+	test.AssertEquals(t, logEvent.ValidationMethods["www.bank.example"], core.ChallengeTypeHTTP01)
+}
+
+func TestOrderAuthzPermitted(t *testing.T) {
+	ra := &RegistrationAuthorityImpl{ValidationMethods: testValidationMethods}
+	makeAuthz := func(status string, challenges ...*corepb.Challenge) *corepb.Authorization {
+		return &corepb.Authorization{Status: &status, Challenges: challenges}
+	}
+	makeChall := func(challType string, status core.AcmeStatus) *corepb.Challenge {
+		statusString := string(status)
+		return &corepb.Challenge{Type: &challType, Status: &statusString}
+	}
+	http01 := makeChall(core.ChallengeTypeHTTP01, core.StatusPending)
+	dns01 := makeChall(core.ChallengeTypeDNS01, core.StatusPending)
+	validHTTP01 := makeChall(core.ChallengeTypeHTTP01, core.StatusValid)
+	validDNS01 := makeChall(core.ChallengeTypeDNS01, core.StatusValid)
+
+	test.Assert(t, ra.orderAuthzPermitted("example.com", makeAuthz("pending", http01, dns01)), "Unrestricted authz wasn't permitted")
+	test.Assert(t, !ra.orderAuthzPermitted("bank.example", makeAuthz("pending", http01, dns01)), "Pending authz offering http-01 was permitted")
+	test.Assert(t, ra.orderAuthzPermitted("bank.example", makeAuthz("pending", dns01)), "Pending authz only offering dns-01 wasn't permitted")
+	test.Assert(t, ra.orderAuthzPermitted("bank.example", makeAuthz("valid", http01, validDNS01)), "Authz validated with dns-01 wasn't permitted")
+	test.Assert(t, !ra.orderAuthzPermitted("bank.example", makeAuthz("valid", validHTTP01, dns01)), "Authz validated with http-01 was permitted")
+}
@@ -0,0 +1,26 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Counter counts events in sliding windows shared between RA instances, so
+// that limits are enforced globally rather than against each instance's view
+// of the database. An event is reserved when its limit is checked, so that
+// concurrent requests on different instances can't both get the last event
+// under the threshold, and is released if, say, the registration it was
+// reserved for then fails to be stored, so that requests which fail don't
+// count towards a limit.
+type Counter interface {
+	// Count returns the number of events recorded for key in the window
+	// ending at now.
+	Count(ctx context.Context, key string, window time.Duration, now time.Time) (int, error)
+	// Reserve records an event for key at now, which is counted until it
+	// leaves the window, unless threshold events are already counted in the
+	// window ending at now. It returns an ID for the event to release it with,
+	// or an empty string if the limit has been reached.
+	Reserve(ctx context.Context, key string, window time.Duration, now time.Time, threshold int) (string, error)
+	// Release removes an event recorded by Reserve.
+	Release(ctx context.Context, key, id string) error
+}
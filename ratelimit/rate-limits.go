@@ -1,6 +1,7 @@
 package ratelimit
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -18,12 +19,19 @@ type Limits interface {
 	RegistrationsPerIPRange() RateLimitPolicy
 	PendingAuthorizationsPerAccount() RateLimitPolicy
 	InvalidAuthorizationsPerAccount() RateLimitPolicy
+	FailedValidationsPerAccount() RateLimitPolicy
 	CertificatesPerFQDNSet() RateLimitPolicy
 	PendingOrdersPerAccount() RateLimitPolicy
 	NewOrdersPerAccount() RateLimitPolicy
+	CheckOrdersPerAccount() RateLimitPolicy
 	LoadPolicies(contents []byte) error
+	SetRegistrationOverrides(overrides RegistrationOverrides) error
 }
 
+// RegistrationOverrides maps the name of a limit, as used in the policy file
+// (e.g. "certificatesPerName"), to per-registration thresholds for it.
+type RegistrationOverrides map[string]map[int64]int
+
 // limitsImpl is an unexported implementation of the Limits interface. It acts
 // as a container for a rateLimitConfig and a mutex. This allows the inner
 // rateLimitConfig pointer to be updated safely when the overall configuration
@@ -31,6 +39,11 @@ type Limits interface {
 type limitsImpl struct {
 	sync.RWMutex
 	rlPolicy *rateLimitConfig
+	// filePolicy is the configuration last loaded from the policy file, and
+	// regOverrides the overrides last set by SetRegistrationOverrides.
+	// rlPolicy is the combination of the two.
+	filePolicy   *rateLimitConfig
+	regOverrides RegistrationOverrides
 }
 
 func (r *limitsImpl) TotalCertificates() RateLimitPolicy {
@@ -87,6 +100,15 @@ func (r *limitsImpl) InvalidAuthorizationsPerAccount() RateLimitPolicy {
 	return r.rlPolicy.InvalidAuthorizationsPerAccount
 }
 
+func (r *limitsImpl) FailedValidationsPerAccount() RateLimitPolicy {
+	r.RLock()
+	defer r.RUnlock()
+	if r.rlPolicy == nil {
+		return RateLimitPolicy{}
+	}
+	return r.rlPolicy.FailedValidationsPerAccount
+}
+
 func (r *limitsImpl) CertificatesPerFQDNSet() RateLimitPolicy {
 	r.RLock()
 	defer r.RUnlock()
@@ -114,6 +136,15 @@ func (r *limitsImpl) NewOrdersPerAccount() RateLimitPolicy {
 	return r.rlPolicy.NewOrdersPerAccount
 }
 
+func (r *limitsImpl) CheckOrdersPerAccount() RateLimitPolicy {
+	r.RLock()
+	defer r.RUnlock()
+	if r.rlPolicy == nil {
+		return RateLimitPolicy{}
+	}
+	return r.rlPolicy.CheckOrdersPerAccount
+}
+
 // LoadPolicies loads various rate limiting policies from a byte array of
 // YAML configuration (typically read from disk by a reloader)
 func (r *limitsImpl) LoadPolicies(contents []byte) error {
@@ -124,11 +155,71 @@ func (r *limitsImpl) LoadPolicies(contents []byte) error {
 	}
 
 	r.Lock()
-	r.rlPolicy = &newPolicy
-	r.Unlock()
+	defer r.Unlock()
+	r.filePolicy = &newPolicy
+	r.rlPolicy = mergeOverrides(r.filePolicy, r.regOverrides)
+	return nil
+}
+
+// SetRegistrationOverrides replaces the per-registration overrides applied on
+// top of those in the policy file, e.g. with overrides read from the database.
+// Where both set a threshold for the same registration and limit, the one set
+// here is used.
+func (r *limitsImpl) SetRegistrationOverrides(overrides RegistrationOverrides) error {
+	for name := range overrides {
+		if !ValidLimitName(name) {
+			return fmt.Errorf("unknown rate limit %q", name)
+		}
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.regOverrides = overrides
+	r.rlPolicy = mergeOverrides(r.filePolicy, r.regOverrides)
 	return nil
 }
 
+// mergeOverrides returns a copy of policy with overrides added to the
+// registration overrides of each limit. The maps in policy are not modified.
+func mergeOverrides(policy *rateLimitConfig, overrides RegistrationOverrides) *rateLimitConfig {
+	if policy == nil {
+		return nil
+	}
+	merged := *policy
+	for name, regOverrides := range overrides {
+		limit := merged.byName(name)
+		if limit == nil || len(regOverrides) == 0 {
+			continue
+		}
+		combined := make(map[int64]int, len(limit.RegistrationOverrides)+len(regOverrides))
+		for regID, threshold := range limit.RegistrationOverrides {
+			combined[regID] = threshold
+		}
+		for regID, threshold := range regOverrides {
+			combined[regID] = threshold
+		}
+		limit.RegistrationOverrides = combined
+	}
+	return &merged
+}
+
+// ValidLimitName returns true if name is the name of a limit in the policy
+// file.
+func ValidLimitName(name string) bool {
+	return (&rateLimitConfig{}).byName(name) != nil
+}
+
+// MaxNamesPerCertificate is the limit name under which per-registration
+// overrides of the RA's MaxNames setting are stored alongside rate limit
+// overrides. It isn't a limit in the policy file.
+const MaxNamesPerCertificate = "maxNamesPerCertificate"
+
+// ValidOverrideName returns true if name is a limit that can be overridden
+// per registration, either in the policy file or by MaxNamesPerCertificate.
+func ValidOverrideName(name string) bool {
+	return ValidLimitName(name) || name == MaxNamesPerCertificate
+}
+
 func New() Limits {
 	return &limitsImpl{}
 }
@@ -163,17 +254,58 @@ type rateLimitConfig struct {
 	// Note that this limit is actually "per account, per hostname," but that
 	// is too long for the variable name.
 	InvalidAuthorizationsPerAccount RateLimitPolicy `yaml:"invalidAuthorizationsPerAccount"`
+	// Number of validations of a hostname that can fail per account within the
+	// given window, counted from when each validation failed. Overrides by key
+	// are not applied, but overrides by registration are. Like
+	// InvalidAuthorizationsPerAccount this limit is "per account, per
+	// hostname." It requires the FailedValidationsTable feature.
+	FailedValidationsPerAccount RateLimitPolicy `yaml:"failedValidationsPerAccount"`
 	// Number of pending orders that can exist per account. Overrides by key are
 	// not applied, but overrides by registration are. **DEPRECATED**
 	PendingOrdersPerAccount RateLimitPolicy `yaml:"pendingOrdersPerAccount"`
 	// Number of new orders that can be created per account within the given
 	// window. Overrides by key are not applied, but overrides by registration are.
 	NewOrdersPerAccount RateLimitPolicy `yaml:"newOrdersPerAccount"`
+	// Number of check order requests that can be made per account within the
+	// given window. It is only enforced when the RA has a shared rate limit
+	// counter. Overrides by key are not applied, but overrides by registration
+	// are.
+	CheckOrdersPerAccount RateLimitPolicy `yaml:"checkOrdersPerAccount"`
 	// Number of certificates that can be extant containing a specific set
 	// of DNS names.
 	CertificatesPerFQDNSet RateLimitPolicy `yaml:"certificatesPerFQDNSet"`
 }
 
+// byName returns the limit with the given policy file name, or nil if there
+// isn't one.
+func (c *rateLimitConfig) byName(name string) *RateLimitPolicy {
+	switch name {
+	case "totalCertificates":
+		return &c.TotalCertificates
+	case "certificatesPerName":
+		return &c.CertificatesPerName
+	case "registrationsPerIP":
+		return &c.RegistrationsPerIP
+	case "registrationsPerIPRange":
+		return &c.RegistrationsPerIPRange
+	case "pendingAuthorizationsPerAccount":
+		return &c.PendingAuthorizationsPerAccount
+	case "invalidAuthorizationsPerAccount":
+		return &c.InvalidAuthorizationsPerAccount
+	case "failedValidationsPerAccount":
+		return &c.FailedValidationsPerAccount
+	case "pendingOrdersPerAccount":
+		return &c.PendingOrdersPerAccount
+	case "newOrdersPerAccount":
+		return &c.NewOrdersPerAccount
+	case "checkOrdersPerAccount":
+		return &c.CheckOrdersPerAccount
+	case "certificatesPerFQDNSet":
+		return &c.CertificatesPerFQDNSet
+	}
+	return nil
+}
+
 // RateLimitPolicy describes a general limiting policy
 type RateLimitPolicy struct {
 	// How long to count items for
@@ -192,7 +324,8 @@ type RateLimitPolicy struct {
 	// A per-registration override setting. This can be used, e.g. if there are
 	// hosting providers that we would like to grant a higher rate of issuance
 	// than the default. If both key-based and registration-based overrides are
-	// available, the registration-based on takes priority.
+	// available, the registration-based on takes priority. Overrides set with
+	// SetRegistrationOverrides, e.g. from the database, are included here.
 	RegistrationOverrides map[int64]int `yaml:"registrationOverrides"`
 }
 
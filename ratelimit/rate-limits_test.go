@@ -113,6 +113,12 @@ func TestLoadPolicies(t *testing.T) {
 	test.AssertEquals(t, len(pendingAuthsPerAcct.Overrides), 0)
 	test.AssertEquals(t, len(pendingAuthsPerAcct.RegistrationOverrides), 0)
 
+	// Test that the FailedValidationsPerAccount section parsed correctly
+	failedValidationsPerAcct := policy.FailedValidationsPerAccount()
+	test.AssertEquals(t, failedValidationsPerAcct.Threshold, 20)
+	test.AssertEquals(t, failedValidationsPerAcct.Window.Duration, time.Hour)
+	test.AssertEquals(t, len(failedValidationsPerAcct.RegistrationOverrides), 0)
+
 	// Test that the CertificatesPerFQDN section parsed correctly
 	certsPerFQDN := policy.CertificatesPerFQDNSet()
 	test.AssertEquals(t, certsPerFQDN.Threshold, 5)
@@ -147,5 +153,68 @@ func TestLoadPolicies(t *testing.T) {
 	test.AssertEquals(t, emptyPolicy.RegistrationsPerIP().Threshold, 0)
 	test.AssertEquals(t, emptyPolicy.RegistrationsPerIP().Threshold, 0)
 	test.AssertEquals(t, emptyPolicy.PendingAuthorizationsPerAccount().Threshold, 0)
+	test.AssertEquals(t, emptyPolicy.FailedValidationsPerAccount().Threshold, 0)
 	test.AssertEquals(t, emptyPolicy.CertificatesPerFQDNSet().Threshold, 0)
 }
+
+func TestSetRegistrationOverrides(t *testing.T) {
+	policy := New()
+
+	// Overrides set before the policy file is loaded are applied once it is
+	overrides := RegistrationOverrides{
+		"certificatesPerName": {101: 1000, 102: 5},
+		"newOrdersPerAccount": {103: 500},
+	}
+	err := policy.SetRegistrationOverrides(overrides)
+	test.AssertNotError(t, err, "SetRegistrationOverrides failed")
+	test.AssertEquals(t, policy.CertificatesPerName().Threshold, 0)
+
+	err = policy.LoadPolicies([]byte(`
+certificatesPerName:
+  window: 2160h
+  threshold: 2
+  registrationOverrides:
+    101: 10
+    104: 20
+newOrdersPerAccount:
+  window: 3h
+  threshold: 300
+`))
+	test.AssertNotError(t, err, "LoadPolicies failed")
+	certsPerName := policy.CertificatesPerName()
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 101), 1000)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 102), 5)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 104), 20)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 105), 2)
+	newOrders := policy.NewOrdersPerAccount()
+	test.AssertEquals(t, newOrders.GetThreshold("", 103), 500)
+	test.AssertEquals(t, newOrders.GetThreshold("", 101), 300)
+
+	// Replacing the overrides removes any that are no longer present
+	err = policy.SetRegistrationOverrides(RegistrationOverrides{"certificatesPerName": {102: 50}})
+	test.AssertNotError(t, err, "SetRegistrationOverrides failed")
+	certsPerName = policy.CertificatesPerName()
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 101), 10)
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 102), 50)
+	newOrders = policy.NewOrdersPerAccount()
+	test.AssertEquals(t, newOrders.GetThreshold("", 103), 300)
+
+	err = policy.SetRegistrationOverrides(RegistrationOverrides{"certificatesPerDomain": {101: 1}})
+	test.AssertError(t, err, "SetRegistrationOverrides accepted an unknown limit")
+	certsPerName = policy.CertificatesPerName()
+	test.AssertEquals(t, certsPerName.GetThreshold("example.com", 102), 50)
+}
+
+func TestValidLimitName(t *testing.T) {
+	test.Assert(t, ValidLimitName("certificatesPerName"), "certificatesPerName not valid")
+	test.Assert(t, ValidLimitName("registrationsPerIPRange"), "registrationsPerIPRange not valid")
+	test.Assert(t, !ValidLimitName("CertificatesPerName"), "CertificatesPerName valid")
+	test.Assert(t, !ValidLimitName(""), "empty name valid")
+	test.Assert(t, !ValidLimitName(MaxNamesPerCertificate), "maxNamesPerCertificate valid")
+}
+
+func TestValidOverrideName(t *testing.T) {
+	test.Assert(t, ValidOverrideName("newOrdersPerAccount"), "newOrdersPerAccount not valid")
+	test.Assert(t, ValidOverrideName(MaxNamesPerCertificate), "maxNamesPerCertificate not valid")
+	test.Assert(t, !ValidOverrideName("certificatesPerDomain"), "certificatesPerDomain valid")
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	redigo "github.com/garyburd/redigo/redis"
+
+	"github.com/letsencrypt/boulder/redis"
+)
+
+// Each key's events are members of a sorted set scored by their time in
+// microseconds, which unlike nanoseconds can be represented exactly by Lua's
+// numbers.
+
+// countLua removes the events of a key that have left the window and returns
+// the number that remain. KEYS[1] is the key and ARGV is the current time and
+// window in microseconds.
+const countLua = `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', tonumber(ARGV[1]) - tonumber(ARGV[2]))
+return redis.call('ZCARD', KEYS[1])
+`
+
+var countScript = redigo.NewScript(1, countLua)
+
+// reserveLua removes the events of a key that have left the window and, if
+// fewer than the threshold remain, records an event and has the set expire
+// once the event leaves the window. It returns 1 if the event was recorded and
+// 0 otherwise. Since a script runs atomically, concurrent reservations can't
+// exceed the threshold between them. KEYS[1] is the key and ARGV is the
+// current time and window in microseconds, the threshold and a unique member
+// for the event.
+const reserveLua = `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', tonumber(ARGV[1]) - tonumber(ARGV[2]))
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+  return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[4])
+redis.call('PEXPIRE', KEYS[1], math.ceil(tonumber(ARGV[2]) / 1000))
+return 1
+`
+
+var reserveScript = redigo.NewScript(1, reserveLua)
+
+// RedisCounter is a Counter that keeps its windows in a single Redis server.
+type RedisCounter struct {
+	client *redis.Client
+}
+
+// NewRedisCounter returns a RedisCounter for the Redis server at addr. If
+// password isn't empty each connection authenticates with it. Each command is
+// given timeout to complete, and at most maxIdle connections are kept open
+// between commands.
+func NewRedisCounter(addr, password string, timeout time.Duration, maxIdle int) (*RedisCounter, error) {
+	client, err := redis.NewClient(addr, password, timeout, maxIdle)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisCounter{client: client}, nil
+}
+
+// Count implements Counter.
+func (r *RedisCounter) Count(ctx context.Context, key string, window time.Duration, now time.Time) (int, error) {
+	return redigo.Int(r.client.Eval(ctx, countScript, key, now.UnixNano()/1000, int64(window/time.Microsecond)))
+}
+
+// Reserve implements Counter. The returned ID is the event's member of the
+// key's sorted set.
+func (r *RedisCounter) Reserve(ctx context.Context, key string, window time.Duration, now time.Time, threshold int) (string, error) {
+	var nonce [8]byte
+	_, err := rand.Read(nonce[:])
+	if err != nil {
+		return "", err
+	}
+	nowMicros := now.UnixNano() / 1000
+	member := hex.EncodeToString(nonce[:])
+	reserved, err := redigo.Int(r.client.Eval(ctx, reserveScript, key, nowMicros, int64(window/time.Microsecond), threshold, member))
+	if err != nil || reserved == 0 {
+		return "", err
+	}
+	return member, nil
+}
+
+// Release implements Counter.
+func (r *RedisCounter) Release(ctx context.Context, key, id string) error {
+	_, err := r.client.Do(ctx, "ZREM", key, id)
+	return err
+}
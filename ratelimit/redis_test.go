@@ -0,0 +1,225 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	redigo "github.com/garyburd/redigo/redis"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// fakeRedis is a Redis server that understands AUTH, ZREM, and EVALSHA and
+// EVAL of countLua and reserveLua, which it emulates.
+type fakeRedis struct {
+	l        net.Listener
+	password string
+
+	sync.Mutex
+	loaded map[string]string
+	evals  int
+	events map[string][]fakeEvent
+}
+
+type fakeEvent struct {
+	member string
+	at     int64
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	test.AssertNotError(t, err, "Couldn't listen")
+	f := &fakeRedis{
+		l:        l,
+		password: password,
+		loaded:   make(map[string]string),
+		events:   make(map[string][]fakeEvent),
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	// A command is sent in the same form as an array reply, so it can be
+	// read as one.
+	rc := redigo.NewConn(conn, 0, 0)
+	authed := f.password == ""
+	for {
+		args, err := redigo.Strings(rc.Receive())
+		if err != nil {
+			return
+		}
+		var resp string
+		switch {
+		case args[0] == "AUTH":
+			if args[1] != f.password {
+				resp = "-ERR invalid password\r\n"
+			} else {
+				authed = true
+				resp = "+OK\r\n"
+			}
+		case !authed:
+			resp = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "EVALSHA" || args[0] == "EVAL":
+			resp = f.eval(args)
+		case args[0] == "ZREM":
+			resp = f.zrem(args[1], args[2])
+		default:
+			resp = "-ERR unknown command\r\n"
+		}
+		_, err = conn.Write([]byte(resp))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) eval(args []string) string {
+	f.Lock()
+	defer f.Unlock()
+	script := args[1]
+	if args[0] == "EVALSHA" {
+		var ok bool
+		script, ok = f.loaded[args[1]]
+		if !ok {
+			return "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+		}
+	} else {
+		sum := sha1.Sum([]byte(script))
+		f.loaded[hex.EncodeToString(sum[:])] = script
+	}
+	f.evals++
+	key := args[3]
+	now, _ := strconv.ParseInt(args[4], 10, 64)
+	window, _ := strconv.ParseInt(args[5], 10, 64)
+	var kept []fakeEvent
+	for _, e := range f.events[key] {
+		if e.at > now-window {
+			kept = append(kept, e)
+		}
+	}
+	f.events[key] = kept
+	switch script {
+	case countLua:
+		return ":" + strconv.Itoa(len(kept)) + "\r\n"
+	case reserveLua:
+		threshold, _ := strconv.Atoi(args[6])
+		if len(kept) >= threshold {
+			return ":0\r\n"
+		}
+		f.events[key] = append(kept, fakeEvent{member: args[7], at: now})
+		return ":1\r\n"
+	}
+	return "-ERR unexpected script\r\n"
+}
+
+func (f *fakeRedis) zrem(key, member string) string {
+	f.Lock()
+	defer f.Unlock()
+	for i, e := range f.events[key] {
+		if e.member == member {
+			f.events[key] = append(f.events[key][:i], f.events[key][i+1:]...)
+			return ":1\r\n"
+		}
+	}
+	return ":0\r\n"
+}
+
+func TestRedisCounter(t *testing.T) {
+	f := newFakeRedis(t, "hunter2")
+	defer f.l.Close()
+
+	c, err := NewRedisCounter(f.l.Addr().String(), "hunter2", time.Second, 2)
+	test.AssertNotError(t, err, "Couldn't create RedisCounter")
+
+	ctx := context.Background()
+	now := time.Date(2018, 3, 20, 12, 0, 0, 0, time.UTC)
+	window := time.Hour
+	count, err := c.Count(ctx, "a", window, now)
+	test.AssertNotError(t, err, "Count failed")
+	test.AssertEquals(t, count, 0)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, err := c.Reserve(ctx, "a", window, now.Add(time.Duration(i)*time.Minute), 3)
+		test.AssertNotError(t, err, "Reserve failed")
+		test.Assert(t, id != "", "reservation under the threshold refused")
+		ids = append(ids, id)
+	}
+	count, err = c.Count(ctx, "a", window, now.Add(3*time.Minute))
+	test.AssertNotError(t, err, "Count failed")
+	test.AssertEquals(t, count, 3)
+
+	// Reservations over the threshold aren't recorded
+	id, err := c.Reserve(ctx, "a", window, now.Add(3*time.Minute), 3)
+	test.AssertNotError(t, err, "Reserve failed")
+	test.AssertEquals(t, id, "")
+
+	// Counting doesn't add an event
+	count, err = c.Count(ctx, "a", window, now.Add(3*time.Minute))
+	test.AssertNotError(t, err, "Count failed")
+	test.AssertEquals(t, count, 3)
+
+	// Other keys are counted separately
+	count, err = c.Count(ctx, "b", window, now.Add(3*time.Minute))
+	test.AssertNotError(t, err, "Count failed")
+	test.AssertEquals(t, count, 0)
+
+	// Released events aren't counted
+	err = c.Release(ctx, "a", ids[2])
+	test.AssertNotError(t, err, "Release failed")
+	count, err = c.Count(ctx, "a", window, now.Add(3*time.Minute))
+	test.AssertNotError(t, err, "Count failed")
+	test.AssertEquals(t, count, 2)
+
+	// Once the first event leaves the window it isn't counted
+	count, err = c.Count(ctx, "a", window, now.Add(window+time.Second))
+	test.AssertNotError(t, err, "Count failed")
+	test.AssertEquals(t, count, 1)
+
+	// Each script was only sent in full once, after the first NOSCRIPT
+	f.Lock()
+	test.AssertEquals(t, len(f.loaded), 2)
+	test.AssertEquals(t, f.evals, 10)
+	f.Unlock()
+}
+
+func TestRedisCounterErrors(t *testing.T) {
+	f := newFakeRedis(t, "hunter2")
+	defer f.l.Close()
+
+	c, err := NewRedisCounter(f.l.Addr().String(), "wrong", time.Second, 1)
+	test.AssertNotError(t, err, "Couldn't create RedisCounter")
+	_, err = c.Count(context.Background(), "a", time.Hour, time.Now())
+	test.AssertError(t, err, "Count succeeded with the wrong password")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	test.AssertNotError(t, err, "Couldn't listen")
+	addr := l.Addr().String()
+	l.Close()
+	c, err = NewRedisCounter(addr, "", time.Second, 1)
+	test.AssertNotError(t, err, "Couldn't create RedisCounter")
+	_, err = c.Reserve(context.Background(), "a", time.Hour, time.Now(), 1)
+	test.AssertError(t, err, "Reserve succeeded without a server")
+	err = c.Release(context.Background(), "a", "id")
+	test.AssertError(t, err, "Release succeeded without a server")
+
+	_, err = NewRedisCounter("", "", time.Second, 1)
+	test.AssertError(t, err, "Created a RedisCounter without an address")
+	_, err = NewRedisCounter(addr, "", 0, 1)
+	test.AssertError(t, err, "Created a RedisCounter without a timeout")
+}
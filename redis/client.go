@@ -0,0 +1,67 @@
+// Package redis wraps the redigo Redis client with the connection settings
+// and commands Boulder needs.
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	redigo "github.com/garyburd/redigo/redis"
+)
+
+// Client sends commands to a single Redis server, keeping a pool of idle
+// connections between them.
+type Client struct {
+	pool *redigo.Pool
+}
+
+// NewClient returns a Client for the Redis server at addr. If password isn't
+// empty each connection authenticates with it. Connecting, and sending each
+// command and reading its reply, are given timeout to complete, and at most
+// maxIdle connections are kept open between commands.
+func NewClient(addr, password string, timeout time.Duration, maxIdle int) (*Client, error) {
+	if addr == "" {
+		return nil, errors.New("no Redis address")
+	}
+	if timeout <= 0 {
+		return nil, errors.New("Redis timeout must be positive")
+	}
+	if maxIdle < 0 {
+		maxIdle = 0
+	}
+	return &Client{
+		pool: &redigo.Pool{
+			MaxIdle: maxIdle,
+			Dial: func() (redigo.Conn, error) {
+				return redigo.Dial("tcp", addr,
+					redigo.DialConnectTimeout(timeout),
+					redigo.DialReadTimeout(timeout),
+					redigo.DialWriteTimeout(timeout),
+					redigo.DialPassword(password))
+			},
+		},
+	}, nil
+}
+
+// Do runs a command and returns its reply, as described by the redigo
+// package. Replies that are Redis errors are returned as a redigo.Error.
+func (c *Client) Do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.Do(cmd, args...)
+}
+
+// Eval runs a Lua script, which is only sent in full if the server hasn't
+// cached it yet.
+func (c *Client) Eval(ctx context.Context, script *redigo.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return script.Do(conn, keysAndArgs...)
+}
@@ -0,0 +1,131 @@
+package redis
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	redigo "github.com/garyburd/redigo/redis"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// fakeServer is a Redis server that understands AUTH, SET and GET.
+type fakeServer struct {
+	l        net.Listener
+	password string
+
+	sync.Mutex
+	values map[string]string
+	ttls   map[string]string
+}
+
+func newFakeServer(t *testing.T, password string) *fakeServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	test.AssertNotError(t, err, "Couldn't listen")
+	f := &fakeServer{
+		l:        l,
+		password: password,
+		values:   make(map[string]string),
+		ttls:     make(map[string]string),
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	// A command is sent in the same form as an array reply, so it can be
+	// read as one.
+	rc := redigo.NewConn(conn, 0, 0)
+	authed := f.password == ""
+	for {
+		args, err := redigo.Strings(rc.Receive())
+		if err != nil {
+			return
+		}
+		var resp string
+		f.Lock()
+		switch {
+		case args[0] == "AUTH":
+			if args[1] != f.password {
+				resp = "-ERR invalid password\r\n"
+			} else {
+				authed = true
+				resp = "+OK\r\n"
+			}
+		case !authed:
+			resp = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "SET" && len(args) == 5 && args[3] == "PX":
+			f.values[args[1]] = args[2]
+			f.ttls[args[1]] = args[4]
+			resp = "+OK\r\n"
+		case args[0] == "GET":
+			value, present := f.values[args[1]]
+			if !present {
+				resp = "$-1\r\n"
+			} else {
+				resp = "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+			}
+		default:
+			resp = "-ERR unknown command\r\n"
+		}
+		f.Unlock()
+		_, err = conn.Write([]byte(resp))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func TestOCSPResponses(t *testing.T) {
+	f := newFakeServer(t, "hunter2")
+	defer f.l.Close()
+
+	c, err := NewClient(f.l.Addr().String(), "hunter2", time.Second, 1)
+	test.AssertNotError(t, err, "Couldn't create Client")
+
+	ctx := context.Background()
+	response, err := c.GetOCSPResponse(ctx, "00")
+	test.AssertNotError(t, err, "GetOCSPResponse failed")
+	test.Assert(t, response == nil, "Got a response that was never stored")
+
+	stored := []byte{0, 1, 2, '\r', '\n', 255}
+	err = c.StoreOCSPResponse(ctx, "00", stored, time.Hour)
+	test.AssertNotError(t, err, "StoreOCSPResponse failed")
+	response, err = c.GetOCSPResponse(ctx, "00")
+	test.AssertNotError(t, err, "GetOCSPResponse failed")
+	test.AssertDeepEquals(t, response, stored)
+	f.Lock()
+	test.AssertEquals(t, f.ttls["ocsp:00"], "3600000")
+	f.Unlock()
+
+	err = c.StoreOCSPResponse(ctx, "01", stored, 0)
+	test.AssertError(t, err, "Stored an expired response")
+}
+
+func TestClientErrors(t *testing.T) {
+	f := newFakeServer(t, "hunter2")
+	defer f.l.Close()
+
+	c, err := NewClient(f.l.Addr().String(), "wrong", time.Second, 1)
+	test.AssertNotError(t, err, "Couldn't create Client")
+	_, err = c.Do(context.Background(), "GET", "a")
+	test.AssertError(t, err, "Do succeeded with the wrong password")
+
+	_, err = NewClient("", "", time.Second, 1)
+	test.AssertError(t, err, "Created a Client without an address")
+	_, err = NewClient(f.l.Addr().String(), "", 0, 1)
+	test.AssertError(t, err, "Created a Client without a timeout")
+}
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	redigo "github.com/garyburd/redigo/redis"
+)
+
+// ocspResponseKey returns the key under which the OCSP response for serial is
+// stored.
+func ocspResponseKey(serial string) string {
+	return "ocsp:" + serial
+}
+
+// StoreOCSPResponse stores a pre-signed OCSP response for serial, to expire
+// after ttl, which should be no later than the response's nextUpdate.
+func (r *Client) StoreOCSPResponse(ctx context.Context, serial string, response []byte, ttl time.Duration) error {
+	millis := int64(ttl / time.Millisecond)
+	if millis <= 0 {
+		return fmt.Errorf("OCSP response for %s has already expired", serial)
+	}
+	_, err := r.Do(ctx, "SET", ocspResponseKey(serial), response, "PX", millis)
+	return err
+}
+
+// GetOCSPResponse returns the stored OCSP response for serial, or nil if there
+// isn't one.
+func (r *Client) GetOCSPResponse(ctx context.Context, serial string) ([]byte, error) {
+	response, err := redigo.Bytes(r.Do(ctx, "GET", ocspResponseKey(serial)))
+	if err == redigo.ErrNil {
+		return nil, nil
+	}
+	return response, err
+}
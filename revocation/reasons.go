@@ -44,3 +44,50 @@ var UserAllowedReasons = map[Reason]struct{}{
 	Superseded:           {}, // superseded
 	CessationOfOperation: {}, // cessationOfOperation
 }
+
+// Requester identifies who is asking for a certificate to be revoked, which
+// determines the reasons they may give.
+type Requester int
+
+const (
+	// Subscriber is the account that the certificate was issued to.
+	Subscriber Requester = iota
+	// ThirdParty is an account that holds authorizations for all of the names
+	// in the certificate, but did not request it.
+	ThirdParty
+	// KeyHolder is anyone proving possession of the certificate's private key.
+	KeyHolder
+	// Admin is a Boulder administrator, e.g. using the admin-revoker tool.
+	Admin
+)
+
+// requesterAllowedReasons maps each Requester to the reasons it may give. A
+// third party can't speak to the subscriber's key or affiliation, and only the
+// holder of the key can claim that it was compromised without an account.
+var requesterAllowedReasons = map[Requester]map[Reason]struct{}{
+	Subscriber: UserAllowedReasons,
+	ThirdParty: {
+		Unspecified:          {},
+		CessationOfOperation: {},
+	},
+	KeyHolder: {
+		Unspecified:   {},
+		KeyCompromise: {},
+	},
+	Admin: {
+		Unspecified:          {},
+		KeyCompromise:        {},
+		CACompromise:         {},
+		AffiliationChanged:   {},
+		Superseded:           {},
+		CessationOfOperation: {},
+		PrivilegeWithdrawn:   {},
+		AACompromise:         {},
+	},
+}
+
+// AllowedFor returns true if requester may revoke a certificate with reason.
+func AllowedFor(requester Requester, reason Reason) bool {
+	_, present := requesterAllowedReasons[requester][reason]
+	return present
+}
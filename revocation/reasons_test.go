@@ -0,0 +1,33 @@
+package revocation
+
+import (
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestAllowedFor(t *testing.T) {
+	testCases := []struct {
+		requester Requester
+		reason    Reason
+		allowed   bool
+	}{
+		{Subscriber, Unspecified, true},
+		{Subscriber, KeyCompromise, true},
+		{Subscriber, Superseded, true},
+		{Subscriber, CACompromise, false},
+		{ThirdParty, Unspecified, true},
+		{ThirdParty, CessationOfOperation, true},
+		{ThirdParty, KeyCompromise, false},
+		{ThirdParty, Superseded, false},
+		{KeyHolder, KeyCompromise, true},
+		{KeyHolder, AffiliationChanged, false},
+		{Admin, CACompromise, true},
+		{Admin, CertificateHold, false},
+		{Admin, RemoveFromCRL, false},
+		{Requester(100), Unspecified, false},
+	}
+	for _, tc := range testCases {
+		test.AssertEquals(t, AllowedFor(tc.requester, tc.reason), tc.allowed)
+	}
+}
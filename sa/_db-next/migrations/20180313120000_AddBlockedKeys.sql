@@ -0,0 +1,18 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `blockedKeys` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+  `keyHash` VARCHAR(255) NOT NULL,
+  `added` DATETIME NOT NULL,
+  `addedBy` VARCHAR(255) NOT NULL,
+  `comment` VARCHAR(255) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `keyHash` (`keyHash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `blockedKeys`;
@@ -0,0 +1,18 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `registrationIssuanceStats` (
+  `registrationID` BIGINT(20) NOT NULL,
+  `day` DATE NOT NULL,
+  `certificatesIssued` BIGINT(20) NOT NULL DEFAULT 0,
+  `namesIssued` BIGINT(20) NOT NULL DEFAULT 0,
+  `failedOrders` BIGINT(20) NOT NULL DEFAULT 0,
+  PRIMARY KEY (`registrationID`, `day`),
+  KEY `day_idx` (`day`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `registrationIssuanceStats`;
@@ -0,0 +1,19 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `policyOverrides` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+  `domain` VARCHAR(255) NOT NULL,
+  `registrationID` BIGINT(20) NOT NULL DEFAULT 0,
+  `added` DATETIME NOT NULL,
+  `addedBy` VARCHAR(255) NOT NULL,
+  `comment` VARCHAR(255) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `domain_registrationID` (`domain`, `registrationID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `policyOverrides`;
@@ -0,0 +1,22 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `rateLimitOverrides` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+  `registrationID` BIGINT(20) NOT NULL,
+  `limitName` VARCHAR(255) NOT NULL,
+  `threshold` INT(11) NOT NULL,
+  `expires` DATETIME NOT NULL,
+  `added` DATETIME NOT NULL,
+  `addedBy` VARCHAR(255) NOT NULL,
+  `comment` VARCHAR(255) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `registrationID_limitName` (`registrationID`, `limitName`),
+  KEY `expires_idx` (`expires`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `rateLimitOverrides`;
@@ -0,0 +1,18 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `failedValidations` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+  `registrationID` BIGINT(20) NOT NULL,
+  `hostname` VARCHAR(255) NOT NULL,
+  `attempted` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `regID_hostname_attempted_idx` (`registrationID`, `hostname`, `attempted`),
+  KEY `attempted_idx` (`attempted`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `failedValidations`;
@@ -0,0 +1,20 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `accountFQDNSets` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+  -- SHA256 hash of alphabetically sorted, lowercased, comma joined
+  -- DNS names contained in a certificate
+  `setHash` BINARY(32) NOT NULL,
+  `registrationID` BIGINT(20) NOT NULL,
+  `issued` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `setHash_regID_issued_idx` (`setHash`, `registrationID`, `issued`),
+  KEY `issued_idx` (`issued`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `accountFQDNSets`;
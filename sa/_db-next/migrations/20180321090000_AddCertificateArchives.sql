@@ -0,0 +1,17 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- The archive tables hold certificates and their statuses that cert-archiver
+-- has moved out of the hot tables. They must keep the same columns as the
+-- tables they archive, so migrations that change one should change both.
+CREATE TABLE `certificatesArchive` LIKE `certificates`;
+
+CREATE TABLE `certificateStatusArchive` LIKE `certificateStatus`;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `certificatesArchive`;
+
+DROP TABLE `certificateStatusArchive`;
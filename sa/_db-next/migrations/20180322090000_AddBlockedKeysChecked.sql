@@ -0,0 +1,14 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- extantCertificatesChecked is set by the bad-key-revoker once it has revoked
+-- the certificates that were issued for a blocked key before it was blocked.
+ALTER TABLE `blockedKeys` ADD COLUMN `extantCertificatesChecked` BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE `blockedKeys` ADD INDEX `extantCertificatesChecked_idx` (`extantCertificatesChecked`);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `blockedKeys` DROP INDEX `extantCertificatesChecked_idx`;
+ALTER TABLE `blockedKeys` DROP COLUMN `extantCertificatesChecked`;
@@ -0,0 +1,19 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- verifiedContacts records the email contacts that a registration has shown
+-- it controls by following the link in a verification message.
+CREATE TABLE `verifiedContacts` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+  `registrationID` BIGINT(20) NOT NULL,
+  `email` VARCHAR(255) NOT NULL,
+  `verified` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `registrationID_email` (`registrationID`, `email`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `verifiedContacts`;
@@ -0,0 +1,15 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- checkAttempts and nextCheck are set by the bad-key-revoker when it fails to
+-- revoke the extant certificates for a blocked key, so that the key is retried
+-- with a backoff rather than blocking the keys after it.
+ALTER TABLE `blockedKeys` ADD COLUMN `checkAttempts` INT NOT NULL DEFAULT 0;
+ALTER TABLE `blockedKeys` ADD COLUMN `nextCheck` DATETIME DEFAULT NULL;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `blockedKeys` DROP COLUMN `nextCheck`;
+ALTER TABLE `blockedKeys` DROP COLUMN `checkAttempts`;
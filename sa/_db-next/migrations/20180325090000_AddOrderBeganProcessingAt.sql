@@ -0,0 +1,15 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- beganProcessingAt is set by the SA when an order starts processing with the
+-- AsyncFinalize feature enabled, so that orders left processing by an RA that
+-- stopped before issuing their certificates can be found and failed.
+ALTER TABLE `orders` ADD COLUMN `beganProcessingAt` DATETIME DEFAULT NULL;
+ALTER TABLE `orders` ADD INDEX `beganProcessingAt_idx` (`beganProcessingAt`);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `orders` DROP INDEX `beganProcessingAt_idx`;
+ALTER TABLE `orders` DROP COLUMN `beganProcessingAt`;
@@ -0,0 +1,24 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- ctResubmissions holds the ocsp-updater's attempts to get an SCT for a
+-- certificate from a CT log, with the CTResubmissionQueue feature enabled, so
+-- that its backoff survives restarts. Rows are deleted once the log's receipt
+-- is stored or the certificate is too old to be resubmitted.
+CREATE TABLE `ctResubmissions` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+  `serial` VARCHAR(255) NOT NULL,
+  `logID` VARCHAR(255) NOT NULL,
+  `attempts` INT(11) NOT NULL,
+  `nextAttempt` DATETIME NOT NULL,
+  `created` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `serial_logID` (`serial`, `logID`),
+  KEY `created_idx` (`created`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `ctResubmissions`;
@@ -0,0 +1,23 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- ctSubmissions records the result of each submission of a certificate, or
+-- its precertificate, to a CT log made by the RA with the RecordCTSubmissions
+-- feature enabled.
+CREATE TABLE `ctSubmissions` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+  `serial` VARCHAR(255) NOT NULL,
+  `logURL` VARCHAR(255) NOT NULL,
+  `precert` TINYINT(1) NOT NULL,
+  `result` VARCHAR(16) NOT NULL,
+  `submitted` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `serial_idx` (`serial`),
+  KEY `submitted_idx` (`submitted`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `ctSubmissions`;
@@ -0,0 +1,12 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- The ocsp-updater's RevokedCertificates and RecentRevocations loops select
+-- revoked certificates ordered by, and for the latter bounded by, revokedDate.
+ALTER TABLE `certificateStatus` ADD INDEX `status_revokedDate_idx` (`status`, `revokedDate`);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `certificateStatus` DROP INDEX `status_revokedDate_idx`;
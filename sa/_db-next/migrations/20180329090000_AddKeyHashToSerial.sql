@@ -0,0 +1,20 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- keyHashToSerial maps the SPKI SHA-256 hash of each certificate's public key
+-- to its serial, when issued with the StoreKeyHashes feature enabled, so that
+-- every certificate for a compromised key can be found and revoked.
+CREATE TABLE `keyHashToSerial` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+  `keyHash` VARCHAR(255) NOT NULL,
+  `certNotAfter` DATETIME NOT NULL,
+  `certSerial` VARCHAR(255) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `keyHash_certSerial` (`keyHash`, `certSerial`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `keyHashToSerial`;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- addressUsed is the IP address the failed validation connected to, if it got
+-- that far.
+ALTER TABLE `failedValidations` ADD COLUMN `addressUsed` VARCHAR(45) DEFAULT NULL;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `failedValidations` DROP COLUMN `addressUsed`;
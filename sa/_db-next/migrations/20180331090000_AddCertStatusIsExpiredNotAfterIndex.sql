@@ -0,0 +1,12 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- The ocsp-updater's PruneExpiredResponses loop selects expired certificates
+-- ordered by, and bounded by, notAfter.
+ALTER TABLE `certificateStatus` ADD INDEX `isExpired_notAfter_idx` (`isExpired`, `notAfter`);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `certificateStatus` DROP INDEX `isExpired_notAfter_idx`;
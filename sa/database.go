@@ -1,13 +1,16 @@
 package sa
 
 import (
+	"crypto/tls"
 	"database/sql"
 	"fmt"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/go-gorp/gorp.v2"
 
 	"github.com/letsencrypt/boulder/core"
@@ -20,6 +23,26 @@ import (
 // tables. It automatically maps the tables for the primary parts of Boulder
 // around the Storage Authority.
 func NewDbMap(dbConnect string, maxOpenConns int) (*gorp.DbMap, error) {
+	return NewDbMapWithSettings(dbConnect, DbSettings{MaxOpenConns: maxOpenConns})
+}
+
+// DbSettings controls the connection pool of a database map. Zero values
+// leave the database/sql defaults in place, except for MaxOpenConns, where
+// zero means unlimited.
+type DbSettings struct {
+	// MaxOpenConns is the maximum number of open connections to the database.
+	MaxOpenConns int
+	// MaxIdleConns is the maximum number of idle connections kept in the pool.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused.
+	ConnMaxLifetime time.Duration
+	// TLS, if not nil, makes connections use TLS with the given configuration.
+	TLS *tls.Config
+}
+
+// NewDbMapWithSettings functions similarly to NewDbMap, but it applies all of
+// the given connection pool settings.
+func NewDbMapWithSettings(dbConnect string, settings DbSettings) (*gorp.DbMap, error) {
 	var err error
 	var config *mysql.Config
 	if strings.HasPrefix(dbConnect, "mysql+tcp://") {
@@ -34,7 +57,30 @@ func NewDbMap(dbConnect string, maxOpenConns int) (*gorp.DbMap, error) {
 		return nil, err
 	}
 
-	return NewDbMapFromConfig(config, maxOpenConns)
+	if settings.TLS != nil {
+		config.TLSConfig, err = registerTLSConfig(settings.TLS)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newDbMapFromConfig(config, settings)
+}
+
+// tlsConfigCount is used to give each TLS configuration registered with the
+// MySQL driver a unique name.
+var tlsConfigCount int64
+
+// registerTLSConfig registers the given TLS configuration with the MySQL
+// driver, returning the name it was registered under. If no server name is
+// configured, the driver verifies the server's certificate against the host
+// it connects to.
+func registerTLSConfig(tlsConfig *tls.Config) (string, error) {
+	name := fmt.Sprintf("boulder-%d", atomic.AddInt64(&tlsConfigCount, 1))
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", err
+	}
+	return name, nil
 }
 
 // sqlOpen is used in the tests to check that the arguments are properly
@@ -48,9 +94,24 @@ var setMaxOpenConns = func(db *sql.DB, maxOpenConns int) {
 	db.SetMaxOpenConns(maxOpenConns)
 }
 
+// setConnPoolSettings applies the remaining pool settings, and is replaced in
+// tests like setMaxOpenConns.
+var setConnPoolSettings = func(db *sql.DB, settings DbSettings) {
+	if settings.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(settings.MaxIdleConns)
+	}
+	if settings.ConnMaxLifetime != 0 {
+		db.SetConnMaxLifetime(settings.ConnMaxLifetime)
+	}
+}
+
 // NewDbMapFromConfig functions similarly to NewDbMap, but it takes the
 // decomposed form of the connection string, a *mysql.Config.
 func NewDbMapFromConfig(config *mysql.Config, maxOpenConns int) (*gorp.DbMap, error) {
+	return newDbMapFromConfig(config, DbSettings{MaxOpenConns: maxOpenConns})
+}
+
+func newDbMapFromConfig(config *mysql.Config, settings DbSettings) (*gorp.DbMap, error) {
 	adjustMySQLConfig(config)
 
 	db, err := sqlOpen("mysql", config.FormatDSN())
@@ -60,7 +121,8 @@ func NewDbMapFromConfig(config *mysql.Config, maxOpenConns int) (*gorp.DbMap, er
 	if err = db.Ping(); err != nil {
 		return nil, err
 	}
-	setMaxOpenConns(db, maxOpenConns)
+	setMaxOpenConns(db, settings.MaxOpenConns)
+	setConnPoolSettings(db, settings)
 
 	dialect := gorp.MySQLDialect{Engine: "InnoDB", Encoding: "UTF8"}
 	dbmap := &gorp.DbMap{Db: db, Dialect: dialect, TypeConverter: BoulderTypeConverter{}}
@@ -162,6 +224,54 @@ func (log *SQLLogger) Printf(format string, v ...interface{}) {
 	log.Debug(fmt.Sprintf(format, v...))
 }
 
+// InitDBMetrics registers gauges for the connection pool of dbMap, labelled
+// with the pool's name so that several pools can be told apart: its configured
+// limits, and the number of connections currently open.
+//
+// db_max_open_connections replaces the unlabelled max_db_connections gauge
+// that boulder-sa and ocsp-responder used to export. So that dashboards and
+// alerts can be moved over to db_max_open_connections{pool="primary"}, the old
+// gauge is still exported for the primary pool, and will be removed in a
+// later release.
+func InitDBMetrics(dbMap *gorp.DbMap, scope metrics.Scope, settings DbSettings, pool string) {
+	if pool == "primary" {
+		deprecatedMaxOpen := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "max_db_connections",
+			Help: "Deprecated, use db_max_open_connections. Maximum number of DB connections allowed.",
+		})
+		scope.MustRegister(deprecatedMaxOpen)
+		deprecatedMaxOpen.Set(float64(settings.MaxOpenConns))
+	}
+
+	labels := prometheus.Labels{"pool": pool}
+	maxOpen := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "db_max_open_connections",
+		Help:        "Maximum number of open DB connections allowed, 0 for unlimited.",
+		ConstLabels: labels,
+	})
+	maxIdle := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "db_max_idle_connections",
+		Help:        "Maximum number of idle DB connections kept, 0 for the default.",
+		ConstLabels: labels,
+	})
+	maxLifetime := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "db_conn_max_lifetime_seconds",
+		Help:        "Maximum number of seconds a DB connection may be reused, 0 for forever.",
+		ConstLabels: labels,
+	})
+	open := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "db_open_connections",
+		Help:        "Number of open DB connections, in use or idle.",
+		ConstLabels: labels,
+	}, func() float64 {
+		return float64(dbMap.Db.Stats().OpenConnections)
+	})
+	scope.MustRegister(maxOpen, maxIdle, maxLifetime, open)
+	maxOpen.Set(float64(settings.MaxOpenConns))
+	maxIdle.Set(float64(settings.MaxIdleConns))
+	maxLifetime.Set(settings.ConnMaxLifetime.Seconds())
+}
+
 func ReportDbConnCount(dbMap *gorp.DbMap, statter metrics.Scope) {
 	db := dbMap.Db
 	for {
@@ -198,4 +308,5 @@ func initTables(dbMap *gorp.DbMap) {
 	dbMap.AddTableWithName(orderToAuthzModel{}, "orderToAuthz").SetKeys(false, "OrderID", "AuthzID")
 	dbMap.AddTableWithName(requestedNameModel{}, "requestedNames").SetKeys(false, "OrderID")
 	dbMap.AddTableWithName(orderFQDNSet{}, "orderFqdnSets").SetKeys(true, "ID")
+	dbMap.AddTableWithName(IssuanceStats{}, "registrationIssuanceStats").SetKeys(false, "RegistrationID", "Day")
 }
@@ -0,0 +1,101 @@
+package sa
+
+import (
+	"strings"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+// errorOutcomes names the outcome label used for each type of BoulderError.
+var errorOutcomes = map[berrors.ErrorType]string{
+	berrors.InternalServer:          "internalServer",
+	berrors.Malformed:               "malformed",
+	berrors.Unauthorized:            "unauthorized",
+	berrors.NotFound:                "notFound",
+	berrors.RateLimit:               "rateLimit",
+	berrors.RejectedIdentifier:      "rejectedIdentifier",
+	berrors.InvalidEmail:            "invalidEmail",
+	berrors.ConnectionFailure:       "connectionFailure",
+	berrors.WrongAuthorizationState: "wrongAuthorizationState",
+	berrors.CAA:                     "caa",
+	berrors.BadPublicKey:            "badPublicKey",
+	berrors.Timeout:                 "timeout",
+}
+
+// outcome classifies the result of an SA method for use as a metric label.
+func outcome(err error) string {
+	if err == nil {
+		return "success"
+	}
+	if be, ok := err.(*berrors.BoulderError); ok {
+		if name, present := errorOutcomes[be.Type]; present {
+			return name
+		}
+	}
+	switch err {
+	case context.Canceled:
+		return "canceled"
+	case context.DeadlineExceeded:
+		return "deadlineExceeded"
+	}
+	return "error"
+}
+
+// MethodMetrics records the latency and errors of each SA method, so that slow
+// or failing queries can be attributed to the call site that made them rather
+// than only being visible at the database.
+type MethodMetrics struct {
+	clk      clock.Clock
+	latency  *prometheus.HistogramVec
+	failures *prometheus.CounterVec
+}
+
+// NewMethodMetrics creates a MethodMetrics and registers its metrics with
+// scope.
+func NewMethodMetrics(scope metrics.Scope, clk clock.Clock) *MethodMetrics {
+	latency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sa_method_latency",
+			Help:    "Histogram of seconds taken by each SA method, by method and outcome",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		},
+		[]string{"method", "outcome"},
+	)
+	failures := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sa_method_errors",
+			Help: "Number of SA method calls that returned an error, by method and outcome",
+		},
+		[]string{"method", "outcome"},
+	)
+	scope.MustRegister(latency, failures)
+	return &MethodMetrics{clk: clk, latency: latency, failures: failures}
+}
+
+// Intercept is a grpc.UnaryServerInterceptor that times each call to the SA's
+// gRPC server. It must be installed inside the interceptor that wraps errors
+// for transmission, so that it sees the SA's own errors.
+func (mm *MethodMetrics) Intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	// FullMethod is of the form "/sa.StorageAuthority/GetRegistration"
+	method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+	start := mm.clk.Now()
+	resp, err := handler(ctx, req)
+	result := outcome(err)
+	mm.latency.With(prometheus.Labels{
+		"method":  method,
+		"outcome": result,
+	}).Observe(mm.clk.Since(start).Seconds())
+	if err != nil {
+		mm.failures.With(prometheus.Labels{
+			"method":  method,
+			"outcome": result,
+		}).Inc()
+	}
+	return resp, err
+}
@@ -0,0 +1,48 @@
+package sa
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestMethodMetrics(t *testing.T) {
+	fc := clock.NewFake()
+	mm := NewMethodMetrics(metrics.NewNoopScope(), fc)
+
+	call := func(method string, err error) {
+		info := &grpc.UnaryServerInfo{FullMethod: "/sa.StorageAuthority/" + method}
+		_, gotErr := mm.Intercept(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+			fc.Add(time.Second)
+			return nil, err
+		})
+		test.AssertEquals(t, gotErr, err)
+	}
+	call("GetRegistration", nil)
+	call("GetRegistration", berrors.NotFoundError("no registration"))
+	call("GetRegistration", berrors.NotFoundError("no registration"))
+	call("AddCertificate", errors.New("database is down"))
+
+	samples := func(method, outcome string) int {
+		return test.CountHistogramSamples(mm.latency.With(prometheus.Labels{"method": method, "outcome": outcome}))
+	}
+	test.AssertEquals(t, samples("GetRegistration", "success"), 1)
+	test.AssertEquals(t, samples("GetRegistration", "notFound"), 2)
+	test.AssertEquals(t, samples("AddCertificate", "error"), 1)
+
+	failures := func(method, outcome string) int {
+		return test.CountCounter(mm.failures.With(prometheus.Labels{"method": method, "outcome": outcome}))
+	}
+	test.AssertEquals(t, failures("GetRegistration", "success"), 0)
+	test.AssertEquals(t, failures("GetRegistration", "notFound"), 2)
+	test.AssertEquals(t, failures("AddCertificate", "error"), 1)
+}
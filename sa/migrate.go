@@ -0,0 +1,271 @@
+package sa
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// migrationLock is the name of the MySQL named lock held while migrations are
+// applied, so that concurrent runs against the same database don't interleave.
+const migrationLock = "boulder-migrate"
+
+// Migration is one versioned SQL migration, in the format used by goose: a
+// file named <version>_<name>.sql with its statements between the
+// "-- +goose Up" and "-- +goose Down" annotations.
+type Migration struct {
+	Version int64
+	Name    string
+	// Up holds the statements that apply the migration.
+	Up []string
+}
+
+// LoadMigrations reads the migrations in each of dirs and returns them sorted
+// by version. It is an error for two migrations to share a version.
+func LoadMigrations(dirs ...string) ([]Migration, error) {
+	var migrations []Migration
+	seen := make(map[int64]string)
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.IsDir() || filepath.Ext(f.Name()) != ".sql" {
+				continue
+			}
+			path := filepath.Join(dir, f.Name())
+			m, err := loadMigration(path)
+			if err != nil {
+				return nil, err
+			}
+			if other, present := seen[m.Version]; present {
+				return nil, fmt.Errorf("migrations %s and %s have the same version", other, path)
+			}
+			seen[m.Version] = path
+			migrations = append(migrations, *m)
+		}
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func loadMigration(path string) (*Migration, error) {
+	base := filepath.Base(path)
+	parts := strings.SplitN(strings.TrimSuffix(base, ".sql"), "_", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("migration %s isn't named <version>_<name>.sql", path)
+	}
+	version, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || version <= 0 {
+		return nil, fmt.Errorf("migration %s has an invalid version %q", path, parts[0])
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	up, err := parseUpStatements(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing migration %s: %s", path, err)
+	}
+	return &Migration{Version: version, Name: parts[1], Up: up}, nil
+}
+
+// parseUpStatements splits the Up section of a migration into statements the
+// same way goose does: a statement ends with a line ending in a semicolon,
+// unless it is between "-- +goose StatementBegin" and "-- +goose StatementEnd".
+func parseUpStatements(r io.Reader) ([]string, error) {
+	var statements []string
+	var current []string
+	inUp, inStatement := false, false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			switch strings.TrimSpace(strings.TrimPrefix(trimmed, "--")) {
+			case "+goose Up":
+				inUp = true
+			case "+goose Down":
+				inUp = false
+			case "+goose StatementBegin":
+				inStatement = true
+			case "+goose StatementEnd":
+				inStatement = false
+				if inUp && len(current) > 0 {
+					statements = append(statements, strings.Join(current, "\n"))
+				}
+				current = nil
+			}
+			continue
+		}
+		if !inUp || trimmed == "" {
+			continue
+		}
+		current = append(current, line)
+		if !inStatement && strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(current) > 0 {
+		return nil, fmt.Errorf("statement isn't terminated by a semicolon")
+	}
+	return statements, nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// appliedVersions returns the versions recorded as applied in the
+// goose_db_version table. As in goose, the most recent row for each version
+// decides whether it is applied.
+func appliedVersions(ctx context.Context, db queryer) (map[int64]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version_id, is_applied FROM goose_db_version ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	decided := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var applied bool
+		if err := rows.Scan(&version, &applied); err != nil {
+			return nil, err
+		}
+		if _, present := decided[version]; !present {
+			decided[version] = applied
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool)
+	for version, isApplied := range decided {
+		if isApplied {
+			applied[version] = true
+		}
+	}
+	return applied, nil
+}
+
+// PendingMigrations returns the migrations that haven't been applied to db.
+func PendingMigrations(ctx context.Context, db *sql.DB, migrations []Migration) ([]Migration, error) {
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies the migrations that haven't been applied to db, in version
+// order, recording each in the goose_db_version table so that goose and this
+// runner agree on the schema version. It holds a named lock while it runs,
+// waiting at most lockTimeout to acquire it. The migrations that were applied
+// are returned, even if a later one fails.
+func Migrate(ctx context.Context, db *sql.DB, migrations []Migration, lockTimeout time.Duration) ([]Migration, error) {
+	// Named locks belong to a connection, so everything must happen on the same
+	// one.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLock, int(lockTimeout.Seconds())).Scan(&locked)
+	if err != nil {
+		return nil, err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return nil, fmt.Errorf("timed out waiting for the %q lock", migrationLock)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(ctx, "DO RELEASE_LOCK(?)", migrationLock)
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS goose_db_version (
+		id serial NOT NULL,
+		version_id bigint NOT NULL,
+		is_applied boolean NOT NULL,
+		tstamp timestamp NULL default now(),
+		PRIMARY KEY(id)
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		for _, statement := range m.Up {
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				return done, fmt.Errorf("applying migration %d_%s: %s", m.Version, m.Name, err)
+			}
+		}
+		_, err = conn.ExecContext(ctx, "INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, true)", m.Version)
+		if err != nil {
+			return done, fmt.Errorf("recording migration %d_%s: %s", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// CheckSchema returns an error if any of the migrations in dirs haven't been
+// applied to db, so that services can refuse to start against a database whose
+// schema is older than the code expects.
+func CheckSchema(ctx context.Context, db *sql.DB, dirs ...string) error {
+	migrations, err := LoadMigrations(dirs...)
+	if err != nil {
+		return err
+	}
+	pending, err := PendingMigrations(ctx, db, migrations)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		var names []string
+		for _, m := range pending {
+			names = append(names, fmt.Sprintf("%d_%s", m.Version, m.Name))
+		}
+		return fmt.Errorf("database is missing migrations: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
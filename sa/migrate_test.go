@@ -0,0 +1,71 @@
+package sa
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestParseUpStatements(t *testing.T) {
+	statements, err := parseUpStatements(strings.NewReader(`
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE a (
+  -- A comment inside a statement
+  id int
+);
+ALTER TABLE b ADD COLUMN c int;
+
+-- +goose StatementBegin
+CREATE PROCEDURE p()
+BEGIN
+  SELECT 1;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE a;
+`))
+	test.AssertNotError(t, err, "parseUpStatements failed")
+	test.AssertDeepEquals(t, statements, []string{
+		"CREATE TABLE a (\n  id int\n);",
+		"ALTER TABLE b ADD COLUMN c int;",
+		"CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\nEND;",
+	})
+
+	_, err = parseUpStatements(strings.NewReader("-- +goose Up\nCREATE TABLE a (id int)\n"))
+	test.AssertError(t, err, "unterminated statement was accepted")
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations("_db/migrations", "_db-next/migrations")
+	test.AssertNotError(t, err, "LoadMigrations failed")
+	test.Assert(t, len(migrations) > 0, "no migrations were loaded")
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d_%s is out of order", m.Version, m.Name)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "migrations")
+	test.AssertNotError(t, err, "creating temp dir")
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	err = ioutil.WriteFile(filepath.Join(dir, "20150818171317_Duplicate.sql"), []byte("-- +goose Up\n"), 0600)
+	test.AssertNotError(t, err, "writing migration")
+	_, err = LoadMigrations("_db/migrations", dir)
+	test.AssertError(t, err, "duplicate migration version was accepted")
+
+	err = ioutil.WriteFile(filepath.Join(dir, "Unversioned.sql"), []byte("-- +goose Up\n"), 0600)
+	test.AssertNotError(t, err, "writing migration")
+	_, err = LoadMigrations(dir)
+	test.AssertError(t, err, "unversioned migration was accepted")
+}
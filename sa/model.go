@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"strings"
 	"time"
 
 	jose "gopkg.in/square/go-jose.v2"
@@ -73,6 +74,17 @@ func selectSctReceipt(s dbOneSelector, q string, args ...interface{}) (core.Sign
 	return model, err
 }
 
+// selectSctReceipts selects all fields of multiple SCT receipt objects
+func selectSctReceipts(s dbSelector, q string, args ...interface{}) ([]core.SignedCertificateTimestamp, error) {
+	var models []core.SignedCertificateTimestamp
+	_, err := s.Select(
+		&models,
+		"SELECT id, sctVersion, logID, timestamp, extensions, signature, certificateSerial, LockCol FROM sctReceipts "+q,
+		args...,
+	)
+	return models, err
+}
+
 const certFields = "registrationID, serial, digest, der, issued, expires"
 
 // SelectCertificate selects all fields of one certificate object
@@ -119,6 +131,41 @@ func SelectCertificateStatuses(s dbSelector, q string, args ...interface{}) ([]c
 	return models, err
 }
 
+// SelectCertificateStatusesBySerial selects the certificate statuses of the
+// given serials with a single query. Serials without a certificate status are
+// left out of the result.
+func SelectCertificateStatusesBySerial(s dbSelector, serials []string) ([]core.CertificateStatus, error) {
+	if len(serials) == 0 {
+		return nil, nil
+	}
+	qmarks := make([]string, len(serials))
+	params := make([]interface{}, len(serials))
+	for i, serial := range serials {
+		qmarks[i] = "?"
+		params[i] = serial
+	}
+	return SelectCertificateStatuses(s, "WHERE serial IN ("+strings.Join(qmarks, ",")+")", params...)
+}
+
+// SerialPrefixFilter returns a SQL condition matching rows whose serial, held
+// in the given column, begins with one of the given prefix bytes, along with
+// the named arguments the condition refers to. The condition is suitable for
+// joining to a WHERE clause with AND. If no prefixes are given the condition
+// is empty and matches nothing additional.
+func SerialPrefixFilter(column string, prefixes []int) (string, map[string]interface{}) {
+	args := make(map[string]interface{}, len(prefixes))
+	if len(prefixes) == 0 {
+		return "", args
+	}
+	clauses := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		name := fmt.Sprintf("serialPrefix%d", i)
+		clauses[i] = fmt.Sprintf("%s LIKE :%s", column, name)
+		args[name] = fmt.Sprintf("%02x%%", prefix)
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
 var mediumBlobSize = int(math.Pow(2, 24))
 
 type issuedNameModel struct {
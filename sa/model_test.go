@@ -35,3 +35,19 @@ func TestModelToRegistrationNonNilContact(t *testing.T) {
 		t.Errorf("Expected empty Contact field, got %#v", reg.Contact)
 	}
 }
+
+func TestSerialPrefixFilter(t *testing.T) {
+	filter, args := SerialPrefixFilter("serial", nil)
+	if filter != "" || len(args) != 0 {
+		t.Errorf("Expected empty filter with no prefixes, got %q %#v", filter, args)
+	}
+
+	filter, args = SerialPrefixFilter("cs.serial", []int{1, 255})
+	expected := "(cs.serial LIKE :serialPrefix0 OR cs.serial LIKE :serialPrefix1)"
+	if filter != expected {
+		t.Errorf("Expected filter %q, got %q", expected, filter)
+	}
+	if args["serialPrefix0"] != "01%" || args["serialPrefix1"] != "ff%" {
+		t.Errorf("Unexpected filter arguments %#v", args)
+	}
+}
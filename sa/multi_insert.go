@@ -0,0 +1,67 @@
+package sa
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/go-gorp/gorp.v2"
+)
+
+// multiInserter builds a single INSERT statement for many rows of one table,
+// so that they can be stored in one round trip to the database.
+type multiInserter struct {
+	table   string
+	columns []string
+	rows    [][]interface{}
+}
+
+func newMultiInserter(table string, columns ...string) *multiInserter {
+	return &multiInserter{
+		table:   table,
+		columns: columns,
+	}
+}
+
+// add adds a row with a value for each column, in order. Values are converted
+// with the BoulderTypeConverter, as gorp would convert them.
+func (mi *multiInserter) add(values ...interface{}) error {
+	if len(values) != len(mi.columns) {
+		return fmt.Errorf("%d values given for %d columns of %s", len(values), len(mi.columns), mi.table)
+	}
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		converted, err := BoulderTypeConverter{}.ToDb(v)
+		if err != nil {
+			return err
+		}
+		row[i] = converted
+	}
+	mi.rows = append(mi.rows, row)
+	return nil
+}
+
+// query returns the INSERT statement for the rows added so far and its
+// arguments.
+func (mi *multiInserter) query() (string, []interface{}) {
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(mi.columns)), ",") + ")"
+	rowPlaceholders := make([]string, len(mi.rows))
+	var args []interface{}
+	for i, row := range mi.rows {
+		rowPlaceholders[i] = placeholders
+		args = append(args, row...)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		mi.table, strings.Join(mi.columns, ", "), strings.Join(rowPlaceholders, ", "))
+	return query, args
+}
+
+// insert stores the rows added so far in tx. It does nothing if there are
+// none.
+func (mi *multiInserter) insert(tx *gorp.Transaction) error {
+	if len(mi.rows) == 0 {
+		return nil
+	}
+	query, args := mi.query()
+	_, err := tx.Exec(query, args...)
+	return err
+}
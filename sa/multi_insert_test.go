@@ -0,0 +1,26 @@
+package sa
+
+import (
+	"testing"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestMultiInserter(t *testing.T) {
+	mi := newMultiInserter("pendingAuthorizations", "id", "identifier", "status")
+
+	err := mi.add("a", core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "example.com"}, core.StatusPending)
+	test.AssertNotError(t, err, "add failed")
+	err = mi.add("b", core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "example.net"}, core.StatusPending)
+	test.AssertNotError(t, err, "add failed")
+	err = mi.add("c")
+	test.AssertError(t, err, "add accepted too few values")
+
+	query, args := mi.query()
+	test.AssertEquals(t, query, "INSERT INTO pendingAuthorizations (id, identifier, status) VALUES (?,?,?), (?,?,?)")
+	test.AssertDeepEquals(t, args, []interface{}{
+		"a", `{"type":"dns","value":"example.com"}`, "pending",
+		"b", `{"type":"dns","value":"example.net"}`, "pending",
+	})
+}
@@ -42,6 +42,21 @@ It has these top-level messages:
 	Authorizations
 	AddPendingAuthorizationsRequest
 	AuthorizationIDs
+	KeyBlockedRequest
+	PolicyOverriddenRequest
+	AddBlockedKeyRequest
+	GetSerialsByKeyRequest
+	Serials
+	RateLimitOverride
+	RateLimitOverrides
+	CountFailedValidationsRequest
+	FQDNSetIssuedForAccountRequest
+	NewOrderAndAuthzsRequest
+	SignedCertificateTimestamps
+	AddVerifiedContactRequest
+	AccountStats
+	CertificateStatuses
+	AddCTSubmissionRequest
 */
 package proto
 
@@ -680,7 +695,10 @@ type AddCertificateRequest struct {
 	RegID *int64 `protobuf:"varint,2,opt,name=regID" json:"regID,omitempty"`
 	// A signed OCSP response for the certificate contained in "der".
 	// Note: The certificate status in the OCSP response is assumed to be 0 (good).
-	Ocsp             []byte `protobuf:"bytes,3,opt,name=ocsp" json:"ocsp,omitempty"`
+	Ocsp []byte `protobuf:"bytes,3,opt,name=ocsp" json:"ocsp,omitempty"`
+	// The time the certificate was issued, in Unix nanoseconds. If unset,
+	// the current time is used.
+	Issued           *int64 `protobuf:"varint,4,opt,name=issued" json:"issued,omitempty"`
 	XXX_unrecognized []byte `json:"-"`
 }
 
@@ -710,6 +728,13 @@ func (m *AddCertificateRequest) GetOcsp() []byte {
 	return nil
 }
 
+func (m *AddCertificateRequest) GetIssued() int64 {
+	if m != nil && m.Issued != nil {
+		return *m.Issued
+	}
+	return 0
+}
+
 type AddCertificateResponse struct {
 	Digest           *string `protobuf:"bytes,1,opt,name=digest" json:"digest,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
@@ -1026,6 +1051,449 @@ func (m *AuthorizationIDs) GetIds() []string {
 	return nil
 }
 
+type KeyBlockedRequest struct {
+	KeyHash          *string `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *KeyBlockedRequest) Reset()                    { *m = KeyBlockedRequest{} }
+func (m *KeyBlockedRequest) String() string            { return proto1.CompactTextString(m) }
+func (*KeyBlockedRequest) ProtoMessage()               {}
+func (*KeyBlockedRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *KeyBlockedRequest) GetKeyHash() string {
+	if m != nil && m.KeyHash != nil {
+		return *m.KeyHash
+	}
+	return ""
+}
+
+type PolicyOverriddenRequest struct {
+	Domain           *string `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
+	RegistrationID   *int64  `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *PolicyOverriddenRequest) Reset()                    { *m = PolicyOverriddenRequest{} }
+func (m *PolicyOverriddenRequest) String() string            { return proto1.CompactTextString(m) }
+func (*PolicyOverriddenRequest) ProtoMessage()               {}
+func (*PolicyOverriddenRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *PolicyOverriddenRequest) GetDomain() string {
+	if m != nil && m.Domain != nil {
+		return *m.Domain
+	}
+	return ""
+}
+
+func (m *PolicyOverriddenRequest) GetRegistrationID() int64 {
+	if m != nil && m.RegistrationID != nil {
+		return *m.RegistrationID
+	}
+	return 0
+}
+
+type AddBlockedKeyRequest struct {
+	KeyHash          *string `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
+	Added            *int64  `protobuf:"varint,2,opt,name=added" json:"added,omitempty"`
+	AddedBy          *string `protobuf:"bytes,3,opt,name=addedBy" json:"addedBy,omitempty"`
+	Comment          *string `protobuf:"bytes,4,opt,name=comment" json:"comment,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *AddBlockedKeyRequest) Reset()                    { *m = AddBlockedKeyRequest{} }
+func (m *AddBlockedKeyRequest) String() string            { return proto1.CompactTextString(m) }
+func (*AddBlockedKeyRequest) ProtoMessage()               {}
+func (*AddBlockedKeyRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *AddBlockedKeyRequest) GetKeyHash() string {
+	if m != nil && m.KeyHash != nil {
+		return *m.KeyHash
+	}
+	return ""
+}
+
+func (m *AddBlockedKeyRequest) GetAdded() int64 {
+	if m != nil && m.Added != nil {
+		return *m.Added
+	}
+	return 0
+}
+
+func (m *AddBlockedKeyRequest) GetAddedBy() string {
+	if m != nil && m.AddedBy != nil {
+		return *m.AddedBy
+	}
+	return ""
+}
+
+func (m *AddBlockedKeyRequest) GetComment() string {
+	if m != nil && m.Comment != nil {
+		return *m.Comment
+	}
+	return ""
+}
+
+type GetSerialsByKeyRequest struct {
+	KeyHash          *string `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
+	NotAfter         *int64  `protobuf:"varint,2,opt,name=notAfter" json:"notAfter,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *GetSerialsByKeyRequest) Reset()                    { *m = GetSerialsByKeyRequest{} }
+func (m *GetSerialsByKeyRequest) String() string            { return proto1.CompactTextString(m) }
+func (*GetSerialsByKeyRequest) ProtoMessage()               {}
+func (*GetSerialsByKeyRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *GetSerialsByKeyRequest) GetKeyHash() string {
+	if m != nil && m.KeyHash != nil {
+		return *m.KeyHash
+	}
+	return ""
+}
+
+func (m *GetSerialsByKeyRequest) GetNotAfter() int64 {
+	if m != nil && m.NotAfter != nil {
+		return *m.NotAfter
+	}
+	return 0
+}
+
+type Serials struct {
+	Serials          []string `protobuf:"bytes,1,rep,name=serials" json:"serials,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *Serials) Reset()                    { *m = Serials{} }
+func (m *Serials) String() string            { return proto1.CompactTextString(m) }
+func (*Serials) ProtoMessage()               {}
+func (*Serials) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *Serials) GetSerials() []string {
+	if m != nil {
+		return m.Serials
+	}
+	return nil
+}
+
+type RateLimitOverride struct {
+	RegistrationID   *int64  `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	LimitName        *string `protobuf:"bytes,2,opt,name=limitName" json:"limitName,omitempty"`
+	Threshold        *int64  `protobuf:"varint,3,opt,name=threshold" json:"threshold,omitempty"`
+	Expires          *int64  `protobuf:"varint,4,opt,name=expires" json:"expires,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RateLimitOverride) Reset()                    { *m = RateLimitOverride{} }
+func (m *RateLimitOverride) String() string            { return proto1.CompactTextString(m) }
+func (*RateLimitOverride) ProtoMessage()               {}
+func (*RateLimitOverride) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *RateLimitOverride) GetRegistrationID() int64 {
+	if m != nil && m.RegistrationID != nil {
+		return *m.RegistrationID
+	}
+	return 0
+}
+
+func (m *RateLimitOverride) GetLimitName() string {
+	if m != nil && m.LimitName != nil {
+		return *m.LimitName
+	}
+	return ""
+}
+
+func (m *RateLimitOverride) GetThreshold() int64 {
+	if m != nil && m.Threshold != nil {
+		return *m.Threshold
+	}
+	return 0
+}
+
+func (m *RateLimitOverride) GetExpires() int64 {
+	if m != nil && m.Expires != nil {
+		return *m.Expires
+	}
+	return 0
+}
+
+type RateLimitOverrides struct {
+	Overrides        []*RateLimitOverride `protobuf:"bytes,1,rep,name=overrides" json:"overrides,omitempty"`
+	XXX_unrecognized []byte               `json:"-"`
+}
+
+func (m *RateLimitOverrides) Reset()                    { *m = RateLimitOverrides{} }
+func (m *RateLimitOverrides) String() string            { return proto1.CompactTextString(m) }
+func (*RateLimitOverrides) ProtoMessage()               {}
+func (*RateLimitOverrides) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *RateLimitOverrides) GetOverrides() []*RateLimitOverride {
+	if m != nil {
+		return m.Overrides
+	}
+	return nil
+}
+
+type CountFailedValidationsRequest struct {
+	RegistrationID *int64  `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Hostname       *string `protobuf:"bytes,2,opt,name=hostname" json:"hostname,omitempty"`
+	// Count validations that failed in this range.
+	Range            *Range `protobuf:"bytes,3,opt,name=range" json:"range,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *CountFailedValidationsRequest) Reset()                    { *m = CountFailedValidationsRequest{} }
+func (m *CountFailedValidationsRequest) String() string            { return proto1.CompactTextString(m) }
+func (*CountFailedValidationsRequest) ProtoMessage()               {}
+func (*CountFailedValidationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *CountFailedValidationsRequest) GetRegistrationID() int64 {
+	if m != nil && m.RegistrationID != nil {
+		return *m.RegistrationID
+	}
+	return 0
+}
+
+func (m *CountFailedValidationsRequest) GetHostname() string {
+	if m != nil && m.Hostname != nil {
+		return *m.Hostname
+	}
+	return ""
+}
+
+func (m *CountFailedValidationsRequest) GetRange() *Range {
+	if m != nil {
+		return m.Range
+	}
+	return nil
+}
+
+type FQDNSetIssuedForAccountRequest struct {
+	Domains        []string `protobuf:"bytes,1,rep,name=domains" json:"domains,omitempty"`
+	RegistrationID *int64   `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"`
+	// Only consider certificates issued after this time, in Unix nanoseconds.
+	Earliest         *int64 `protobuf:"varint,3,opt,name=earliest" json:"earliest,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *FQDNSetIssuedForAccountRequest) Reset()         { *m = FQDNSetIssuedForAccountRequest{} }
+func (m *FQDNSetIssuedForAccountRequest) String() string { return proto1.CompactTextString(m) }
+func (*FQDNSetIssuedForAccountRequest) ProtoMessage()    {}
+func (*FQDNSetIssuedForAccountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{41}
+}
+
+func (m *FQDNSetIssuedForAccountRequest) GetDomains() []string {
+	if m != nil {
+		return m.Domains
+	}
+	return nil
+}
+
+func (m *FQDNSetIssuedForAccountRequest) GetRegistrationID() int64 {
+	if m != nil && m.RegistrationID != nil {
+		return *m.RegistrationID
+	}
+	return 0
+}
+
+func (m *FQDNSetIssuedForAccountRequest) GetEarliest() int64 {
+	if m != nil && m.Earliest != nil {
+		return *m.Earliest
+	}
+	return 0
+}
+
+type NewOrderAndAuthzsRequest struct {
+	NewOrder         *core.Order           `protobuf:"bytes,1,opt,name=newOrder" json:"newOrder,omitempty"`
+	NewAuthzs        []*core.Authorization `protobuf:"bytes,2,rep,name=newAuthzs" json:"newAuthzs,omitempty"`
+	XXX_unrecognized []byte                `json:"-"`
+}
+
+func (m *NewOrderAndAuthzsRequest) Reset()                    { *m = NewOrderAndAuthzsRequest{} }
+func (m *NewOrderAndAuthzsRequest) String() string            { return proto1.CompactTextString(m) }
+func (*NewOrderAndAuthzsRequest) ProtoMessage()               {}
+func (*NewOrderAndAuthzsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+func (m *NewOrderAndAuthzsRequest) GetNewOrder() *core.Order {
+	if m != nil {
+		return m.NewOrder
+	}
+	return nil
+}
+
+func (m *NewOrderAndAuthzsRequest) GetNewAuthzs() []*core.Authorization {
+	if m != nil {
+		return m.NewAuthzs
+	}
+	return nil
+}
+
+type SignedCertificateTimestamps struct {
+	Sct              []*SignedCertificateTimestamp `protobuf:"bytes,1,rep,name=sct" json:"sct,omitempty"`
+	XXX_unrecognized []byte                        `json:"-"`
+}
+
+func (m *SignedCertificateTimestamps) Reset()                    { *m = SignedCertificateTimestamps{} }
+func (m *SignedCertificateTimestamps) String() string            { return proto1.CompactTextString(m) }
+func (*SignedCertificateTimestamps) ProtoMessage()               {}
+func (*SignedCertificateTimestamps) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+func (m *SignedCertificateTimestamps) GetSct() []*SignedCertificateTimestamp {
+	if m != nil {
+		return m.Sct
+	}
+	return nil
+}
+
+type AddVerifiedContactRequest struct {
+	RegistrationID   *int64  `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Email            *string `protobuf:"bytes,2,opt,name=email" json:"email,omitempty"`
+	Verified         *int64  `protobuf:"varint,3,opt,name=verified" json:"verified,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *AddVerifiedContactRequest) Reset()                    { *m = AddVerifiedContactRequest{} }
+func (m *AddVerifiedContactRequest) String() string            { return proto1.CompactTextString(m) }
+func (*AddVerifiedContactRequest) ProtoMessage()               {}
+func (*AddVerifiedContactRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *AddVerifiedContactRequest) GetRegistrationID() int64 {
+	if m != nil && m.RegistrationID != nil {
+		return *m.RegistrationID
+	}
+	return 0
+}
+
+func (m *AddVerifiedContactRequest) GetEmail() string {
+	if m != nil && m.Email != nil {
+		return *m.Email
+	}
+	return ""
+}
+
+func (m *AddVerifiedContactRequest) GetVerified() int64 {
+	if m != nil && m.Verified != nil {
+		return *m.Verified
+	}
+	return 0
+}
+
+type AccountStats struct {
+	CertificatesLast7Days      *int64 `protobuf:"varint,1,opt,name=certificatesLast7Days" json:"certificatesLast7Days,omitempty"`
+	CertificatesLast30Days     *int64 `protobuf:"varint,2,opt,name=certificatesLast30Days" json:"certificatesLast30Days,omitempty"`
+	CertificatesLast90Days     *int64 `protobuf:"varint,3,opt,name=certificatesLast90Days" json:"certificatesLast90Days,omitempty"`
+	FailedValidationsLast7Days *int64 `protobuf:"varint,4,opt,name=failedValidationsLast7Days" json:"failedValidationsLast7Days,omitempty"`
+	PendingAuthorizations      *int64 `protobuf:"varint,5,opt,name=pendingAuthorizations" json:"pendingAuthorizations,omitempty"`
+	XXX_unrecognized           []byte `json:"-"`
+}
+
+func (m *AccountStats) Reset()                    { *m = AccountStats{} }
+func (m *AccountStats) String() string            { return proto1.CompactTextString(m) }
+func (*AccountStats) ProtoMessage()               {}
+func (*AccountStats) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+func (m *AccountStats) GetCertificatesLast7Days() int64 {
+	if m != nil && m.CertificatesLast7Days != nil {
+		return *m.CertificatesLast7Days
+	}
+	return 0
+}
+
+func (m *AccountStats) GetCertificatesLast30Days() int64 {
+	if m != nil && m.CertificatesLast30Days != nil {
+		return *m.CertificatesLast30Days
+	}
+	return 0
+}
+
+func (m *AccountStats) GetCertificatesLast90Days() int64 {
+	if m != nil && m.CertificatesLast90Days != nil {
+		return *m.CertificatesLast90Days
+	}
+	return 0
+}
+
+func (m *AccountStats) GetFailedValidationsLast7Days() int64 {
+	if m != nil && m.FailedValidationsLast7Days != nil {
+		return *m.FailedValidationsLast7Days
+	}
+	return 0
+}
+
+func (m *AccountStats) GetPendingAuthorizations() int64 {
+	if m != nil && m.PendingAuthorizations != nil {
+		return *m.PendingAuthorizations
+	}
+	return 0
+}
+
+type CertificateStatuses struct {
+	Statuses         []*CertificateStatus `protobuf:"bytes,1,rep,name=statuses" json:"statuses,omitempty"`
+	XXX_unrecognized []byte               `json:"-"`
+}
+
+func (m *CertificateStatuses) Reset()                    { *m = CertificateStatuses{} }
+func (m *CertificateStatuses) String() string            { return proto1.CompactTextString(m) }
+func (*CertificateStatuses) ProtoMessage()               {}
+func (*CertificateStatuses) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+
+func (m *CertificateStatuses) GetStatuses() []*CertificateStatus {
+	if m != nil {
+		return m.Statuses
+	}
+	return nil
+}
+
+type AddCTSubmissionRequest struct {
+	Serial           *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	LogURL           *string `protobuf:"bytes,2,opt,name=logURL" json:"logURL,omitempty"`
+	Precert          *bool   `protobuf:"varint,3,opt,name=precert" json:"precert,omitempty"`
+	Result           *string `protobuf:"bytes,4,opt,name=result" json:"result,omitempty"`
+	Submitted        *int64  `protobuf:"varint,5,opt,name=submitted" json:"submitted,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *AddCTSubmissionRequest) Reset()                    { *m = AddCTSubmissionRequest{} }
+func (m *AddCTSubmissionRequest) String() string            { return proto1.CompactTextString(m) }
+func (*AddCTSubmissionRequest) ProtoMessage()               {}
+func (*AddCTSubmissionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *AddCTSubmissionRequest) GetSerial() string {
+	if m != nil && m.Serial != nil {
+		return *m.Serial
+	}
+	return ""
+}
+
+func (m *AddCTSubmissionRequest) GetLogURL() string {
+	if m != nil && m.LogURL != nil {
+		return *m.LogURL
+	}
+	return ""
+}
+
+func (m *AddCTSubmissionRequest) GetPrecert() bool {
+	if m != nil && m.Precert != nil {
+		return *m.Precert
+	}
+	return false
+}
+
+func (m *AddCTSubmissionRequest) GetResult() string {
+	if m != nil && m.Result != nil {
+		return *m.Result
+	}
+	return ""
+}
+
+func (m *AddCTSubmissionRequest) GetSubmitted() int64 {
+	if m != nil && m.Submitted != nil {
+		return *m.Submitted
+	}
+	return 0
+}
+
 func init() {
 	proto1.RegisterType((*RegistrationID)(nil), "sa.RegistrationID")
 	proto1.RegisterType((*JSONWebKey)(nil), "sa.JSONWebKey")
@@ -1063,6 +1531,21 @@ func init() {
 	proto1.RegisterType((*Authorizations_MapElement)(nil), "sa.Authorizations.MapElement")
 	proto1.RegisterType((*AddPendingAuthorizationsRequest)(nil), "sa.AddPendingAuthorizationsRequest")
 	proto1.RegisterType((*AuthorizationIDs)(nil), "sa.AuthorizationIDs")
+	proto1.RegisterType((*KeyBlockedRequest)(nil), "sa.KeyBlockedRequest")
+	proto1.RegisterType((*PolicyOverriddenRequest)(nil), "sa.PolicyOverriddenRequest")
+	proto1.RegisterType((*AddBlockedKeyRequest)(nil), "sa.AddBlockedKeyRequest")
+	proto1.RegisterType((*GetSerialsByKeyRequest)(nil), "sa.GetSerialsByKeyRequest")
+	proto1.RegisterType((*Serials)(nil), "sa.Serials")
+	proto1.RegisterType((*RateLimitOverride)(nil), "sa.RateLimitOverride")
+	proto1.RegisterType((*RateLimitOverrides)(nil), "sa.RateLimitOverrides")
+	proto1.RegisterType((*CountFailedValidationsRequest)(nil), "sa.CountFailedValidationsRequest")
+	proto1.RegisterType((*FQDNSetIssuedForAccountRequest)(nil), "sa.FQDNSetIssuedForAccountRequest")
+	proto1.RegisterType((*NewOrderAndAuthzsRequest)(nil), "sa.NewOrderAndAuthzsRequest")
+	proto1.RegisterType((*SignedCertificateTimestamps)(nil), "sa.SignedCertificateTimestamps")
+	proto1.RegisterType((*AddVerifiedContactRequest)(nil), "sa.AddVerifiedContactRequest")
+	proto1.RegisterType((*AccountStats)(nil), "sa.AccountStats")
+	proto1.RegisterType((*CertificateStatuses)(nil), "sa.CertificateStatuses")
+	proto1.RegisterType((*AddCTSubmissionRequest)(nil), "sa.AddCTSubmissionRequest")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1119,6 +1602,19 @@ type StorageAuthorityClient interface {
 	GetOrderForNames(ctx context.Context, in *GetOrderForNamesRequest, opts ...grpc.CallOption) (*core.Order, error)
 	GetAuthorizations(ctx context.Context, in *GetAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error)
 	AddPendingAuthorizations(ctx context.Context, in *AddPendingAuthorizationsRequest, opts ...grpc.CallOption) (*AuthorizationIDs, error)
+	KeyBlocked(ctx context.Context, in *KeyBlockedRequest, opts ...grpc.CallOption) (*Exists, error)
+	PolicyOverridden(ctx context.Context, in *PolicyOverriddenRequest, opts ...grpc.CallOption) (*Exists, error)
+	AddBlockedKey(ctx context.Context, in *AddBlockedKeyRequest, opts ...grpc.CallOption) (*core.Empty, error)
+	GetSerialsByKey(ctx context.Context, in *GetSerialsByKeyRequest, opts ...grpc.CallOption) (*Serials, error)
+	GetRateLimitOverrides(ctx context.Context, in *core.Empty, opts ...grpc.CallOption) (*RateLimitOverrides, error)
+	CountFailedValidations(ctx context.Context, in *CountFailedValidationsRequest, opts ...grpc.CallOption) (*Count, error)
+	FQDNSetIssuedForAccount(ctx context.Context, in *FQDNSetIssuedForAccountRequest, opts ...grpc.CallOption) (*Exists, error)
+	NewOrderAndAuthzs(ctx context.Context, in *NewOrderAndAuthzsRequest, opts ...grpc.CallOption) (*core.Order, error)
+	GetSCTReceipts(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*SignedCertificateTimestamps, error)
+	AddVerifiedContact(ctx context.Context, in *AddVerifiedContactRequest, opts ...grpc.CallOption) (*core.Empty, error)
+	GetAccountStats(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*AccountStats, error)
+	GetCertificateStatuses(ctx context.Context, in *Serials, opts ...grpc.CallOption) (*CertificateStatuses, error)
+	AddCTSubmission(ctx context.Context, in *AddCTSubmissionRequest, opts ...grpc.CallOption) (*core.Empty, error)
 }
 
 type storageAuthorityClient struct {
@@ -1345,135 +1841,252 @@ func (c *storageAuthorityClient) FinalizeAuthorization(ctx context.Context, in *
 	return out, nil
 }
 
-func (c *storageAuthorityClient) MarkCertificateRevoked(ctx context.Context, in *MarkCertificateRevokedRequest, opts ...grpc.CallOption) (*core.Empty, error) {
-	out := new(core.Empty)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/MarkCertificateRevoked", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) MarkCertificateRevoked(ctx context.Context, in *MarkCertificateRevokedRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/MarkCertificateRevoked", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) AddCertificate(ctx context.Context, in *AddCertificateRequest, opts ...grpc.CallOption) (*AddCertificateResponse, error) {
+	out := new(AddCertificateResponse)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddCertificate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) AddSCTReceipt(ctx context.Context, in *SignedCertificateTimestamp, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddSCTReceipt", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) RevokeAuthorizationsByDomain(ctx context.Context, in *RevokeAuthorizationsByDomainRequest, opts ...grpc.CallOption) (*RevokeAuthorizationsByDomainResponse, error) {
+	out := new(RevokeAuthorizationsByDomainResponse)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/RevokeAuthorizationsByDomain", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) DeactivateRegistration(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/DeactivateRegistration", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) DeactivateAuthorization(ctx context.Context, in *AuthorizationID, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/DeactivateAuthorization", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) NewOrder(ctx context.Context, in *core.Order, opts ...grpc.CallOption) (*core.Order, error) {
+	out := new(core.Order)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/NewOrder", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) SetOrderProcessing(ctx context.Context, in *core.Order, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/SetOrderProcessing", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) SetOrderError(ctx context.Context, in *core.Order, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/SetOrderError", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) FinalizeOrder(ctx context.Context, in *core.Order, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/FinalizeOrder", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetOrder(ctx context.Context, in *OrderRequest, opts ...grpc.CallOption) (*core.Order, error) {
+	out := new(core.Order)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetOrder", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetValidOrderAuthorizations(ctx context.Context, in *GetValidOrderAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error) {
+	out := new(Authorizations)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetValidOrderAuthorizations", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetOrderForNames(ctx context.Context, in *GetOrderForNamesRequest, opts ...grpc.CallOption) (*core.Order, error) {
+	out := new(core.Order)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetOrderForNames", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetAuthorizations(ctx context.Context, in *GetAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error) {
+	out := new(Authorizations)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetAuthorizations", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) AddCertificate(ctx context.Context, in *AddCertificateRequest, opts ...grpc.CallOption) (*AddCertificateResponse, error) {
-	out := new(AddCertificateResponse)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddCertificate", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) AddPendingAuthorizations(ctx context.Context, in *AddPendingAuthorizationsRequest, opts ...grpc.CallOption) (*AuthorizationIDs, error) {
+	out := new(AuthorizationIDs)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddPendingAuthorizations", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) AddSCTReceipt(ctx context.Context, in *SignedCertificateTimestamp, opts ...grpc.CallOption) (*core.Empty, error) {
-	out := new(core.Empty)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddSCTReceipt", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) KeyBlocked(ctx context.Context, in *KeyBlockedRequest, opts ...grpc.CallOption) (*Exists, error) {
+	out := new(Exists)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/KeyBlocked", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) RevokeAuthorizationsByDomain(ctx context.Context, in *RevokeAuthorizationsByDomainRequest, opts ...grpc.CallOption) (*RevokeAuthorizationsByDomainResponse, error) {
-	out := new(RevokeAuthorizationsByDomainResponse)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/RevokeAuthorizationsByDomain", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) PolicyOverridden(ctx context.Context, in *PolicyOverriddenRequest, opts ...grpc.CallOption) (*Exists, error) {
+	out := new(Exists)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/PolicyOverridden", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) DeactivateRegistration(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*core.Empty, error) {
+func (c *storageAuthorityClient) AddBlockedKey(ctx context.Context, in *AddBlockedKeyRequest, opts ...grpc.CallOption) (*core.Empty, error) {
 	out := new(core.Empty)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/DeactivateRegistration", in, out, c.cc, opts...)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddBlockedKey", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) DeactivateAuthorization(ctx context.Context, in *AuthorizationID, opts ...grpc.CallOption) (*core.Empty, error) {
-	out := new(core.Empty)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/DeactivateAuthorization", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) GetSerialsByKey(ctx context.Context, in *GetSerialsByKeyRequest, opts ...grpc.CallOption) (*Serials, error) {
+	out := new(Serials)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetSerialsByKey", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) NewOrder(ctx context.Context, in *core.Order, opts ...grpc.CallOption) (*core.Order, error) {
-	out := new(core.Order)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/NewOrder", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) GetRateLimitOverrides(ctx context.Context, in *core.Empty, opts ...grpc.CallOption) (*RateLimitOverrides, error) {
+	out := new(RateLimitOverrides)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetRateLimitOverrides", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) SetOrderProcessing(ctx context.Context, in *core.Order, opts ...grpc.CallOption) (*core.Empty, error) {
-	out := new(core.Empty)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/SetOrderProcessing", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) CountFailedValidations(ctx context.Context, in *CountFailedValidationsRequest, opts ...grpc.CallOption) (*Count, error) {
+	out := new(Count)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/CountFailedValidations", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) SetOrderError(ctx context.Context, in *core.Order, opts ...grpc.CallOption) (*core.Empty, error) {
-	out := new(core.Empty)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/SetOrderError", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) FQDNSetIssuedForAccount(ctx context.Context, in *FQDNSetIssuedForAccountRequest, opts ...grpc.CallOption) (*Exists, error) {
+	out := new(Exists)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/FQDNSetIssuedForAccount", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) FinalizeOrder(ctx context.Context, in *core.Order, opts ...grpc.CallOption) (*core.Empty, error) {
-	out := new(core.Empty)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/FinalizeOrder", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) NewOrderAndAuthzs(ctx context.Context, in *NewOrderAndAuthzsRequest, opts ...grpc.CallOption) (*core.Order, error) {
+	out := new(core.Order)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/NewOrderAndAuthzs", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) GetOrder(ctx context.Context, in *OrderRequest, opts ...grpc.CallOption) (*core.Order, error) {
-	out := new(core.Order)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetOrder", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) GetSCTReceipts(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*SignedCertificateTimestamps, error) {
+	out := new(SignedCertificateTimestamps)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetSCTReceipts", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) GetValidOrderAuthorizations(ctx context.Context, in *GetValidOrderAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error) {
-	out := new(Authorizations)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetValidOrderAuthorizations", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) AddVerifiedContact(ctx context.Context, in *AddVerifiedContactRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddVerifiedContact", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) GetOrderForNames(ctx context.Context, in *GetOrderForNamesRequest, opts ...grpc.CallOption) (*core.Order, error) {
-	out := new(core.Order)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetOrderForNames", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) GetAccountStats(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*AccountStats, error) {
+	out := new(AccountStats)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetAccountStats", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) GetAuthorizations(ctx context.Context, in *GetAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error) {
-	out := new(Authorizations)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetAuthorizations", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) GetCertificateStatuses(ctx context.Context, in *Serials, opts ...grpc.CallOption) (*CertificateStatuses, error) {
+	out := new(CertificateStatuses)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetCertificateStatuses", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *storageAuthorityClient) AddPendingAuthorizations(ctx context.Context, in *AddPendingAuthorizationsRequest, opts ...grpc.CallOption) (*AuthorizationIDs, error) {
-	out := new(AuthorizationIDs)
-	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddPendingAuthorizations", in, out, c.cc, opts...)
+func (c *storageAuthorityClient) AddCTSubmission(ctx context.Context, in *AddCTSubmissionRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddCTSubmission", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1526,6 +2139,19 @@ type StorageAuthorityServer interface {
 	GetOrderForNames(context.Context, *GetOrderForNamesRequest) (*core.Order, error)
 	GetAuthorizations(context.Context, *GetAuthorizationsRequest) (*Authorizations, error)
 	AddPendingAuthorizations(context.Context, *AddPendingAuthorizationsRequest) (*AuthorizationIDs, error)
+	KeyBlocked(context.Context, *KeyBlockedRequest) (*Exists, error)
+	PolicyOverridden(context.Context, *PolicyOverriddenRequest) (*Exists, error)
+	AddBlockedKey(context.Context, *AddBlockedKeyRequest) (*core.Empty, error)
+	GetSerialsByKey(context.Context, *GetSerialsByKeyRequest) (*Serials, error)
+	GetRateLimitOverrides(context.Context, *core.Empty) (*RateLimitOverrides, error)
+	CountFailedValidations(context.Context, *CountFailedValidationsRequest) (*Count, error)
+	FQDNSetIssuedForAccount(context.Context, *FQDNSetIssuedForAccountRequest) (*Exists, error)
+	NewOrderAndAuthzs(context.Context, *NewOrderAndAuthzsRequest) (*core.Order, error)
+	GetSCTReceipts(context.Context, *Serial) (*SignedCertificateTimestamps, error)
+	AddVerifiedContact(context.Context, *AddVerifiedContactRequest) (*core.Empty, error)
+	GetAccountStats(context.Context, *RegistrationID) (*AccountStats, error)
+	GetCertificateStatuses(context.Context, *Serials) (*CertificateStatuses, error)
+	AddCTSubmission(context.Context, *AddCTSubmissionRequest) (*core.Empty, error)
 }
 
 func RegisterStorageAuthorityServer(s *grpc.Server, srv StorageAuthorityServer) {
@@ -2234,6 +2860,240 @@ func _StorageAuthority_AddPendingAuthorizations_Handler(srv interface{}, ctx con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageAuthority_KeyBlocked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyBlockedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).KeyBlocked(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/KeyBlocked",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).KeyBlocked(ctx, req.(*KeyBlockedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_PolicyOverridden_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PolicyOverriddenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).PolicyOverridden(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/PolicyOverridden",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).PolicyOverridden(ctx, req.(*PolicyOverriddenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_AddBlockedKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBlockedKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).AddBlockedKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/AddBlockedKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).AddBlockedKey(ctx, req.(*AddBlockedKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetSerialsByKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSerialsByKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetSerialsByKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetSerialsByKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetSerialsByKey(ctx, req.(*GetSerialsByKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetRateLimitOverrides_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(core.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetRateLimitOverrides(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetRateLimitOverrides",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetRateLimitOverrides(ctx, req.(*core.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_CountFailedValidations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountFailedValidationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).CountFailedValidations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/CountFailedValidations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).CountFailedValidations(ctx, req.(*CountFailedValidationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_FQDNSetIssuedForAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FQDNSetIssuedForAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).FQDNSetIssuedForAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/FQDNSetIssuedForAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).FQDNSetIssuedForAccount(ctx, req.(*FQDNSetIssuedForAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_NewOrderAndAuthzs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewOrderAndAuthzsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).NewOrderAndAuthzs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/NewOrderAndAuthzs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).NewOrderAndAuthzs(ctx, req.(*NewOrderAndAuthzsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetSCTReceipts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Serial)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetSCTReceipts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetSCTReceipts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetSCTReceipts(ctx, req.(*Serial))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_AddVerifiedContact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddVerifiedContactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).AddVerifiedContact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/AddVerifiedContact",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).AddVerifiedContact(ctx, req.(*AddVerifiedContactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetAccountStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegistrationID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetAccountStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetAccountStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetAccountStats(ctx, req.(*RegistrationID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetCertificateStatuses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Serials)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetCertificateStatuses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetCertificateStatuses",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetCertificateStatuses(ctx, req.(*Serials))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_AddCTSubmission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCTSubmissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).AddCTSubmission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/AddCTSubmission",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).AddCTSubmission(ctx, req.(*AddCTSubmissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "sa.StorageAuthority",
 	HandlerType: (*StorageAuthorityServer)(nil),
@@ -2394,6 +3254,58 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AddPendingAuthorizations",
 			Handler:    _StorageAuthority_AddPendingAuthorizations_Handler,
 		},
+		{
+			MethodName: "KeyBlocked",
+			Handler:    _StorageAuthority_KeyBlocked_Handler,
+		},
+		{
+			MethodName: "PolicyOverridden",
+			Handler:    _StorageAuthority_PolicyOverridden_Handler,
+		},
+		{
+			MethodName: "AddBlockedKey",
+			Handler:    _StorageAuthority_AddBlockedKey_Handler,
+		},
+		{
+			MethodName: "GetSerialsByKey",
+			Handler:    _StorageAuthority_GetSerialsByKey_Handler,
+		},
+		{
+			MethodName: "GetRateLimitOverrides",
+			Handler:    _StorageAuthority_GetRateLimitOverrides_Handler,
+		},
+		{
+			MethodName: "CountFailedValidations",
+			Handler:    _StorageAuthority_CountFailedValidations_Handler,
+		},
+		{
+			MethodName: "FQDNSetIssuedForAccount",
+			Handler:    _StorageAuthority_FQDNSetIssuedForAccount_Handler,
+		},
+		{
+			MethodName: "NewOrderAndAuthzs",
+			Handler:    _StorageAuthority_NewOrderAndAuthzs_Handler,
+		},
+		{
+			MethodName: "GetSCTReceipts",
+			Handler:    _StorageAuthority_GetSCTReceipts_Handler,
+		},
+		{
+			MethodName: "AddVerifiedContact",
+			Handler:    _StorageAuthority_AddVerifiedContact_Handler,
+		},
+		{
+			MethodName: "GetAccountStats",
+			Handler:    _StorageAuthority_GetAccountStats_Handler,
+		},
+		{
+			MethodName: "GetCertificateStatuses",
+			Handler:    _StorageAuthority_GetCertificateStatuses_Handler,
+		},
+		{
+			MethodName: "AddCTSubmission",
+			Handler:    _StorageAuthority_AddCTSubmission_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "sa/proto/sa.proto",
@@ -2402,115 +3314,156 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 func init() { proto1.RegisterFile("sa/proto/sa.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1760 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xef, 0x72, 0x1b, 0xb7,
-	0x11, 0xe7, 0x1f, 0xd3, 0x96, 0x56, 0x7f, 0x2c, 0xc1, 0x92, 0x7c, 0x39, 0x4b, 0xb2, 0x8c, 0xb8,
-	0xae, 0x32, 0xed, 0x28, 0xae, 0xda, 0x49, 0x3a, 0xa3, 0xba, 0xad, 0x64, 0xc9, 0x8c, 0x62, 0x5b,
-	0x56, 0x8f, 0x8e, 0x92, 0x69, 0x67, 0x3a, 0x03, 0xf3, 0x60, 0x1a, 0x35, 0x75, 0xc7, 0x00, 0xa0,
-	0x64, 0xf9, 0x05, 0xda, 0x27, 0xe8, 0xf4, 0x63, 0x9f, 0xa3, 0xef, 0xd2, 0x37, 0xe8, 0x0b, 0xf4,
-	0x5b, 0x07, 0x0b, 0x1c, 0xef, 0x0f, 0xef, 0xc8, 0x78, 0xdc, 0xc9, 0xb7, 0xdb, 0xc5, 0xee, 0x6f,
-	0x17, 0x8b, 0xc5, 0xe2, 0x47, 0xc2, 0xb2, 0x62, 0x9f, 0x0f, 0x64, 0xac, 0xe3, 0xcf, 0x15, 0xdb,
-	0xc1, 0x0f, 0xd2, 0x50, 0xcc, 0x5f, 0xed, 0xc6, 0x92, 0xbb, 0x05, 0xf3, 0x69, 0x97, 0xe8, 0x16,
-	0x2c, 0x06, 0xbc, 0x27, 0x94, 0x96, 0x4c, 0x8b, 0x38, 0x3a, 0x3e, 0x24, 0x8b, 0xd0, 0x10, 0xa1,
-	0x57, 0xdf, 0xaa, 0x6f, 0x37, 0x83, 0x86, 0x08, 0xe9, 0x26, 0xc0, 0xd7, 0x9d, 0x17, 0x27, 0xdf,
-	0xf2, 0x57, 0x4f, 0xf9, 0x15, 0x59, 0x82, 0xe6, 0x5f, 0x2e, 0xdf, 0xe2, 0xf2, 0x7c, 0x60, 0x3e,
-	0xe9, 0x3d, 0xb8, 0xb9, 0x3f, 0xd4, 0x6f, 0x62, 0x29, 0xde, 0x8f, 0x43, 0xcc, 0x22, 0xc4, 0xbf,
-	0xea, 0xb0, 0xd9, 0xe6, 0xfa, 0x94, 0x47, 0xa1, 0x88, 0x7a, 0x39, 0xeb, 0x80, 0x7f, 0x3f, 0xe4,
-	0x4a, 0x93, 0x07, 0xb0, 0x28, 0x73, 0x79, 0xb8, 0x0c, 0x0a, 0x5a, 0x63, 0x27, 0x42, 0x1e, 0x69,
-	0xf1, 0x5a, 0x70, 0xf9, 0xf2, 0x6a, 0xc0, 0xbd, 0x06, 0x86, 0x29, 0x68, 0xc9, 0x36, 0xdc, 0x4c,
-	0x35, 0x67, 0xac, 0x3f, 0xe4, 0x5e, 0x13, 0x0d, 0x8b, 0x6a, 0xb2, 0x09, 0x70, 0xc1, 0xfa, 0x22,
-	0xfc, 0x26, 0xd2, 0xa2, 0xef, 0x5d, 0xc3, 0xa8, 0x19, 0x0d, 0x55, 0xb0, 0xd1, 0xe6, 0xfa, 0xcc,
-	0x28, 0x72, 0x99, 0xab, 0x0f, 0x4d, 0xdd, 0x83, 0x1b, 0x61, 0x7c, 0xce, 0x44, 0xa4, 0xbc, 0xc6,
-	0x56, 0x73, 0x7b, 0x36, 0x48, 0x44, 0x53, 0xd4, 0x28, 0xbe, 0xc4, 0x04, 0x9b, 0x81, 0xf9, 0xa4,
-	0xff, 0xac, 0xc3, 0xad, 0x92, 0x90, 0xe4, 0xd7, 0xd0, 0xc2, 0xd4, 0xbc, 0xfa, 0x56, 0x73, 0x7b,
-	0x6e, 0x97, 0xee, 0x28, 0xb6, 0x53, 0x62, 0xb7, 0xf3, 0x9c, 0x0d, 0x8e, 0xfa, 0xfc, 0x9c, 0x47,
-	0x3a, 0xb0, 0x0e, 0xfe, 0x0b, 0x80, 0x54, 0x49, 0xd6, 0xe0, 0xba, 0x0d, 0xee, 0x4e, 0xc9, 0x49,
-	0xe4, 0x33, 0x68, 0xb1, 0xa1, 0x7e, 0xf3, 0x1e, 0xab, 0x3a, 0xb7, 0x7b, 0x6b, 0x07, 0x5b, 0x25,
-	0x7f, 0x62, 0xd6, 0x82, 0xfe, 0xb7, 0x01, 0xcb, 0x8f, 0xb9, 0x34, 0xa5, 0xec, 0x32, 0xcd, 0x3b,
-	0x9a, 0xe9, 0xa1, 0x32, 0xc0, 0x8a, 0x4b, 0xc1, 0xfa, 0x09, 0xb0, 0x95, 0xc8, 0x0e, 0x10, 0x35,
-	0x7c, 0xa5, 0xba, 0x52, 0xbc, 0xe2, 0x72, 0x7f, 0x30, 0x90, 0xf1, 0x05, 0x0f, 0x31, 0xca, 0x4c,
-	0x50, 0xb2, 0x82, 0x38, 0x88, 0xe8, 0x8e, 0xcd, 0x49, 0xe6, 0x5c, 0xe3, 0xae, 0x1a, 0x3c, 0x63,
-	0x4a, 0x7f, 0x33, 0x08, 0x99, 0xe6, 0xa1, 0x3b, 0xb2, 0xa2, 0x9a, 0x6c, 0xc1, 0x9c, 0xe4, 0x17,
-	0xf1, 0x5b, 0x1e, 0x1e, 0x32, 0xcd, 0xbd, 0x16, 0x5a, 0x65, 0x55, 0xe4, 0x3e, 0x2c, 0x38, 0x31,
-	0xe0, 0x4c, 0xc5, 0x91, 0x77, 0x1d, 0x6d, 0xf2, 0x4a, 0xf2, 0x2b, 0x58, 0xed, 0x33, 0xa5, 0x8f,
-	0xde, 0x0d, 0x84, 0x3d, 0xca, 0x13, 0xd6, 0xeb, 0xf0, 0x48, 0x7b, 0x37, 0xd0, 0xba, 0x7c, 0x91,
-	0x50, 0x98, 0x37, 0x09, 0x05, 0x5c, 0x0d, 0xe2, 0x48, 0x71, 0x6f, 0x06, 0x2f, 0x4c, 0x4e, 0x47,
-	0x7c, 0x98, 0x89, 0x62, 0xbd, 0xff, 0x5a, 0x73, 0xe9, 0xcd, 0x22, 0xd8, 0x48, 0x26, 0xeb, 0x30,
-	0x2b, 0x14, 0xc2, 0xf2, 0xd0, 0x03, 0x2c, 0x53, 0xaa, 0xa0, 0x5b, 0x70, 0xbd, 0x63, 0xeb, 0x5a,
-	0x51, 0x6f, 0xba, 0x07, 0xad, 0x80, 0x45, 0x3d, 0x0c, 0xc2, 0x99, 0xec, 0x0b, 0xae, 0xb4, 0xeb,
-	0xcb, 0x91, 0x6c, 0x9c, 0xfb, 0x4c, 0x9b, 0x95, 0x06, 0xae, 0x38, 0x89, 0x6e, 0x40, 0xeb, 0x71,
-	0x3c, 0x8c, 0x34, 0x59, 0x81, 0x56, 0xd7, 0x7c, 0x38, 0x4f, 0x2b, 0xd0, 0xef, 0xe0, 0x2e, 0x2e,
-	0x67, 0x4e, 0x5f, 0x1d, 0x5c, 0x9d, 0xb0, 0x73, 0x3e, 0xba, 0x13, 0x77, 0xa1, 0x25, 0x4d, 0x78,
-	0x74, 0x9c, 0xdb, 0x9d, 0x35, 0x7d, 0x8a, 0xf9, 0x04, 0x56, 0x6f, 0x90, 0x23, 0xe3, 0xe0, 0xae,
-	0x82, 0x15, 0xe8, 0x5f, 0xeb, 0x30, 0x8f, 0xd0, 0x0e, 0x8e, 0xfc, 0x0e, 0xe6, 0xbb, 0x19, 0xd9,
-	0xb5, 0xfd, 0x1d, 0x03, 0x97, 0xb5, 0xcb, 0xf6, 0x7b, 0xce, 0xc1, 0xff, 0x22, 0xd7, 0xf6, 0x04,
-	0xae, 0x99, 0x40, 0xae, 0x56, 0xf8, 0x9d, 0xee, 0xb1, 0x91, 0xdd, 0xe3, 0x29, 0x6c, 0x60, 0x80,
-	0xec, 0x70, 0x54, 0x07, 0x57, 0xc7, 0xa7, 0xc9, 0x0e, 0xcd, 0x8c, 0x1b, 0xb8, 0x39, 0xd8, 0x10,
-	0x83, 0x74, 0xc7, 0x8d, 0xf2, 0x1d, 0xd3, 0xbf, 0xd5, 0xe1, 0x1e, 0x42, 0x1e, 0x47, 0x17, 0x1f,
-	0x3f, 0x4c, 0x7c, 0x98, 0x79, 0x13, 0x2b, 0x8d, 0xbb, 0xb1, 0x13, 0x70, 0x24, 0xa7, 0xa9, 0x34,
-	0x2b, 0x52, 0xe9, 0x00, 0xc1, 0x4c, 0x5e, 0xc8, 0x90, 0xcb, 0x51, 0xe8, 0x75, 0x98, 0x65, 0x5d,
-	0xdc, 0xfd, 0x28, 0x6a, 0xaa, 0x98, 0xbe, 0xbf, 0x43, 0x58, 0x69, 0x73, 0xdd, 0x79, 0xfc, 0x32,
-	0xe0, 0x5d, 0x2e, 0x06, 0x3a, 0x81, 0xad, 0x9a, 0x08, 0x2b, 0xd0, 0xea, 0xc7, 0xbd, 0xe3, 0x43,
-	0x97, 0xbe, 0x15, 0xe8, 0x57, 0xb0, 0x82, 0xa9, 0x3d, 0xf9, 0xc3, 0xe1, 0x49, 0x87, 0x6b, 0x95,
-	0x41, 0xb9, 0x14, 0x51, 0x18, 0x5f, 0xba, 0xcc, 0x9c, 0x54, 0x3d, 0x54, 0xe9, 0x43, 0x58, 0x71,
-	0x20, 0x47, 0xef, 0x84, 0x4a, 0x91, 0x32, 0x1e, 0xf5, 0xbc, 0xc7, 0x29, 0x6c, 0x9d, 0x4a, 0x7e,
-	0x21, 0xe2, 0xa1, 0xca, 0xb4, 0x76, 0xde, 0xbb, 0x6a, 0x70, 0xae, 0x40, 0x4b, 0xf2, 0x64, 0x37,
-	0xcd, 0xc0, 0x0a, 0xe6, 0x9e, 0x5a, 0x77, 0xe3, 0xc7, 0xf1, 0x0b, 0xfd, 0x66, 0x02, 0x27, 0xd1,
-	0xa7, 0xb0, 0xf1, 0x9c, 0xc9, 0xb7, 0x99, 0x78, 0x41, 0x32, 0x7d, 0x26, 0x97, 0x8f, 0xc0, 0xb5,
-	0x6e, 0x1c, 0x72, 0x17, 0x0f, 0xbf, 0x69, 0x07, 0x56, 0xf7, 0xc3, 0x30, 0x87, 0x65, 0x41, 0x96,
-	0xa0, 0x19, 0x72, 0x99, 0xbc, 0xda, 0x21, 0x97, 0xe5, 0xf9, 0x1a, 0x50, 0x33, 0xa1, 0xb0, 0x71,
-	0xe6, 0x03, 0xfc, 0xa6, 0x0f, 0x61, 0xad, 0x08, 0xea, 0xe6, 0x97, 0xa9, 0x85, 0xe8, 0x25, 0x83,
-	0xc5, 0xd4, 0x02, 0x25, 0xfa, 0x9f, 0x3a, 0xf8, 0x1d, 0xd1, 0x8b, 0x78, 0xd6, 0xeb, 0xa5, 0x38,
-	0xe7, 0x4a, 0xb3, 0xf3, 0x41, 0x91, 0x60, 0x98, 0x07, 0x58, 0x75, 0xf5, 0x19, 0x97, 0x4a, 0xc4,
-	0x91, 0xcb, 0x27, 0xa3, 0x49, 0x1b, 0xa5, 0x99, 0x69, 0x14, 0xd3, 0xad, 0x3a, 0x81, 0x74, 0x4f,
-	0x40, 0xaa, 0x30, 0x98, 0xfc, 0x9d, 0xe6, 0x91, 0x01, 0x50, 0x38, 0xfb, 0xe7, 0x83, 0x8c, 0xc6,
-	0x78, 0x2b, 0xd1, 0x8b, 0x98, 0x1e, 0x4a, 0x8e, 0x63, 0x7f, 0x3e, 0x48, 0x15, 0xe4, 0xe7, 0xb0,
-	0xdc, 0xcd, 0xbc, 0x6c, 0xb6, 0xfc, 0x37, 0x30, 0xfa, 0xf8, 0x02, 0x7d, 0x04, 0x9f, 0xda, 0x33,
-	0xcb, 0xdf, 0xe8, 0x83, 0xab, 0x43, 0x6c, 0x8d, 0x29, 0x9d, 0x43, 0xff, 0x0c, 0xf7, 0x27, 0xbb,
-	0xbb, 0x6a, 0xaf, 0xc3, 0xec, 0x6b, 0x11, 0xb1, 0xbe, 0x78, 0xcf, 0x93, 0xea, 0xa5, 0x0a, 0xd3,
-	0xd5, 0x03, 0x4b, 0xaf, 0x5c, 0x05, 0x13, 0x91, 0x6e, 0xc2, 0x3c, 0xde, 0xf3, 0xec, 0xe0, 0xca,
-	0xf2, 0xbb, 0x67, 0x40, 0x13, 0x7e, 0x83, 0x76, 0xe5, 0x73, 0xa9, 0x78, 0x68, 0x6b, 0x70, 0x9d,
-	0x75, 0xbb, 0x7a, 0xd4, 0x40, 0x4e, 0xa2, 0x6d, 0xb8, 0xdd, 0xe6, 0x76, 0xb0, 0x3c, 0x89, 0x65,
-	0xee, 0x4d, 0x48, 0x5d, 0xea, 0x59, 0x97, 0x8a, 0xa7, 0xe0, 0x1f, 0x75, 0xf0, 0xda, 0x5c, 0xff,
-	0x68, 0x94, 0xcb, 0x30, 0x0b, 0xc9, 0xbf, 0x1f, 0x0a, 0xc9, 0xcf, 0x76, 0x4d, 0xd4, 0xf7, 0x0a,
-	0xdb, 0x6a, 0x26, 0x28, 0xaa, 0xe9, 0xdf, 0xeb, 0xb0, 0x58, 0xe0, 0x65, 0xbf, 0x4c, 0x78, 0x93,
-	0x7d, 0xa0, 0x36, 0xcc, 0x74, 0x9c, 0x40, 0xc9, 0xd0, 0xf6, 0xff, 0x4f, 0xc9, 0x9e, 0xc1, 0xdd,
-	0xfd, 0x30, 0x2c, 0xa3, 0xd9, 0xa3, 0xca, 0x7d, 0x96, 0x4f, 0x74, 0x12, 0xda, 0x7d, 0x58, 0x2a,
-	0x10, 0x7b, 0x2c, 0x9b, 0x08, 0x93, 0xc1, 0x69, 0x3e, 0x77, 0xff, 0xbd, 0x02, 0x4b, 0x1d, 0x1d,
-	0x4b, 0xd6, 0x4b, 0x1a, 0x58, 0x5f, 0x91, 0x3d, 0xb8, 0xd9, 0xe6, 0xb9, 0xb7, 0x93, 0x10, 0x7c,
-	0x30, 0x72, 0xc7, 0xe3, 0x13, 0x1b, 0x3d, 0xab, 0xa5, 0x35, 0xf2, 0x1b, 0x7c, 0x48, 0xb2, 0xca,
-	0x83, 0x2b, 0xf3, 0xd3, 0x63, 0xd1, 0x20, 0xa4, 0x3f, 0x45, 0x2a, 0xbc, 0x7f, 0x0b, 0x4b, 0xc5,
-	0xb6, 0x21, 0xb7, 0xc6, 0x8e, 0xe3, 0xf8, 0xd0, 0x2f, 0xdb, 0x3a, 0xad, 0x91, 0x97, 0xd8, 0xc0,
-	0x65, 0x35, 0x24, 0xc8, 0xb6, 0x27, 0xff, 0x8e, 0xa9, 0x42, 0x3d, 0x83, 0xb5, 0xf2, 0x1f, 0x11,
-	0xe4, 0x9e, 0x03, 0xad, 0xfe, 0x81, 0xe1, 0xdf, 0xae, 0x60, 0xf9, 0xb4, 0x46, 0x7e, 0x01, 0x8b,
-	0x6d, 0x9e, 0x25, 0x62, 0x04, 0x8c, 0xb1, 0x9d, 0x4c, 0xfe, 0xb2, 0x4d, 0x26, 0xb3, 0x4c, 0x6b,
-	0x64, 0x0f, 0xcb, 0x3b, 0xce, 0xdc, 0xb3, 0x8e, 0xab, 0x48, 0xb0, 0x8a, 0x26, 0xb4, 0x46, 0x1e,
-	0xc2, 0xda, 0x18, 0xf5, 0xb3, 0x3c, 0x33, 0x25, 0x04, 0xfe, 0xec, 0x88, 0x9e, 0xd1, 0x1a, 0xe9,
-	0x80, 0x57, 0x45, 0x16, 0xc9, 0xa7, 0x23, 0xc3, 0x6a, 0x2a, 0xe9, 0x2f, 0x15, 0xc9, 0x1e, 0xad,
-	0x91, 0xef, 0x1c, 0x3b, 0xcb, 0xbb, 0x1d, 0xbd, 0x63, 0x5d, 0xfd, 0x91, 0xc8, 0x5f, 0xb9, 0x0d,
-	0x8e, 0xf1, 0x3e, 0x7b, 0x50, 0x13, 0x39, 0x61, 0x7e, 0xe3, 0xcf, 0xe1, 0x4e, 0x85, 0x35, 0xd6,
-	0xeb, 0x43, 0xe1, 0x1e, 0x81, 0x8f, 0x9f, 0xa5, 0xb7, 0xbb, 0xf4, 0x76, 0xe5, 0xdc, 0x77, 0x61,
-	0x2e, 0x43, 0xf9, 0xc8, 0xda, 0x68, 0x2d, 0xc7, 0x01, 0xf3, 0x3e, 0xa7, 0x2e, 0x64, 0x29, 0x61,
-	0x25, 0x3f, 0x19, 0x99, 0x4e, 0x22, 0xb4, 0x79, 0xc4, 0xa7, 0xb0, 0x90, 0xe3, 0x88, 0xc4, 0x73,
-	0xdd, 0x3f, 0x46, 0x1b, 0xfd, 0x4d, 0x6c, 0xc7, 0x4a, 0x16, 0x41, 0x6b, 0xe4, 0x0b, 0x58, 0xc8,
-	0x51, 0x45, 0x0b, 0x56, 0xc6, 0x1e, 0xf3, 0x49, 0x7c, 0x09, 0x0b, 0x39, 0x62, 0x68, 0xfd, 0xca,
-	0xb8, 0xa2, 0x8f, 0x77, 0xc2, 0xaa, 0x68, 0x8d, 0xbc, 0x80, 0x4f, 0x2a, 0xf9, 0x21, 0xb9, 0x6f,
-	0x4c, 0xa7, 0xd1, 0xc7, 0x02, 0xe0, 0x1e, 0xdc, 0x3c, 0xe1, 0x97, 0x85, 0x31, 0x39, 0x36, 0xd4,
-	0x2a, 0x06, 0xdd, 0x97, 0x40, 0xec, 0x4f, 0xdd, 0xa9, 0xfe, 0x73, 0x56, 0x77, 0x74, 0x3e, 0xd0,
-	0x57, 0xb4, 0x46, 0x8e, 0xe0, 0xf6, 0x09, 0xbf, 0x2c, 0x9d, 0x70, 0x65, 0xd3, 0xab, 0x6a, 0xa4,
-	0xfd, 0x1e, 0x7c, 0x1b, 0xff, 0x87, 0x23, 0x15, 0x12, 0xd9, 0x83, 0xd5, 0x27, 0x8e, 0xc0, 0x7c,
-	0xb8, 0xf3, 0xd7, 0xb0, 0x56, 0x4e, 0x9c, 0xed, 0xcd, 0x9a, 0x48, 0xaa, 0x8b, 0x58, 0xc7, 0xb0,
-	0x98, 0xa7, 0xb8, 0xe4, 0x13, 0x7c, 0x31, 0xca, 0xb8, 0xb4, 0xef, 0x97, 0x2d, 0x59, 0x8e, 0x86,
-	0xcf, 0xcf, 0xc2, 0x7e, 0x18, 0x66, 0x3a, 0x7c, 0x4a, 0x1f, 0x17, 0x53, 0x51, 0xb0, 0x3e, 0x89,
-	0x0d, 0x92, 0x9f, 0xda, 0x8b, 0x3e, 0x95, 0x6e, 0xfa, 0xdb, 0xd3, 0x0d, 0x47, 0x49, 0xef, 0xc1,
-	0xda, 0x21, 0x67, 0x5d, 0x2d, 0x2e, 0xc6, 0xdb, 0x69, 0x7c, 0xae, 0x14, 0x32, 0x7e, 0x04, 0xb7,
-	0x53, 0xe7, 0x1f, 0xf0, 0xee, 0x16, 0xdc, 0x1f, 0xc0, 0xcc, 0x09, 0xbf, 0xc4, 0x29, 0x44, 0xdc,
-	0x12, 0x0a, 0x7e, 0x56, 0xc0, 0x97, 0x87, 0x74, 0x1c, 0xb1, 0x3c, 0x95, 0x71, 0x97, 0x2b, 0x25,
-	0xa2, 0x5e, 0xa9, 0x47, 0x82, 0xfc, 0x33, 0x58, 0x48, 0x3c, 0x8e, 0xa4, 0x8c, 0xe5, 0x34, 0xe3,
-	0xa4, 0x17, 0xab, 0x73, 0x49, 0x8d, 0x67, 0x12, 0x92, 0x4b, 0xf0, 0x11, 0xc9, 0x12, 0xec, 0x62,
-	0xe2, 0x7f, 0x82, 0x3b, 0x13, 0xf8, 0x35, 0x79, 0x90, 0x7d, 0xff, 0xab, 0x09, 0xb8, 0x4f, 0xc6,
-	0x29, 0xe5, 0x88, 0xed, 0xe4, 0xe8, 0x36, 0xb9, 0xe3, 0x10, 0xcb, 0x48, 0x78, 0x31, 0xb9, 0x36,
-	0x2c, 0x8f, 0x91, 0x6c, 0xb2, 0xee, 0x00, 0x3e, 0x24, 0x91, 0x6f, 0xc1, 0xab, 0xa2, 0x9e, 0xf6,
-	0x31, 0x9e, 0x42, 0x4c, 0xfd, 0x95, 0x92, 0x5e, 0x51, 0xb4, 0x76, 0x70, 0xe3, 0x8f, 0x2d, 0xfc,
-	0xa7, 0xfa, 0x7f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x5e, 0x68, 0x89, 0xbd, 0xd8, 0x16, 0x00, 0x00,
+	// 2411 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc5, 0x1a, 0xcb, 0x72, 0xdc, 0xc6,
+	0x31, 0xbb, 0x6b, 0x8a, 0xcb, 0xe6, 0x43, 0xe4, 0x88, 0x8f, 0x15, 0x28, 0xea, 0x01, 0x29, 0xb2,
+	0x5c, 0x49, 0x68, 0x89, 0x4e, 0x49, 0x76, 0xd1, 0xb2, 0x4d, 0x8a, 0x94, 0x44, 0x8b, 0x22, 0x19,
+	0x2c, 0x45, 0x3b, 0x49, 0x55, 0xaa, 0xa0, 0xc5, 0x68, 0x89, 0x70, 0xb9, 0xd8, 0x00, 0x58, 0x4a,
+	0xcb, 0x43, 0x4e, 0xa9, 0x4a, 0xae, 0xb9, 0xa4, 0x72, 0xf4, 0x39, 0x9f, 0x90, 0x6f, 0xca, 0x0f,
+	0xe4, 0x96, 0x9e, 0x9e, 0x01, 0x30, 0x78, 0xed, 0x92, 0xe5, 0x54, 0x72, 0x43, 0xcf, 0xf4, 0x6b,
+	0xba, 0x7b, 0xba, 0x7b, 0xba, 0x00, 0x73, 0x81, 0xfd, 0x69, 0xcf, 0xf7, 0x42, 0xef, 0xd3, 0xc0,
+	0x5e, 0xa5, 0x0f, 0x56, 0x0d, 0x6c, 0x63, 0xa1, 0xe5, 0xf9, 0x5c, 0x6d, 0x88, 0x4f, 0xb9, 0x65,
+	0xde, 0x86, 0x19, 0x8b, 0xb7, 0xdd, 0x20, 0xf4, 0xed, 0xd0, 0xf5, 0xba, 0x3b, 0x5b, 0x6c, 0x06,
+	0xaa, 0xae, 0xd3, 0xa8, 0xdc, 0xae, 0x3c, 0xa8, 0x59, 0xf8, 0x65, 0xde, 0x04, 0xf8, 0xb6, 0xb9,
+	0xbf, 0xf7, 0x1d, 0x7f, 0xfb, 0x8a, 0x0f, 0xd8, 0x2c, 0xd4, 0x7e, 0xff, 0xfe, 0x84, 0xb6, 0xa7,
+	0x2c, 0xf1, 0x69, 0xde, 0x81, 0xab, 0x1b, 0xfd, 0xf0, 0xd8, 0xf3, 0xdd, 0xf3, 0x3c, 0x8b, 0x09,
+	0x62, 0xf1, 0xcf, 0x0a, 0xdc, 0x7c, 0xc1, 0xc3, 0x03, 0xde, 0x75, 0xdc, 0x6e, 0x3b, 0x85, 0x6d,
+	0xf1, 0x3f, 0xf4, 0x79, 0x10, 0xb2, 0xfb, 0x30, 0xe3, 0xa7, 0xf4, 0x50, 0x1a, 0x64, 0x56, 0x05,
+	0x9e, 0xeb, 0xf0, 0x6e, 0xe8, 0xbe, 0x73, 0xb9, 0x7f, 0x38, 0xe8, 0xf1, 0x46, 0x95, 0xc4, 0x64,
+	0x56, 0xd9, 0x03, 0xb8, 0x9a, 0xac, 0x1c, 0xd9, 0x9d, 0x3e, 0x6f, 0xd4, 0x08, 0x31, 0xbb, 0xcc,
+	0xf0, 0x7c, 0x67, 0x76, 0xc7, 0x75, 0xde, 0xe0, 0x6a, 0xa7, 0xf1, 0x11, 0x49, 0xd5, 0x56, 0xcc,
+	0x00, 0x56, 0x50, 0xf7, 0x23, 0xb1, 0x90, 0xd2, 0x3c, 0xb8, 0xac, 0xea, 0x0d, 0x18, 0x77, 0xbc,
+	0x53, 0xdb, 0xed, 0x06, 0xa8, 0x73, 0x0d, 0x55, 0x89, 0x40, 0x61, 0xd4, 0xae, 0xf7, 0x9e, 0x14,
+	0xac, 0x59, 0xe2, 0xd3, 0xfc, 0xa1, 0x02, 0xd7, 0x0a, 0x44, 0xb2, 0xcf, 0x61, 0x8c, 0x54, 0x43,
+	0x11, 0xb5, 0x07, 0x93, 0x6b, 0xe6, 0x2a, 0xfa, 0xb8, 0x00, 0x6f, 0xf5, 0xb5, 0xdd, 0xdb, 0xee,
+	0xf0, 0x53, 0x3c, 0xa9, 0x25, 0x09, 0x8c, 0x7d, 0x80, 0x64, 0x91, 0x2d, 0xc2, 0x15, 0x29, 0x5c,
+	0x79, 0x49, 0x41, 0xec, 0x13, 0x18, 0xb3, 0x91, 0xd3, 0x39, 0x59, 0x75, 0x72, 0xed, 0xda, 0x2a,
+	0x85, 0x4a, 0xda, 0x63, 0x12, 0xc3, 0xfc, 0x77, 0x15, 0xe6, 0x9e, 0x71, 0x5f, 0x98, 0xb2, 0x65,
+	0x87, 0xbc, 0x19, 0xda, 0x61, 0x3f, 0x10, 0x8c, 0x03, 0xee, 0xbb, 0x76, 0x27, 0x62, 0x2c, 0x21,
+	0xb6, 0x0a, 0x2c, 0xe8, 0xbf, 0x0d, 0x5a, 0xbe, 0xfb, 0x96, 0xfb, 0x1b, 0x3d, 0x0c, 0xbe, 0x33,
+	0xee, 0x90, 0x94, 0xba, 0x55, 0xb0, 0x43, 0x7c, 0x88, 0xa3, 0x72, 0x9b, 0x82, 0x84, 0x5f, 0xbd,
+	0x56, 0xd0, 0xdb, 0xb5, 0x83, 0xf0, 0x4d, 0xcf, 0x41, 0xb9, 0x8e, 0x72, 0x59, 0x76, 0x99, 0xdd,
+	0x86, 0x49, 0x9f, 0x9f, 0x79, 0x27, 0xdc, 0xd9, 0x42, 0xb8, 0x31, 0x46, 0x58, 0xfa, 0x12, 0xbb,
+	0x07, 0xd3, 0x0a, 0xb4, 0xb8, 0x1d, 0x78, 0xdd, 0xc6, 0x15, 0xc2, 0x49, 0x2f, 0xb2, 0x5f, 0xc2,
+	0x42, 0x07, 0xd9, 0x6e, 0x7f, 0xe8, 0xb9, 0xd2, 0x95, 0x7b, 0x76, 0xbb, 0x89, 0x36, 0x6c, 0x8c,
+	0x13, 0x76, 0xf1, 0x26, 0x33, 0x61, 0x4a, 0x28, 0x64, 0xf1, 0xa0, 0x87, 0xfe, 0xe0, 0x8d, 0x3a,
+	0x5d, 0x98, 0xd4, 0x1a, 0x33, 0xa0, 0xde, 0xf5, 0xc2, 0x8d, 0x77, 0x21, 0xf7, 0x1b, 0x13, 0xc4,
+	0x2c, 0x86, 0xd9, 0x0d, 0x98, 0x70, 0x03, 0x62, 0x8b, 0x27, 0x04, 0x32, 0x53, 0xb2, 0x80, 0xb7,
+	0xf6, 0x4a, 0x53, 0xda, 0xb5, 0xc4, 0xde, 0xe6, 0x3a, 0x8c, 0x59, 0x76, 0xb7, 0x4d, 0x42, 0xb8,
+	0xed, 0x77, 0x5c, 0x8c, 0x54, 0x15, 0x97, 0x31, 0x2c, 0x88, 0x3b, 0x68, 0x08, 0xdc, 0xa9, 0xd2,
+	0x8e, 0x82, 0xcc, 0x15, 0x18, 0x7b, 0xe6, 0xf5, 0xf1, 0x14, 0xf3, 0x30, 0xd6, 0x12, 0x1f, 0x8a,
+	0x52, 0x02, 0xe6, 0xf7, 0x70, 0x8b, 0xb6, 0x35, 0xef, 0x07, 0x9b, 0x83, 0x3d, 0xfb, 0x94, 0xc7,
+	0x77, 0xe2, 0x16, 0x8c, 0xf9, 0x42, 0x3c, 0x11, 0x4e, 0xae, 0x4d, 0x88, 0x38, 0x25, 0x7d, 0x2c,
+	0xb9, 0x2e, 0x38, 0x77, 0x05, 0x81, 0xba, 0x0a, 0x12, 0x30, 0xff, 0x5c, 0x81, 0x29, 0x62, 0xad,
+	0xd8, 0xb1, 0xaf, 0x61, 0xaa, 0xa5, 0xc1, 0x2a, 0xec, 0x97, 0x05, 0x3b, 0x1d, 0x4f, 0x8f, 0xf7,
+	0x14, 0x81, 0xf1, 0x38, 0x15, 0xf6, 0x0c, 0x3e, 0x12, 0x82, 0x94, 0xad, 0xe8, 0x3b, 0x39, 0x63,
+	0x55, 0x3f, 0xe3, 0x01, 0xac, 0x90, 0x00, 0x3d, 0x39, 0xe2, 0x21, 0x77, 0x0e, 0xa2, 0x13, 0x8a,
+	0x1c, 0xd7, 0x53, 0x79, 0x10, 0xbf, 0x92, 0x13, 0x57, 0x8b, 0x4f, 0x6c, 0xfe, 0xa5, 0x02, 0x77,
+	0x88, 0xe5, 0x4e, 0xf7, 0xec, 0xc7, 0x27, 0x13, 0x74, 0xeb, 0xb1, 0x17, 0x84, 0x74, 0x1a, 0x99,
+	0x01, 0x63, 0x38, 0x51, 0xa5, 0x56, 0xa2, 0x4a, 0x13, 0x18, 0x69, 0xb2, 0xef, 0x3b, 0xdc, 0x8f,
+	0x45, 0x63, 0xc8, 0xd9, 0x2d, 0x3a, 0x7d, 0x2c, 0x35, 0x59, 0x18, 0x7d, 0xbe, 0x2d, 0x98, 0xc7,
+	0x3c, 0xd9, 0x7c, 0x76, 0x68, 0xf1, 0x16, 0x77, 0x7b, 0x61, 0xc4, 0xb6, 0x2c, 0x23, 0xa0, 0xdd,
+	0x3b, 0x5e, 0x1b, 0x45, 0x49, 0xf5, 0x25, 0x60, 0xbe, 0x84, 0x79, 0x52, 0xed, 0xf9, 0xaf, 0xb6,
+	0xf6, 0x9a, 0x3c, 0x0c, 0x34, 0x2e, 0xef, 0xdd, 0xae, 0x83, 0x59, 0x52, 0x6a, 0xa6, 0xa0, 0xf2,
+	0xa4, 0x6a, 0x3e, 0x84, 0x79, 0xc5, 0x64, 0xfb, 0x03, 0x5a, 0x2e, 0xe6, 0xa4, 0x51, 0x54, 0xd2,
+	0x14, 0x07, 0x70, 0xfb, 0x00, 0xef, 0xbe, 0xeb, 0xf5, 0x03, 0x2d, 0xb4, 0xd3, 0xd4, 0x65, 0x89,
+	0x13, 0x4f, 0x83, 0x1e, 0x52, 0xa7, 0xc1, 0x28, 0x22, 0x40, 0xdc, 0x53, 0x49, 0x2e, 0xe8, 0x38,
+	0x7d, 0x11, 0x5d, 0xdd, 0x52, 0x90, 0xf9, 0x0a, 0x56, 0x5e, 0xdb, 0xfe, 0x89, 0x26, 0xcf, 0x8a,
+	0xb2, 0xcf, 0x70, 0xf3, 0x61, 0x28, 0xb7, 0x3c, 0x87, 0x2b, 0x79, 0xf4, 0x6d, 0x9e, 0xc0, 0xc2,
+	0x86, 0xe3, 0xa4, 0x78, 0x49, 0x26, 0x58, 0x60, 0xd0, 0xd3, 0x51, 0xd5, 0xc6, 0xcf, 0x62, 0x7d,
+	0x05, 0x53, 0x91, 0xa1, 0x28, 0x70, 0xa6, 0x2c, 0xfa, 0x16, 0x0a, 0xb8, 0x41, 0xd0, 0x8f, 0x13,
+	0xad, 0x82, 0xd0, 0xbe, 0x8b, 0x59, 0x61, 0x2a, 0xaf, 0x09, 0x1b, 0xb9, 0xed, 0x28, 0xe1, 0x08,
+	0x1b, 0x11, 0x64, 0xfe, 0xab, 0x02, 0x46, 0xd3, 0x6d, 0x77, 0xb9, 0x4e, 0x75, 0xe8, 0xe2, 0x35,
+	0x0d, 0xed, 0xd3, 0x5e, 0xb6, 0xf1, 0x10, 0x85, 0x39, 0x68, 0x85, 0x47, 0x18, 0xa1, 0x18, 0xf2,
+	0x4a, 0x4f, 0x6d, 0x25, 0x09, 0xa0, 0x9a, 0x16, 0x40, 0x22, 0x8a, 0xc3, 0x88, 0xa5, 0xd2, 0x38,
+	0x59, 0x10, 0x3c, 0xf9, 0x87, 0x90, 0x77, 0x05, 0x83, 0x80, 0x6a, 0xc2, 0x94, 0xa5, 0xad, 0x08,
+	0xea, 0x00, 0x35, 0xc4, 0x52, 0xe3, 0x73, 0x2a, 0x07, 0x53, 0x56, 0xb2, 0xc0, 0x7e, 0x0e, 0x73,
+	0x2d, 0xad, 0xe2, 0x49, 0xb7, 0x8c, 0x93, 0xf4, 0xfc, 0x86, 0xf9, 0x14, 0xee, 0x4a, 0x5f, 0xa6,
+	0x6f, 0xfa, 0xe6, 0x60, 0x8b, 0x42, 0x66, 0x44, 0x44, 0x99, 0xbf, 0x83, 0x7b, 0xc3, 0xc9, 0x95,
+	0xb5, 0x51, 0xe5, 0x77, 0x6e, 0x17, 0x33, 0xca, 0x39, 0x8f, 0xac, 0x97, 0x2c, 0x88, 0x68, 0xef,
+	0xc9, 0xb6, 0x4b, 0x59, 0x30, 0x02, 0xb1, 0xaf, 0x9b, 0xa2, 0xfb, 0xaf, 0x27, 0x34, 0xbd, 0xef,
+	0xdb, 0x05, 0x33, 0xea, 0x7b, 0x08, 0xaf, 0x38, 0x5f, 0x65, 0x9d, 0x86, 0xa7, 0xc1, 0x9c, 0x11,
+	0xc6, 0x81, 0xa5, 0x20, 0xf3, 0x05, 0x2c, 0x21, 0x37, 0x62, 0xf4, 0xdc, 0xf3, 0x53, 0xb5, 0x22,
+	0x21, 0xa9, 0xe8, 0x24, 0x25, 0x25, 0xe2, 0xef, 0x15, 0x68, 0x20, 0xa7, 0xff, 0x59, 0x2b, 0x26,
+	0x3a, 0x0e, 0x1f, 0xd9, 0x63, 0xdd, 0x3d, 0x5a, 0x13, 0x52, 0xcf, 0x03, 0x0a, 0xab, 0xba, 0x95,
+	0x5d, 0x36, 0xff, 0x56, 0x81, 0x99, 0x4c, 0xbf, 0xf6, 0x59, 0xd4, 0x4f, 0xc9, 0xc2, 0xb5, 0x22,
+	0xb2, 0xe6, 0x90, 0x56, 0x8d, 0x70, 0xff, 0xfb, 0xad, 0xda, 0x2e, 0xdc, 0xc2, 0xab, 0x5a, 0xd4,
+	0x7e, 0xc7, 0x96, 0xfb, 0x24, 0xad, 0xe8, 0x30, 0x6e, 0xf7, 0x60, 0x36, 0xd3, 0xf0, 0x93, 0xd9,
+	0x5c, 0x27, 0x4a, 0xa8, 0xe2, 0xd3, 0xfc, 0x05, 0xcc, 0xe1, 0x7b, 0x61, 0xb3, 0xe3, 0xb5, 0xb4,
+	0x64, 0x86, 0x76, 0x3f, 0xe1, 0x83, 0x97, 0x76, 0x70, 0xac, 0x0e, 0x13, 0x81, 0xe6, 0xaf, 0x61,
+	0xe9, 0xc0, 0xeb, 0xb8, 0xad, 0xc1, 0xfe, 0x19, 0xf7, 0x7d, 0xd7, 0xc1, 0x26, 0x7d, 0x54, 0xca,
+	0xcd, 0x3b, 0xbb, 0x5a, 0xe4, 0x6c, 0xf3, 0x1c, 0xe6, 0xf1, 0xf4, 0x4a, 0x13, 0xd4, 0x69, 0xa4,
+	0x32, 0x22, 0xf2, 0x6c, 0xd4, 0xc0, 0x89, 0x92, 0x23, 0x01, 0x02, 0x9f, 0x3e, 0x36, 0x07, 0x2a,
+	0xe3, 0x44, 0xa0, 0xd8, 0x69, 0x79, 0xa7, 0xc2, 0x5b, 0x14, 0x1a, 0xb8, 0xa3, 0x40, 0x73, 0x0f,
+	0x16, 0x45, 0x51, 0xa4, 0x84, 0x80, 0x57, 0xf7, 0x42, 0xd2, 0xf5, 0xb6, 0xb0, 0x9a, 0x6e, 0x0b,
+	0xcd, 0xbb, 0x30, 0xae, 0x98, 0x09, 0x06, 0xb2, 0x14, 0xc4, 0x75, 0x4c, 0x81, 0xe6, 0x5f, 0x2b,
+	0x30, 0x67, 0x61, 0x1e, 0xda, 0x75, 0x4f, 0xdd, 0x50, 0xd9, 0x93, 0x5f, 0xf8, 0x6e, 0x60, 0x3e,
+	0xe9, 0x08, 0xc2, 0xbd, 0xa4, 0xb5, 0x48, 0x16, 0x28, 0xbd, 0x1e, 0xfb, 0x3c, 0x38, 0xf6, 0x3a,
+	0x8e, 0xba, 0x25, 0xc9, 0x82, 0xd0, 0x89, 0x53, 0x8b, 0x1a, 0xa8, 0xd4, 0x1b, 0x81, 0xe6, 0x0e,
+	0xb0, 0x9c, 0x4a, 0xe2, 0x7a, 0x4c, 0x78, 0x11, 0xa0, 0x22, 0x6f, 0x41, 0x36, 0x16, 0x19, 0x54,
+	0x2b, 0xc1, 0x33, 0xff, 0x54, 0x51, 0xbd, 0xd9, 0x73, 0xdb, 0xed, 0x70, 0x87, 0x32, 0xd4, 0xff,
+	0xa1, 0x89, 0xfa, 0x23, 0xdc, 0x54, 0xfd, 0xc5, 0x0e, 0x15, 0x44, 0x4c, 0x6b, 0x1b, 0xb2, 0x5b,
+	0x1a, 0xd9, 0x69, 0x5c, 0x34, 0x74, 0x53, 0xcd, 0x7b, 0x2d, 0xdd, 0xbc, 0x9b, 0x67, 0xd0, 0xd8,
+	0xe3, 0xef, 0x65, 0x6a, 0xee, 0x3a, 0x32, 0x05, 0x45, 0x92, 0x3f, 0xc6, 0x10, 0x52, 0x7b, 0xaa,
+	0x03, 0x9f, 0x94, 0x17, 0x5a, 0x66, 0xfc, 0x78, 0x93, 0x3d, 0x82, 0x09, 0xfc, 0x56, 0x69, 0xad,
+	0x5a, 0x7e, 0xf5, 0x13, 0x2c, 0x73, 0x1f, 0x96, 0xcb, 0x8b, 0x78, 0xc0, 0x1e, 0x42, 0x0d, 0x6b,
+	0xb4, 0x72, 0xe6, 0x4d, 0x61, 0xb5, 0x72, 0x6c, 0x4b, 0xa0, 0x9a, 0x7d, 0xb8, 0x8e, 0xf7, 0x13,
+	0xab, 0xba, 0x78, 0x94, 0x3b, 0xcf, 0xbc, 0x6e, 0x68, 0xb7, 0xc2, 0xcb, 0xba, 0x12, 0xaf, 0x2c,
+	0x47, 0xdb, 0x76, 0xa2, 0x6e, 0x92, 0x00, 0x61, 0xbf, 0x33, 0xc5, 0x37, 0xb2, 0x5f, 0x04, 0x9b,
+	0xff, 0xa8, 0xc2, 0x94, 0x72, 0x98, 0x78, 0xbb, 0x06, 0xe2, 0xa1, 0xa7, 0x15, 0xf1, 0x40, 0xbc,
+	0x25, 0x9f, 0x6c, 0xd9, 0x83, 0x40, 0x49, 0x2c, 0xde, 0x64, 0x8f, 0x61, 0x31, 0xbb, 0xf1, 0xd9,
+	0x43, 0x22, 0x93, 0x2e, 0x2d, 0xd9, 0x2d, 0xa2, 0xfb, 0x42, 0xd2, 0xd5, 0x8a, 0xe9, 0xe4, 0x2e,
+	0xfb, 0x0a, 0x8c, 0x77, 0xd9, 0xb8, 0x4f, 0x54, 0x95, 0xb7, 0x6e, 0x08, 0x86, 0x38, 0x65, 0xaf,
+	0xa8, 0x10, 0xa8, 0x07, 0x72, 0xf1, 0x26, 0xb6, 0xe5, 0xd7, 0x72, 0x6f, 0x7d, 0xbc, 0xbf, 0x8f,
+	0xa0, 0x1e, 0xa8, 0x6f, 0xfd, 0xfa, 0xe6, 0x50, 0xad, 0x18, 0x4d, 0xd4, 0x6f, 0xea, 0x1b, 0x0f,
+	0x9b, 0xfd, 0xb7, 0xa7, 0xd8, 0x4a, 0x6a, 0x33, 0xa0, 0xb2, 0x56, 0x57, 0x3c, 0x53, 0xbd, 0xf6,
+	0x1b, 0x6b, 0x57, 0x39, 0x57, 0x41, 0xd4, 0xdb, 0xf8, 0x5c, 0xd8, 0x89, 0x6c, 0x56, 0xb7, 0x22,
+	0x50, 0x50, 0x60, 0xd2, 0xe9, 0x77, 0xa2, 0x7c, 0xac, 0x20, 0x6a, 0xef, 0x84, 0xd8, 0x50, 0xcc,
+	0x0d, 0xe4, 0x81, 0x93, 0x85, 0xb5, 0x1f, 0x96, 0x61, 0xb6, 0x19, 0x7a, 0xbe, 0xdd, 0x8e, 0x7a,
+	0xae, 0x70, 0xc0, 0xd6, 0xe1, 0x2a, 0x66, 0x70, 0xfd, 0x19, 0xc8, 0x18, 0xe5, 0x82, 0x54, 0xfc,
+	0x19, 0x4c, 0xde, 0x1a, 0x7d, 0xd5, 0xfc, 0x09, 0xfb, 0x92, 0xde, 0x44, 0xfa, 0x22, 0xd5, 0x00,
+	0x36, 0x23, 0x38, 0x24, 0x53, 0xb5, 0x12, 0xea, 0xaf, 0x60, 0x36, 0xdb, 0xe9, 0xb0, 0x6b, 0xb9,
+	0x0e, 0x02, 0x85, 0x17, 0x5d, 0x59, 0xa4, 0x3f, 0xa4, 0x9e, 0xab, 0xa8, 0xec, 0x33, 0x1a, 0x1c,
+	0x0d, 0x1f, 0xc9, 0x95, 0x71, 0x3d, 0xa2, 0x92, 0x56, 0x34, 0x9c, 0xba, 0xa3, 0x98, 0x96, 0xcf,
+	0xca, 0x8c, 0xa5, 0x92, 0x81, 0x15, 0xf2, 0x7d, 0x04, 0x33, 0x48, 0xab, 0x85, 0x0e, 0x03, 0xca,
+	0x1e, 0x14, 0x03, 0xc6, 0x9c, 0x54, 0x46, 0xdb, 0x46, 0x92, 0x75, 0x32, 0x6f, 0x7e, 0x08, 0xa5,
+	0x13, 0x16, 0x07, 0x24, 0x12, 0xe3, 0xfb, 0x25, 0x37, 0xc5, 0x90, 0x23, 0x93, 0x24, 0xd7, 0x1b,
+	0x13, 0xf1, 0xa4, 0x01, 0x29, 0x9a, 0xd0, 0x28, 0x9b, 0x7b, 0xb0, 0xbb, 0x31, 0x62, 0xf9, 0x54,
+	0xc4, 0x98, 0xcd, 0xce, 0x2d, 0x90, 0xe9, 0xf7, 0xaa, 0x98, 0xa5, 0xc9, 0xb6, 0x3f, 0x60, 0x0e,
+	0xfc, 0x91, 0x9c, 0x5f, 0xaa, 0x03, 0xe6, 0x46, 0x18, 0xd2, 0x51, 0x43, 0xc7, 0x1b, 0xe9, 0x83,
+	0xbf, 0x86, 0xe5, 0x12, 0x6c, 0xb2, 0xd7, 0x65, 0xd9, 0x3d, 0x05, 0x83, 0x3e, 0x0b, 0x1b, 0xd2,
+	0xc2, 0xdb, 0x95, 0x22, 0x5f, 0x83, 0x49, 0x6d, 0x7a, 0xc1, 0x16, 0xe3, 0xbd, 0xd4, 0x38, 0x23,
+	0x4d, 0x73, 0xa0, 0x44, 0x16, 0xce, 0x5e, 0xd8, 0x4f, 0x63, 0xd4, 0x61, 0xb3, 0x99, 0x34, 0xc7,
+	0x57, 0x30, 0x9d, 0x1a, 0x77, 0xb0, 0x86, 0x8a, 0xfe, 0xdc, 0x04, 0xc4, 0x18, 0x51, 0x05, 0x91,
+	0xd9, 0x63, 0x98, 0x4e, 0x4d, 0x3d, 0x24, 0xb3, 0xa2, 0x41, 0x48, 0x5a, 0x89, 0x27, 0x30, 0x9d,
+	0x9a, 0x71, 0x48, 0xba, 0xa2, 0xb1, 0x87, 0x41, 0x77, 0x42, 0x2e, 0x21, 0xe1, 0x3e, 0x5c, 0x2f,
+	0x1d, 0x75, 0xb0, 0x7b, 0x02, 0x75, 0xd4, 0x24, 0x24, 0xc3, 0x10, 0xd3, 0x24, 0x76, 0x23, 0x99,
+	0x34, 0x99, 0x4b, 0x6a, 0x25, 0x89, 0xee, 0x09, 0x30, 0x39, 0xb5, 0x1d, 0x49, 0xaf, 0xda, 0x98,
+	0xed, 0xd3, 0x5e, 0x38, 0x40, 0xc2, 0x6d, 0x58, 0x42, 0xa9, 0x85, 0x19, 0xae, 0x28, 0x7b, 0x95,
+	0xa5, 0xb4, 0x6f, 0xc0, 0x90, 0xf2, 0x2f, 0xce, 0x29, 0xa3, 0xc8, 0x3a, 0x2c, 0x3c, 0x57, 0x6f,
+	0xee, 0xcb, 0x13, 0x7f, 0x0b, 0x8b, 0xc5, 0x33, 0x20, 0x79, 0xb3, 0x86, 0xce, 0x87, 0xb2, 0xbc,
+	0x76, 0xf0, 0x09, 0x9a, 0x9a, 0xca, 0xb0, 0xeb, 0x54, 0x31, 0x8a, 0xc6, 0x42, 0x86, 0x51, 0xb4,
+	0x25, 0xc7, 0x0a, 0x54, 0x7e, 0xa6, 0x71, 0x4f, 0x8b, 0xf0, 0x11, 0x71, 0x9c, 0x55, 0x25, 0x80,
+	0x1b, 0xc3, 0x06, 0x18, 0xec, 0x63, 0x79, 0xd1, 0x47, 0x4e, 0x48, 0x8c, 0x07, 0xa3, 0x11, 0x63,
+	0xa5, 0xd7, 0x61, 0x71, 0x8b, 0x63, 0xee, 0x74, 0xcf, 0xf2, 0xe1, 0x94, 0xcf, 0x2b, 0x19, 0x8d,
+	0x9f, 0xc2, 0x52, 0x42, 0x7c, 0x81, 0xba, 0x9b, 0x21, 0xbf, 0x0f, 0xf5, 0xa8, 0x23, 0x67, 0x7a,
+	0xbf, 0x6d, 0xe8, 0x00, 0x55, 0x1e, 0xd6, 0x54, 0xb3, 0x90, 0x03, 0xdf, 0x6b, 0x71, 0x6c, 0x82,
+	0xba, 0xed, 0x42, 0x8a, 0x88, 0xf3, 0xcf, 0x60, 0x3a, 0xa2, 0xd8, 0xf6, 0x7d, 0xcf, 0x1f, 0x85,
+	0x1c, 0xc5, 0x62, 0xb9, 0x2e, 0x09, 0x72, 0x3d, 0x9a, 0xcb, 0x30, 0x2a, 0x22, 0xfa, 0x4c, 0x28,
+	0xab, 0xf8, 0x6f, 0x61, 0x79, 0xc8, 0x48, 0x88, 0xdd, 0xd7, 0xeb, 0x7f, 0xf9, 0xcc, 0xc8, 0x60,
+	0xf9, 0x29, 0x48, 0xdc, 0xed, 0xa4, 0x26, 0x44, 0x6c, 0x59, 0x71, 0x2c, 0x9a, 0x1b, 0x65, 0x95,
+	0x7b, 0x01, 0x73, 0xb9, 0xb9, 0x10, 0xbb, 0xa1, 0x18, 0x5c, 0x46, 0x91, 0xef, 0xa0, 0x51, 0x36,
+	0x2d, 0x91, 0xc5, 0x78, 0xc4, 0x2c, 0xc5, 0x98, 0x2f, 0x88, 0x15, 0xd9, 0xe1, 0x40, 0x32, 0x12,
+	0x61, 0xd4, 0x98, 0xe4, 0x46, 0x24, 0x99, 0xb4, 0xfa, 0x14, 0x66, 0xb3, 0x63, 0x11, 0x69, 0x94,
+	0x92, 0x61, 0x49, 0x86, 0xfc, 0x73, 0xba, 0xc2, 0xc9, 0xe8, 0x43, 0xd6, 0x87, 0xa2, 0x69, 0x48,
+	0x36, 0x2e, 0xbe, 0xa4, 0xb6, 0x57, 0x1f, 0x5c, 0x30, 0x23, 0x2a, 0x70, 0xf9, 0x69, 0x06, 0x52,
+	0xc7, 0x1d, 0x97, 0xf4, 0xe5, 0x82, 0xe8, 0x7b, 0xf3, 0x0f, 0x7e, 0x5d, 0x8a, 0xb1, 0x58, 0xf8,
+	0xd4, 0xd7, 0x5b, 0x97, 0xdc, 0x0b, 0x5f, 0xeb, 0x35, 0xca, 0x5e, 0xff, 0xd9, 0x32, 0xbd, 0x54,
+	0xf2, 0x4a, 0x97, 0x3d, 0xf0, 0xf0, 0x27, 0x7c, 0xc6, 0x9c, 0xdf, 0xc0, 0x5c, 0xee, 0xc9, 0x2d,
+	0x43, 0xac, 0xec, 0x25, 0x9e, 0x0d, 0xd2, 0xaf, 0xa9, 0xc9, 0x4d, 0x72, 0x6a, 0xba, 0x57, 0xbd,
+	0x35, 0x3c, 0xc1, 0x0a, 0x15, 0x36, 0x81, 0xe5, 0x1f, 0xcb, 0x6c, 0x45, 0xb9, 0xb5, 0xf8, 0x11,
+	0x9d, 0xf5, 0xed, 0x17, 0xe4, 0xdb, 0xd4, 0xdb, 0xb7, 0x28, 0x39, 0x52, 0x3a, 0xd0, 0xb1, 0xc8,
+	0x02, 0x8b, 0x45, 0x1d, 0x37, 0x79, 0x36, 0x89, 0x00, 0xd9, 0xe6, 0x17, 0x60, 0xc9, 0xc0, 0xca,
+	0x3c, 0xff, 0x58, 0x5c, 0x86, 0xf2, 0x6f, 0xc2, 0x8c, 0xea, 0x9b, 0xe3, 0xbf, 0x19, 0xa3, 0xff,
+	0x16, 0xfe, 0x03, 0xce, 0xba, 0x17, 0xed, 0xe6, 0x20, 0x00, 0x00,
 }
@@ -0,0 +1,112 @@
+package sa
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/go-gorp/gorp.v2"
+
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// Replicas is a set of read-only database replicas that the SA sends
+// lag-tolerant read queries to, such as rate limit counts and certificate
+// lookups. Replicas are used in turn, skipping any that failed their last
+// health check or query until they pass a health check again.
+type Replicas struct {
+	log      blog.Logger
+	replicas []*replica
+	// next is the index of the replica to try first on the next pick.
+	next uint32
+}
+
+type replica struct {
+	name  string
+	dbMap *gorp.DbMap
+	// healthy is 1 if the replica is in use and 0 if it's being skipped.
+	healthy int32
+}
+
+// NewReplicas returns a Replicas for the given database maps, all of which are
+// initially considered healthy.
+func NewReplicas(dbMaps []*gorp.DbMap, logger blog.Logger) *Replicas {
+	r := &Replicas{log: logger}
+	for i, dbMap := range dbMaps {
+		r.replicas = append(r.replicas, &replica{
+			name:    fmt.Sprintf("replica%d", i),
+			dbMap:   dbMap,
+			healthy: 1,
+		})
+	}
+	return r
+}
+
+// pick returns the next healthy replica, or nil if there are none.
+func (r *Replicas) pick() *replica {
+	if r == nil || len(r.replicas) == 0 {
+		return nil
+	}
+	start := atomic.AddUint32(&r.next, 1)
+	for i := 0; i < len(r.replicas); i++ {
+		rep := r.replicas[(int(start)+i)%len(r.replicas)]
+		if atomic.LoadInt32(&rep.healthy) == 1 {
+			return rep
+		}
+	}
+	return nil
+}
+
+// setHealthy marks a replica as healthy or not, logging any change.
+func (r *Replicas) setHealthy(rep *replica, healthy bool, err error) {
+	var v int32
+	if healthy {
+		v = 1
+	}
+	if atomic.SwapInt32(&rep.healthy, v) == v {
+		return
+	}
+	if healthy {
+		r.log.Info(fmt.Sprintf("Database %s is healthy again", rep.name))
+	} else {
+		r.log.Warning(fmt.Sprintf("Database %s is unhealthy, sending its queries to the primary: %s", rep.name, err))
+	}
+}
+
+// checkHealth pings each replica and marks it healthy if the ping succeeds.
+func (r *Replicas) checkHealth() {
+	for _, rep := range r.replicas {
+		err := rep.dbMap.Db.Ping()
+		r.setHealthy(rep, err == nil, err)
+	}
+}
+
+// CheckHealthForever checks the health of the replicas every interval. It
+// never returns.
+func (r *Replicas) CheckHealthForever(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		r.checkHealth()
+	}
+}
+
+// readOnly runs query against a healthy replica if there is one. If the
+// replica fails, or finds no rows because it may not have caught up with the
+// primary yet, query is run against the primary instead. A replica that fails
+// is skipped until it passes a health check.
+func (ssa *SQLStorageAuthority) readOnly(query func(db *gorp.DbMap) error) error {
+	rep := ssa.replicas.pick()
+	if rep == nil {
+		return query(ssa.dbMap)
+	}
+	err := query(rep.dbMap)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		ssa.replicas.setHealthy(rep, false, err)
+	}
+	ssa.scope.Inc("ReplicaFallbacks", 1)
+	return query(ssa.dbMap)
+}
@@ -0,0 +1,92 @@
+package sa
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gopkg.in/go-gorp/gorp.v2"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestReplicasPick(t *testing.T) {
+	var nilReplicas *Replicas
+	test.Assert(t, nilReplicas.pick() == nil, "Picked a replica from nil Replicas")
+
+	a, b := &gorp.DbMap{}, &gorp.DbMap{}
+	r := NewReplicas([]*gorp.DbMap{a, b}, blog.NewMock())
+
+	// Healthy replicas are used in turn
+	first, second := r.pick(), r.pick()
+	test.Assert(t, first != nil && second != nil, "Didn't pick healthy replicas")
+	test.Assert(t, first != second, "Picked the same replica twice in a row")
+
+	// Unhealthy replicas are skipped
+	r.setHealthy(first, false, errors.New("broken"))
+	for i := 0; i < 3; i++ {
+		test.Assert(t, r.pick() == second, "Picked an unhealthy replica")
+	}
+	r.setHealthy(second, false, errors.New("broken"))
+	test.Assert(t, r.pick() == nil, "Picked a replica when all were unhealthy")
+
+	r.setHealthy(first, true, nil)
+	test.Assert(t, r.pick() == first, "Didn't pick a replica that became healthy")
+}
+
+func TestReplicasCheckHealth(t *testing.T) {
+	// Nothing listens on port 1, so pinging the replica fails
+	db, err := sql.Open("mysql", "boulder@tcp(127.0.0.1:1)/boulder")
+	test.AssertNotError(t, err, "sql.Open failed")
+	r := NewReplicas([]*gorp.DbMap{{Db: db}}, blog.NewMock())
+
+	r.checkHealth()
+	test.Assert(t, r.pick() == nil, "Replica that can't be reached is healthy")
+}
+
+func TestReadOnly(t *testing.T) {
+	primary, replicaMap := &gorp.DbMap{}, &gorp.DbMap{}
+	replicas := NewReplicas([]*gorp.DbMap{replicaMap}, blog.NewMock())
+	ssa := &SQLStorageAuthority{
+		dbMap: primary,
+		scope: metrics.NewNoopScope(),
+	}
+
+	// Without replicas the primary is used
+	var used []*gorp.DbMap
+	query := func(replicaErr error) func(db *gorp.DbMap) error {
+		used = nil
+		return func(db *gorp.DbMap) error {
+			used = append(used, db)
+			if db == replicaMap {
+				return replicaErr
+			}
+			return nil
+		}
+	}
+	err := ssa.readOnly(query(nil))
+	test.AssertNotError(t, err, "readOnly failed")
+	test.AssertDeepEquals(t, used, []*gorp.DbMap{primary})
+
+	ssa.UseReplicas(replicas)
+	err = ssa.readOnly(query(nil))
+	test.AssertNotError(t, err, "readOnly failed")
+	test.AssertDeepEquals(t, used, []*gorp.DbMap{replicaMap})
+
+	// A replica that finds nothing may be behind, so the primary is asked
+	// too, but the replica stays in use
+	err = ssa.readOnly(query(sql.ErrNoRows))
+	test.AssertNotError(t, err, "readOnly failed")
+	test.AssertDeepEquals(t, used, []*gorp.DbMap{replicaMap, primary})
+	test.Assert(t, replicas.pick() != nil, "Replica was marked unhealthy after finding no rows")
+
+	// A replica that fails falls back to the primary and is skipped afterwards
+	err = ssa.readOnly(query(errors.New("broken")))
+	test.AssertNotError(t, err, "readOnly failed")
+	test.AssertDeepEquals(t, used, []*gorp.DbMap{replicaMap, primary})
+	err = ssa.readOnly(query(nil))
+	test.AssertNotError(t, err, "readOnly failed")
+	test.AssertDeepEquals(t, used, []*gorp.DbMap{primary})
+}
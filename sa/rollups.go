@@ -0,0 +1,143 @@
+package sa
+
+import (
+	"sort"
+	"time"
+
+	"gopkg.in/go-gorp/gorp.v2"
+)
+
+// IssuanceStats is the rollup of a registration's issuance activity over a
+// single UTC day. Rows are maintained by RollupIssuanceStats so that support
+// and billing questions can be answered without scanning the certificates
+// table.
+type IssuanceStats struct {
+	RegistrationID int64     `db:"registrationID"`
+	Day            time.Time `db:"day"`
+	// CertificatesIssued is the number of certificates issued on Day.
+	CertificatesIssued int64 `db:"certificatesIssued"`
+	// NamesIssued is the number of names across those certificates.
+	NamesIssued int64 `db:"namesIssued"`
+	// FailedOrders is the number of orders created on Day that have an error.
+	FailedOrders int64 `db:"failedOrders"`
+}
+
+const issuanceStatsFields = "registrationID, day, certificatesIssued, namesIssued, failedOrders"
+
+// regCount is a count of rows for a single registration, as returned by the
+// aggregation queries in RollupIssuanceStats.
+type regCount struct {
+	RegistrationID int64 `db:"registrationID"`
+	Count          int64 `db:"count"`
+}
+
+// truncateToDay returns the start of the UTC day containing t.
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// mergeIssuanceCounts combines per-registration counts into IssuanceStats rows
+// for day, ordered by registration ID.
+func mergeIssuanceCounts(day time.Time, certs, names, failed []regCount) []IssuanceStats {
+	byReg := make(map[int64]*IssuanceStats)
+	get := func(regID int64) *IssuanceStats {
+		s, present := byReg[regID]
+		if !present {
+			s = &IssuanceStats{RegistrationID: regID, Day: day}
+			byReg[regID] = s
+		}
+		return s
+	}
+	for _, c := range certs {
+		get(c.RegistrationID).CertificatesIssued += c.Count
+	}
+	for _, c := range names {
+		get(c.RegistrationID).NamesIssued += c.Count
+	}
+	for _, c := range failed {
+		get(c.RegistrationID).FailedOrders += c.Count
+	}
+
+	stats := make([]IssuanceStats, 0, len(byReg))
+	for _, s := range byReg {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].RegistrationID < stats[j].RegistrationID })
+	return stats
+}
+
+// RollupIssuanceStats computes the issuance statistics of every registration
+// with activity on the UTC day containing day and replaces any existing rows
+// for that day with them, so it is safe to run more than once for the same
+// day. It returns the number of rows written.
+func RollupIssuanceStats(dbMap *gorp.DbMap, day time.Time) (int, error) {
+	start := truncateToDay(day)
+	args := map[string]interface{}{
+		"start": start,
+		"end":   start.AddDate(0, 0, 1),
+	}
+
+	var certs, names, failed []regCount
+	_, err := dbMap.Select(&certs,
+		`SELECT registrationID, COUNT(1) AS count FROM certificates
+		WHERE issued >= :start AND issued < :end
+		GROUP BY registrationID`, args)
+	if err != nil {
+		return 0, err
+	}
+	_, err = dbMap.Select(&names,
+		`SELECT c.registrationID, COUNT(1) AS count FROM certificates AS c
+		JOIN issuedNames AS n ON n.serial = c.serial
+		WHERE c.issued >= :start AND c.issued < :end
+		GROUP BY c.registrationID`, args)
+	if err != nil {
+		return 0, err
+	}
+	_, err = dbMap.Select(&failed,
+		`SELECT registrationID, COUNT(1) AS count FROM orders
+		WHERE created >= :start AND created < :end AND error IS NOT NULL
+		GROUP BY registrationID`, args)
+	if err != nil {
+		return 0, err
+	}
+	stats := mergeIssuanceCounts(start, certs, names, failed)
+
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM registrationIssuanceStats WHERE day = ?", start)
+	if err != nil {
+		return 0, Rollback(tx, err)
+	}
+	for i := range stats {
+		if err := tx.Insert(&stats[i]); err != nil {
+			return 0, Rollback(tx, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(stats), nil
+}
+
+// SelectIssuanceStats returns the issuance statistics for the UTC days from
+// start up to and including end, ordered by day and registration ID. If regID
+// is non-zero only the statistics of that registration are returned.
+func SelectIssuanceStats(s dbSelector, regID int64, start, end time.Time) ([]IssuanceStats, error) {
+	q := "SELECT " + issuanceStatsFields + " FROM registrationIssuanceStats WHERE day >= :start AND day <= :end"
+	args := map[string]interface{}{
+		"start": truncateToDay(start),
+		"end":   truncateToDay(end),
+	}
+	if regID != 0 {
+		q += " AND registrationID = :regID"
+		args["regID"] = regID
+	}
+	q += " ORDER BY day, registrationID"
+
+	var stats []IssuanceStats
+	_, err := s.Select(&stats, q, args)
+	return stats, err
+}
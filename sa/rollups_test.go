@@ -0,0 +1,30 @@
+package sa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestTruncateToDay(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	day := truncateToDay(time.Date(2018, 3, 14, 5, 30, 0, 0, loc))
+	test.AssertEquals(t, day, time.Date(2018, 3, 13, 0, 0, 0, 0, time.UTC))
+}
+
+func TestMergeIssuanceCounts(t *testing.T) {
+	day := time.Date(2018, 3, 14, 0, 0, 0, 0, time.UTC)
+	stats := mergeIssuanceCounts(day,
+		[]regCount{{RegistrationID: 2, Count: 3}, {RegistrationID: 1, Count: 1}},
+		[]regCount{{RegistrationID: 2, Count: 7}, {RegistrationID: 1, Count: 2}},
+		[]regCount{{RegistrationID: 3, Count: 4}},
+	)
+	test.AssertDeepEquals(t, stats, []IssuanceStats{
+		{RegistrationID: 1, Day: day, CertificatesIssued: 1, NamesIssued: 2},
+		{RegistrationID: 2, Day: day, CertificatesIssued: 3, NamesIssued: 7},
+		{RegistrationID: 3, Day: day, FailedOrders: 4},
+	})
+
+	test.AssertEquals(t, len(mergeIssuanceCounts(day, nil, nil, nil)), 0)
+}
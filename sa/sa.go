@@ -24,6 +24,7 @@ import (
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/probs"
 	"github.com/letsencrypt/boulder/revocation"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
@@ -45,6 +46,9 @@ type SQLStorageAuthority struct {
 	// We use a function type here so we can mock out this internal function in
 	// unittests.
 	countCertificatesByName certCountFunc
+
+	// replicas, if set, serve lag-tolerant read queries in place of dbMap.
+	replicas *Replicas
 }
 
 func digest256(data []byte) []byte {
@@ -110,6 +114,13 @@ func NewSQLStorageAuthority(
 	return ssa, nil
 }
 
+// UseReplicas sends the SA's lag-tolerant read queries (certificate lookups,
+// and the counts and FQDN set checks used for rate limiting) to replicas,
+// falling back to the primary database when they fail.
+func (ssa *SQLStorageAuthority) UseReplicas(replicas *Replicas) {
+	ssa.replicas = replicas
+}
+
 func statusIsPending(status core.AcmeStatus) bool {
 	return status == core.StatusPending || status == core.StatusProcessing || status == core.StatusUnknown
 }
@@ -297,18 +308,20 @@ func ipRange(ip net.IP) (net.IP, net.IP) {
 // time range for a single IP address.
 func (ssa *SQLStorageAuthority) CountRegistrationsByIP(ctx context.Context, ip net.IP, earliest time.Time, latest time.Time) (int, error) {
 	var count int64
-	err := ssa.dbMap.SelectOne(
-		&count,
-		`SELECT COUNT(1) FROM registrations
-		 WHERE
-		 initialIP = :ip AND
-		 :earliest < createdAt AND
-		 createdAt <= :latest`,
-		map[string]interface{}{
-			"ip":       []byte(ip),
-			"earliest": earliest,
-			"latest":   latest,
-		})
+	err := ssa.readOnly(func(db *gorp.DbMap) error {
+		return db.SelectOne(
+			&count,
+			`SELECT COUNT(1) FROM registrations
+			 WHERE
+			 initialIP = :ip AND
+			 :earliest < createdAt AND
+			 createdAt <= :latest`,
+			map[string]interface{}{
+				"ip":       []byte(ip),
+				"earliest": earliest,
+				"latest":   latest,
+			})
+	})
 	if err != nil {
 		return -1, err
 	}
@@ -322,20 +335,22 @@ func (ssa *SQLStorageAuthority) CountRegistrationsByIP(ctx context.Context, ip n
 func (ssa *SQLStorageAuthority) CountRegistrationsByIPRange(ctx context.Context, ip net.IP, earliest time.Time, latest time.Time) (int, error) {
 	var count int64
 	beginIP, endIP := ipRange(ip)
-	err := ssa.dbMap.SelectOne(
-		&count,
-		`SELECT COUNT(1) FROM registrations
-		 WHERE
-		 :beginIP <= initialIP AND
-		 initialIP < :endIP AND
-		 :earliest < createdAt AND
-		 createdAt <= :latest`,
-		map[string]interface{}{
-			"earliest": earliest,
-			"latest":   latest,
-			"beginIP":  []byte(beginIP),
-			"endIP":    []byte(endIP),
-		})
+	err := ssa.readOnly(func(db *gorp.DbMap) error {
+		return db.SelectOne(
+			&count,
+			`SELECT COUNT(1) FROM registrations
+			 WHERE
+			 :beginIP <= initialIP AND
+			 initialIP < :endIP AND
+			 :earliest < createdAt AND
+			 createdAt <= :latest`,
+			map[string]interface{}{
+				"earliest": earliest,
+				"latest":   latest,
+				"beginIP":  []byte(beginIP),
+				"endIP":    []byte(endIP),
+			})
+	})
 	if err != nil {
 		return -1, err
 	}
@@ -466,14 +481,17 @@ func (ssa *SQLStorageAuthority) countCertificatesByExactName(domain string, earl
 // and are not counted.
 func (ssa *SQLStorageAuthority) countCertificates(domain string, earliest, latest time.Time, query string) (int, error) {
 	var serials []string
-	_, err := ssa.dbMap.Select(
-		&serials,
-		query,
-		map[string]interface{}{
-			"reversedDomain": ReverseName(domain),
-			"earliest":       earliest,
-			"latest":         latest,
-		})
+	err := ssa.readOnly(func(db *gorp.DbMap) error {
+		_, err := db.Select(
+			&serials,
+			query,
+			map[string]interface{}{
+				"reversedDomain": ReverseName(domain),
+				"earliest":       earliest,
+				"latest":         latest,
+			})
+		return err
+	})
 	if err == sql.ErrNoRows {
 		return 0, nil
 	} else if err != nil {
@@ -524,7 +542,12 @@ func (ssa *SQLStorageAuthority) GetCertificate(ctx context.Context, serial strin
 		return core.Certificate{}, err
 	}
 
-	cert, err := SelectCertificate(ssa.dbMap, "WHERE serial = ?", serial)
+	var cert core.Certificate
+	err := ssa.readOnly(func(db *gorp.DbMap) error {
+		var err error
+		cert, err = SelectCertificate(db, "WHERE serial = ?", serial)
+		return err
+	})
 	if err == sql.ErrNoRows {
 		return core.Certificate{}, berrors.NotFoundError("certificate with serial %q not found", serial)
 	}
@@ -544,13 +567,22 @@ func (ssa *SQLStorageAuthority) GetCertificateStatus(ctx context.Context, serial
 	}
 
 	var status core.CertificateStatus
-	statusObj, err := ssa.dbMap.Get(certStatusModel{}, serial)
+	var statusObj interface{}
+	err := ssa.readOnly(func(db *gorp.DbMap) error {
+		var err error
+		statusObj, err = db.Get(certStatusModel{}, serial)
+		if err == nil && statusObj == nil {
+			// Let readOnly retry the primary in case the replica is behind
+			return sql.ErrNoRows
+		}
+		return err
+	})
+	if err == sql.ErrNoRows {
+		return status, nil
+	}
 	if err != nil {
 		return status, err
 	}
-	if statusObj == nil {
-		return status, nil
-	}
 	statusModel := statusObj.(*certStatusModel)
 	status = core.CertificateStatus{
 		Serial:                statusModel.Serial,
@@ -567,6 +599,55 @@ func (ssa *SQLStorageAuthority) GetCertificateStatus(ctx context.Context, serial
 	return status, nil
 }
 
+// MaxStatusesPerRequest is the most serials GetCertificateStatuses will look
+// up at once, keeping the query and the response a manageable size.
+const MaxStatusesPerRequest = 1000
+
+// GetCertificateStatuses returns the certificate statuses of the given serials,
+// saving callers with many serials to check a round trip for each. Serials
+// without a certificate status are left out of the result.
+func (ssa *SQLStorageAuthority) GetCertificateStatuses(ctx context.Context, serials []string) ([]core.CertificateStatus, error) {
+	if len(serials) > MaxStatusesPerRequest {
+		return nil, berrors.MalformedError("too many serials: %d, at most %d may be looked up at once", len(serials), MaxStatusesPerRequest)
+	}
+	for _, serial := range serials {
+		if !core.ValidSerial(serial) {
+			return nil, berrors.MalformedError("invalid certificate serial %s", serial)
+		}
+	}
+	var statuses []core.CertificateStatus
+	var replica bool
+	err := ssa.readOnly(func(db *gorp.DbMap) error {
+		var err error
+		replica = db != ssa.dbMap
+		statuses, err = SelectCertificateStatusesBySerial(db, serials)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !replica || len(statuses) == len(serials) {
+		return statuses, nil
+	}
+	// A replica that is behind won't have the statuses of the newest
+	// certificates, so only those it's missing are looked up on the primary.
+	found := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		found[status.Serial] = true
+	}
+	var missing []string
+	for _, serial := range serials {
+		if !found[serial] {
+			missing = append(missing, serial)
+		}
+	}
+	fromPrimary, err := SelectCertificateStatusesBySerial(ssa.dbMap, missing)
+	if err != nil {
+		return nil, err
+	}
+	return append(statuses, fromPrimary...), nil
+}
+
 // NewRegistration stores a new Registration
 func (ssa *SQLStorageAuthority) NewRegistration(ctx context.Context, reg core.Registration) (core.Registration, error) {
 	reg.CreatedAt = ssa.clk.Now()
@@ -843,9 +924,33 @@ func (ssa *SQLStorageAuthority) FinalizeAuthorization(ctx context.Context, authz
 		return Rollback(tx, err)
 	}
 
+	if authz.Status == core.StatusInvalid && features.Enabled(features.FailedValidationsTable) {
+		_, err = tx.Exec(
+			"INSERT INTO failedValidations (registrationID, hostname, attempted, addressUsed) VALUES (?, ?, ?, ?)",
+			authz.RegistrationID, authz.Identifier.Value, ssa.clk.Now(), failedValidationAddress(authz))
+		if err != nil {
+			return Rollback(tx, err)
+		}
+	}
+
 	return tx.Commit()
 }
 
+// failedValidationAddress returns the IP address the last validation of the
+// invalid authz's failed challenge connected to, or nil if it didn't get that
+// far, e.g. because the name didn't resolve.
+func failedValidationAddress(authz core.Authorization) interface{} {
+	for _, chall := range authz.Challenges {
+		if chall.Status != core.StatusInvalid || len(chall.ValidationRecord) == 0 {
+			continue
+		}
+		if addr := chall.ValidationRecord[len(chall.ValidationRecord)-1].AddressUsed; addr != nil {
+			return addr.String()
+		}
+	}
+	return nil
+}
+
 // RevokeAuthorizationsByDomain invalidates all pending or finalized authorizations
 // for a specific domain
 func (ssa *SQLStorageAuthority) RevokeAuthorizationsByDomain(ctx context.Context, ident core.AcmeIdentifier) (int64, int64, error) {
@@ -883,8 +988,9 @@ func (ssa *SQLStorageAuthority) RevokeAuthorizationsByDomain(ctx context.Context
 }
 
 // AddCertificate stores an issued certificate and returns the digest as
-// a string, or an error if any occurred.
-func (ssa *SQLStorageAuthority) AddCertificate(ctx context.Context, certDER []byte, regID int64, ocspResponse []byte) (string, error) {
+// a string, or an error if any occurred. The certificate's issued time is
+// issued if it isn't nil, or else the current time.
+func (ssa *SQLStorageAuthority) AddCertificate(ctx context.Context, certDER []byte, regID int64, ocspResponse []byte, issued *time.Time) (string, error) {
 	parsedCertificate, err := x509.ParseCertificate(certDER)
 	if err != nil {
 		return "", err
@@ -892,12 +998,16 @@ func (ssa *SQLStorageAuthority) AddCertificate(ctx context.Context, certDER []by
 	digest := core.Fingerprint256(certDER)
 	serial := core.SerialToString(parsedCertificate.SerialNumber)
 
+	issuedTime := ssa.clk.Now()
+	if issued != nil {
+		issuedTime = *issued
+	}
 	cert := &core.Certificate{
 		RegistrationID: regID,
 		Serial:         serial,
 		Digest:         digest,
 		DER:            certDER,
-		Issued:         ssa.clk.Now(),
+		Issued:         issuedTime,
 		Expires:        parsedCertificate.NotAfter,
 	}
 
@@ -933,11 +1043,28 @@ func (ssa *SQLStorageAuthority) AddCertificate(ctx context.Context, certDER []by
 		return "", Rollback(tx, err)
 	}
 
+	// Certificates without an OCSP URL (i.e. short-lived certificates) are
+	// marked so that the ocsp-updater doesn't try to keep OCSP responses for
+	// them up to date.
+	if features.Enabled(features.ShortLivedCertificates) && len(parsedCertificate.OCSPServer) == 0 {
+		_, err = tx.Exec("UPDATE certificateStatus SET noOCSP = 1 WHERE serial = ?", serial)
+		if err != nil {
+			return "", Rollback(tx, err)
+		}
+	}
+
 	err = addIssuedNames(tx, parsedCertificate)
 	if err != nil {
 		return "", Rollback(tx, err)
 	}
 
+	if features.Enabled(features.StoreKeyHashes) {
+		err = addKeyHash(tx, parsedCertificate)
+		if err != nil {
+			return "", Rollback(tx, err)
+		}
+	}
+
 	err = addFQDNSet(
 		tx,
 		parsedCertificate.DNSNames,
@@ -949,6 +1076,15 @@ func (ssa *SQLStorageAuthority) AddCertificate(ctx context.Context, certDER []by
 		return "", Rollback(tx, err)
 	}
 
+	if features.Enabled(features.AccountFQDNSets) {
+		_, err = tx.Exec(
+			"INSERT INTO accountFQDNSets (setHash, registrationID, issued) VALUES (?, ?, ?)",
+			hashNames(parsedCertificate.DNSNames), regID, parsedCertificate.NotBefore)
+		if err != nil {
+			return "", Rollback(tx, err)
+		}
+	}
+
 	return digest, tx.Commit()
 }
 
@@ -987,16 +1123,18 @@ func (ssa *SQLStorageAuthority) CountPendingAuthorizations(ctx context.Context,
 
 func (ssa *SQLStorageAuthority) CountOrders(ctx context.Context, acctID int64, earliest, latest time.Time) (int, error) {
 	var count int
-	err := ssa.dbMap.SelectOne(&count,
-		`SELECT count(1) FROM orders
-		WHERE registrationID = :acctID AND
-		created >= :windowLeft AND
-		created < :windowRight`,
-		map[string]interface{}{
-			"acctID":      acctID,
-			"windowLeft":  earliest,
-			"windowRight": latest,
-		})
+	err := ssa.readOnly(func(db *gorp.DbMap) error {
+		return db.SelectOne(&count,
+			`SELECT count(1) FROM orders
+			WHERE registrationID = :acctID AND
+			created >= :windowLeft AND
+			created < :windowRight`,
+			map[string]interface{}{
+				"acctID":      acctID,
+				"windowLeft":  earliest,
+				"windowRight": latest,
+			})
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -1040,6 +1178,37 @@ func (ssa *SQLStorageAuthority) CountInvalidAuthorizations(
 	return
 }
 
+// CountFailedValidations counts the validations of a hostname by a registration
+// that failed in a given time range. It always returns zero unless the
+// FailedValidationsTable feature is enabled.
+func (ssa *SQLStorageAuthority) CountFailedValidations(
+	ctx context.Context,
+	req *sapb.CountFailedValidationsRequest,
+) (*sapb.Count, error) {
+	count := &sapb.Count{
+		Count: new(int64),
+	}
+	if !features.Enabled(features.FailedValidationsTable) {
+		return count, nil
+	}
+	err := ssa.dbMap.SelectOne(count.Count,
+		`SELECT COUNT(1) FROM failedValidations
+		WHERE registrationID = :regID AND
+		hostname = :hostname AND
+		attempted > :earliest AND
+		attempted <= :latest`,
+		map[string]interface{}{
+			"regID":    *req.RegistrationID,
+			"hostname": *req.Hostname,
+			"earliest": time.Unix(0, *req.Range.Earliest),
+			"latest":   time.Unix(0, *req.Range.Latest),
+		})
+	if err != nil {
+		return nil, err
+	}
+	return count, nil
+}
+
 // ErrNoReceipt is an error type for non-existent SCT receipt
 type ErrNoReceipt string
 
@@ -1057,6 +1226,12 @@ func (ssa *SQLStorageAuthority) GetSCTReceipt(ctx context.Context, serial string
 	return receipt, err
 }
 
+// GetSCTReceipts gets every SCT receipt stored for a given certificate serial,
+// ordered by log ID
+func (ssa *SQLStorageAuthority) GetSCTReceipts(ctx context.Context, serial string) ([]core.SignedCertificateTimestamp, error) {
+	return selectSctReceipts(ssa.dbMap, "WHERE certificateSerial = ? ORDER BY logID", serial)
+}
+
 // AddSCTReceipt adds a new SCT receipt to the (append-only) sctReceipts table
 func (ssa *SQLStorageAuthority) AddSCTReceipt(ctx context.Context, sct core.SignedCertificateTimestamp) error {
 	err := ssa.dbMap.Insert(&sct)
@@ -1071,9 +1246,7 @@ func (ssa *SQLStorageAuthority) AddSCTReceipt(ctx context.Context, sct core.Sign
 }
 
 func hashNames(names []string) []byte {
-	names = core.UniqueLowerNames(names)
-	hash := sha256.Sum256([]byte(strings.Join(names, ",")))
-	return hash[:]
+	return core.HashNames(names)
 }
 
 func addFQDNSet(tx *gorp.Transaction, names []string, serial string, issued time.Time, expires time.Time) error {
@@ -1150,18 +1323,37 @@ func addIssuedNames(tx execable, cert *x509.Certificate) error {
 	return err
 }
 
+// addKeyHash records the hash of the certificate's public key in the
+// keyHashToSerial table so that the certificate can be found and revoked if the
+// key is later compromised or blocked.
+func addKeyHash(tx execable, cert *x509.Certificate) error {
+	keyHash, err := core.KeyDigest(cert.PublicKey)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO keyHashToSerial (keyHash, certNotAfter, certSerial) VALUES (?, ?, ?)`,
+		keyHash,
+		cert.NotAfter,
+		core.SerialToString(cert.SerialNumber),
+	)
+	return err
+}
+
 // CountFQDNSets returns the number of sets with hash |setHash| within the window
 // |window|
 func (ssa *SQLStorageAuthority) CountFQDNSets(ctx context.Context, window time.Duration, names []string) (int64, error) {
 	var count int64
-	err := ssa.dbMap.SelectOne(
-		&count,
-		`SELECT COUNT(1) FROM fqdnSets
-		WHERE setHash = ?
-		AND issued > ?`,
-		hashNames(names),
-		ssa.clk.Now().Add(-window),
-	)
+	err := ssa.readOnly(func(db *gorp.DbMap) error {
+		return db.SelectOne(
+			&count,
+			`SELECT COUNT(1) FROM fqdnSets
+			WHERE setHash = ?
+			AND issued > ?`,
+			hashNames(names),
+			ssa.clk.Now().Add(-window),
+		)
+	})
 	return count, err
 }
 
@@ -1278,15 +1470,50 @@ func (ssa *SQLStorageAuthority) getNewIssuancesByFQDNSet(fqdnSets []setHash, ear
 // FQDNSetExists returns a bool indicating if one or more FQDN sets |names|
 // exists in the database
 func (ssa *SQLStorageAuthority) FQDNSetExists(ctx context.Context, names []string) (bool, error) {
+	var count int64
+	err := ssa.readOnly(func(db *gorp.DbMap) error {
+		return db.SelectOne(
+			&count,
+			`SELECT COUNT(1) FROM fqdnSets
+			WHERE setHash = ?
+			LIMIT 1`,
+			hashNames(names),
+		)
+	})
+	return count > 0, err
+}
+
+// FQDNSetIssuedForAccount returns true if a certificate for exactly the FQDN
+// set |domains| was issued to the given registration after the earliest time
+// in the request. Issuance is only recorded per registration with the
+// AccountFQDNSets feature, so without it an error is returned rather than an
+// answer that would always be false.
+func (ssa *SQLStorageAuthority) FQDNSetIssuedForAccount(
+	ctx context.Context,
+	req *sapb.FQDNSetIssuedForAccountRequest,
+) (*sapb.Exists, error) {
+	if !features.Enabled(features.AccountFQDNSets) {
+		return nil, berrors.InternalServerError("FQDNSetIssuedForAccount requires the AccountFQDNSets feature")
+	}
+	exists := false
 	var count int64
 	err := ssa.dbMap.SelectOne(
 		&count,
-		`SELECT COUNT(1) FROM fqdnSets
-		WHERE setHash = ?
+		`SELECT COUNT(1) FROM accountFQDNSets
+		WHERE setHash = :setHash AND
+		registrationID = :regID AND
+		issued > :earliest
 		LIMIT 1`,
-		hashNames(names),
-	)
-	return count > 0, err
+		map[string]interface{}{
+			"setHash":  hashNames(req.Domains),
+			"regID":    *req.RegistrationID,
+			"earliest": time.Unix(0, *req.Earliest),
+		})
+	if err != nil {
+		return nil, err
+	}
+	exists = count > 0
+	return &sapb.Exists{Exists: &exists}, nil
 }
 
 // PreviousCertificateExists returns true iff there was at least one certificate
@@ -1347,6 +1574,230 @@ func (ssa *SQLStorageAuthority) PreviousCertificateExists(
 	return notExists, nil
 }
 
+// KeyBlocked checks whether a public key, identified by the base64 SHA-256
+// hash of its SPKI, is present in the blockedKeys table. Keys are never
+// considered blocked unless the BlockedKeyTable feature is enabled.
+func (ssa *SQLStorageAuthority) KeyBlocked(ctx context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+	exists := false
+	if features.Enabled(features.BlockedKeyTable) {
+		var count int
+		err := ssa.dbMap.SelectOne(
+			&count,
+			`SELECT COUNT(1) FROM blockedKeys WHERE keyHash = ?`,
+			*req.KeyHash,
+		)
+		if err != nil {
+			return nil, err
+		}
+		exists = count > 0
+	}
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
+// PolicyOverridden checks whether the policyOverrides table allows issuance
+// for a domain, or any of its parent domains, either to the given registration
+// or to all registrations (registration ID 0). Nothing is considered
+// overridden unless the PolicyOverrides feature is enabled.
+func (ssa *SQLStorageAuthority) PolicyOverridden(ctx context.Context, req *sapb.PolicyOverriddenRequest) (*sapb.Exists, error) {
+	exists := false
+	if features.Enabled(features.PolicyOverrides) {
+		domains := domainAndParents(*req.Domain)
+		qmarks := make([]string, len(domains))
+		args := []interface{}{*req.RegistrationID}
+		for i, domain := range domains {
+			qmarks[i] = "?"
+			args = append(args, domain)
+		}
+		var count int
+		err := ssa.dbMap.SelectOne(
+			&count,
+			`SELECT COUNT(1) FROM policyOverrides
+			WHERE registrationID IN (0, ?)
+			AND domain IN (`+strings.Join(qmarks, ",")+`)`,
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		exists = count > 0
+	}
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
+// AddBlockedKey adds a public key, identified by the base64 SHA-256 hash of its
+// SPKI, to the blockedKeys table. It is not an error for the key to already be
+// blocked.
+func (ssa *SQLStorageAuthority) AddBlockedKey(ctx context.Context, req *sapb.AddBlockedKeyRequest) (*corepb.Empty, error) {
+	var comment interface{}
+	if req.Comment != nil && *req.Comment != "" {
+		comment = *req.Comment
+	}
+	_, err := ssa.dbMap.Exec(
+		`INSERT INTO blockedKeys (keyHash, added, addedBy, comment) VALUES (?, ?, ?, ?)`,
+		*req.KeyHash,
+		time.Unix(0, *req.Added),
+		*req.AddedBy,
+		comment,
+	)
+	if err != nil && !strings.HasPrefix(err.Error(), "Error 1062: Duplicate entry") {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+// AddVerifiedContact records that the registration has shown it controls an
+// email address in its contacts. Verifying an address again updates the time
+// it was verified.
+func (ssa *SQLStorageAuthority) AddVerifiedContact(ctx context.Context, req *sapb.AddVerifiedContactRequest) (*corepb.Empty, error) {
+	_, err := ssa.dbMap.Exec(
+		`INSERT INTO verifiedContacts (registrationID, email, verified) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE verified = VALUES(verified)`,
+		*req.RegistrationID,
+		*req.Email,
+		time.Unix(0, *req.Verified),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+// AddCTSubmission records the result of submitting a certificate, or its
+// precertificate, to a CT log.
+func (ssa *SQLStorageAuthority) AddCTSubmission(ctx context.Context, req *sapb.AddCTSubmissionRequest) (*corepb.Empty, error) {
+	_, err := ssa.dbMap.Exec(
+		`INSERT INTO ctSubmissions (serial, logURL, precert, result, submitted) VALUES (?, ?, ?, ?, ?)`,
+		*req.Serial,
+		*req.LogURL,
+		*req.Precert,
+		*req.Result,
+		time.Unix(0, *req.Submitted),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &corepb.Empty{}, nil
+}
+
+// GetAccountStats returns counters describing a registration's recent
+// activity for support and abuse investigation: the certificates it was issued
+// in the last 7, 30 and 90 days, its failed validations in the last 7 days
+// (zero unless the FailedValidationsTable feature is enabled), and its
+// currently pending authorizations.
+func (ssa *SQLStorageAuthority) GetAccountStats(ctx context.Context, req *sapb.RegistrationID) (*sapb.AccountStats, error) {
+	now := ssa.clk.Now()
+	stats := &sapb.AccountStats{
+		CertificatesLast7Days:      new(int64),
+		CertificatesLast30Days:     new(int64),
+		CertificatesLast90Days:     new(int64),
+		FailedValidationsLast7Days: new(int64),
+		PendingAuthorizations:      new(int64),
+	}
+	for days, count := range map[int]*int64{
+		7:  stats.CertificatesLast7Days,
+		30: stats.CertificatesLast30Days,
+		90: stats.CertificatesLast90Days,
+	} {
+		err := ssa.readOnly(func(db *gorp.DbMap) error {
+			return db.SelectOne(count,
+				`SELECT COUNT(1) FROM certificates
+				WHERE registrationID = :regID AND
+				issued > :earliest`,
+				map[string]interface{}{
+					"regID":    *req.Id,
+					"earliest": now.AddDate(0, 0, -days),
+				})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if features.Enabled(features.FailedValidationsTable) {
+		err := ssa.dbMap.SelectOne(stats.FailedValidationsLast7Days,
+			`SELECT COUNT(1) FROM failedValidations
+			WHERE registrationID = :regID AND
+			attempted > :earliest`,
+			map[string]interface{}{
+				"regID":    *req.Id,
+				"earliest": now.AddDate(0, 0, -7),
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+	pending, err := ssa.CountPendingAuthorizations(ctx, *req.Id)
+	if err != nil {
+		return nil, err
+	}
+	*stats.PendingAuthorizations = int64(pending)
+	return stats, nil
+}
+
+// GetRateLimitOverrides returns the unexpired per-registration rate limit
+// overrides in the rateLimitOverrides table. No overrides are returned unless
+// the RateLimitOverrides feature is enabled.
+func (ssa *SQLStorageAuthority) GetRateLimitOverrides(ctx context.Context, req *corepb.Empty) (*sapb.RateLimitOverrides, error) {
+	result := &sapb.RateLimitOverrides{}
+	if !features.Enabled(features.RateLimitOverrides) {
+		return result, nil
+	}
+	var rows []struct {
+		RegistrationID int64
+		LimitName      string
+		Threshold      int64
+		Expires        time.Time
+	}
+	_, err := ssa.dbMap.Select(
+		&rows,
+		`SELECT registrationID, limitName, threshold, expires FROM rateLimitOverrides
+		WHERE expires > ?`,
+		ssa.clk.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		row := row
+		expires := row.Expires.UnixNano()
+		result.Overrides = append(result.Overrides, &sapb.RateLimitOverride{
+			RegistrationID: &row.RegistrationID,
+			LimitName:      &row.LimitName,
+			Threshold:      &row.Threshold,
+			Expires:        &expires,
+		})
+	}
+	return result, nil
+}
+
+// GetSerialsByKey returns the serials of the certificates issued for the public
+// key with the given SPKI hash that expire after notAfter, or of all of them,
+// expired or not, if notAfter is zero. Only certificates issued while the
+// StoreKeyHashes feature was enabled have their key hash recorded.
+func (ssa *SQLStorageAuthority) GetSerialsByKey(ctx context.Context, req *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error) {
+	var serials []string
+	_, err := ssa.dbMap.Select(
+		&serials,
+		`SELECT certSerial FROM keyHashToSerial WHERE keyHash = ? AND certNotAfter > ?`,
+		*req.KeyHash,
+		time.Unix(0, *req.NotAfter),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sapb.Serials{Serials: serials}, nil
+}
+
+// domainAndParents returns domain followed by each of its parent domains, e.g.
+// "www.example.com", "example.com" and "com" for "www.example.com".
+func domainAndParents(domain string) []string {
+	labels := strings.Split(domain, ".")
+	domains := make([]string, len(labels))
+	for i := range labels {
+		domains[i] = strings.Join(labels[i:], ".")
+	}
+	return domains
+}
+
 // DeactivateRegistration deactivates a currently valid registration
 func (ssa *SQLStorageAuthority) DeactivateRegistration(ctx context.Context, id int64) error {
 	_, err := ssa.dbMap.Exec(
@@ -1407,10 +1858,22 @@ func (ssa *SQLStorageAuthority) DeactivateAuthorization(ctx context.Context, id
 
 // NewOrder adds a new v2 style order to the database
 func (ssa *SQLStorageAuthority) NewOrder(ctx context.Context, req *corepb.Order) (*corepb.Order, error) {
-	order := &orderModel{
-		RegistrationID: *req.RegistrationID,
-		Expires:        time.Unix(0, *req.Expires),
-		Created:        ssa.clk.Now(),
+	return ssa.NewOrderAndAuthzs(ctx, &sapb.NewOrderAndAuthzsRequest{NewOrder: req})
+}
+
+// NewOrderAndAuthzs adds a new v2 style order to the database along with new
+// pending authorizations and their challenges. Everything is stored in one
+// transaction, using a single multi-row insert per table rather than a round
+// trip per row. The IDs of the new authorizations are added to the order's
+// authorizations.
+func (ssa *SQLStorageAuthority) NewOrderAndAuthzs(ctx context.Context, req *sapb.NewOrderAndAuthzsRequest) (*corepb.Order, error) {
+	newAuthzs := make([]core.Authorization, len(req.NewAuthzs))
+	for i, authzPB := range req.NewAuthzs {
+		authz, err := bgrpc.PBToAuthz(authzPB)
+		if err != nil {
+			return nil, err
+		}
+		newAuthzs[i] = authz
 	}
 
 	tx, err := ssa.dbMap.Begin()
@@ -1418,33 +1881,72 @@ func (ssa *SQLStorageAuthority) NewOrder(ctx context.Context, req *corepb.Order)
 		return nil, err
 	}
 
+	newAuthzIDs, err := newAuthzIDs(tx, len(newAuthzs))
+	if err != nil {
+		return nil, Rollback(tx, err)
+	}
+	authzInserter := newMultiInserter("pendingAuthorizations",
+		"id", "identifier", "registrationID", "status", "expires", "combinations", "LockCol")
+	challInserter := newMultiInserter("challenges",
+		"authorizationID", "type", "status", "error", "token", "keyAuthorization", "validationRecord", "LockCol")
+	for i, authz := range newAuthzs {
+		err := authzInserter.add(newAuthzIDs[i], authz.Identifier, authz.RegistrationID,
+			authz.Status, authz.Expires, authz.Combinations, 1)
+		if err != nil {
+			return nil, Rollback(tx, err)
+		}
+		for _, c := range authz.Challenges {
+			cm, err := challengeToModel(&c, newAuthzIDs[i])
+			if err != nil {
+				return nil, Rollback(tx, err)
+			}
+			err = challInserter.add(cm.AuthorizationID, cm.Type, cm.Status, cm.Error,
+				cm.Token, cm.KeyAuthorization, cm.ValidationRecord, 1)
+			if err != nil {
+				return nil, Rollback(tx, err)
+			}
+		}
+	}
+	if err := authzInserter.insert(tx); err != nil {
+		return nil, Rollback(tx, err)
+	}
+	if err := challInserter.insert(tx); err != nil {
+		return nil, Rollback(tx, err)
+	}
+
+	order := &orderModel{
+		RegistrationID: *req.NewOrder.RegistrationID,
+		Expires:        time.Unix(0, *req.NewOrder.Expires),
+		Created:        ssa.clk.Now(),
+	}
 	if err := tx.Insert(order); err != nil {
 		return nil, Rollback(tx, err)
 	}
 
-	for _, id := range req.Authorizations {
-		otoa := &orderToAuthzModel{
-			OrderID: order.ID,
-			AuthzID: id,
-		}
-		if err := tx.Insert(otoa); err != nil {
+	authzIDs := append(req.NewOrder.Authorizations, newAuthzIDs...)
+	otoaInserter := newMultiInserter("orderToAuthz", "orderID", "authzID")
+	for _, id := range authzIDs {
+		if err := otoaInserter.add(order.ID, id); err != nil {
 			return nil, Rollback(tx, err)
 		}
 	}
+	if err := otoaInserter.insert(tx); err != nil {
+		return nil, Rollback(tx, err)
+	}
 
-	for _, name := range req.Names {
-		reqdName := &requestedNameModel{
-			OrderID:      order.ID,
-			ReversedName: ReverseName(name),
-		}
-		if err := tx.Insert(reqdName); err != nil {
+	namesInserter := newMultiInserter("requestedNames", "orderID", "reversedName")
+	for _, name := range req.NewOrder.Names {
+		if err := namesInserter.add(order.ID, ReverseName(name)); err != nil {
 			return nil, Rollback(tx, err)
 		}
 	}
+	if err := namesInserter.insert(tx); err != nil {
+		return nil, Rollback(tx, err)
+	}
 
 	// Add an FQDNSet entry for the order
 	if err := addOrderFQDNSet(
-		tx, req.Names, order.ID, order.RegistrationID, order.Expires); err != nil {
+		tx, req.NewOrder.Names, order.ID, order.RegistrationID, order.Expires); err != nil {
 		return nil, Rollback(tx, err)
 	}
 
@@ -1452,38 +1954,92 @@ func (ssa *SQLStorageAuthority) NewOrder(ctx context.Context, req *corepb.Order)
 		return nil, err
 	}
 
-	// Update the request with the ID that the order received
-	req.Id = &order.ID
+	resp := req.NewOrder
+	// Update the request with the ID that the order received and the IDs of
+	// its new authorizations
+	resp.Id = &order.ID
+	resp.Authorizations = authzIDs
 	// Update the request with the created timestamp from the model
 	createdTS := order.Created.UnixNano()
-	req.Created = &createdTS
+	resp.Created = &createdTS
 
 	// Update the request with pending status (No need to calculate the status
 	// based on authzs here, we know a brand new order is always pending)
 	pendingStatus := string(core.StatusPending)
-	req.Status = &pendingStatus
+	resp.Status = &pendingStatus
 	processingStatus := false
-	req.BeganProcessing = &processingStatus
-	return req, nil
+	resp.BeganProcessing = &processingStatus
+	return resp, nil
+}
+
+// newAuthzIDs returns n random authorization IDs that aren't used by any
+// existing pending or final authorization.
+func newAuthzIDs(tx *gorp.Transaction, n int) ([]string, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = core.NewToken()
+	}
+	for {
+		qmarks := make([]string, n)
+		params := make([]interface{}, n)
+		for i, id := range ids {
+			qmarks[i] = "?"
+			params[i] = id
+		}
+		in := strings.Join(qmarks, ",")
+		var taken []string
+		_, err := tx.Select(
+			&taken,
+			"SELECT id FROM pendingAuthorizations WHERE id IN ("+in+") "+
+				"UNION SELECT id FROM authz WHERE id IN ("+in+")",
+			append(params, params...)...)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if len(taken) == 0 {
+			return ids, nil
+		}
+		takenIDs := make(map[string]bool, len(taken))
+		for _, id := range taken {
+			takenIDs[id] = true
+		}
+		for i, id := range ids {
+			if takenIDs[id] {
+				ids[i] = core.NewToken()
+			}
+		}
+	}
 }
 
 // SetOrderProcessing updates a provided *corepb.Order in pending status to be
 // in processing status by updating the `beganProcessing` field of the
-// corresponding Order table row in the DB.
+// corresponding Order table row in the DB. With the AsyncFinalize feature
+// enabled it also records when processing began, so that orders left
+// processing by an RA that stopped can be failed by FailStaleProcessingOrders.
 func (ssa *SQLStorageAuthority) SetOrderProcessing(ctx context.Context, req *corepb.Order) error {
 	tx, err := ssa.dbMap.Begin()
 	if err != nil {
 		return err
 	}
 
-	result, err := tx.Exec(`
+	query := `
 		UPDATE orders
 		SET beganProcessing = ?
 		WHERE id = ?
-		AND beganProcessing = ?`,
-		true,
-		*req.Id,
-		false)
+		AND beganProcessing = ?`
+	args := []interface{}{true, *req.Id, false}
+	if features.Enabled(features.AsyncFinalize) {
+		query = `
+		UPDATE orders
+		SET beganProcessing = ?, beganProcessingAt = ?
+		WHERE id = ?
+		AND beganProcessing = ?`
+		args = []interface{}{true, ssa.clk.Now(), *req.Id, false}
+	}
+	result, err := tx.Exec(query, args...)
 	if err != nil {
 		err = berrors.InternalServerError("error updating order to beganProcessing status")
 		return Rollback(tx, err)
@@ -1498,6 +2054,55 @@ func (ssa *SQLStorageAuthority) SetOrderProcessing(ctx context.Context, req *cor
 	return tx.Commit()
 }
 
+// staleOrderProblem is the error given to orders failed by
+// FailStaleProcessingOrders.
+var staleOrderProblem = probs.ServerInternal("Timed out finalizing order")
+
+// FailStaleProcessingOrders sets an error on the orders that began processing
+// before cutoff and are still processing, making them invalid, and returns how
+// many there were. The RA only keeps orders waiting for its finalize workers
+// in memory, so an order it was finalizing when it stopped would otherwise
+// stay processing forever. Only orders whose processing began with the
+// AsyncFinalize feature enabled in the SA are considered.
+func (ssa *SQLStorageAuthority) FailStaleProcessingOrders(ctx context.Context, cutoff time.Time) (int64, error) {
+	pbProb, err := bgrpc.ProblemDetailsToPB(staleOrderProblem)
+	if err != nil {
+		return 0, err
+	}
+	errJSON, err := json.Marshal(pbProb)
+	if err != nil {
+		return 0, err
+	}
+	result, err := ssa.dbMap.Exec(`
+		UPDATE orders
+		SET error = ?
+		WHERE beganProcessingAt < ?
+		AND beganProcessing = true
+		AND (certificateSerial IS NULL OR certificateSerial = '')
+		AND error IS NULL`,
+		errJSON,
+		cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// FailStaleProcessingOrdersForever calls FailStaleProcessingOrders every
+// interval for the orders that have been processing longer than timeout,
+// which should be longer than the RA's finalize timeout.
+func (ssa *SQLStorageAuthority) FailStaleProcessingOrdersForever(timeout, interval time.Duration) {
+	for {
+		n, err := ssa.FailStaleProcessingOrders(context.Background(), ssa.clk.Now().Add(-timeout))
+		if err != nil {
+			ssa.log.AuditErr(fmt.Sprintf("Failed to fail stale processing orders: %s", err))
+		} else if n > 0 {
+			ssa.log.AuditInfo(fmt.Sprintf("Failed %d orders that were processing for longer than %s", n, timeout))
+		}
+		ssa.clk.Sleep(interval)
+	}
+}
+
 // SetOrderError updates a provided Order's error field.
 func (ssa *SQLStorageAuthority) SetOrderError(ctx context.Context, order *corepb.Order) error {
 	tx, err := ssa.dbMap.Begin()
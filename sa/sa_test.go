@@ -12,6 +12,7 @@ import (
 	"math/big"
 	"net"
 	"reflect"
+	"sort"
 	"sync"
 	"testing"
 	"time"
@@ -24,7 +25,9 @@ import (
 
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/revocation"
@@ -389,6 +392,76 @@ func TestCountInvalidAuthorizations(t *testing.T) {
 	}
 }
 
+func TestFailedValidationAddress(t *testing.T) {
+	authz := core.Authorization{Challenges: []core.Challenge{
+		{Status: core.StatusPending},
+		{Status: core.StatusInvalid},
+	}}
+	// A validation that never connected has no address
+	test.AssertEquals(t, failedValidationAddress(authz), nil)
+
+	authz.Challenges[1].ValidationRecord = []core.ValidationRecord{
+		{AddressUsed: net.ParseIP("10.0.0.1")},
+		{AddressUsed: net.ParseIP("2001:db8::1")},
+	}
+	test.AssertEquals(t, failedValidationAddress(authz), "2001:db8::1")
+}
+
+func TestCountFailedValidations(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	_ = features.Set(map[string]bool{"FailedValidationsTable": true})
+	defer features.Reset()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+
+	fail := func(domain string) {
+		authz := CreateDomainAuthWithRegID(t, domain, sa, reg.ID)
+		authz.Status = core.StatusInvalid
+		err := sa.FinalizeAuthorization(ctx, authz)
+		test.AssertNotError(t, err, "Couldn't finalize pending authorization with ID "+authz.ID)
+	}
+	count := func(hostname string, window time.Duration) int64 {
+		latest := fc.Now().UnixNano()
+		earliest := fc.Now().Add(-window).UnixNano()
+		count, err := sa.CountFailedValidations(ctx, &sapb.CountFailedValidationsRequest{
+			RegistrationID: &reg.ID,
+			Hostname:       &hostname,
+			Range: &sapb.Range{
+				Earliest: &earliest,
+				Latest:   &latest,
+			},
+		})
+		test.AssertNotError(t, err, "counting failed validations")
+		return *count.Count
+	}
+
+	hostname := "example.net"
+	fail(hostname)
+	fc.Add(2 * time.Hour)
+	fail(hostname)
+	fail(hostname)
+	fail("example.com")
+
+	// Validations are counted by when they failed, not when their
+	// authorizations expire
+	test.AssertEquals(t, count(hostname, time.Hour), int64(2))
+	test.AssertEquals(t, count(hostname, 3*time.Hour), int64(3))
+	test.AssertEquals(t, count("example.com", time.Hour), int64(1))
+
+	// Authorizations finalized as valid aren't failed validations
+	authz := CreateDomainAuthWithRegID(t, "example.org", sa, reg.ID)
+	authz.Status = core.StatusValid
+	err := sa.FinalizeAuthorization(ctx, authz)
+	test.AssertNotError(t, err, "Couldn't finalize pending authorization with ID "+authz.ID)
+	test.AssertEquals(t, count("example.org", time.Hour), int64(0))
+
+	// Without the feature nothing is counted
+	features.Reset()
+	test.AssertEquals(t, count(hostname, time.Hour), int64(0))
+}
+
 // Ensure we get the latest valid authorization for an ident
 func TestGetValidAuthorizationsDuplicate(t *testing.T) {
 	sa, clk, cleanUp := initSA(t)
@@ -494,7 +567,7 @@ func TestAddCertificate(t *testing.T) {
 	certDER, err := ioutil.ReadFile("www.eff.org.der")
 	test.AssertNotError(t, err, "Couldn't read example cert DER")
 
-	digest, err := sa.AddCertificate(ctx, certDER, reg.ID, nil)
+	digest, err := sa.AddCertificate(ctx, certDER, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add www.eff.org.der")
 	test.AssertEquals(t, digest, "qWoItDZmR4P9eFbeYgXXP3SR4ApnkQj8x4LsB_ORKBo")
 
@@ -514,7 +587,7 @@ func TestAddCertificate(t *testing.T) {
 	test.AssertNotError(t, err, "Couldn't read example cert DER")
 	serial := "ffdd9b8a82126d96f61d378d5ba99a0474f0"
 
-	digest2, err := sa.AddCertificate(ctx, certDER2, reg.ID, nil)
+	digest2, err := sa.AddCertificate(ctx, certDER2, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.der")
 	test.AssertEquals(t, digest2, "vrlPN5wIPME1D2PPsCy-fGnTWh8dMyyYQcXPRkjHAQI")
 
@@ -532,7 +605,7 @@ func TestAddCertificate(t *testing.T) {
 	test.AssertNotError(t, err, "Couldn't read example cert DER")
 	serial = "ffa0160630d618b2eb5c0510824b14274856"
 	ocspResp := []byte{0, 0, 1}
-	_, err = sa.AddCertificate(ctx, certDER3, reg.ID, ocspResp)
+	_, err = sa.AddCertificate(ctx, certDER3, reg.ID, ocspResp, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert2.der")
 
 	certificateStatus3, err := sa.GetCertificateStatus(ctx, serial)
@@ -577,7 +650,7 @@ func TestCountCertificatesByNames(t *testing.T) {
 
 	// Add the test cert and query for its names.
 	reg := satest.CreateWorkingRegistration(t, sa)
-	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert.der")
 
 	// Time range including now should find the cert
@@ -619,7 +692,7 @@ func TestCountCertificatesByNames(t *testing.T) {
 
 	certDER2, err := ioutil.ReadFile("test-cert2.der")
 	test.AssertNotError(t, err, "Couldn't read test-cert2.der")
-	_, err = sa.AddCertificate(ctx, certDER2, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, certDER2, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add test-cert2.der")
 	counts, err = sa.CountCertificatesByNames(ctx, names, yesterday, now.Add(10000*time.Hour))
 	test.AssertNotError(t, err, "Error counting certs.")
@@ -689,6 +762,44 @@ func TestGetSCTReceipt(t *testing.T) {
 	test.Assert(t, sqlSCT.CertificateSerial == sct.CertificateSerial, "Invalid certificate serial")
 }
 
+func TestGetSCTReceipts(t *testing.T) {
+	sigBytes, err := base64.StdEncoding.DecodeString(sctSignature)
+	test.AssertNotError(t, err, "Failed to decode SCT signature")
+	sa, _, cleanup := initSA(t)
+	defer cleanup()
+
+	scts, err := sa.GetSCTReceipts(ctx, sctCertSerial)
+	test.AssertNotError(t, err, "Failed to get SCT receipts")
+	test.AssertEquals(t, len(scts), 0)
+
+	for _, logID := range []string{"log B", "log A"} {
+		err = sa.AddSCTReceipt(ctx, core.SignedCertificateTimestamp{
+			SCTVersion:        sctVersion,
+			LogID:             logID,
+			Timestamp:         sctTimestamp,
+			Signature:         sigBytes,
+			CertificateSerial: sctCertSerial,
+		})
+		test.AssertNotError(t, err, "Failed to add SCT receipt")
+	}
+	// A receipt for another certificate
+	err = sa.AddSCTReceipt(ctx, core.SignedCertificateTimestamp{
+		SCTVersion:        sctVersion,
+		LogID:             "log A",
+		Timestamp:         sctTimestamp,
+		Signature:         sigBytes,
+		CertificateSerial: "other",
+	})
+	test.AssertNotError(t, err, "Failed to add SCT receipt")
+
+	scts, err = sa.GetSCTReceipts(ctx, sctCertSerial)
+	test.AssertNotError(t, err, "Failed to get SCT receipts")
+	test.AssertEquals(t, len(scts), 2)
+	test.AssertEquals(t, scts[0].LogID, "log A")
+	test.AssertEquals(t, scts[1].LogID, "log B")
+	test.AssertByteEquals(t, scts[0].Signature, sigBytes)
+}
+
 func TestMarkCertificateRevoked(t *testing.T) {
 	sa, fc, cleanUp := initSA(t)
 	defer cleanUp()
@@ -697,7 +808,7 @@ func TestMarkCertificateRevoked(t *testing.T) {
 	// Add a cert to the DB to test with.
 	certDER, err := ioutil.ReadFile("www.eff.org.der")
 	test.AssertNotError(t, err, "Couldn't read example cert DER")
-	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add www.eff.org.der")
 
 	serial := "000000000000000000000000000000021bd4"
@@ -722,6 +833,36 @@ func TestMarkCertificateRevoked(t *testing.T) {
 	}
 }
 
+func TestGetCertificateStatuses(t *testing.T) {
+	sa, _, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	certDER, err := ioutil.ReadFile("www.eff.org.der")
+	test.AssertNotError(t, err, "Couldn't read example cert DER")
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, nil)
+	test.AssertNotError(t, err, "Couldn't add www.eff.org.der")
+	serial := "000000000000000000000000000000021bd4"
+	err = sa.MarkCertificateRevoked(ctx, serial, revocation.KeyCompromise)
+	test.AssertNotError(t, err, "MarkCertificateRevoked failed")
+
+	// Serials without a status are left out
+	statuses, err := sa.GetCertificateStatuses(ctx, []string{serial, "000000000000000000000000000000021bd5"})
+	test.AssertNotError(t, err, "GetCertificateStatuses failed")
+	test.AssertEquals(t, len(statuses), 1)
+	test.AssertEquals(t, statuses[0].Serial, serial)
+	test.AssertEquals(t, statuses[0].Status, core.OCSPStatusRevoked)
+
+	statuses, err = sa.GetCertificateStatuses(ctx, nil)
+	test.AssertNotError(t, err, "GetCertificateStatuses failed with no serials")
+	test.AssertEquals(t, len(statuses), 0)
+
+	_, err = sa.GetCertificateStatuses(ctx, []string{"not a serial"})
+	test.AssertError(t, err, "GetCertificateStatuses accepted an invalid serial")
+	_, err = sa.GetCertificateStatuses(ctx, make([]string, MaxStatusesPerRequest+1))
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "GetCertificateStatuses accepted too many serials")
+}
+
 func TestCountCertificates(t *testing.T) {
 	sa, fc, cleanUp := initSA(t)
 	defer cleanUp()
@@ -735,7 +876,7 @@ func TestCountCertificates(t *testing.T) {
 	// Add a cert to the DB to test with.
 	certDER, err := ioutil.ReadFile("www.eff.org.der")
 	test.AssertNotError(t, err, "Couldn't read example cert DER")
-	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "Couldn't add www.eff.org.der")
 
 	fc.Add(2 * time.Hour)
@@ -969,6 +1110,57 @@ func TestFQDNSetsExists(t *testing.T) {
 	test.Assert(t, exists, "FQDN set does exist")
 }
 
+func TestFQDNSetIssuedForAccount(t *testing.T) {
+	sa, _, cleanUp := initSA(t)
+	defer cleanUp()
+
+	_ = features.Set(map[string]bool{"AccountFQDNSets": true})
+	defer features.Reset()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+
+	// Test cert generated locally by Boulder / CFSSL, names [example.com,
+	// www.example.com, admin.example.com]
+	certDER, err := ioutil.ReadFile("test-cert.der")
+	test.AssertNotError(t, err, "Couldn't read example cert DER")
+	cert, err := x509.ParseCertificate(certDER)
+	test.AssertNotError(t, err, "Couldn't parse example cert DER")
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, nil)
+	test.AssertNotError(t, err, "Couldn't add test-cert.der")
+
+	issuedForAccount := func(names []string, regID int64, earliest time.Time) bool {
+		earliestNanos := earliest.UnixNano()
+		exists, err := sa.FQDNSetIssuedForAccount(ctx, &sapb.FQDNSetIssuedForAccountRequest{
+			Domains:        names,
+			RegistrationID: &regID,
+			Earliest:       &earliestNanos,
+		})
+		test.AssertNotError(t, err, "Failed to check FQDN set issuance")
+		return *exists.Exists
+	}
+
+	before := cert.NotBefore.Add(-time.Hour)
+	test.Assert(t, issuedForAccount([]string{"www.example.com", "Admin.example.com", "example.com"}, reg.ID, before),
+		"FQDN set wasn't issued to the account")
+	test.Assert(t, !issuedForAccount([]string{"www.example.com", "example.com"}, reg.ID, before),
+		"A different FQDN set was issued to the account")
+	test.Assert(t, !issuedForAccount(cert.DNSNames, reg.ID+1, before),
+		"FQDN set was issued to another account")
+	test.Assert(t, !issuedForAccount(cert.DNSNames, reg.ID, cert.NotBefore.Add(time.Hour)),
+		"FQDN set was issued after the earliest time")
+
+	// Without the feature issuance isn't recorded per account, so it can't be
+	// checked
+	features.Reset()
+	earliest := before.UnixNano()
+	_, err = sa.FQDNSetIssuedForAccount(ctx, &sapb.FQDNSetIssuedForAccountRequest{
+		Domains:        cert.DNSNames,
+		RegistrationID: &reg.ID,
+		Earliest:       &earliest,
+	})
+	test.AssertError(t, err, "FQDNSetIssuedForAccount succeeded without the feature")
+}
+
 type execRecorder struct {
 	query string
 	args  []interface{}
@@ -1020,7 +1212,7 @@ func TestPreviousCertificateExists(t *testing.T) {
 	certDER, err := ioutil.ReadFile("www.eff.org.der")
 	test.AssertNotError(t, err, "reading cert DER")
 
-	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil)
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, nil)
 	test.AssertNotError(t, err, "calling AddCertificate")
 
 	cases := []struct {
@@ -1365,6 +1557,75 @@ func TestNewOrder(t *testing.T) {
 	test.AssertDeepEquals(t, names, []string{"com.example", "com.example.another.just"})
 }
 
+func TestNewOrderAndAuthzs(t *testing.T) {
+	sa, fc, cleanup := initSA(t)
+	defer cleanup()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+
+	// One authorization already exists and two are stored with the order
+	authzExpires := fc.Now().Add(time.Hour)
+	existing, err := sa.NewPendingAuthorization(ctx, core.Authorization{
+		Identifier:     core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "example.com"},
+		RegistrationID: reg.ID,
+		Status:         core.StatusPending,
+		Expires:        &authzExpires,
+	})
+	test.AssertNotError(t, err, "Couldn't create new pending authorization")
+
+	var newAuthzs []*corepb.Authorization
+	for _, name := range []string{"a.example.com", "b.example.com"} {
+		authzPB, err := bgrpc.AuthzToPB(core.Authorization{
+			Identifier:     core.AcmeIdentifier{Type: core.IdentifierDNS, Value: name},
+			RegistrationID: reg.ID,
+			Status:         core.StatusPending,
+			Expires:        &authzExpires,
+			Challenges: []core.Challenge{
+				{Type: core.ChallengeTypeHTTP01, Status: core.StatusPending, Token: core.NewToken()},
+				{Type: core.ChallengeTypeDNS01, Status: core.StatusPending, Token: core.NewToken()},
+			},
+			Combinations: [][]int{{0}, {1}},
+		})
+		test.AssertNotError(t, err, "AuthzToPB failed")
+		newAuthzs = append(newAuthzs, authzPB)
+	}
+
+	expires := fc.Now().Add(time.Hour).UnixNano()
+	order, err := sa.NewOrderAndAuthzs(ctx, &sapb.NewOrderAndAuthzsRequest{
+		NewOrder: &corepb.Order{
+			RegistrationID: &reg.ID,
+			Expires:        &expires,
+			Names:          []string{"example.com", "a.example.com", "b.example.com"},
+			Authorizations: []string{existing.ID},
+		},
+		NewAuthzs: newAuthzs,
+	})
+	test.AssertNotError(t, err, "sa.NewOrderAndAuthzs failed")
+	test.AssertEquals(t, len(order.Authorizations), 3)
+	test.AssertEquals(t, order.Authorizations[0], existing.ID)
+	test.AssertEquals(t, *order.Status, string(core.StatusPending))
+
+	var authzIDs []string
+	_, err = sa.dbMap.Select(&authzIDs, "SELECT authzID FROM orderToAuthz WHERE orderID = ?;", *order.Id)
+	test.AssertNotError(t, err, "Failed to select orderToAuthz entries")
+	test.AssertEquals(t, len(authzIDs), 3)
+
+	// The new authorizations were stored with their challenges
+	for i, name := range []string{"a.example.com", "b.example.com"} {
+		authz, err := sa.GetAuthorization(ctx, order.Authorizations[i+1])
+		test.AssertNotError(t, err, "Couldn't get new authorization")
+		test.AssertEquals(t, authz.Identifier.Value, name)
+		test.AssertEquals(t, authz.Status, core.StatusPending)
+		test.AssertEquals(t, authz.RegistrationID, reg.ID)
+		test.AssertEquals(t, len(authz.Challenges), 2)
+		test.AssertDeepEquals(t, authz.Combinations, [][]int{{0}, {1}})
+	}
+
+	names, err := sa.namesForOrder(*order.Id)
+	test.AssertNotError(t, err, "namesForOrder errored")
+	test.AssertEquals(t, len(names), 3)
+}
+
 func TestSetOrderProcessing(t *testing.T) {
 	sa, fc, cleanup := initSA(t)
 	defer cleanup()
@@ -1418,6 +1679,58 @@ func TestSetOrderProcessing(t *testing.T) {
 	test.AssertEquals(t, *updatedOrder.BeganProcessing, true)
 }
 
+func TestFailStaleProcessingOrders(t *testing.T) {
+	sa, fc, cleanup := initSA(t)
+	defer cleanup()
+	_ = features.Set(map[string]bool{"AsyncFinalize": true})
+	defer features.Reset()
+
+	reg, err := sa.NewRegistration(ctx, core.Registration{
+		Key:       &jose.JSONWebKey{Key: &rsa.PublicKey{N: big.NewInt(1), E: 1}},
+		InitialIP: net.ParseIP("42.42.42.42"),
+	})
+	test.AssertNotError(t, err, "Couldn't create test registration")
+
+	newProcessingOrder := func() *corepb.Order {
+		expires := fc.Now().Add(time.Hour).UnixNano()
+		order, err := sa.NewOrder(ctx, &corepb.Order{
+			RegistrationID: &reg.ID,
+			Expires:        &expires,
+			Names:          []string{"example.com"},
+		})
+		test.AssertNotError(t, err, "NewOrder failed")
+		err = sa.SetOrderProcessing(ctx, order)
+		test.AssertNotError(t, err, "SetOrderProcessing failed")
+		return order
+	}
+	status := func(order *corepb.Order) string {
+		updated, err := sa.GetOrder(ctx, &sapb.OrderRequest{Id: order.Id})
+		test.AssertNotError(t, err, "GetOrder failed")
+		return *updated.Status
+	}
+
+	stale := newProcessingOrder()
+	fc.Add(time.Minute)
+	recent := newProcessingOrder()
+
+	// Only the order that began processing before the cutoff is failed
+	n, err := sa.FailStaleProcessingOrders(ctx, fc.Now().Add(-30*time.Second))
+	test.AssertNotError(t, err, "FailStaleProcessingOrders failed")
+	test.AssertEquals(t, n, int64(1))
+	test.AssertEquals(t, status(stale), string(core.StatusInvalid))
+	test.AssertEquals(t, status(recent), string(core.StatusProcessing))
+
+	// Finalized orders aren't failed
+	serial := "serial"
+	recent.CertificateSerial = &serial
+	err = sa.FinalizeOrder(ctx, recent)
+	test.AssertNotError(t, err, "FinalizeOrder failed")
+	n, err = sa.FailStaleProcessingOrders(ctx, fc.Now().Add(time.Minute))
+	test.AssertNotError(t, err, "FailStaleProcessingOrders failed")
+	test.AssertEquals(t, n, int64(0))
+	test.AssertEquals(t, status(recent), string(core.StatusValid))
+}
+
 func TestFinalizeOrder(t *testing.T) {
 	sa, fc, cleanup := initSA(t)
 	defer cleanup()
@@ -2184,3 +2497,240 @@ func TestStatusForOrder(t *testing.T) {
 	}
 
 }
+
+func TestPolicyOverridden(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	_ = features.Set(map[string]bool{"PolicyOverrides": true})
+	defer features.Reset()
+
+	dbMap, err := NewDbMap(vars.DBConnSAFullPerms, 0)
+	test.AssertNotError(t, err, "Couldn't create full perms dbMap")
+	addOverride := func(domain string, regID int64) {
+		_, err := dbMap.Exec(
+			`INSERT INTO policyOverrides (domain, registrationID, added, addedBy, comment)
+			VALUES (?, ?, ?, ?, ?)`,
+			domain, regID, fc.Now(), "test", "")
+		test.AssertNotError(t, err, "Couldn't add policy override")
+	}
+	addOverride("example.com", 1)
+	addOverride("letsencrypt.org", 0)
+
+	overridden := func(domain string, regID int64) bool {
+		exists, err := sa.PolicyOverridden(ctx, &sapb.PolicyOverriddenRequest{
+			Domain:         &domain,
+			RegistrationID: &regID,
+		})
+		test.AssertNotError(t, err, "PolicyOverridden failed")
+		return exists.GetExists()
+	}
+	test.Assert(t, overridden("example.com", 1), "Override for account not found")
+	test.Assert(t, overridden("www.example.com", 1), "Override for parent domain not found")
+	test.Assert(t, !overridden("example.com", 2), "Override for another account found")
+	test.Assert(t, !overridden("notexample.com", 1), "Override for unrelated domain found")
+	test.Assert(t, overridden("www.letsencrypt.org", 2), "Global override not found")
+
+	_ = features.Set(map[string]bool{"PolicyOverrides": false})
+	test.Assert(t, !overridden("example.com", 1), "Override found with PolicyOverrides disabled")
+}
+
+func TestAddBlockedKey(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	_ = features.Set(map[string]bool{"BlockedKeyTable": true})
+	defer features.Reset()
+
+	keyHash := "sha256-of-spki"
+	added := fc.Now().UnixNano()
+	addedBy := "test"
+	req := &sapb.AddBlockedKeyRequest{KeyHash: &keyHash, Added: &added, AddedBy: &addedBy}
+	_, err := sa.AddBlockedKey(ctx, req)
+	test.AssertNotError(t, err, "AddBlockedKey failed")
+	// Blocking a key twice is not an error
+	_, err = sa.AddBlockedKey(ctx, req)
+	test.AssertNotError(t, err, "AddBlockedKey failed for an already blocked key")
+
+	exists, err := sa.KeyBlocked(ctx, &sapb.KeyBlockedRequest{KeyHash: &keyHash})
+	test.AssertNotError(t, err, "KeyBlocked failed")
+	test.Assert(t, exists.GetExists(), "Added key not blocked")
+}
+
+func TestAddVerifiedContact(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	email := "one@example.com"
+	verified := fc.Now().UnixNano()
+	req := &sapb.AddVerifiedContactRequest{RegistrationID: &reg.ID, Email: &email, Verified: &verified}
+	_, err := sa.AddVerifiedContact(ctx, req)
+	test.AssertNotError(t, err, "AddVerifiedContact failed")
+
+	// Verifying an address again updates when it was verified
+	fc.Add(time.Hour)
+	verified = fc.Now().UnixNano()
+	_, err = sa.AddVerifiedContact(ctx, req)
+	test.AssertNotError(t, err, "AddVerifiedContact failed for an already verified contact")
+
+	var rows []struct {
+		Email    string
+		Verified time.Time
+	}
+	_, err = sa.dbMap.Select(&rows, "SELECT email, verified FROM verifiedContacts WHERE registrationID = ?", reg.ID)
+	test.AssertNotError(t, err, "Couldn't select verified contacts")
+	test.AssertEquals(t, len(rows), 1)
+	test.AssertEquals(t, rows[0].Email, email)
+	test.AssertEquals(t, rows[0].Verified.UnixNano(), verified)
+}
+
+func TestAddCTSubmission(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	serial := "000000000000000000000000000000000001"
+	logURL := "http://ct.example.com"
+	precert := true
+	submitted := fc.Now().UnixNano()
+	for _, result := range []string{"failure", "success"} {
+		result := result
+		_, err := sa.AddCTSubmission(ctx, &sapb.AddCTSubmissionRequest{
+			Serial:    &serial,
+			LogURL:    &logURL,
+			Precert:   &precert,
+			Result:    &result,
+			Submitted: &submitted,
+		})
+		test.AssertNotError(t, err, "AddCTSubmission failed")
+	}
+
+	var results []string
+	_, err := sa.dbMap.Select(&results, "SELECT result FROM ctSubmissions WHERE serial = ? ORDER BY id", serial)
+	test.AssertNotError(t, err, "Couldn't select CT submissions")
+	test.AssertDeepEquals(t, results, []string{"failure", "success"})
+}
+
+func TestGetAccountStats(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	_ = features.Set(map[string]bool{"FailedValidationsTable": true})
+	defer features.Reset()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	stats, err := sa.GetAccountStats(ctx, &sapb.RegistrationID{Id: &reg.ID})
+	test.AssertNotError(t, err, "GetAccountStats failed for a new registration")
+	test.AssertEquals(t, *stats.CertificatesLast90Days, int64(0))
+
+	for i, file := range []string{"www.eff.org.der", "test-cert.der", "test-cert2.der"} {
+		certDER, err := ioutil.ReadFile(file)
+		test.AssertNotError(t, err, "Couldn't read "+file)
+		issued := fc.Now().AddDate(0, 0, -[]int{1, 20, 60}[i])
+		_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, &issued)
+		test.AssertNotError(t, err, "Couldn't add "+file)
+	}
+	invalid := CreateDomainAuthWithRegID(t, "example.net", sa, reg.ID)
+	invalid.Status = core.StatusInvalid
+	err = sa.FinalizeAuthorization(ctx, invalid)
+	test.AssertNotError(t, err, "Couldn't finalize pending authorization with ID "+invalid.ID)
+	_ = CreateDomainAuthWithRegID(t, "example.com", sa, reg.ID)
+
+	stats, err = sa.GetAccountStats(ctx, &sapb.RegistrationID{Id: &reg.ID})
+	test.AssertNotError(t, err, "GetAccountStats failed")
+	test.AssertEquals(t, *stats.CertificatesLast7Days, int64(1))
+	test.AssertEquals(t, *stats.CertificatesLast30Days, int64(2))
+	test.AssertEquals(t, *stats.CertificatesLast90Days, int64(3))
+	test.AssertEquals(t, *stats.FailedValidationsLast7Days, int64(1))
+	test.AssertEquals(t, *stats.PendingAuthorizations, int64(1))
+}
+
+func TestGetSerialsByKey(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	dbMap, err := NewDbMap(vars.DBConnSAFullPerms, 0)
+	test.AssertNotError(t, err, "Couldn't create full perms dbMap")
+	addSerial := func(keyHash, serial string, notAfter time.Time) {
+		_, err := dbMap.Exec(
+			`INSERT INTO keyHashToSerial (keyHash, certNotAfter, certSerial) VALUES (?, ?, ?)`,
+			keyHash, notAfter, serial)
+		test.AssertNotError(t, err, "Couldn't add keyHashToSerial row")
+	}
+	addSerial("a", "01", fc.Now().Add(time.Hour))
+	addSerial("a", "02", fc.Now().Add(-time.Hour))
+	addSerial("a", "03", fc.Now().Add(2*time.Hour))
+	addSerial("b", "04", fc.Now().Add(time.Hour))
+
+	keyHash := "a"
+	notAfter := fc.Now().UnixNano()
+	serials, err := sa.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{KeyHash: &keyHash, NotAfter: &notAfter})
+	test.AssertNotError(t, err, "GetSerialsByKey failed")
+	sort.Strings(serials.Serials)
+	test.AssertDeepEquals(t, serials.Serials, []string{"01", "03"})
+}
+
+func TestAddCertificateKeyHash(t *testing.T) {
+	sa, _, cleanUp := initSA(t)
+	defer cleanUp()
+
+	_ = features.Set(map[string]bool{"StoreKeyHashes": true})
+	defer features.Reset()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	certDER, err := ioutil.ReadFile("www.eff.org.der")
+	test.AssertNotError(t, err, "Couldn't read example cert DER")
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, nil)
+	test.AssertNotError(t, err, "Couldn't add www.eff.org.der")
+	cert, err := x509.ParseCertificate(certDER)
+	test.AssertNotError(t, err, "Couldn't parse www.eff.org.der")
+	keyHash, err := core.KeyDigest(cert.PublicKey)
+	test.AssertNotError(t, err, "Couldn't hash public key")
+
+	// A zero NotAfter finds certificates regardless of expiry
+	var notAfter int64
+	serials, err := sa.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{KeyHash: &keyHash, NotAfter: &notAfter})
+	test.AssertNotError(t, err, "GetSerialsByKey failed")
+	test.AssertDeepEquals(t, serials.Serials, []string{core.SerialToString(cert.SerialNumber)})
+}
+
+func TestGetRateLimitOverrides(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	dbMap, err := NewDbMap(vars.DBConnSAFullPerms, 0)
+	test.AssertNotError(t, err, "Couldn't create full perms dbMap")
+	addOverride := func(regID int64, limitName string, threshold int, expires time.Time) {
+		_, err := dbMap.Exec(
+			`INSERT INTO rateLimitOverrides (registrationID, limitName, threshold, expires, added, addedBy)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			regID, limitName, threshold, expires, fc.Now(), "test")
+		test.AssertNotError(t, err, "Couldn't add rate limit override")
+	}
+	addOverride(1, "certificatesPerName", 1000, fc.Now().Add(time.Hour))
+	addOverride(1, "newOrdersPerAccount", 500, fc.Now().Add(-time.Hour))
+	addOverride(2, "certificatesPerName", 50, fc.Now().Add(time.Hour))
+
+	overrides, err := sa.GetRateLimitOverrides(ctx, &corepb.Empty{})
+	test.AssertNotError(t, err, "GetRateLimitOverrides failed")
+	test.AssertEquals(t, len(overrides.Overrides), 0)
+
+	_ = features.Set(map[string]bool{"RateLimitOverrides": true})
+	defer features.Reset()
+
+	overrides, err = sa.GetRateLimitOverrides(ctx, &corepb.Empty{})
+	test.AssertNotError(t, err, "GetRateLimitOverrides failed")
+	test.AssertEquals(t, len(overrides.Overrides), 2)
+	thresholds := make(map[int64]int64)
+	for _, o := range overrides.Overrides {
+		test.AssertEquals(t, o.GetLimitName(), "certificatesPerName")
+		test.AssertEquals(t, o.GetExpires(), fc.Now().Add(time.Hour).UnixNano())
+		thresholds[o.GetRegistrationID()] = o.GetThreshold()
+	}
+	test.AssertDeepEquals(t, thresholds, map[int64]int64{1: 1000, 2: 50})
+}
+
+func TestDomainAndParents(t *testing.T) {
+	test.AssertDeepEquals(t, domainAndParents("www.example.com"), []string{"www.example.com", "example.com", "com"})
+	test.AssertDeepEquals(t, domainAndParents("com"), []string{"com"})
+}
@@ -18,10 +18,9 @@ apt-get install -y --no-install-recommends \
   libseccomp-dev \
   opensc &
 
-# Install port forwarder, database migration tool, and testing tools.
+# Install port forwarder and testing tools.
 export GOBIN=/usr/local/bin GOPATH=/tmp/gopath
 go get \
-  bitbucket.org/liamstask/goose/cmd/goose \
   github.com/golang/lint/golint \
   github.com/golang/mock/mockgen \
   github.com/golang/protobuf/proto \
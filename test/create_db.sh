@@ -32,24 +32,14 @@ for dbenv in $DBENVS; do
     echo "created empty ${db} database"
   fi
 
-  goose -path=./sa/_db/ -env=$dbenv up || die "unable to migrate ${db} with ./sa/_db/"
-  echo "migrated ${db} database with ./sa/_db/"
-
+  migrationDirs="./sa/_db/migrations"
   if [[ "$BOULDER_CONFIG_DIR" = "test/config-next" ]]; then
-    nextDir="./sa/_db-next/"
-
-    # Goose exits non-zero if there are no migrations to apply with the error
-    # message:
-    #   "2016/09/26 15:43:38 no valid version found"
-    # so we only want to run goose with the nextDir if there is a migrations
-    # directory present with at least one migration
-    if [ $(find "$nextDir/migrations" -maxdepth 0 -type d -not -empty 2>/dev/null) ]; then
-      goose -path=${nextDir} -env=$dbenv up || die "unable to migrate ${db} with ${nextDir}"
-      echo "migrated ${db} database with ${nextDir}"
-    else
-      echo "no ${nextDir} migrations to apply"
-    fi
+    migrationDirs="${migrationDirs},./sa/_db-next/migrations"
   fi
+  go run ./cmd/boulder-migrate/main.go up \
+    --db-connect "root@tcp(boulder-mysql:3306)/${db}" \
+    --dirs "${migrationDirs}" || die "unable to migrate ${db} with ${migrationDirs}"
+  echo "migrated ${db} database with ${migrationDirs}"
 
   # With MYSQL_CONTAINER, patch the GRANT statements to
   # use 127.0.0.1, not localhost, as MySQL may interpret
@@ -1,5 +1,7 @@
 // This is a test server that implements the subset of RFC6962 APIs needed to
-// run Boulder's CT log submission code. Currently it only implements add-chain.
+// run Boulder's CT log submission code. Currently it only implements add-chain
+// and add-pre-chain. Each personality can be made slow, unreliable, or
+// temporally sharded, to exercise the publisher's handling of real logs.
 // This is used by startservers.py.
 package main
 
@@ -28,9 +30,50 @@ type ctSubmissionRequest struct {
 type integrationSrv struct {
 	sync.Mutex
 	submissions     int64
+	rejections      int64
 	key             *ecdsa.PrivateKey
 	latencySchedule []float64
 	latencyItem     int
+	errorSchedule   []int
+	errorItem       int
+	temporalStart   time.Time
+	temporalEnd     time.Time
+}
+
+// nextError returns the status code the next submission should fail with
+// according to the error schedule, or zero if it should succeed.
+func (is *integrationSrv) nextError() int {
+	if is.errorSchedule == nil {
+		return 0
+	}
+	is.Lock()
+	defer is.Unlock()
+	status := is.errorSchedule[is.errorItem%len(is.errorSchedule)]
+	is.errorItem++
+	return status
+}
+
+// acceptsChain returns an error if the leaf of chain doesn't expire within
+// the log's temporal shard, if it has one.
+func (is *integrationSrv) acceptsChain(chain []string) error {
+	if is.temporalStart.IsZero() && is.temporalEnd.IsZero() {
+		return nil
+	}
+	der, err := base64.StdEncoding.DecodeString(chain[0])
+	if err != nil {
+		return err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+	if !is.temporalStart.IsZero() && leaf.NotAfter.Before(is.temporalStart) {
+		return fmt.Errorf("certificate expires before %s", is.temporalStart)
+	}
+	if !is.temporalEnd.IsZero() && !leaf.NotAfter.Before(is.temporalEnd) {
+		return fmt.Errorf("certificate expires at or after %s", is.temporalEnd)
+	}
+	return nil
 }
 
 func (is *integrationSrv) handler(w http.ResponseWriter, r *http.Request) {
@@ -66,6 +109,17 @@ func (is *integrationSrv) handler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if status := is.nextError(); status != 0 {
+			atomic.AddInt64(&is.rejections, 1)
+			http.Error(w, "injected error", status)
+			return
+		}
+		if err := is.acceptsChain(addChainReq.Chain); err != nil {
+			atomic.AddInt64(&is.rejections, 1)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		precert := false
 		if r.URL.Path == "/ct/v1/add-pre-chain" {
 			precert = true
@@ -82,6 +136,15 @@ func (is *integrationSrv) handler(w http.ResponseWriter, r *http.Request) {
 		submissions := atomic.LoadInt64(&is.submissions)
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(fmt.Sprintf("%d", submissions)))
+	case "/rejections":
+		if r.Method != "GET" {
+			http.NotFound(w, r)
+			return
+		}
+
+		rejections := atomic.LoadInt64(&is.rejections)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fmt.Sprintf("%d", rejections)))
 	default:
 		http.NotFound(w, r)
 		return
@@ -102,6 +165,15 @@ type Personality struct {
 	// If present, sleep for the given number of seconds before replying. Each
 	// request uses the next number in the list, eventually cycling through.
 	LatencySchedule []float64
+	// If present, each submission uses the next status code in the list,
+	// eventually cycling through. A non-zero status code is returned as an
+	// error instead of an SCT; zero means the submission is handled normally.
+	ErrorSchedule []int
+	// If present, only certificates whose NotAfter is at or after TemporalStart
+	// and before TemporalEnd are accepted, as by a temporally sharded log.
+	// Others are rejected with a 400.
+	TemporalStart time.Time
+	TemporalEnd   time.Time
 }
 
 func runPersonality(p Personality) {
@@ -120,6 +192,9 @@ func runPersonality(p Personality) {
 	is := integrationSrv{
 		key:             key,
 		latencySchedule: p.LatencySchedule,
+		errorSchedule:   p.ErrorSchedule,
+		temporalStart:   p.TemporalStart,
+		temporalEnd:     p.TemporalEnd,
 	}
 	srv := &http.Server{
 		Addr:    p.Addr,
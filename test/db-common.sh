@@ -1,4 +1,4 @@
-# Common variables used by Goose-related scripts.
+# Common variables used by database migration scripts.
 function die() {
   if [ ! -z "$1" ]; then
     echo $1 > /dev/stderr
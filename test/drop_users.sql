@@ -26,5 +26,7 @@ GRANT USAGE ON *.* TO 'purger'@'localhost';
 DROP USER 'purger'@'localhost';
 GRANT USAGE ON *.* TO 'backfiller'@'localhost';
 DROP USER 'backfiller'@'localhost';
+GRANT USAGE ON *.* TO 'admin'@'localhost';
+DROP USER 'admin'@'localhost';
 GRANT USAGE ON *.* TO 'test_setup'@'localhost';
 DROP USER 'test_setup'@'localhost';
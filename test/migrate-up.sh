@@ -13,7 +13,9 @@ for svc in $SERVICES; do
   for dbenv in $DBENVS; do
     db="boulder_${svc}_${dbenv}"
 
-    goose -path=./$svc/_db/ -env=$dbenv up || die "unable to migrate ${db}"
+    go run ./cmd/boulder-migrate/main.go up \
+      --db-connect "root@tcp(boulder-mysql:3306)/${db}" \
+      --dirs "./$svc/_db/migrations" || die "unable to migrate ${db}"
     echo "migrated ${db} database"
   done
 done
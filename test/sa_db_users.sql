@@ -26,6 +26,10 @@ CREATE USER IF NOT EXISTS 'cert_checker'@'localhost';
 CREATE USER IF NOT EXISTS 'ocsp_update'@'localhost';
 CREATE USER IF NOT EXISTS 'test_setup'@'localhost';
 CREATE USER IF NOT EXISTS 'purger'@'localhost';
+CREATE USER IF NOT EXISTS 'admin'@'localhost';
+CREATE USER IF NOT EXISTS 'stats'@'localhost';
+CREATE USER IF NOT EXISTS 'archiver'@'localhost';
+CREATE USER IF NOT EXISTS 'abuse_detector'@'localhost';
 
 -- Storage Authority
 GRANT SELECT,INSERT,UPDATE ON authz TO 'sa'@'localhost';
@@ -43,6 +47,14 @@ GRANT SELECT,INSERT,UPDATE ON orders TO 'sa'@'localhost';
 GRANT SELECT,INSERT ON orderToAuthz TO 'sa'@'localhost';
 GRANT SELECT,INSERT ON requestedNames TO 'sa'@'localhost';
 GRANT SELECT,INSERT,DELETE ON orderFqdnSets TO 'sa'@'localhost';
+GRANT SELECT,INSERT ON blockedKeys TO 'sa'@'localhost';
+GRANT SELECT ON policyOverrides TO 'sa'@'localhost';
+GRANT SELECT ON rateLimitOverrides TO 'sa'@'localhost';
+GRANT SELECT,INSERT ON failedValidations TO 'sa'@'localhost';
+GRANT SELECT,INSERT ON accountFQDNSets TO 'sa'@'localhost';
+GRANT SELECT,INSERT ON keyHashToSerial TO 'sa'@'localhost';
+GRANT SELECT,INSERT,UPDATE ON verifiedContacts TO 'sa'@'localhost';
+GRANT INSERT ON ctSubmissions TO 'sa'@'localhost';
 
 -- OCSP Responder
 GRANT SELECT ON certificateStatus TO 'ocsp_resp'@'localhost';
@@ -53,16 +65,26 @@ GRANT INSERT ON ocspResponses TO 'ocsp_update'@'localhost';
 GRANT SELECT ON certificates TO 'ocsp_update'@'localhost';
 GRANT SELECT,UPDATE ON certificateStatus TO 'ocsp_update'@'localhost';
 GRANT SELECT ON sctReceipts TO 'ocsp_update'@'localhost';
+GRANT SELECT,INSERT,UPDATE,DELETE ON ctResubmissions TO 'ocsp_update'@'localhost';
 
 -- Revoker Tool
 GRANT SELECT ON registrations TO 'revoker'@'localhost';
 GRANT SELECT ON certificates TO 'revoker'@'localhost';
+GRANT SELECT,INSERT,UPDATE ON blockedKeys TO 'revoker'@'localhost';
+GRANT SELECT,INSERT,DELETE ON policyOverrides TO 'revoker'@'localhost';
+GRANT SELECT,INSERT,UPDATE,DELETE ON rateLimitOverrides TO 'revoker'@'localhost';
+GRANT SELECT ON keyHashToSerial TO 'revoker'@'localhost';
+GRANT SELECT ON certificateStatus TO 'revoker'@'localhost';
 
 -- Expiration mailer
 GRANT SELECT ON certificates TO 'mailer'@'localhost';
 GRANT SELECT ON registrations TO 'mailer'@'localhost';
 GRANT SELECT,UPDATE ON certificateStatus TO 'mailer'@'localhost';
 GRANT SELECT ON fqdnSets TO 'mailer'@'localhost';
+GRANT SELECT ON verifiedContacts TO 'mailer'@'localhost';
+
+-- Contact exporter, which also uses the mailer user
+GRANT SELECT ON issuedNames TO 'mailer'@'localhost';
 
 -- Cert checker
 GRANT SELECT ON certificates TO 'cert_checker'@'localhost';
@@ -71,6 +93,40 @@ GRANT SELECT ON certificates TO 'cert_checker'@'localhost';
 GRANT SELECT,DELETE ON pendingAuthorizations TO 'purger'@'localhost';
 GRANT SELECT,DELETE ON authz TO 'purger'@'localhost';
 GRANT SELECT,DELETE ON challenges TO 'purger'@'localhost';
+GRANT SELECT,DELETE ON orders TO 'purger'@'localhost';
+GRANT SELECT,DELETE ON orderToAuthz TO 'purger'@'localhost';
+GRANT SELECT,DELETE ON orderFqdnSets TO 'purger'@'localhost';
+GRANT SELECT,DELETE ON requestedNames TO 'purger'@'localhost';
+GRANT SELECT,DELETE ON failedValidations TO 'purger'@'localhost';
+GRANT SELECT,DELETE ON accountFQDNSets TO 'purger'@'localhost';
+
+-- Admin tool
+GRANT SELECT ON orders TO 'admin'@'localhost';
+GRANT SELECT ON orderToAuthz TO 'admin'@'localhost';
+GRANT SELECT ON authz TO 'admin'@'localhost';
+GRANT SELECT ON pendingAuthorizations TO 'admin'@'localhost';
+GRANT SELECT ON certificates TO 'admin'@'localhost';
+GRANT SELECT ON failedValidations TO 'admin'@'localhost';
+GRANT SELECT ON challenges TO 'admin'@'localhost';
+GRANT SELECT ON registrations TO 'admin'@'localhost';
+
+-- Issuance statistics rollups
+GRANT SELECT ON certificates TO 'stats'@'localhost';
+GRANT SELECT ON issuedNames TO 'stats'@'localhost';
+GRANT SELECT ON orders TO 'stats'@'localhost';
+GRANT SELECT,INSERT,DELETE ON registrationIssuanceStats TO 'stats'@'localhost';
+
+-- Certificate archiver
+GRANT SELECT,DELETE ON certificates TO 'archiver'@'localhost';
+GRANT SELECT,DELETE ON certificateStatus TO 'archiver'@'localhost';
+GRANT SELECT,INSERT ON certificatesArchive TO 'archiver'@'localhost';
+GRANT SELECT,INSERT ON certificateStatusArchive TO 'archiver'@'localhost';
+
+-- Abuse detector
+GRANT SELECT ON certificates TO 'abuse_detector'@'localhost';
+GRANT SELECT ON issuedNames TO 'abuse_detector'@'localhost';
+GRANT SELECT ON failedValidations TO 'abuse_detector'@'localhost';
+GRANT SELECT,INSERT,UPDATE ON rateLimitOverrides TO 'abuse_detector'@'localhost';
 
 -- Test setup and teardown
 GRANT ALL PRIVILEGES ON * to 'test_setup'@'localhost';
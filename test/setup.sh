@@ -7,7 +7,6 @@
 set -ev
 
 go get \
-  bitbucket.org/liamstask/goose/cmd/goose \
   github.com/golang/lint/golint \
   github.com/golang/mock/mockgen \
   github.com/golang/protobuf/proto \
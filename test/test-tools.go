@@ -162,6 +162,21 @@ func CountCounter(counter prometheus.Counter) int {
 	return int(iom.Counter.GetValue())
 }
 
+// GaugeValue returns the current value of a prometheus gauge
+func GaugeValue(gauge prometheus.Gauge) float64 {
+	ch := make(chan prometheus.Metric, 10)
+	gauge.Collect(ch)
+	var m prometheus.Metric
+	select {
+	case <-time.After(time.Second):
+		panic("timed out collecting metrics")
+	case m = <-ch:
+	}
+	var iom io_prometheus_client.Metric
+	_ = m.Write(&iom)
+	return iom.Gauge.GetValue()
+}
+
 func CountHistogramSamples(hist prometheus.Histogram) int {
 	ch := make(chan prometheus.Metric, 10)
 	hist.Collect(ch)
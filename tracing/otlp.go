@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// OTLPExporter is an Exporter that sends spans to an OpenTelemetry collector
+// using the OTLP/HTTP protocol with JSON encoding.
+type OTLPExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewOTLPExporter returns an OTLPExporter that posts to endpoint, the full URL
+// of the collector's traces receiver, e.g. "http://localhost:4318/v1/traces".
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              Kind            `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// otlpStatusError is the OTLP status code of a failed span.
+const otlpStatusError = 2
+
+func attributes(attrs map[string]string) []otlpAttribute {
+	var keys []string
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var out []otlpAttribute
+	for _, k := range keys {
+		out = append(out, otlpAttribute{Key: k, Value: otlpValue{attrs[k]}})
+	}
+	return out
+}
+
+func newOTLPRequest(service string, spans []*Span) otlpRequest {
+	scope := otlpScopeSpans{}
+	scope.Scope.Name = "github.com/letsencrypt/boulder/tracing"
+	for _, s := range spans {
+		os := otlpSpan{
+			TraceID:           hex.EncodeToString(s.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.SpanID[:]),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        attributes(s.Attributes()),
+		}
+		if s.ParentID != [8]byte{} {
+			os.ParentSpanID = hex.EncodeToString(s.ParentID[:])
+		}
+		if s.Error != "" {
+			os.Status = otlpStatus{Code: otlpStatusError, Message: s.Error}
+		}
+		scope.Spans = append(scope.Spans, os)
+	}
+	rs := otlpResourceSpans{ScopeSpans: []otlpScopeSpans{scope}}
+	rs.Resource.Attributes = []otlpAttribute{{Key: "service.name", Value: otlpValue{service}}}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{rs}}
+}
+
+// Export implements Exporter.
+func (oe *OTLPExporter) Export(service string, spans []*Span) error {
+	body, err := json.Marshal(newOTLPRequest(service, spans))
+	if err != nil {
+		return err
+	}
+	resp, err := oe.client.Post(oe.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
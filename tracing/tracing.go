@@ -0,0 +1,318 @@
+// Package tracing records spans for the work done by each Boulder component
+// on behalf of a request, and propagates trace context between components
+// using the W3C traceparent format, so that the time spent issuing a
+// certificate can be broken down by component. Spans are exported to an
+// OpenTelemetry collector by an Exporter.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// Kind describes the relationship of a span to its neighbours, using the
+// values of OpenTelemetry's SpanKind.
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// TraceParentHeader is the HTTP header and gRPC metadata key used to
+// propagate trace context.
+const TraceParentHeader = "traceparent"
+
+// Span is a timed operation within a trace.
+type Span struct {
+	TraceID  [16]byte
+	SpanID   [8]byte
+	ParentID [8]byte
+	Name     string
+	Kind     Kind
+	Start    time.Time
+	End      time.Time
+	// Sampled spans are exported when they end. Spans that aren't sampled
+	// are still created so that their trace context can be propagated.
+	Sampled bool
+	// Error, if not empty, describes why the operation failed.
+	Error string
+
+	tracer *Tracer
+	sync.Mutex
+	attributes map[string]string
+	ended      bool
+}
+
+// SetAttribute records a key/value pair describing the span.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]string)
+	}
+	s.attributes[key] = value
+}
+
+// Attributes returns a copy of the span's attributes.
+func (s *Span) Attributes() map[string]string {
+	s.Lock()
+	defer s.Unlock()
+	attrs := make(map[string]string, len(s.attributes))
+	for k, v := range s.attributes {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// Finish ends the span, recording err as its outcome, and queues it for
+// export if it's sampled. Only the first call has any effect.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	if s.ended {
+		s.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = s.tracer.now()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.Unlock()
+	if s.Sampled {
+		s.tracer.queue(s)
+	}
+}
+
+// TraceParent returns the span's trace context in the W3C traceparent format.
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	flags := "00"
+	if s.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(s.TraceID[:]), hex.EncodeToString(s.SpanID[:]), flags)
+}
+
+// parent is the trace context a new span continues.
+type parent struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+// parseTraceParent parses a W3C traceparent value, returning false if it's
+// malformed.
+func parseTraceParent(value string) (parent, bool) {
+	var p parent
+	fields := strings.Split(strings.TrimSpace(value), "-")
+	if len(fields) < 4 || len(fields[0]) != 2 || fields[0] == "ff" {
+		return p, false
+	}
+	traceID, err := hex.DecodeString(fields[1])
+	if err != nil || len(traceID) != 16 {
+		return p, false
+	}
+	spanID, err := hex.DecodeString(fields[2])
+	if err != nil || len(spanID) != 8 {
+		return p, false
+	}
+	flags, err := hex.DecodeString(fields[3])
+	if err != nil || len(flags) != 1 {
+		return p, false
+	}
+	copy(p.traceID[:], traceID)
+	copy(p.spanID[:], spanID)
+	p.sampled = flags[0]&1 == 1
+	return p, p.traceID != [16]byte{} && p.spanID != [8]byte{}
+}
+
+// Exporter sends finished spans to a collector.
+type Exporter interface {
+	Export(service string, spans []*Span) error
+}
+
+// Tracer creates spans and exports those that are sampled in batches.
+type Tracer struct {
+	service    string
+	sampleRate float64
+	exporter   Exporter
+	log        blog.Logger
+	now        func() time.Time
+	random     func() float64
+
+	spans chan *Span
+}
+
+// maxQueuedSpans is how many spans may wait to be exported before new ones
+// are dropped.
+const maxQueuedSpans = 2048
+
+// exportBatchSize is the most spans sent to the exporter at once.
+const exportBatchSize = 512
+
+// NewTracer returns a Tracer for the named service that samples the given
+// fraction of new traces and sends their spans to exporter every interval.
+// Traces continued from another service keep that service's decision.
+func NewTracer(service string, sampleRate float64, exporter Exporter, interval time.Duration, log blog.Logger) *Tracer {
+	t := &Tracer{
+		service:    service,
+		sampleRate: sampleRate,
+		exporter:   exporter,
+		log:        log,
+		now:        time.Now,
+		random:     randomFloat,
+		spans:      make(chan *Span, maxQueuedSpans),
+	}
+	go t.exportLoop(interval)
+	return t
+}
+
+func (t *Tracer) queue(s *Span) {
+	select {
+	case t.spans <- s:
+	default:
+		// Tracing mustn't slow down the request, so spans are dropped
+		// when the exporter can't keep up.
+	}
+}
+
+// exportLoop sends the queued spans to the exporter every interval, or
+// sooner once a full batch is waiting.
+func (t *Tracer) exportLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var batch []*Span
+	for {
+		select {
+		case s := <-t.spans:
+			batch = append(batch, s)
+			if len(batch) < exportBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		if err := t.exporter.Export(t.service, batch); err != nil {
+			t.log.Warning(fmt.Sprintf("Exporting %d spans: %s", len(batch), err))
+		}
+		batch = nil
+	}
+}
+
+// start creates a span that continues p, or starts a new trace if p is nil.
+func (t *Tracer) start(p *parent, name string, kind Kind) *Span {
+	s := &Span{Name: name, Kind: kind, Start: t.now(), tracer: t}
+	if p != nil {
+		s.TraceID = p.traceID
+		s.ParentID = p.spanID
+		s.Sampled = p.sampled
+	} else {
+		randomBytes(s.TraceID[:])
+		s.Sampled = t.random() < t.sampleRate
+	}
+	randomBytes(s.SpanID[:])
+	return s
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultTracer *Tracer
+)
+
+// Set makes t the Tracer used to start spans. Until it's called, no spans
+// are created.
+func Set(t *Tracer) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultTracer = t
+}
+
+func get() *Tracer {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultTracer
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying s.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// FromContext returns the span carried by ctx, or nil if it has none.
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// StartSpan starts a span that's a child of the one in ctx, or the root of a
+// new trace if ctx has none, and returns it along with a context carrying it.
+// If no Tracer is set the span is nil, which is safe to use.
+func StartSpan(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	t := get()
+	if t == nil {
+		return ctx, nil
+	}
+	var p *parent
+	if ps := FromContext(ctx); ps != nil {
+		p = &parent{traceID: ps.TraceID, spanID: ps.SpanID, sampled: ps.Sampled}
+	}
+	s := t.start(p, name, kind)
+	return ContextWithSpan(ctx, s), s
+}
+
+// StartRemoteSpan starts a span that continues the trace described by
+// traceParent, received from another service, or starts a new trace if it's
+// empty or malformed.
+func StartRemoteSpan(ctx context.Context, traceParent, name string, kind Kind) (context.Context, *Span) {
+	t := get()
+	if t == nil {
+		return ctx, nil
+	}
+	var p *parent
+	if parsed, ok := parseTraceParent(traceParent); ok {
+		p = &parsed
+	}
+	s := t.start(p, name, kind)
+	return ContextWithSpan(ctx, s), s
+}
+
+func randomBytes(b []byte) {
+	// Trace and span IDs needn't be unpredictable, so a failure to read
+	// randomness isn't worth failing a request over.
+	_, _ = rand.Read(b)
+}
+
+func randomFloat() float64 {
+	var b [8]byte
+	randomBytes(b[:])
+	var n uint64
+	for _, c := range b[:7] {
+		n = n<<8 | uint64(c)
+	}
+	return float64(n>>3) / float64(1<<53)
+}
@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// recordingExporter is an Exporter that keeps the spans it's given.
+type recordingExporter struct {
+	sync.Mutex
+	spans []*Span
+}
+
+func (re *recordingExporter) Export(service string, spans []*Span) error {
+	re.Lock()
+	defer re.Unlock()
+	re.spans = append(re.spans, spans...)
+	return nil
+}
+
+func (re *recordingExporter) count() int {
+	re.Lock()
+	defer re.Unlock()
+	return len(re.spans)
+}
+
+func TestTraceParent(t *testing.T) {
+	_, ok := parseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	test.Assert(t, ok, "valid traceparent wasn't parsed")
+
+	for _, bad := range []string{
+		"",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331",
+		"00-0af7651916cd43dd-b7ad6b7169203331-01",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b71692033zz-01",
+	} {
+		_, ok := parseTraceParent(bad)
+		test.Assert(t, !ok, "malformed traceparent was parsed: "+bad)
+	}
+
+	tracer := &Tracer{now: time.Now, random: func() float64 { return 0 }, sampleRate: 1}
+	s := tracer.start(nil, "root", KindServer)
+	p, ok := parseTraceParent(s.TraceParent())
+	test.Assert(t, ok, "generated traceparent wasn't parsed")
+	test.AssertEquals(t, p.traceID, s.TraceID)
+	test.AssertEquals(t, p.spanID, s.SpanID)
+	test.Assert(t, p.sampled, "sampled flag was lost")
+}
+
+func TestSpans(t *testing.T) {
+	ctx, s := StartSpan(context.Background(), "untraced", KindInternal)
+	test.Assert(t, s == nil, "span started without a Tracer")
+	s.SetAttribute("key", "value")
+	s.Finish(nil)
+	test.AssertEquals(t, FromContext(ctx), (*Span)(nil))
+
+	exporter := &recordingExporter{}
+	tracer := NewTracer("test", 1, exporter, time.Millisecond, blog.NewMock())
+	Set(tracer)
+	defer Set(nil)
+
+	ctx, root := StartSpan(context.Background(), "root", KindServer)
+	test.Assert(t, root.Sampled, "root span wasn't sampled")
+	_, child := StartSpan(ctx, "child", KindClient)
+	test.AssertEquals(t, child.TraceID, root.TraceID)
+	test.AssertEquals(t, child.ParentID, root.SpanID)
+
+	_, remote := StartRemoteSpan(context.Background(), child.TraceParent(), "remote", KindServer)
+	test.AssertEquals(t, remote.TraceID, root.TraceID)
+	test.AssertEquals(t, remote.ParentID, child.SpanID)
+
+	remote.Finish(errors.New("failed"))
+	remote.Finish(nil)
+	test.AssertEquals(t, remote.Error, "failed")
+	child.Finish(nil)
+	root.Finish(nil)
+	for i := 0; i < 100 && exporter.count() < 3; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	test.AssertEquals(t, exporter.count(), 3)
+
+	// Unsampled traces aren't exported, and neither are their children
+	tracer.sampleRate = 0
+	ctx, root = StartSpan(context.Background(), "root", KindServer)
+	test.Assert(t, !root.Sampled, "root span was sampled")
+	_, child = StartSpan(ctx, "child", KindClient)
+	test.Assert(t, !child.Sampled, "child of unsampled span was sampled")
+	child.Finish(nil)
+	root.Finish(nil)
+	time.Sleep(5 * time.Millisecond)
+	test.AssertEquals(t, exporter.count(), 3)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var req otlpRequest
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		test.AssertEquals(t, r.Header.Get("Content-Type"), "application/json")
+		body, _ := ioutil.ReadAll(r.Body)
+		err := json.Unmarshal(body, &req)
+		test.AssertNotError(t, err, "bad export request")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	tracer := &Tracer{now: time.Now, random: func() float64 { return 0 }, sampleRate: 1}
+	s := tracer.start(nil, "/sa.StorageAuthority/GetRegistration", KindServer)
+	s.SetAttribute("request_id", "abcd")
+	s.End = s.Start.Add(time.Second)
+	s.Error = "not found"
+
+	exporter := NewOTLPExporter(srv.URL)
+	err := exporter.Export("boulder-sa", []*Span{s})
+	test.AssertNotError(t, err, "Export failed")
+	test.AssertEquals(t, len(req.ResourceSpans), 1)
+	rs := req.ResourceSpans[0]
+	test.AssertDeepEquals(t, rs.Resource.Attributes, []otlpAttribute{{"service.name", otlpValue{"boulder-sa"}}})
+	test.AssertEquals(t, len(rs.ScopeSpans[0].Spans), 1)
+	span := rs.ScopeSpans[0].Spans[0]
+	test.AssertEquals(t, span.Name, "/sa.StorageAuthority/GetRegistration")
+	test.AssertEquals(t, span.Kind, KindServer)
+	test.AssertEquals(t, span.ParentSpanID, "")
+	test.AssertEquals(t, span.Status.Code, otlpStatusError)
+	test.AssertDeepEquals(t, span.Attributes, []otlpAttribute{{"request_id", otlpValue{"abcd"}}})
+
+	status = http.StatusServiceUnavailable
+	err = exporter.Export("boulder-sa", []*Span{s})
+	test.AssertError(t, err, "Export didn't fail")
+}
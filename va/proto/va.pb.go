@@ -16,6 +16,7 @@ It has these top-level messages:
 	PerformValidationRequest
 	AuthzMeta
 	ValidationResult
+	ReplayValidationRequest
 */
 package proto
 
@@ -193,6 +194,39 @@ func (m *ValidationResult) GetProblems() *core.ProblemDetails {
 	return nil
 }
 
+type ReplayValidationRequest struct {
+	Domain           *string         `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
+	Challenge        *core.Challenge `protobuf:"bytes,2,opt,name=challenge" json:"challenge,omitempty"`
+	UserAgent        *string         `protobuf:"bytes,3,opt,name=userAgent" json:"userAgent,omitempty"`
+	XXX_unrecognized []byte          `json:"-"`
+}
+
+func (m *ReplayValidationRequest) Reset()                    { *m = ReplayValidationRequest{} }
+func (m *ReplayValidationRequest) String() string            { return proto1.CompactTextString(m) }
+func (*ReplayValidationRequest) ProtoMessage()               {}
+func (*ReplayValidationRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *ReplayValidationRequest) GetDomain() string {
+	if m != nil && m.Domain != nil {
+		return *m.Domain
+	}
+	return ""
+}
+
+func (m *ReplayValidationRequest) GetChallenge() *core.Challenge {
+	if m != nil {
+		return m.Challenge
+	}
+	return nil
+}
+
+func (m *ReplayValidationRequest) GetUserAgent() string {
+	if m != nil && m.UserAgent != nil {
+		return *m.UserAgent
+	}
+	return ""
+}
+
 func init() {
 	proto1.RegisterType((*IsCAAValidRequest)(nil), "va.IsCAAValidRequest")
 	proto1.RegisterType((*IsCAAValidResponse)(nil), "va.IsCAAValidResponse")
@@ -201,6 +235,7 @@ func init() {
 	proto1.RegisterType((*PerformValidationRequest)(nil), "va.PerformValidationRequest")
 	proto1.RegisterType((*AuthzMeta)(nil), "va.AuthzMeta")
 	proto1.RegisterType((*ValidationResult)(nil), "va.ValidationResult")
+	proto1.RegisterType((*ReplayValidationRequest)(nil), "va.ReplayValidationRequest")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -216,6 +251,7 @@ const _ = grpc.SupportPackageIsVersion4
 type VAClient interface {
 	IsSafeDomain(ctx context.Context, in *IsSafeDomainRequest, opts ...grpc.CallOption) (*IsDomainSafe, error)
 	PerformValidation(ctx context.Context, in *PerformValidationRequest, opts ...grpc.CallOption) (*ValidationResult, error)
+	ReplayValidation(ctx context.Context, in *ReplayValidationRequest, opts ...grpc.CallOption) (*ValidationResult, error)
 }
 
 type vAClient struct {
@@ -244,11 +280,21 @@ func (c *vAClient) PerformValidation(ctx context.Context, in *PerformValidationR
 	return out, nil
 }
 
+func (c *vAClient) ReplayValidation(ctx context.Context, in *ReplayValidationRequest, opts ...grpc.CallOption) (*ValidationResult, error) {
+	out := new(ValidationResult)
+	err := grpc.Invoke(ctx, "/va.VA/ReplayValidation", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for VA service
 
 type VAServer interface {
 	IsSafeDomain(context.Context, *IsSafeDomainRequest) (*IsDomainSafe, error)
 	PerformValidation(context.Context, *PerformValidationRequest) (*ValidationResult, error)
+	ReplayValidation(context.Context, *ReplayValidationRequest) (*ValidationResult, error)
 }
 
 func RegisterVAServer(s *grpc.Server, srv VAServer) {
@@ -291,6 +337,24 @@ func _VA_PerformValidation_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VA_ReplayValidation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplayValidationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VAServer).ReplayValidation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/va.VA/ReplayValidation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VAServer).ReplayValidation(ctx, req.(*ReplayValidationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _VA_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "va.VA",
 	HandlerType: (*VAServer)(nil),
@@ -303,6 +367,10 @@ var _VA_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PerformValidation",
 			Handler:    _VA_PerformValidation_Handler,
 		},
+		{
+			MethodName: "ReplayValidation",
+			Handler:    _VA_ReplayValidation_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "va/proto/va.proto",
@@ -375,30 +443,32 @@ var _CAA_serviceDesc = grpc.ServiceDesc{
 func init() { proto1.RegisterFile("va/proto/va.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 396 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x92, 0x3d, 0xaf, 0xda, 0x30,
-	0x14, 0x86, 0x49, 0x22, 0xca, 0xe5, 0xdc, 0x7e, 0x5c, 0x4e, 0x81, 0x46, 0x51, 0x07, 0xe4, 0x4a,
-	0x15, 0x52, 0x45, 0xa0, 0x59, 0xab, 0x0e, 0x29, 0x59, 0x32, 0x54, 0x42, 0xae, 0xc4, 0xd0, 0xcd,
-	0x25, 0x06, 0x22, 0x05, 0x4c, 0xed, 0x90, 0xa1, 0x3f, 0xa0, 0x53, 0x7f, 0x74, 0x65, 0x3b, 0x10,
-	0x44, 0xa9, 0xd8, 0xec, 0xf3, 0x3e, 0xf6, 0xf9, 0x78, 0x0f, 0xf4, 0x2a, 0x36, 0x3d, 0x48, 0x51,
-	0x8a, 0x69, 0xc5, 0x42, 0x73, 0x40, 0xb7, 0x62, 0xc1, 0x60, 0x25, 0x24, 0xaf, 0x05, 0x7d, 0xb4,
-	0x12, 0xf9, 0x00, 0xbd, 0x54, 0xcd, 0xe3, 0x78, 0xc9, 0x8a, 0x3c, 0xa3, 0xfc, 0xe7, 0x91, 0xab,
-	0x12, 0x87, 0xf0, 0x2c, 0x13, 0x3b, 0x96, 0xef, 0x7d, 0x67, 0xe4, 0x8c, 0xbb, 0xb4, 0xbe, 0x91,
-	0x04, 0xf0, 0x12, 0x56, 0x07, 0xb1, 0x57, 0x1c, 0x43, 0xe8, 0x1c, 0xa4, 0xf8, 0x51, 0xf0, 0x9d,
-	0xc1, 0x1f, 0xa3, 0x7e, 0x68, 0x12, 0x2c, 0x6c, 0x30, 0xe1, 0x25, 0xcb, 0x0b, 0x45, 0x4f, 0x10,
-	0x99, 0xc0, 0xeb, 0x54, 0x7d, 0x63, 0x6b, 0x9e, 0x98, 0x5f, 0xef, 0x25, 0x7d, 0x0f, 0xcf, 0x53,
-	0x65, 0x51, 0xfd, 0x48, 0x73, 0xb9, 0x79, 0x6e, 0xb8, 0x07, 0x5a, 0xdf, 0xc8, 0x6f, 0x07, 0xfc,
-	0x05, 0x97, 0x6b, 0x21, 0x77, 0xa6, 0x3e, 0x56, 0xe6, 0xe2, 0xde, 0xe7, 0x38, 0x81, 0xee, 0x6a,
-	0xcb, 0x8a, 0x82, 0xef, 0x37, 0xdc, 0x77, 0x4d, 0xf5, 0xaf, 0x6c, 0xf5, 0xf3, 0x53, 0x98, 0x36,
-	0x04, 0xbe, 0x83, 0x36, 0x3b, 0x96, 0xdb, 0x5f, 0xbe, 0x67, 0xd0, 0x17, 0x61, 0xc5, 0xc2, 0x58,
-	0x07, 0xbe, 0xf2, 0x92, 0x51, 0xab, 0x91, 0x8f, 0xd0, 0x3d, 0xc7, 0xf0, 0x25, 0xb8, 0x79, 0x56,
-	0x27, 0x75, 0xf3, 0x0c, 0xfb, 0xd0, 0x96, 0x7c, 0x93, 0x26, 0x26, 0x99, 0x47, 0xed, 0x85, 0x54,
-	0xf0, 0x74, 0x59, 0xb3, 0x3a, 0x16, 0x25, 0xce, 0xa0, 0x23, 0xf9, 0x4a, 0xc8, 0x4c, 0xf9, 0xce,
-	0xc8, 0x1b, 0x3f, 0x46, 0x43, 0x5b, 0xd8, 0x25, 0xa8, 0x65, 0x7a, 0xc2, 0x70, 0x06, 0x0f, 0xf5,
-	0x8c, 0x55, 0xdd, 0xcb, 0x6d, 0x27, 0xce, 0x54, 0xf4, 0xc7, 0x01, 0x77, 0x19, 0xe3, 0x27, 0x3d,
-	0xe2, 0xc6, 0x11, 0x7c, 0xa3, 0xfb, 0xba, 0xe1, 0x51, 0xf0, 0x64, 0x85, 0xc6, 0x0d, 0xd2, 0xc2,
-	0x14, 0x7a, 0xff, 0x8c, 0x1d, 0xdf, 0x6a, 0xf0, 0x7f, 0x6e, 0x04, 0x7d, 0xad, 0x5e, 0x37, 0x4c,
-	0x5a, 0x51, 0x02, 0xde, 0x3c, 0x8e, 0xf1, 0x33, 0x40, 0xb3, 0x66, 0x38, 0xb0, 0x39, 0xaf, 0x76,
-	0x34, 0x18, 0x5e, 0x87, 0xed, 0x36, 0x92, 0xd6, 0x97, 0xce, 0xf7, 0xb6, 0xd9, 0xed, 0xbf, 0x01,
-	0x00, 0x00, 0xff, 0xff, 0x5e, 0x1b, 0xcd, 0xab, 0x0a, 0x03, 0x00, 0x00,
+	// 428 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb5, 0x53, 0x4b, 0x4f, 0xc2, 0x40,
+	0x10, 0xa6, 0x34, 0x08, 0x1d, 0x5f, 0xb0, 0xf2, 0x0a, 0x72, 0x30, 0x6b, 0x62, 0x4c, 0x0c, 0xa0,
+	0x5c, 0x8d, 0x87, 0x4a, 0x13, 0xd3, 0x83, 0x09, 0x59, 0x13, 0x0e, 0xde, 0x56, 0x58, 0xa0, 0x49,
+	0xa1, 0xd8, 0x2d, 0x24, 0x7a, 0xf0, 0xe8, 0x3f, 0xf4, 0xff, 0xb8, 0x8f, 0x42, 0x09, 0x42, 0x38,
+	0x79, 0x9b, 0xc7, 0x37, 0x33, 0xdf, 0x7e, 0x3b, 0x03, 0x85, 0x05, 0x6d, 0xcd, 0xc2, 0x20, 0x0a,
+	0x5a, 0x0b, 0xda, 0x54, 0x06, 0x4a, 0x2f, 0x68, 0xad, 0xd4, 0x0f, 0x42, 0x16, 0x27, 0xa4, 0xa9,
+	0x53, 0xf8, 0x06, 0x0a, 0x2e, 0xef, 0xd8, 0x76, 0x8f, 0xfa, 0xde, 0x80, 0xb0, 0xf7, 0x39, 0xe3,
+	0x11, 0x2a, 0xc3, 0xc1, 0x20, 0x98, 0x50, 0x6f, 0x5a, 0x35, 0x2e, 0x8c, 0x6b, 0x8b, 0xc4, 0x1e,
+	0x76, 0x00, 0xad, 0x83, 0xf9, 0x2c, 0x98, 0x72, 0x86, 0x9a, 0x90, 0x15, 0xbd, 0xde, 0x7c, 0x36,
+	0x51, 0xf0, 0xc3, 0x76, 0xb1, 0xa9, 0x06, 0x74, 0x75, 0xd0, 0x61, 0x11, 0xf5, 0x7c, 0x4e, 0x96,
+	0x20, 0xdc, 0x80, 0x33, 0x97, 0xbf, 0xd0, 0x21, 0x73, 0x54, 0xd7, 0x7d, 0x43, 0xaf, 0xe0, 0xc8,
+	0xe5, 0x1a, 0x2a, 0x8b, 0x24, 0xce, 0x53, 0xe5, 0x0a, 0x97, 0x23, 0xb1, 0x87, 0xbf, 0x0d, 0xa8,
+	0x76, 0x59, 0x38, 0x0c, 0xc2, 0x89, 0xe2, 0x47, 0x23, 0x2f, 0xd8, 0xd7, 0x1c, 0x35, 0xc0, 0xea,
+	0x8f, 0xa9, 0xef, 0xb3, 0xe9, 0x88, 0x55, 0xd3, 0x8a, 0xfd, 0xa9, 0x66, 0xdf, 0x59, 0x86, 0x49,
+	0x82, 0x40, 0x97, 0x90, 0xa1, 0xf3, 0x68, 0xfc, 0x59, 0x35, 0x15, 0xf4, 0xb8, 0x29, 0x24, 0xb6,
+	0x65, 0xe0, 0x59, 0x3c, 0x92, 0xe8, 0x1c, 0xbe, 0x03, 0x6b, 0x15, 0x43, 0x27, 0x90, 0xf6, 0x06,
+	0xf1, 0x50, 0x61, 0xa1, 0x22, 0x64, 0x42, 0x36, 0x72, 0x1d, 0x35, 0xcc, 0x24, 0xda, 0xc1, 0x0b,
+	0xc8, 0xaf, 0x73, 0xe6, 0x73, 0x3f, 0x42, 0xb7, 0x90, 0x0d, 0x99, 0xa0, 0x32, 0xe0, 0xa2, 0xdc,
+	0x14, 0xd3, 0xca, 0x9a, 0xd8, 0x3a, 0x50, 0xa6, 0xc9, 0x12, 0x26, 0x2a, 0x72, 0xb1, 0xc6, 0x3c,
+	0x7e, 0xcb, 0xf6, 0x9f, 0x58, 0xa1, 0xf0, 0x17, 0x54, 0x08, 0x9b, 0xf9, 0xf4, 0xe3, 0xdf, 0x14,
+	0xab, 0x83, 0x35, 0xe7, 0x2c, 0xb4, 0x47, 0x6c, 0x1a, 0x29, 0xd5, 0x2c, 0x92, 0x04, 0xda, 0x3f,
+	0x06, 0xa4, 0x7b, 0x36, 0xba, 0x97, 0x5f, 0x9c, 0x6c, 0x04, 0xaa, 0x48, 0x5d, 0xb7, 0xec, 0x48,
+	0x2d, 0xaf, 0x13, 0xc9, 0x36, 0xe0, 0x14, 0x72, 0xa1, 0xf0, 0xe7, 0xdb, 0x51, 0x5d, 0x02, 0x77,
+	0x6d, 0x43, 0xad, 0x28, 0xb3, 0x9b, 0x82, 0x8b, 0x56, 0x4f, 0x90, 0xdf, 0x94, 0x03, 0x9d, 0x4b,
+	0xec, 0x0e, 0x91, 0x76, 0x35, 0x6a, 0x3b, 0x60, 0x8a, 0x33, 0x41, 0x0f, 0x00, 0xc9, 0xbd, 0xa0,
+	0x92, 0x26, 0xbf, 0x71, 0x6c, 0xb5, 0xf2, 0x66, 0x58, 0x9f, 0x15, 0x4e, 0x3d, 0x66, 0x5f, 0x33,
+	0xea, 0x48, 0x7f, 0x01, 0xf7, 0x7a, 0x59, 0x27, 0xd3, 0x03, 0x00, 0x00,
 }
@@ -0,0 +1,92 @@
+package va
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+)
+
+// SourcePool is a set of local IP addresses that the VA binds outbound
+// HTTP-01 and TLS-SNI connections to, so that validation requests come from a
+// known, manageable set of addresses. Each validation takes the next address
+// of each IP family in turn, so load is spread across the pool and an address
+// can be rotated out by removing it from the configuration.
+//
+// DNS queries, including those for DNS-01 and CAA, go to the configured
+// resolvers and are not bound to the pool.
+type SourcePool struct {
+	v4   []net.IP
+	v6   []net.IP
+	next uint64
+}
+
+// NewSourcePool returns a SourcePool of the given IP addresses. There must be
+// at least one, and each must only appear once.
+func NewSourcePool(addrs []string) (*SourcePool, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no source addresses")
+	}
+	p := &SourcePool{}
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source address %q", addr)
+		}
+		if seen[ip.String()] {
+			return nil, fmt.Errorf("duplicate source address %q", addr)
+		}
+		seen[ip.String()] = true
+		if ip.To4() != nil {
+			p.v4 = append(p.v4, ip.To4())
+		} else {
+			p.v6 = append(p.v6, ip)
+		}
+	}
+	return p, nil
+}
+
+// source is the pair of addresses chosen from a SourcePool for one validation.
+// Either may be nil, in which case connections to that IP family use the
+// address chosen by the operating system.
+type source struct {
+	v4 net.IP
+	v6 net.IP
+}
+
+// take returns the next source from the pool. A nil pool returns a source
+// leaving all addresses to the operating system.
+func (p *SourcePool) take() source {
+	if p == nil {
+		return source{}
+	}
+	n := atomic.AddUint64(&p.next, 1) - 1
+	var s source
+	if len(p.v4) > 0 {
+		s.v4 = p.v4[n%uint64(len(p.v4))]
+	}
+	if len(p.v6) > 0 {
+		s.v6 = p.v6[n%uint64(len(p.v6))]
+	}
+	return s
+}
+
+// forTarget returns the source address to use for a connection to target, or
+// nil if the operating system should choose.
+func (s source) forTarget(target net.IP) net.IP {
+	if target.To4() != nil {
+		return s.v4
+	}
+	return s.v6
+}
+
+// dialer returns a net.Dialer for connecting to target, bound to the
+// appropriate source address, along with that address.
+func (s source) dialer(target net.IP) (*net.Dialer, net.IP) {
+	d := &net.Dialer{Timeout: singleDialTimeout}
+	addr := s.forTarget(target)
+	if addr != nil {
+		d.LocalAddr = &net.TCPAddr{IP: addr}
+	}
+	return d, addr
+}
@@ -0,0 +1,68 @@
+package va
+
+import (
+	"net"
+	"testing"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestNewSourcePool(t *testing.T) {
+	_, err := NewSourcePool(nil)
+	test.AssertError(t, err, "NewSourcePool accepted no addresses")
+	_, err = NewSourcePool([]string{"10.0.0.1", "example.com"})
+	test.AssertError(t, err, "NewSourcePool accepted a hostname")
+	_, err = NewSourcePool([]string{"10.0.0.1", "::ffff:10.0.0.1"})
+	test.AssertError(t, err, "NewSourcePool accepted a duplicate address")
+
+	p, err := NewSourcePool([]string{"10.0.0.1", "2001:db8::1", "10.0.0.2"})
+	test.AssertNotError(t, err, "NewSourcePool failed")
+	test.AssertEquals(t, len(p.v4), 2)
+	test.AssertEquals(t, len(p.v6), 1)
+}
+
+func TestSourcePoolRotation(t *testing.T) {
+	var nilPool *SourcePool
+	test.AssertDeepEquals(t, nilPool.take(), source{})
+
+	p, err := NewSourcePool([]string{"10.0.0.1", "10.0.0.2", "2001:db8::1"})
+	test.AssertNotError(t, err, "NewSourcePool failed")
+	v4Target := net.ParseIP("192.0.2.1")
+	v6Target := net.ParseIP("2001:db8::ffff")
+
+	var v4Sources []string
+	for i := 0; i < 4; i++ {
+		s := p.take()
+		v4Sources = append(v4Sources, s.forTarget(v4Target).String())
+		test.AssertEquals(t, s.forTarget(v6Target).String(), "2001:db8::1")
+	}
+	test.AssertDeepEquals(t, v4Sources, []string{"10.0.0.1", "10.0.0.2", "10.0.0.1", "10.0.0.2"})
+
+	// A pool without IPv6 addresses leaves IPv6 connections unbound
+	p, err = NewSourcePool([]string{"10.0.0.1"})
+	test.AssertNotError(t, err, "NewSourcePool failed")
+	d, addr := p.take().dialer(v6Target)
+	test.Assert(t, addr == nil, "IPv6 connection bound to an IPv4 address")
+	test.Assert(t, d.LocalAddr == nil, "IPv6 dialer has a local address")
+	d, addr = p.take().dialer(v4Target)
+	test.AssertEquals(t, addr.String(), "10.0.0.1")
+	test.AssertEquals(t, d.LocalAddr.String(), "10.0.0.1:0")
+}
+
+func TestHTTPSourcePool(t *testing.T) {
+	chall := core.HTTPChallenge01()
+	setChallengeToken(&chall, expectedToken)
+	hs := httpSrv(t, chall.Token)
+	defer hs.Close()
+	va, _ := setup(hs, 0)
+
+	records, prob := va.validateHTTP01(ctx, dnsi("localhost"), chall)
+	test.Assert(t, prob == nil, "HTTP validation failed")
+	test.Assert(t, records[0].SourceAddress == nil, "Source address recorded without a pool")
+
+	va.SourcePool, _ = NewSourcePool([]string{"127.0.0.1"})
+	records, prob = va.validateHTTP01(ctx, dnsi("localhost"), chall)
+	test.Assert(t, prob == nil, "HTTP validation from source pool failed")
+	test.AssertEquals(t, records[0].SourceAddress.String(), "127.0.0.1")
+}
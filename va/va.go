@@ -29,6 +29,7 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/identifier"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/probs"
@@ -108,6 +109,11 @@ type ValidationAuthorityImpl struct {
 	clk               clock.Clock
 	remoteVAs         []RemoteVA
 	maxRemoteFailures int
+	// SourcePool, if set, is the pool of local addresses that HTTP-01 and
+	// TLS-SNI validation connections are made from.
+	SourcePool *SourcePool
+	// HTTP configures the requests made for HTTP-01 validation.
+	HTTP HTTPConfig
 
 	metrics *vaMetrics
 }
@@ -143,6 +149,71 @@ func NewValidationAuthorityImpl(
 	}
 }
 
+// HTTPConfig configures the requests the VA makes for HTTP-01 validation,
+// beyond the User-Agent given to NewValidationAuthorityImpl.
+type HTTPConfig struct {
+	// Accept is the Accept header sent with every request. Defaults to "*/*".
+	Accept string
+	// Headers are extra headers sent with every request.
+	Headers map[string]string
+	// KeepAlive allows a connection to be reused when a request is redirected
+	// to the same host, instead of closing it after every request.
+	KeepAlive bool
+	// MaxResponseSize is the largest response body accepted, in bytes.
+	// Defaults to maxResponseSize.
+	MaxResponseSize int64
+}
+
+// HTTPOverride replaces the User-Agent of, and adds headers to, the HTTP-01
+// requests made for a single validation. It's meant for testing how a
+// server responds to different clients.
+type HTTPOverride struct {
+	UserAgent string
+	Headers   map[string]string
+}
+
+type httpOverrideKey struct{}
+
+// WithHTTPOverride returns a context that applies o to the HTTP-01 requests
+// made while validating with it.
+func WithHTTPOverride(ctx context.Context, o HTTPOverride) context.Context {
+	return context.WithValue(ctx, httpOverrideKey{}, o)
+}
+
+// setHeaders sets the configured headers of an HTTP-01 request, and any
+// overridden by ctx, returning the User-Agent and Accept headers used.
+func (va *ValidationAuthorityImpl) setHeaders(ctx context.Context, req *http.Request) (string, string) {
+	for name, value := range va.HTTP.Headers {
+		req.Header.Set(name, value)
+	}
+	userAgent := va.userAgent
+	if o, ok := ctx.Value(httpOverrideKey{}).(HTTPOverride); ok {
+		for name, value := range o.Headers {
+			req.Header.Set(name, value)
+		}
+		if o.UserAgent != "" {
+			userAgent = o.UserAgent
+		}
+	}
+
+	// Some of our users use mod_security. Mod_security sees a lack of Accept
+	// headers as bot behavior and rejects requests. While this is a bug in
+	// mod_security's rules (given that the HTTP specs disagree with that
+	// requirement), we add the Accept header now in order to fix our
+	// mod_security users' mysterious breakages. See
+	// <https://github.com/SpiderLabs/owasp-modsecurity-crs/issues/265> and
+	// <https://github.com/letsencrypt/boulder/issues/1019>.
+	accept := va.HTTP.Accept
+	if accept == "" {
+		accept = "*/*"
+	}
+	req.Header.Set("Accept", accept)
+	if userAgent != "" {
+		req.Header["User-Agent"] = []string{userAgent}
+	}
+	return userAgent, accept
+}
+
 // Used for audit logging
 type verificationRequestEvent struct {
 	ID                string                  `json:",omitempty"`
@@ -185,18 +256,22 @@ func (va ValidationAuthorityImpl) getAddr(ctx context.Context, hostname string)
 // inner `record` member populated by the `resolveAndConstructDialer` function.
 type http01Dialer struct {
 	record      core.ValidationRecord
+	source      source
 	stats       metrics.Scope
 	dialerCount int
 }
 
 // realDialer is used to create a true `net.Dialer` that can be used once an IP
-// address to connect to is determined. It increments the `dialerCount` integer
-// to track how many "fresh" dialer instances have been created during a `Dial`
-// for testing purposes.
-func (d *http01Dialer) realDialer() *net.Dialer {
+// address to connect to is determined. The dialer is bound to the source
+// address for the target's IP family, which is noted in the validation record.
+// It increments the `dialerCount` integer to track how many "fresh" dialer
+// instances have been created during a `Dial` for testing purposes.
+func (d *http01Dialer) realDialer(target net.IP) *net.Dialer {
 	// Record that we created a new instance of a real net.Dialer
 	d.dialerCount++
-	return &net.Dialer{Timeout: singleDialTimeout}
+	dialer, sourceAddr := d.source.dialer(target)
+	d.record.SourceAddress = sourceAddr
+	return dialer
 }
 
 // Dial processes the IP addresses from the inner validation record, using
@@ -219,7 +294,7 @@ func (d *http01Dialer) Dial(_, _ string) (net.Conn, error) {
 		}
 		address := net.JoinHostPort(addresses[0].String(), d.record.Port)
 		d.record.AddressUsed = addresses[0]
-		realDialer = d.realDialer()
+		realDialer = d.realDialer(addresses[0])
 		return realDialer.Dial("tcp", address)
 	}
 
@@ -228,7 +303,7 @@ func (d *http01Dialer) Dial(_, _ string) (net.Conn, error) {
 	if features.Enabled(features.IPv6First) && len(v6) > 0 {
 		address := net.JoinHostPort(v6[0].String(), d.record.Port)
 		d.record.AddressUsed = v6[0]
-		realDialer = d.realDialer()
+		realDialer = d.realDialer(v6[0])
 		conn, err := realDialer.Dial("tcp", address)
 
 		// If there is no error, return immediately
@@ -257,7 +332,7 @@ func (d *http01Dialer) Dial(_, _ string) (net.Conn, error) {
 	// talking to the first IPv6 address, try the first IPv4 address
 	address := net.JoinHostPort(v4[0].String(), d.record.Port)
 	d.record.AddressUsed = v4[0]
-	realDialer = d.realDialer()
+	realDialer = d.realDialer(v4[0])
 	return realDialer.Dial("tcp", address)
 }
 
@@ -275,14 +350,16 @@ func availableAddresses(rec core.ValidationRecord) (v4 []net.IP, v6 []net.IP) {
 }
 
 // resolveAndConstructDialer gets the preferred address using va.getAddr and returns
-// the chosen address and dialer for that address and correct port.
-func (va *ValidationAuthorityImpl) resolveAndConstructDialer(ctx context.Context, name string, port int) (http01Dialer, *probs.ProblemDetails) {
+// the chosen address and dialer for that address and correct port, connecting
+// from src.
+func (va *ValidationAuthorityImpl) resolveAndConstructDialer(ctx context.Context, name string, port int, src source) (http01Dialer, *probs.ProblemDetails) {
 	d := http01Dialer{
 		record: core.ValidationRecord{
 			Hostname: name,
 			Port:     strconv.Itoa(port),
 		},
-		stats: va.stats,
+		source: src,
+		stats:  va.stats,
 	}
 
 	addr, allAddrs, err := va.getAddr(ctx, name)
@@ -326,12 +403,15 @@ func (va *ValidationAuthorityImpl) fetchHTTP(ctx context.Context, identifier cor
 		return nil, nil, probs.Malformed("URL provided for HTTP was invalid")
 	}
 
-	if va.userAgent != "" {
-		httpRequest.Header["User-Agent"] = []string{va.userAgent}
-	}
+	userAgent, accept := va.setHeaders(ctx, httpRequest)
 
-	dialer, prob := va.resolveAndConstructDialer(ctx, host, port)
+	// The same source addresses are used for the initial request and any
+	// redirects
+	src := va.SourcePool.take()
+	dialer, prob := va.resolveAndConstructDialer(ctx, host, port, src)
 	dialer.record.URL = url.String()
+	dialer.record.UserAgent = userAgent
+	dialer.record.Accept = accept
 	// Start with an empty validation record list - we will add a record after
 	// each dialer.Dial()
 	var validationRecords []core.ValidationRecord
@@ -343,36 +423,21 @@ func (va *ValidationAuthorityImpl) fetchHTTP(ctx context.Context, identifier cor
 		// We are talking to a client that does not yet have a certificate,
 		// so we accept a temporary, invalid one.
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		// We don't expect to make multiple requests to a client, so close
-		// connection immediately.
-		DisableKeepAlives: true,
+		// Unless configured otherwise we don't expect to make multiple
+		// requests to a client, so close connection immediately.
+		DisableKeepAlives: !va.HTTP.KeepAlive,
 		// Intercept Dial in order to connect to the IP address we
 		// select.
 		Dial: dialer.Dial,
 	}
-
-	// Some of our users use mod_security. Mod_security sees a lack of Accept
-	// headers as bot behavior and rejects requests. While this is a bug in
-	// mod_security's rules (given that the HTTP specs disagree with that
-	// requirement), we add the Accept header now in order to fix our
-	// mod_security users' mysterious breakages. See
-	// <https://github.com/SpiderLabs/owasp-modsecurity-crs/issues/265> and
-	// <https://github.com/letsencrypt/boulder/issues/1019>. This was done
-	// because it's a one-line fix with no downside. We're not likely to want to
-	// do many more things to satisfy misunderstandings around HTTP.
-	httpRequest.Header.Set("Accept", "*/*")
+	defer tr.CloseIdleConnections()
 
 	logRedirect := func(req *http.Request, via []*http.Request) error {
 		if len(validationRecords) >= maxRedirect {
 			return fmt.Errorf("Too many redirects")
 		}
 
-		// Set Accept header for mod_security (see the other place the header is
-		// set)
-		req.Header.Set("Accept", "*/*")
-		if va.userAgent != "" {
-			req.Header["User-Agent"] = []string{va.userAgent}
-		}
+		userAgent, accept := va.setHeaders(ctx, req)
 
 		urlHost = req.URL.Host
 		reqHost := req.URL.Host
@@ -394,8 +459,10 @@ func (va *ValidationAuthorityImpl) fetchHTTP(ctx context.Context, identifier cor
 			reqPort = va.httpPort
 		}
 
-		dialer, err := va.resolveAndConstructDialer(ctx, reqHost, reqPort)
+		dialer, err := va.resolveAndConstructDialer(ctx, reqHost, reqPort, src)
 		dialer.record.URL = req.URL.String()
+		dialer.record.UserAgent = userAgent
+		dialer.record.Accept = accept
 		// A subsequent dialing from a redirect means adding another validation
 		// record
 		validationRecords = append(validationRecords, dialer.record)
@@ -419,7 +486,11 @@ func (va *ValidationAuthorityImpl) fetchHTTP(ctx context.Context, identifier cor
 		return nil, validationRecords, detailedError(err)
 	}
 
-	body, err := ioutil.ReadAll(&io.LimitedReader{R: httpResponse.Body, N: maxResponseSize})
+	maxSize := va.HTTP.MaxResponseSize
+	if maxSize == 0 {
+		maxSize = maxResponseSize
+	}
+	body, err := ioutil.ReadAll(&io.LimitedReader{R: httpResponse.Body, N: maxSize})
 	closeErr := httpResponse.Body.Close()
 	if err == nil {
 		err = closeErr
@@ -429,8 +500,8 @@ func (va *ValidationAuthorityImpl) fetchHTTP(ctx context.Context, identifier cor
 		return nil, validationRecords, probs.Unauthorized(fmt.Sprintf("Error reading HTTP response body: %v", err))
 	}
 	// io.LimitedReader will silently truncate a Reader so if the
-	// resulting payload is the same size as maxResponseSize fail
-	if len(body) >= maxResponseSize {
+	// resulting payload is the same size as maxSize fail
+	if int64(len(body)) >= maxSize {
 		return nil, validationRecords, probs.Unauthorized(fmt.Sprintf("Invalid response from %s: \"%s\"", url.String(), body))
 	}
 
@@ -470,6 +541,7 @@ func (va *ValidationAuthorityImpl) tryGetTLSSNICerts(ctx context.Context, identi
 		return nil, validationRecords, problem
 	}
 	thisRecord := &validationRecords[0]
+	src := va.SourcePool.take()
 
 	// Split the available addresses into v4 and v6 addresses
 	v4, v6 := availableAddresses(*thisRecord)
@@ -486,7 +558,9 @@ func (va *ValidationAuthorityImpl) tryGetTLSSNICerts(ctx context.Context, identi
 	if !features.Enabled(features.IPv6First) {
 		address := net.JoinHostPort(addresses[0].String(), thisRecord.Port)
 		thisRecord.AddressUsed = addresses[0]
-		certs, err := va.getTLSSNICerts(address, identifier, challenge, zName)
+		dialer, sourceAddr := src.dialer(addresses[0])
+		thisRecord.SourceAddress = sourceAddr
+		certs, err := va.getTLSSNICerts(dialer, address, identifier, challenge, zName)
 		return certs, validationRecords, err
 	}
 
@@ -495,8 +569,10 @@ func (va *ValidationAuthorityImpl) tryGetTLSSNICerts(ctx context.Context, identi
 	if features.Enabled(features.IPv6First) && len(v6) > 0 {
 		address := net.JoinHostPort(v6[0].String(), thisRecord.Port)
 		thisRecord.AddressUsed = v6[0]
+		dialer, sourceAddr := src.dialer(v6[0])
+		thisRecord.SourceAddress = sourceAddr
 
-		certs, err := va.getTLSSNICerts(address, identifier, challenge, zName)
+		certs, err := va.getTLSSNICerts(dialer, address, identifier, challenge, zName)
 
 		// If there is no error, return immediately
 		if err == nil {
@@ -525,7 +601,9 @@ func (va *ValidationAuthorityImpl) tryGetTLSSNICerts(ctx context.Context, identi
 	// talking to the first IPv6 address, try the first IPv4 address
 	address := net.JoinHostPort(v4[0].String(), thisRecord.Port)
 	thisRecord.AddressUsed = v4[0]
-	certs, err := va.getTLSSNICerts(address, identifier, challenge, zName)
+	dialer, sourceAddr := src.dialer(v4[0])
+	thisRecord.SourceAddress = sourceAddr
+	certs, err := va.getTLSSNICerts(dialer, address, identifier, challenge, zName)
 	return certs, validationRecords, err
 }
 
@@ -553,9 +631,9 @@ func (va *ValidationAuthorityImpl) validateTLSSNI01WithZName(ctx context.Context
 	return validationRecords, probs.Unauthorized(errText)
 }
 
-func (va *ValidationAuthorityImpl) getTLSSNICerts(hostPort string, identifier core.AcmeIdentifier, challenge core.Challenge, zName string) ([]*x509.Certificate, *probs.ProblemDetails) {
+func (va *ValidationAuthorityImpl) getTLSSNICerts(dialer *net.Dialer, hostPort string, identifier core.AcmeIdentifier, challenge core.Challenge, zName string) ([]*x509.Certificate, *probs.ProblemDetails) {
 	va.log.Info(fmt.Sprintf("%s [%s] Attempting to validate for %s %s", challenge.Type, identifier, hostPort, zName))
-	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: singleDialTimeout}, "tcp", hostPort, &tls.Config{
+	conn, err := tls.DialWithDialer(dialer, "tcp", hostPort, &tls.Config{
 		ServerName:         zName,
 		InsecureSkipVerify: true,
 	})
@@ -840,6 +918,47 @@ func (va *ValidationAuthorityImpl) performRemoteValidation(ctx context.Context,
 	}).Observe(va.clk.Since(s).Seconds())
 }
 
+// withIdentifier returns a copy of prob with its Identifier set to domain, so
+// that the problem says which name failed validation.
+func withIdentifier(prob *probs.ProblemDetails, domain string) *probs.ProblemDetails {
+	result := *prob
+	ident := identifier.DNSIdentifier(domain)
+	result.Identifier = &ident
+	return &result
+}
+
+// ReplayValidation checks a challenge again, with the same CAA and Safe
+// Browsing checks as PerformValidation but without consulting remote VAs.
+// It's used by support staff to reproduce validation failures reported by
+// subscribers, so the result is only logged and returned, and the caller
+// doesn't store it. If userAgent isn't empty it's used for HTTP-01 requests
+// instead of the configured User-Agent.
+func (va *ValidationAuthorityImpl) ReplayValidation(ctx context.Context, domain string, challenge core.Challenge, userAgent string) ([]core.ValidationRecord, error) {
+	logEvent := verificationRequestEvent{
+		Hostname:    domain,
+		RequestTime: va.clk.Now(),
+	}
+	if userAgent != "" {
+		ctx = WithHTTPOverride(ctx, HTTPOverride{UserAgent: userAgent})
+	}
+	records, prob := va.validateChallengeAndIdentifier(
+		ctx,
+		core.AcmeIdentifier{Type: "dns", Value: domain},
+		challenge)
+	logEvent.ValidationRecords = records
+	logEvent.Challenge = challenge
+	logEvent.ResponseTime = va.clk.Now()
+	if prob != nil {
+		prob = withIdentifier(prob, domain)
+		logEvent.Error = prob.Error()
+	}
+	va.log.AuditObject("Validation replay result", logEvent)
+	if prob == nil {
+		return records, nil
+	}
+	return records, prob
+}
+
 // PerformValidation validates the given challenge. It always returns a list of
 // validation records, even when it also returns an error.
 //
@@ -873,12 +992,14 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, domain
 	}
 
 	if prob != nil {
+		prob = withIdentifier(prob, domain)
 		challenge.Status = core.StatusInvalid
 		challenge.Error = prob
 		logEvent.Error = prob.Error()
 	} else if remoteError != nil {
 		prob = <-remoteError
 		if prob != nil {
+			prob = withIdentifier(prob, domain)
 			challenge.Status = core.StatusInvalid
 			challenge.Error = prob
 			logEvent.Error = prob.Error()
@@ -460,6 +460,50 @@ func TestHTTPRedirectUserAgent(t *testing.T) {
 	}
 }
 
+func TestHTTPHeaders(t *testing.T) {
+	chall := core.HTTPChallenge01()
+	setChallengeToken(&chall, expectedToken)
+
+	var got http.Header
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		ch := core.Challenge{Token: expectedToken}
+		keyAuthz, _ := ch.ExpectedKeyAuthorization(accountKey)
+		fmt.Fprint(w, keyAuthz)
+	}))
+	defer hs.Close()
+	va, _ := setup(hs, 0)
+	va.HTTP = HTTPConfig{
+		Accept:  "text/plain",
+		Headers: map[string]string{"X-Validation": "boulder"},
+	}
+
+	records, prob := va.validateHTTP01(ctx, dnsi("localhost"), chall)
+	test.Assert(t, prob == nil, fmt.Sprintf("Validation failed: %s", prob))
+	test.AssertEquals(t, got.Get("User-Agent"), "user agent 1.0")
+	test.AssertEquals(t, got.Get("Accept"), "text/plain")
+	test.AssertEquals(t, got.Get("X-Validation"), "boulder")
+	test.AssertEquals(t, records[0].UserAgent, "user agent 1.0")
+	test.AssertEquals(t, records[0].Accept, "text/plain")
+
+	// A context can override the User-Agent and headers of one validation
+	overrideCtx := WithHTTPOverride(ctx, HTTPOverride{
+		UserAgent: "testing 1.0",
+		Headers:   map[string]string{"X-Validation": "test"},
+	})
+	records, prob = va.validateHTTP01(overrideCtx, dnsi("localhost"), chall)
+	test.Assert(t, prob == nil, fmt.Sprintf("Validation failed: %s", prob))
+	test.AssertEquals(t, got.Get("User-Agent"), "testing 1.0")
+	test.AssertEquals(t, got.Get("X-Validation"), "test")
+	test.AssertEquals(t, records[0].UserAgent, "testing 1.0")
+
+	// Responses larger than the configured maximum are rejected
+	va.HTTP.MaxResponseSize = 16
+	_, prob = va.validateHTTP01(ctx, dnsi("localhost"), chall)
+	test.Assert(t, prob != nil, "Validation succeeded with a response larger than the maximum")
+	test.AssertEquals(t, prob.Type, probs.UnauthorizedProblem)
+}
+
 func getPort(hs *httptest.Server) int {
 	url, err := url.Parse(hs.URL)
 	if err != nil {
@@ -871,6 +915,39 @@ func TestPerformValidationValid(t *testing.T) {
 	}
 }
 
+func TestReplayValidation(t *testing.T) {
+	chall := core.HTTPChallenge01()
+	setChallengeToken(&chall, expectedToken)
+
+	var gotUA string
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.UserAgent()
+		ch := core.Challenge{Token: expectedToken}
+		keyAuthz, _ := ch.ExpectedKeyAuthorization(accountKey)
+		fmt.Fprint(w, keyAuthz)
+	}))
+	defer hs.Close()
+	va, mockLog := setup(hs, 0)
+
+	records, err := va.ReplayValidation(ctx, "localhost", chall, "support")
+	test.AssertNotError(t, err, "Replayed validation failed")
+	test.AssertEquals(t, gotUA, "support")
+	test.AssertEquals(t, records[0].UserAgent, "support")
+	test.AssertEquals(t, len(mockLog.GetAllMatching(`Validation replay result`)), 1)
+
+	// Replays aren't counted as validations
+	samples := test.CountHistogramSamples(va.metrics.validationTime.With(prometheus.Labels{
+		"type":   "http-01",
+		"result": "valid",
+	}))
+	test.AssertEquals(t, samples, 0)
+
+	chalDNS := createChallenge(core.ChallengeTypeDNS01)
+	_, err = va.ReplayValidation(ctx, "wrong-dns01.com", chalDNS, "")
+	test.AssertError(t, err, "Replayed validation with the wrong TXT record succeeded")
+	test.AssertEquals(t, err.Error(), "unauthorized :: Incorrect TXT record \"a\" found at _acme-challenge.wrong-dns01.com")
+}
+
 // TestPerformValidationWildcard tests that the VA properly strips the `*.`
 // prefix from a wildcard name provided to the PerformValidation function.
 func TestPerformValidationWildcard(t *testing.T) {
@@ -1164,7 +1241,7 @@ func TestHTTP01DialerFallback(t *testing.T) {
 
 	// Create a test dialer for the dual homed host. There is only an IPv4 httpSrv
 	// so the IPv6 address returned in the AAAA record will always fail.
-	d, _ := va.resolveAndConstructDialer(context.Background(), "ipv4.and.ipv6.localhost", va.httpPort)
+	d, _ := va.resolveAndConstructDialer(context.Background(), "ipv4.and.ipv6.localhost", va.httpPort, source{})
 
 	// Try to dial the dialer
 	_, dialProb := d.Dial("", "ipv4.and.ipv6.localhost")
@@ -1,16 +1,24 @@
 package web
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"golang.org/x/net/context"
 
+	"github.com/letsencrypt/boulder/core"
 	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/tracing"
 )
 
 type RequestEvent struct {
+	// RequestID identifies the request in the logs of every component that
+	// handles it.
+	RequestID string    `json:",omitempty"`
+	TraceID   string    `json:",omitempty"`
 	RealIP    string    `json:",omitempty"`
 	Endpoint  string    `json:",omitempty"`
 	Method    string    `json:",omitempty"`
@@ -30,7 +38,8 @@ func (e *RequestEvent) AddError(msg string, args ...interface{}) {
 type WFEHandlerFunc func(context.Context, *RequestEvent, http.ResponseWriter, *http.Request)
 
 func (f WFEHandlerFunc) ServeHTTP(e *RequestEvent, w http.ResponseWriter, r *http.Request) {
-	ctx := context.TODO()
+	ctx := core.WithRequestID(context.TODO(), e.RequestID)
+	ctx = tracing.ContextWithSpan(ctx, tracing.FromContext(r.Context()))
 	f(ctx, e, w, r)
 }
 
@@ -65,6 +74,7 @@ func (r *responseWriterWithStatus) WriteHeader(code int) {
 
 func (th *TopHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	logEvent := &RequestEvent{
+		RequestID: core.NewRequestID(),
 		RealIP:    r.Header.Get("X-Real-IP"),
 		Method:    r.Method,
 		UserAgent: r.Header.Get("User-Agent"),
@@ -72,9 +82,26 @@ func (th *TopHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	defer th.logEvent(logEvent)
 
+	// Traces start at the WFE. Trace context sent by clients isn't trusted.
+	ctx, span := tracing.StartSpan(context.Background(), "HTTP "+r.Method, tracing.KindServer)
+	if span != nil {
+		logEvent.TraceID = hex.EncodeToString(span.TraceID[:])
+		span.SetAttribute("request_id", logEvent.RequestID)
+		r = r.WithContext(ctx)
+	}
+
 	rwws := &responseWriterWithStatus{w, 0}
 	defer func() {
 		logEvent.Code = rwws.code
+		if span != nil {
+			span.SetAttribute("http.route", logEvent.Endpoint)
+			span.SetAttribute("http.status_code", strconv.Itoa(rwws.code))
+			var err error
+			if rwws.code >= 500 {
+				err = fmt.Errorf("HTTP status %d", rwws.code)
+			}
+			span.Finish(err)
+		}
 	}()
 	th.wfe.ServeHTTP(logEvent, rwws, r)
 }
@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/test"
 )
@@ -27,3 +30,26 @@ func TestLogCode(t *testing.T) {
 	th.ServeHTTP(httptest.NewRecorder(), req)
 	test.AssertEquals(t, 1, len(mockLog.GetAllMatching(`"Code":201`)))
 }
+
+type ctxHandler struct {
+	requestID string
+}
+
+func (h *ctxHandler) ServeHTTP(e *RequestEvent, w http.ResponseWriter, r *http.Request) {
+	WFEHandlerFunc(func(ctx context.Context, e *RequestEvent, w http.ResponseWriter, r *http.Request) {
+		h.requestID = core.RequestID(ctx)
+	}).ServeHTTP(e, w, r)
+}
+
+func TestRequestID(t *testing.T) {
+	mockLog := blog.UseMock()
+	h := &ctxHandler{}
+	th := NewTopHandler(mockLog, h)
+	req, err := http.NewRequest("GET", "/", &bytes.Reader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	th.ServeHTTP(httptest.NewRecorder(), req)
+	test.Assert(t, h.requestID != "", "handler's context had no request ID")
+	test.AssertEquals(t, 1, len(mockLog.GetAllMatching(`"RequestID":"`+h.requestID+`"`)))
+}
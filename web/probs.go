@@ -8,6 +8,28 @@ import (
 )
 
 func problemDetailsForBoulderError(err *berrors.BoulderError, msg string) *probs.ProblemDetails {
+	prob := problemDetailsForBoulderErrorType(err, msg)
+	if len(err.SubErrors) > 0 {
+		subProbs := make([]probs.SubProblemDetails, len(err.SubErrors))
+		for i, subErr := range err.SubErrors {
+			subProb := problemDetailsForBoulderErrorType(subErr.BoulderError, "")
+			detail := subErr.Detail
+			if subProb.Type == probs.ServerInternalProblem {
+				detail = ""
+			}
+			subProbs[i] = probs.SubProblemDetails{
+				Type:       subProb.Type,
+				Detail:     detail,
+				Identifier: subErr.Identifier,
+				Code:       subProb.Code,
+			}
+		}
+		prob = prob.WithSubProblems(subProbs)
+	}
+	return prob
+}
+
+func problemDetailsForBoulderErrorType(err *berrors.BoulderError, msg string) *probs.ProblemDetails {
 	switch err.Type {
 	case berrors.Malformed:
 		return probs.Malformed(fmt.Sprintf("%s :: %s", msg, err))
@@ -26,9 +48,21 @@ func problemDetailsForBoulderError(err *berrors.BoulderError, msg string) *probs
 	case berrors.InvalidEmail:
 		return probs.InvalidEmail(fmt.Sprintf("%s :: %s", msg, err))
 	case berrors.WrongAuthorizationState:
-		return probs.Malformed(fmt.Sprintf("%s :: %s", msg, err))
+		prob := probs.Malformed(fmt.Sprintf("%s :: %s", msg, err))
+		prob.Code = "wrongAuthorizationState"
+		return prob
 	case berrors.CAA:
 		return probs.CAA(fmt.Sprintf("%s :: %s", msg, err))
+	case berrors.BadPublicKey:
+		return probs.BadPublicKey(fmt.Sprintf("%s :: %s", msg, err))
+	case berrors.BadCSR:
+		return probs.BadCSR(fmt.Sprintf("%s :: %s", msg, err))
+	case berrors.Timeout:
+		return probs.ServerTimeout(fmt.Sprintf("%s :: %s", msg, err))
+	case berrors.CTUnavailable:
+		prob := probs.ServerTimeout(fmt.Sprintf("%s :: %s", msg, err))
+		prob.Code = "ctUnavailable"
+		return prob
 	default:
 		// Internal server error messages may include sensitive data, so we do
 		// not include it.
@@ -52,3 +86,21 @@ func ProblemDetailsForError(err error, msg string) *probs.ProblemDetails {
 		return probs.ServerInternal(msg)
 	}
 }
+
+// ProblemDetailsForKeyError returns a ProblemDetails with the given detail for
+// a public key rejected by the key policy. Keys that are known to be weak or
+// compromised get the specific BadPublicKey problem type, all other rejected
+// keys are Malformed with the unacceptableKey code. If the key couldn't be
+// checked at all the problem is a ServerInternal one without the detail, which
+// may include sensitive data.
+func ProblemDetailsForKeyError(err error, detail string) *probs.ProblemDetails {
+	if berrors.Is(err, berrors.BadPublicKey) {
+		return probs.BadPublicKey(detail)
+	}
+	if berrors.Is(err, berrors.InternalServer) {
+		return probs.ServerInternal("Error checking public key")
+	}
+	prob := probs.Malformed(detail)
+	prob.Code = "unacceptableKey"
+	return prob
+}
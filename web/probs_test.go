@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/probs"
 	"github.com/letsencrypt/boulder/test"
 )
@@ -36,6 +37,10 @@ func TestProblemDetailsFromError(t *testing.T) {
 		{berrors.RateLimitError(detailMsg), 429, probs.RateLimitedProblem, fullDetail + ": see https://letsencrypt.org/docs/rate-limits/"},
 		{berrors.InvalidEmailError(detailMsg), 400, probs.InvalidEmailProblem, fullDetail},
 		{berrors.RejectedIdentifierError(detailMsg), 400, probs.RejectedIdentifierProblem, fullDetail},
+		{berrors.BadPublicKeyError(detailMsg), 400, probs.BadPublicKeyProblem, fullDetail},
+		{berrors.BadCSRError(detailMsg), 400, probs.BadCSRProblem, fullDetail},
+		{berrors.TimeoutError(detailMsg), 503, probs.ServerTimeoutProblem, fullDetail},
+		{berrors.CTUnavailableError(detailMsg), 503, probs.ServerTimeoutProblem, fullDetail},
 	}
 	for _, c := range testCases {
 		p := ProblemDetailsForError(c.err, errMsg)
@@ -58,3 +63,47 @@ func TestProblemDetailsFromError(t *testing.T) {
 	p := ProblemDetailsForError(expected, "k")
 	test.AssertDeepEquals(t, expected, p)
 }
+
+func TestProblemDetailsForKeyError(t *testing.T) {
+	p := ProblemDetailsForKeyError(berrors.BadPublicKeyError("weak key"), "bad key :: weak key")
+	test.AssertEquals(t, p.Type, probs.BadPublicKeyProblem)
+	test.AssertEquals(t, p.Detail, "bad key :: weak key")
+	test.AssertEquals(t, p.HTTPStatus, 400)
+
+	p = ProblemDetailsForKeyError(berrors.MalformedError("key too small"), "key too small")
+	test.AssertEquals(t, p.Type, probs.MalformedProblem)
+	test.AssertEquals(t, p.Detail, "key too small")
+
+	p = ProblemDetailsForKeyError(berrors.InternalServerError("checking whether public key is blocked: SA unavailable"), "SA unavailable")
+	test.AssertEquals(t, p.Type, probs.ServerInternalProblem)
+	test.AssertEquals(t, p.Detail, "Error checking public key")
+}
+
+func TestProblemDetailsForSubErrors(t *testing.T) {
+	err := (&berrors.BoulderError{
+		Type:   berrors.RejectedIdentifier,
+		Detail: "Policy forbids issuing for 2 names",
+	}).WithSubErrors([]berrors.SubBoulderError{
+		{
+			BoulderError: &berrors.BoulderError{Type: berrors.RejectedIdentifier, Detail: "forbidden"},
+			Identifier:   identifier.DNSIdentifier("example.com"),
+		},
+		{
+			BoulderError: &berrors.BoulderError{Type: berrors.InternalServer, Detail: "secret"},
+			Identifier:   identifier.DNSIdentifier("example.net"),
+		},
+	})
+	p := ProblemDetailsForError(err, "Error creating new order")
+	test.AssertEquals(t, p.Type, probs.RejectedIdentifierProblem)
+	test.AssertDeepEquals(t, p.SubProblems, []probs.SubProblemDetails{
+		{
+			Type:       probs.RejectedIdentifierProblem,
+			Detail:     "forbidden",
+			Identifier: identifier.DNSIdentifier("example.com"),
+		},
+		{
+			Type:       probs.ServerInternalProblem,
+			Identifier: identifier.DNSIdentifier("example.net"),
+		},
+	})
+}
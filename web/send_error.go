@@ -13,11 +13,16 @@ import (
 //  - Adds both the external and the internal error to a RequestEvent.
 //  - If the ProblemDetails provided is a ServerInternalProblem, audit logs the
 //    internal error.
-//  - Prefixes the Type field of the ProblemDetails with a namespace.
+//  - Prefixes the Type field of the ProblemDetails, and of its subproblems,
+//    with a namespace.
+//  - Sets the Code of the ProblemDetails, and of its subproblems, to the Type
+//    if it isn't set already, and if docsURL isn't empty sets Documentation
+//    to docsURL followed by the Code.
 //  - Sends an HTTP response containing the error and an error code to the user.
 func SendError(
 	log blog.Logger,
 	namespace string,
+	docsURL string,
 	response http.ResponseWriter,
 	logEvent *RequestEvent,
 	prob *probs.ProblemDetails,
@@ -42,7 +47,26 @@ func SendError(
 		}
 	}
 
+	if prob.Code == "" {
+		prob.Code = string(prob.Type)
+	}
+	if docsURL != "" {
+		prob.Documentation = docsURL + prob.Code
+	}
 	prob.Type = probs.ProblemType(namespace) + prob.Type
+	if len(prob.SubProblems) > 0 {
+		// Copy the subproblems so that they aren't prefixed twice if prob
+		// is sent again.
+		subProbs := make([]probs.SubProblemDetails, len(prob.SubProblems))
+		for i, subProb := range prob.SubProblems {
+			if subProb.Code == "" {
+				subProb.Code = string(subProb.Type)
+			}
+			subProb.Type = probs.ProblemType(namespace) + subProb.Type
+			subProbs[i] = subProb
+		}
+		prob.SubProblems = subProbs
+	}
 	problemDoc, err := json.MarshalIndent(prob, "", "  ")
 	if err != nil {
 		log.AuditErr(fmt.Sprintf("Could not marshal error message: %s - %+v", err, prob))
@@ -0,0 +1,41 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSendErrorCodes(t *testing.T) {
+	prob := probs.Malformed("bad request")
+	prob.SubProblems = []probs.SubProblemDetails{
+		{Type: probs.RejectedIdentifierProblem, Detail: "forbidden"},
+	}
+	rw := httptest.NewRecorder()
+	SendError(blog.NewMock(), "urn:acme:error:", "https://example.com/docs/errors/", rw, &RequestEvent{}, prob, nil)
+	test.AssertEquals(t, rw.Code, 400)
+
+	var sent probs.ProblemDetails
+	err := json.Unmarshal(rw.Body.Bytes(), &sent)
+	test.AssertNotError(t, err, "Failed to unmarshal problem document")
+	test.AssertEquals(t, string(sent.Type), "urn:acme:error:malformed")
+	test.AssertEquals(t, sent.Code, "malformed")
+	test.AssertEquals(t, sent.Documentation, "https://example.com/docs/errors/malformed")
+	test.AssertEquals(t, len(sent.SubProblems), 1)
+	test.AssertEquals(t, string(sent.SubProblems[0].Type), "urn:acme:error:rejectedIdentifier")
+	test.AssertEquals(t, sent.SubProblems[0].Code, "rejectedIdentifier")
+
+	// A code set by the problem's constructor is kept, and without a docs URL
+	// there is no documentation link.
+	rw = httptest.NewRecorder()
+	SendError(blog.NewMock(), "urn:acme:error:", "", rw, &RequestEvent{}, probs.NotFound("no such thing"), nil)
+	sent = probs.ProblemDetails{}
+	err = json.Unmarshal(rw.Body.Bytes(), &sent)
+	test.AssertNotError(t, err, "Failed to unmarshal problem document")
+	test.AssertEquals(t, sent.Code, "notFound")
+	test.AssertEquals(t, sent.Documentation, "")
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// contentTypes holds content types for extensions that the mime package
+// doesn't reliably know about.
+var contentTypes = map[string]string{
+	".pem": "application/pem-certificate-chain",
+	".der": "application/pkix-cert",
+	".crt": "application/pkix-cert",
+	".cer": "application/pkix-cert",
+	".pdf": "application/pdf",
+}
+
+// StaticAsset is a file, such as a subscriber agreement or an intermediate
+// certificate, that is read into memory at startup and served verbatim.
+type StaticAsset struct {
+	Content     []byte
+	ContentType string
+	// ETag is the quoted hex SHA-256 digest of Content.
+	ETag   string
+	MaxAge time.Duration
+}
+
+// LoadStaticAsset reads the file at path and verifies that its hex-encoded
+// SHA-256 digest matches expectedSHA256, so that a truncated or tampered file
+// is never served. If contentType is empty it is inferred from the file
+// extension.
+func LoadStaticAsset(path, expectedSHA256, contentType string, maxAge time.Duration) (*StaticAsset, error) {
+	if expectedSHA256 == "" {
+		return nil, fmt.Errorf("no SHA-256 digest configured for static asset %q", path)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(content)
+	hexDigest := hex.EncodeToString(digest[:])
+	if !strings.EqualFold(hexDigest, expectedSHA256) {
+		return nil, fmt.Errorf("static asset %q has SHA-256 digest %s, expected %s", path, hexDigest, expectedSHA256)
+	}
+	if contentType == "" {
+		ext := strings.ToLower(filepath.Ext(path))
+		contentType = contentTypes[ext]
+		if contentType == "" {
+			contentType = mime.TypeByExtension(ext)
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	return &StaticAsset{
+		Content:     content,
+		ContentType: contentType,
+		ETag:        fmt.Sprintf("%q", hexDigest),
+		MaxAge:      maxAge,
+	}, nil
+}
+
+// StaticAssets maps names to the assets served under them.
+type StaticAssets map[string]*StaticAsset
+
+// Serve is a WFEHandlerFunc that responds with the asset named by the request
+// path, which is expected to have had the handler's prefix stripped. Caching
+// headers allow clients and CDNs to cache assets for their configured
+// lifetime and to revalidate them using the ETag.
+func (assets StaticAssets) Serve(ctx context.Context, logEvent *RequestEvent, response http.ResponseWriter, request *http.Request) {
+	asset, ok := assets[request.URL.Path]
+	if !ok {
+		logEvent.AddError("unknown static asset %q", request.URL.Path)
+		http.NotFound(response, request)
+		return
+	}
+
+	response.Header().Set("ETag", asset.ETag)
+	response.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(asset.MaxAge.Seconds())))
+	if request.Header.Get("If-None-Match") == asset.ETag {
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	response.Header().Set("Content-Type", asset.ContentType)
+	response.WriteHeader(http.StatusOK)
+	if _, err := response.Write(asset.Content); err != nil {
+		logEvent.AddError("unable to write static asset response: %s", err)
+	}
+}
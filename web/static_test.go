@@ -0,0 +1,80 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func writeAsset(t *testing.T, dir, name string, content []byte) (string, string) {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, content, 0644)
+	test.AssertNotError(t, err, "Failed to write asset")
+	digest := sha256.Sum256(content)
+	return path, hex.EncodeToString(digest[:])
+}
+
+func TestLoadStaticAsset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	test.AssertNotError(t, err, "Failed to create temp dir")
+	defer os.RemoveAll(dir)
+
+	path, digest := writeAsset(t, dir, "terms.pdf", []byte("%PDF-1.4 terms"))
+
+	asset, err := LoadStaticAsset(path, digest, "", time.Hour)
+	test.AssertNotError(t, err, "Failed to load asset")
+	test.AssertEquals(t, asset.ContentType, "application/pdf")
+	test.AssertEquals(t, asset.ETag, `"`+digest+`"`)
+
+	asset, err = LoadStaticAsset(path, digest, "text/plain", time.Hour)
+	test.AssertNotError(t, err, "Failed to load asset")
+	test.AssertEquals(t, asset.ContentType, "text/plain")
+
+	_, err = LoadStaticAsset(path, "00"+digest[2:], "", time.Hour)
+	test.AssertError(t, err, "Loaded an asset with a mismatched digest")
+	_, err = LoadStaticAsset(path, "", "", time.Hour)
+	test.AssertError(t, err, "Loaded an asset without a digest")
+	_, err = LoadStaticAsset(filepath.Join(dir, "missing.pem"), digest, "", time.Hour)
+	test.AssertError(t, err, "Loaded a missing asset")
+}
+
+func TestServeStaticAsset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	test.AssertNotError(t, err, "Failed to create temp dir")
+	defer os.RemoveAll(dir)
+
+	path, digest := writeAsset(t, dir, "intermediate.der", []byte{0x30, 0x00})
+	asset, err := LoadStaticAsset(path, digest, "", 24*time.Hour)
+	test.AssertNotError(t, err, "Failed to load asset")
+	assets := StaticAssets{"intermediate.der": asset}
+
+	req, _ := http.NewRequest("GET", "intermediate.der", nil)
+	resp := httptest.NewRecorder()
+	assets.Serve(context.Background(), &RequestEvent{}, resp, req)
+	test.AssertEquals(t, resp.Code, http.StatusOK)
+	test.AssertEquals(t, resp.Header().Get("Content-Type"), "application/pkix-cert")
+	test.AssertEquals(t, resp.Header().Get("Cache-Control"), "public, max-age=86400")
+	test.AssertEquals(t, resp.Header().Get("ETag"), asset.ETag)
+	test.AssertByteEquals(t, resp.Body.Bytes(), []byte{0x30, 0x00})
+
+	req.Header.Set("If-None-Match", asset.ETag)
+	resp = httptest.NewRecorder()
+	assets.Serve(context.Background(), &RequestEvent{}, resp, req)
+	test.AssertEquals(t, resp.Code, http.StatusNotModified)
+	test.AssertEquals(t, resp.Body.Len(), 0)
+
+	req, _ = http.NewRequest("GET", "root.der", nil)
+	resp = httptest.NewRecorder()
+	assets.Serve(context.Background(), &RequestEvent{}, resp, req)
+	test.AssertEquals(t, resp.Code, http.StatusNotFound)
+}
@@ -50,6 +50,7 @@ const (
 	certPath       = "/acme/cert/"
 	revokeCertPath = "/acme/revoke-cert"
 	termsPath      = "/terms"
+	staticPath     = "/static/"
 	issuerPath     = "/acme/issuer-cert"
 	buildIDPath    = "/build"
 	rolloverPath   = "/acme/key-change"
@@ -75,6 +76,10 @@ type WebFrontEndImpl struct {
 	// URL to the current subscriber agreement (should contain some version identifier)
 	SubscriberAgreementURL string
 
+	// Files, such as the subscriber agreement and intermediate certificates,
+	// served from /static/ by name
+	StaticAssets web.StaticAssets
+
 	// Register of anti-replay nonces
 	nonceService *nonce.NonceService
 
@@ -90,6 +95,10 @@ type WebFrontEndImpl struct {
 	AcceptRevocationReason bool
 	AllowAuthzDeactivation bool
 
+	// ProblemDocsURL, if set, is the base URL of the documentation for each
+	// kind of failure. Problem documents link to it followed by their code.
+	ProblemDocsURL string
+
 	csrSignatureAlgs *prometheus.CounterVec
 }
 
@@ -124,6 +133,13 @@ func NewWebFrontEndImpl(
 	}, nil
 }
 
+// StartNoncePool starts pre-generating up to size nonces in the background,
+// so that setting the Replay-Nonce header doesn't wait on encrypting a new
+// nonce. A size of zero leaves nonces generated on demand.
+func (wfe *WebFrontEndImpl) StartNoncePool(size int) {
+	wfe.nonceService.StartPool(size)
+}
+
 // HandleFunc registers a handler at the given path. It's
 // http.HandleFunc(), but with a wrapper around the handler that
 // provides some generic per-request functionality:
@@ -277,6 +293,9 @@ func (wfe *WebFrontEndImpl) Handler() http.Handler {
 	wfe.HandleFunc(m, issuerPath, wfe.Issuer, "GET")
 	wfe.HandleFunc(m, buildIDPath, wfe.BuildID, "GET")
 	wfe.HandleFunc(m, rolloverPath, wfe.KeyRollover, "POST")
+	if len(wfe.StaticAssets) > 0 {
+		wfe.HandleFunc(m, staticPath, wfe.StaticAssets.Serve, "GET")
+	}
 
 	// We don't use our special HandleFunc for "/" because it matches everything,
 	// meaning we can wind up returning 405 when we mean to return 404. See
@@ -464,9 +483,9 @@ func (wfe *WebFrontEndImpl) verifyPOST(ctx context.Context, logEvent *web.Reques
 		// When looking up keys from the registrations DB, we can be confident they
 		// are "good". But when we are verifying against any submitted key, we want
 		// to check its quality before doing the verify.
-		if err = wfe.keyPolicy.GoodKey(submittedKey.Key); err != nil {
+		if err = wfe.keyPolicy.GoodKey(ctx, submittedKey.Key); err != nil {
 			wfe.stats.Inc("Errors.JWKRejectedByGoodKey", 1)
-			return nil, nil, reg, probs.Malformed(err.Error())
+			return nil, nil, reg, web.ProblemDetailsForKeyError(err, err.Error())
 		}
 		key = submittedKey
 	} else if err != nil {
@@ -540,7 +559,7 @@ func (wfe *WebFrontEndImpl) verifyPOST(ctx context.Context, logEvent *web.Reques
 // sendError wraps web.SendError
 func (wfe *WebFrontEndImpl) sendError(response http.ResponseWriter, logEvent *web.RequestEvent, prob *probs.ProblemDetails, ierr error) {
 	wfe.stats.Inc(fmt.Sprintf("HTTP.ProblemTypes.%s", prob.Type), 1)
-	web.SendError(wfe.log, probs.V1ErrorNS, response, logEvent, prob, ierr)
+	web.SendError(wfe.log, probs.V1ErrorNS, wfe.ProblemDocsURL, response, logEvent, prob, ierr)
 }
 
 func link(url, relation string) string {
@@ -664,23 +683,6 @@ func (wfe *WebFrontEndImpl) NewAuthorization(ctx context.Context, logEvent *web.
 	}
 }
 
-func (wfe *WebFrontEndImpl) regHoldsAuthorizations(ctx context.Context, regID int64, names []string) (bool, error) {
-	authz, err := wfe.SA.GetValidAuthorizations(ctx, regID, names, wfe.clk.Now())
-	if err != nil {
-		return false, err
-	}
-	if len(names) != len(authz) {
-		return false, nil
-	}
-	missingNames := false
-	for _, name := range names {
-		if _, present := authz[name]; !present {
-			missingNames = true
-		}
-	}
-	return !missingNames, nil
-}
-
 // RevokeCertificate is used by clients to request the revocation of a cert.
 func (wfe *WebFrontEndImpl) RevokeCertificate(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
 	// We don't ask verifyPOST to verify there is a corresponding registration,
@@ -741,28 +743,24 @@ func (wfe *WebFrontEndImpl) RevokeCertificate(ctx context.Context, logEvent *web
 		return
 	}
 
-	if !(core.KeyDigestEquals(requestKey, parsedCertificate.PublicKey) || registration.ID == cert.RegistrationID) {
-		valid, err := wfe.regHoldsAuthorizations(ctx, registration.ID, parsedCertificate.DNSNames)
-		if err != nil {
-			wfe.sendError(response, logEvent, probs.ServerInternal("Failed to retrieve authorizations for names in certificate"), err)
-			return
-		}
-		if !valid {
-			wfe.sendError(response, logEvent,
-				probs.Unauthorized("Revocation request must be signed by private key of cert to be revoked, by the "+
-					"account key of the account that issued it, or by the account key of an account that holds valid "+
-					"authorizations for all names in the certificate."),
-				nil)
-			return
-		}
+	// A request signed by an account key is authorized by the RA, which checks
+	// whether the account issued the certificate or holds valid authorizations
+	// for all of its names. Without an account the request must be signed by
+	// the certificate's key, since the RA takes a registration ID of 0 to mean
+	// that it was.
+	if registration.ID == 0 && !core.KeyDigestEquals(requestKey, parsedCertificate.PublicKey) {
+		wfe.sendError(response, logEvent,
+			probs.Unauthorized("Revocation request must be signed by private key of cert to be revoked, by the "+
+				"account key of the account that issued it, or by the account key of an account that holds valid "+
+				"authorizations for all names in the certificate."),
+			nil)
+		return
 	}
 
+	// Whether the requester may give the revocation reason is decided by the
+	// RA
 	reason := revocation.Reason(0)
 	if revokeRequest.Reason != nil && wfe.AcceptRevocationReason {
-		if _, present := revocation.UserAllowedReasons[*revokeRequest.Reason]; !present {
-			wfe.sendError(response, logEvent, probs.Malformed("unsupported revocation reason code provided"), nil)
-			return
-		}
 		reason = *revokeRequest.Reason
 	}
 
@@ -843,8 +841,8 @@ func (wfe *WebFrontEndImpl) NewCertificate(ctx context.Context, logEvent *web.Re
 	// bytes on the wire, and (b) the CA logs all rejections as audit events, but
 	// a bad key from the client is just a malformed request and doesn't need to
 	// be audited.
-	if err := wfe.keyPolicy.GoodKey(certificateRequest.CSR.PublicKey); err != nil {
-		wfe.sendError(response, logEvent, probs.Malformed("Invalid key in certificate request :: %s", err), err)
+	if err := wfe.keyPolicy.GoodKey(ctx, certificateRequest.CSR.PublicKey); err != nil {
+		wfe.sendError(response, logEvent, web.ProblemDetailsForKeyError(err, fmt.Sprintf("Invalid key in certificate request :: %s", err)), err)
 		return
 	}
 	logEvent.Extra["CSRDNSNames"] = certificateRequest.CSR.DNSNames
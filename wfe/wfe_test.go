@@ -24,6 +24,7 @@ import (
 	"github.com/jmhodges/clock"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/csr"
 	"github.com/letsencrypt/boulder/ctpolicy"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/features"
@@ -209,6 +210,9 @@ EeMZ9nWyIM6bktLrE11HnFOnKhAYsM5fZA==
 
 type MockRegistrationAuthority struct {
 	lastRevocationReason revocation.Reason
+	lastRevocationRegID  int64
+	// revocationErr, if set, is returned by RevokeCertificateWithReg
+	revocationErr error
 }
 
 func (ra *MockRegistrationAuthority) NewRegistration(ctx context.Context, reg core.Registration) (core.Registration, error) {
@@ -238,7 +242,11 @@ func (ra *MockRegistrationAuthority) UpdateAuthorization(ctx context.Context, au
 }
 
 func (ra *MockRegistrationAuthority) RevokeCertificateWithReg(ctx context.Context, cert x509.Certificate, reason revocation.Reason, reg int64) error {
+	if ra.revocationErr != nil {
+		return ra.revocationErr
+	}
 	ra.lastRevocationReason = reason
+	ra.lastRevocationRegID = reg
 	return nil
 }
 
@@ -266,17 +274,21 @@ func (ra *MockRegistrationAuthority) FinalizeOrder(ctx context.Context, _ *rapb.
 	return nil, nil
 }
 
+func (ra *MockRegistrationAuthority) CheckNewOrder(ctx context.Context, _ *rapb.NewOrderRequest) error {
+	return nil
+}
+
 type mockPA struct{}
 
 func (pa *mockPA) ChallengesFor(identifier core.AcmeIdentifier, registrationID int64, revalidation bool) (challenges []core.Challenge, combinations [][]int, err error) {
 	return
 }
 
-func (pa *mockPA) WillingToIssue(id core.AcmeIdentifier) error {
+func (pa *mockPA) WillingToIssue(ctx context.Context, id core.AcmeIdentifier, registrationID int64) error {
 	return nil
 }
 
-func (pa *mockPA) WillingToIssueWildcard(id core.AcmeIdentifier) error {
+func (pa *mockPA) WillingToIssueWildcard(ctx context.Context, id core.AcmeIdentifier, registrationID int64) error {
 	return nil
 }
 
@@ -881,15 +893,14 @@ func TestIssueCertificate(t *testing.T) {
 	// authorized, etc.
 	stats := metrics.NewNoopScope()
 
-	ctp := ctpolicy.New(&mocks.Publisher{}, nil, nil, wfe.log)
+	ctp := ctpolicy.New(&mocks.Publisher{}, nil, nil, nil, 0, wfe.log, metrics.NewNoopScope())
 	ra := ra.NewRegistrationAuthorityImpl(
 		fc,
 		wfe.log,
 		stats,
 		0,
 		testKeyPolicy,
-		0,
-		true,
+		csr.Policy{CommonName: csr.CNPromote},
 		false,
 		300*24*time.Hour,
 		7*24*time.Hour,
@@ -972,7 +983,7 @@ func TestIssueCertificate(t *testing.T) {
     }`, wfe.nonceService)))
 	assertJSONEquals(t,
 		responseWriter.Body.String(),
-		`{"type":"`+probs.V1ErrorNS+`malformed","detail":"Error creating new cert :: invalid signature on CSR","status":400}`)
+		`{"type":"`+probs.V1ErrorNS+`badCSR","detail":"Error creating new cert :: invalid signature on CSR","status":400}`)
 
 	// Valid, signed JWS body, payload has a valid CSR but no authorizations:
 	// openssl req -outform der -new -nodes -key wfe/test/178.key -subj /CN=meep.com | b64url
@@ -1505,7 +1516,7 @@ func TestRevokeCertificateReasons(t *testing.T) {
 	test.AssertEquals(t, responseWriter.Body.String(), "")
 	test.AssertEquals(t, ra.lastRevocationReason, revocation.Reason(0))
 
-	// Unsupported reason
+	// Which reasons are allowed is decided by the RA
 	responseWriter = httptest.NewRecorder()
 	unsupported := revocation.Reason(2)
 	revokeRequestJSON, err = makeRevokeRequestJSON(&unsupported)
@@ -1514,19 +1525,16 @@ func TestRevokeCertificateReasons(t *testing.T) {
 	result, _ = signer.Sign(revokeRequestJSON)
 	wfe.RevokeCertificate(ctx, newRequestEvent(), responseWriter,
 		makePostRequest(result.FullSerialize()))
-	test.AssertEquals(t, responseWriter.Code, 400)
-	assertJSONEquals(t, responseWriter.Body.String(), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"unsupported revocation reason code provided","status":400}`)
+	test.AssertEquals(t, responseWriter.Code, 200)
+	test.AssertEquals(t, ra.lastRevocationReason, revocation.Reason(2))
 
 	responseWriter = httptest.NewRecorder()
-	unsupported = revocation.Reason(100)
-	revokeRequestJSON, err = makeRevokeRequestJSON(&unsupported)
-	test.AssertNotError(t, err, "Failed to make revokeRequestJSON")
-
+	ra.revocationErr = berrors.MalformedError("revocation reason \"cACompromise\" is not allowed for this requester")
 	result, _ = signer.Sign(revokeRequestJSON)
 	wfe.RevokeCertificate(ctx, newRequestEvent(), responseWriter,
 		makePostRequest(result.FullSerialize()))
 	test.AssertEquals(t, responseWriter.Code, 400)
-	assertJSONEquals(t, responseWriter.Body.String(), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"unsupported revocation reason code provided","status":400}`)
+	assertJSONEquals(t, responseWriter.Body.String(), `{"type":"`+probs.V1ErrorNS+`malformed","detail":"Failed to revoke certificate :: revocation reason \"cACompromise\" is not allowed for this requester","status":400}`)
 }
 
 // Valid revocation request for existing, non-revoked cert, signed with account
@@ -1552,7 +1560,7 @@ func TestRevokeCertificateAccountKey(t *testing.T) {
 
 // A revocation request signed by an unauthorized key.
 func TestRevokeCertificateWrongKey(t *testing.T) {
-	wfe, _ := setupWFE(t)
+	wfe, fc := setupWFE(t)
 	responseWriter := httptest.NewRecorder()
 	test2JWK := loadPrivateKey(t, []byte(test2KeyPrivatePEM))
 	test2Key, ok := test2JWK.(*rsa.PrivateKey)
@@ -1561,10 +1569,24 @@ func TestRevokeCertificateWrongKey(t *testing.T) {
 	revokeRequestJSON, err := makeRevokeRequestJSON(nil)
 	test.AssertNotError(t, err, "Unable to create revoke request")
 
+	// Whether the account may revoke the certificate is decided by the RA
+	wfe.RA = &MockRegistrationAuthority{
+		revocationErr: berrors.UnauthorizedError("requester does not hold valid authorizations for these names in the certificate: bad.example.com"),
+	}
 	result, _ := accountKeySigner2.Sign(revokeRequestJSON)
 	wfe.RevokeCertificate(ctx, newRequestEvent(), responseWriter,
 		makePostRequest(result.FullSerialize()))
 	test.AssertEquals(t, responseWriter.Code, 403)
+	assertJSONEquals(t, responseWriter.Body.String(),
+		`{"type":"`+probs.V1ErrorNS+`unauthorized","detail":"Failed to revoke certificate :: requester does not hold valid authorizations for these names in the certificate: bad.example.com","status":403}`)
+
+	// Without an account the request must be signed by the certificate's key
+	wfe.SA = &mockSANoSuchRegistration{mocks.NewStorageAuthority(fc)}
+	responseWriter = httptest.NewRecorder()
+	result, _ = newJoseSigner(t, test2Key, wfe.nonceService).Sign(revokeRequestJSON)
+	wfe.RevokeCertificate(ctx, newRequestEvent(), responseWriter,
+		makePostRequest(result.FullSerialize()))
+	test.AssertEquals(t, responseWriter.Code, 403)
 	assertJSONEquals(t, responseWriter.Body.String(),
 		`{"type":"`+probs.V1ErrorNS+`unauthorized","detail":"Revocation request must be signed by private key of cert to be revoked, by the account key of the account that issued it, or by the account key of an account that holds valid authorizations for all names in the certificate.","status":403}`)
 }
@@ -1894,6 +1916,28 @@ func TestIssuer(t *testing.T) {
 	test.Assert(t, bytes.Compare(responseWriter.Body.Bytes(), wfe.IssuerCert) == 0, "Incorrect bytes returned")
 }
 
+// TestStaticAssets only checks that the static path is routed to the
+// configured assets, serving them is tested in the web package.
+func TestStaticAssets(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	get := func() *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		wfe.Handler().ServeHTTP(responseWriter, &http.Request{
+			Method: "GET",
+			URL:    mustParseURL("/static/terms.pdf"),
+		})
+		return responseWriter
+	}
+
+	// Without any configured assets the static path isn't served
+	test.AssertEquals(t, get().Code, http.StatusNotFound)
+
+	wfe.StaticAssets = web.StaticAssets{"terms.pdf": &web.StaticAsset{Content: []byte("terms"), ETag: `"terms"`}}
+	responseWriter := get()
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Body.String(), "terms")
+}
+
 func TestGetCertificate(t *testing.T) {
 	_ = features.Set(map[string]bool{"UseAIAIssuerURL": false})
 	defer features.Reset()
@@ -0,0 +1,122 @@
+package wfe2
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+// AccountCache holds recently looked up accounts, keyed by account ID, so
+// that requests authenticated with a Key ID don't each require an SA
+// GetRegistration call and the parsing of the account's JWK. Entries expire
+// after a TTL and are invalidated by this WFE when it updates, rolls over the
+// key of, or deactivates an account. Changes made through other WFE instances
+// are only picked up once the entry expires, so the TTL should be short.
+//
+// A nil *AccountCache is valid and caches nothing.
+type AccountCache struct {
+	sync.Mutex
+	clk        clock.Clock
+	ttl        time.Duration
+	maxEntries int
+	entries    map[int64]accountCacheEntry
+
+	lookups *prometheus.CounterVec
+}
+
+type accountCacheEntry struct {
+	account core.Registration
+	expires time.Time
+}
+
+// NewAccountCache returns an AccountCache holding at most maxEntries accounts
+// for ttl each.
+func NewAccountCache(clk clock.Clock, ttl time.Duration, maxEntries int, scope metrics.Scope) *AccountCache {
+	lookups := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "accountCacheLookups",
+			Help: "Number of account cache lookups by result",
+		},
+		[]string{"result"})
+	scope.MustRegister(lookups)
+
+	return &AccountCache{
+		clk:        clk,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[int64]accountCacheEntry),
+		lookups:    lookups,
+	}
+}
+
+// Get returns the cached account with the given ID, if there is an unexpired
+// one.
+func (c *AccountCache) Get(id int64) (core.Registration, bool) {
+	if c == nil {
+		return core.Registration{}, false
+	}
+	c.Lock()
+	defer c.Unlock()
+	entry, present := c.entries[id]
+	if !present || !c.clk.Now().Before(entry.expires) {
+		delete(c.entries, id)
+		c.lookups.With(prometheus.Labels{"result": "miss"}).Inc()
+		return core.Registration{}, false
+	}
+	c.lookups.With(prometheus.Labels{"result": "hit"}).Inc()
+	return entry.account, true
+}
+
+// Add caches an account. If the cache is full expired entries are removed
+// first, and if that isn't enough an arbitrary entry is evicted.
+func (c *AccountCache) Add(account core.Registration) {
+	if c == nil || c.maxEntries <= 0 {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	now := c.clk.Now()
+	if _, present := c.entries[account.ID]; !present && len(c.entries) >= c.maxEntries {
+		for id, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, id)
+			}
+		}
+		for id := range c.entries {
+			if len(c.entries) < c.maxEntries {
+				break
+			}
+			delete(c.entries, id)
+		}
+	}
+	c.entries[account.ID] = accountCacheEntry{
+		account: account,
+		expires: now.Add(c.ttl),
+	}
+}
+
+// Invalidate removes the account with the given ID from the cache.
+func (c *AccountCache) Invalidate(id int64) {
+	if c == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	delete(c.entries, id)
+}
+
+// Len returns the number of cached accounts, including expired ones that
+// haven't been removed yet.
+func (c *AccountCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.Lock()
+	defer c.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,93 @@
+package wfe2
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestAccountCache(t *testing.T) {
+	fc := clock.NewFake()
+	cache := NewAccountCache(fc, time.Minute, 2, metrics.NewNoopScope())
+
+	_, present := cache.Get(1)
+	test.Assert(t, !present, "empty cache returned an account")
+
+	cache.Add(core.Registration{ID: 1, Status: core.StatusValid})
+	acct, present := cache.Get(1)
+	test.Assert(t, present, "cached account not returned")
+	test.AssertEquals(t, acct.ID, int64(1))
+	test.AssertEquals(t, test.CountCounter(cache.lookups.WithLabelValues("hit")), 1)
+	test.AssertEquals(t, test.CountCounter(cache.lookups.WithLabelValues("miss")), 1)
+
+	cache.Invalidate(1)
+	_, present = cache.Get(1)
+	test.Assert(t, !present, "invalidated account returned")
+
+	cache.Add(core.Registration{ID: 1})
+	fc.Add(time.Minute)
+	_, present = cache.Get(1)
+	test.Assert(t, !present, "expired account returned")
+	test.AssertEquals(t, cache.Len(), 0)
+
+	// Adding to a full cache evicts expired entries first, then arbitrary ones.
+	cache.Add(core.Registration{ID: 1})
+	cache.Add(core.Registration{ID: 2})
+	fc.Add(time.Minute)
+	cache.Add(core.Registration{ID: 3})
+	test.AssertEquals(t, cache.Len(), 1)
+	cache.Add(core.Registration{ID: 4})
+	cache.Add(core.Registration{ID: 5})
+	test.AssertEquals(t, cache.Len(), 2)
+	_, present = cache.Get(5)
+	test.Assert(t, present, "newest account was evicted")
+
+	var nilCache *AccountCache
+	nilCache.Add(core.Registration{ID: 1})
+	_, present = nilCache.Get(1)
+	test.Assert(t, !present, "nil cache returned an account")
+	nilCache.Invalidate(1)
+	test.AssertEquals(t, nilCache.Len(), 0)
+}
+
+// countingSA counts calls to GetRegistration.
+type countingSA struct {
+	core.StorageGetter
+	calls int
+}
+
+func (sa *countingSA) GetRegistration(ctx context.Context, id int64) (core.Registration, error) {
+	sa.calls++
+	return sa.StorageGetter.GetRegistration(ctx, id)
+}
+
+func TestLookupJWKAccountCache(t *testing.T) {
+	wfe, fc := setupWFE(t)
+	sa := &countingSA{StorageGetter: wfe.SA}
+	wfe.SA = sa
+	wfe.AccountCache = NewAccountCache(fc, time.Minute, 10, metrics.NewNoopScope())
+
+	for i := 0; i < 2; i++ {
+		jws, _, body := signRequestKeyID(t, 1, nil, "", "", wfe.nonceService)
+		_, acct, prob := wfe.lookupJWK(jws, context.Background(), makePostRequestWithPath("test-path", body), newRequestEvent())
+		test.Assert(t, prob == nil, "lookupJWK failed")
+		test.AssertEquals(t, acct.ID, int64(1))
+	}
+	test.AssertEquals(t, sa.calls, 1)
+
+	// Deactivating the account must invalidate its cache entry
+	responseWriter := httptest.NewRecorder()
+	request := signAndPost(t, "1", "http://localhost/1", `{"status":"deactivated"}`, 1, wfe.nonceService)
+	wfe.Account(ctx, newRequestEvent(), responseWriter, request)
+	test.AssertEquals(t, responseWriter.Code, 200)
+	test.AssertEquals(t, sa.calls, 1)
+	_, present := wfe.AccountCache.Get(1)
+	test.Assert(t, !present, "deactivated account still cached")
+}
@@ -0,0 +1,316 @@
+package wfe2
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	berrors "github.com/letsencrypt/boulder/errors"
+	blog "github.com/letsencrypt/boulder/log"
+	bmail "github.com/letsencrypt/boulder/mail"
+	"github.com/letsencrypt/boulder/metrics"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+const (
+	// DefaultContactVerificationSubject is the subject of verification
+	// messages if none is configured.
+	DefaultContactVerificationSubject = "Please verify the email address of your ACME account"
+	// DefaultContactVerificationTemplate is the text/template of verification
+	// messages if none is configured. It is given the Email being verified,
+	// the RegistrationID, the verification Link and the time it Expires.
+	DefaultContactVerificationTemplate = `Hello,
+
+The email address {{.Email}} was added as a contact of ACME account
+{{.RegistrationID}}. To confirm that you want to receive notices about the
+account's certificates, such as expiration reminders, visit:
+
+  {{.Link}}
+
+The link expires {{.Expires}}. If you didn't expect this message you can ignore
+it, and will not be sent certificate notices unless the address is verified.
+`
+)
+
+// verifiedContactAdder is the part of the SA used to record verified
+// contacts.
+type verifiedContactAdder interface {
+	AddVerifiedContact(ctx context.Context, req *sapb.AddVerifiedContactRequest) (*corepb.Empty, error)
+}
+
+// ContactVerifier sends a message to each email address added to an
+// account's contacts with a link that proves control of the address. The
+// link contains a token, MACed with a secret key, naming the account, the
+// address and when the link expires, so nothing is stored until the link is
+// followed. Verified contacts are recorded with the SA, and mailers can be
+// configured to skip unverified ones.
+//
+// Messages are queued and sent one at a time by Run, no more often than once
+// per send interval. When the queue is full further messages are dropped, so
+// account requests can't build up unbounded work.
+//
+// A nil *ContactVerifier is valid and sends nothing.
+type ContactVerifier struct {
+	key          []byte
+	linkBase     string
+	lifetime     time.Duration
+	sa           verifiedContactAdder
+	mailer       bmail.Mailer
+	subject      string
+	tmpl         *template.Template
+	sendInterval time.Duration
+	clk          clock.Clock
+	log          blog.Logger
+
+	queue    chan verification
+	lastSent time.Time
+
+	sent     *prometheus.CounterVec
+	verified prometheus.Counter
+}
+
+// verification is a queued request to verify the emails of an account.
+type verification struct {
+	regID  int64
+	emails []string
+}
+
+// NewContactVerifier returns a ContactVerifier that MACs tokens with key,
+// which must be at least 32 bytes, and sends links made of linkBase followed
+// by the token. Links are valid for lifetime. Up to queueSize accounts can be
+// waiting for messages to be sent, and messages are sent no more often than
+// once per sendInterval.
+func NewContactVerifier(
+	key []byte,
+	linkBase string,
+	lifetime time.Duration,
+	sa verifiedContactAdder,
+	mailer bmail.Mailer,
+	subject string,
+	tmpl *template.Template,
+	queueSize int,
+	sendInterval time.Duration,
+	clk clock.Clock,
+	logger blog.Logger,
+	scope metrics.Scope,
+) (*ContactVerifier, error) {
+	if len(key) < 32 {
+		return nil, fmt.Errorf("contact verification key must be at least 32 bytes, got %d", len(key))
+	}
+	if lifetime <= 0 {
+		return nil, fmt.Errorf("contact verification link lifetime must be positive")
+	}
+	if queueSize <= 0 {
+		return nil, fmt.Errorf("contact verification queue size must be positive")
+	}
+
+	sent := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "contactVerificationsSent",
+			Help: "Number of contact verification messages sent by result (success, error or dropped)",
+		},
+		[]string{"result"})
+	scope.MustRegister(sent)
+	verified := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "contactsVerified",
+			Help: "Number of contacts verified by following a verification link",
+		})
+	scope.MustRegister(verified)
+
+	return &ContactVerifier{
+		key:          key,
+		linkBase:     linkBase,
+		lifetime:     lifetime,
+		sa:           sa,
+		mailer:       mailer,
+		subject:      subject,
+		tmpl:         tmpl,
+		sendInterval: sendInterval,
+		clk:          clk,
+		log:          logger,
+		queue:        make(chan verification, queueSize),
+		sent:         sent,
+		verified:     verified,
+	}, nil
+}
+
+// mac returns the MAC of a token's payload.
+func (cv *ContactVerifier) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, cv.key)
+	_, _ = h.Write(payload)
+	return h.Sum(nil)
+}
+
+// token returns a token verifying email for the registration, valid until
+// expires. The email address goes last in the payload since it may contain
+// the separator.
+func (cv *ContactVerifier) token(regID int64, email string, expires time.Time) string {
+	payload := []byte(fmt.Sprintf("%d:%d:%s", regID, expires.Unix(), email))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(cv.mac(payload))
+}
+
+// parseToken returns the registration ID and email address verified by an
+// unexpired token with a valid MAC.
+func (cv *ContactVerifier) parseToken(token string) (int64, string, error) {
+	invalid := berrors.UnauthorizedError("contact verification link is invalid or has expired")
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return 0, "", invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return 0, "", invalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(mac, cv.mac(payload)) {
+		return 0, "", invalid
+	}
+	fields := strings.SplitN(string(payload), ":", 3)
+	if len(fields) != 3 {
+		return 0, "", invalid
+	}
+	regID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", invalid
+	}
+	expires, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || !cv.clk.Now().Before(time.Unix(expires, 0)) {
+		return 0, "", invalid
+	}
+	return regID, fields[2], nil
+}
+
+// newEmails returns the email addresses in contacts that aren't in previous.
+func newEmails(contacts []string, previous []string) []string {
+	seen := make(map[string]bool)
+	for _, contact := range previous {
+		seen[contact] = true
+	}
+	var emails []string
+	for _, contact := range contacts {
+		if seen[contact] || !strings.HasPrefix(contact, "mailto:") {
+			continue
+		}
+		seen[contact] = true
+		emails = append(emails, strings.TrimPrefix(contact, "mailto:"))
+	}
+	return emails
+}
+
+// QueueVerifications queues a verification message to each email address in
+// the account's contacts that isn't in previous, which are the contacts the
+// account had before it was updated, if it was. It doesn't wait for them to
+// be sent, and if the queue is full they are dropped and logged, since the
+// account has already been created or updated.
+func (cv *ContactVerifier) QueueVerifications(acct core.Registration, previous *[]string) {
+	if cv == nil || acct.Contact == nil {
+		return
+	}
+	var prev []string
+	if previous != nil {
+		prev = *previous
+	}
+	emails := newEmails(*acct.Contact, prev)
+	if len(emails) == 0 {
+		return
+	}
+
+	select {
+	case cv.queue <- verification{regID: acct.ID, emails: emails}:
+	default:
+		cv.log.Warning(fmt.Sprintf("Contact verification queue full, dropping verifications for account %d", acct.ID))
+		cv.sent.With(prometheus.Labels{"result": "dropped"}).Add(float64(len(emails)))
+	}
+}
+
+// Run sends the queued verification messages. It never returns, so it's meant
+// to be called in its own goroutine.
+func (cv *ContactVerifier) Run() {
+	for v := range cv.queue {
+		cv.send(v)
+	}
+}
+
+// send sends the messages for a queued verification, waiting for the send
+// interval to pass since the last message before each one. Failures are logged
+// and counted.
+func (cv *ContactVerifier) send(v verification) {
+	err := cv.mailer.Connect()
+	if err != nil {
+		cv.log.AuditErr(fmt.Sprintf("Failed to connect to send contact verifications for account %d: %s", v.regID, err))
+		cv.sent.With(prometheus.Labels{"result": "error"}).Add(float64(len(v.emails)))
+		return
+	}
+	defer func() {
+		_ = cv.mailer.Close()
+	}()
+
+	for _, email := range v.emails {
+		if wait := cv.sendInterval - cv.clk.Since(cv.lastSent); wait > 0 {
+			cv.clk.Sleep(wait)
+		}
+		cv.lastSent = cv.clk.Now()
+		expires := cv.clk.Now().Add(cv.lifetime)
+		var body bytes.Buffer
+		err := cv.tmpl.Execute(&body, struct {
+			Email          string
+			RegistrationID int64
+			Link           string
+			Expires        string
+		}{
+			Email:          email,
+			RegistrationID: v.regID,
+			Link:           cv.linkBase + cv.token(v.regID, email, expires),
+			Expires:        expires.UTC().Format(time.RFC822Z),
+		})
+		if err == nil {
+			err = cv.mailer.SendMail([]string{email}, cv.subject, body.String())
+		}
+		if err != nil {
+			cv.log.AuditErr(fmt.Sprintf("Failed to send contact verification for account %d to %q: %s", v.regID, email, err))
+			cv.sent.With(prometheus.Labels{"result": "error"}).Inc()
+			continue
+		}
+		cv.sent.With(prometheus.Labels{"result": "success"}).Inc()
+	}
+}
+
+// Check returns the registration ID and email address named by a token,
+// without recording anything.
+func (cv *ContactVerifier) Check(token string) (int64, string, error) {
+	return cv.parseToken(token)
+}
+
+// Verify records the email address named by a token as verified for its
+// registration, returning both.
+func (cv *ContactVerifier) Verify(ctx context.Context, token string) (int64, string, error) {
+	regID, email, err := cv.parseToken(token)
+	if err != nil {
+		return 0, "", err
+	}
+	verified := cv.clk.Now().UnixNano()
+	_, err = cv.sa.AddVerifiedContact(ctx, &sapb.AddVerifiedContactRequest{
+		RegistrationID: &regID,
+		Email:          &email,
+		Verified:       &verified,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	cv.verified.Inc()
+	return regID, email, nil
+}
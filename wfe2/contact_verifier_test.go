@@ -0,0 +1,126 @@
+package wfe2
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	berrors "github.com/letsencrypt/boulder/errors"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/mocks"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type mockVerifiedContactAdder struct {
+	verified map[int64][]string
+}
+
+func (sa *mockVerifiedContactAdder) AddVerifiedContact(_ context.Context, req *sapb.AddVerifiedContactRequest) (*corepb.Empty, error) {
+	sa.verified[*req.RegistrationID] = append(sa.verified[*req.RegistrationID], *req.Email)
+	return &corepb.Empty{}, nil
+}
+
+func newTestContactVerifier(t *testing.T) (*ContactVerifier, *mockVerifiedContactAdder, *mocks.Mailer, clock.FakeClock) {
+	sa := &mockVerifiedContactAdder{verified: make(map[int64][]string)}
+	mailer := &mocks.Mailer{}
+	fc := clock.NewFake()
+	tmpl, err := template.New("contact-verification").Parse(DefaultContactVerificationTemplate)
+	test.AssertNotError(t, err, "Failed to parse default template")
+	cv, err := NewContactVerifier(bytes.Repeat([]byte{1}, 32), "https://example.com/verify-contact/", time.Hour,
+		sa, mailer, DefaultContactVerificationSubject, tmpl, 2, time.Second, fc, blog.NewMock(), metrics.NewNoopScope())
+	test.AssertNotError(t, err, "Failed to create contact verifier")
+	return cv, sa, mailer, fc
+}
+
+func TestNewContactVerifier(t *testing.T) {
+	_, err := NewContactVerifier(make([]byte, 16), "", time.Hour, nil, nil, "", nil, 1, 0, clock.NewFake(), blog.NewMock(), metrics.NewNoopScope())
+	test.AssertError(t, err, "Contact verifier created with a short key")
+	_, err = NewContactVerifier(make([]byte, 32), "", 0, nil, nil, "", nil, 1, 0, clock.NewFake(), blog.NewMock(), metrics.NewNoopScope())
+	test.AssertError(t, err, "Contact verifier created without a link lifetime")
+	_, err = NewContactVerifier(make([]byte, 32), "", time.Hour, nil, nil, "", nil, 0, 0, clock.NewFake(), blog.NewMock(), metrics.NewNoopScope())
+	test.AssertError(t, err, "Contact verifier created without a queue")
+}
+
+func TestContactVerificationToken(t *testing.T) {
+	cv, _, _, fc := newTestContactVerifier(t)
+
+	token := cv.token(1, "a:b@example.com", fc.Now().Add(time.Hour))
+	regID, email, err := cv.parseToken(token)
+	test.AssertNotError(t, err, "Failed to parse token")
+	test.AssertEquals(t, regID, int64(1))
+	test.AssertEquals(t, email, "a:b@example.com")
+
+	// The payload can't be changed without invalidating the MAC
+	other := cv.token(2, "a:b@example.com", fc.Now().Add(time.Hour))
+	forged := strings.Split(other, ".")[0] + "." + strings.Split(token, ".")[1]
+	for _, bad := range []string{"", "abc", token + "x", forged} {
+		_, _, err := cv.parseToken(bad)
+		test.AssertError(t, err, "Parsed an invalid token: "+bad)
+		test.Assert(t, berrors.Is(err, berrors.Unauthorized), "Wrong error type")
+	}
+
+	fc.Add(time.Hour)
+	_, _, err = cv.parseToken(token)
+	test.AssertError(t, err, "Parsed an expired token")
+}
+
+func TestSendVerifications(t *testing.T) {
+	cv, sa, mailer, fc := newTestContactVerifier(t)
+
+	acct := core.Registration{
+		ID:      1,
+		Contact: &[]string{"mailto:one@example.com", "tel:867-5309", "mailto:two@example.com"},
+	}
+	cv.QueueVerifications(acct, &[]string{"mailto:one@example.com"})
+	test.AssertEquals(t, len(mailer.Messages), 0)
+	cv.send(<-cv.queue)
+	test.AssertEquals(t, len(mailer.Messages), 1)
+	msg := mailer.Messages[0]
+	test.AssertEquals(t, msg.To, "two@example.com")
+	test.AssertEquals(t, msg.Subject, DefaultContactVerificationSubject)
+	test.AssertEquals(t, test.CountCounter(cv.sent.WithLabelValues("success")), 1)
+
+	// Following the link in the message verifies the address
+	start := strings.Index(msg.Body, "https://example.com/verify-contact/")
+	test.Assert(t, start >= 0, "Message didn't contain a verification link")
+	token := strings.Fields(msg.Body[start+len("https://example.com/verify-contact/"):])[0]
+	regID, email, err := cv.Check(token)
+	test.AssertNotError(t, err, "Failed to check token")
+	test.AssertEquals(t, regID, int64(1))
+	test.AssertEquals(t, email, "two@example.com")
+	test.AssertEquals(t, len(sa.verified), 0)
+	regID, email, err = cv.Verify(context.Background(), token)
+	test.AssertNotError(t, err, "Failed to verify contact")
+	test.AssertEquals(t, regID, int64(1))
+	test.AssertEquals(t, email, "two@example.com")
+	test.AssertDeepEquals(t, sa.verified, map[int64][]string{1: {"two@example.com"}})
+
+	// A new account is sent a message for every email address, waiting for
+	// the send interval between them
+	mailer.Clear()
+	start2 := fc.Now()
+	cv.QueueVerifications(acct, nil)
+	cv.send(<-cv.queue)
+	test.AssertEquals(t, len(mailer.Messages), 2)
+	test.AssertEquals(t, fc.Now().Sub(start2), 2*time.Second)
+
+	// When the queue is full verifications are dropped
+	cv.QueueVerifications(acct, nil)
+	cv.QueueVerifications(acct, nil)
+	cv.QueueVerifications(acct, nil)
+	test.AssertEquals(t, len(cv.queue), 2)
+	test.AssertEquals(t, test.CountCounter(cv.sent.WithLabelValues("dropped")), 2)
+
+	// A nil ContactVerifier sends nothing
+	var nilVerifier *ContactVerifier
+	nilVerifier.QueueVerifications(acct, nil)
+}
@@ -0,0 +1,222 @@
+package wfe2
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/metrics"
+)
+
+const (
+	// maxOrderPollOffenders is the number of accounts reported by the
+	// orderPollOffenders metric.
+	maxOrderPollOffenders = 5
+	// maxProcessingTracked is how long an order that was last seen processing
+	// is kept once its window has ended, waiting for a poll that finds it done.
+	maxProcessingTracked = time.Hour
+)
+
+// OrderPollLimiter counts how often each account polls each of its orders
+// within a fixed window, so that clients polling excessively, e.g. in a tight
+// loop while an order is processing, can be told to back off. Polls are only
+// counted once the order is known to belong to the polling account, so that
+// nobody can use up the limit of another account's order. Counts are kept per
+// WFE instance.
+//
+// The limiter also keeps a moving average of how long orders are seen in
+// processing status, so that a processing order's poller can be asked to come
+// back around when the order is expected to be done.
+//
+// A nil *OrderPollLimiter limits nothing.
+type OrderPollLimiter struct {
+	sync.Mutex
+	clk        clock.Clock
+	maxPolls   int
+	window     time.Duration
+	maxEntries int
+	entries    map[orderPollKey]*orderPolls
+	lastPrune  time.Time
+	// processingTime is the moving average of how long orders spent in
+	// processing status, or zero if none have been seen to finish yet.
+	processingTime time.Duration
+
+	polls     *prometheus.CounterVec
+	offenders *prometheus.GaugeVec
+}
+
+// orderPollKey identifies an order polled by the account that owns it.
+type orderPollKey struct {
+	acctID  int64
+	orderID int64
+}
+
+type orderPolls struct {
+	windowStart time.Time
+	count       int
+	// limited is the number of polls refused since the order was first seen.
+	limited int
+	// processingSince is when the order was first seen in processing status.
+	processingSince time.Time
+}
+
+// NewOrderPollLimiter returns an OrderPollLimiter allowing maxPolls polls of
+// each order per window, tracking at most maxEntries orders.
+func NewOrderPollLimiter(clk clock.Clock, maxPolls int, window time.Duration, maxEntries int, scope metrics.Scope) *OrderPollLimiter {
+	polls := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "orderPolls",
+			Help: "Number of order polls by whether they were allowed or limited",
+		},
+		[]string{"result"})
+	scope.MustRegister(polls)
+	offenders := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "orderPollOffenders",
+			Help: "Number of limited order polls for the accounts with the most, among recently polled orders",
+		},
+		[]string{"account"})
+	scope.MustRegister(offenders)
+
+	return &OrderPollLimiter{
+		clk:        clk,
+		maxPolls:   maxPolls,
+		window:     window,
+		maxEntries: maxEntries,
+		entries:    make(map[orderPollKey]*orderPolls),
+		lastPrune:  clk.Now(),
+		polls:      polls,
+		offenders:  offenders,
+	}
+}
+
+// Allow records a poll of the order with the given ID by the account that
+// owns it. If the account has polled the order too often in the current window
+// it returns false and how long the client should wait before polling again.
+func (l *OrderPollLimiter) Allow(acctID, orderID int64) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.Lock()
+	defer l.Unlock()
+	now := l.clk.Now()
+	if now.Sub(l.lastPrune) >= l.window {
+		l.prune(now)
+	}
+	key := orderPollKey{acctID: acctID, orderID: orderID}
+	entry, present := l.entries[key]
+	if !present {
+		if len(l.entries) >= l.maxEntries {
+			l.prune(now)
+			for k := range l.entries {
+				if len(l.entries) < l.maxEntries {
+					break
+				}
+				delete(l.entries, k)
+			}
+		}
+		entry = &orderPolls{windowStart: now}
+		l.entries[key] = entry
+	}
+	if !now.Before(entry.windowStart.Add(l.window)) {
+		entry.windowStart = now
+		entry.count = 0
+	}
+	entry.count++
+	if entry.count <= l.maxPolls {
+		l.polls.With(prometheus.Labels{"result": "allowed"}).Inc()
+		return true, 0
+	}
+	entry.limited++
+	l.polls.With(prometheus.Labels{"result": "limited"}).Inc()
+	return false, l.retryAfter(entry, now)
+}
+
+// retryAfter is the later of the end of the entry's window and, for a
+// processing order, when it is expected to be done. It is at least a second.
+func (l *OrderPollLimiter) retryAfter(entry *orderPolls, now time.Time) time.Duration {
+	wait := entry.windowStart.Add(l.window).Sub(now)
+	if !entry.processingSince.IsZero() && l.processingTime > 0 {
+		if expected := entry.processingSince.Add(l.processingTime).Sub(now); expected > wait {
+			wait = expected
+		}
+	}
+	if wait < time.Second {
+		return time.Second
+	}
+	return wait
+}
+
+// Observe records the status of an order returned by a poll of the account
+// that owns it.
+func (l *OrderPollLimiter) Observe(acctID, orderID int64, status core.AcmeStatus) {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	entry, present := l.entries[orderPollKey{acctID: acctID, orderID: orderID}]
+	if !present {
+		return
+	}
+	now := l.clk.Now()
+	switch status {
+	case core.StatusProcessing:
+		if entry.processingSince.IsZero() {
+			entry.processingSince = now
+		}
+	case core.StatusValid, core.StatusInvalid:
+		if entry.processingSince.IsZero() {
+			return
+		}
+		took := now.Sub(entry.processingSince)
+		entry.processingSince = time.Time{}
+		if l.processingTime == 0 {
+			l.processingTime = took
+		} else {
+			// Weight the latest observation at 1/8th, like TCP's smoothed RTT
+			l.processingTime += (took - l.processingTime) / 8
+		}
+	}
+}
+
+// prune removes orders whose window has ended, unless they were recently seen
+// processing and are waiting to be seen finished. It then updates the
+// orderPollOffenders metric from the remaining orders. The caller must hold
+// the lock.
+func (l *OrderPollLimiter) prune(now time.Time) {
+	l.lastPrune = now
+	limited := make(map[int64]int)
+	for key, entry := range l.entries {
+		if now.Sub(entry.windowStart) >= l.window &&
+			(entry.processingSince.IsZero() || now.Sub(entry.processingSince) >= maxProcessingTracked) {
+			delete(l.entries, key)
+			continue
+		}
+		if entry.limited > 0 {
+			limited[key.acctID] += entry.limited
+		}
+	}
+
+	accounts := make([]int64, 0, len(limited))
+	for acctID := range limited {
+		accounts = append(accounts, acctID)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return limited[accounts[i]] > limited[accounts[j]]
+	})
+	if len(accounts) > maxOrderPollOffenders {
+		accounts = accounts[:maxOrderPollOffenders]
+	}
+	l.offenders.Reset()
+	for _, acctID := range accounts {
+		l.offenders.With(prometheus.Labels{
+			"account": strconv.FormatInt(acctID, 10),
+		}).Set(float64(limited[acctID]))
+	}
+}
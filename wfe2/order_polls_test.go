@@ -0,0 +1,122 @@
+package wfe2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestOrderPollLimiter(t *testing.T) {
+	fc := clock.NewFake()
+	limiter := NewOrderPollLimiter(fc, 2, time.Minute, 10, metrics.NewNoopScope())
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := limiter.Allow(100, 1)
+		test.Assert(t, allowed, "poll within the limit was refused")
+	}
+	fc.Add(20 * time.Second)
+	allowed, wait := limiter.Allow(100, 1)
+	test.Assert(t, !allowed, "poll over the limit was allowed")
+	test.AssertEquals(t, wait, 40*time.Second)
+	test.AssertEquals(t, test.CountCounter(limiter.polls.WithLabelValues("allowed")), 2)
+	test.AssertEquals(t, test.CountCounter(limiter.polls.WithLabelValues("limited")), 1)
+
+	// Other orders are counted separately
+	allowed, _ = limiter.Allow(100, 2)
+	test.Assert(t, allowed, "poll of another order was refused")
+
+	// And so are the polls of other accounts
+	allowed, _ = limiter.Allow(200, 1)
+	test.Assert(t, allowed, "poll by another account was refused")
+
+	// A new window starts once the old one ends
+	fc.Add(40 * time.Second)
+	allowed, _ = limiter.Allow(100, 1)
+	test.Assert(t, allowed, "poll in a new window was refused")
+
+	// Clients polling a processing order are told to come back when orders
+	// usually finish processing, if that's later than the end of the window
+	limiter.Observe(100, 1, core.StatusProcessing)
+	fc.Add(time.Minute)
+	limiter.Observe(100, 1, core.StatusValid)
+	test.AssertEquals(t, limiter.processingTime, time.Minute)
+
+	allowed, _ = limiter.Allow(100, 3)
+	test.Assert(t, allowed, "first poll was refused")
+	limiter.Observe(100, 3, core.StatusProcessing)
+	limiter.Allow(100, 3)
+	allowed, wait = limiter.Allow(100, 3)
+	test.Assert(t, !allowed, "poll over the limit was allowed")
+	test.AssertEquals(t, wait, time.Minute)
+	fc.Add(50 * time.Second)
+	_, wait = limiter.Allow(100, 3)
+	test.AssertEquals(t, wait, 10*time.Second)
+
+	// The moving average moves slowly towards new observations
+	fc.Add(5*time.Minute + 10*time.Second)
+	limiter.Observe(100, 3, core.StatusInvalid)
+	test.AssertEquals(t, limiter.processingTime, time.Minute+37500*time.Millisecond)
+
+	var nilLimiter *OrderPollLimiter
+	allowed, _ = nilLimiter.Allow(100, 1)
+	test.Assert(t, allowed, "nil limiter refused a poll")
+	nilLimiter.Observe(1, 1, core.StatusValid)
+}
+
+func TestOrderPollLimiterPrune(t *testing.T) {
+	fc := clock.NewFake()
+	limiter := NewOrderPollLimiter(fc, 1, time.Minute, 2, metrics.NewNoopScope())
+
+	for _, id := range []int64{1, 2} {
+		limiter.Allow(id*100, id)
+		limiter.Allow(id*100, id)
+		limiter.Observe(id*100, id, core.StatusPending)
+	}
+	test.AssertEquals(t, len(limiter.entries), 2)
+
+	// Tracking another order in a full limiter evicts one
+	limiter.Allow(100, 3)
+	test.AssertEquals(t, len(limiter.entries), 2)
+
+	// Once their windows end orders are pruned
+	fc.Add(time.Minute)
+	limiter.Allow(100, 4)
+	_, tracked := limiter.entries[orderPollKey{100, 4}]
+	test.Assert(t, tracked, "new order not tracked")
+	_, tracked = limiter.entries[orderPollKey{100, 3}]
+	test.Assert(t, !tracked, "order with an ended window not pruned")
+
+	limiter.Lock()
+	limiter.entries[orderPollKey{200, 2}] = &orderPolls{windowStart: fc.Now(), limited: 3}
+	limiter.entries[orderPollKey{500, 5}] = &orderPolls{windowStart: fc.Now(), limited: 1}
+	limiter.prune(fc.Now())
+	limiter.Unlock()
+	test.AssertEquals(t, test.GaugeValue(limiter.offenders.WithLabelValues("200")), float64(3))
+	test.AssertEquals(t, test.GaugeValue(limiter.offenders.WithLabelValues("500")), float64(1))
+}
+
+func TestOrderPollLimiterKeepsProcessing(t *testing.T) {
+	fc := clock.NewFake()
+	limiter := NewOrderPollLimiter(fc, 1, time.Minute, 10, metrics.NewNoopScope())
+
+	limiter.Allow(100, 1)
+	limiter.Observe(100, 1, core.StatusProcessing)
+	limiter.Allow(100, 2)
+
+	fc.Add(time.Minute)
+	limiter.Allow(100, 3)
+	_, tracked := limiter.entries[orderPollKey{100, 1}]
+	test.Assert(t, tracked, "processing order pruned")
+	_, tracked = limiter.entries[orderPollKey{100, 2}]
+	test.Assert(t, !tracked, "order with an ended window not pruned")
+
+	fc.Add(time.Hour)
+	limiter.Allow(100, 3)
+	_, tracked = limiter.entries[orderPollKey{100, 1}]
+	test.Assert(t, !tracked, "long processing order not pruned")
+}
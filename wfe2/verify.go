@@ -405,8 +405,14 @@ func (wfe *WebFrontEndImpl) lookupJWK(
 		return nil, nil, probs.Malformed(fmt.Sprintf("Malformed account ID in KeyID header"))
 	}
 
-	// Try to find the account for this account ID
-	account, err := wfe.SA.GetRegistration(ctx, accountID)
+	// Try to find the account for this account ID, first in the account cache
+	account, cached := wfe.AccountCache.Get(accountID)
+	if !cached {
+		account, err = wfe.SA.GetRegistration(ctx, accountID)
+		if err == nil {
+			wfe.AccountCache.Add(account)
+		}
+	}
 	if err != nil {
 		// If the account isn't found, return a suitable problem
 		if berrors.Is(err, berrors.NotFound) {
@@ -546,6 +552,7 @@ func (wfe *WebFrontEndImpl) validPOSTForAccount(
 // the JWK that was embedded in the JWS. Otherwise if the valid JWS conditions
 // are not met or an error occurs only a problem is returned
 func (wfe *WebFrontEndImpl) validSelfAuthenticatedJWS(
+	ctx context.Context,
 	jws *jose.JSONWebSignature,
 	request *http.Request,
 	logEvent *web.RequestEvent) ([]byte, *jose.JSONWebKey, *probs.ProblemDetails) {
@@ -556,9 +563,9 @@ func (wfe *WebFrontEndImpl) validSelfAuthenticatedJWS(
 	}
 
 	// If the key doesn't meet the GoodKey policy return a problem immediately
-	if err := wfe.keyPolicy.GoodKey(pubKey.Key); err != nil {
+	if err := wfe.keyPolicy.GoodKey(ctx, pubKey.Key); err != nil {
 		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "JWKRejectedByGoodKey"}).Inc()
-		return nil, nil, probs.Malformed(err.Error())
+		return nil, nil, web.ProblemDetailsForKeyError(err, err.Error())
 	}
 
 	// Verify the JWS with the embedded JWK
@@ -573,6 +580,7 @@ func (wfe *WebFrontEndImpl) validSelfAuthenticatedJWS(
 // validSelfAuthenticatedPOST checks that a given POST request has a valid JWS
 // using `validSelfAuthenticatedJWS`.
 func (wfe *WebFrontEndImpl) validSelfAuthenticatedPOST(
+	ctx context.Context,
 	request *http.Request,
 	logEvent *web.RequestEvent) ([]byte, *jose.JSONWebKey, *probs.ProblemDetails) {
 	// Parse the JWS from the POST request
@@ -581,7 +589,7 @@ func (wfe *WebFrontEndImpl) validSelfAuthenticatedPOST(
 		return nil, nil, prob
 	}
 	// Extract and validate the embedded JWK from the parsed JWS
-	return wfe.validSelfAuthenticatedJWS(jws, request, logEvent)
+	return wfe.validSelfAuthenticatedJWS(ctx, jws, request, logEvent)
 }
 
 // rolloverRequest is a struct representing an ACME key rollover request
@@ -606,6 +614,7 @@ type rolloverRequest struct {
 // and that the account field of the rollover object matches the account that
 // verified the outer JWS.
 func (wfe *WebFrontEndImpl) validKeyRollover(
+	ctx context.Context,
 	outerJWS *jose.JSONWebSignature,
 	innerJWS *jose.JSONWebSignature,
 	logEvent *web.RequestEvent) (*rolloverRequest, *probs.ProblemDetails) {
@@ -617,9 +626,9 @@ func (wfe *WebFrontEndImpl) validKeyRollover(
 	}
 
 	// If the key doesn't meet the GoodKey policy return a problem immediately
-	if err := wfe.keyPolicy.GoodKey(jwk.Key); err != nil {
+	if err := wfe.keyPolicy.GoodKey(ctx, jwk.Key); err != nil {
 		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "KeyRolloverJWKRejectedByGoodKey"}).Inc()
-		return nil, probs.Malformed(err.Error())
+		return nil, web.ProblemDetailsForKeyError(err, err.Error())
 	}
 
 	// Check that the public key and JWS algorithms match expected
@@ -819,6 +819,7 @@ func TestParseJWSRequest(t *testing.T) {
 				Type:       probs.MalformedProblem,
 				Detail:     "missing Content-Length header",
 				HTTPStatus: http.StatusLengthRequired,
+				Code:       "contentLengthRequired",
 			},
 		},
 		{
@@ -1397,7 +1398,7 @@ func TestValidSelfAuthenticatedPOST(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			wfe.stats.joseErrorCount.Reset()
 			inputLogEvent := newRequestEvent()
-			outPayload, jwk, prob := wfe.validSelfAuthenticatedPOST(tc.Request, inputLogEvent)
+			outPayload, jwk, prob := wfe.validSelfAuthenticatedPOST(context.Background(), tc.Request, inputLogEvent)
 			if tc.ExpectedProblem == nil && prob != nil {
 				t.Fatal(fmt.Sprintf("Expected nil problem, got %#v\n", prob))
 			} else if tc.ExpectedProblem == nil {
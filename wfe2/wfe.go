@@ -3,10 +3,12 @@ package wfe2
 import (
 	"bytes"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"html"
 	"net"
 	"net/http"
 	"path"
@@ -55,8 +57,12 @@ const (
 	rolloverPath      = "/acme/key-change"
 	newNoncePath      = "/acme/new-nonce"
 	newOrderPath      = "/acme/new-order"
+	checkOrderPath    = "/acme/check-order"
 	orderPath         = "/acme/order/"
 	finalizeOrderPath = "/acme/finalize/"
+	sctsPath          = "/acme/scts/"
+	staticPath        = "/static/"
+	verifyContactPath = "/verify-contact/"
 )
 
 // WebFrontEndImpl provides all the logic for Boulder's web-facing interface,
@@ -79,9 +85,26 @@ type WebFrontEndImpl struct {
 	// sorted from leaf to root
 	certificateChains map[string][]byte
 
+	// AlternateCertificateChains maps AIA issuer URLs to chains, in the same
+	// form as certificateChains, that can be served instead of the default
+	// chain for certificates from that issuer, e.g. one ending in a
+	// cross-signed rather than a self-signed root. Alternate N is served at
+	// the certificate's URL followed by "/N", counting from 1, and linked to
+	// from the other chains with rel="alternate".
+	AlternateCertificateChains map[string][][]byte
+
 	// URL to the current subscriber agreement (should contain some version identifier)
 	SubscriberAgreementURL string
 
+	// RequireCurrentAgreement refuses new orders and finalization from
+	// accounts whose agreement isn't SubscriberAgreementURL, i.e. that agreed
+	// to an earlier version, until they agree to the current one.
+	RequireCurrentAgreement bool
+
+	// Files, such as the subscriber agreement and intermediate certificates,
+	// served from /static/ by name
+	StaticAssets web.StaticAssets
+
 	// Register of anti-replay nonces
 	nonceService *nonce.NonceService
 
@@ -96,6 +119,23 @@ type WebFrontEndImpl struct {
 
 	AcceptRevocationReason bool
 	AllowAuthzDeactivation bool
+
+	// AccountCache caches the accounts of requests authenticated with a Key
+	// ID. If nil, every such request looks up its account with the SA.
+	AccountCache *AccountCache
+
+	// OrderPollLimiter refuses polls of orders that are polled too often. If
+	// nil, order polls are never limited.
+	OrderPollLimiter *OrderPollLimiter
+
+	// ContactVerifier sends verification links for the email contacts of new
+	// and updated accounts, which are served from /verify-contact/. If nil,
+	// contacts aren't verified.
+	ContactVerifier *ContactVerifier
+
+	// ProblemDocsURL, if set, is the base URL of the documentation for each
+	// kind of failure. Problem documents link to it followed by their code.
+	ProblemDocsURL string
 }
 
 // NewWebFrontEndImpl constructs a web service for Boulder
@@ -122,6 +162,13 @@ func NewWebFrontEndImpl(
 	}, nil
 }
 
+// StartNoncePool starts pre-generating up to size nonces in the background,
+// so that setting the Replay-Nonce header doesn't wait on encrypting a new
+// nonce. A size of zero leaves nonces generated on demand.
+func (wfe *WebFrontEndImpl) StartNoncePool(size int) {
+	wfe.nonceService.StartPool(size)
+}
+
 // HandleFunc registers a handler at the given path. It's
 // http.HandleFunc(), but with a wrapper around the handler that
 // provides some generic per-request functionality:
@@ -295,8 +342,18 @@ func (wfe *WebFrontEndImpl) Handler() http.Handler {
 	wfe.HandleFunc(m, rolloverPath, wfe.KeyRollover, "POST")
 	wfe.HandleFunc(m, newNoncePath, wfe.Nonce, "GET")
 	wfe.HandleFunc(m, newOrderPath, wfe.NewOrder, "POST")
+	if features.Enabled(features.CheckOrderEndpoint) {
+		wfe.HandleFunc(m, checkOrderPath, wfe.CheckOrder, "POST")
+	}
 	wfe.HandleFunc(m, orderPath, wfe.GetOrder, "GET")
 	wfe.HandleFunc(m, finalizeOrderPath, wfe.FinalizeOrder, "POST")
+	wfe.HandleFunc(m, sctsPath, wfe.SCTs, "GET")
+	if len(wfe.StaticAssets) > 0 {
+		wfe.HandleFunc(m, staticPath, wfe.StaticAssets.Serve, "GET")
+	}
+	if wfe.ContactVerifier != nil {
+		wfe.HandleFunc(m, verifyContactPath, wfe.VerifyContact, "GET", "POST")
+	}
 	// We don't use our special HandleFunc for "/" because it matches everything,
 	// meaning we can wind up returning 405 when we mean to return 404. See
 	// https://github.com/letsencrypt/boulder/issues/717
@@ -362,6 +419,9 @@ func (wfe *WebFrontEndImpl) Directory(
 		"newOrder":   newOrderPath,
 		"keyChange":  rolloverPath,
 	}
+	if features.Enabled(features.CheckOrderEndpoint) {
+		directoryEndpoints["checkOrder"] = checkOrderPath
+	}
 
 	// Add a random key to the directory in order to make sure that clients don't hardcode an
 	// expected set of keys. This ensures that we can properly extend the directory when we
@@ -401,13 +461,28 @@ func (wfe *WebFrontEndImpl) Nonce(
 // sendError wraps web.SendError
 func (wfe *WebFrontEndImpl) sendError(response http.ResponseWriter, logEvent *web.RequestEvent, prob *probs.ProblemDetails, ierr error) {
 	wfe.stats.httpErrorCount.With(prometheus.Labels{"type": string(prob.Type)}).Inc()
-	web.SendError(wfe.log, probs.V2ErrorNS, response, logEvent, prob, ierr)
+	web.SendError(wfe.log, probs.V2ErrorNS, wfe.ProblemDocsURL, response, logEvent, prob, ierr)
 }
 
 func link(url, relation string) string {
 	return fmt.Sprintf("<%s>;rel=\"%s\"", url, relation)
 }
 
+// checkAgreement returns a userActionRequired problem, and adds a
+// terms-of-service Link header, if RequireCurrentAgreement is set and the
+// account hasn't agreed to the current subscriber agreement.
+func (wfe *WebFrontEndImpl) checkAgreement(response http.ResponseWriter, acct *core.Registration) *probs.ProblemDetails {
+	if !wfe.RequireCurrentAgreement || wfe.SubscriberAgreementURL == "" ||
+		acct.Agreement == wfe.SubscriberAgreementURL {
+		return nil
+	}
+	response.Header().Add("Link", link(wfe.SubscriberAgreementURL, "terms-of-service"))
+	return probs.UserActionRequired(
+		"The subscriber agreement has changed. Review the new agreement and update the account with "+
+			"termsOfServiceAgreed set to true to agree to it",
+		wfe.SubscriberAgreementURL)
+}
+
 // NewAccount is used by clients to submit a new account
 func (wfe *WebFrontEndImpl) NewAccount(
 	ctx context.Context,
@@ -418,7 +493,7 @@ func (wfe *WebFrontEndImpl) NewAccount(
 	// NewAccount uses `validSelfAuthenticatedPOST` instead of
 	// `validPOSTforAccount` because there is no account to authenticate against
 	// until after it is created!
-	body, key, prob := wfe.validSelfAuthenticatedPOST(request, logEvent)
+	body, key, prob := wfe.validSelfAuthenticatedPOST(ctx, request, logEvent)
 	if prob != nil {
 		// validSelfAuthenticatedPOST handles its own setting of logEvent.Errors
 		wfe.sendError(response, logEvent, prob, nil)
@@ -488,6 +563,7 @@ func (wfe *WebFrontEndImpl) NewAccount(
 	logEvent.Requester = acct.ID
 	addRequesterHeader(response, acct.ID)
 	logEvent.Contacts = acct.Contact
+	wfe.ContactVerifier.QueueVerifications(acct, nil)
 
 	acctURL := web.RelativeEndpoint(request, fmt.Sprintf("%s%d", acctPath, acct.ID))
 
@@ -505,23 +581,6 @@ func (wfe *WebFrontEndImpl) NewAccount(
 	}
 }
 
-func (wfe *WebFrontEndImpl) acctHoldsAuthorizations(ctx context.Context, acctID int64, names []string) (bool, error) {
-	authz, err := wfe.SA.GetValidAuthorizations(ctx, acctID, names, wfe.clk.Now())
-	if err != nil {
-		return false, err
-	}
-	if len(names) != len(authz) {
-		return false, nil
-	}
-	missingNames := false
-	for _, name := range names {
-		if _, present := authz[name]; !present {
-			missingNames = true
-		}
-	}
-	return !missingNames, nil
-}
-
 // authorizedToRevokeCert is a callback function that can be used to validate if
 // a given requester is authorized to revoke the certificate parsed out of the
 // revocation request from the inner JWS. If the requester is not authorized to
@@ -596,12 +655,10 @@ func (wfe *WebFrontEndImpl) processRevocation(
 		return prob
 	}
 
-	// Verify the revocation reason supplied is allowed
+	// Whether the requester may give the revocation reason is decided by the
+	// RA
 	reason := revocation.Reason(0)
 	if revokeRequest.Reason != nil && wfe.AcceptRevocationReason {
-		if _, present := revocation.UserAllowedReasons[*revokeRequest.Reason]; !present {
-			return probs.Malformed("unsupported revocation reason code provided")
-		}
 		reason = *revokeRequest.Reason
 	}
 
@@ -628,25 +685,10 @@ func (wfe *WebFrontEndImpl) revokeCertByKeyID(
 	if prob != nil {
 		return prob
 	}
-	// For Key ID revocations we decide if an account is able to revoke a specific
-	// certificate by checking that the account has valid authorizations for all
-	// of the names in the certificate or was the issuing account
-	authorizedToRevoke := func(parsedCertificate *x509.Certificate) *probs.ProblemDetails {
-		cert, err := wfe.SA.GetCertificate(ctx, core.SerialToString(parsedCertificate.SerialNumber))
-		if err != nil {
-			return probs.ServerInternal("Failed to retrieve certificate")
-		}
-		if cert.RegistrationID == acct.ID {
-			return nil
-		}
-		valid, err := wfe.acctHoldsAuthorizations(ctx, acct.ID, parsedCertificate.DNSNames)
-		if err != nil {
-			return probs.ServerInternal("Failed to retrieve authorizations for names in certificate")
-		}
-		if !valid {
-			return probs.Unauthorized(
-				"The key ID specified in the revocation request does not hold valid authorizations for all names in the certificate to be revoked")
-		}
+	// For Key ID revocations whether the account may revoke the certificate,
+	// because it was the issuing account or holds valid authorizations for
+	// all of the names in the certificate, is decided by the RA.
+	authorizedToRevoke := func(*x509.Certificate) *probs.ProblemDetails {
 		return nil
 	}
 	return wfe.processRevocation(ctx, jwsBody, acct.ID, authorizedToRevoke, request, logEvent)
@@ -668,7 +710,7 @@ func (wfe *WebFrontEndImpl) revokeCertByJWK(
 	// `validSelfAuthenticatedJWS` similar to new-reg and key rollover.
 	// We do *not* use `validSelfAuthenticatedPOST` here because we've already
 	// read the HTTP request body in `parseJWSRequest` and it is now empty.
-	jwsBody, jwk, prob := wfe.validSelfAuthenticatedJWS(outerJWS, request, logEvent)
+	jwsBody, jwk, prob := wfe.validSelfAuthenticatedJWS(ctx, outerJWS, request, logEvent)
 	if prob != nil {
 		return prob
 	}
@@ -989,6 +1031,19 @@ func (wfe *WebFrontEndImpl) Account(
 		wfe.sendError(response, logEvent, probs.Malformed("Error unmarshaling account"), err)
 		return
 	}
+	// Accounts agree to the current subscriber agreement, e.g. after it has
+	// changed, the same way as when they're created.
+	var agreement struct {
+		TermsOfServiceAgreed bool `json:"termsOfServiceAgreed"`
+	}
+	err = json.Unmarshal(body, &agreement)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.Malformed("Error unmarshaling account"), err)
+		return
+	}
+	if agreement.TermsOfServiceAgreed {
+		update.Agreement = wfe.SubscriberAgreementURL
+	}
 
 	// People *will* POST their full accounts to this endpoint, including
 	// the 'valid' status, to avoid always failing out when that happens only
@@ -1031,11 +1086,13 @@ func (wfe *WebFrontEndImpl) Account(
 	update.Key = currAcct.Key
 
 	updatedAcct, err := wfe.RA.UpdateRegistration(ctx, *currAcct, update)
+	wfe.AccountCache.Invalidate(currAcct.ID)
 	if err != nil {
 		wfe.sendError(response, logEvent,
 			web.ProblemDetailsForError(err, "Unable to update account"), err)
 		return
 	}
+	wfe.ContactVerifier.QueueVerifications(updatedAcct, currAcct.Contact)
 
 	if len(wfe.SubscriberAgreementURL) > 0 {
 		response.Header().Add("Link", link(wfe.SubscriberAgreementURL, "terms-of-service"))
@@ -1137,6 +1194,17 @@ var allHex = regexp.MustCompile("^[0-9a-f]+$")
 func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
 
 	serial := request.URL.Path
+	// A path of serial/N requests alternate chain N.
+	alternate := 0
+	if i := strings.Index(serial, "/"); i != -1 {
+		n, err := strconv.Atoi(serial[i+1:])
+		if err != nil || n < 1 {
+			logEvent.AddError("certificate chain requested was not valid: %s", serial)
+			wfe.sendError(response, logEvent, probs.NotFound("Certificate not found"), nil)
+			return
+		}
+		serial, alternate = serial[:i], n
+	}
 	// Certificate paths consist of the CertBase path, plus exactly sixteen hex
 	// digits.
 	if !core.ValidSerial(serial) {
@@ -1184,10 +1252,8 @@ func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.Reque
 		// the CA, but should be. See
 		//  https://github.com/letsencrypt/boulder/issues/3374
 		aiaIssuerURL := parsedCert.IssuingCertificateURL[0]
-		if chain, ok := wfe.certificateChains[aiaIssuerURL]; ok {
-			// Prepend the chain with the leaf certificate
-			responsePEM = append(leafPEM, chain...)
-		} else {
+		chain, ok := wfe.certificateChains[aiaIssuerURL]
+		if !ok {
 			// If there is no wfe.certificateChains entry for the AIA Issuer URL there
 			// is probably a misconfiguration and we should treat it as an internal
 			// server error.
@@ -1200,6 +1266,35 @@ func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.Reque
 			), nil)
 			return
 		}
+
+		alternates := wfe.AlternateCertificateChains[aiaIssuerURL]
+		if alternate > len(alternates) {
+			logEvent.AddError("certificate serial %#v has no alternate chain %d", serial, alternate)
+			wfe.sendError(response, logEvent, probs.NotFound("Certificate not found"), nil)
+			return
+		}
+		if alternate > 0 {
+			chain = alternates[alternate-1]
+		}
+		// Link to each of the chains not being served
+		certURL := web.RelativeEndpoint(request, certPath+serial)
+		for i := 0; i <= len(alternates); i++ {
+			if i == alternate {
+				continue
+			}
+			chainURL := certURL
+			if i > 0 {
+				chainURL = fmt.Sprintf("%s/%d", certURL, i)
+			}
+			response.Header().Add("Link", link(chainURL, "alternate"))
+		}
+
+		// Prepend the chain with the leaf certificate
+		responsePEM = append(leafPEM, chain...)
+	} else if alternate > 0 {
+		logEvent.AddError("certificate serial %#v has no alternate chain %d", serial, alternate)
+		wfe.sendError(response, logEvent, probs.NotFound("Certificate not found"), nil)
+		return
 	} else {
 		// Otherwise, with no configured certificateChains just serve the leaf
 		// certificate.
@@ -1221,6 +1316,107 @@ func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.Reque
 	return
 }
 
+// sctJSON is the representation of an SCT served by the SCTs endpoint. Its
+// fields are named as in the response to an RFC 6962 add-chain request, but
+// signature holds only the base64 of the log's signature, without the hash
+// and signature algorithms.
+type sctJSON struct {
+	SCTVersion uint8  `json:"sct_version"`
+	ID         string `json:"id"`
+	Timestamp  uint64 `json:"timestamp"`
+	Extensions string `json:"extensions"`
+	Signature  string `json:"signature"`
+}
+
+// SCTs is used by a client to request the SCTs that CT logs have returned for
+// one of its certificates, so that they can be delivered in the TLS handshake
+// or stapled to OCSP responses.
+func (wfe *WebFrontEndImpl) SCTs(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
+	serial := request.URL.Path
+	if !core.ValidSerial(serial) {
+		logEvent.AddError("certificate serial provided was not valid: %s", serial)
+		wfe.sendError(response, logEvent, probs.NotFound("Certificate not found"), nil)
+		return
+	}
+	logEvent.Extra["RequestedSerial"] = serial
+
+	_, err := wfe.SA.GetCertificate(ctx, serial)
+	if err != nil {
+		logEvent.AddError("unable to get certificate by serial id %#v: %s", serial, err)
+		wfe.sendError(response, logEvent, probs.NotFound("Certificate not found"), err)
+		return
+	}
+
+	receipts, err := wfe.SA.GetSCTReceipts(ctx, serial)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Unable to get SCTs"), err)
+		return
+	}
+	scts := make([]sctJSON, len(receipts))
+	for i, r := range receipts {
+		scts[i] = sctJSON{
+			SCTVersion: r.SCTVersion,
+			ID:         r.LogID,
+			Timestamp:  r.Timestamp,
+			Extensions: base64.StdEncoding.EncodeToString(r.Extensions),
+			Signature:  base64.StdEncoding.EncodeToString(r.Signature),
+		}
+	}
+
+	response.Header().Add("Link", link(web.RelativeEndpoint(request, certPath+serial), "up"))
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, scts)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to marshal SCTs"), err)
+	}
+}
+
+// VerifyContact handles the links sent by the ContactVerifier. A GET, which
+// mail clients and scanners may make without the subscriber's involvement,
+// only shows a form asking for confirmation. Submitting the form POSTs the
+// token back, which records the email address it names as verified for its
+// account.
+func (wfe *WebFrontEndImpl) VerifyContact(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
+	token := request.URL.Path
+	if request.Method != "POST" {
+		regID, email, err := wfe.ContactVerifier.Check(token)
+		if err != nil {
+			wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Unable to verify contact"), err)
+			return
+		}
+		logEvent.Requester = regID
+
+		response.Header().Set("Content-Type", "text/html")
+		response.WriteHeader(http.StatusOK)
+		_, err = fmt.Fprintf(response, `<html>
+		<body>
+			<form method="POST">
+				Confirm that you want %s to receive notices about the certificates of account %d.
+				<input type="submit" value="Confirm">
+			</form>
+		</body>
+	</html>
+	`, html.EscapeString(email), regID)
+		if err != nil {
+			wfe.log.Warning(fmt.Sprintf("Could not write response: %s", err))
+		}
+		return
+	}
+
+	regID, email, err := wfe.ContactVerifier.Verify(ctx, token)
+	if err != nil {
+		wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Unable to verify contact"), err)
+		return
+	}
+	logEvent.Requester = regID
+	logEvent.Contacts = &[]string{"mailto:" + email}
+
+	response.Header().Set("Content-Type", "text/plain")
+	response.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(response, "Verified %s as a contact of account %d\n", email, regID); err != nil {
+		wfe.log.Warning(fmt.Sprintf("Could not write response: %s", err))
+	}
+}
+
 // Issuer obtains the issuer certificate used by this instance of Boulder.
 func (wfe *WebFrontEndImpl) Issuer(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
 	// TODO Content negotiation
@@ -1328,7 +1524,7 @@ func (wfe *WebFrontEndImpl) KeyRollover(
 	}
 
 	// Validate the inner JWS as a key rollover request for the outer JWS
-	rolloverRequest, prob := wfe.validKeyRollover(outerJWS, innerJWS, logEvent)
+	rolloverRequest, prob := wfe.validKeyRollover(ctx, outerJWS, innerJWS, logEvent)
 	if prob != nil {
 		wfe.sendError(response, logEvent, prob, nil)
 		return
@@ -1378,6 +1574,7 @@ func (wfe *WebFrontEndImpl) KeyRollover(
 
 	// Update the account key to the new key
 	updatedAcct, err := wfe.RA.UpdateRegistration(ctx, *acct, core.Registration{Key: &newKey})
+	wfe.AccountCache.Invalidate(acct.ID)
 	if err != nil {
 		wfe.sendError(response, logEvent,
 			web.ProblemDetailsForError(err, "Unable to update account with new key"), err)
@@ -1397,6 +1594,7 @@ func (wfe *WebFrontEndImpl) deactivateAccount(
 	request *http.Request,
 	logEvent *web.RequestEvent) {
 	err := wfe.RA.DeactivateRegistration(ctx, acct)
+	wfe.AccountCache.Invalidate(acct.ID)
 	if err != nil {
 		wfe.sendError(response, logEvent,
 			web.ProblemDetailsForError(err, "Error deactivating account"), err)
@@ -1475,7 +1673,41 @@ func (wfe *WebFrontEndImpl) NewOrder(
 		wfe.sendError(response, logEvent, prob, nil)
 		return
 	}
+	if prob := wfe.checkAgreement(response, acct); prob != nil {
+		wfe.sendError(response, logEvent, prob, nil)
+		return
+	}
 
+	names, prob := parseNewOrderRequest(body)
+	if prob != nil {
+		wfe.sendError(response, logEvent, prob, nil)
+		return
+	}
+
+	order, err := wfe.RA.NewOrder(ctx, &rapb.NewOrderRequest{
+		RegistrationID: &acct.ID,
+		Names:          names,
+	})
+	if err != nil {
+		wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Error creating new order"), err)
+		return
+	}
+
+	orderURL := web.RelativeEndpoint(request,
+		fmt.Sprintf("%s%d/%d", orderPath, acct.ID, *order.Id))
+	response.Header().Set("Location", orderURL)
+
+	respObj := wfe.orderToOrderJSON(request, order)
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusCreated, respObj)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Error marshaling order"), err)
+		return
+	}
+}
+
+// parseNewOrderRequest returns the DNS names requested by the body of a
+// new-order or check-order request.
+func parseNewOrderRequest(body []byte) ([]string, *probs.ProblemDetails) {
 	// We only allow specifying Identifiers in a new order request - if the
 	// `notBefore` and/or `notAfter` fields described in Section 7.4 of acme-08
 	// are sent we return a probs.Malformed as we do not support them
@@ -1485,19 +1717,14 @@ func (wfe *WebFrontEndImpl) NewOrder(
 	}
 	err := json.Unmarshal(body, &newOrderRequest)
 	if err != nil {
-		wfe.sendError(response, logEvent,
-			probs.Malformed("Unable to unmarshal NewOrder request body"), err)
-		return
+		return nil, probs.Malformed("Unable to unmarshal NewOrder request body")
 	}
 
 	if len(newOrderRequest.Identifiers) == 0 {
-		wfe.sendError(response, logEvent,
-			probs.Malformed("NewOrder request did not specify any identifiers"), nil)
-		return
+		return nil, probs.Malformed("NewOrder request did not specify any identifiers")
 	}
 	if newOrderRequest.NotBefore != "" || newOrderRequest.NotAfter != "" {
-		wfe.sendError(response, logEvent, probs.Malformed("NotBefore and NotAfter are not supported"), nil)
-		return
+		return nil, probs.Malformed("NotBefore and NotAfter are not supported")
 	}
 
 	// Collect up all of the DNS identifier values into a []string for subsequent
@@ -1505,34 +1732,52 @@ func (wfe *WebFrontEndImpl) NewOrder(
 	names := make([]string, len(newOrderRequest.Identifiers))
 	for i, ident := range newOrderRequest.Identifiers {
 		if ident.Type != core.IdentifierDNS {
-			wfe.sendError(response, logEvent,
-				probs.Malformed("NewOrder request included invalid non-DNS type identifier: type %q, value %q",
-					ident.Type, ident.Value),
-				nil)
-			return
+			return nil, probs.Malformed("NewOrder request included invalid non-DNS type identifier: type %q, value %q",
+				ident.Type, ident.Value)
 		}
 		names[i] = ident.Value
 	}
+	return names, nil
+}
 
-	order, err := wfe.RA.NewOrder(ctx, &rapb.NewOrderRequest{
-		RegistrationID: &acct.ID,
-		Names:          names,
-	})
-	if err != nil {
-		wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Error creating new order"), err)
+// CheckOrder checks a new-order request body against the issuance policy and
+// rate limits without creating an order, so that clients can find out whether
+// an order would be refused before using up any of their rate limits. It
+// responds with no content if the order would be allowed and with the problem
+// that new-order would return, so far as it can be known in advance, if not.
+// It is not part of the ACME spec.
+func (wfe *WebFrontEndImpl) CheckOrder(
+	ctx context.Context,
+	logEvent *web.RequestEvent,
+	response http.ResponseWriter,
+	request *http.Request) {
+	body, _, acct, prob := wfe.validPOSTForAccount(request, ctx, logEvent)
+	addRequesterHeader(response, logEvent.Requester)
+	if prob != nil {
+		// validPOSTForAccount handles its own setting of logEvent.Errors
+		wfe.sendError(response, logEvent, prob, nil)
+		return
+	}
+	if prob := wfe.checkAgreement(response, acct); prob != nil {
+		wfe.sendError(response, logEvent, prob, nil)
 		return
 	}
 
-	orderURL := web.RelativeEndpoint(request,
-		fmt.Sprintf("%s%d/%d", orderPath, acct.ID, *order.Id))
-	response.Header().Set("Location", orderURL)
+	names, prob := parseNewOrderRequest(body)
+	if prob != nil {
+		wfe.sendError(response, logEvent, prob, nil)
+		return
+	}
 
-	respObj := wfe.orderToOrderJSON(request, order)
-	err = wfe.writeJsonResponse(response, logEvent, http.StatusCreated, respObj)
+	err := wfe.RA.CheckNewOrder(ctx, &rapb.NewOrderRequest{
+		RegistrationID: &acct.ID,
+		Names:          names,
+	})
 	if err != nil {
-		wfe.sendError(response, logEvent, probs.ServerInternal("Error marshaling order"), err)
+		wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Error checking new order"), err)
 		return
 	}
+	response.WriteHeader(http.StatusNoContent)
 }
 
 // GetOrder is used to retrieve a existing order object
@@ -1569,6 +1814,16 @@ func (wfe *WebFrontEndImpl) GetOrder(ctx context.Context, logEvent *web.RequestE
 		return
 	}
 
+	if allowed, wait := wfe.OrderPollLimiter.Allow(acctID, orderID); !allowed {
+		retryAfter := int((wait + time.Second - 1) / time.Second)
+		response.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		wfe.sendError(response, logEvent, probs.RateLimited(fmt.Sprintf(
+			"Order %d polled too frequently, retry after %d seconds", orderID, retryAfter)), nil)
+		return
+	}
+	wfe.OrderPollLimiter.Observe(acctID, orderID, core.AcmeStatus(*order.Status))
+
+	setOrderRetryAfter(response, order)
 	respObj := wfe.orderToOrderJSON(request, order)
 	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, respObj)
 	if err != nil {
@@ -1577,6 +1832,18 @@ func (wfe *WebFrontEndImpl) GetOrder(ctx context.Context, logEvent *web.RequestE
 	}
 }
 
+// orderRetryAfter is the number of seconds clients are asked to wait before
+// polling an order that is still processing.
+const orderRetryAfter = 3
+
+// setOrderRetryAfter sets a Retry-After header for orders in processing status,
+// which are being finalized asynchronously.
+func setOrderRetryAfter(response http.ResponseWriter, order *corepb.Order) {
+	if order.Status != nil && *order.Status == string(core.StatusProcessing) {
+		response.Header().Set("Retry-After", strconv.Itoa(orderRetryAfter))
+	}
+}
+
 // FinalizeOrder is used to request issuance for a existing order object.
 // Most processing of the order details is handled by the RA but
 // we do attempt to throw away requests with invalid CSRs here.
@@ -1589,6 +1856,10 @@ func (wfe *WebFrontEndImpl) FinalizeOrder(ctx context.Context, logEvent *web.Req
 		wfe.sendError(response, logEvent, prob, nil)
 		return
 	}
+	if prob := wfe.checkAgreement(response, acct); prob != nil {
+		wfe.sendError(response, logEvent, prob, nil)
+		return
+	}
 
 	// Order URLs are like: /acme/finalize/<account>/<order>/. The prefix is
 	// stripped by the time we get here.
@@ -1684,6 +1955,7 @@ func (wfe *WebFrontEndImpl) FinalizeOrder(ctx context.Context, logEvent *web.Req
 	orderURL := web.RelativeEndpoint(request,
 		fmt.Sprintf("%s%d/%d", orderPath, acct.ID, *updatedOrder.Id))
 	response.Header().Set("Location", orderURL)
+	setOrderRetryAfter(response, updatedOrder)
 
 	respObj := wfe.orderToOrderJSON(request, updatedOrder)
 	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, respObj)
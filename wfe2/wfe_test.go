@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -19,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jmhodges/clock"
 	"golang.org/x/net/context"
@@ -27,6 +29,7 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
@@ -205,6 +208,9 @@ EeMZ9nWyIM6bktLrE11HnFOnKhAYsM5fZA==
 
 type MockRegistrationAuthority struct {
 	lastRevocationReason revocation.Reason
+	lastRevocationRegID  int64
+	// revocationErr, if set, is returned by RevokeCertificateWithReg
+	revocationErr error
 }
 
 func (ra *MockRegistrationAuthority) NewRegistration(ctx context.Context, acct core.Registration) (core.Registration, error) {
@@ -234,7 +240,11 @@ func (ra *MockRegistrationAuthority) UpdateAuthorization(ctx context.Context, au
 }
 
 func (ra *MockRegistrationAuthority) RevokeCertificateWithReg(ctx context.Context, cert x509.Certificate, reason revocation.Reason, reg int64) error {
+	if ra.revocationErr != nil {
+		return ra.revocationErr
+	}
 	ra.lastRevocationReason = reason
+	ra.lastRevocationRegID = reg
 	return nil
 }
 
@@ -268,6 +278,15 @@ func (ra *MockRegistrationAuthority) NewOrder(ctx context.Context, req *rapb.New
 	}, nil
 }
 
+func (ra *MockRegistrationAuthority) CheckNewOrder(ctx context.Context, req *rapb.NewOrderRequest) error {
+	for _, name := range req.Names {
+		if name == "ratelimited.com" {
+			return berrors.RateLimitError("too many certificates already issued for: %s", name)
+		}
+	}
+	return nil
+}
+
 func (ra *MockRegistrationAuthority) FinalizeOrder(ctx context.Context, req *rapb.FinalizeOrderRequest) (*corepb.Order, error) {
 	statusProcessing := string(core.StatusProcessing)
 	req.Order.Status = &statusProcessing
@@ -280,11 +299,11 @@ func (pa *mockPA) ChallengesFor(identifier core.AcmeIdentifier) (challenges []co
 	return
 }
 
-func (pa *mockPA) WillingToIssue(id core.AcmeIdentifier) error {
+func (pa *mockPA) WillingToIssue(ctx context.Context, id core.AcmeIdentifier, registrationID int64) error {
 	return nil
 }
 
-func (pa *mockPA) WillingToIssueWildcard(id core.AcmeIdentifier) error {
+func (pa *mockPA) WillingToIssueWildcard(ctx context.Context, id core.AcmeIdentifier, registrationID int64) error {
 	return nil
 }
 
@@ -1470,6 +1489,124 @@ func TestIssuer(t *testing.T) {
 	test.Assert(t, bytes.Compare(responseWriter.Body.Bytes(), wfe.IssuerCert) == 0, "Incorrect bytes returned")
 }
 
+// TestStaticAssets only checks that the static path is routed to the
+// configured assets, serving them is tested in the web package.
+func TestStaticAssets(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	get := func() *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		wfe.Handler().ServeHTTP(responseWriter, &http.Request{
+			Method: "GET",
+			URL:    mustParseURL("/static/terms.pdf"),
+		})
+		return responseWriter
+	}
+
+	// Without any configured assets the static path isn't served
+	test.AssertEquals(t, get().Code, http.StatusNotFound)
+
+	wfe.StaticAssets = web.StaticAssets{"terms.pdf": &web.StaticAsset{Content: []byte("terms"), ETag: `"terms"`}}
+	responseWriter := get()
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Body.String(), "terms")
+}
+
+func TestVerifyContact(t *testing.T) {
+	wfe, _ := setupWFE(t)
+
+	// Without a ContactVerifier the path isn't served
+	responseWriter := httptest.NewRecorder()
+	wfe.Handler().ServeHTTP(responseWriter, &http.Request{
+		Method: "GET",
+		URL:    mustParseURL(verifyContactPath + "token"),
+	})
+	test.AssertEquals(t, responseWriter.Code, http.StatusNotFound)
+
+	cv, sa, _, fc := newTestContactVerifier(t)
+	wfe.ContactVerifier = cv
+	mux := wfe.Handler()
+
+	// Following the link only asks for confirmation
+	token := cv.token(1, "<one>@example.com", fc.Now().Add(time.Hour))
+	responseWriter = httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, &http.Request{
+		Method: "GET",
+		URL:    mustParseURL(verifyContactPath + token),
+	})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertContains(t, responseWriter.Body.String(), `<form method="POST">`)
+	test.AssertContains(t, responseWriter.Body.String(), "&lt;one&gt;@example.com")
+	test.AssertEquals(t, len(sa.verified), 0)
+
+	// Confirming records the contact as verified
+	responseWriter = httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, &http.Request{
+		Method: "POST",
+		URL:    mustParseURL(verifyContactPath + token),
+	})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Body.String(), "Verified <one>@example.com as a contact of account 1\n")
+	test.AssertDeepEquals(t, sa.verified, map[int64][]string{1: {"<one>@example.com"}})
+
+	for _, method := range []string{"GET", "POST"} {
+		responseWriter = httptest.NewRecorder()
+		mux.ServeHTTP(responseWriter, &http.Request{
+			Method: method,
+			URL:    mustParseURL(verifyContactPath + cv.token(1, "one@example.com", fc.Now())),
+		})
+		test.AssertEquals(t, responseWriter.Code, http.StatusForbidden)
+		test.AssertUnmarshaledEquals(t, responseWriter.Body.String(),
+			`{"type":"`+probs.V2ErrorNS+`unauthorized","detail":"Unable to verify contact :: contact verification link is invalid or has expired","status":403}`)
+	}
+}
+
+func TestSCTs(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	mux := wfe.Handler()
+
+	responseWriter := httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, &http.Request{
+		Method: "GET",
+		URL:    mustParseURL("/acme/scts/0000000000000000000000000000000000b2"),
+	})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Header().Get("Content-Type"), "application/json")
+	test.AssertEquals(t, responseWriter.Header().Get("Link"),
+		`<http://localhost/acme/cert/0000000000000000000000000000000000b2>;rel="up"`)
+	var scts []sctJSON
+	err := json.Unmarshal(responseWriter.Body.Bytes(), &scts)
+	test.AssertNotError(t, err, "Failed to unmarshal SCTs")
+	test.AssertDeepEquals(t, scts, []sctJSON{{
+		SCTVersion: 0,
+		ID:         "3Zk0/KXnJIDJVmh9gTSZCEmySfe1adjHvKs/XMHzbmQ=",
+		Timestamp:  1500000000000,
+		Extensions: "",
+		Signature:  "AQID",
+	}})
+
+	// A certificate without SCTs has an empty list
+	responseWriter = httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, &http.Request{
+		Method: "GET",
+		URL:    mustParseURL("/acme/scts/0000000000000000000000000000000000ee"),
+	})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Body.String(), "[]")
+
+	// As does an unknown certificate
+	for _, path := range []string{
+		"/acme/scts/0000000000000000000000000000000000ff",
+		"/acme/scts/nothex",
+	} {
+		responseWriter = httptest.NewRecorder()
+		mux.ServeHTTP(responseWriter, &http.Request{
+			Method: "GET",
+			URL:    mustParseURL(path),
+		})
+		test.AssertEquals(t, responseWriter.Code, http.StatusNotFound)
+	}
+}
+
 func TestGetCertificate(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	mux := wfe.Handler()
@@ -1571,6 +1708,48 @@ func TestGetCertificate(t *testing.T) {
 	}
 }
 
+func TestGetCertificateAlternateChains(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	rootPemBytes, err := ioutil.ReadFile("../test/test-root.pem")
+	test.AssertNotError(t, err, "Error reading ../test/test-root.pem")
+	alternate := []byte(fmt.Sprintf("\n%s", string(rootPemBytes)))
+	wfe.AlternateCertificateChains = map[string][][]byte{
+		"http://localhost:4000/acme/issuer-cert": {alternate},
+	}
+	mux := wfe.Handler()
+	certPemBytes, _ := ioutil.ReadFile("test/178.crt")
+	chainPemBytes, _ := ioutil.ReadFile("../test/test-ca2.pem")
+	serial := "0000000000000000000000000000000000b2"
+
+	get := func(path string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		mux.ServeHTTP(responseWriter, req)
+		return responseWriter
+	}
+
+	// The default chain links to the alternate
+	resp := get("/acme/cert/" + serial)
+	test.AssertEquals(t, resp.Code, http.StatusOK)
+	test.AssertDeepEquals(t, resp.Body.Bytes(), append(certPemBytes, append([]byte("\n"), chainPemBytes...)...))
+	links := resp.Header()["Link"]
+	test.AssertEquals(t, len(links), 1)
+	test.AssertContains(t, links[0], "/acme/cert/"+serial+`/1>;rel="alternate"`)
+
+	// The alternate chain links back to the default
+	resp = get("/acme/cert/" + serial + "/1")
+	test.AssertEquals(t, resp.Code, http.StatusOK)
+	test.AssertDeepEquals(t, resp.Body.Bytes(), append(certPemBytes, alternate...))
+	links = resp.Header()["Link"]
+	test.AssertEquals(t, len(links), 1)
+	test.AssertContains(t, links[0], "/acme/cert/"+serial+`>;rel="alternate"`)
+
+	for _, path := range []string{serial + "/2", serial + "/0", serial + "/x", serial + "/1/1"} {
+		resp = get("/acme/cert/" + path)
+		test.AssertEquals(t, resp.Code, http.StatusNotFound)
+	}
+}
+
 // This uses httptest.NewServer because ServeMux.ServeHTTP won't prevent the
 // body from being sent like the net/http Server's actually do.
 func TestGetCertificateHEADHasCorrectBodyLength(t *testing.T) {
@@ -1762,6 +1941,61 @@ func TestDeactivateAccount(t *testing.T) {
 		}`)
 }
 
+// agreementRecordingRA records the update given to UpdateRegistration.
+type agreementRecordingRA struct {
+	MockRegistrationAuthority
+	update core.Registration
+}
+
+func (ra *agreementRecordingRA) UpdateRegistration(ctx context.Context, acct core.Registration, update core.Registration) (core.Registration, error) {
+	ra.update = update
+	acct.Agreement = update.Agreement
+	return acct, nil
+}
+
+func TestRequireCurrentAgreement(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	ra := &agreementRecordingRA{}
+	wfe.RA = ra
+	newAgreementURL := "http://example.invalid/terms-v2"
+	wfe.SubscriberAgreementURL = newAgreementURL
+	wfe.RequireCurrentAgreement = true
+
+	// Account 1 agreed to the previous agreement, so can't create orders
+	orderBody := `{"Identifiers": [{"type": "dns", "value": "not-example.com"}]}`
+	responseWriter := httptest.NewRecorder()
+	wfe.NewOrder(ctx, newRequestEvent(), responseWriter,
+		signAndPost(t, "new-order", "http://localhost/new-order", orderBody, 1, wfe.nonceService))
+	test.AssertEquals(t, responseWriter.Code, http.StatusForbidden)
+	test.AssertEquals(t, responseWriter.Header().Get("Link"), link(newAgreementURL, "terms-of-service"))
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{
+		"type": "`+probs.V2ErrorNS+`userActionRequired",
+		"detail": "The subscriber agreement has changed. Review the new agreement and update the account with termsOfServiceAgreed set to true to agree to it",
+		"status": 403,
+		"instance": "`+newAgreementURL+`"
+	}`)
+
+	// Nor finalize them
+	responseWriter = httptest.NewRecorder()
+	wfe.FinalizeOrder(ctx, newRequestEvent(), responseWriter,
+		signAndPost(t, "1/1", "http://localhost/1/1", "{}", 1, wfe.nonceService))
+	test.AssertEquals(t, responseWriter.Code, http.StatusForbidden)
+
+	// Agreeing to the terms of service updates the account's agreement
+	_, _, body := signRequestKeyID(t, 1, nil, "http://localhost/1", `{"termsOfServiceAgreed":true}`, wfe.nonceService)
+	responseWriter = httptest.NewRecorder()
+	wfe.Account(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("1", body))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, ra.update.Agreement, newAgreementURL)
+
+	// Without RequireCurrentAgreement the account can create orders
+	wfe.RequireCurrentAgreement = false
+	responseWriter = httptest.NewRecorder()
+	wfe.NewOrder(ctx, newRequestEvent(), responseWriter,
+		signAndPost(t, "new-order", "http://localhost/new-order", orderBody, 1, wfe.nonceService))
+	test.AssertEquals(t, responseWriter.Code, http.StatusCreated)
+}
+
 func TestNewOrder(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	responseWriter := httptest.NewRecorder()
@@ -1864,6 +2098,66 @@ func TestNewOrder(t *testing.T) {
 	}
 }
 
+func TestCheckOrder(t *testing.T) {
+	_ = features.Set(map[string]bool{"CheckOrderEndpoint": true})
+	defer features.Reset()
+	wfe, _ := setupWFE(t)
+	mux := wfe.Handler()
+
+	// The endpoint is advertised in the directory
+	responseWriter := httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, &http.Request{
+		Method: "GET",
+		URL:    mustParseURL(directoryPath),
+		Host:   "localhost:4300",
+	})
+	var directory map[string]interface{}
+	err := json.Unmarshal(responseWriter.Body.Bytes(), &directory)
+	test.AssertNotError(t, err, "Failed to unmarshal directory")
+	test.AssertEquals(t, directory["checkOrder"], "http://localhost:4300/acme/check-order")
+
+	targetPath := "check-order"
+	signedURL := "http://localhost/" + targetPath
+
+	testCases := []struct {
+		Name         string
+		Body         string
+		ExpectedCode int
+		ExpectedBody string
+	}{
+		{
+			Name:         "No identifiers",
+			Body:         "{}",
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NewOrder request did not specify any identifiers","status":400}`,
+		},
+		{
+			Name:         "Rate limited",
+			Body:         `{"identifiers":[{"type":"dns","value":"example.com"},{"type":"dns","value":"ratelimited.com"}]}`,
+			ExpectedCode: http.StatusTooManyRequests,
+			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `rateLimited","detail":"Error checking new order :: too many certificates already issued for: ratelimited.com: see https://letsencrypt.org/docs/rate-limits/","status":429}`,
+		},
+		{
+			Name:         "Allowed",
+			Body:         `{"identifiers":[{"type":"dns","value":"example.com"}]}`,
+			ExpectedCode: http.StatusNoContent,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			responseWriter := httptest.NewRecorder()
+			wfe.CheckOrder(ctx, newRequestEvent(), responseWriter,
+				signAndPost(t, targetPath, signedURL, tc.Body, 1, wfe.nonceService))
+			test.AssertEquals(t, responseWriter.Code, tc.ExpectedCode)
+			if tc.ExpectedBody != "" {
+				test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), tc.ExpectedBody)
+			} else {
+				test.AssertEquals(t, responseWriter.Body.Len(), 0)
+			}
+		})
+	}
+}
+
 func TestFinalizeOrder(t *testing.T) {
 	wfe, _ := setupWFE(t)
 	responseWriter := httptest.NewRecorder()
@@ -2168,6 +2462,31 @@ func TestOrder(t *testing.T) {
 	}
 }
 
+func TestOrderPollLimit(t *testing.T) {
+	wfe, fc := setupWFE(t)
+	wfe.OrderPollLimiter = NewOrderPollLimiter(fc, 1, time.Minute, 10, metrics.NewNoopScope())
+
+	// Polls naming an account that doesn't own the order don't count against
+	// the owner's limit
+	for i := 0; i < 2; i++ {
+		responseWriter := httptest.NewRecorder()
+		wfe.GetOrder(ctx, newRequestEvent(), responseWriter, &http.Request{URL: &url.URL{Path: "2/1"}, Method: "GET"})
+		test.AssertEquals(t, responseWriter.Code, http.StatusNotFound)
+	}
+
+	responseWriter := httptest.NewRecorder()
+	wfe.GetOrder(ctx, newRequestEvent(), responseWriter, &http.Request{URL: &url.URL{Path: "1/1"}, Method: "GET"})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+
+	fc.Add(30 * time.Second)
+	responseWriter = httptest.NewRecorder()
+	wfe.GetOrder(ctx, newRequestEvent(), responseWriter, &http.Request{URL: &url.URL{Path: "1/1"}, Method: "GET"})
+	test.AssertEquals(t, responseWriter.Code, http.StatusTooManyRequests)
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "30")
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(),
+		`{"type":"`+probs.V2ErrorNS+`rateLimited","detail":"Order 1 polled too frequently, retry after 30 seconds","status":429}`)
+}
+
 func makeRevokeRequestJSON(reason *revocation.Reason) ([]byte, error) {
 	certPemBytes, err := ioutil.ReadFile("test/238.crt")
 	if err != nil {
@@ -2247,6 +2566,7 @@ func TestRevokeCertificateReasons(t *testing.T) {
 		Name             string
 		Reason           *revocation.Reason
 		ExpectedHTTPCode int
+		RAError          error
 		ExpectedBody     string
 		ExpectedReason   *revocation.Reason
 	}{
@@ -2262,21 +2582,24 @@ func TestRevokeCertificateReasons(t *testing.T) {
 			ExpectedReason:   &reason0,
 		},
 		{
-			Name:             "Unsupported reason",
+			// Which reasons are allowed is decided by the RA
+			Name:             "Reason passed to the RA",
 			Reason:           &reason2,
-			ExpectedHTTPCode: http.StatusBadRequest,
-			ExpectedBody:     `{"type":"` + probs.V2ErrorNS + `malformed","detail":"unsupported revocation reason code provided","status":400}`,
+			ExpectedHTTPCode: http.StatusOK,
+			ExpectedReason:   &reason2,
 		},
 		{
-			Name:             "Non-existent reason",
+			Name:             "Reason rejected by the RA",
 			Reason:           &reason100,
+			RAError:          berrors.MalformedError("revocation reason \"\" is not allowed for this requester"),
 			ExpectedHTTPCode: http.StatusBadRequest,
-			ExpectedBody:     `{"type":"` + probs.V2ErrorNS + `malformed","detail":"unsupported revocation reason code provided","status":400}`,
+			ExpectedBody:     `{"type":"` + probs.V2ErrorNS + `malformed","detail":"Failed to revoke certificate :: revocation reason \"\" is not allowed for this requester","status":400}`,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
+			ra.revocationErr = tc.RAError
 			responseWriter = httptest.NewRecorder()
 			revokeRequestJSON, err := makeRevokeRequestJSON(tc.Reason)
 			test.AssertNotError(t, err, "Failed to make revokeRequestJSON")
@@ -2335,9 +2658,13 @@ func TestRevokeCertificateWithAuthorizations(t *testing.T) {
 	test.AssertEquals(t, responseWriter.Body.String(), "")
 }
 
-// A revocation request signed by an unauthorized key.
+// A revocation request signed by an unauthorized key. Whether the account
+// may revoke the certificate is decided by the RA.
 func TestRevokeCertificateWrongKey(t *testing.T) {
 	wfe, _ := setupWFE(t)
+	wfe.RA = &MockRegistrationAuthority{
+		revocationErr: berrors.UnauthorizedError("requester does not hold valid authorizations for these names in the certificate: bad.example.com"),
+	}
 	responseWriter := httptest.NewRecorder()
 	revokeRequestJSON, err := makeRevokeRequestJSON(nil)
 	test.AssertNotError(t, err, "Failed to make revokeRequestJSON")
@@ -2349,7 +2676,61 @@ func TestRevokeCertificateWrongKey(t *testing.T) {
 
 	test.AssertEquals(t, responseWriter.Code, 403)
 	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(),
-		`{"type":"`+probs.V2ErrorNS+`unauthorized","detail":"The key ID specified in the revocation request does not hold valid authorizations for all names in the certificate to be revoked","status":403}`)
+		`{"type":"`+probs.V2ErrorNS+`unauthorized","detail":"Failed to revoke certificate :: requester does not hold valid authorizations for these names in the certificate: bad.example.com","status":403}`)
+}
+
+// mockSAWildcardCert is a SA mock that stores a wildcard certificate issued
+// to account 1.
+type mockSAWildcardCert struct {
+	core.StorageGetter
+	der []byte
+}
+
+func (sa *mockSAWildcardCert) GetCertificate(_ context.Context, serial string) (core.Certificate, error) {
+	if serial != "000000000000000000000000000000000042" {
+		return core.Certificate{}, berrors.NotFoundError("No cert")
+	}
+	return core.Certificate{RegistrationID: 1, DER: sa.der}, nil
+}
+
+func (sa *mockSAWildcardCert) GetCertificateStatus(_ context.Context, serial string) (core.CertificateStatus, error) {
+	return core.CertificateStatus{Status: core.OCSPStatusGood}, nil
+}
+
+// A third party's revocation of a wildcard certificate is passed on to the RA,
+// which accepts authorizations for the base domain, rather than being
+// rejected for lacking an authorization for the wildcard name.
+func TestRevokeWildcardCertificateThirdParty(t *testing.T) {
+	wfe, fc := setupWFE(t)
+	ra := &MockRegistrationAuthority{}
+	wfe.RA = ra
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "Failed to generate key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0x42),
+		DNSNames:     []string{"*.example.com"},
+		NotBefore:    fc.Now(),
+		NotAfter:     fc.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "Failed to create certificate")
+	wfe.SA = &mockSAWildcardCert{mocks.NewStorageAuthority(fc), der}
+
+	revokeRequestJSON, err := json.Marshal(struct {
+		CertificateDER core.JSONBuffer `json:"certificate"`
+	}{der})
+	test.AssertNotError(t, err, "Failed to marshal request")
+
+	// Account 5 didn't issue the certificate and has no authorization for
+	// "*.example.com" itself
+	responseWriter := httptest.NewRecorder()
+	_, _, jwsBody := signRequestKeyID(t, 5, nil, "http://localhost/revoke-cert", string(revokeRequestJSON), wfe.nonceService)
+	wfe.RevokeCertificate(ctx, newRequestEvent(), responseWriter,
+		makePostRequestWithPath("revoke-cert", jwsBody))
+
+	test.AssertEquals(t, responseWriter.Code, 200)
+	test.AssertEquals(t, ra.lastRevocationRegID, int64(5))
 }
 
 // Valid revocation request for already-revoked cert
@@ -2516,3 +2897,15 @@ func TestPrepAuthzForDisplay(t *testing.T) {
 	// challenge
 	test.AssertEquals(t, chal.ProvidedKeyAuthorization, "")
 }
+
+func TestSetOrderRetryAfter(t *testing.T) {
+	processing := string(core.StatusProcessing)
+	responseWriter := httptest.NewRecorder()
+	setOrderRetryAfter(responseWriter, &corepb.Order{Status: &processing})
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "3")
+
+	valid := string(core.StatusValid)
+	responseWriter = httptest.NewRecorder()
+	setOrderRetryAfter(responseWriter, &corepb.Order{Status: &valid})
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "")
+}